@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kljama/netscan/internal/events"
+	"github.com/kljama/netscan/internal/influx"
+	"github.com/kljama/netscan/internal/state"
+	"github.com/rs/zerolog/log"
+)
+
+// annotator writes Grafana annotations to the main writer of every InfluxDB destination
+type annotator []*influx.Writer
+
+// newAnnotator returns the annotator of the destinations (empty without InfluxDB)
+func newAnnotator(destinations []*influxDestination) annotator {
+	a := make(annotator, 0, len(destinations))
+	for _, destination := range destinations {
+		a = append(a, destination.writer)
+	}
+	return a
+}
+
+// annotate writes one annotation to every destination; failures are logged and otherwise ignored
+func (a annotator) annotate(event, ip, title, text string) {
+	for _, w := range a {
+		if err := w.WriteAnnotation(event, ip, title, text); err != nil {
+			log.Warn().
+				Str("event", event).
+				Str("ip", ip).
+				Err(err).
+				Msg("Failed to write annotation")
+		}
+	}
+}
+
+// annotateEvent returns the annotation subscriber: devices going down and discovery sweeps that added devices
+func annotateEvent(a annotator) func(events.Event) {
+	return func(ev events.Event) {
+		switch payload := ev.Payload.(type) {
+		case state.StateEvent:
+			if payload.State != state.ReachabilityDown {
+				return
+			}
+			name := ev.Hostname
+			if name == "" {
+				name = ev.IP
+			}
+			a.annotate(influx.AnnotationDeviceDown, ev.IP, name+" down",
+				fmt.Sprintf("%s stopped answering after %d failed pings (was %s)", ev.IP, payload.Failures, payload.Previous))
+		case events.ScanSummary:
+			if payload.NewDevices == 0 {
+				return
+			}
+			a.annotate(influx.AnnotationNewDevices, "", fmt.Sprintf("%d new devices", payload.NewDevices),
+				fmt.Sprintf("Discovery of %s found %d devices, %d new, in %.0fs",
+					strings.Join(payload.Networks, ", "), payload.Found, payload.NewDevices, payload.DurationS))
+		}
+	}
+}
+
+// annotateRescan writes the annotation of a completed daily SNMP re-scan
+func (a annotator) annotateRescan(summary snmpRescanSummary) {
+	a.annotate(influx.AnnotationScanCompleted, "", "Daily SNMP re-scan completed",
+		fmt.Sprintf("%d devices queried, %d answered, %d re-enriched in %s",
+			summary.Devices, summary.Answered, summary.Changed, summary.Duration.Round(1e9)))
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/kljama/netscan/internal/config"
+)
+
+// apiToken is an authenticated API caller (token, basic-auth user or client certificate); callers with networks only see devices inside them
+type apiToken struct {
+	name     string
+	networks []*net.IPNet // Empty = all devices
+}
+
+// allows reports whether the token may see and manage the device ip
+// A nil token (API tokens not configured) allows every device
+func (t *apiToken) allows(ip string) bool {
+	if t == nil || len(t.networks) == 0 {
+		return true
+	}
+	parsed := net.ParseIP(ip)
+	for _, network := range t.networks {
+		if parsed != nil && network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// scoped reports whether the token is restricted to networks
+func (t *apiToken) scoped() bool {
+	return t != nil && len(t.networks) > 0
+}
+
+// apiAuthenticator identifies API callers by one kind of credential
+type apiAuthenticator interface {
+	// authenticate returns the caller, or nil and why its credential was rejected ("" = none of this kind sent)
+	authenticate(r *http.Request) (*apiToken, string)
+	// challenge is the WWW-Authenticate value of this scheme ("" = not an HTTP auth scheme)
+	challenge() string
+}
+
+// apiAuth runs the configured authenticators in order; the first one accepting the request identifies the caller
+type apiAuth struct {
+	authenticators []apiAuthenticator
+}
+
+// newAPIAuth combines authenticators; returns nil when there are none (API open)
+func newAPIAuth(authenticators ...apiAuthenticator) *apiAuth {
+	return (*apiAuth)(nil).with(authenticators...)
+}
+
+// with returns the auth extended by more authenticators; a nil auth (API open) is closed by the first one
+func (a *apiAuth) with(authenticators ...apiAuthenticator) *apiAuth {
+	if len(authenticators) == 0 {
+		return a
+	}
+	extended := &apiAuth{}
+	if a != nil {
+		extended.authenticators = append(extended.authenticators, a.authenticators...)
+	}
+	extended.authenticators = append(extended.authenticators, authenticators...)
+	return extended
+}
+
+// challenge is the WWW-Authenticate value of a 401, naming the schemes the API accepts
+func (a *apiAuth) challenge() string {
+	var schemes []string
+	for _, authenticator := range a.authenticators {
+		if scheme := authenticator.challenge(); scheme != "" {
+			schemes = append(schemes, scheme)
+		}
+	}
+	return strings.Join(schemes, ", ")
+}
+
+// authenticate identifies the request's caller; a non-zero status and message mean the request is rejected
+func (a *apiAuth) authenticate(r *http.Request) (*apiToken, int, string) {
+	var token *apiToken
+	rejected := ""
+	for _, authenticator := range a.authenticators {
+		var message string
+		if token, message = authenticator.authenticate(r); token != nil {
+			break
+		}
+		if rejected == "" {
+			rejected = message
+		}
+	}
+	if token == nil {
+		if rejected == "" {
+			rejected = "missing credentials"
+		}
+		return nil, http.StatusUnauthorized, rejected
+	}
+	if token.scoped() && !isScopedPath(r.URL.Path) {
+		return token, http.StatusForbidden, "endpoint not available to network-scoped tokens"
+	}
+	return token, 0, ""
+}
+
+// bearerAuth accepts api_tokens sent as "Authorization: Bearer <token>"
+// Tokens are kept as SHA-256 digests, so the map lookup does not compare secrets byte by byte
+type bearerAuth struct {
+	tokens map[[sha256.Size]byte]*apiToken
+}
+
+// newBearerAuth builds the token table from config
+func newBearerAuth(tokens []config.APITokenConfig) *bearerAuth {
+	auth := &bearerAuth{tokens: make(map[[sha256.Size]byte]*apiToken, len(tokens))}
+	for _, cfg := range tokens {
+		auth.tokens[sha256.Sum256([]byte(cfg.Token))] = newAPIToken(cfg.Name, cfg.Networks)
+	}
+	return auth
+}
+
+func (a *bearerAuth) authenticate(r *http.Request) (*apiToken, string) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return nil, ""
+	}
+	if token := a.tokens[sha256.Sum256([]byte(strings.TrimPrefix(auth, "Bearer ")))]; token != nil {
+		return token, ""
+	}
+	return nil, "invalid bearer token"
+}
+
+func (a *bearerAuth) challenge() string {
+	return "Bearer"
+}
+
+// basicAuth accepts api_users sent with HTTP basic auth
+// Credentials are keyed by the SHA-256 digest of "username:password", like bearer tokens
+type basicAuth struct {
+	users map[[sha256.Size]byte]*apiToken
+}
+
+// newBasicAuth builds the user table from config
+func newBasicAuth(users []config.APIUserConfig) *basicAuth {
+	auth := &basicAuth{users: make(map[[sha256.Size]byte]*apiToken, len(users))}
+	for _, cfg := range users {
+		auth.users[sha256.Sum256([]byte(cfg.Username+":"+cfg.Password))] = newAPIToken(cfg.Username, cfg.Networks)
+	}
+	return auth
+}
+
+func (a *basicAuth) authenticate(r *http.Request) (*apiToken, string) {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, ""
+	}
+	if token := a.users[sha256.Sum256([]byte(username+":"+password))]; token != nil {
+		return token, ""
+	}
+	return nil, "invalid username or password"
+}
+
+func (a *basicAuth) challenge() string {
+	return `Basic realm="netscan"`
+}
+
+// clientCertAuth accepts client certificates verified against health_tls_client_ca during the TLS handshake
+type clientCertAuth struct {
+	names map[string]*apiToken // api_client_certs keyed by certificate common name (nil = any verified certificate, unscoped)
+}
+
+// newClientCertAuth builds the certificate table from config
+func newClientCertAuth(certs []config.APIClientCertConfig) *clientCertAuth {
+	auth := &clientCertAuth{}
+	if len(certs) > 0 {
+		auth.names = make(map[string]*apiToken, len(certs))
+	}
+	for _, cfg := range certs {
+		auth.names[cfg.CommonName] = newAPIToken(cfg.Name, cfg.Networks)
+	}
+	return auth
+}
+
+func (a *clientCertAuth) authenticate(r *http.Request) (*apiToken, string) {
+	commonName, ok := clientCertName(r)
+	if !ok {
+		return nil, ""
+	}
+	if a.names == nil {
+		return &apiToken{name: commonName}, ""
+	}
+	if token := a.names[commonName]; token != nil {
+		return token, ""
+	}
+	return nil, "client certificate not authorized"
+}
+
+func (a *clientCertAuth) challenge() string {
+	return ""
+}
+
+// clientCertName returns the common name of the request's verified client certificate
+func clientCertName(r *http.Request) (string, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+	return r.TLS.VerifiedChains[0][0].Subject.CommonName, true
+}
+
+// newAPIToken parses the networks of a token, user or client certificate (validated at startup)
+func newAPIToken(name string, cidrs []string) *apiToken {
+	token := &apiToken{name: name}
+	for _, cidr := range cidrs {
+		if _, network, err := net.ParseCIDR(cidr); err == nil {
+			token.networks = append(token.networks, network)
+		}
+	}
+	return token
+}
+
+// isScopedPath reports whether an endpoint filters its devices by token scope
+// Every other API endpoint exposes the global estate and is reserved for unscoped tokens
+func isScopedPath(path string) bool {
+	return strings.HasPrefix(path, "/api/device/") || path == "/api/events" || path == "/api/events/stream" || path == "/api/rollups" || path == "/api/groups" || path == "/api/export" || path == "/api/probe"
+}
+
+// apiTokenKey is the request context key of the authenticated token
+type apiTokenKey struct{}
+
+// requestToken returns the authenticated token of a request (nil when API tokens are not configured)
+func requestToken(r *http.Request) *apiToken {
+	token, _ := r.Context().Value(apiTokenKey{}).(*apiToken)
+	return token
+}
+
+// withToken attaches an authenticated token to a request
+func withToken(r *http.Request, token *apiToken) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), apiTokenKey{}, token))
+}
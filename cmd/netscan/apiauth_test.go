@@ -0,0 +1,197 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// TestAPITokenAuth verifies token checks, network scoping and that health probes stay open
+func TestAPITokenAuth(t *testing.T) {
+	stateMgr := state.NewManager(100)
+	stateMgr.SetDownThreshold(1)
+	for _, ip := range []string{"10.1.0.5", "10.2.0.5"} {
+		stateMgr.Add(state.Device{IP: ip, LastSeen: time.Now()})
+		stateMgr.ReportPingSuccess(ip)
+		stateMgr.ReportPingFail(ip, 10, time.Minute)
+	}
+	stateMgr.EnableRollups(7, time.Local)
+	stateMgr.RecordPingCycle("10.1.0.5", 1, 0, 0, 0, 0, time.Now())
+	stateMgr.RecordPingCycle("10.2.0.5", 1, 0, 0, 0, 0, time.Now())
+
+	hs := &HealthServer{stateMgr: stateMgr}
+	hs.SetAPITokens([]config.APITokenConfig{
+		{Name: "admin", Token: "admin-token-0123456789"},
+		{Name: "branch", Token: "branch-token-0123456789", Networks: []string{"10.1.0.0/16"}},
+	}, nil)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health/live", hs.livenessHandler)
+	mux.HandleFunc("GET /api/events", hs.eventsHandler)
+	mux.HandleFunc("GET /api/history", hs.historyHandler)
+	mux.HandleFunc("GET /api/device/{ip}/rollups", hs.deviceRollupsHandler)
+	mux.HandleFunc("GET /api/rollups", hs.rollupsHandler)
+	mux.HandleFunc("GET /api/groups", hs.groupsHandler)
+	handler := apiMiddleware(newAPILimiter(1000, 1000), hs.apiAuth, mux)
+
+	get := func(path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := get("/health/live", ""); rec.Code != http.StatusOK {
+		t.Errorf("health probe should not need a token, got %d", rec.Code)
+	}
+	if rec := get("/api/events", ""); rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") != "Bearer" {
+		t.Errorf("expected 401 with WWW-Authenticate without token, got %d", rec.Code)
+	}
+	if rec := get("/api/events", "wrong-token-0123456789"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for an unknown token, got %d", rec.Code)
+	}
+
+	// Unscoped token sees everything
+	var events eventsResponse
+	rec := get("/api/events", "admin-token-0123456789")
+	if err := json.NewDecoder(rec.Body).Decode(&events); err != nil || events.DevicesDown != 2 || len(events.Events) != 2 {
+		t.Errorf("admin: unexpected events %+v (%v)", events, err)
+	}
+	if rec := get("/api/history", "admin-token-0123456789"); rec.Code == http.StatusForbidden || rec.Code == http.StatusUnauthorized {
+		t.Errorf("admin should reach global endpoints, got %d", rec.Code)
+	}
+
+	// Scoped token only sees its network
+	events = eventsResponse{}
+	rec = get("/api/events", "branch-token-0123456789")
+	if err := json.NewDecoder(rec.Body).Decode(&events); err != nil || events.DevicesDown != 1 || len(events.Events) != 1 {
+		t.Errorf("branch: unexpected events %+v (%v)", events, err)
+	}
+	for _, ev := range events.Events {
+		if ev.IP != "10.1.0.5" {
+			t.Errorf("branch: event outside the scope: %+v", ev)
+		}
+	}
+	if rec := get("/api/events?ip=10.2.0.5", "branch-token-0123456789"); rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for an event filter outside the scope, got %d", rec.Code)
+	}
+	if rec := get("/api/device/10.2.0.5/rollups", "branch-token-0123456789"); rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a device outside the scope, got %d", rec.Code)
+	}
+	if rec := get("/api/device/10.1.0.5/rollups", "branch-token-0123456789"); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 for a device inside the scope, got %d", rec.Code)
+	}
+	var rollups rollupsResponse
+	rec = get("/api/rollups", "branch-token-0123456789")
+	if err := json.NewDecoder(rec.Body).Decode(&rollups); err != nil || len(rollups.Devices) != 1 || rollups.Devices[0].IP != "10.1.0.5" {
+		t.Errorf("branch: unexpected rollups %+v (%v)", rollups, err)
+	}
+	var groups groupsResponse
+	rec = get("/api/groups?by=network", "branch-token-0123456789")
+	if err := json.NewDecoder(rec.Body).Decode(&groups); err != nil || len(groups.Groups) != 1 || groups.Groups[0].Devices != 1 {
+		t.Errorf("branch: expected groups of its own device only, got %d %+v (%v)", rec.Code, groups, err)
+	}
+	if rec := get("/api/history", "branch-token-0123456789"); rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a global endpoint with a scoped token, got %d", rec.Code)
+	}
+}
+
+// TestAPITokenAllows verifies network matching and the nil (auth disabled) token
+func TestAPITokenAllows(t *testing.T) {
+	var open *apiToken
+	if !open.allows("192.168.1.1") || open.scoped() {
+		t.Error("nil token should allow every device")
+	}
+	token := newBearerAuth([]config.APITokenConfig{{Name: "v6", Token: "x", Networks: []string{"2001:db8::/32", "192.168.0.0/24"}}}).tokens
+	for _, tok := range token {
+		if !tok.allows("2001:db8::1") || !tok.allows("192.168.0.9") || tok.allows("192.168.1.9") || tok.allows("bogus") {
+			t.Error("unexpected scope matching")
+		}
+	}
+	if newAPIAuth() != nil {
+		t.Error("expected nil auth without tokens")
+	}
+}
+
+// TestAPIBasicAuth verifies basic-auth users, their scope and the challenge naming both schemes
+func TestAPIBasicAuth(t *testing.T) {
+	hs := &HealthServer{stateMgr: state.NewManager(10)}
+	hs.SetAPITokens(
+		[]config.APITokenConfig{{Name: "admin", Token: "admin-token-0123456789"}},
+		[]config.APIUserConfig{{Username: "ops", Password: "ops-password-0123456789", Networks: []string{"10.1.0.0/16"}}},
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/events", hs.eventsHandler)
+	mux.HandleFunc("GET /api/history", hs.historyHandler)
+	handler := apiMiddleware(newAPILimiter(1000, 1000), hs.apiAuth, mux)
+
+	get := func(path, username, password string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := get("/api/events", "", ""); rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") != `Bearer, Basic realm="netscan"` {
+		t.Errorf("expected 401 challenging both schemes, got %d %q", rec.Code, rec.Header().Get("WWW-Authenticate"))
+	}
+	if rec := get("/api/events", "ops", "wrong-password-0123456789"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a wrong password, got %d", rec.Code)
+	}
+	if rec := get("/api/events", "admin", "admin-token-0123456789"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a token sent as a password, got %d", rec.Code)
+	}
+	if rec := get("/api/events", "ops", "ops-password-0123456789"); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 for a valid user, got %d", rec.Code)
+	}
+	if rec := get("/api/history", "ops", "ops-password-0123456789"); rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a global endpoint with a scoped user, got %d", rec.Code)
+	}
+	if auth := newAPIAuth(newBasicAuth([]config.APIUserConfig{{Username: "ops", Password: "x"}})); auth.challenge() != `Basic realm="netscan"` {
+		t.Errorf("expected a basic-only challenge without tokens, got %q", auth.challenge())
+	}
+}
+
+// TestClientCertAuth verifies certificate name mapping, the any-certificate mode and requests without certificates
+func TestClientCertAuth(t *testing.T) {
+	withCert := func(commonName string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/events", nil)
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: commonName}}}}}
+		return req
+	}
+
+	anyCert := newClientCertAuth(nil)
+	if token, _ := anyCert.authenticate(withCert("monitoring")); token == nil || token.name != "monitoring" || token.scoped() {
+		t.Errorf("expected any verified certificate to be accepted unscoped, got %+v", token)
+	}
+	if token, message := anyCert.authenticate(httptest.NewRequest(http.MethodGet, "/api/events", nil)); token != nil || message != "" {
+		t.Errorf("expected a request without a certificate to be skipped, got %+v %q", token, message)
+	}
+
+	mapped := newClientCertAuth([]config.APIClientCertConfig{{Name: "branch", CommonName: "vienna.example.com", Networks: []string{"10.1.0.0/16"}}})
+	if token, _ := mapped.authenticate(withCert("vienna.example.com")); token == nil || token.name != "branch" || !token.scoped() {
+		t.Errorf("expected the mapped certificate to be accepted, got %+v", token)
+	}
+	if token, message := mapped.authenticate(withCert("monitoring")); token != nil || message == "" {
+		t.Errorf("expected an unmapped certificate to be rejected, got %+v %q", token, message)
+	}
+	if key := clientKey(withCert("vienna.example.com")); key != "cert:vienna.example.com" {
+		t.Errorf("expected the certificate as rate limit client, got %q", key)
+	}
+	if challenge := newAPIAuth(mapped).challenge(); challenge != "" {
+		t.Errorf("expected no WWW-Authenticate challenge for client certificates, got %q", challenge)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/time/rate"
+)
+
+// apiClientIdle is how long a client's limiter is kept after its last request
+const apiClientIdle = 10 * time.Minute
+
+// apiClient is the rate limiter state of one API client
+type apiClient struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// apiLimiter enforces a token bucket per API client (bearer token or source IP)
+// A runaway script exhausts only its own bucket, so it cannot starve other clients or trigger poll storms
+type apiLimiter struct {
+	mu        sync.Mutex
+	clients   map[string]*apiClient
+	rate      rate.Limit
+	burst     int
+	lastSweep time.Time
+}
+
+// newAPILimiter creates a per-client limiter; non-positive values fall back to the config defaults
+func newAPILimiter(requestsPerSec float64, burst int) *apiLimiter {
+	if requestsPerSec <= 0 {
+		requestsPerSec = 5.0
+	}
+	if burst <= 0 {
+		burst = 20
+	}
+	return &apiLimiter{
+		clients:   make(map[string]*apiClient),
+		rate:      rate.Limit(requestsPerSec),
+		burst:     burst,
+		lastSweep: time.Now(),
+	}
+}
+
+// allow reports whether the client may make a request now
+func (l *apiLimiter) allow(key string) bool {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Evict idle clients so rotating source addresses cannot grow the map without bound
+	if now.Sub(l.lastSweep) > apiClientIdle {
+		for k, c := range l.clients {
+			if now.Sub(c.lastSeen) > apiClientIdle {
+				delete(l.clients, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	client, exists := l.clients[key]
+	if !exists {
+		client = &apiClient{limiter: rate.NewLimiter(l.rate, l.burst)}
+		l.clients[key] = client
+	}
+	client.lastSeen = now
+	return client.limiter.AllowN(now, 1)
+}
+
+// clientKey identifies the caller: a fingerprint of its bearer token, its basic-auth user or client certificate,
+// otherwise its source IP
+// Secrets are never stored or logged
+func clientKey(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		sum := sha256.Sum256([]byte(strings.TrimPrefix(auth, "Bearer ")))
+		return "token:" + hex.EncodeToString(sum[:])[:12]
+	}
+	if username, _, ok := r.BasicAuth(); ok {
+		return "user:" + username
+	}
+	if commonName, ok := clientCertName(r); ok {
+		return "cert:" + commonName
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// isControlPlanePath reports whether a path belongs to the API (rate limited and access logged)
+// Health probes stay unlimited so orchestrators never see a 429 from a healthy scanner
+func isControlPlanePath(path string) bool {
+	return strings.HasPrefix(path, "/api/") || strings.HasPrefix(path, "/debug/pprof/")
+}
+
+// statusRecorder captures the response status for access logging
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap exposes the underlying writer so http.ResponseController can flush streamed responses
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// apiMiddleware rate limits API requests per client, checks credentials when auth is configured
+// and writes a structured access log line for each request
+func apiMiddleware(limiter *apiLimiter, auth *apiAuth, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isControlPlanePath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		key := clientKey(r)
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		var token *apiToken
+		switch {
+		case !limiter.allow(key):
+			// Rate limiting runs first, so it also throttles token guessing
+			rec.Header().Set("Retry-After", "1")
+			http.Error(rec, "rate limit exceeded", http.StatusTooManyRequests)
+		case auth != nil:
+			var status int
+			var message string
+			if token, status, message = auth.authenticate(r); status != 0 {
+				if challenge := auth.challenge(); status == http.StatusUnauthorized && challenge != "" {
+					rec.Header().Set("WWW-Authenticate", challenge)
+				}
+				http.Error(rec, message, status)
+				break
+			}
+			next.ServeHTTP(rec, withToken(r, token))
+		default:
+			next.ServeHTTP(rec, r)
+		}
+
+		event := log.Info()
+		if rec.status >= 500 {
+			event = log.Warn()
+		}
+		event.
+			Str("method", r.Method).
+			Str("path", r.URL.Path).
+			Int("status", rec.status).
+			Dur("latency", time.Since(start)).
+			Str("client", key)
+		if token != nil {
+			event = event.Str("token", token.name)
+		}
+		event.Msg("API request")
+	})
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestAPIMiddlewareRateLimit verifies each client gets its own bucket and health probes are never limited
+func TestAPIMiddlewareRateLimit(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("GET /api/events", func(w http.ResponseWriter, r *http.Request) {})
+	handler := apiMiddleware(newAPILimiter(0.001, 2), nil, mux)
+
+	request := func(path, remote, token string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remote
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code == http.StatusTooManyRequests && rec.Header().Get("Retry-After") == "" {
+			t.Error("429 response should set Retry-After")
+		}
+		return rec.Code
+	}
+
+	// Burst of 2, then limited
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		if got := request("/api/events", "10.0.0.1:5000", ""); got != want {
+			t.Errorf("request %d from 10.0.0.1: expected %d, got %d", i+1, want, got)
+		}
+	}
+
+	// Same IP on another port shares the bucket; another IP does not
+	if got := request("/api/events", "10.0.0.1:6000", ""); got != http.StatusTooManyRequests {
+		t.Errorf("same source IP should share the limit, got %d", got)
+	}
+	if got := request("/api/events", "10.0.0.2:5000", ""); got != http.StatusOK {
+		t.Errorf("other source IP should have its own limit, got %d", got)
+	}
+
+	// A bearer token is limited independently of the source IP
+	if got := request("/api/events", "10.0.0.1:5000", "automation"); got != http.StatusOK {
+		t.Errorf("token client should have its own limit, got %d", got)
+	}
+
+	// Health probes bypass the limiter
+	for i := 0; i < 5; i++ {
+		if got := request("/health", "10.0.0.1:5000", ""); got != http.StatusOK {
+			t.Fatalf("health probe %d was limited: %d", i+1, got)
+		}
+	}
+}
+
+// TestClientKey verifies tokens are fingerprinted rather than stored
+func TestClientKey(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/events", nil)
+	req.RemoteAddr = "192.0.2.7:41000"
+	if got := clientKey(req); got != "192.0.2.7" {
+		t.Errorf("expected source IP key, got %q", got)
+	}
+
+	req.Header.Set("Authorization", "Bearer s3cret-token")
+	got := clientKey(req)
+	if !strings.HasPrefix(got, "token:") || strings.Contains(got, "s3cret") {
+		t.Errorf("expected token fingerprint, got %q", got)
+	}
+	if got != clientKey(req) {
+		t.Error("fingerprint should be stable")
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/monitoring"
+)
+
+// autoTuneMinProbes is the fewest probes per interval for a loss rate to count; with fewer the setting is held
+const autoTuneMinProbes = 20
+
+// tuneSettings are the values auto-tuning adjusts
+type tuneSettings struct {
+	IcmpWorkers int     // Workers of discovery sweeps
+	SnmpWorkers int     // Workers of SNMP scans
+	PingRate    float64 // Global ping rate limit (pings/s)
+	SNMPRate    float64 // Global SNMP rate limit (queries/s)
+}
+
+// tuneSample is one observation of probe outcomes and host load
+type tuneSample struct {
+	At       time.Time
+	Outcomes monitoring.ProbeOutcomes // Cumulative probe outcome counters
+	CPUTime  time.Duration            // Cumulative process CPU time (0 = unknown)
+	MemoryMB uint64                   // Resident memory (0 = unknown)
+}
+
+// autoTuner adjusts worker counts and rate limits by additive increase / multiplicative decrease: probe loss
+// above max_loss_rate or CPU/memory above their limits cut the affected settings by a quarter, low loss with
+// host headroom raises them a step, and anything in between (or too few probes to judge) holds them
+// Only the main event loop calls it
+type autoTuner struct {
+	cfg           config.AutoTuneConfig
+	base          tuneSettings // Configured values; rate bounds are factors of these
+	current       tuneSettings
+	memoryLimitMB int
+	cpus          int
+	last          *tuneSample
+}
+
+// newAutoTuner creates a tuner starting at the configured worker counts and rate limits
+func newAutoTuner(cfg *config.Config, cpus int) *autoTuner {
+	base := tuneSettings{
+		IcmpWorkers: cfg.IcmpWorkers,
+		SnmpWorkers: cfg.SnmpWorkers,
+		PingRate:    cfg.PingRateLimit,
+		SNMPRate:    cfg.SNMPRateLimit,
+	}
+	return &autoTuner{
+		cfg:           cfg.AutoTune,
+		base:          base,
+		current:       base,
+		memoryLimitMB: cfg.MemoryLimitMB,
+		cpus:          max(cpus, 1),
+	}
+}
+
+// step compares a sample with the previous one and returns the settings to apply and why they changed
+// (nil when unchanged); the first sample only sets the baseline
+func (t *autoTuner) step(s tuneSample) (tuneSettings, []string) {
+	last := t.last
+	t.last = &s
+	if last == nil {
+		return t.current, nil
+	}
+
+	var reasons []string
+	pressure := false
+	headroom := true
+	if s.CPUTime > 0 && last.CPUTime > 0 && s.At.After(last.At) {
+		cpu := float64(s.CPUTime-last.CPUTime) / float64(s.At.Sub(last.At)) / float64(t.cpus) * 100
+		if cpu > t.cfg.MaxCPUPercent {
+			pressure = true
+			reasons = append(reasons, fmt.Sprintf("cpu %.0f%% above %.0f%%", cpu, t.cfg.MaxCPUPercent))
+		}
+		headroom = cpu < t.cfg.MaxCPUPercent*3/4
+	}
+	if s.MemoryMB > 0 && t.memoryLimitMB > 0 {
+		memory := float64(s.MemoryMB) / float64(t.memoryLimitMB) * 100
+		if memory > t.cfg.MaxMemoryPercent {
+			pressure = true
+			reasons = append(reasons, fmt.Sprintf("memory %.0f%% above %.0f%%", memory, t.cfg.MaxMemoryPercent))
+		}
+		headroom = headroom && memory < t.cfg.MaxMemoryPercent*3/4
+	}
+
+	previous := t.current
+	pingDirection, pingReason := t.direction("ping", s.Outcomes.Pings-last.Outcomes.Pings, s.Outcomes.PingsLost-last.Outcomes.PingsLost, pressure, headroom)
+	t.current.IcmpWorkers = tuneWorkers(t.current.IcmpWorkers, pingDirection, t.cfg.MinIcmpWorkers, t.cfg.MaxIcmpWorkers)
+	t.current.PingRate = t.tuneRate(t.current.PingRate, t.base.PingRate, pingDirection)
+	snmpDirection, snmpReason := t.direction("snmp", s.Outcomes.SNMPQueries-last.Outcomes.SNMPQueries, s.Outcomes.SNMPLost-last.Outcomes.SNMPLost, pressure, headroom)
+	t.current.SnmpWorkers = tuneWorkers(t.current.SnmpWorkers, snmpDirection, t.cfg.MinSnmpWorkers, t.cfg.MaxSnmpWorkers)
+	t.current.SNMPRate = t.tuneRate(t.current.SNMPRate, t.base.SNMPRate, snmpDirection)
+
+	if t.current == previous {
+		return t.current, nil
+	}
+	for _, reason := range []string{pingReason, snmpReason} {
+		if reason != "" {
+			reasons = append(reasons, reason)
+		}
+	}
+	return t.current, reasons
+}
+
+// direction decides whether one probe type backs off (-1), grows (+1) or holds (0) from its loss since the last sample
+func (t *autoTuner) direction(name string, probes, lost uint64, pressure, headroom bool) (int, string) {
+	if probes < autoTuneMinProbes {
+		if pressure {
+			return -1, ""
+		}
+		return 0, ""
+	}
+	loss := float64(lost) / float64(probes)
+	switch {
+	case loss > t.cfg.MaxLossRate:
+		return -1, fmt.Sprintf("%s loss %.1f%% above %.1f%%", name, loss*100, t.cfg.MaxLossRate*100)
+	case pressure:
+		return -1, ""
+	case loss <= t.cfg.MaxLossRate/2 && headroom:
+		return 1, fmt.Sprintf("%s loss %.1f%% with host headroom", name, loss*100)
+	}
+	return 0, ""
+}
+
+// tuneWorkers cuts workers by a quarter or raises them by a tenth of the range, within [lo, hi]
+func tuneWorkers(workers, direction, lo, hi int) int {
+	switch direction {
+	case -1:
+		workers = workers * 3 / 4
+	case 1:
+		workers += max((hi-lo)/10, 1)
+	}
+	return min(max(workers, lo), hi)
+}
+
+// tuneRate cuts a rate limit by a quarter or raises it by a tenth, within the configured factors of base
+func (t *autoTuner) tuneRate(current, base float64, direction int) float64 {
+	switch direction {
+	case -1:
+		current *= 0.75
+	case 1:
+		current *= 1.1
+	}
+	return min(max(current, base*t.cfg.MinRateFactor), base*t.cfg.MaxRateFactor)
+}
+
+// tunedWorkers holds the worker counts of discovery sweeps and SNMP scans: icmp_workers and snmp_workers until
+// auto-tuning adjusts them. Sweeps and scans read them when they start and keep them until they finish
+type tunedWorkers struct {
+	icmp atomic.Int64
+	snmp atomic.Int64
+}
+
+// newTunedWorkers starts at the configured worker counts
+func newTunedWorkers(cfg *config.Config) *tunedWorkers {
+	w := &tunedWorkers{}
+	w.icmp.Store(int64(cfg.IcmpWorkers))
+	w.snmp.Store(int64(cfg.SnmpWorkers))
+	return w
+}
+
+// set applies the worker counts of tuned settings
+func (w *tunedWorkers) set(s tuneSettings) {
+	w.icmp.Store(int64(s.IcmpWorkers))
+	w.snmp.Store(int64(s.SnmpWorkers))
+}
+
+// icmpWorkers returns the workers of the next discovery sweep
+func (w *tunedWorkers) icmpWorkers() int {
+	return int(w.icmp.Load())
+}
+
+// snmpWorkers returns the workers of the next SNMP scan
+func (w *tunedWorkers) snmpWorkers() int {
+	return int(w.snmp.Load())
+}
+
+// sampleTuning observes the probe outcome counters and the process's CPU time and resident memory
+func sampleTuning(at time.Time) tuneSample {
+	return tuneSample{
+		At:       at,
+		Outcomes: monitoring.Outcomes(),
+		CPUTime:  getCPUTime(),
+		MemoryMB: getRSSMB(),
+	}
+}
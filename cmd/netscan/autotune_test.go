@@ -0,0 +1,91 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/monitoring"
+)
+
+// TestAutoTuner verifies loss and host pressure cut the settings, low loss with headroom grows them within bounds
+func TestAutoTuner(t *testing.T) {
+	cfg := &config.Config{
+		IcmpWorkers:   64,
+		SnmpWorkers:   32,
+		PingRateLimit: 100,
+		SNMPRateLimit: 10,
+		MemoryLimitMB: 1000,
+		AutoTune: config.AutoTuneConfig{
+			Enabled: true, MinIcmpWorkers: 8, MaxIcmpWorkers: 256, MinSnmpWorkers: 4, MaxSnmpWorkers: 128,
+			MinRateFactor: 0.25, MaxRateFactor: 2, MaxLossRate: 0.05, MaxCPUPercent: 80, MaxMemoryPercent: 90,
+		},
+	}
+	tuner := newAutoTuner(cfg, 2)
+	start := time.Now()
+	var outcomes monitoring.ProbeOutcomes
+	cpu := time.Second
+	sample := func(i int, pings, pingsLost, snmp, snmpLost uint64, cpuUsed time.Duration, memoryMB uint64) (tuneSettings, []string) {
+		outcomes.Pings += pings
+		outcomes.PingsLost += pingsLost
+		outcomes.SNMPQueries += snmp
+		outcomes.SNMPLost += snmpLost
+		cpu += cpuUsed
+		return tuner.step(tuneSample{At: start.Add(time.Duration(i) * 10 * time.Second), Outcomes: outcomes, CPUTime: cpu, MemoryMB: memoryMB})
+	}
+
+	if _, reasons := sample(0, 0, 0, 0, 0, 0, 100); reasons != nil {
+		t.Fatalf("expected the first sample to set the baseline only, got %v", reasons)
+	}
+
+	// Ping loss above 5% backs off ICMP only; SNMP with too few queries holds
+	got, reasons := sample(1, 1000, 100, 5, 5, time.Second, 100)
+	if got.IcmpWorkers != 48 || got.PingRate != 75 || got.SnmpWorkers != 32 || got.SNMPRate != 10 || len(reasons) != 1 {
+		t.Errorf("expected ICMP backoff to 48 workers and 75/s, got %+v (%v)", got, reasons)
+	}
+
+	// Low loss with headroom (5% CPU) grows both by a step
+	got, _ = sample(2, 1000, 0, 100, 0, time.Second, 100)
+	if got.IcmpWorkers != 48+24 || got.SnmpWorkers != 32+12 || got.PingRate != 75*1.1 || got.SNMPRate != 11 {
+		t.Errorf("expected growth by one step, got %+v", got)
+	}
+
+	// CPU above 80% of both cores cuts everything, even without loss
+	got, reasons = sample(3, 1000, 0, 100, 0, 18*time.Second, 100)
+	if got.IcmpWorkers != 54 || got.SnmpWorkers != 33 || len(reasons) == 0 {
+		t.Errorf("expected CPU backoff, got %+v (%v)", got, reasons)
+	}
+
+	// Loss between half the threshold and the threshold holds
+	if _, reasons := sample(4, 1000, 40, 100, 4, time.Second, 100); reasons != nil {
+		t.Errorf("expected settings held, got %v", reasons)
+	}
+
+	// Repeated backoff stops at the lower bounds
+	for i := 5; i < 30; i++ {
+		got, _ = sample(i, 1000, 500, 100, 50, time.Second, 950)
+	}
+	if got.IcmpWorkers != 8 || got.SnmpWorkers != 4 || got.PingRate != 25 || got.SNMPRate != 2.5 {
+		t.Errorf("expected the lower bounds, got %+v", got)
+	}
+
+	// Repeated growth stops at the upper bounds
+	for i := 30; i < 100; i++ {
+		got, _ = sample(i, 1000, 0, 100, 0, time.Second, 100)
+	}
+	if got.IcmpWorkers != 256 || got.SnmpWorkers != 128 || got.PingRate != 200 || got.SNMPRate != 20 {
+		t.Errorf("expected the upper bounds, got %+v", got)
+	}
+}
+
+// TestTunedWorkers verifies sweeps and scans start at the configured workers and pick up tuned counts
+func TestTunedWorkers(t *testing.T) {
+	workers := newTunedWorkers(&config.Config{IcmpWorkers: 64, SnmpWorkers: 32})
+	if workers.icmpWorkers() != 64 || workers.snmpWorkers() != 32 {
+		t.Fatalf("expected the configured 64/32 workers, got %d/%d", workers.icmpWorkers(), workers.snmpWorkers())
+	}
+	workers.set(tuneSettings{IcmpWorkers: 48, SnmpWorkers: 24})
+	if workers.icmpWorkers() != 48 || workers.snmpWorkers() != 24 {
+		t.Errorf("expected the tuned 48/24 workers, got %d/%d", workers.icmpWorkers(), workers.snmpWorkers())
+	}
+}
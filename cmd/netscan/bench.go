@@ -0,0 +1,449 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/influx"
+	"github.com/kljama/netscan/internal/logger"
+	"github.com/kljama/netscan/internal/monitoring"
+	"github.com/kljama/netscan/internal/state"
+	"golang.org/x/time/rate"
+)
+
+const benchUsage = "usage: netscan bench [-config config.yml] [-devices N] [-duration 30s] [-rtt 2ms] [-loss 0] [-format table|json]"
+
+// benchLagSamples bounds the scheduling lag reservoir so memory stays flat at any scale
+const benchLagSamples = 1 << 16
+
+// benchFirstPingDelay matches the ping scheduler's delay before a new device's first ping
+const benchFirstPingDelay = time.Second
+
+// benchMaxDevices is the number of simulated addresses available in 10.0.0.0/8
+const benchMaxDevices = 1<<24 - 2
+
+// benchOptions is the scale and pipeline settings of one benchmark run
+type benchOptions struct {
+	Devices  int
+	Duration time.Duration
+	RTT      time.Duration // Mean simulated round-trip time
+	Loss     float64       // Probability that a simulated echo request is lost
+
+	// Pipeline settings, taken from the config file when one is given
+	Interval      time.Duration
+	Timeout       time.Duration
+	PingsPerCycle int
+	Workers       int
+	RateLimit     float64 // Pings per second (0 = unlimited)
+	BurstLimit    int
+	StartSpread   time.Duration
+	Jitter        time.Duration
+	MaxFails      int
+	Backoff       time.Duration
+	CoalesceAfter time.Duration
+	CoalesceEvery int
+	BatchSize     int
+	FlushInterval time.Duration
+	ProbeProfile  config.ProbeProfile // Monitoring probe profile (zero = the defaults)
+}
+
+// defaultBenchOptions mirrors the daemon defaults, except that pings are not rate limited
+func defaultBenchOptions() benchOptions {
+	return benchOptions{
+		Devices:       1000,
+		Duration:      30 * time.Second,
+		RTT:           2 * time.Millisecond,
+		Interval:      time.Second,
+		Timeout:       3 * time.Second,
+		PingsPerCycle: 1,
+		Workers:       256,
+		StartSpread:   time.Second,
+		MaxFails:      10,
+		Backoff:       5 * time.Minute,
+		BatchSize:     5000,
+		FlushInterval: 5 * time.Second,
+	}
+}
+
+// benchOptionsFromConfig takes the ping, circuit breaker and InfluxDB batching settings of cfg
+func benchOptionsFromConfig(cfg *config.Config) benchOptions {
+	opts := defaultBenchOptions()
+	opts.Interval = cfg.PingInterval
+	opts.Timeout = cfg.PingTimeout
+	opts.PingsPerCycle = cfg.PingsPerCycle
+	opts.Workers = cfg.PingWorkers
+	opts.RateLimit = cfg.PingRateLimit
+	opts.BurstLimit = cfg.PingBurstLimit
+	opts.StartSpread = cfg.PingStartSpread
+	opts.Jitter = cfg.PingJitter
+	opts.MaxFails = cfg.PingMaxConsecutiveFails
+	opts.Backoff = cfg.PingBackoffDuration
+	opts.CoalesceAfter = cfg.PingFailureCoalesceAfter
+	opts.CoalesceEvery = cfg.PingFailureCoalesceEvery
+	opts.BatchSize = cfg.InfluxDB.BatchSize
+	opts.FlushInterval = cfg.InfluxDB.FlushInterval
+	opts.ProbeProfile = cfg.ProbeProfiles.Monitoring
+	return opts
+}
+
+// benchReport is the outcome of a benchmark run
+// Allocation figures cover the whole process, including the in-process mock InfluxDB
+type benchReport struct {
+	Devices          int     `json:"devices"`
+	Workers          int     `json:"workers"`
+	Interval         string  `json:"interval"`
+	RTT              string  `json:"rtt"`
+	Loss             float64 `json:"loss"`
+	DurationS        float64 `json:"duration_s"`
+	PingCycles       uint64  `json:"ping_cycles"`
+	CyclesPerS       float64 `json:"cycles_per_s"`
+	TargetCyclesPerS float64 `json:"target_cycles_per_s"` // devices / (interval + rtt): the rate when workers keep up
+	PointsWritten    uint64  `json:"points_written"`      // Points received by the mock InfluxDB
+	PointsPerS       float64 `json:"points_per_s"`
+	PointsDropped    uint64  `json:"points_dropped"`
+	FailedBatches    uint64  `json:"failed_batches"`
+	AllocMBPerS      float64 `json:"alloc_mb_per_s"`
+	AllocsPerS       float64 `json:"allocs_per_s"`
+	AllocsPerCycle   float64 `json:"allocs_per_cycle"`
+	GCCycles         uint32  `json:"gc_cycles"`
+	HeapMB           float64 `json:"heap_mb"`
+	SchedLagP50Ms    float64 `json:"sched_lag_p50_ms"`
+	SchedLagP99Ms    float64 `json:"sched_lag_p99_ms"`
+	SchedLagMaxMs    float64 `json:"sched_lag_max_ms"`
+	SchedLagSamples  uint64  `json:"sched_lag_samples"`
+}
+
+// runBench runs the ping pipeline against simulated devices and a mock InfluxDB and prints throughput,
+// allocation rates and scheduling latency, so hardware can be sized without touching the network
+func runBench(args []string) int {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	configPath := fs.String("config", "", "Take ping and InfluxDB batching settings from this configuration file")
+	varsPath := fs.String("vars", "", "Per-site variables file overriding the config's vars block")
+	format := fs.String("format", "table", "Output format: table or json")
+	logLevel := fs.String("log-level", "error", "Log level during the run: debug, info, warn or error")
+	devices := fs.Int("devices", 1000, "Number of simulated devices")
+	duration := fs.Duration("duration", 30*time.Second, "How long to run the pipeline")
+	rtt := fs.Duration("rtt", 2*time.Millisecond, "Mean simulated round-trip time")
+	loss := fs.Float64("loss", 0, "Probability (0-1) that a simulated echo request is lost")
+	interval := fs.Duration("interval", 0, "Ping interval (default: config ping_interval, or 1s)")
+	workers := fs.Int("workers", 0, "Ping workers (default: config ping_workers, or 256)")
+	rateLimit := fs.Float64("rate", 0, "Ping rate limit per second, 0 = unlimited (default: config ping_rate_limit, or unlimited)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, benchUsage)
+		return 2
+	}
+	if *format != "table" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "unsupported format %q (table, json)\n", *format)
+		return 2
+	}
+	if err := logger.Configure(logger.Options{Level: *logLevel, Stderr: true}); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+
+	opts := defaultBenchOptions()
+	if *configPath != "" {
+		cfg, err := config.LoadConfigWithVars(*configPath, *varsPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+			return 1
+		}
+		if _, err := config.ValidateScanConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid configuration: %v\n", err)
+			return 1
+		}
+		opts = benchOptionsFromConfig(cfg)
+	}
+	opts.Devices, opts.Duration, opts.RTT, opts.Loss = *devices, *duration, *rtt, *loss
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "interval":
+			opts.Interval = *interval
+			if *configPath == "" {
+				opts.StartSpread = *interval
+			}
+		case "workers":
+			opts.Workers = *workers
+		case "rate":
+			opts.RateLimit = *rateLimit
+		}
+	})
+	if err := opts.validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+
+	fmt.Fprintf(os.Stderr, "Benchmarking %d simulated devices for %s after a %s warmup...\n", opts.Devices, opts.Duration, opts.warmup())
+	report := runBenchmark(opts)
+	if err := writeBenchReport(os.Stdout, *format, report); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write report: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// validate rejects settings the benchmark cannot run with
+func (o benchOptions) validate() error {
+	switch {
+	case o.Devices < 1 || o.Devices > benchMaxDevices:
+		return fmt.Errorf("-devices must be between 1 and %d, got %d", benchMaxDevices, o.Devices)
+	case o.Duration <= 0:
+		return fmt.Errorf("-duration must be positive, got %s", o.Duration)
+	case o.Loss < 0 || o.Loss > 1:
+		return fmt.Errorf("-loss must be between 0 and 1, got %g", o.Loss)
+	case o.Interval <= 0:
+		return fmt.Errorf("-interval must be positive, got %s", o.Interval)
+	case o.Workers < 1:
+		return fmt.Errorf("-workers must be at least 1, got %d", o.Workers)
+	case o.RateLimit < 0:
+		return fmt.Errorf("-rate must not be negative, got %g", o.RateLimit)
+	}
+	return nil
+}
+
+// runBenchmark schedules opts.Devices simulated devices on the daemon's ping scheduler, state manager and
+// InfluxDB writer (pointed at an in-process mock) and measures opts.Duration after a warmup
+// The simulated ping engine replaces the configured one for the rest of the process
+func runBenchmark(opts benchOptions) benchReport {
+	mock := newMockInflux()
+	defer mock.Close()
+
+	writer := influx.NewWriter(mock.URL(), "bench", "bench", "bench", "bench", opts.BatchSize, opts.FlushInterval)
+
+	stateMgr := state.NewManager(opts.Devices)
+	var results monitoring.PingWriter = writer
+	if opts.CoalesceAfter > 0 {
+		results = monitoring.NewFailureCoalescer(writer, opts.CoalesceAfter, opts.CoalesceEvery)
+	}
+	limiter := rate.NewLimiter(rate.Inf, 0)
+	if opts.RateLimit > 0 {
+		limiter = rate.NewLimiter(rate.Limit(opts.RateLimit), opts.BurstLimit)
+	}
+
+	var inFlight atomic.Int64
+	var pingsSent atomic.Uint64
+	scheduler := monitoring.NewPingScheduler(opts.Interval, opts.Timeout, opts.PingsPerCycle, opts.Workers, results, stateMgr, limiter, &inFlight, &pingsSent, opts.MaxFails, opts.Backoff, config.AddressPolicy{}, nil, nil)
+	scheduler.SetProber(monitoring.NewSimulatedProber(opts.RTT, opts.Loss, opts.ProbeProfile))
+	scheduler.SetSpread(opts.StartSpread, opts.Jitter)
+	lags := newLagSampler(benchLagSamples)
+	scheduler.SetLagObserver(lags.observe)
+
+	now := time.Now()
+	for i := 0; i < opts.Devices; i++ {
+		device := state.Device{IP: benchIP(i), Hostname: fmt.Sprintf("sim-%d", i+1), LastSeen: now}
+		stateMgr.Add(device)
+		scheduler.Add(device)
+	}
+
+	// Measure after every device had its first ping, so the ramp-up does not dilute the rates
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopped := make(chan struct{})
+	go func() {
+		scheduler.Run(ctx)
+		close(stopped)
+	}()
+	time.Sleep(opts.warmup())
+
+	runtime.GC()
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+	lags.reset()
+	cyclesBefore, pointsBefore := pingsSent.Load(), mock.points.Load()
+	start := time.Now()
+
+	time.Sleep(opts.Duration)
+
+	elapsed := time.Since(start)
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	cycles, points := pingsSent.Load()-cyclesBefore, mock.points.Load()-pointsBefore
+
+	cancel()
+	<-stopped      // In-flight cycles finish
+	writer.Close() // Final flush, so dropped points are counted
+
+	seconds := elapsed.Seconds()
+	allocs := after.Mallocs - before.Mallocs
+	report := benchReport{
+		Devices:          opts.Devices,
+		Workers:          opts.Workers,
+		Interval:         opts.Interval.String(),
+		RTT:              opts.RTT.String(),
+		Loss:             opts.Loss,
+		DurationS:        seconds,
+		PingCycles:       cycles,
+		CyclesPerS:       float64(cycles) / seconds,
+		TargetCyclesPerS: float64(opts.Devices) / (opts.Interval + opts.RTT).Seconds(),
+		PointsWritten:    points,
+		PointsPerS:       float64(points) / seconds,
+		PointsDropped:    writer.GetDroppedPoints(),
+		FailedBatches:    writer.GetFailedBatches(),
+		AllocMBPerS:      float64(after.TotalAlloc-before.TotalAlloc) / 1024 / 1024 / seconds,
+		AllocsPerS:       float64(allocs) / seconds,
+		GCCycles:         after.NumGC - before.NumGC,
+		HeapMB:           float64(after.HeapAlloc) / 1024 / 1024,
+		SchedLagP50Ms:    lags.percentile(0.50).Seconds() * 1000,
+		SchedLagP99Ms:    lags.percentile(0.99).Seconds() * 1000,
+		SchedLagMaxMs:    lags.maximum().Seconds() * 1000,
+		SchedLagSamples:  lags.count(),
+	}
+	if cycles > 0 {
+		report.AllocsPerCycle = float64(allocs) / float64(cycles)
+	}
+	return report
+}
+
+// warmup is how long the run goes before measuring: the scheduler's first ping delay plus the start spread
+func (o benchOptions) warmup() time.Duration {
+	return benchFirstPingDelay + o.StartSpread
+}
+
+// benchIP returns the address of simulated device i, counting up from 10.0.0.1
+func benchIP(i int) string {
+	n := uint32(i + 1)
+	return netip.AddrFrom4([4]byte{10, byte(n >> 16), byte(n >> 8), byte(n)}).String()
+}
+
+// writeBenchReport prints the report as aligned key/value lines or as JSON
+func writeBenchReport(w io.Writer, format string, report benchReport) error {
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Devices\t%d (rtt %s, loss %.1f%%)\n", report.Devices, report.RTT, report.Loss*100)
+	fmt.Fprintf(tw, "Workers\t%d\n", report.Workers)
+	fmt.Fprintf(tw, "Interval\t%s\n", report.Interval)
+	fmt.Fprintf(tw, "Duration\t%.1fs\n", report.DurationS)
+	fmt.Fprintf(tw, "Ping cycles\t%d (%.1f/s, target %.1f/s)\n", report.PingCycles, report.CyclesPerS, report.TargetCyclesPerS)
+	fmt.Fprintf(tw, "Points written\t%d (%.1f/s, %d dropped, %d failed batches)\n", report.PointsWritten, report.PointsPerS, report.PointsDropped, report.FailedBatches)
+	fmt.Fprintf(tw, "Allocations\t%.2f MB/s, %.0f allocs/s, %.1f allocs/cycle\n", report.AllocMBPerS, report.AllocsPerS, report.AllocsPerCycle)
+	fmt.Fprintf(tw, "GC cycles\t%d (heap %.1f MB at end)\n", report.GCCycles, report.HeapMB)
+	fmt.Fprintf(tw, "Scheduling lag\tp50 %.2fms, p99 %.2fms, max %.2fms (%d cycles)\n", report.SchedLagP50Ms, report.SchedLagP99Ms, report.SchedLagMaxMs, report.SchedLagSamples)
+	return tw.Flush()
+}
+
+// lagSampler keeps a uniform sample of scheduling lags (reservoir sampling) plus their count and maximum
+type lagSampler struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	seen    uint64
+	max     time.Duration
+}
+
+// newLagSampler creates a sampler keeping at most size lags
+func newLagSampler(size int) *lagSampler {
+	return &lagSampler{samples: make([]time.Duration, 0, size)}
+}
+
+// observe records one cycle's scheduling lag (PingScheduler lag observer)
+func (s *lagSampler) observe(lag time.Duration) {
+	lag = max(lag, 0)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seen++
+	s.max = max(s.max, lag)
+	if len(s.samples) < cap(s.samples) {
+		s.samples = append(s.samples, lag)
+	} else if j := rand.Uint64N(s.seen); j < uint64(len(s.samples)) {
+		s.samples[j] = lag
+	}
+}
+
+// reset discards everything observed so far
+func (s *lagSampler) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples = s.samples[:0]
+	s.seen = 0
+	s.max = 0
+}
+
+// percentile returns the p-quantile (0-1) of the sampled lags, 0 without samples
+func (s *lagSampler) percentile(p float64) time.Duration {
+	s.mu.Lock()
+	sorted := slices.Clone(s.samples)
+	s.mu.Unlock()
+	if len(sorted) == 0 {
+		return 0
+	}
+	slices.Sort(sorted)
+	return sorted[min(int(p*float64(len(sorted))), len(sorted)-1)]
+}
+
+// maximum returns the largest observed lag
+func (s *lagSampler) maximum() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.max
+}
+
+// count returns the number of observed cycles
+func (s *lagSampler) count() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seen
+}
+
+// mockInflux is an in-process InfluxDB write endpoint that accepts every batch and counts its points
+type mockInflux struct {
+	server *httptest.Server
+	points atomic.Uint64
+}
+
+// newMockInflux starts the mock on a loopback port
+func newMockInflux() *mockInflux {
+	m := &mockInflux{}
+	m.server = httptest.NewServer(http.HandlerFunc(m.handle))
+	return m
+}
+
+// handle counts the line protocol lines of a write request; every other request succeeds empty
+func (m *mockInflux) handle(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api/v2/write" {
+		var buf [32 * 1024]byte
+		for {
+			n, err := r.Body.Read(buf[:])
+			for _, b := range buf[:n] {
+				if b == '\n' {
+					m.points.Add(1)
+				}
+			}
+			if err != nil {
+				break
+			}
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// URL returns the mock's base URL
+func (m *mockInflux) URL() string {
+	return m.server.URL
+}
+
+// Close stops the mock
+func (m *mockInflux) Close() {
+	m.server.Close()
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRunBenchmark runs a short benchmark and checks the pipeline moved points to the mock InfluxDB
+func TestRunBenchmark(t *testing.T) {
+	opts := defaultBenchOptions()
+	opts.Devices = 50
+	opts.Duration = 500 * time.Millisecond
+	opts.RTT = time.Millisecond
+	opts.Interval = 50 * time.Millisecond
+	opts.StartSpread = 50 * time.Millisecond
+	opts.Workers = 8
+	opts.FlushInterval = 50 * time.Millisecond
+	if err := opts.validate(); err != nil {
+		t.Fatalf("options should be valid: %v", err)
+	}
+
+	report := runBenchmark(opts)
+	if report.PingCycles == 0 || report.CyclesPerS <= 0 {
+		t.Fatalf("expected ping cycles, got %+v", report)
+	}
+	if report.PointsWritten == 0 {
+		t.Errorf("expected points at the mock InfluxDB, got %+v", report)
+	}
+	if report.PointsDropped != 0 || report.FailedBatches != 0 {
+		t.Errorf("expected no dropped points or failed batches, got %+v", report)
+	}
+	if report.SchedLagSamples == 0 || report.SchedLagP99Ms < report.SchedLagP50Ms || report.SchedLagMaxMs < report.SchedLagP99Ms {
+		t.Errorf("inconsistent scheduling lag: %+v", report)
+	}
+	if report.AllocsPerCycle <= 0 {
+		t.Errorf("expected allocations per cycle, got %+v", report)
+	}
+
+	var table bytes.Buffer
+	if err := writeBenchReport(&table, "table", report); err != nil {
+		t.Fatalf("table output failed: %v", err)
+	}
+	if !strings.Contains(table.String(), "Scheduling lag") {
+		t.Errorf("table output is missing the scheduling lag:\n%s", table.String())
+	}
+	var decoded benchReport
+	var out bytes.Buffer
+	if err := writeBenchReport(&out, "json", report); err != nil {
+		t.Fatalf("json output failed: %v", err)
+	}
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil || decoded.PingCycles != report.PingCycles {
+		t.Errorf("json output does not round-trip: %v", err)
+	}
+}
+
+// TestBenchOptionsValidate verifies unusable settings are rejected
+func TestBenchOptionsValidate(t *testing.T) {
+	for name, mutate := range map[string]func(*benchOptions){
+		"no devices":    func(o *benchOptions) { o.Devices = 0 },
+		"too many":      func(o *benchOptions) { o.Devices = benchMaxDevices + 1 },
+		"zero duration": func(o *benchOptions) { o.Duration = 0 },
+		"loss above 1":  func(o *benchOptions) { o.Loss = 1.5 },
+		"zero interval": func(o *benchOptions) { o.Interval = 0 },
+		"no workers":    func(o *benchOptions) { o.Workers = 0 },
+		"negative rate": func(o *benchOptions) { o.RateLimit = -1 },
+	} {
+		opts := defaultBenchOptions()
+		mutate(&opts)
+		if err := opts.validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if got := benchIP(0); got != "10.0.0.1" {
+		t.Errorf("first simulated device should be 10.0.0.1, got %s", got)
+	}
+	if got := benchIP(256); got != "10.0.1.1" {
+		t.Errorf("device 257 should be 10.0.1.1, got %s", got)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Circuit breaker states reported by /api/device/{ip}/breaker
+const (
+	breakerClosed = "closed" // Pinged normally
+	breakerOpen   = "open"   // Suspended until suspended_until
+)
+
+// deviceBreakerResponse is the GET /api/device/{ip}/breaker response body
+type deviceBreakerResponse struct {
+	IP               string     `json:"ip"`
+	Hostname         string     `json:"hostname"`
+	State            string     `json:"state"`                     // closed or open
+	SuspendedUntil   *time.Time `json:"suspended_until,omitempty"` // End of the current suspension
+	TestPingAt       *time.Time `json:"test_ping_at,omitempty"`    // Pending half-open test ping
+	ConsecutiveFails int        `json:"consecutive_fails"`
+	WindowFailures   int        `json:"window_failures"`     // Failed cycles in ping_failure_window (windowed mode)
+	BackoffLevel     int        `json:"backoff_level"`       // Escalation level of the latest suspension
+	LastTrip         *time.Time `json:"last_trip,omitempty"` // When the circuit breaker last tripped
+	NextBackoffS     float64    `json:"next_backoff_s"`      // Suspension a trip would start now
+}
+
+// SetPingBackoff sets the fixed circuit breaker backoff reported by /api/device/{ip}/breaker; call before Start
+func (hs *HealthServer) SetPingBackoff(backoff time.Duration) {
+	hs.pingBackoff = backoff
+}
+
+// deviceBreakerHandler serves the ping circuit breaker state and backoff escalation of a device
+func (hs *HealthServer) deviceBreakerHandler(w http.ResponseWriter, r *http.Request) {
+	ip := net.ParseIP(r.PathValue("ip"))
+	if ip == nil {
+		http.Error(w, "invalid device ip", http.StatusBadRequest)
+		return
+	}
+	if !requestToken(r).allows(ip.String()) {
+		http.Error(w, "device outside the token's networks", http.StatusForbidden)
+		return
+	}
+	now := time.Now()
+	breaker, found := hs.stateMgr.Breaker(ip.String(), hs.pingBackoff, now)
+	if !found {
+		http.Error(w, "device not found", http.StatusNotFound)
+		return
+	}
+
+	response := deviceBreakerResponse{
+		IP:               ip.String(),
+		Hostname:         breaker.Hostname,
+		State:            breakerClosed,
+		ConsecutiveFails: breaker.ConsecutiveFails,
+		WindowFailures:   breaker.WindowFailures,
+		BackoffLevel:     breaker.Escalation.Level,
+		NextBackoffS:     breaker.NextBackoff.Seconds(),
+	}
+	if breaker.SuspendedUntil.After(now) {
+		response.State = breakerOpen
+		response.SuspendedUntil = &breaker.SuspendedUntil
+		if !breaker.ProbeAt.IsZero() {
+			response.TestPingAt = &breaker.ProbeAt
+		}
+	}
+	if !breaker.Escalation.LastTrip.IsZero() {
+		response.LastTrip = &breaker.Escalation.LastTrip
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/state"
+)
+
+// TestDeviceBreakerHandler validates the reported circuit breaker state and backoff escalation
+func TestDeviceBreakerHandler(t *testing.T) {
+	mgr := state.NewManager(10)
+	mgr.SetBackoffSteps([]time.Duration{time.Minute, 5 * time.Minute}, time.Hour)
+	mgr.Add(state.Device{IP: "192.168.1.1", Hostname: "router1", LastSeen: time.Now()})
+	mgr.Add(state.Device{IP: "192.168.1.2", LastSeen: time.Now()})
+	mgr.ReportPingFail("192.168.1.1", 1, 5*time.Minute)
+
+	hs := &HealthServer{stateMgr: mgr}
+	hs.SetPingBackoff(5 * time.Minute)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/device/{ip}/breaker", hs.deviceBreakerHandler)
+
+	for path, want := range map[string]int{
+		"/api/device/192.168.1.3/breaker": http.StatusNotFound,
+		"/api/device/not-an-ip/breaker":   http.StatusBadRequest,
+		"/api/device/192.168.1.2/breaker": http.StatusOK,
+	} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("%s: expected %d, got %d", path, want, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/device/192.168.1.1/breaker", nil))
+	var response deviceBreakerResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if response.State != breakerOpen || response.SuspendedUntil == nil || response.LastTrip == nil {
+		t.Errorf("expected an open breaker with its suspension and trip, got %+v", response)
+	}
+	if response.BackoffLevel != 0 || response.NextBackoffS != 300 {
+		t.Errorf("expected level 0 with the 5m step next, got level %d and %vs", response.BackoffLevel, response.NextBackoffS)
+	}
+}
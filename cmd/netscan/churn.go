@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kljama/netscan/internal/state"
+)
+
+// churnCycles is the number of discovery cycles kept for /api/stats/churn (a day at a 5m discovery interval)
+const churnCycles = 288
+
+// churnCycle is the device churn of one discovery cycle: from the end of the previous sweep to the end of this one,
+// so devices pruned or evicted between sweeps are counted in the cycle that follows
+type churnCycle struct {
+	Start time.Time `json:"start"` // End of the previous sweep (daemon start for the first cycle)
+	End   time.Time `json:"end"`   // End of the cycle's sweep
+	state.Churn
+	Networks map[string]state.Churn `json:"networks,omitempty"` // Per network (label or CIDR); networks without churn are omitted
+}
+
+// churnResponse is the GET /api/stats/churn response body
+type churnResponse struct {
+	Totals   state.Churn            `json:"totals"`   // Since daemon start
+	Networks map[string]state.Churn `json:"networks"` // Totals per network (label or CIDR, "" outside every network)
+	Cycles   []churnCycle           `json:"cycles"`   // Oldest first
+}
+
+// churnTracker splits the state manager's churn counters into discovery cycles
+type churnTracker struct {
+	mu           sync.Mutex
+	stateMgr     *state.Manager
+	lastEnd      time.Time              // End of the previous cycle
+	lastTotals   state.Churn            // Counters at the end of the previous cycle
+	lastNetworks map[string]state.Churn // Per-network counters at the end of the previous cycle
+	cycles       []churnCycle           // Oldest first, at most churnCycles
+}
+
+// newChurnTracker starts counting cycles at now; churn before now (e.g. restored state) is part of the first cycle
+func newChurnTracker(stateMgr *state.Manager, now time.Time) *churnTracker {
+	return &churnTracker{stateMgr: stateMgr, lastEnd: now}
+}
+
+// record closes the current cycle at now and returns its churn
+func (t *churnTracker) record(now time.Time) churnCycle {
+	totals, networks := t.stateMgr.Churn()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	cycle := churnCycle{Start: t.lastEnd, End: now, Churn: totals.Sub(t.lastTotals)}
+	for network, churn := range networks {
+		delta := churn.Sub(t.lastNetworks[network])
+		if delta.Zero() {
+			continue
+		}
+		if cycle.Networks == nil {
+			cycle.Networks = make(map[string]state.Churn)
+		}
+		cycle.Networks[network] = delta
+	}
+	t.lastEnd, t.lastTotals, t.lastNetworks = now, totals, networks
+
+	if len(t.cycles) == churnCycles {
+		copy(t.cycles, t.cycles[1:])
+		t.cycles = t.cycles[:churnCycles-1]
+	}
+	t.cycles = append(t.cycles, cycle)
+	return cycle
+}
+
+// recent returns the last n cycles (all when n <= 0), oldest first
+func (t *churnTracker) recent(n int) []churnCycle {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if n <= 0 || n > len(t.cycles) {
+		n = len(t.cycles)
+	}
+	return append([]churnCycle{}, t.cycles[len(t.cycles)-n:]...)
+}
+
+// churnHandler serves device churn since startup and per discovery cycle, optionally limited to the last cycles (?cycles=12)
+func (hs *HealthServer) churnHandler(w http.ResponseWriter, r *http.Request) {
+	if hs.churn == nil {
+		http.Error(w, "churn statistics not available", http.StatusServiceUnavailable)
+		return
+	}
+	limit := 0
+	if raw := r.URL.Query().Get("cycles"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > churnCycles {
+			http.Error(w, "cycles must be between 1 and "+strconv.Itoa(churnCycles), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	totals, networks := hs.stateMgr.Churn()
+	w.Header().Set("Content-Type", "application/json")
+	response := churnResponse{Totals: totals, Networks: networks, Cycles: hs.churn.recent(limit)}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/state"
+)
+
+// TestChurnTracker verifies each cycle holds the churn since the previous one, per network, and the ring is bounded
+func TestChurnTracker(t *testing.T) {
+	stateMgr := state.NewManager(100)
+	stateMgr.SetNetworkResolver(func(ip string) string { return "lab" })
+	start := time.Now()
+	tracker := newChurnTracker(stateMgr, start)
+
+	stateMgr.AddDevice("192.168.1.10")
+	stateMgr.AddDevice("192.168.1.11")
+	first := tracker.record(start.Add(time.Minute))
+	if first.Discovered != 2 || !first.Start.Equal(start) || first.Networks["lab"].Discovered != 2 {
+		t.Errorf("unexpected first cycle: %+v", first)
+	}
+
+	stateMgr.RemoveWhere(state.RemovedStale, func(d state.Device) bool { return d.IP == "192.168.1.10" })
+	second := tracker.record(start.Add(2 * time.Minute))
+	if second.Discovered != 0 || second.Pruned != 1 || !second.Start.Equal(first.End) {
+		t.Errorf("unexpected second cycle: %+v", second)
+	}
+
+	quiet := tracker.record(start.Add(3 * time.Minute))
+	if !quiet.Zero() || quiet.Networks != nil {
+		t.Errorf("expected a cycle without churn, got %+v", quiet)
+	}
+
+	if cycles := tracker.recent(2); len(cycles) != 2 || !cycles[1].End.Equal(quiet.End) {
+		t.Errorf("expected the last two cycles, got %+v", cycles)
+	}
+	for i := 0; i < churnCycles; i++ {
+		tracker.record(start.Add(time.Duration(4+i) * time.Minute))
+	}
+	if cycles := tracker.recent(0); len(cycles) != churnCycles || cycles[0].End.Equal(first.End) {
+		t.Errorf("expected %d cycles with the oldest dropped, got %d", churnCycles, len(cycles))
+	}
+}
+
+// TestChurnHandler validates the cycles parameter and response shape
+func TestChurnHandler(t *testing.T) {
+	stateMgr := state.NewManager(100)
+	tracker := newChurnTracker(stateMgr, time.Now())
+	stateMgr.AddDevice("192.168.1.10")
+	tracker.record(time.Now())
+	tracker.record(time.Now())
+
+	hs := &HealthServer{stateMgr: stateMgr, churn: tracker}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/stats/churn", hs.churnHandler)
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantCycles int
+	}{
+		{"/api/stats/churn", http.StatusOK, 2},
+		{"/api/stats/churn?cycles=1", http.StatusOK, 1},
+		{"/api/stats/churn?cycles=0", http.StatusBadRequest, 0},
+		{"/api/stats/churn?cycles=many", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: expected status %d, got %d (%s)", tt.path, tt.wantStatus, rec.Code, rec.Body.String())
+			continue
+		}
+		if rec.Code != http.StatusOK {
+			continue
+		}
+		var resp churnResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("%s: failed to decode response: %v", tt.path, err)
+		}
+		if resp.Totals.Discovered != 1 || resp.Networks[""].Discovered != 1 || len(resp.Cycles) != tt.wantCycles {
+			t.Errorf("%s: unexpected response: %+v", tt.path, resp)
+		}
+	}
+
+	// Without a tracker the endpoint reports it is unavailable
+	hs.churn = nil
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/stats/churn", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without a tracker, got %d", rec.Code)
+	}
+}
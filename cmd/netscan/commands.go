@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/kljama/netscan/internal/config"
+)
+
+// runSubcommand dispatches CLI subcommands such as "netscan import telegraf ping.conf" or "netscan scan"
+// Returns handled=false when args are not a known subcommand and should be parsed as daemon flags
+func runSubcommand(args []string) (exitCode int, handled bool) {
+	if len(args) == 0 {
+		return 0, false
+	}
+
+	switch args[0] {
+	case "import":
+		return runImport(args[1:]), true
+	case "scan":
+		return runScan(args[1:]), true
+	case "validate":
+		return runValidate(args[1:]), true
+	case "schedule":
+		return runSchedule(args[1:]), true
+	case "bench":
+		return runBench(args[1:]), true
+	case "doctor":
+		return runDoctor(args[1:]), true
+	default:
+		return 0, false
+	}
+}
+
+// runImport converts configuration from other tools into a netscan config stanza
+// Usage: netscan import telegraf [-o output.yml] <telegraf.conf>
+func runImport(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: netscan import telegraf [-o output.yml] <telegraf.conf>")
+		return 2
+	}
+
+	switch args[0] {
+	case "telegraf":
+		return runImportTelegraf(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown import source %q (supported: telegraf)\n", args[0])
+		return 2
+	}
+}
+
+// runImportTelegraf parses Telegraf [[inputs.ping]] settings and prints the equivalent netscan stanza
+func runImportTelegraf(args []string) int {
+	fs := flag.NewFlagSet("import telegraf", flag.ContinueOnError)
+	output := fs.String("o", "", "Write the generated stanza to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: netscan import telegraf [-o output.yml] <telegraf.conf>")
+		return 2
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open telegraf config: %v\n", err)
+		return 1
+	}
+	defer f.Close()
+
+	imported, err := config.ImportTelegrafPing(f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to import telegraf config: %v\n", err)
+		return 1
+	}
+
+	stanza, err := imported.ToYAML()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to render netscan config: %v\n", err)
+		return 1
+	}
+
+	if *output == "" {
+		fmt.Print(stanza)
+	} else if err := os.WriteFile(*output, []byte(stanza), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write %s: %v\n", *output, err)
+		return 1
+	}
+
+	if len(imported.Skipped) > 0 {
+		fmt.Fprintf(os.Stderr, "warning: %d non-IP target(s) were not imported, see comments in output\n", len(imported.Skipped))
+	}
+	return 0
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package main
+
+import "time"
+
+// getCPUTime is not implemented on this platform and always returns 0
+func getCPUTime() time.Duration {
+	return 0
+}
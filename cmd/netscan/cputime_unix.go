@@ -0,0 +1,18 @@
+//go:build linux || darwin
+
+package main
+
+import (
+	"syscall"
+	"time"
+)
+
+// getCPUTime returns the user plus system CPU time consumed by the process (getrusage)
+// On failure it returns 0, which auto-tuning treats as unknown
+func getCPUTime() time.Duration {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano())
+}
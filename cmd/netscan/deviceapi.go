@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kljama/netscan/internal/monitoring"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// refreshTimeout bounds how long an API refresh waits for an SNMP rate limiter token
+const refreshTimeout = 30 * time.Second
+
+// deviceSNMPResponse is the GET /api/device/{ip}/snmp response body
+type deviceSNMPResponse struct {
+	IP        string            `json:"ip"`
+	Hostname  string            `json:"hostname"`
+	PolledAt  time.Time         `json:"polled_at"`
+	Refreshed bool              `json:"refreshed"` // true when refresh=true triggered a poll for this response
+	Values    []state.SNMPValue `json:"values"`
+}
+
+// deviceSNMPHandler serves the cached SNMP result for a device, optionally polling it first (refresh=true)
+func (hs *HealthServer) deviceSNMPHandler(w http.ResponseWriter, r *http.Request) {
+	ip := net.ParseIP(r.PathValue("ip"))
+	if ip == nil {
+		http.Error(w, "invalid device ip", http.StatusBadRequest)
+		return
+	}
+	if !requestToken(r).allows(ip.String()) {
+		http.Error(w, "device outside the token's networks", http.StatusForbidden)
+		return
+	}
+	device, found := hs.stateMgr.Get(ip.String())
+	if !found {
+		http.Error(w, "device not found", http.StatusNotFound)
+		return
+	}
+
+	refreshed := false
+	if refresh, _ := strconv.ParseBool(r.URL.Query().Get("refresh")); refresh {
+		if hs.snmpRefresher == nil {
+			http.Error(w, "refresh not available", http.StatusServiceUnavailable)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), refreshTimeout)
+		err := hs.snmpRefresher.Refresh(ctx, *device)
+		cancel()
+		switch {
+		case errors.Is(err, monitoring.ErrRefreshTooSoon):
+			w.Header().Set("Retry-After", strconv.Itoa(int(monitoring.RefreshMinInterval/time.Second)))
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		refreshed = true
+	}
+
+	result, found := hs.stateMgr.GetSNMPResult(ip.String())
+	if !found {
+		http.Error(w, "device not found", http.StatusNotFound)
+		return
+	}
+	if result == nil {
+		http.Error(w, "no SNMP result cached for device yet", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := deviceSNMPResponse{
+		IP:        ip.String(),
+		Hostname:  device.Hostname,
+		PolledAt:  result.PolledAt,
+		Refreshed: refreshed,
+		Values:    result.Values,
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
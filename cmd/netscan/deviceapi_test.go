@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/state"
+)
+
+// TestDeviceSNMPHandler validates cached results are served and missing data maps to the right status codes
+func TestDeviceSNMPHandler(t *testing.T) {
+	mgr := state.NewManager(10)
+	mgr.Add(state.Device{IP: "192.168.1.1", Hostname: "router1", LastSeen: time.Now()})
+	mgr.Add(state.Device{IP: "192.168.1.2", LastSeen: time.Now()})
+	polledAt := time.Now()
+	mgr.SetSNMPResult("192.168.1.1", &state.SNMPResult{
+		PolledAt: polledAt,
+		Values:   []state.SNMPValue{{Group: "system", Name: "sysName", Value: "router1", PolledAt: polledAt}},
+	})
+
+	hs := &HealthServer{stateMgr: mgr}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/device/{ip}/snmp", hs.deviceSNMPHandler)
+
+	tests := []struct {
+		path       string
+		wantStatus int
+	}{
+		{"/api/device/192.168.1.1/snmp", http.StatusOK},
+		{"/api/device/192.168.1.2/snmp", http.StatusNotFound},                        // Not polled yet
+		{"/api/device/192.168.1.3/snmp", http.StatusNotFound},                        // Unknown device
+		{"/api/device/not-an-ip/snmp", http.StatusBadRequest},                        // Invalid IP
+		{"/api/device/192.168.1.1/snmp?refresh=true", http.StatusServiceUnavailable}, // No refresher configured
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: expected status %d, got %d (%s)", tt.path, tt.wantStatus, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/device/192.168.1.1/snmp", nil))
+	var resp deviceSNMPResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Hostname != "router1" || resp.Refreshed || len(resp.Values) != 1 || resp.Values[0].Name != "sysName" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/kljama/netscan/internal/discovery"
+	"github.com/rs/zerolog/log"
+)
+
+// discoveryControl tracks the running discovery sweep and relays API requests to cancel it or start one
+// Sweeps are still started only by the main event loop, which drains scanRequests
+type discoveryControl struct {
+	mu      sync.Mutex
+	current *discovery.Progress      // Running sweep (nil when idle)
+	cancel  context.CancelFunc       // Cancels the running sweep
+	last    *discovery.Progress      // Most recently finished sweep
+	label   func(cidr string) string // Friendly network labels for status responses (nil = none)
+
+	scanRequests chan struct{} // On-demand scan requests (buffered, one pending at most)
+}
+
+// discoveryStatus is the GET /api/discovery response
+type discoveryStatus struct {
+	Running     bool                        `json:"running"`
+	ScanPending bool                        `json:"scan_pending"`     // An on-demand scan was requested and has not started yet
+	Current     *discovery.ProgressSnapshot `json:"current"`          // Running sweep (null when idle)
+	Last        *discovery.ProgressSnapshot `json:"last"`             // Last finished sweep (null before the first one ends)
+	Labels      map[string]string           `json:"labels,omitempty"` // Friendly labels of the networks above
+}
+
+// newDiscoveryControl creates an idle controller; label (optional) names networks in status responses
+func newDiscoveryControl(label func(cidr string) string) *discoveryControl {
+	return &discoveryControl{label: label, scanRequests: make(chan struct{}, 1)}
+}
+
+// begin registers a new sweep over networks and returns its cancellable context and progress tracker
+func (c *discoveryControl) begin(parent context.Context, networks []string) (context.Context, *discovery.Progress) {
+	ctx, cancel := context.WithCancel(parent)
+	progress := discovery.NewProgress(networks)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.current, c.cancel = progress, cancel
+	return ctx, progress
+}
+
+// finish records the end of the running sweep; cancelled means it stopped before probing every target
+func (c *discoveryControl) finish(cancelled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.current == nil {
+		return
+	}
+	c.current.Finish(cancelled)
+	c.cancel()
+	c.last, c.current, c.cancel = c.current, nil, nil
+}
+
+// cancelSweep stops the running sweep; returns false when no sweep is running
+func (c *discoveryControl) cancelSweep() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.current == nil {
+		return false
+	}
+	c.cancel()
+	return true
+}
+
+// requestScan asks the event loop for an immediate sweep; returns false when a sweep is already running
+// A request made while another one is pending is merged into it
+func (c *discoveryControl) requestScan() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.current != nil {
+		return false
+	}
+	select {
+	case c.scanRequests <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// running returns the progress of the running sweep (nil when idle)
+func (c *discoveryControl) running() *discovery.ProgressSnapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.current == nil {
+		return nil
+	}
+	snap := c.current.Snapshot()
+	return &snap
+}
+
+// status returns the running and last finished sweeps
+func (c *discoveryControl) status() discoveryStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status := discoveryStatus{Running: c.current != nil, ScanPending: len(c.scanRequests) > 0}
+	if c.current != nil {
+		snap := c.current.Snapshot()
+		status.Current = &snap
+	}
+	if c.last != nil {
+		snap := c.last.Snapshot()
+		status.Last = &snap
+	}
+	for _, snap := range []*discovery.ProgressSnapshot{status.Current, status.Last} {
+		if snap == nil || c.label == nil {
+			continue
+		}
+		for _, network := range snap.Networks {
+			if label := c.label(network); label != "" {
+				if status.Labels == nil {
+					status.Labels = make(map[string]string)
+				}
+				status.Labels[network] = label
+			}
+		}
+	}
+	return status
+}
+
+// discoveryHandler serves the running sweep's progress and the last finished sweep
+func (hs *HealthServer) discoveryHandler(w http.ResponseWriter, r *http.Request) {
+	if hs.discovery == nil {
+		http.Error(w, "discovery control not available", http.StatusServiceUnavailable)
+		return
+	}
+	writeDiscoveryStatus(w, http.StatusOK, hs.discovery.status())
+}
+
+// discoveryScanHandler starts an on-demand sweep; 409 while a sweep is running
+func (hs *HealthServer) discoveryScanHandler(w http.ResponseWriter, r *http.Request) {
+	if hs.discovery == nil {
+		http.Error(w, "discovery control not available", http.StatusServiceUnavailable)
+		return
+	}
+	if !hs.discovery.requestScan() {
+		http.Error(w, "a discovery sweep is already running", http.StatusConflict)
+		return
+	}
+	log.Info().Str("remote", r.RemoteAddr).Msg("On-demand discovery scan requested")
+	writeDiscoveryStatus(w, http.StatusAccepted, hs.discovery.status())
+}
+
+// discoveryCancelHandler cancels the running sweep; 409 when no sweep is running
+func (hs *HealthServer) discoveryCancelHandler(w http.ResponseWriter, r *http.Request) {
+	if hs.discovery == nil {
+		http.Error(w, "discovery control not available", http.StatusServiceUnavailable)
+		return
+	}
+	if !hs.discovery.cancelSweep() {
+		http.Error(w, "no discovery sweep is running", http.StatusConflict)
+		return
+	}
+	log.Info().Str("remote", r.RemoteAddr).Msg("Discovery sweep cancellation requested")
+	writeDiscoveryStatus(w, http.StatusAccepted, hs.discovery.status())
+}
+
+// writeDiscoveryStatus writes status as JSON with the given HTTP status code
+func writeDiscoveryStatus(w http.ResponseWriter, code int, status discoveryStatus) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		log.Debug().Err(err).Msg("Failed to write discovery status")
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestDiscoveryControlAPI validates scan requests, cancellation and the reported status
+func TestDiscoveryControlAPI(t *testing.T) {
+	hs := &HealthServer{}
+	rec := httptest.NewRecorder()
+	hs.discoveryHandler(rec, httptest.NewRequest(http.MethodGet, "/api/discovery", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without discovery control, got %d", rec.Code)
+	}
+
+	control := newDiscoveryControl(func(cidr string) string {
+		if cidr == "10.0.0.0/24" {
+			return "fra1-servers"
+		}
+		return ""
+	})
+	hs.SetDiscoveryControl(control)
+
+	// Nothing to cancel while idle
+	rec = httptest.NewRecorder()
+	hs.discoveryCancelHandler(rec, httptest.NewRequest(http.MethodPost, "/api/discovery/cancel", nil))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 cancelling while idle, got %d", rec.Code)
+	}
+
+	// An on-demand scan is queued for the event loop; a second request merges into it
+	for i := 0; i < 2; i++ {
+		rec = httptest.NewRecorder()
+		hs.discoveryScanHandler(rec, httptest.NewRequest(http.MethodPost, "/api/discovery/scan", nil))
+		if rec.Code != http.StatusAccepted {
+			t.Errorf("expected 202 requesting a scan, got %d", rec.Code)
+		}
+	}
+	if len(control.scanRequests) != 1 || !control.status().ScanPending {
+		t.Fatalf("expected one pending scan request, got %d", len(control.scanRequests))
+	}
+	<-control.scanRequests
+
+	// The event loop starts the sweep; scans are refused and cancellation stops its context
+	ctx, _ := control.begin(context.Background(), []string{"10.0.0.0/24"})
+	if running := control.running(); running == nil || running.Networks[0] != "10.0.0.0/24" {
+		t.Errorf("expected the running sweep's progress for /health, got %+v", running)
+	}
+	rec = httptest.NewRecorder()
+	hs.discoveryScanHandler(rec, httptest.NewRequest(http.MethodPost, "/api/discovery/scan", nil))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 requesting a scan while one runs, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	hs.discoveryCancelHandler(rec, httptest.NewRequest(http.MethodPost, "/api/discovery/cancel", nil))
+	if rec.Code != http.StatusAccepted || ctx.Err() == nil {
+		t.Errorf("expected 202 and a cancelled sweep context, got %d (ctx err %v)", rec.Code, ctx.Err())
+	}
+	control.finish(ctx.Err() != nil)
+
+	rec = httptest.NewRecorder()
+	hs.discoveryHandler(rec, httptest.NewRequest(http.MethodGet, "/api/discovery", nil))
+	var status discoveryStatus
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatalf("failed to decode status: %v", err)
+	}
+	if status.Running || status.Current != nil || status.Last == nil || !status.Last.Cancelled || status.Last.FinishedAt == nil {
+		t.Errorf("expected an idle controller with a cancelled last sweep, got %+v", status)
+	}
+	if status.Labels["10.0.0.0/24"] != "fra1-servers" {
+		t.Errorf("expected the network label in the status, got %v", status.Labels)
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/discovery"
+	"github.com/kljama/netscan/internal/influx"
+	"github.com/kljama/netscan/internal/logger"
+	"github.com/kljama/netscan/internal/monitoring"
+	"github.com/kljama/netscan/internal/snmp"
+	"github.com/kljama/netscan/internal/validate"
+)
+
+const doctorUsage = "usage: netscan doctor [-config config.yml] [-vars vars.yml] [-ping 127.0.0.1] [-snmp host] [-format table|json]"
+
+// doctorCheckTimeout bounds the SNMP and each InfluxDB check
+const doctorCheckTimeout = 10 * time.Second
+
+// Check outcomes of "netscan doctor"
+const (
+	doctorOK   = "ok"
+	doctorWarn = "warn"
+	doctorFail = "fail"
+	doctorSkip = "skip"
+)
+
+// doctorCheck is one line of the readiness report
+type doctorCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // ok, warn, fail or skip
+	Detail string `json:"detail"`
+}
+
+// doctorReport is the readiness report printed by "netscan doctor"
+type doctorReport struct {
+	Ready  bool          `json:"ready"` // No check failed
+	Checks []doctorCheck `json:"checks"`
+}
+
+// add appends a check; a failed check makes the report not ready
+func (r *doctorReport) add(name, status, detail string) {
+	r.Checks = append(r.Checks, doctorCheck{Name: name, Status: status, Detail: detail})
+	if status == doctorFail {
+		r.Ready = false
+	}
+}
+
+// runDoctor checks the permissions and connectivity the daemon needs (config, ICMP sockets, a test ping, an SNMP get
+// and InfluxDB credentials and buckets) and prints a readiness report. Exits 1 when any check fails
+func runDoctor(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	configPath := fs.String("config", "config.yml", "Path to configuration file")
+	varsPath := fs.String("vars", "", "Per-site variables file overriding the config's vars block")
+	pingTarget := fs.String("ping", "127.0.0.1", "Host sent a test ping")
+	snmpTarget := fs.String("snmp", "", "Device queried for sysName and sysDescr with the configured credentials (empty = skip)")
+	format := fs.String("format", "table", "Output format: table or json")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, doctorUsage)
+		return 2
+	}
+	switch *format {
+	case "table", "json":
+	default:
+		fmt.Fprintf(os.Stderr, "unsupported format %q (table, json)\n", *format)
+		return 2
+	}
+
+	// Logs go to stderr so stdout only carries the report
+	logger.SetupStderr(false)
+
+	report := runDoctorChecks(*configPath, *varsPath, *pingTarget, *snmpTarget)
+	if err := writeDoctorReport(os.Stdout, *format, report); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write report: %v\n", err)
+		return 1
+	}
+	if !report.Ready {
+		return 1
+	}
+	return 0
+}
+
+// runDoctorChecks runs every check in order; checks that need the config are skipped when it cannot be loaded
+func runDoctorChecks(configPath, varsPath, pingTarget, snmpTarget string) doctorReport {
+	report := doctorReport{Ready: true}
+
+	cfg, err := config.LoadConfigWithVars(configPath, varsPath)
+	if err != nil {
+		report.add("config", doctorFail, err.Error())
+	} else {
+		result := config.CheckConfig(cfg)
+		if err := discovery.CheckDiscoverers(cfg.DiscoveryMethods); err != nil {
+			result.Errors = append(result.Errors, err)
+		}
+		switch {
+		case !result.OK():
+			report.add("config", doctorFail, fmt.Sprintf("%d error(s), first: %v (run netscan validate for all)", len(result.Errors), result.Errors[0]))
+		case len(result.Warnings) > 0:
+			report.add("config", doctorWarn, fmt.Sprintf("%d warning(s), first: %s", len(result.Warnings), strings.TrimPrefix(result.Warnings[0], "WARNING: ")))
+		default:
+			report.add("config", doctorOK, configPath+" is valid")
+		}
+	}
+
+	rawErr, udpErr := monitoring.CheckICMPSockets()
+	switch {
+	case rawErr == nil:
+		report.add("icmp_sockets", doctorOK, "raw ICMP sockets permitted")
+	case udpErr == nil:
+		report.add("icmp_sockets", doctorWarn, fmt.Sprintf("raw ICMP sockets not permitted (%v), pings use unprivileged UDP sockets; "+
+			"traceroute needs root or CAP_NET_RAW", rawErr))
+	default:
+		report.add("icmp_sockets", doctorFail, fmt.Sprintf("no ICMP sockets permitted: run as root or grant CAP_NET_RAW (%v), "+
+			"or allow UDP ICMP sockets with net.ipv4.ping_group_range (%v)", rawErr, udpErr))
+	}
+
+	if cfg == nil {
+		report.add("ping", doctorSkip, "config not loaded")
+		report.add("snmp", doctorSkip, "config not loaded")
+		report.add("influxdb", doctorSkip, "config not loaded")
+		return report
+	}
+
+	status, detail := doctorPing(cfg, pingTarget)
+	report.add("ping", status, detail)
+
+	status, detail = doctorSNMP(cfg, snmpTarget)
+	report.add("snmp", status, detail)
+
+	if cfg.InfluxDB.URL == "" {
+		report.add("influxdb", doctorSkip, "influxdb.url not set")
+		return report
+	}
+	status, detail = doctorInfluxDB(cfg.InfluxDB.Target())
+	report.add("influxdb", status, detail)
+	for _, mirror := range cfg.InfluxDB.Mirrors {
+		status, detail = doctorInfluxDB(mirror.Target())
+		report.add("influxdb_mirror:"+mirror.Name, status, detail)
+	}
+	return report
+}
+
+// doctorPing sends one echo request to target with the configured ICMP mode, source address and timeout
+// Loopback and link-local targets are allowed, so the default 127.0.0.1 works without allow_loopback
+func doctorPing(cfg *config.Config, target string) (status, detail string) {
+	ip, err := resolveIPv4(target)
+	if err != nil {
+		return doctorFail, err.Error()
+	}
+	icmpSetup, err := setupPinging(cfg)
+	if err != nil {
+		return doctorFail, err.Error()
+	}
+	defer icmpSetup.close()
+
+	policy := config.AddressPolicy{AllowLoopback: true, AllowLinkLocal: true}
+	stats, err := monitoring.Probe(icmpSetup.engine, policy, ip, 1, cfg.PingTimeout)
+	if err != nil {
+		return doctorFail, fmt.Sprintf("ping %s failed: %v", ip, err)
+	}
+	if stats.PacketsRecv == 0 {
+		return doctorFail, fmt.Sprintf("no reply from %s within %v", ip, cfg.PingTimeout)
+	}
+	return doctorOK, fmt.Sprintf("reply from %s in %.3f ms", ip, float64(stats.AvgRtt.Microseconds())/1000)
+}
+
+// doctorSNMP queries sysName and sysDescr of target with the SNMP settings the daemon would use for it
+func doctorSNMP(cfg *config.Config, target string) (status, detail string) {
+	if target == "" {
+		return doctorSkip, "pass -snmp <host> to test SNMP credentials"
+	}
+	ip, err := resolveIPv4(target)
+	if err != nil {
+		return doctorFail, err.Error()
+	}
+	source, err := cfg.SourceAddress()
+	if err != nil {
+		return doctorFail, err.Error()
+	}
+	localAddr := ""
+	if source != "" {
+		localAddr = net.JoinHostPort(source, "0")
+	}
+	snmpConfig := cfg.SNMPResolver()(ip)
+
+	ctx, cancel := context.WithTimeout(context.Background(), doctorCheckTimeout)
+	defer cancel()
+	client, err := snmp.Dial(ctx, ip, snmp.NewOptions(snmpConfig, localAddr))
+	if err != nil {
+		return doctorFail, fmt.Sprintf("connect to %s failed: %v", ip, err)
+	}
+	defer client.Close()
+	variables, err := client.GetWithFallback(ctx, []string{snmp.OIDSysName, snmp.OIDSysDescr})
+	if err != nil || len(variables) < 2 {
+		return doctorFail, fmt.Sprintf("no SNMP answer from %s:%d (%v); check the community, snmp.port and the device's ACLs",
+			ip, snmpConfig.Port, err)
+	}
+	sysName, _ := validate.SNMPString(variables[0].Value, "sysName", snmpConfig.MaxStringLength)
+	sysDescr, _ := validate.SNMPString(variables[1].Value, "sysDescr", snmpConfig.MaxStringLength)
+	return doctorOK, fmt.Sprintf("%s answered: sysName=%s, sysDescr=%s", ip, orDash(sysName), orDash(firstLine(sysDescr)))
+}
+
+// doctorInfluxDB checks the health, credentials and buckets of one InfluxDB destination
+func doctorInfluxDB(target config.InfluxDBTarget) (status, detail string) {
+	ctx, cancel := context.WithTimeout(context.Background(), doctorCheckTimeout)
+	defer cancel()
+	if err := influx.CheckTarget(ctx, target); err != nil {
+		return doctorFail, fmt.Sprintf("%s: %v", target.URL, err)
+	}
+	kind := "buckets"
+	if target.V1() {
+		kind = "databases"
+	}
+	return doctorOK, fmt.Sprintf("%s accepts writes to %s %q and %q", target.URL, kind, target.Bucket, target.HealthBucket)
+}
+
+// resolveIPv4 returns host if it is an IP address, otherwise its first IPv4 address
+func resolveIPv4(host string) (string, error) {
+	if net.ParseIP(host) != nil {
+		return host, nil
+	}
+	addrs, err := net.LookupIP(host)
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve %s: %v", host, err)
+	}
+	for _, addr := range addrs {
+		if v4 := addr.To4(); v4 != nil {
+			return v4.String(), nil
+		}
+	}
+	return "", fmt.Errorf("%s has no IPv4 address", host)
+}
+
+// writeDoctorReport prints the checks as an aligned table followed by a verdict line, or as JSON
+func writeDoctorReport(w io.Writer, format string, report doctorReport) error {
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tSTATUS\tDETAIL")
+	for _, check := range report.Checks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", check.Name, strings.ToUpper(check.Status), check.Detail)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if report.Ready {
+		_, err := fmt.Fprintln(w, "\nReady: netscan has the permissions and connectivity it needs")
+		return err
+	}
+	_, err := fmt.Fprintln(w, "\nNot ready: fix the failed checks above")
+	return err
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestWriteDoctorReport validates the table with its verdict line and the JSON report
+func TestWriteDoctorReport(t *testing.T) {
+	report := doctorReport{Ready: true}
+	report.add("icmp_sockets", doctorWarn, "raw ICMP sockets not permitted")
+	report.add("snmp", doctorSkip, "pass -snmp <host> to test SNMP credentials")
+
+	var buf bytes.Buffer
+	if err := writeDoctorReport(&buf, "table", report); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 || !strings.HasPrefix(lines[0], "CHECK") || !strings.Contains(lines[1], "WARN") {
+		t.Errorf("unexpected table:\n%s", buf.String())
+	}
+	if !strings.HasPrefix(lines[4], "Ready:") {
+		t.Errorf("expected a ready verdict with only warnings and skips, got %q", lines[4])
+	}
+
+	report.add("influxdb", doctorFail, "credentials rejected")
+	if report.Ready {
+		t.Fatal("expected a failed check to make the report not ready")
+	}
+	buf.Reset()
+	if err := writeDoctorReport(&buf, "table", report); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "Not ready:") {
+		t.Errorf("expected a not-ready verdict, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := writeDoctorReport(&buf, "json", report); err != nil {
+		t.Fatal(err)
+	}
+	var decoded doctorReport
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if decoded.Ready || len(decoded.Checks) != 3 || decoded.Checks[2].Status != doctorFail {
+		t.Errorf("unexpected JSON report: %+v", decoded)
+	}
+}
+
+// TestDoctorChecksWithoutConfig verifies checks needing the config are skipped when it cannot be loaded
+func TestDoctorChecksWithoutConfig(t *testing.T) {
+	report := runDoctorChecks(filepath.Join(t.TempDir(), "missing.yml"), "", "127.0.0.1", "")
+	if report.Ready {
+		t.Error("expected a missing config to make the report not ready")
+	}
+	status := make(map[string]string)
+	for _, check := range report.Checks {
+		status[check.Name] = check.Status
+	}
+	if status["config"] != doctorFail {
+		t.Errorf("expected the config check to fail, got %q", status["config"])
+	}
+	for _, name := range []string{"ping", "snmp", "influxdb"} {
+		if status[name] != doctorSkip {
+			t.Errorf("expected %s to be skipped, got %q", name, status[name])
+		}
+	}
+	if _, ok := status["icmp_sockets"]; !ok {
+		t.Error("expected the ICMP socket check to run without a config")
+	}
+}
+
+// TestRunDoctorUsage validates exit codes for usage errors
+func TestRunDoctorUsage(t *testing.T) {
+	if code := runDoctor([]string{"extra"}); code != 2 {
+		t.Errorf("expected exit code 2 for a positional argument, got %d", code)
+	}
+	if code := runDoctor([]string{"-format", "csv"}); code != 2 {
+		t.Errorf("expected exit code 2 for an unsupported format, got %d", code)
+	}
+}
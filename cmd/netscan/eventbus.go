@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/events"
+	"github.com/kljama/netscan/internal/monitoring"
+	"github.com/kljama/netscan/internal/notify"
+	"github.com/kljama/netscan/internal/output"
+	"github.com/kljama/netscan/internal/state"
+	"github.com/rs/zerolog/log"
+)
+
+// startSubscriber consumes a bus subscription on its own goroutine until the bus is closed
+func startSubscriber(bus *events.Bus, name string, wg *sync.WaitGroup, handle func(events.Event)) {
+	sub := bus.Subscribe(name, events.DefaultBuffer)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		// Panic recovery for event subscriber goroutine (handler panics are recovered per event)
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error().
+					Str("subscriber", name).
+					Interface("panic", r).
+					Msg("Event subscriber panic recovered")
+			}
+		}()
+		sub.Consume(handle)
+	}()
+}
+
+// logEvent is the log subscriber: one log line per event, in publish order
+func logEvent(ev events.Event) {
+	switch payload := ev.Payload.(type) {
+	case state.StateEvent:
+		log.Info().
+			Uint64("seq", ev.Seq).
+			Str("ip", ev.IP).
+			Str("state", payload.State).
+			Str("previous", payload.Previous).
+			Int("failures", payload.Failures).
+			Dur("previous_duration", payload.PreviousDuration()).
+			Msg("Device state changed")
+	case events.Suspension:
+		// The pinger already logs the trip at warn level
+		log.Debug().
+			Uint64("seq", ev.Seq).
+			Str("ip", ev.IP).
+			Time("until", payload.Until).
+			Msg("Device pinging suspended by circuit breaker")
+	case events.Quarantine:
+		log.Warn().
+			Uint64("seq", ev.Seq).
+			Str("ip", ev.IP).
+			Str("hostname", ev.Hostname).
+			Int("trips", payload.Trips).
+			Time("first_trip", payload.FirstTrip).
+			Msg("Device quarantined after repeated circuit breaker trips, review it on /api/quarantine")
+	case events.Reboot:
+		log.Warn().
+			Uint64("seq", ev.Seq).
+			Str("ip", ev.IP).
+			Str("hostname", ev.Hostname).
+			Time("rebooted_at", payload.RebootedAt).
+			Float64("uptime_s", payload.UpTimeS).
+			Msg("Device rebooted (sysUpTime went backwards)")
+	case events.DeviceChange:
+		fields := make([]string, len(payload.Changes))
+		for i, change := range payload.Changes {
+			fields[i] = change.Field
+		}
+		log.Info().
+			Uint64("seq", ev.Seq).
+			Str("ip", ev.IP).
+			Str("hostname", ev.Hostname).
+			Strs("fields", fields).
+			Msg("Device info changed")
+	case events.Discovery:
+		if payload.Source == events.SourceARP {
+			log.Info().
+				Uint64("seq", ev.Seq).
+				Str("ip", ev.IP).
+				Str("mac", payload.MAC).
+				Str("interface", payload.Interface).
+				Msg("New device seen in ARP traffic, performing initial SNMP scan")
+		} else {
+			log.Info().
+				Uint64("seq", ev.Seq).
+				Str("ip", ev.IP).
+				Msg("New device found, performing initial SNMP scan")
+		}
+	case events.CheckChange:
+		log.Info().
+			Uint64("seq", ev.Seq).
+			Str("ip", ev.IP).
+			Str("check", payload.Check).
+			Bool("healthy", payload.Healthy).
+			Strs("failed", payload.Failed).
+			Msg("Composite check changed")
+	case events.LatencyAlert:
+		entry := log.Warn()
+		if payload.Level == config.LatencyLevelOK {
+			entry = log.Info()
+		}
+		entry.
+			Uint64("seq", ev.Seq).
+			Str("ip", ev.IP).
+			Str("threshold", payload.Threshold).
+			Str("level", payload.Level).
+			Str("previous", payload.Previous).
+			Float64("rtt_ms", payload.RTTMs).
+			Float64("limit_ms", payload.LimitMs).
+			Msg("Latency threshold level changed")
+	case events.ScanSummary:
+		log.Info().
+			Uint64("seq", ev.Seq).
+			Int("devices_found", payload.Found).
+			Int("new_devices", payload.NewDevices).
+			Float64("duration_s", payload.DurationS).
+			Msg("Discovery completed")
+	case events.SinkError:
+		log.Error().
+			Uint64("seq", ev.Seq).
+			Str("ip", ev.IP).
+			Str("sink", payload.Sink).
+			Str("event", payload.Event).
+			Str("error", payload.Error).
+			Msg("Failed to write event")
+	}
+}
+
+// eventSinkWriter is the sink subscriber: writes device events to the result sinks
+// Failed writes are published back to the bus as sink_error events
+type eventSinkWriter struct {
+	bus       *events.Bus
+	results   output.Sink
+	stream    *output.StreamWriter // NDJSON stream for discovered records (nil without -output)
+	publisher *output.Publisher    // NATS/Kafka publisher for discovered records (nil without publish)
+}
+
+// handle writes one event
+func (s *eventSinkWriter) handle(ev events.Event) {
+	var err error
+	switch payload := ev.Payload.(type) {
+	case state.StateEvent:
+		err = s.results.WriteStateChange(ev.IP, ev.Hostname, payload.State, payload.Previous, payload.Failures, payload.PreviousDuration())
+	case events.LatencyAlert:
+		err = s.results.WriteLatencyAlert(ev.IP, ev.Hostname, payload.Threshold, payload.Level, payload.Previous,
+			time.Duration(payload.RTTMs*float64(time.Millisecond)), time.Duration(payload.LimitMs*float64(time.Millisecond)), payload.Percentile)
+	case events.Discovery:
+		if s.stream != nil {
+			err = s.stream.WriteDiscovered(ev.IP)
+		}
+		if s.publisher != nil {
+			if publishErr := s.publisher.WriteDiscovered(ev.IP); err == nil {
+				err = publishErr
+			}
+		}
+	}
+	if err != nil {
+		s.bus.Publish(events.Event{
+			Type:    events.TypeSinkError,
+			IP:      ev.IP,
+			Payload: events.SinkError{Sink: "results", Event: ev.Type, Error: err.Error()},
+		})
+	}
+}
+
+// notifyEvent returns the notification subscriber, which queues notifiable events on the notifier
+func notifyEvent(notifier *notify.Notifier) func(events.Event) {
+	return func(ev events.Event) {
+		if event, ok := notify.FromBusEvent(ev); ok {
+			notifier.Notify(event)
+		}
+	}
+}
+
+// traceEvent returns the traceroute subscriber, which traces devices that go down or cross a latency
+// threshold's warning or critical limit, as selected by traceroute.triggers
+func traceEvent(ctx context.Context, tracer *monitoring.Tracer, cfg config.TracerouteConfig) func(events.Event) {
+	return func(ev events.Event) {
+		switch payload := ev.Payload.(type) {
+		case state.StateEvent:
+			if payload.State == state.ReachabilityDown && cfg.Wants(config.TracerouteOnDown) {
+				tracer.Trigger(ctx, ev.IP, ev.Hostname, config.TracerouteOnDown)
+			}
+		case events.LatencyAlert:
+			if payload.Level != config.LatencyLevelOK && cfg.Wants(config.TracerouteOnLatency) {
+				tracer.Trigger(ctx, ev.IP, ev.Hostname, config.TracerouteOnLatency)
+			}
+		}
+	}
+}
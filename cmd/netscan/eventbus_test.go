@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/events"
+	"github.com/kljama/netscan/internal/output"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// failingStateSink fails every state change write (other methods are not used by the sink subscriber)
+type failingStateSink struct {
+	output.Sink
+}
+
+func (failingStateSink) WriteStateChange(ip, hostname, newState, previous string, failures int, previousDuration time.Duration) error {
+	return errors.New("influxdb unavailable")
+}
+
+// TestEventSinkWriterPublishesErrors validates that failed sink writes come back as sink_error events
+func TestEventSinkWriterPublishesErrors(t *testing.T) {
+	bus := events.New()
+	sub := bus.Subscribe("test", 10)
+	writer := &eventSinkWriter{bus: bus, results: failingStateSink{}}
+
+	writer.handle(events.Event{Type: events.TypeDeviceState, IP: "192.168.1.1", Payload: state.StateEvent{IP: "192.168.1.1", State: state.ReachabilityDown}})
+	writer.handle(events.Event{Type: events.TypeDeviceDiscovered, IP: "192.168.1.2", Payload: events.Discovery{Source: events.SourceSweep}})
+	bus.Close()
+
+	var got []events.Event
+	for ev := range sub.Events() {
+		got = append(got, ev)
+	}
+	if len(got) != 1 || got[0].Type != events.TypeSinkError || got[0].IP != "192.168.1.1" {
+		t.Fatalf("expected one sink_error event for 192.168.1.1, got %+v", got)
+	}
+	if payload := got[0].Payload.(events.SinkError); payload.Event != events.TypeDeviceState || payload.Error != "influxdb unavailable" {
+		t.Errorf("unexpected sink error payload %+v", payload)
+	}
+}
+
+// TestEventsStreamHandler validates server-sent events, the type filter and network-scoped tokens
+func TestEventsStreamHandler(t *testing.T) {
+	bus := events.New()
+	hs := &HealthServer{stateMgr: state.NewManager(10), eventBus: bus}
+	auth := newAPIAuth(newBearerAuth([]config.APITokenConfig{
+		{Name: "admin", Token: "admin-token-0123456789"},
+		{Name: "branch", Token: "branch-token-0123456789", Networks: []string{"10.1.0.0/16"}},
+	}))
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/events/stream", hs.eventsStreamHandler)
+	server := httptest.NewServer(apiMiddleware(newAPILimiter(0, 0), auth, mux))
+	defer server.Close()
+
+	open := func(path, token string) (*bufio.Reader, func()) {
+		ctx, cancel := context.WithCancel(context.Background())
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+			t.Fatalf("%s: unexpected response %d %q", path, resp.StatusCode, resp.Header.Get("Content-Type"))
+		}
+		return bufio.NewReader(resp.Body), func() { cancel(); resp.Body.Close() }
+	}
+	// next returns the event type and data of the next server-sent event
+	next := func(r *bufio.Reader) (string, string) {
+		var eventType, data string
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				t.Fatalf("failed to read stream: %v", err)
+			}
+			line = strings.TrimRight(line, "\n")
+			switch {
+			case line == "" && eventType != "":
+				return eventType, data
+			case strings.HasPrefix(line, "event: "):
+				eventType = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}
+
+	all, closeAll := open("/api/events/stream", "admin-token-0123456789")
+	defer closeAll()
+	branch, closeBranch := open("/api/events/stream", "branch-token-0123456789")
+	defer closeBranch()
+	checks, closeChecks := open("/api/events/stream?type=composite_check", "admin-token-0123456789")
+	defer closeChecks()
+
+	bus.Publish(events.Event{Type: events.TypeScanCompleted, Payload: events.ScanSummary{Found: 2}})
+	bus.Publish(events.Event{Type: events.TypeDeviceState, IP: "192.168.1.1", Payload: state.StateEvent{IP: "192.168.1.1", State: state.ReachabilityDown}})
+	bus.Publish(events.Event{Type: events.TypeCompositeCheck, IP: "10.1.0.5", Payload: events.CheckChange{Check: "uplink", Healthy: true}})
+
+	for _, want := range []string{events.TypeScanCompleted, events.TypeDeviceState, events.TypeCompositeCheck} {
+		if got, _ := next(all); got != want {
+			t.Errorf("admin stream: expected %s, got %s", want, got)
+		}
+	}
+	if got, data := next(branch); got != events.TypeCompositeCheck || !strings.Contains(data, `"ip":"10.1.0.5"`) || !strings.Contains(data, `"seq":3`) {
+		t.Errorf("branch stream: expected only the 10.1.0.5 check event, got %s %s", got, data)
+	}
+	if got, _ := next(checks); got != events.TypeCompositeCheck {
+		t.Errorf("filtered stream: expected composite_check, got %s", got)
+	}
+
+	hs.eventBus = nil
+	rec := httptest.NewRecorder()
+	hs.eventsStreamHandler(rec, httptest.NewRequest(http.MethodGet, "/api/events/stream", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without an event bus, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kljama/netscan/internal/state"
+)
+
+// Limits for GET /api/events (the state manager keeps the last 1000 transitions)
+const (
+	defaultEventsLimit = 100
+	maxEventsLimit     = 1000
+)
+
+// eventsResponse is the GET /api/events response body
+type eventsResponse struct {
+	DevicesDown int                `json:"devices_down"`
+	Events      []state.StateEvent `json:"events"` // Newest first
+}
+
+// eventsHandler serves recent device up/down transitions, optionally for one device (?ip=) and limited (?limit=)
+// Network-scoped API tokens only see events and down devices inside their networks
+func (hs *HealthServer) eventsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	token := requestToken(r)
+
+	ip := ""
+	if raw := query.Get("ip"); raw != "" {
+		parsed := net.ParseIP(raw)
+		if parsed == nil {
+			http.Error(w, "invalid device ip", http.StatusBadRequest)
+			return
+		}
+		ip = parsed.String()
+		if !token.allows(ip) {
+			http.Error(w, "device outside the token's networks", http.StatusForbidden)
+			return
+		}
+	}
+
+	limit := defaultEventsLimit
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxEventsLimit {
+			http.Error(w, "limit must be between 1 and 1000", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	var response eventsResponse
+	if !token.scoped() {
+		response.DevicesDown = hs.stateMgr.GetDownCount()
+		response.Events = hs.stateMgr.RecentStateEvents(ip, limit)
+	} else {
+		for _, dev := range hs.stateMgr.GetAll() {
+			if dev.Reachability == state.ReachabilityDown && token.allows(dev.IP) {
+				response.DevicesDown++
+			}
+		}
+		// Filter the full event log so the limit applies to the events the token can see
+		response.Events = make([]state.StateEvent, 0, limit)
+		for _, ev := range hs.stateMgr.RecentStateEvents(ip, maxEventsLimit) {
+			if len(response.Events) == limit {
+				break
+			}
+			if token.allows(ev.IP) {
+				response.Events = append(response.Events, ev)
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// eventsStreamKeepalive is the interval of comment lines that keep idle event streams open through proxies
+const eventsStreamKeepalive = 30 * time.Second
+
+// eventsStreamHandler streams live bus events as server-sent events until the client disconnects
+// ?type= limits the stream to a comma-separated list of event types
+// Network-scoped API tokens only receive events of devices inside their networks
+func (hs *HealthServer) eventsStreamHandler(w http.ResponseWriter, r *http.Request) {
+	if hs.eventBus == nil {
+		http.Error(w, "event stream not available", http.StatusServiceUnavailable)
+		return
+	}
+	token := requestToken(r)
+	var types map[string]bool
+	if raw := r.URL.Query().Get("type"); raw != "" {
+		types = make(map[string]bool)
+		for _, t := range strings.Split(raw, ",") {
+			types[strings.TrimSpace(t)] = true
+		}
+	}
+
+	sub := hs.eventBus.Subscribe("api:"+r.RemoteAddr, 256)
+	defer hs.eventBus.Unsubscribe(sub)
+
+	controller := http.NewResponseController(w)
+	// The stream outlives health_write_timeout; the keepalive detects dead clients instead
+	controller.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	controller.Flush()
+
+	keepalive := time.NewTicker(eventsStreamKeepalive)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		case ev, ok := <-sub.Events():
+			if !ok {
+				return // Bus closed at shutdown
+			}
+			if types != nil && !types[ev.Type] {
+				continue
+			}
+			if token.scoped() && (ev.IP == "" || !token.allows(ev.IP)) {
+				continue
+			}
+			data, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.Seq, ev.Type, data)
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
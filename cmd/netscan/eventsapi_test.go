@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/state"
+)
+
+// TestEventsHandler validates transitions are served newest first with ip and limit filters
+func TestEventsHandler(t *testing.T) {
+	mgr := state.NewManager(10)
+	mgr.SetDownThreshold(1)
+	for _, ip := range []string{"192.168.1.1", "192.168.1.2"} {
+		mgr.Add(state.Device{IP: ip, LastSeen: time.Now()})
+		mgr.ReportPingSuccess(ip)
+		mgr.ReportPingFail(ip, 10, time.Minute)
+	}
+	mgr.ReportPingSuccess("192.168.1.1")
+
+	hs := &HealthServer{stateMgr: mgr}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/events", hs.eventsHandler)
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantEvents int
+	}{
+		{"/api/events", http.StatusOK, 3},
+		{"/api/events?ip=192.168.1.1", http.StatusOK, 2},
+		{"/api/events?limit=1", http.StatusOK, 1},
+		{"/api/events?ip=not-an-ip", http.StatusBadRequest, 0},
+		{"/api/events?limit=0", http.StatusBadRequest, 0},
+		{"/api/events?limit=5000", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: expected status %d, got %d (%s)", tt.path, tt.wantStatus, rec.Code, rec.Body.String())
+			continue
+		}
+		if rec.Code != http.StatusOK {
+			continue
+		}
+		var resp eventsResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("%s: failed to decode response: %v", tt.path, err)
+		}
+		if len(resp.Events) != tt.wantEvents {
+			t.Errorf("%s: expected %d events, got %d", tt.path, tt.wantEvents, len(resp.Events))
+		}
+		if resp.DevicesDown != 1 {
+			t.Errorf("%s: expected 1 device down, got %d", tt.path, resp.DevicesDown)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/events?limit=1", nil))
+	var resp eventsResponse
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if len(resp.Events) != 1 || resp.Events[0].IP != "192.168.1.1" || resp.Events[0].State != state.ReachabilityUp {
+		t.Errorf("expected newest event to be 192.168.1.1 up, got %+v", resp.Events)
+	}
+}
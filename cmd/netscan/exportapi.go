@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/kljama/netscan/internal/inventory"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// exportHandler serves the full device inventory as JSON or, with ?format=csv, as a CSV download
+// Devices outside the token's networks are left out
+func (hs *HealthServer) exportHandler(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		http.Error(w, "format must be json or csv", http.StatusBadRequest)
+		return
+	}
+
+	token := requestToken(r)
+	var devices []state.Device
+	for _, dev := range hs.stateMgr.GetAll() {
+		if token.allows(dev.IP) {
+			devices = append(devices, dev)
+		}
+	}
+	export := inventory.NewExport(devices, time.Now())
+
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="inventory-`+export.Generated.UTC().Format("20060102-150405")+`.csv"`)
+		if err := export.WriteCSV(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := export.WriteJSON(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
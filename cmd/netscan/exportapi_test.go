@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/inventory"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// TestExportHandler validates the JSON and CSV inventory exports and format validation
+func TestExportHandler(t *testing.T) {
+	stateMgr := state.NewManager(100)
+	stateMgr.SetDownThreshold(1)
+	hs := &HealthServer{stateMgr: stateMgr}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/export", hs.exportHandler)
+
+	stateMgr.Add(state.Device{IP: "192.168.1.20", Hostname: "printer", DeviceType: "printer", LastSeen: time.Now()})
+	stateMgr.Add(state.Device{IP: "192.168.1.3", Hostname: "core-sw", SysDescr: "Cisco IOS, 15.2"})
+	stateMgr.ReportPingSuccess("192.168.1.3")
+	stateMgr.ReportPingFail("192.168.1.20", 10, time.Minute)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/export", nil))
+	var export inventory.Export
+	if err := json.NewDecoder(rec.Body).Decode(&export); err != nil {
+		t.Fatalf("failed to decode export: %v", err)
+	}
+	if len(export.Devices) != 2 || export.Devices[0].IP != "192.168.1.3" || export.Devices[0].Status != inventory.DeviceUp ||
+		export.Devices[1].Status != inventory.DeviceDown || export.Devices[1].DeviceType != "printer" {
+		t.Errorf("unexpected export: %+v", export.Devices)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/export?format=csv", nil))
+	if rec.Header().Get("Content-Type") != "text/csv" {
+		t.Errorf("expected text/csv, got %q", rec.Header().Get("Content-Type"))
+	}
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse CSV: %v", err)
+	}
+	if len(rows) != 3 || rows[0][0] != "ip" || rows[1][2] != "Cisco IOS, 15.2" || rows[1][8] != "up" || rows[2][7] == "" {
+		t.Errorf("unexpected CSV rows: %v", rows)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/export?format=xml", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown format, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/kljama/netscan/internal/flags"
+	"github.com/rs/zerolog/log"
+)
+
+// flagRequest is the POST /api/flags body
+type flagRequest struct {
+	Flag    string `json:"flag"`    // debug, probe_trace, pprof or verbose
+	IP      string `json:"ip"`      // Device IP (verbose only)
+	Enabled *bool  `json:"enabled"` // Defaults to true
+	TTL     string `json:"ttl"`     // Go duration, defaults to flags.DefaultTTL
+}
+
+// flagsResponse lists the active runtime flags
+type flagsResponse struct {
+	Active []flags.State `json:"active"`
+}
+
+// registerFlagsAPI adds /api/flags and the flag-gated /debug/pprof/ handlers to mux
+func registerFlagsAPI(mux *http.ServeMux) {
+	mux.HandleFunc("/api/flags", flagsHandler)
+	mux.HandleFunc("/debug/pprof/", pprofGate(pprof.Index))
+	mux.HandleFunc("/debug/pprof/cmdline", pprofGate(pprof.Cmdline))
+	mux.HandleFunc("/debug/pprof/profile", pprofGate(pprof.Profile))
+	mux.HandleFunc("/debug/pprof/symbol", pprofGate(pprof.Symbol))
+	mux.HandleFunc("/debug/pprof/trace", pprofGate(pprof.Trace))
+}
+
+// flagsHandler lists (GET) or toggles (POST) runtime flags
+func flagsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if err := applyFlagRequest(w, r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(flagsResponse{Active: flags.Active()}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// applyFlagRequest decodes a POST body and sets or clears the requested flag
+func applyFlagRequest(w http.ResponseWriter, r *http.Request) error {
+	var req flagRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+		return fmt.Errorf("invalid request body: %v", err)
+	}
+
+	if req.Enabled != nil && !*req.Enabled {
+		if err := flags.Clear(req.Flag, req.IP); err != nil {
+			return err
+		}
+		log.Info().Str("flag", req.Flag).Str("ip", req.IP).Str("remote", r.RemoteAddr).Msg("Runtime flag cleared")
+		return nil
+	}
+
+	var ttl time.Duration
+	if req.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(req.TTL); err != nil {
+			return fmt.Errorf("invalid ttl: %v", err)
+		}
+	}
+	state, err := flags.Set(req.Flag, req.IP, ttl)
+	if err != nil {
+		return err
+	}
+	log.Info().
+		Str("flag", state.Flag).
+		Str("ip", state.IP).
+		Time("expires_at", state.ExpiresAt).
+		Str("remote", r.RemoteAddr).
+		Msg("Runtime flag enabled")
+	return nil
+}
+
+// pprofGate serves a pprof handler only while the pprof flag is enabled
+func pprofGate(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !flags.PprofEnabled() {
+			http.NotFound(w, r)
+			return
+		}
+		h(w, r)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kljama/netscan/internal/state"
+)
+
+// groupStatus is one group of the /api/groups response
+// Every device is counted in exactly one of up, down, suspended and pending
+type groupStatus struct {
+	Name      string `json:"name"` // Value of the grouping key ("" for devices without one)
+	Devices   int    `json:"devices"`
+	Up        int    `json:"up"`
+	Down      int    `json:"down"`
+	Suspended int    `json:"suspended"` // Pinging suspended by the circuit breaker
+	Pending   int    `json:"pending"`   // No ping result yet
+	rollupStats
+}
+
+// groupsResponse is the GET /api/groups response body
+type groupsResponse struct {
+	By     string        `json:"by"`
+	Groups []groupStatus `json:"groups"` // Sorted by name
+}
+
+// siteGroups maps devices to their site and the site's static tags for /api/groups
+type siteGroups struct {
+	resolve func(ip string) string       // Site of a device ("" outside every site)
+	tags    map[string]map[string]string // sites.influxdb.tags per site name
+}
+
+// groupKey returns the function mapping a device to its group for ?by=, or nil for an unknown key
+// Keys: site (default), network, device_type, os_family and tag:<key> for a site tag from sites.influxdb.tags or
+// a device_tags tag (the site tag wins, as in InfluxDB)
+func (hs *HealthServer) groupKey(by string) func(dev *state.Device) string {
+	site := func(ip string) string {
+		if hs.sites == nil {
+			return ""
+		}
+		return hs.sites.resolve(ip)
+	}
+	switch {
+	case by == "site":
+		return func(dev *state.Device) string { return site(dev.IP) }
+	case by == "network":
+		return func(dev *state.Device) string { return dev.Network }
+	case by == "device_type":
+		return func(dev *state.Device) string { return dev.DeviceType }
+	case by == "os_family":
+		return func(dev *state.Device) string { return dev.OSFamily }
+	case strings.HasPrefix(by, "tag:") && len(by) > len("tag:"):
+		key := strings.TrimPrefix(by, "tag:")
+		return func(dev *state.Device) string {
+			if hs.sites != nil {
+				if value, ok := hs.sites.tags[site(dev.IP)][key]; ok {
+					return value
+				}
+			}
+			return dev.Tags[key]
+		}
+	}
+	return nil
+}
+
+// groupsHandler serves device counts per reachability state and RTT statistics of every group of devices
+// RTT, loss and availability cover the devices' RTT history (rtt_history_samples), so one request replaces
+// enumerating every device for a wallboard
+func (hs *HealthServer) groupsHandler(w http.ResponseWriter, r *http.Request) {
+	by := r.URL.Query().Get("by")
+	if by == "" {
+		by = "site"
+	}
+	key := hs.groupKey(by)
+	if key == nil {
+		http.Error(w, "by must be site, network, device_type, os_family or tag:<key>", http.StatusBadRequest)
+		return
+	}
+
+	token := requestToken(r)
+	now := time.Now()
+	groups := make(map[string]*groupStatus)
+	history := make(map[string][]state.DailyRollup)
+	for _, dev := range hs.stateMgr.GetAll() {
+		if !token.allows(dev.IP) {
+			continue
+		}
+		name := key(&dev)
+		group := groups[name]
+		if group == nil {
+			group = &groupStatus{Name: name}
+			groups[name] = group
+		}
+		group.Devices++
+		switch {
+		case dev.SuspendedUntil.After(now):
+			group.Suspended++
+		case dev.Reachability == state.ReachabilityUp:
+			group.Up++
+		case dev.Reachability == state.ReachabilityDown:
+			group.Down++
+		default:
+			group.Pending++
+		}
+		if samples := hs.stateMgr.GetRTTHistory(dev.IP); len(samples) > 0 {
+			history[name] = append(history[name], state.SummarizeRTTHistory(samples))
+		}
+	}
+
+	response := groupsResponse{By: by, Groups: make([]groupStatus, 0, len(groups))}
+	for name, group := range groups {
+		group.rollupStats = newRollupStats(state.SumRollups(history[name]))
+		response.Groups = append(response.Groups, *group)
+	}
+	sort.Slice(response.Groups, func(i, j int) bool { return response.Groups[i].Name < response.Groups[j].Name })
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// TestGroupsHandler validates per-site counts, RTT aggregation and the other grouping keys
+func TestGroupsHandler(t *testing.T) {
+	stateMgr := state.NewManager(100)
+	stateMgr.EnableRTTHistory(5)
+	stateMgr.SetDownThreshold(1)
+	hs := &HealthServer{stateMgr: stateMgr}
+	hs.SetSites([]config.SiteConfig{
+		{Name: "fra1", InfluxDB: config.SiteInfluxDBConfig{Tags: map[string]string{"region": "eu"}}},
+		{Name: "nyc1", InfluxDB: config.SiteInfluxDBConfig{Tags: map[string]string{"region": "us"}}},
+	}, func(ip string) string {
+		switch ip[:6] {
+		case "10.1.0":
+			return "fra1"
+		case "10.2.0":
+			return "nyc1"
+		}
+		return ""
+	})
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/groups", hs.groupsHandler)
+	get := func(path string) (*httptest.ResponseRecorder, groupsResponse) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var resp groupsResponse
+		if rec.Code == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("%s: failed to decode response: %v", path, err)
+			}
+		}
+		return rec, resp
+	}
+
+	now := time.Now()
+	stateMgr.Add(state.Device{IP: "10.1.0.1", DeviceType: "router"})
+	stateMgr.Add(state.Device{IP: "10.1.0.2"})
+	stateMgr.Add(state.Device{IP: "10.1.0.3"})
+	stateMgr.Add(state.Device{IP: "10.2.0.1", DeviceType: "router"})
+	stateMgr.Add(state.Device{IP: "192.168.1.1", Tags: map[string]string{"tier": "lab"}})
+	stateMgr.ReportPingSuccess("10.1.0.1")
+	stateMgr.RecordPingCycle("10.1.0.1", 2, 2, time.Millisecond, 2*time.Millisecond, 3*time.Millisecond, now)
+	stateMgr.ReportPingFail("10.1.0.2", 10, time.Minute)
+	stateMgr.RecordPingCycle("10.1.0.2", 2, 0, 0, 0, 0, now)
+	stateMgr.ReportPingFail("10.2.0.1", 1, time.Minute) // Suspended
+	stateMgr.ReportPingSuccess("10.2.0.1")
+	stateMgr.ReportPingFail("10.2.0.1", 1, time.Minute)
+
+	rec, resp := get("/api/groups")
+	if rec.Code != http.StatusOK || resp.By != "site" || len(resp.Groups) != 3 {
+		t.Fatalf("expected 3 site groups, got %d: %s", rec.Code, rec.Body.String())
+	}
+	outside, fra1, nyc1 := resp.Groups[0], resp.Groups[1], resp.Groups[2]
+	if outside.Name != "" || outside.Devices != 1 || outside.Pending != 1 {
+		t.Errorf("unexpected group of devices outside every site: %+v", outside)
+	}
+	if fra1.Name != "fra1" || fra1.Devices != 3 || fra1.Up != 1 || fra1.Down != 1 || fra1.Pending != 1 || fra1.Suspended != 0 {
+		t.Errorf("unexpected fra1 counts: %+v", fra1)
+	}
+	if fra1.Cycles != 2 || fra1.PacketLossPct != 50 || fra1.RTTAvgMs != 2 || fra1.RTTMaxMs != 3 {
+		t.Errorf("unexpected fra1 RTT statistics: %+v", fra1.rollupStats)
+	}
+	if nyc1.Name != "nyc1" || nyc1.Suspended != 1 || nyc1.Down != 0 {
+		t.Errorf("unexpected nyc1 counts: %+v", nyc1)
+	}
+
+	if _, resp := get("/api/groups?by=device_type"); len(resp.Groups) != 2 || resp.Groups[1].Name != "router" || resp.Groups[1].Devices != 2 {
+		t.Errorf("unexpected device_type groups: %+v", resp.Groups)
+	}
+	if _, resp := get("/api/groups?by=tag:region"); len(resp.Groups) != 3 || resp.Groups[1].Name != "eu" || resp.Groups[1].Devices != 3 {
+		t.Errorf("unexpected region groups: %+v", resp.Groups)
+	}
+	if _, resp := get("/api/groups?by=tag:tier"); len(resp.Groups) != 2 || resp.Groups[1].Name != "lab" || resp.Groups[1].Devices != 1 {
+		t.Errorf("unexpected device tag groups: %+v", resp.Groups)
+	}
+	if rec, _ := get("/api/groups?by=color"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown key, got %d", rec.Code)
+	}
+}
@@ -1,29 +1,72 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"runtime"
-	"strconv"
-	"strings"
 	"time"
 
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/discovery"
+	"github.com/kljama/netscan/internal/events"
+	"github.com/kljama/netscan/internal/history"
 	"github.com/kljama/netscan/internal/influx"
+	"github.com/kljama/netscan/internal/inventory"
+	"github.com/kljama/netscan/internal/monitoring"
 	"github.com/kljama/netscan/internal/state"
 	"github.com/rs/zerolog/log"
 )
 
+// healthReadHeaderTimeout bounds how long a client may take to send request headers
+const healthReadHeaderTimeout = 10 * time.Second
+
+// healthMinTLSVersion is the oldest TLS version accepted when health_tls_cert is set
+const healthMinTLSVersion = tls.VersionTLS12
+
+// healthShutdownTimeout bounds how long shutdown waits for in-flight API requests
+const healthShutdownTimeout = 5 * time.Second
+
 // HealthServer provides HTTP health check endpoint
 type HealthServer struct {
 	stateMgr           *state.Manager
-	writer             *influx.Writer
+	writer             *influx.Writer            // nil when running without InfluxDB (local rollups only)
 	startTime          time.Time
 	port               int
 	getPingerCount     func() int
 	getPingsSentCount  func() uint64
+	flagsAPI           bool                      // Serve /api/flags and flag-gated /debug/pprof/
+	snmpRefresher      *monitoring.SNMPRefresher // On-demand polls for /api/device/{ip}/snmp?refresh=true (nil = disabled)
+	history            *history.Ring             // Key metrics history for /api/history (nil = disabled)
+	apiLimiter         *apiLimiter               // Per-client rate limits for API requests
+	apiAuth            *apiAuth                  // Credentials required for API requests (nil = API open)
+	readTimeout        time.Duration             // health_read_timeout (0 = none)
+	writeTimeout       time.Duration             // health_write_timeout (0 = none)
+	idleTimeout        time.Duration             // health_idle_timeout (0 = read timeout)
+	tlsCert            string                    // health_tls_cert ("" = plain HTTP)
+	tlsKey             string                    // health_tls_key
+	clientCAs          *x509.CertPool            // health_tls_client_ca, verifying client certificates (nil = not requested)
+	requireClientCert  bool                      // health_tls_client_auth: require, also for health probes
+	reconciler         *inventory.Reconciler     // Expected devices reconciliation for /api/report/reconciliation (nil = disabled)
+	eventBus           *events.Bus               // Live events for /api/events/stream (nil = disabled)
+	influxHealth       *influx.HealthCache       // Background InfluxDB health status (nil = check on every request)
+	influxMirrors      []*influxDestination      // influxdb.mirrors, reported on /health but not required for readiness
+	schedule           *daemonSchedule           // Effective schedule for /api/schedule (nil = not available)
+	discovery          *discoveryControl         // Sweep progress and control for /api/discovery (nil = not available)
+	sites              *siteGroups               // Site and site tag grouping for /api/groups (nil = no sites)
+	quarantine         *quarantineSettings       // Quarantine review on /api/quarantine (nil = quarantine disabled)
+	churn              *churnTracker             // Device churn per discovery cycle for /api/stats/churn (nil = not available)
+	prober             *deviceProber             // On-demand probes for POST /api/probe (nil = not available)
+	rescan             *snmpRescan               // On-demand SNMP re-scans for POST /api/device/{ip}/rescan (nil = not available)
+	maintenance        *deviceMaintenance        // Per-device maintenance on /api/device/{ip}/maintenance (nil = not available)
+	pingBackoff        time.Duration             // ping_backoff_duration, for the next backoff on /api/device/{ip}/breaker
+	server             *http.Server              // Listener started by Start, stopped by Shutdown (nil before Start)
 }
 
 // HealthResponse represents the health check JSON response
@@ -33,17 +76,44 @@ type HealthResponse struct {
 	Uptime             string    `json:"uptime"`               // Human readable uptime
 	DeviceCount        int       `json:"device_count"`         // Number of monitored devices
 	SuspendedDevices   int       `json:"suspended_devices"`    // Number of suspended devices (circuit breaker)
+	DevicesUp          int       `json:"devices_up"`           // Number of devices currently reported up
+	DevicesDown        int       `json:"devices_down"`         // Number of devices currently reported down
 	ActivePingers      int       `json:"active_pingers"`       // Number of active pinger goroutines (accurate count)
+	InfluxDBEnabled    bool      `json:"influxdb_enabled"`     // False when running on local rollups only
 	InfluxDBOK         bool      `json:"influxdb_ok"`          // InfluxDB connectivity status
+	InfluxDBError      string    `json:"influxdb_error,omitempty"`      // Why the last health check failed
+	InfluxDBCheckedAt  *time.Time `json:"influxdb_checked_at,omitempty"` // When InfluxDB was last checked
 	InfluxDBSuccessful uint64    `json:"influxdb_successful"`  // Successful batch writes
 	InfluxDBFailed     uint64    `json:"influxdb_failed"`      // Failed batch writes
+	InfluxDBEnqueued   uint64    `json:"influxdb_points_enqueued"`  // Points accepted into the write queue
+	InfluxDBFlushed    uint64    `json:"influxdb_points_flushed"`   // Points written to InfluxDB
+	InfluxDBDroppedFull     uint64 `json:"influxdb_dropped_full"`     // Points dropped because the write queue was full
+	InfluxDBDroppedShutdown uint64 `json:"influxdb_dropped_shutdown"` // Points dropped after or during shutdown
+	InfluxDBQueueDepth int64     `json:"influxdb_queue_depth"`      // Points queued but not yet flushed
+	InfluxDBMirrors    []InfluxMirrorHealth `json:"influxdb_mirrors,omitempty"` // Write status of each influxdb.mirrors destination
 	PingsSentTotal     uint64    `json:"pings_sent_total"`     // Total monitoring pings sent
 	Goroutines         int       `json:"goroutines"`           // Current goroutine count
 	MemoryMB           uint64    `json:"memory_mb"`            // Current memory usage in MB (Go heap Alloc)
 	RSSMB              uint64    `json:"rss_mb"`               // OS-level resident set size in MB
+	Discovery          *discovery.ProgressSnapshot `json:"discovery,omitempty"` // Progress of the running discovery sweep
 	Timestamp          time.Time `json:"timestamp"`            // Current timestamp
 }
 
+// InfluxMirrorHealth is the write status of one InfluxDB mirror
+type InfluxMirrorHealth struct {
+	Name       string     `json:"name"`
+	OK         bool       `json:"ok"`                   // Mirror connectivity status
+	Error      string     `json:"error,omitempty"`      // Why the last health check failed
+	CheckedAt  *time.Time `json:"checked_at,omitempty"` // When the mirror was last checked
+	Successful uint64     `json:"successful"`           // Successful batch writes
+	Failed     uint64     `json:"failed"`               // Failed batch writes
+	Flushed    uint64     `json:"points_flushed"`       // Points written to the mirror
+	Dropped    uint64     `json:"points_dropped"`       // Points dropped because the queue was full or at shutdown
+	QueueDepth int64      `json:"queue_depth"`          // Points queued but not yet flushed
+
+	writer *influx.Writer // Receives the mirror's health metrics
+}
+
 // NewHealthServer creates a new health check server
 func NewHealthServer(port int, stateMgr *state.Manager, writer *influx.Writer, getPingerCount func() int, getPingsSentCount func() uint64) *HealthServer {
 	return &HealthServer{
@@ -56,13 +126,207 @@ func NewHealthServer(port int, stateMgr *state.Manager, writer *influx.Writer, g
 	}
 }
 
+// EnableFlagsAPI serves the runtime flags API alongside health checks; call before Start
+func (hs *HealthServer) EnableFlagsAPI() {
+	hs.flagsAPI = true
+}
+
+// SetSNMPRefresher enables refresh=true on the device SNMP API; call before Start
+func (hs *HealthServer) SetSNMPRefresher(refresher *monitoring.SNMPRefresher) {
+	hs.snmpRefresher = refresher
+}
+
+// SetHistory serves the key metrics history on /api/history; call before Start
+func (hs *HealthServer) SetHistory(ring *history.Ring) {
+	hs.history = ring
+}
+
+// SetReconciler serves inventory reconciliation reports on /api/report/reconciliation; call before Start
+func (hs *HealthServer) SetReconciler(reconciler *inventory.Reconciler) {
+	hs.reconciler = reconciler
+}
+
+// SetEventBus streams bus events on /api/events/stream; call before Start
+func (hs *HealthServer) SetEventBus(bus *events.Bus) {
+	hs.eventBus = bus
+}
+
+// SetInfluxHealth serves the cached InfluxDB health status instead of checking on every request; call before Start
+func (hs *HealthServer) SetInfluxHealth(cache *influx.HealthCache) {
+	hs.influxHealth = cache
+}
+
+// SetInfluxMirrors reports the InfluxDB mirrors on /health; an unhealthy mirror degrades the status
+// but does not affect readiness; call before Start
+func (hs *HealthServer) SetInfluxMirrors(mirrors []*influxDestination) {
+	hs.influxMirrors = mirrors
+}
+
+// SetSchedule serves the daemon's effective schedule on /api/schedule; call before Start
+func (hs *HealthServer) SetSchedule(schedule *daemonSchedule) {
+	hs.schedule = schedule
+}
+
+// SetDiscoveryControl serves sweep progress, cancellation and on-demand scans on /api/discovery; call before Start
+func (hs *HealthServer) SetDiscoveryControl(control *discoveryControl) {
+	hs.discovery = control
+}
+
+// SetSites groups devices by site and site tags on /api/groups; call before Start
+func (hs *HealthServer) SetSites(sites []config.SiteConfig, resolve func(ip string) string) {
+	groups := &siteGroups{resolve: resolve, tags: make(map[string]map[string]string, len(sites))}
+	for _, site := range sites {
+		groups.tags[site.Name] = site.InfluxDB.Tags
+	}
+	hs.sites = groups
+}
+
+// SetQuarantine serves the quarantine list and review actions on /api/quarantine; call before Start
+// Review actions are saved to file immediately ("" = in memory)
+func (hs *HealthServer) SetQuarantine(trips int, window time.Duration, file string) {
+	hs.quarantine = &quarantineSettings{trips: trips, window: window, file: file}
+}
+
+// SetChurn serves device churn per discovery cycle on /api/stats/churn; call before Start
+func (hs *HealthServer) SetChurn(churn *churnTracker) {
+	hs.churn = churn
+}
+
+// SetProber serves on-demand device probes on POST /api/probe; call before Start
+func (hs *HealthServer) SetProber(prober *deviceProber) {
+	hs.prober = prober
+}
+
+// influxStatus returns the InfluxDB health status, from the cache when one is set
+func (hs *HealthServer) influxStatus() influx.HealthStatus {
+	if hs.influxHealth != nil {
+		return hs.influxHealth.Status()
+	}
+	return influx.HealthStatus{Err: hs.writer.HealthCheck(), CheckedAt: time.Now()}
+}
+
+// SetAPILimits sets the per-client API rate limit (requests/second and burst); call before Start
+func (hs *HealthServer) SetAPILimits(requestsPerSec float64, burst int) {
+	hs.apiLimiter = newAPILimiter(requestsPerSec, burst)
+}
+
+// SetAPITokens requires one of the tokens or basic-auth users on every API request; call before Start
+func (hs *HealthServer) SetAPITokens(tokens []config.APITokenConfig, users []config.APIUserConfig) {
+	if len(tokens) > 0 {
+		hs.apiAuth = hs.apiAuth.with(newBearerAuth(tokens))
+	}
+	if len(users) > 0 {
+		hs.apiAuth = hs.apiAuth.with(newBasicAuth(users))
+	}
+}
+
+// SetClientCerts requests client certificates signed by the PEM CA bundle and accepts them on API requests
+// With require, the TLS handshake fails without a valid certificate; otherwise it is optional; call before Start
+func (hs *HealthServer) SetClientCerts(caFile string, require bool, certs []config.APIClientCertConfig) error {
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return fmt.Errorf("read client CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("client CA %s: no PEM certificates found", caFile)
+	}
+	hs.clientCAs = pool
+	hs.requireClientCert = require
+	hs.apiAuth = hs.apiAuth.with(newClientCertAuth(certs))
+	return nil
+}
+
+// SetServerTimeouts bounds reading requests, writing responses and idle keep-alive connections; call before Start
+func (hs *HealthServer) SetServerTimeouts(read, write, idle time.Duration) {
+	hs.readTimeout = read
+	hs.writeTimeout = write
+	hs.idleTimeout = idle
+}
+
+// SetTLS serves over HTTPS with the PEM certificate and key files; call before Start
+func (hs *HealthServer) SetTLS(certFile, keyFile string) {
+	hs.tlsCert = certFile
+	hs.tlsKey = keyFile
+}
+
 // Start begins serving health checks (non-blocking)
 func (hs *HealthServer) Start() error {
-	http.HandleFunc("/health", hs.healthHandler)
-	http.HandleFunc("/health/ready", hs.readinessHandler)
-	http.HandleFunc("/health/live", hs.livenessHandler)
+	// Dedicated mux: net/http/pprof registers itself on the default mux, which must never be exposed ungated
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", hs.healthHandler)
+	mux.HandleFunc("/health/ready", hs.readinessHandler)
+	mux.HandleFunc("/health/live", hs.livenessHandler)
+	mux.HandleFunc("GET /api/device/{ip}/snmp", hs.deviceSNMPHandler)
+	mux.HandleFunc("GET /api/events", hs.eventsHandler)
+	mux.HandleFunc("GET /api/events/stream", hs.eventsStreamHandler)
+	mux.HandleFunc("GET /api/history", hs.historyHandler)
+	mux.HandleFunc("GET /api/stats/churn", hs.churnHandler)
+	mux.HandleFunc("GET /api/device/{ip}/history", hs.deviceHistoryHandler)
+	mux.HandleFunc("GET /api/device/{ip}/breaker", hs.deviceBreakerHandler)
+	mux.HandleFunc("GET /api/report/reconciliation", hs.reconciliationHandler)
+	mux.HandleFunc("GET /api/export", hs.exportHandler)
+	mux.HandleFunc("GET /api/device/{ip}/rollups", hs.deviceRollupsHandler)
+	mux.HandleFunc("GET /api/rollups", hs.rollupsHandler)
+	mux.HandleFunc("GET /api/groups", hs.groupsHandler)
+	mux.HandleFunc("GET /api/quarantine", hs.quarantineHandler)
+	mux.HandleFunc("POST /api/quarantine/{ip}/release", hs.quarantineReleaseHandler)
+	mux.HandleFunc("POST /api/quarantine/{ip}/exclude", hs.quarantineExcludeHandler)
+	mux.HandleFunc("GET /api/schedule", hs.scheduleHandler)
+	mux.HandleFunc("GET /api/discovery", hs.discoveryHandler)
+	mux.HandleFunc("POST /api/discovery/scan", hs.discoveryScanHandler)
+	mux.HandleFunc("POST /api/discovery/cancel", hs.discoveryCancelHandler)
+	mux.HandleFunc("POST /api/probe", hs.probeHandler)
+	mux.HandleFunc("POST /api/device/{ip}/rescan", hs.deviceRescanHandler)
+	mux.HandleFunc("GET /api/device/{ip}/maintenance", hs.deviceMaintenanceHandler)
+	mux.HandleFunc("PUT /api/device/{ip}/maintenance", hs.deviceMaintenanceSetHandler)
+	mux.HandleFunc("DELETE /api/device/{ip}/maintenance", hs.deviceMaintenanceClearHandler)
+	if hs.flagsAPI {
+		registerFlagsAPI(mux)
+	}
+
+	if hs.apiLimiter == nil {
+		hs.apiLimiter = newAPILimiter(0, 0)
+	}
+	handler := apiMiddleware(hs.apiLimiter, hs.apiAuth, mux)
+
+	var tlsConfig *tls.Config
+	if hs.tlsCert != "" {
+		// Load before listening so a bad key pair fails startup like a port in use
+		cert, err := tls.LoadX509KeyPair(hs.tlsCert, hs.tlsKey)
+		if err != nil {
+			return fmt.Errorf("health server TLS: %w", err)
+		}
+		tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: healthMinTLSVersion}
+		if hs.clientCAs != nil {
+			tlsConfig.ClientCAs = hs.clientCAs
+			tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+			if hs.requireClientCert {
+				tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+		}
+	}
 
 	addr := fmt.Sprintf(":%d", hs.port)
+	// Listen before returning so a port already in use fails startup instead of a background goroutine
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("health server listen on %s: %w", addr, err)
+	}
+
+	// Request contexts derive from baseCtx, which Shutdown cancels so long-lived event streams end promptly
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	hs.server = &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: healthReadHeaderTimeout,
+		ReadTimeout:       hs.readTimeout,
+		WriteTimeout:      hs.writeTimeout,
+		IdleTimeout:       hs.idleTimeout,
+		TLSConfig:         tlsConfig,
+		BaseContext:       func(net.Listener) context.Context { return baseCtx },
+	}
+	hs.server.RegisterOnShutdown(cancelRequests)
+
 	go func() {
 		// Panic recovery for health server goroutine
 		defer func() {
@@ -73,15 +337,30 @@ func (hs *HealthServer) Start() error {
 			}
 		}()
 
-		if err := http.ListenAndServe(addr, nil); err != nil {
+		var err error
+		if tlsConfig != nil {
+			err = hs.server.ServeTLS(listener, "", "")
+		} else {
+			err = hs.server.Serve(listener)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Error().Err(err).Msg("Health server error")
 		}
 	}()
 
-	log.Info().Str("address", addr).Msg("Health check endpoint started")
+	log.Info().Str("address", addr).Bool("tls", tlsConfig != nil).Bool("client_certs", hs.clientCAs != nil).Bool("flags_api", hs.flagsAPI).Msg("Health check endpoint started")
 	return nil
 }
 
+// Shutdown stops accepting connections and waits for in-flight requests until ctx expires, releasing the port
+// Event streams are ended immediately; a no-op before Start
+func (hs *HealthServer) Shutdown(ctx context.Context) error {
+	if hs.server == nil {
+		return nil
+	}
+	return hs.server.Shutdown(ctx)
+}
+
 // healthHandler provides detailed health information
 func (hs *HealthServer) healthHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -103,11 +382,36 @@ func (hs *HealthServer) GetHealthMetrics() HealthResponse {
 	// Get OS-level RSS (Linux /proc)
 	rssMB := getRSSMB()
 
-	// Determine overall status
-	influxOK := hs.writer.HealthCheck() == nil
+	// Determine overall status (without InfluxDB there is nothing to degrade)
 	status := "healthy"
-	if !influxOK {
-		status = "degraded"
+	influxOK := false
+	var influxError string
+	var influxCheckedAt *time.Time
+	var influxSuccessful, influxFailed uint64
+	var queue influx.QueueStats
+	if hs.writer != nil {
+		influxStatus := hs.influxStatus()
+		influxOK = influxStatus.OK()
+		if !influxOK {
+			status = "degraded"
+			influxError = influxStatus.Err.Error()
+		}
+		if !influxStatus.CheckedAt.IsZero() {
+			influxCheckedAt = &influxStatus.CheckedAt
+		}
+		influxSuccessful, influxFailed = hs.writer.GetSuccessfulBatches(), hs.writer.GetFailedBatches()
+		queue = hs.writer.GetQueueStats()
+	}
+	mirrors := hs.mirrorHealth()
+	for _, mirror := range mirrors {
+		if !mirror.OK {
+			status = "degraded"
+		}
+	}
+
+	var sweep *discovery.ProgressSnapshot
+	if hs.discovery != nil {
+		sweep = hs.discovery.running()
 	}
 
 	return HealthResponse{
@@ -116,22 +420,69 @@ func (hs *HealthServer) GetHealthMetrics() HealthResponse {
 		Uptime:             time.Since(hs.startTime).String(),
 		DeviceCount:        hs.stateMgr.Count(),
 		SuspendedDevices:   hs.stateMgr.GetSuspendedCount(),
+		DevicesUp:          hs.stateMgr.GetUpCount(),
+		DevicesDown:        hs.stateMgr.GetDownCount(),
 		ActivePingers:      hs.getPingerCount(), // Accurate count from activePingers map
+		InfluxDBEnabled:    hs.writer != nil,
 		InfluxDBOK:         influxOK,
-		InfluxDBSuccessful: hs.writer.GetSuccessfulBatches(),
-		InfluxDBFailed:     hs.writer.GetFailedBatches(),
+		InfluxDBError:      influxError,
+		InfluxDBCheckedAt:  influxCheckedAt,
+		InfluxDBSuccessful: influxSuccessful,
+		InfluxDBFailed:     influxFailed,
+		InfluxDBEnqueued:   queue.Enqueued,
+		InfluxDBFlushed:    queue.Flushed,
+		InfluxDBDroppedFull:     queue.DroppedFull,
+		InfluxDBDroppedShutdown: queue.DroppedShutdown,
+		InfluxDBQueueDepth: queue.Depth,
+		InfluxDBMirrors:    mirrors,
 		PingsSentTotal:     hs.getPingsSentCount(), // Total pings sent counter
 		Goroutines:         runtime.NumGoroutine(),
 		MemoryMB:           m.Alloc / 1024 / 1024,
 		RSSMB:              rssMB,
+		Discovery:          sweep,
 		Timestamp:          time.Now(),
 	}
 }
 
+// mirrorHealth returns the write status of every InfluxDB mirror (nil without mirrors)
+func (hs *HealthServer) mirrorHealth() []InfluxMirrorHealth {
+	if len(hs.influxMirrors) == 0 {
+		return nil
+	}
+	mirrors := make([]InfluxMirrorHealth, 0, len(hs.influxMirrors))
+	for _, mirror := range hs.influxMirrors {
+		status := mirror.health.Status()
+		queue := mirror.writer.GetQueueStats()
+		entry := InfluxMirrorHealth{
+			Name:       mirror.name,
+			OK:         status.OK(),
+			Successful: mirror.writer.GetSuccessfulBatches(),
+			Failed:     mirror.writer.GetFailedBatches(),
+			Flushed:    queue.Flushed,
+			Dropped:    queue.DroppedFull + queue.DroppedShutdown,
+			QueueDepth: queue.Depth,
+			writer:     mirror.writer,
+		}
+		if !status.OK() {
+			entry.Error = status.Err.Error()
+		}
+		if !status.CheckedAt.IsZero() {
+			entry.CheckedAt = &status.CheckedAt
+		}
+		mirrors = append(mirrors, entry)
+	}
+	return mirrors
+}
+
 // readinessHandler indicates if service is ready to accept traffic
 func (hs *HealthServer) readinessHandler(w http.ResponseWriter, r *http.Request) {
-	// Service is ready if InfluxDB is accessible
-	if err := hs.writer.HealthCheck(); err != nil {
+	// Service is ready if InfluxDB is accessible (or not configured)
+	if hs.writer == nil {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("READY"))
+		return
+	}
+	if !hs.influxStatus().OK() {
 		w.WriteHeader(http.StatusServiceUnavailable)
 		w.Write([]byte("NOT READY: InfluxDB unavailable"))
 		return
@@ -147,35 +498,3 @@ func (hs *HealthServer) livenessHandler(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("ALIVE"))
 }
-
-// getRSSMB attempts to read /proc/self/status and parse VmRSS (kB) to MB.
-// This is Linux-specific. On failure it returns 0.
-func getRSSMB() uint64 {
-	f, err := os.Open("/proc/self/status")
-	if err != nil {
-		return 0
-	}
-	defer f.Close()
-
-	s := bufio.NewScanner(f)
-	for s.Scan() {
-		line := s.Text()
-		if strings.HasPrefix(line, "VmRSS:") {
-			fields := strings.Fields(line)
-			// Expected format: "VmRSS:    3472 kB"
-			if len(fields) >= 3 && fields[2] == "kB" {
-				kb, err := strconv.ParseUint(fields[1], 10, 64)
-				if err == nil {
-					return kb / 1024
-				}
-			}
-		}
-	}
-	
-	// Check for scanner errors
-	if err := s.Err(); err != nil {
-		return 0
-	}
-	
-	return 0
-}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/events"
+	"github.com/kljama/netscan/internal/influx"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// TestHealthUsesCachedInfluxStatus verifies health endpoints answer from the cache instead of contacting InfluxDB
+func TestHealthUsesCachedInfluxStatus(t *testing.T) {
+	// Nothing listens on this address; a synchronous check would fail rather than pass
+	writer := influx.NewWriter("http://127.0.0.1:1", "token", "org", "bucket", "health", 100, time.Hour)
+	defer writer.Close()
+
+	cache := influx.NewHealthCache(writer.HealthCheck, time.Minute)
+	cache.Record(nil, time.Now())
+	hs := NewHealthServer(0, state.NewManager(10), writer, func() int { return 0 }, func() uint64 { return 0 })
+	hs.SetInfluxHealth(cache)
+
+	start := time.Now()
+	metrics := hs.GetHealthMetrics()
+	if !metrics.InfluxDBOK || metrics.Status != "healthy" || metrics.InfluxDBCheckedAt == nil {
+		t.Errorf("expected cached healthy status, got %+v", metrics)
+	}
+	rec := httptest.NewRecorder()
+	hs.readinessHandler(rec, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected ready, got %d", rec.Code)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("expected cached answers, took %v", elapsed)
+	}
+
+	cache.Record(errors.New("influxdb health check failed: timeout"), time.Now())
+	metrics = hs.GetHealthMetrics()
+	if metrics.InfluxDBOK || metrics.Status != "degraded" || metrics.InfluxDBError == "" {
+		t.Errorf("expected degraded status with error, got %+v", metrics)
+	}
+	rec = httptest.NewRecorder()
+	hs.readinessHandler(rec, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected not ready, got %d", rec.Code)
+	}
+}
+
+// TestHealthReportsInfluxMirrors verifies an unhealthy mirror degrades /health without affecting readiness
+func TestHealthReportsInfluxMirrors(t *testing.T) {
+	writer := influx.NewWriter("http://127.0.0.1:1", "token", "org", "bucket", "health", 100, time.Hour)
+	defer writer.Close()
+	cache := influx.NewHealthCache(writer.HealthCheck, time.Minute)
+	cache.Record(nil, time.Now())
+
+	mirrorWriter := influx.NewWriter("http://127.0.0.1:2", "token", "org", "bucket", "health", 100, time.Hour)
+	defer mirrorWriter.Close()
+	mirror := &influxDestination{name: "central", writer: mirrorWriter, health: influx.NewHealthCache(mirrorWriter.HealthCheck, time.Minute)}
+	mirror.health.Record(nil, time.Now())
+
+	hs := NewHealthServer(0, state.NewManager(10), writer, func() int { return 0 }, func() uint64 { return 0 })
+	hs.SetInfluxHealth(cache)
+	hs.SetInfluxMirrors([]*influxDestination{mirror})
+
+	metrics := hs.GetHealthMetrics()
+	if metrics.Status != "healthy" || len(metrics.InfluxDBMirrors) != 1 || !metrics.InfluxDBMirrors[0].OK || metrics.InfluxDBMirrors[0].Name != "central" {
+		t.Errorf("expected healthy status with a healthy mirror, got %+v", metrics)
+	}
+
+	mirror.health.Record(errors.New("influxdb health check failed: timeout"), time.Now())
+	metrics = hs.GetHealthMetrics()
+	if metrics.Status != "degraded" || metrics.InfluxDBMirrors[0].OK || metrics.InfluxDBMirrors[0].Error == "" || !metrics.InfluxDBOK {
+		t.Errorf("expected degraded status from the mirror only, got %+v", metrics)
+	}
+	rec := httptest.NewRecorder()
+	hs.readinessHandler(rec, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected ready while only a mirror is down, got %d", rec.Code)
+	}
+}
+
+// TestHealthServerShutdown verifies Shutdown ends open event streams and releases the port
+func TestHealthServerShutdown(t *testing.T) {
+	probe, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := probe.Addr().(*net.TCPAddr).Port
+	probe.Close()
+
+	hs := NewHealthServer(port, state.NewManager(10), nil, func() int { return 0 }, func() uint64 { return 0 })
+	hs.SetEventBus(events.New())
+	if err := hs.Start(); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	// Without keep-alives no idle connection is left for Shutdown to wait on, only the event stream
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	base := fmt.Sprintf("http://127.0.0.1:%d", port)
+	resp, err := client.Get(base + "/health/live")
+	if err != nil {
+		t.Fatalf("health server not reachable: %v", err)
+	}
+	resp.Body.Close()
+
+	// An open event stream must not hold up shutdown
+	stream, err := client.Get(base + "/api/events/stream")
+	if err != nil {
+		t.Fatalf("failed to open event stream: %v", err)
+	}
+	defer stream.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := hs.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("shutdown waited %v for the event stream", elapsed)
+	}
+
+	// The port is free again, so a restarted server can bind it
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		t.Fatalf("port still in use after shutdown: %v", err)
+	}
+	ln.Close()
+	if err := (&HealthServer{}).Shutdown(ctx); err != nil {
+		t.Errorf("expected Shutdown before Start to be a no-op, got %v", err)
+	}
+}
+
+// TestHealthServerTLS verifies the server answers over HTTPS only and that a bad key pair fails Start
+func TestHealthServerTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeTestCertificate(t, certFile, keyFile)
+
+	probe, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := probe.Addr().(*net.TCPAddr).Port
+	probe.Close()
+
+	hs := NewHealthServer(port, state.NewManager(10), nil, func() int { return 0 }, func() uint64 { return 0 })
+	hs.SetServerTimeouts(5*time.Second, 5*time.Second, 10*time.Second)
+	hs.SetTLS(certFile, keyFile)
+	if err := hs.Start(); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer hs.Shutdown(context.Background())
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	resp, err := client.Get(fmt.Sprintf("https://127.0.0.1:%d/health/live", port))
+	if err != nil {
+		t.Fatalf("health server not reachable over HTTPS: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.TLS == nil || resp.TLS.Version < tls.VersionTLS12 {
+		t.Errorf("unexpected HTTPS response: %d %+v", resp.StatusCode, resp.TLS)
+	}
+
+	// Plain HTTP gets the net/http "client sent an HTTP request to an HTTPS server" answer
+	if resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/health/live", port)); err == nil {
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected 400 for plain HTTP, got %d", resp.StatusCode)
+		}
+	}
+
+	bad := NewHealthServer(0, state.NewManager(10), nil, func() int { return 0 }, func() uint64 { return 0 })
+	bad.SetTLS(certFile, certFile)
+	if err := bad.Start(); err == nil {
+		bad.Shutdown(context.Background())
+		t.Error("expected Start to fail with an invalid key pair")
+	}
+}
+
+// TestHealthServerClientCerts verifies client certificates authenticate API requests while health probes stay open
+func TestHealthServerClientCerts(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeTestCertificate(t, certFile, keyFile)
+	ca, caKey := issueTestCertificate(t, "netscan-test-ca", nil, nil)
+	caFile := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	probe, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := probe.Addr().(*net.TCPAddr).Port
+	probe.Close()
+
+	hs := NewHealthServer(port, state.NewManager(10), nil, func() int { return 0 }, func() uint64 { return 0 })
+	hs.SetTLS(certFile, keyFile)
+	if err := hs.SetClientCerts(caFile, false, []config.APIClientCertConfig{
+		{Name: "noc", CommonName: "noc-client"},
+		{Name: "branch", CommonName: "branch-client", Networks: []string{"10.1.0.0/16"}},
+	}); err != nil {
+		t.Fatalf("failed to load client CA: %v", err)
+	}
+	if err := hs.Start(); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer hs.Shutdown(context.Background())
+
+	get := func(path, commonName string) int {
+		t.Helper()
+		tlsConfig := &tls.Config{InsecureSkipVerify: true}
+		if commonName != "" {
+			cert, key := issueTestCertificate(t, commonName, ca, caKey)
+			tlsConfig.Certificates = []tls.Certificate{{Certificate: [][]byte{cert.Raw}, PrivateKey: key}}
+		}
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+		resp, err := client.Get(fmt.Sprintf("https://127.0.0.1:%d%s", port, path))
+		if err != nil {
+			t.Fatalf("GET %s as %q: %v", path, commonName, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := get("/health/live", ""); code != http.StatusOK {
+		t.Errorf("health probe without a certificate: expected 200, got %d", code)
+	}
+	if code := get("/api/events", ""); code != http.StatusUnauthorized {
+		t.Errorf("API without a certificate: expected 401, got %d", code)
+	}
+	if code := get("/api/events", "noc-client"); code != http.StatusOK {
+		t.Errorf("API with a mapped certificate: expected 200, got %d", code)
+	}
+	if code := get("/api/events", "intruder"); code != http.StatusUnauthorized {
+		t.Errorf("API with an unmapped certificate: expected 401, got %d", code)
+	}
+	if code := get("/api/history", "branch-client"); code != http.StatusForbidden {
+		t.Errorf("global endpoint with a network-scoped certificate: expected 403, got %d", code)
+	}
+}
+
+// writeTestCertificate writes a self-signed localhost certificate and its key as PEM files
+func writeTestCertificate(t *testing.T, certFile, keyFile string) {
+	t.Helper()
+	cert, key := issueTestCertificate(t, "localhost", nil, nil)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// issueTestCertificate creates a certificate for 127.0.0.1 signed by parent, or a self-signed CA without one
+func issueTestCertificate(t *testing.T, commonName string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/kljama/netscan/internal/history"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// historyResponse is the GET /api/history response body
+type historyResponse struct {
+	Interval string           `json:"interval"` // Sampling interval (health_report_interval)
+	Samples  []history.Sample `json:"samples"`  // Oldest first
+}
+
+// deviceHistoryResponse is the GET /api/device/{ip}/history response body
+type deviceHistoryResponse struct {
+	IP       string            `json:"ip"`
+	Hostname string            `json:"hostname,omitempty"`
+	Summary  rollupStats       `json:"summary"` // Loss and RTT statistics over the samples
+	Samples  []state.RTTSample `json:"samples"` // Latest ping cycles (rtt_history_samples), oldest first
+}
+
+// historyHandler serves the key metrics history kept in memory, optionally limited to a window (?since=6h)
+// It never touches InfluxDB, so it keeps working during a database outage
+func (hs *HealthServer) historyHandler(w http.ResponseWriter, r *http.Request) {
+	if hs.history == nil {
+		http.Error(w, "metrics history not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	window := history.Retention
+	if raw := r.URL.Query().Get("since"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 || d > history.Retention {
+			http.Error(w, "since must be a duration between 1s and 24h", http.StatusBadRequest)
+			return
+		}
+		window = d
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := historyResponse{
+		Interval: hs.history.Interval().String(),
+		Samples:  hs.history.Samples(time.Now().Add(-window)),
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// deviceHistoryHandler serves the device's latest ping cycles kept in memory with their loss and RTT statistics
+// Like /api/history it never touches InfluxDB
+func (hs *HealthServer) deviceHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	ip := net.ParseIP(r.PathValue("ip"))
+	if ip == nil {
+		http.Error(w, "invalid device ip", http.StatusBadRequest)
+		return
+	}
+	if !requestToken(r).allows(ip.String()) {
+		http.Error(w, "device outside the token's networks", http.StatusForbidden)
+		return
+	}
+	if _, found := hs.stateMgr.Get(ip.String()); !found {
+		http.Error(w, "device not found", http.StatusNotFound)
+		return
+	}
+
+	samples := hs.stateMgr.GetRTTHistory(ip.String())
+	if samples == nil {
+		samples = []state.RTTSample{} // Not pinged yet
+	}
+	w.Header().Set("Content-Type", "application/json")
+	response := deviceHistoryResponse{
+		IP:       ip.String(),
+		Hostname: hs.hostname(ip.String()),
+		Summary:  newRollupStats(state.SummarizeRTTHistory(samples)),
+		Samples:  samples,
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
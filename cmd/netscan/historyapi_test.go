@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/history"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// TestHistoryHandler validates the history window parameter and response shape
+func TestHistoryHandler(t *testing.T) {
+	ring, err := history.NewRing(10*time.Second, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	ring.Add(history.Sample{Time: now.Add(-2 * time.Hour), DeviceCount: 100})
+	ring.Add(history.Sample{Time: now.Add(-time.Minute), DeviceCount: 101})
+
+	hs := &HealthServer{history: ring}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/history", hs.historyHandler)
+
+	tests := []struct {
+		path        string
+		wantStatus  int
+		wantSamples int
+	}{
+		{"/api/history", http.StatusOK, 2},
+		{"/api/history?since=1h", http.StatusOK, 1},
+		{"/api/history?since=48h", http.StatusBadRequest, 0},
+		{"/api/history?since=soon", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: expected status %d, got %d (%s)", tt.path, tt.wantStatus, rec.Code, rec.Body.String())
+			continue
+		}
+		if rec.Code != http.StatusOK {
+			continue
+		}
+		var resp historyResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("%s: failed to decode response: %v", tt.path, err)
+		}
+		if resp.Interval != "10s" || len(resp.Samples) != tt.wantSamples {
+			t.Errorf("%s: unexpected response: %+v", tt.path, resp)
+		}
+	}
+
+	// Without a ring the endpoint reports it is unavailable
+	hs.history = nil
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/history", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without history, got %d", rec.Code)
+	}
+}
+
+// TestDeviceHistoryHandler validates the per-device RTT history and its loss statistics
+func TestDeviceHistoryHandler(t *testing.T) {
+	stateMgr := state.NewManager(100)
+	stateMgr.EnableRTTHistory(3)
+	hs := &HealthServer{stateMgr: stateMgr}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/device/{ip}/history", hs.deviceHistoryHandler)
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	stateMgr.AddDevice("192.168.1.10")
+	now := time.Now()
+	stateMgr.RecordPingCycle("192.168.1.10", 2, 2, 9*time.Millisecond, 9*time.Millisecond, 9*time.Millisecond, now.Add(-4*time.Minute)) // Pushed out
+	stateMgr.RecordPingCycle("192.168.1.10", 2, 2, time.Millisecond, 2*time.Millisecond, 3*time.Millisecond, now.Add(-3*time.Minute))
+	stateMgr.RecordPingCycle("192.168.1.10", 2, 0, 0, 0, 0, now.Add(-2*time.Minute))
+	stateMgr.RecordPingCycle("192.168.1.10", 2, 1, 4*time.Millisecond, 4*time.Millisecond, 4*time.Millisecond, now.Add(-time.Minute))
+
+	rec := get("/api/device/192.168.1.10/history")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d (%s)", rec.Code, rec.Body.String())
+	}
+	var resp deviceHistoryResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Samples) != 3 || resp.Samples[0].RTTAvgMs != 2 || resp.Samples[1].Recv != 0 || resp.Samples[2].RTTMaxMs != 4 {
+		t.Fatalf("expected the 3 latest cycles oldest first, got %+v", resp.Samples)
+	}
+	if s := resp.Summary; s.Cycles != 3 || s.PacketsSent != 6 || s.PacketsRecv != 3 || s.PacketLossPct != 50 || s.RTTMinMs != 1 || s.RTTAvgMs != 3 || s.RTTMaxMs != 4 {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+
+	// A device that was not pinged yet has an empty history
+	stateMgr.AddDevice("192.168.1.11")
+	rec = get("/api/device/192.168.1.11/history")
+	resp = deviceHistoryResponse{}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Samples == nil || len(resp.Samples) != 0 {
+		t.Errorf("expected an empty history, got %s", rec.Body.String())
+	}
+
+	if rec := get("/api/device/192.168.1.99/history"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown device, got %d", rec.Code)
+	}
+	if rec := get("/api/device/not-an-ip/history"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid ip, got %d", rec.Code)
+	}
+
+	// History of removed devices is dropped
+	stateMgr.RemoveWhere("test", func(d state.Device) bool { return d.IP == "192.168.1.10" })
+	if removed := stateMgr.PruneRTTHistory(); removed != 1 || stateMgr.GetRTTHistory("192.168.1.10") != nil {
+		t.Errorf("expected the removed device's history to be dropped, removed %d", removed)
+	}
+}
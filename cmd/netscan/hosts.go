@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/rs/zerolog/log"
+)
+
+// hostNetworks tracks the IPv4 addresses of the host names in networks, resolved again every host_resolve_interval
+// resolve is called by one goroutine at a time; label and networks may be called concurrently
+type hostNetworks struct {
+	mu      sync.RWMutex
+	hosts   []config.NetworkHost
+	lookup  config.HostLookup
+	current map[string][]string // Host name -> IPv4 addresses of its latest successful resolution
+	labels  map[string]string   // IPv4 address -> label of the first host name resolving to it
+	loaded  map[string]bool     // /32 networks the host names added to networks when the config was loaded
+}
+
+// newHostNetworks starts from the addresses resolved when the config was loaded; lookup resolves them again
+func newHostNetworks(hosts []config.NetworkHost, lookup config.HostLookup) *hostNetworks {
+	h := &hostNetworks{
+		hosts:   hosts,
+		lookup:  lookup,
+		current: make(map[string][]string, len(hosts)),
+		loaded:  make(map[string]bool),
+	}
+	for _, host := range hosts {
+		h.current[host.Name] = host.IPv4
+		for _, cidr := range host.Networks {
+			h.loaded[cidr] = true
+		}
+	}
+	h.labels = h.labelsLocked()
+	return h
+}
+
+// labelsLocked maps every current address to the label of the first host name (in config order) resolving to it
+func (h *hostNetworks) labelsLocked() map[string]string {
+	labels := make(map[string]string)
+	for _, host := range h.hosts {
+		for _, ip := range h.current[host.Name] {
+			if _, ok := labels[ip]; !ok {
+				labels[ip] = host.Label
+			}
+		}
+	}
+	return labels
+}
+
+// resolve resolves every host name again and returns the addresses added and dropped since the previous resolution
+// A name that fails to resolve keeps its addresses, so a DNS outage does not stop their monitoring
+func (h *hostNetworks) resolve(ctx context.Context) (added, dropped []string) {
+	resolved := make(map[string][]string, len(h.hosts))
+	for _, host := range h.hosts {
+		ipv4, _, err := config.ResolveHost(ctx, h.lookup, host.Name)
+		if err != nil {
+			log.Warn().Str("host", host.Name).Err(err).Msg("Failed to resolve networks host name, keeping its addresses")
+			continue
+		}
+		resolved[host.Name] = ipv4
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	before := h.labels
+	for name, ipv4 := range resolved {
+		h.current[name] = ipv4
+	}
+	h.labels = h.labelsLocked()
+	for ip := range h.labels {
+		if _, ok := before[ip]; !ok {
+			added = append(added, ip)
+		}
+	}
+	for ip := range before {
+		if _, ok := h.labels[ip]; !ok {
+			dropped = append(dropped, ip)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(dropped)
+	return added, dropped
+}
+
+// label returns the network label of ip when a host name currently resolves to it
+func (h *hostNetworks) label(ip string) (string, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	label, ok := h.labels[ip]
+	return label, ok
+}
+
+// networks returns base (the configured networks) without the /32s of addresses the host names no longer
+// resolve to, plus /32s of addresses they resolved to since the config was loaded
+func (h *hostNetworks) networks(base []string) []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	networks := make([]string, 0, len(base))
+	listed := make(map[string]bool, len(base))
+	for _, cidr := range base {
+		listed[cidr] = true
+		if h.loaded[cidr] {
+			if _, ok := h.labels[strings.TrimSuffix(cidr, "/32")]; !ok {
+				continue
+			}
+		}
+		networks = append(networks, cidr)
+	}
+	var added []string
+	for ip := range h.labels {
+		if !listed[ip+"/32"] {
+			added = append(added, ip+"/32")
+		}
+	}
+	sort.Strings(added)
+	return append(networks, added...)
+}
+
+// networkResolver extends the configured network resolver with the labels of addresses of host names
+func (h *hostNetworks) networkResolver(base func(ip string) string) func(ip string) string {
+	return func(ip string) string {
+		if label, ok := h.label(ip); ok {
+			return label
+		}
+		return base(ip)
+	}
+}
+
+// hostChange is the outcome of one host name resolution, handled by the main event loop
+type hostChange struct {
+	added   []string // Addresses the host names resolve to since the previous resolution
+	dropped []string // Addresses no host name resolves to anymore
+}
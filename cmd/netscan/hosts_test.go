@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/kljama/netscan/internal/config"
+)
+
+// TestHostNetworksResolve verifies re-resolution adds and drops addresses, keeps them on DNS failures
+// and leaves explicitly configured networks alone
+func TestHostNetworksResolve(t *testing.T) {
+	records := map[string][]string{"core-sw1.example.com": {"10.0.0.2", "10.0.0.3"}}
+	fail := false
+	lookup := func(ctx context.Context, name string) ([]net.IP, error) {
+		if fail {
+			return nil, errors.New("server misbehaving")
+		}
+		var ips []net.IP
+		for _, addr := range records[name] {
+			ips = append(ips, net.ParseIP(addr))
+		}
+		return ips, nil
+	}
+
+	// 10.0.0.1 was listed explicitly before the host name, so the host only added 10.0.0.2
+	hosts := newHostNetworks([]config.NetworkHost{{
+		Name: "core-sw1.example.com", Label: "core", IPv4: []string{"10.0.0.1", "10.0.0.2"}, Networks: []string{"10.0.0.2/32"},
+	}}, lookup)
+	base := []string{"192.168.1.0/24", "10.0.0.1/32", "10.0.0.2/32"}
+
+	added, dropped := hosts.resolve(context.Background())
+	if !reflect.DeepEqual(added, []string{"10.0.0.3"}) || !reflect.DeepEqual(dropped, []string{"10.0.0.1"}) {
+		t.Errorf("expected 10.0.0.3 added and 10.0.0.1 dropped, got %v and %v", added, dropped)
+	}
+	want := []string{"192.168.1.0/24", "10.0.0.1/32", "10.0.0.2/32", "10.0.0.3/32"}
+	if got := hosts.networks(base); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected networks %v, got %v", want, got)
+	}
+	if label, ok := hosts.label("10.0.0.3"); !ok || label != "core" {
+		t.Errorf("expected the new address to be labeled core, got %q", label)
+	}
+
+	records["core-sw1.example.com"] = []string{"10.0.0.3"}
+	hosts.resolve(context.Background())
+	want = []string{"192.168.1.0/24", "10.0.0.1/32", "10.0.0.3/32"}
+	if got := hosts.networks(base); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the dropped host address to leave networks, got %v", got)
+	}
+
+	fail = true
+	if added, dropped := hosts.resolve(context.Background()); added != nil || dropped != nil {
+		t.Errorf("expected a failed lookup to keep the addresses, got %v added and %v dropped", added, dropped)
+	}
+
+	resolve := hosts.networkResolver(func(ip string) string { return "configured" })
+	if resolve("10.0.0.3") != "core" || resolve("192.168.1.5") != "configured" {
+		t.Error("expected host addresses to resolve to the host label and others to the configured network")
+	}
+}
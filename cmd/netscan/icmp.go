@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/monitoring"
+	"github.com/kljama/netscan/internal/ratelimit"
+	"github.com/rs/zerolog/log"
+)
+
+// pinging is the ICMP setup shared by the ping engine, discovery sweeps and traceroutes
+type pinging struct {
+	engine     monitoring.Prober // ping_engine with the monitoring probe profile
+	privileged bool              // Raw ICMP sockets (false: unprivileged UDP sockets), resolved from icmp_mode
+	source     string            // Local IPv4 address ICMP and SNMP probes are sent from ("" = any)
+	close      func()            // Closes the batch engine's shared socket (a no-op for pro-bing)
+
+	// Discovery and monitoring share one token bucket per network_rate_limits partition (nil = none)
+	networkLimits *ratelimit.Partitions
+}
+
+// setupPinging resolves icmp_mode and the probe source address, applies the per-network rate limits,
+// and creates the monitoring ping engine (ping_engine) with the monitoring probe profile
+func setupPinging(cfg *config.Config) (*pinging, error) {
+	privileged, err := monitoring.ResolveICMPMode(cfg.ICMPMode)
+	if err != nil {
+		return nil, err
+	}
+	// ICMP and SNMP probes leave from source_ip/source_interface when set
+	source, err := cfg.SourceAddress()
+	if err != nil {
+		return nil, err
+	}
+	if source != "" {
+		log.Info().
+			Str("source_ip", source).
+			Str("source_interface", cfg.SourceInterface).
+			Msg("Probes bound to source address")
+	}
+
+	networkLimits := ratelimit.NewPartitions(cfg.NetworkRateLimits)
+	if networkLimits != nil {
+		log.Info().Int("partitions", networkLimits.Count()).Msg("Per-network ping rate limits enabled")
+	}
+	log.Info().
+		Str("icmp_mode", cfg.ICMPMode).
+		Bool("privileged", privileged).
+		Msg("ICMP socket mode selected")
+
+	// The batch engine shares one raw or UDP ICMP socket across all devices
+	if cfg.PingEngine != monitoring.PingEngineBatch {
+		return &pinging{
+			engine:        monitoring.NewProBingProber(privileged, source, cfg.ProbeProfiles.Monitoring),
+			privileged:    privileged,
+			source:        source,
+			close:         func() {},
+			networkLimits: networkLimits,
+		}, nil
+	}
+	batchProber, err := monitoring.NewBatchProber(privileged, source, cfg.ProbeProfiles.Monitoring)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start batch ping engine: %v", err)
+	}
+	return &pinging{
+		engine:        batchProber,
+		privileged:    privileged,
+		source:        source,
+		close:         func() { batchProber.Close() },
+		networkLimits: networkLimits,
+	}, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/influx"
+	"github.com/kljama/netscan/internal/output"
+)
+
+// influxDestination is one InfluxDB the daemon writes to: the influxdb block or one of influxdb.mirrors
+// Every destination has its own writers, so batching, retries and health checks are independent
+type influxDestination struct {
+	name    string              // Mirror name ("" for the primary influxdb block)
+	writer  *influx.Writer      // Writes devices outside every site and the health metrics
+	writers []*influx.Writer    // writer followed by one writer per site
+	sink    output.Sink         // Routes each device's points to its site's writer
+	health  *influx.HealthCache // Background health status, set once connectivity has been checked
+}
+
+// newInfluxDestination creates the writers of one destination; each site's points go to the site's bucket
+// (falling back to the target's bucket, or database with version 1) with the site's tags, like on the primary
+func newInfluxDestination(cfg *config.Config, name string, target config.InfluxDBTarget) *influxDestination {
+	newWriter := func(bucket string) *influx.Writer {
+		var w *influx.Writer
+		if target.V1() {
+			w = influx.NewV1Writer(target.URL, target.Username, target.Password, bucket, target.RetentionPolicy, target.HealthBucket, cfg.InfluxDB.BatchSize, cfg.InfluxDB.FlushInterval)
+		} else {
+			w = influx.NewWriter(target.URL, target.Token, target.Org, bucket, target.HealthBucket, cfg.InfluxDB.BatchSize, cfg.InfluxDB.FlushInterval)
+		}
+		w.SetShutdownTimeout(cfg.InfluxDB.ShutdownTimeout)
+		w.SetAddressPolicy(cfg.AddressPolicy()) // Same allow_loopback / allow_link_local as the pingers
+		return w
+	}
+	bucket := target.Bucket
+	d := &influxDestination{name: name, writer: newWriter(bucket)}
+	d.writers = append(d.writers, d.writer)
+	if len(cfg.Sites) == 0 {
+		d.sink = d.writer
+		return d
+	}
+	siteSinks := make(map[string]output.Sink, len(cfg.Sites))
+	for i := range cfg.Sites {
+		site := &cfg.Sites[i]
+		siteWriter := newWriter(site.InfluxDB.BucketOr(bucket))
+		siteWriter.SetStaticTags(site.Tags())
+		d.writers = append(d.writers, siteWriter)
+		siteSinks[site.Name] = siteWriter
+	}
+	d.sink = output.NewRouter(cfg.SiteResolver(), siteSinks, d.writer)
+	return d
+}
+
+// close flushes and closes every writer of the destination
+func (d *influxDestination) close() {
+	for _, w := range d.writers {
+		w.Close()
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"os"
 	"os/signal"
 	"runtime"
@@ -10,26 +11,46 @@ import (
 	"sync/atomic"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Embedded timezone database for the timezone setting (alpine images ship without one)
 
+	"github.com/kljama/netscan/internal/checks"
 	"github.com/kljama/netscan/internal/config"
 	"github.com/kljama/netscan/internal/discovery"
+	"github.com/kljama/netscan/internal/events"
+	"github.com/kljama/netscan/internal/history"
 	"github.com/kljama/netscan/internal/influx"
+	"github.com/kljama/netscan/internal/inventory"
 	"github.com/kljama/netscan/internal/logger"
 	"github.com/kljama/netscan/internal/monitoring"
+	"github.com/kljama/netscan/internal/notify"
+	"github.com/kljama/netscan/internal/output"
 	"github.com/kljama/netscan/internal/state"
 	"github.com/rs/zerolog/log"
 	"golang.org/x/time/rate"
 )
 
 func main() {
+	// Subcommands (e.g. "netscan import telegraf ping.conf") run to completion and exit
+	if code, handled := runSubcommand(os.Args[1:]); handled {
+		os.Exit(code)
+	}
+
 	configPath := flag.String("config", "config.yml", "Path to configuration file")
+	outputDest := flag.String("output", "", "Also stream probe results as NDJSON to this file ('-' for stdout)")
+	varsPath := flag.String("vars", "", "Per-site variables file overriding the config's vars block")
+	logLevel := flag.String("log-level", "info", "Log level: debug, info, warn or error (DEBUG=true forces debug)")
+	logFormat := flag.String("log-format", "", "Log format: json or console (default json, console with ENVIRONMENT=development)")
 	flag.Parse()
 
 	// Initialize structured logging
-	logger.Setup(false) // Set to true for debug mode
+	// When streaming results to stdout, logs must stay on stderr so pipelines only see NDJSON
+	if err := logger.Configure(logger.Options{Level: *logLevel, Format: *logFormat, Stderr: *outputDest == "-"}); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid logging flags: %v\n", err)
+		os.Exit(2)
+	}
 
 	log.Info().Msg("netscan starting up...")
-	cfg, err := config.LoadConfig(*configPath)
+	cfg, err := config.LoadConfigWithVars(*configPath, *varsPath)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to load config")
 	}
@@ -43,29 +64,329 @@ func main() {
 		log.Warn().Str("warning", warning).Msg("Configuration warning")
 	}
 
+	// Per-module log levels (log_levels) refine the -log-level flag
+	if len(cfg.LogLevels) > 0 {
+		if err := logger.SetModuleLevels(cfg.LogLevels); err != nil {
+			log.Fatal().Err(err).Msg("invalid log_levels")
+		}
+		log.Info().Interface("log_levels", cfg.LogLevels).Msg("Per-module log levels enabled")
+	}
+
 	// Initialize state manager (single source of truth for devices)
 	stateMgr := state.NewManager(cfg.MaxDevices)
+	// Pruned devices that reappear within tombstone_ttl are restored instead of re-discovered
+	stateMgr.EnableTombstones(cfg.TombstoneTTL)
+	// Devices remember the configured network (or network_labels label) they belong to
+	// Addresses of host names in networks are labeled with the host name, also after it is resolved again
+	hostNets := newHostNetworks(cfg.NetworkHosts, config.LookupHost)
+	stateMgr.SetNetworkResolver(hostNets.networkResolver(cfg.NetworkResolver()))
+	// Devices are classified (device_type) from sysDescr, sysObjectID and fingerprinted ports
+	stateMgr.SetClassifier(cfg.DeviceClassification.Classify)
+	// And get the custom tags of the device_tags rules matching their address and hostname
+	stateMgr.SetTagger(cfg.DeviceTagger())
+	// And, with os_fingerprinting, get an OS family from sysDescr, the echo reply TTL and open ports
+	if cfg.OSFingerprinting.Enabled {
+		stateMgr.SetOSInferrer(config.InferOSFamily)
+	}
 
-	// Initialize InfluxDB writer with health check and batching
-	writer := influx.NewWriter(
-		cfg.InfluxDB.URL,
-		cfg.InfluxDB.Token,
-		cfg.InfluxDB.Org,
-		cfg.InfluxDB.Bucket,
-		cfg.InfluxDB.HealthBucket,
-		cfg.InfluxDB.BatchSize,
-		cfg.InfluxDB.FlushInterval,
-	)
-	defer writer.Close()
-
-	log.Info().Msg("Checking InfluxDB connectivity...")
-	if err := writer.HealthCheck(); err != nil {
-		log.Fatal().Err(err).Msg("InfluxDB connection failed")
+	// Devices whose circuit breaker keeps tripping are quarantined (no longer probed) until an operator reviews them
+	if cfg.QuarantineTrips > 0 {
+		stateMgr.EnableQuarantine(cfg.QuarantineTrips, cfg.QuarantineWindow)
+		if cfg.QuarantineFile != "" {
+			if err := stateMgr.LoadQuarantine(cfg.QuarantineFile); err != nil {
+				log.Warn().Err(err).Msg("Failed to load quarantine list, starting empty")
+			}
+		}
+		log.Info().
+			Int("quarantine_trips", cfg.QuarantineTrips).
+			Dur("quarantine_window", cfg.QuarantineWindow).
+			Int("quarantined", len(stateMgr.Quarantined())).
+			Msg("Device quarantine enabled")
+	}
+
+	// Recent ping cycles per device for /api/device/{ip}/history
+	stateMgr.EnableRTTHistory(cfg.RTTHistorySamples)
+
+	// Local daily ping rollups (rollup_days); they replace InfluxDB when influxdb.url is empty
+	if cfg.RollupDays > 0 {
+		stateMgr.EnableRollups(cfg.RollupDays, cfg.Location)
+		if cfg.RollupFile != "" {
+			if err := stateMgr.LoadRollups(cfg.RollupFile); err != nil {
+				log.Warn().Err(err).Msg("Failed to load ping rollups, starting empty")
+			}
+		}
+		log.Info().
+			Int("rollup_days", cfg.RollupDays).
+			Str("rollup_file", cfg.RollupFile).
+			Msg("Local ping rollups enabled")
+	}
+
+	// Initialize InfluxDB writer with health check and batching (nil when running on local rollups only)
+	// Multi-site: each site's devices are written by its own writer (site bucket, site=<name> and site tags)
+	// Devices outside every site keep going to the main writer
+	// influxdb.mirrors receive a copy of every point through writers of their own
+	var writer *influx.Writer
+	var influxDestinations []*influxDestination // The primary influxdb block followed by its mirrors
+	var influxMirrors []*influxDestination
+	var sinks output.Multi
+	if cfg.InfluxDB.URL != "" {
+		primary := newInfluxDestination(cfg, "", cfg.InfluxDB.Target())
+		defer primary.close()
+		writer = primary.writer
+		influxDestinations = append(influxDestinations, primary)
+		for _, mirror := range cfg.InfluxDB.Mirrors {
+			destination := newInfluxDestination(cfg, mirror.Name, mirror.Target())
+			defer destination.close()
+			influxDestinations = append(influxDestinations, destination)
+			influxMirrors = append(influxMirrors, destination)
+			log.Info().
+				Str("mirror", mirror.Name).
+				Str("url", mirror.URL).
+				Str("bucket", mirror.Target().Bucket).
+				Int("version", mirror.Version).
+				Msg("InfluxDB mirror configured")
+		}
+		for _, destination := range influxDestinations {
+			sinks = append(sinks, destination.sink)
+		}
+		for _, site := range cfg.Sites {
+			log.Info().
+				Str("site", site.Name).
+				Strs("networks", cfg.DisplayNetworks(site.Networks)).
+				Str("bucket", site.InfluxDB.BucketOr(cfg.InfluxDB.Target().Bucket)).
+				Msg("Site configured")
+		}
+	} else {
+		log.Warn().Msg("InfluxDB not configured, results are only kept as local ping rollups")
+	}
+
+	// Probe results go to InfluxDB and, with --output, to an NDJSON stream as well
+	var stream *output.StreamWriter
+	if *outputDest != "" {
+		stream, err = output.OpenStream(*outputDest)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to open result stream")
+		}
+		defer stream.Close()
+		sinks = append(sinks, stream)
+		log.Info().Str("output", *outputDest).Msg("Streaming probe results as NDJSON")
+	}
+	// Results and device events are published to NATS or Kafka as well (publish)
+	publisher, err := output.NewPublisher(cfg.Publish)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to set up event publishing")
+	}
+	if publisher != nil {
+		sinks = append(sinks, publisher)
+	}
+	var results output.Sink = sinks // An empty Multi discards results
+	if len(sinks) == 1 {
+		results = sinks[0]
+	}
+
+	// Webhook and syslog notifications for device down/up/suspended (nil when no receivers are configured)
+	notifier, err := notify.New(cfg.Notifications)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to set up notifications")
+	}
+
+	// In-process event bus: device, scan and sink events reach every subscriber in publish order
+	// Subscribers drain their queues after the bus is closed at shutdown
+	eventBus := events.New()
+	var subscriberWg sync.WaitGroup
+	startSubscriber(eventBus, "log", &subscriberWg, logEvent)
+	startSubscriber(eventBus, "sinks", &subscriberWg, (&eventSinkWriter{bus: eventBus, results: results, stream: stream, publisher: publisher}).handle)
+	if notifier != nil {
+		startSubscriber(eventBus, "notifications", &subscriberWg, notifyEvent(notifier))
+	}
+
+	// Per-network device counts, RTT and loss written with every health report (subnet_health)
+	subnetHealth := newSubnetHealthReporter(cfg, stateMgr, influxDestinations)
+
+	// Grafana annotations: startup, devices going down, sweeps adding devices and daily re-scans
+	annotations := newAnnotator(influxDestinations)
+	if len(annotations) > 0 {
+		startSubscriber(eventBus, "annotations", &subscriberWg, annotateEvent(annotations))
+	}
+
+	// Device up/down transitions are tracked by the state manager and published as device_state events
+	stateMgr.SetDownThreshold(cfg.DeviceDownAfter)
+	if cfg.PingFailureMode == "windowed" {
+		stateMgr.SetPingFailureWindow(cfg.PingFailureWindow, cfg.PingFailureRatio)
+	}
+	if cfg.PingHalfOpenAfter > 0 {
+		stateMgr.SetHalfOpenProbing(cfg.PingHalfOpenAfter, cfg.PingBackoffMax)
+	}
+	// Repeat offenders escalate through ping_backoff_steps; levels survive restarts with backoff_file
+	if len(cfg.PingBackoffSteps) > 0 {
+		stateMgr.SetBackoffSteps(cfg.PingBackoffSteps, cfg.PingBackoffReset)
+		if cfg.BackoffFile != "" {
+			if err := stateMgr.LoadBackoff(cfg.BackoffFile); err != nil {
+				log.Warn().Err(err).Msg("Failed to load backoff escalation, starting at the first step")
+			}
+		}
+	}
+	stateMgr.SetStateChangeHandler(func(ev state.StateEvent) {
+		eventBus.Publish(events.Event{Type: events.TypeDeviceState, IP: ev.IP, Hostname: ev.Hostname, Time: ev.Time, Payload: ev})
+	})
+	stateMgr.SetSuspensionHandler(func(ip, hostname string, until time.Time) {
+		eventBus.Publish(events.Event{Type: events.TypeDeviceSuspended, IP: ip, Hostname: hostname, Payload: events.Suspension{Until: until}})
+	})
+	stateMgr.SetQuarantineHandler(func(entry state.QuarantineEntry) {
+		eventBus.Publish(events.Event{Type: events.TypeDeviceQuarantined, IP: entry.IP, Hostname: entry.Hostname, Time: entry.QuarantinedAt, Payload: events.Quarantine{
+			Trips:     entry.Trips,
+			FirstTrip: entry.FirstTrip,
+		}})
+	})
+	stateMgr.SetRebootHandler(func(ip, hostname string, reboot state.Reboot) {
+		eventBus.Publish(events.Event{Type: events.TypeDeviceReboot, IP: ip, Hostname: hostname, Payload: events.Reboot{
+			RebootedAt:      reboot.At,
+			UpTimeS:         reboot.UpTime.Seconds(),
+			PreviousUpTimeS: reboot.PreviousUpTime.Seconds(),
+		}})
+	})
+	stateMgr.SetInfoChangeHandler(func(ip, hostname string, changes []state.InfoChange) {
+		fieldChanges := make([]events.FieldChange, len(changes))
+		for i, change := range changes {
+			fieldChanges[i] = events.FieldChange{Field: change.Field, Old: change.Old, New: change.New}
+		}
+		eventBus.Publish(events.Event{Type: events.TypeDeviceChanged, IP: ip, Hostname: hostname, Payload: events.DeviceChange{Changes: fieldChanges}})
+	})
+
+	// Latency thresholds are evaluated on every successful ping cycle and published as latency_alert events
+	var latencyAlerts *checks.LatencyEvaluator
+	if len(cfg.LatencyThresholds) > 0 {
+		latencyAlerts = checks.NewLatencyEvaluator(cfg.LatencyThresholds, stateMgr)
+		latencyAlerts.SetChangeHandler(func(a checks.LatencyAlert) {
+			eventBus.Publish(events.Event{Type: events.TypeLatencyAlert, IP: a.IP, Hostname: a.Hostname, Time: a.Time, Payload: events.LatencyAlert{
+				Threshold:  a.Threshold,
+				Level:      a.Level,
+				Previous:   a.Previous,
+				RTTMs:      float64(a.RTT) / float64(time.Millisecond),
+				LimitMs:    float64(a.Limit) / float64(time.Millisecond),
+				Percentile: a.Percentile,
+			}})
+		})
+		log.Info().Int("thresholds", len(cfg.LatencyThresholds)).Msg("Latency thresholds enabled")
+	}
+
+	// Pingers write through an optional failure coalescer that thins points for long outages
+	var pingResults monitoring.PingWriter = results
+	var coalescer *monitoring.FailureCoalescer
+	if cfg.PingFailureCoalesceAfter > 0 {
+		coalescer = monitoring.NewFailureCoalescer(results, cfg.PingFailureCoalesceAfter, cfg.PingFailureCoalesceEvery)
+		pingResults = coalescer
+		log.Info().
+			Dur("after", cfg.PingFailureCoalesceAfter).
+			Int("every", cfg.PingFailureCoalesceEvery).
+			Msg("Failure point coalescing enabled")
+	}
+
+	// Select raw or unprivileged ICMP sockets (icmp_mode) and the ping engine
+	icmpSetup, err := setupPinging(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to set up ICMP pinging")
+	}
+	defer icmpSetup.close()
+
+	// Optionally keep SNMP sessions open between polls instead of connecting per poll
+	var snmpSessions *monitoring.SNMPSessionCache
+	if cfg.SNMP.MaxSessions > 0 {
+		snmpSessions = monitoring.NewSNMPSessionCache(cfg.SNMP.MaxSessions, cfg.SNMP.SessionIdleTimeout)
+	}
+	// Settings shared by every SNMP poller and the on-demand refresher; polls are spread by a per-device
+	// offset within snmp_interval plus snmp_jitter per cycle, and unchanged device_info is rewritten every
+	// device_info_refresh instead of on every poll
+	snmpOptions := monitoring.SNMPPollOptions{
+		Sessions:          snmpSessions,
+		SourceIP:          icmpSetup.source,
+		Jitter:            cfg.SNMPJitter,
+		DeviceInfoRefresh: cfg.DeviceInfoRefresh,
+	}
+
+	// The target address policy (allow_loopback / allow_link_local) is passed to the ping scheduler,
+	// the SNMP pollers and the InfluxDB writers, so they all accept the same devices
+	addressPolicy := cfg.AddressPolicy()
+	// Planned outages: skip or tag probes so they neither trip circuit breakers nor report devices down
+	// Devices can also be put in maintenance on /api/device/{ip}/maintenance
+	maintenance := newDeviceMaintenance(cfg.MaintenanceResolver())
+	if len(cfg.MaintenanceWindows) > 0 {
+		log.Info().Int("windows", len(cfg.MaintenanceWindows)).Msg("Maintenance windows enabled")
+	}
+	for _, destination := range influxDestinations {
+		for _, w := range destination.writers {
+			w.SetVirtualLookup(stateMgr.VirtualProtocol) // Tag VRRP/HSRP virtual IPs (learned with snmp.poll_redundancy)
+			w.SetNetworkLookup(stateMgr.Network)         // Tag points with the device's configured network (or its label)
+			w.SetDeviceTypeLookup(stateMgr.DeviceType)   // Tag points with the device's classified type
+			w.SetDeviceTagsLookup(stateMgr.Tags)         // Tag points with the device's device_tags
+			w.SetMaintenanceLookup(func(ip string) bool { return maintenance.resolve(ip, time.Now()) != "" })
+			// Tag device_info with this scanner's identity so other instances can detect overlapping ranges
+			w.SetInstanceID(cfg.InstanceID)
+			w.SetMaxStringLength(cfg.InfluxDB.MaxStringLength)
+		}
+	}
+	if len(annotations) > 0 {
+		annotations.annotate(influx.AnnotationConfigLoaded, "", "netscan started",
+			fmt.Sprintf("Configuration loaded from %s: %d networks, %d sites", *configPath, len(cfg.Networks), len(cfg.Sites)))
+	}
+	if addressPolicy.AllowLoopback || addressPolicy.AllowLinkLocal {
+		log.Warn().
+			Bool("allow_loopback", addressPolicy.AllowLoopback).
+			Bool("allow_link_local", addressPolicy.AllowLinkLocal).
+			Msg("Relaxed target address validation enabled")
+	}
+
+	if err := discovery.CheckDiscoverers(cfg.DiscoveryMethods); err != nil {
+		log.Fatal().Err(err).Msg("Invalid discovery configuration")
+	}
+	// Hosts in exclude_networks / exclude_ips are skipped by every discovery sweep,
+	// so they never enter state and are never pinged or polled
+	if len(cfg.ExcludeNetworks) > 0 || len(cfg.ExcludeIPs) > 0 {
+		log.Info().
+			Strs("exclude_networks", cfg.ExcludeNetworks).
+			Strs("exclude_ips", cfg.ExcludeIPs).
+			Msg("Discovery exclusions configured")
+	}
+
+	// Streaming discovery: probe at most discovery_sweep_budget addresses per sweep, resuming from a cursor
+	var sweepCursor *discovery.SweepCursor
+	if cfg.DiscoverySweepBudget > 0 {
+		sweepCursor, err = discovery.NewSweepCursor(cfg.DiscoveryCursorFile, cfg.DiscoverySweepBudget, cfg.Networks)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to load discovery cursor")
+		}
+		log.Info().
+			Int("budget", cfg.DiscoverySweepBudget).
+			Uint64("offset", sweepCursor.Offset).
+			Str("cursor_file", cfg.DiscoveryCursorFile).
+			Msg("Streaming discovery enabled")
+	}
+
+	// InfluxDB health is checked in the background so health endpoints never wait for it
+	var influxHealth *influx.HealthCache
+	if writer != nil {
+		log.Info().Msg("Checking InfluxDB connectivity...")
+		if err := writer.HealthCheck(); err != nil {
+			log.Fatal().Err(err).Msg("InfluxDB connection failed")
+		}
+		influxHealth = influx.NewHealthCache(writer.HealthCheck, cfg.InfluxDB.HealthCheckInterval)
+		influxHealth.Record(nil, time.Now())
+		influxDestinations[0].health = influxHealth
+		log.Info().
+			Int("version", cfg.InfluxDB.Version).
+			Int("batch_size", cfg.InfluxDB.BatchSize).
+			Dur("flush_interval", cfg.InfluxDB.FlushInterval).
+			Msg("InfluxDB connection successful ✓")
+	}
+	// An unreachable mirror does not stop the daemon; its points are retried and dropped independently
+	for _, mirror := range influxMirrors {
+		err := mirror.writer.HealthCheck()
+		if err != nil {
+			log.Warn().Str("mirror", mirror.name).Err(err).Msg("InfluxDB mirror connection failed, writing to it anyway")
+		}
+		mirror.health = influx.NewHealthCache(mirror.writer.HealthCheck, cfg.InfluxDB.HealthCheckInterval)
+		mirror.health.Record(err, time.Now())
 	}
-	log.Info().
-		Int("batch_size", cfg.InfluxDB.BatchSize).
-		Dur("flush_interval", cfg.InfluxDB.FlushInterval).
-		Msg("InfluxDB connection successful ✓")
 
 	// Initialize global rate limiter for ping operations
 	// This controls the sustained rate of ICMP pings across all devices
@@ -75,6 +396,9 @@ func main() {
 		Int("burst_limit", cfg.PingBurstLimit).
 		Msg("Ping rate limiter initialized")
 
+	// Devices of a site are queried with the site's SNMP credentials, all others with the snmp block
+	snmpConfigFor := cfg.SNMPResolver()
+
 	// Initialize global rate limiter for SNMP operations
 	// This controls the sustained rate of SNMP queries across all devices
 	snmpRateLimiter := rate.NewLimiter(rate.Limit(cfg.SNMPRateLimit), cfg.SNMPBurstLimit)
@@ -95,19 +419,14 @@ func main() {
 	// Initialize atomic counter for total SNMP queries sent (for observability/metrics)
 	var totalSNMPQueries atomic.Uint64
 
-	// Map IP addresses to their pinger cancellation functions
-	// CRITICAL: Protected by mutex to prevent concurrent map access
-	activePingers := make(map[string]context.CancelFunc)
-	var pingersMu sync.Mutex
-
-	// Map of IPs currently in the process of stopping
-	// CRITICAL: Prevents starting a new pinger before old one fully exits
-	// This fixes the race condition where a device is pruned and quickly re-discovered
-	stoppingPingers := make(map[string]bool)
-
-	// Channel for pingers to notify when they've fully exited
-	// Buffer size allows multiple pingers to exit concurrently without blocking
-	pingerExitChan := make(chan string, 100)
+	// Continuous pingers share a fixed worker pool driven by a next-due-time heap
+	// Devices are added and removed by pinger reconciliation; no goroutine is created per device
+	pingScheduler := monitoring.NewPingScheduler(cfg.PingInterval, cfg.PingTimeout, cfg.PingsPerCycle, cfg.PingWorkers, pingResults, stateMgr, pingRateLimiter, &currentInFlightPings, &totalPingsSent, cfg.PingMaxConsecutiveFails, cfg.PingBackoffDuration, addressPolicy, maintenance.resolve, icmpSetup.networkLimits)
+	pingScheduler.SetProber(icmpSetup.engine)
+	pingScheduler.SetSpread(cfg.PingStartSpread, cfg.PingJitter)
+	if latencyAlerts != nil {
+		pingScheduler.SetLatencyObserver(latencyAlerts)
+	}
 
 	// Map IP addresses to their SNMP poller cancellation functions
 	// CRITICAL: Protected by mutex to prevent concurrent map access
@@ -130,15 +449,107 @@ func main() {
 		return totalPingsSent.Load()
 	}
 	healthServer := NewHealthServer(cfg.HealthCheckPort, stateMgr, writer, getPingerCount, getPingsSentCount)
+	if cfg.FlagsAPI {
+		healthServer.EnableFlagsAPI()
+	}
+	healthServer.SetAPILimits(cfg.APIRateLimit, cfg.APIBurstLimit)
+	healthServer.SetAPITokens(cfg.APITokens, cfg.APIUsers)
+	healthServer.SetServerTimeouts(cfg.HealthReadTimeout, cfg.HealthWriteTimeout, cfg.HealthIdleTimeout)
+	if cfg.HealthTLSCert != "" {
+		healthServer.SetTLS(cfg.HealthTLSCert, cfg.HealthTLSKey)
+	}
+	if cfg.HealthTLSClientCA != "" {
+		if err := healthServer.SetClientCerts(cfg.HealthTLSClientCA, cfg.HealthTLSClientAuth == "require", cfg.APIClientCerts); err != nil {
+			log.Fatal().Err(err).Msg("Failed to load health_tls_client_ca")
+		}
+	}
+	healthServer.SetEventBus(eventBus)
+	healthServer.SetPingBackoff(cfg.PingBackoffDuration)
+	// Tickers are recorded as they are created below, so /api/schedule reports their actual next runs
+	daemonSched := newDaemonSchedule(cfg)
+	healthServer.SetSchedule(daemonSched)
+	discoveryCtl := newDiscoveryControl(cfg.NetworkLabel)
+	healthServer.SetDiscoveryControl(discoveryCtl)
+	churnStats := newChurnTracker(stateMgr, time.Now())
+	healthServer.SetChurn(churnStats)
+	if len(cfg.Sites) > 0 {
+		healthServer.SetSites(cfg.Sites, cfg.SiteResolver())
+	}
+	if cfg.QuarantineTrips > 0 {
+		healthServer.SetQuarantine(cfg.QuarantineTrips, cfg.QuarantineWindow, cfg.QuarantineFile)
+	}
+	if influxHealth != nil {
+		healthServer.SetInfluxHealth(influxHealth)
+		healthServer.SetInfluxMirrors(influxMirrors)
+	}
+	if len(cfg.APITokens) > 0 {
+		log.Info().Int("tokens", len(cfg.APITokens)).Msg("API token authentication enabled")
+	}
+	snmpRefresher := monitoring.NewSNMPRefresher(&cfg.SNMP, results, stateMgr, snmpRateLimiter, &currentInFlightSNMPQueries, &totalSNMPQueries, cfg.SNMPMaxConsecutiveFails, cfg.SNMPBackoffDuration, addressPolicy, maintenance.resolve, snmpOptions)
+	snmpRefresher.SetConfigResolver(snmpConfigFor)
+	healthServer.SetSNMPRefresher(snmpRefresher)
+	// On-demand probes for POST /api/probe; devices they add are enriched and scheduled by the event loop
+	prober := newDeviceProber(cfg, icmpSetup, pingRateLimiter, snmpRateLimiter, stateMgr, func(ip string) bool {
+		return discovery.InNetworks(ip, hostNets.networks(cfg.Networks))
+	})
+	healthServer.SetProber(prober)
+	probeAdded := prober.added
+	// withOSFamily adds the device's fingerprinted os_family to device_info fields (unchanged when unknown)
+	withOSFamily := func(ip string, fields map[string]string) map[string]string {
+		family := stateMgr.OSFamily(ip)
+		if family == "" {
+			return fields
+		}
+		if fields == nil {
+			fields = make(map[string]string, 1)
+		}
+		fields["os_family"] = family
+		return fields
+	}
+
+	// Worker counts of discovery sweeps and SNMP scans, adjusted by auto-tuning (Ticker 10)
+	discoveryWorkers := newTunedWorkers(cfg)
+	// SNMP re-scans: daily on snmp_daily_schedule (Ticker 9) and per device on POST /api/device/{ip}/rescan
+	snmpRescanner := newSNMPRescan(cfg, stateMgr, results, snmpRateLimiter, icmpSetup.source, func(ip, hostname, sysDescr string) map[string]string {
+		return withOSFamily(ip, config.DeriveDeviceFields(cfg.SNMP.DeviceFields, hostname, sysDescr))
+	})
+	snmpRescanner.done = annotations.annotateRescan
+	snmpRescanner.workers = discoveryWorkers.snmpWorkers
+	healthServer.SetRescanner(snmpRescanner)
+	healthServer.SetMaintenance(maintenance)
+	// Key metrics history (last 24h) so trends stay available while InfluxDB is down
+	metricsHistory, err := history.NewRing(cfg.HealthReportInterval, cfg.HistoryFile)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to restore metrics history, starting empty")
+	}
+	healthServer.SetHistory(metricsHistory)
+	// Inventory reconciliation against the expected devices file (CMDB export), served by /api/report/reconciliation
+	var reconciler *inventory.Reconciler
+	if cfg.InventoryFile != "" {
+		expected, err := inventory.LoadExpected(cfg.InventoryFile)
+		if err != nil {
+			log.Fatal().Err(err).Str("file", cfg.InventoryFile).Msg("Failed to load expected devices file")
+		}
+		reconciler = inventory.NewReconciler(cfg.InventoryFile, stateMgr)
+		healthServer.SetReconciler(reconciler)
+		log.Info().
+			Str("file", cfg.InventoryFile).
+			Int("expected_devices", len(expected)).
+			Dur("interval", cfg.InventoryReportInterval).
+			Msg("Inventory reconciliation enabled")
+	}
+	lastHistorySave := time.Now()
+	lastSampleTime, lastSamplePings := time.Now(), totalPingsSent.Load()
 	if err := healthServer.Start(); err != nil {
 		log.Warn().Err(err).Msg("Health check server failed to start")
 	}
 
-	// WaitGroup for tracking all pinger goroutines
+	// WaitGroup for tracking the ping scheduler and its workers
 	var pingerWg sync.WaitGroup
 
 	// WaitGroup for tracking all SNMP poller goroutines
 	var snmpPollerWg sync.WaitGroup
+	var runningSNMPPollers atomic.Int64 // SNMP pollers that have not exited yet, reported at shutdown
 
 	// Setup signal handling for graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -146,6 +557,42 @@ func main() {
 	mainCtx, stop := context.WithCancel(context.Background())
 	defer stop()
 
+	// Traceroutes to devices that go down or exceed a latency threshold (needs raw ICMP sockets)
+	if cfg.Traceroute.Enabled {
+		if icmpSetup.privileged {
+			tracer := monitoring.NewTracer(cfg.Traceroute, icmpSetup.source, func(ip, hostname, trigger string, at time.Time, hops []monitoring.TraceHop) {
+				for _, hop := range hops {
+					if err := results.WriteTracerouteHop(ip, hostname, trigger, cfg.Traceroute.Protocol, at, hop.TTL, hop.IP, hop.RTT, hop.Reached); err != nil {
+						log.Error().Str("ip", ip).Int("hop", hop.TTL).Err(err).Msg("Failed to write traceroute hop")
+					}
+				}
+			})
+			startSubscriber(eventBus, "traceroute", &subscriberWg, traceEvent(mainCtx, tracer, cfg.Traceroute))
+			log.Info().
+				Str("protocol", cfg.Traceroute.Protocol).
+				Strs("triggers", cfg.Traceroute.Triggers).
+				Msg("Traceroute on alerts enabled")
+		} else {
+			log.Warn().Msg("traceroute needs raw ICMP sockets (root or CAP_NET_RAW), traceroutes disabled")
+		}
+	}
+
+	// Background InfluxDB health checks for the health endpoints, one per destination
+	for _, destination := range influxDestinations {
+		go func() {
+			// Panic recovery for InfluxDB health checker goroutine
+			defer func() {
+				if r := recover(); r != nil {
+					log.Error().
+						Str("mirror", destination.name).
+						Interface("panic", r).
+						Msg("InfluxDB health checker panic recovered")
+				}
+			}()
+			destination.health.Run(mainCtx)
+		}()
+	}
+
 	// Memory monitoring function
 	checkMemoryUsage := func() {
 		var m runtime.MemStats
@@ -160,68 +607,384 @@ func main() {
 	}
 
 	// Ticker 1: ICMP Discovery Loop - finds new devices
+	// The interval stretches toward icmp_discovery_max_interval while sweeps find no churn
+	discoveryInterval := discovery.NewAdaptiveInterval(cfg.IcmpDiscoveryInterval, cfg.IcmpDiscoveryMaxInterval, cfg.IcmpDiscoveryStableSweeps)
 	icmpDiscoveryTicker := time.NewTicker(cfg.IcmpDiscoveryInterval)
 	defer icmpDiscoveryTicker.Stop()
+	daemonSched.started(scheduleDiscovery, cfg.IcmpDiscoveryInterval, time.Now())
 
 	// Ticker 2: Pinger Reconciliation Loop - ensures all devices have pingers
-	reconciliationTicker := time.NewTicker(5 * time.Second)
+	reconciliationTicker := time.NewTicker(pingerReconciliationInterval)
 	defer reconciliationTicker.Stop()
+	daemonSched.started(schedulePingerReconcile, pingerReconciliationInterval, time.Now())
 
 	// Ticker 3: SNMP Poller Reconciliation Loop - ensures all devices have SNMP pollers
-	snmpReconciliationTicker := time.NewTicker(10 * time.Second)
+	snmpReconciliationTicker := time.NewTicker(snmpReconciliationInterval)
 	defer snmpReconciliationTicker.Stop()
+	daemonSched.started(scheduleSNMPReconcile, snmpReconciliationInterval, time.Now())
 
 	// Ticker 4: State Pruning Loop - removes stale devices
-	pruningTicker := time.NewTicker(1 * time.Hour)
+	pruningTicker := time.NewTicker(pruningInterval)
 	defer pruningTicker.Stop()
+	daemonSched.started(schedulePruning, pruningInterval, time.Now())
 
 	// Ticker 5: Health Report Loop - writes health metrics to InfluxDB
 	healthReportTicker := time.NewTicker(cfg.HealthReportInterval)
 	defer healthReportTicker.Stop()
+	daemonSched.started(scheduleHealthReport, cfg.HealthReportInterval, time.Now())
 
-	// Run initial ICMP discovery at startup
-	log.Info().Msg("Starting ICMP discovery scan...")
-	log.Info().Strs("networks", cfg.Networks).Msg("Scanning networks")
-	responsiveIPs := discovery.RunICMPSweep(mainCtx, cfg.Networks, cfg.IcmpWorkers, pingRateLimiter)
-	log.Info().Int("devices_found", len(responsiveIPs)).Msg("ICMP discovery completed")
-	
-	for _, ip := range responsiveIPs {
-		isNew := stateMgr.AddDevice(ip)
-		if isNew {
-			log.Info().Str("ip", ip).Msg("New device found, performing initial SNMP scan")
-			// Trigger immediate SNMP scan in background
-			go func(newIP string) {
-				// Panic recovery for SNMP scan goroutine
-				defer func() {
-					if r := recover(); r != nil {
-						log.Error().
-							Str("ip", newIP).
-							Interface("panic", r).
-							Msg("Initial SNMP scan panic recovered")
-					}
-				}()
-
-				snmpDevices := discovery.RunSNMPScan([]string{newIP}, &cfg.SNMP, cfg.SnmpWorkers)
-				if len(snmpDevices) > 0 {
-					dev := snmpDevices[0]
-					stateMgr.UpdateDeviceSNMP(dev.IP, dev.Hostname, dev.SysDescr)
-					// Write device info to InfluxDB
-					if err := writer.WriteDeviceInfo(dev.IP, dev.Hostname, dev.SysDescr); err != nil {
+	// Ticker 6: Overlap Check Loop - detects other scanners covering the same networks (optional)
+	// A nil channel never fires, so the case is inert when overlap_check_interval is unset
+	var overlapCheckC <-chan time.Time
+	if cfg.OverlapCheckInterval > 0 {
+		overlapCheckTicker := time.NewTicker(cfg.OverlapCheckInterval)
+		defer overlapCheckTicker.Stop()
+		overlapCheckC = overlapCheckTicker.C
+		daemonSched.started(scheduleOverlapCheck, cfg.OverlapCheckInterval, time.Now())
+	}
+
+	// Ticker 7: Composite Check Loop - evaluates config-defined checks against in-memory state (optional)
+	var compositeCheckC <-chan time.Time
+	var compositeChecks *checks.Evaluator
+	if len(cfg.CompositeChecks) > 0 {
+		compositeCheckTicker := time.NewTicker(cfg.CompositeCheckInterval)
+		defer compositeCheckTicker.Stop()
+		compositeCheckC = compositeCheckTicker.C
+		daemonSched.started(scheduleCompositeChecks, cfg.CompositeCheckInterval, time.Now())
+		compositeChecks = checks.NewEvaluator(cfg.CompositeChecks, stateMgr, results)
+		compositeChecks.SetChangeHandler(func(r checks.Result) {
+			eventBus.Publish(events.Event{
+				Type:     events.TypeCompositeCheck,
+				IP:       r.IP,
+				Hostname: r.Hostname,
+				Time:     r.Time,
+				Payload:  events.CheckChange{Check: r.Check, Healthy: r.Healthy, Passed: r.Passed, Total: r.Total, Failed: r.Failed},
+			})
+		})
+	}
+
+	// Ticker 8: Inventory Reconciliation Loop - compares monitored devices with the expected devices file (optional)
+	// The first report is produced after one interval, so discovery has had time to find devices
+	var inventoryReportC <-chan time.Time
+	if reconciler != nil {
+		inventoryReportTicker := time.NewTicker(cfg.InventoryReportInterval)
+		defer inventoryReportTicker.Stop()
+		inventoryReportC = inventoryReportTicker.C
+		daemonSched.started(scheduleInventoryReconcile, cfg.InventoryReportInterval, time.Now())
+	}
+
+	// Ticker 11: Inventory Export Loop - writes timestamped inventory snapshots for audit (optional)
+	// The first snapshot is written after one interval, so discovery has had time to find devices
+	var inventoryExportC <-chan time.Time
+	var inventoryExporter *inventory.Exporter
+	if cfg.InventoryExport.Enabled() {
+		inventoryExporter = inventory.NewExporter(cfg.InventoryExport, stateMgr)
+		inventoryExportTicker := time.NewTicker(cfg.InventoryExport.Interval)
+		defer inventoryExportTicker.Stop()
+		inventoryExportC = inventoryExportTicker.C
+		daemonSched.started(scheduleInventoryExport, cfg.InventoryExport.Interval, time.Now())
+		log.Info().
+			Str("dir", cfg.InventoryExport.Dir).
+			Str("format", cfg.InventoryExport.Format).
+			Int("keep", cfg.InventoryExport.Keep).
+			Dur("interval", cfg.InventoryExport.Interval).
+			Msg("Inventory export enabled")
+	}
+
+	// Ticker 9: Daily full SNMP re-scan at snmp_daily_schedule on snmp_daily_days (optional)
+	// A timer rather than a ticker, re-armed for the next run each time it fires
+	var snmpRescanC <-chan time.Time
+	var snmpRescanTimer *time.Timer
+	rescanSchedule, rescanEnabled := cfg.SNMPRescanSchedule()
+	if rescanEnabled {
+		snmpRescanTimer = time.NewTimer(time.Until(rescanSchedule.Next(time.Now(), cfg.ScheduleLocation())))
+		defer snmpRescanTimer.Stop()
+		snmpRescanC = snmpRescanTimer.C
+		log.Info().
+			Str("schedule", rescanSchedule.String()).
+			Str("timezone", cfg.ScheduleLocation().String()).
+			Msg("Daily SNMP re-scan enabled")
+	}
+
+	// Ticker 10: Auto-tuning - adjusts worker counts and rate limits from probe loss and host load (optional)
+	var autoTuneC <-chan time.Time
+	var tuner *autoTuner
+	if cfg.AutoTune.Enabled {
+		autoTuneTicker := time.NewTicker(cfg.AutoTune.Interval)
+		defer autoTuneTicker.Stop()
+		autoTuneC = autoTuneTicker.C
+		daemonSched.started(scheduleAutoTune, cfg.AutoTune.Interval, time.Now())
+		tuner = newAutoTuner(cfg, runtime.NumCPU())
+		tuner.step(sampleTuning(time.Now())) // Baseline for the first adjustment
+		log.Info().
+			Int("icmp_workers_min", cfg.AutoTune.MinIcmpWorkers).
+			Int("icmp_workers_max", cfg.AutoTune.MaxIcmpWorkers).
+			Int("snmp_workers_min", cfg.AutoTune.MinSnmpWorkers).
+			Int("snmp_workers_max", cfg.AutoTune.MaxSnmpWorkers).
+			Dur("interval", cfg.AutoTune.Interval).
+			Msg("Auto-tuning enabled")
+	}
+
+	// Ticker 12: Host Name Resolution - re-resolves the host names in networks (optional)
+	// Lookups run in the background and report added and dropped addresses on hostsResolved
+	var hostResolveC <-chan time.Time
+	if len(cfg.NetworkHosts) > 0 && cfg.HostResolveInterval > 0 {
+		hostResolveTicker := time.NewTicker(cfg.HostResolveInterval)
+		defer hostResolveTicker.Stop()
+		hostResolveC = hostResolveTicker.C
+		daemonSched.started(scheduleHostResolve, cfg.HostResolveInterval, time.Now())
+	}
+	hostsResolved := make(chan hostChange, 1)
+	hostsResolving := false // Only touched by the main event loop
+
+	// Networks this instance stopped scanning because another scanner covers them (network -> scanner)
+	// Only accessed from the main event loop, so no locking is needed
+	disabledNetworks := make(map[string]string)
+
+	// New devices wait in the ramp (nil = unlimited) until pinger_start_rate lets their monitoring start
+	pingerRamp := newStartRamp(cfg.PingerStartRate)
+	var pingerRampC <-chan time.Time
+	if pingerRamp != nil {
+		pingerRampTicker := time.NewTicker(startRampTick)
+		defer pingerRampTicker.Stop()
+		pingerRampC = pingerRampTicker.C
+		log.Info().Float64("pinger_start_rate", cfg.PingerStartRate).Msg("New device ramp-up enabled")
+	}
+
+	// scanNewDevice starts the initial SNMP scan of a new device in the background
+	scanNewDevice := func(ip string) {
+		go func(newIP string) {
+			// Panic recovery for SNMP scan goroutine
+			defer func() {
+				if r := recover(); r != nil {
+					log.Error().
+						Str("ip", newIP).
+						Interface("panic", r).
+						Msg("Initial SNMP scan panic recovered")
+				}
+			}()
+
+			// Fingerprint ports first, so the device_info point below carries the final device_type
+			if ports, timeout := cfg.FingerprintProbe(); len(ports) > 0 {
+				open := discovery.OpenPorts(mainCtx, newIP, ports, timeout, pingRateLimiter, icmpSetup.networkLimits)
+				deviceType := stateMgr.UpdateDevicePorts(newIP, open, time.Now())
+				log.Debug().
+					Str("ip", newIP).
+					Ints("open_ports", open).
+					Str("device_type", deviceType).
+					Msg("Classification ports probed")
+			}
+			if cfg.OSFingerprinting.Enabled {
+				ttl := discovery.ReplyTTL(mainCtx, newIP, icmpSetup.privileged, icmpSetup.source, pingRateLimiter, icmpSetup.networkLimits)
+				family := stateMgr.UpdateDeviceTTL(newIP, ttl, time.Now())
+				log.Debug().
+					Str("ip", newIP).
+					Int("reply_ttl", ttl).
+					Str("os_family", family).
+					Msg("OS fingerprint probed")
+			}
+
+			snmpDevices := discovery.RunSNMPScan(mainCtx, []string{newIP}, snmpConfigFor(newIP), discoveryWorkers.snmpWorkers(), icmpSetup.source)
+			if len(snmpDevices) > 0 {
+				dev := snmpDevices[0]
+				stateMgr.UpdateDeviceSNMP(dev.IP, dev.Hostname, dev.SysDescr)
+				if !dev.SystemPolledAt.IsZero() {
+					stateMgr.UpdateDeviceSystem(dev.IP, dev.System, dev.SystemPolledAt)
+				}
+				// Write device info to InfluxDB
+				if err := results.WriteDeviceInfoFields(dev.IP, dev.Hostname, dev.SysDescr, dev.System, withOSFamily(dev.IP, config.DeriveDeviceFields(cfg.SNMP.DeviceFields, dev.Hostname, dev.SysDescr))); err != nil {
+					log.Error().
+						Str("ip", dev.IP).
+						Err(err).
+						Msg("Failed to write device info to InfluxDB")
+				} else {
+					log.Info().
+						Str("ip", dev.IP).
+						Str("hostname", dev.Hostname).
+						Str("device_type", stateMgr.DeviceType(dev.IP)).
+						Msg("Device enriched and written to InfluxDB")
+				}
+			} else {
+				log.Debug().Str("ip", newIP).Msg("SNMP scan failed, will retry via continuous SNMP poller")
+				// Without SNMP the fingerprinted OS family is all device_info can tell
+				if dev, ok := stateMgr.Get(newIP); ok && dev.OSFamily != "" {
+					if err := results.WriteDeviceInfoFields(dev.IP, dev.Hostname, dev.SysDescr, dev.System, withOSFamily(dev.IP, nil)); err != nil {
 						log.Error().
 							Str("ip", dev.IP).
 							Err(err).
-							Msg("Failed to write device info to InfluxDB")
-					} else {
-						log.Info().
-							Str("ip", dev.IP).
-							Str("hostname", dev.Hostname).
-							Msg("Device enriched and written to InfluxDB")
+							Msg("Failed to write OS family to InfluxDB")
 					}
-				} else {
-					log.Debug().Str("ip", newIP).Msg("SNMP scan failed, will retry in next daily scan")
 				}
-			}(ip)
+			}
+		}(ip)
+	}
+
+	// enrichNewDevice publishes a device just added to state and starts its initial SNMP scan,
+	// or queues the scan in the ramp together with the device's pingers
+	enrichNewDevice := func(ip string, found events.Discovery) {
+		eventBus.Publish(events.Event{Type: events.TypeDeviceDiscovered, IP: ip, Payload: found})
+		if pingerRamp != nil {
+			pingerRamp.add(ip)
+			return
+		}
+		scanNewDevice(ip)
+	}
+
+	// recordUPnP stores the UPnP descriptions found by SSDP discovery and writes them to device_info
+	// Runs after the sweep, when the devices reported through OnFound are in state
+	recordUPnP := func(found []state.Device) {
+		for _, upnp := range found {
+			if upnp.UPnP.IsZero() || !stateMgr.UpdateDeviceUPnP(upnp.IP, upnp.UPnP, time.Now()) {
+				continue
+			}
+			dev, ok := stateMgr.Get(upnp.IP)
+			if !ok {
+				continue
+			}
+			fields := withOSFamily(dev.IP, upnp.UPnP.Fields())
+			for name, value := range config.DeriveDeviceFields(cfg.SNMP.DeviceFields, dev.Hostname, dev.SysDescr) {
+				fields[name] = value
+			}
+			if err := results.WriteDeviceInfoFields(dev.IP, dev.Hostname, dev.SysDescr, dev.System, fields); err != nil {
+				log.Error().
+					Str("ip", dev.IP).
+					Err(err).
+					Msg("Failed to write UPnP device info to InfluxDB")
+				continue
+			}
+			log.Debug().
+				Str("ip", dev.IP).
+				Str("manufacturer", upnp.UPnP.Manufacturer).
+				Str("model", upnp.UPnP.ModelName).
+				Msg("UPnP description recorded")
+		}
+	}
+
+	// schedulePinger starts continuous pinging of a device unless it is already scheduled
+	// Only called from the main event loop, like every other pingScheduler change
+	schedulePinger := func(ip string) {
+		if pingScheduler.Has(ip) {
+			return
+		}
+		if pingerRamp != nil && pingerRamp.holds(ip) {
+			return // Started when the ramp releases it
+		}
+		if pingScheduler.Count() >= cfg.MaxConcurrentPingers {
+			log.Warn().
+				Int("max_pingers", cfg.MaxConcurrentPingers).
+				Str("ip", ip).
+				Msg("Maximum concurrent pingers reached, skipping device")
+			return
 		}
+		dev, exists := stateMgr.Get(ip)
+		if !exists {
+			dev = &state.Device{IP: ip, Hostname: ip}
+		}
+		log.Debug().Str("ip", ip).Msg("Starting continuous pinger")
+		pingScheduler.Add(*dev)
+	}
+
+	// New devices found by a running sweep are handed to the event loop, which starts their pingers at once
+	// When the buffer is full the next pinger reconciliation picks them up instead
+	discoveredDevices := make(chan string, 1024)
+
+	// handleDiscovered adds a responsive IP to state and, for new devices, starts an initial SNMP scan
+	// Called by discovery sweeps as each device answers, so monitoring starts without waiting for the
+	// whole sweep to finish; returns true for new devices
+	handleDiscovered := func(ip string) bool {
+		if !stateMgr.AddDevice(ip) {
+			return false
+		}
+		enrichNewDevice(ip, events.Discovery{Source: events.SourceSweep})
+		select {
+		case discoveredDevices <- ip:
+		default:
+		}
+		return true
+	}
+
+	// Discovery sweeps run in the background so the event loop keeps reconciling pingers while
+	// devices are found; the number of new devices is reported on sweepDone when a sweep ends
+	// sweepRunning is only touched by the main goroutine (startup and the event loop)
+	// Each sweep gets its own context, so /api/discovery/cancel stops it without touching the daemon
+	sweepDone := make(chan int, 1)
+	sweepRunning := false
+	startSweep := func(networks []string) {
+		sweepRunning = true
+		log.Info().Str("mode", cfg.DiscoveryMode).Strs("methods", cfg.DiscoveryMethods).Msg("Starting discovery scan...")
+		log.Info().Strs("networks", cfg.DisplayNetworks(networks)).Msg("Scanning networks")
+		sweepCtx, progress := discoveryCtl.begin(mainCtx, networks)
+		go func() {
+			newDevices := 0
+			defer func() {
+				// Panic recovery for discovery sweep goroutine
+				if r := recover(); r != nil {
+					log.Error().
+						Interface("panic", r).
+						Msg("Discovery sweep panic recovered")
+				}
+				discoveryCtl.finish(sweepCtx.Err() != nil)
+				sweepDone <- newDevices
+			}()
+
+			started := time.Now()
+			found := discovery.RunDiscoverers(sweepCtx, cfg, &discovery.Sweep{
+				Networks:      networks,
+				Cursor:        sweepCursor,
+				Limiter:       pingRateLimiter,
+				NetworkLimits: icmpSetup.networkLimits,
+				Privileged:    icmpSetup.privileged,
+				SourceIP:      icmpSetup.source,
+				ICMPWorkers:   discoveryWorkers.icmpWorkers(),
+				SNMPWorkers:   discoveryWorkers.snmpWorkers(),
+				Progress:      progress,
+				OnFound: func(ip string) {
+					if handleDiscovered(ip) {
+						newDevices++
+					}
+				},
+			})
+			recordUPnP(found)
+			if sweepCtx.Err() != nil && mainCtx.Err() == nil {
+				log.Info().
+					Int("found", len(found)).
+					Int("new_devices", newDevices).
+					Msg("Discovery sweep cancelled")
+			}
+			eventBus.Publish(events.Event{
+				Type: events.TypeScanCompleted,
+				Payload: events.ScanSummary{
+					Networks:   networks,
+					Found:      len(found),
+					NewDevices: newDevices,
+					DurationS:  time.Since(started).Seconds(),
+				},
+			})
+		}()
+	}
+
+	// Run initial discovery at startup
+	startSweep(cfg.Networks)
+
+	// Passive ARP listener: hosts joining the LAN are added within seconds instead of at the next sweep
+	// Sightings are handled by the event loop, which knows the networks disabled by overlap detection
+	var arpSightings chan discovery.ARPSighting
+	if len(cfg.ARPListenInterfaces) > 0 {
+		arpSightings = make(chan discovery.ARPSighting, 256)
+		go func() {
+			// Panic recovery for passive ARP listener
+			defer func() {
+				if r := recover(); r != nil {
+					log.Error().
+						Interface("panic", r).
+						Msg("Passive ARP listener panic recovered")
+				}
+			}()
+			discovery.ListenARP(mainCtx, cfg.ARPListenInterfaces, cfg.Networks, cfg.Exclusions(), arpSightings)
+		}()
+		log.Info().
+			Strs("interfaces", cfg.ARPListenInterfaces).
+			Msg("Passive ARP listener enabled")
 	}
 
 	// Shutdown handler
@@ -240,31 +1003,85 @@ func main() {
 		stop()
 	}()
 
-	// Pinger exit notification handler
-	// Removes IPs from stoppingPingers when their goroutines fully exit
+	// Ping scheduler: dispatches due devices to the ping workers until shutdown
+	pingerWg.Add(1)
 	go func() {
-		// Panic recovery for exit notification handler
+		defer pingerWg.Done()
+		// Panic recovery for ping scheduler
 		defer func() {
 			if r := recover(); r != nil {
 				log.Error().
 					Interface("panic", r).
-					Msg("Pinger exit notification handler panic recovered")
+					Msg("Ping scheduler panic recovered")
 			}
 		}()
-
-		for {
-			select {
-			case <-mainCtx.Done():
-				return
-			case ip := <-pingerExitChan:
-				pingersMu.Lock()
-				delete(stoppingPingers, ip)
-				log.Debug().Str("ip", ip).Msg("Pinger fully exited, removed from stopping list")
-				pingersMu.Unlock()
-			}
-		}
+		pingScheduler.Run(mainCtx)
 	}()
 
+	// Notification sender: posts queued device events to the configured webhooks and syslog receivers
+	if notifier != nil {
+		go func() {
+			// Panic recovery for notification sender
+			defer func() {
+				if r := recover(); r != nil {
+					log.Error().
+						Interface("panic", r).
+						Msg("Notification sender panic recovered")
+				}
+			}()
+			notifier.Run(mainCtx)
+		}()
+		log.Info().
+			Int("webhooks", len(cfg.Notifications.Webhooks)).
+			Int("syslog", len(cfg.Notifications.Syslog)).
+			Int("rate_limit_per_minute", cfg.Notifications.RateLimit).
+			Msg("Notifications enabled")
+	}
+
+	// Event publisher: delivers queued records to NATS or Kafka; stopped after the event subscribers at shutdown
+	publishCtx, stopPublishing := context.WithCancel(context.Background())
+	defer stopPublishing()
+	publisherDone := make(chan struct{})
+	if publisher != nil {
+		go func() {
+			defer close(publisherDone)
+			// Panic recovery for event publisher
+			defer func() {
+				if r := recover(); r != nil {
+					log.Error().
+						Interface("panic", r).
+						Msg("Event publisher panic recovered")
+				}
+			}()
+			publisher.Run(publishCtx)
+		}()
+		log.Info().
+			Str("type", cfg.Publish.Type).
+			Str("subject", cfg.Publish.Subject).
+			Str("topic", cfg.Publish.Topic).
+			Strs("events", cfg.Publish.Events).
+			Msg("Event publishing enabled")
+	}
+
+	// SNMP session sweeper: closes idle cached sessions, and all of them on shutdown
+	if snmpSessions != nil {
+		go func() {
+			// Panic recovery for SNMP session sweeper
+			defer func() {
+				if r := recover(); r != nil {
+					log.Error().
+						Interface("panic", r).
+						Msg("SNMP session sweeper panic recovered")
+				}
+			}()
+			snmpSessions.Run(mainCtx)
+		}()
+		log.Info().
+			Int("max_sessions", cfg.SNMP.MaxSessions).
+			Dur("idle_timeout", cfg.SNMP.SessionIdleTimeout).
+			Msg("SNMP session cache enabled")
+	}
+
 	// SNMP poller exit notification handler
 	// Removes IPs from stoppingSNMPPollers when their goroutines fully exit
 	go func() {
@@ -292,16 +1109,44 @@ func main() {
 
 	log.Info().Msg("Starting monitoring loops...")
 	log.Info().Dur("icmp_interval", cfg.IcmpDiscoveryInterval).Msg("ICMP Discovery interval")
+	if cfg.IcmpDiscoveryMaxInterval > cfg.IcmpDiscoveryInterval {
+		log.Info().
+			Dur("max_interval", cfg.IcmpDiscoveryMaxInterval).
+			Int("stable_sweeps", cfg.IcmpDiscoveryStableSweeps).
+			Msg("Adaptive ICMP discovery interval enabled")
+	}
+	log.Info().Str("timezone", cfg.ScheduleLocation().String()).Msg("Schedule timezone")
 	log.Info().Msg("Pinger Reconciliation: every 5s")
+	log.Info().Int("ping_workers", cfg.PingWorkers).Str("ping_engine", cfg.PingEngine).Msg("Continuous ping worker pool")
 	log.Info().Msg("SNMP Poller Reconciliation: every 10s")
+	if len(cfg.CompositeChecks) > 0 {
+		log.Info().
+			Int("checks", len(cfg.CompositeChecks)).
+			Dur("interval", cfg.CompositeCheckInterval).
+			Msg("Composite checks enabled")
+	}
 	log.Info().Msg("State Pruning: every 1h")
 	log.Info().Dur("health_interval", cfg.HealthReportInterval).Msg("Health Report interval")
+	if cfg.OverlapCheckInterval > 0 {
+		log.Info().
+			Str("instance_id", cfg.InstanceID).
+			Dur("interval", cfg.OverlapCheckInterval).
+			Str("action", cfg.OverlapAction).
+			Msg("Multi-scanner overlap detection enabled")
+	}
 
 	// Main event loop with all tickers
 	for {
 		select {
 		case <-mainCtx.Done():
 			// Graceful shutdown
+			// Stop the API first, so no scan or refresh is started while monitoring winds down
+			httpCtx, cancelHTTP := context.WithTimeout(context.Background(), healthShutdownTimeout)
+			if err := healthServer.Shutdown(httpCtx); err != nil {
+				log.Warn().Err(err).Msg("Health server did not shut down cleanly")
+			}
+			cancelHTTP()
+
 			log.Info().Msg("Shutting down all pingers and SNMP pollers...")
 			
 			// Stop all tickers
@@ -310,14 +1155,6 @@ func main() {
 			snmpReconciliationTicker.Stop()
 			pruningTicker.Stop()
 			
-			// Cancel all active pingers
-			pingersMu.Lock()
-			for ip, cancel := range activePingers {
-				log.Debug().Str("ip", ip).Msg("Stopping pinger")
-				cancel()
-			}
-			pingersMu.Unlock()
-			
 			// Cancel all active SNMP pollers
 			snmpPollersMu.Lock()
 			for ip, cancel := range activeSNMPPollers {
@@ -326,68 +1163,128 @@ func main() {
 			}
 			snmpPollersMu.Unlock()
 			
-			// Wait for all pingers to exit
-			log.Info().Msg("Waiting for all pingers to stop...")
-			pingerWg.Wait()
+			// Wait for the ping workers and SNMP pollers to exit in parallel, abandoning those stuck in a
+			// ping or SNMP timeout after shutdown_timeout
+			log.Info().Dur("shutdown_timeout", cfg.ShutdownTimeout).Msg("Waiting for all pingers and SNMP pollers to stop...")
+			waitForShutdown(cfg.ShutdownTimeout,
+				shutdownGroup{name: "ping_workers", wg: &pingerWg, total: pingScheduler.Workers(), running: pingScheduler.RunningWorkers},
+				shutdownGroup{name: "snmp_pollers", wg: &snmpPollerWg, total: int(runningSNMPPollers.Load()), running: func() int { return int(runningSNMPPollers.Load()) }},
+			)
+
+			// Let event subscribers write and forward the last events
+			eventBus.Close()
+			subscriberWg.Wait()
+
+			// Deliver the records still queued for NATS or Kafka
+			if publisher != nil {
+				stopPublishing()
+				<-publisherDone
+				publisher.Close()
+				stats := publisher.Stats()
+				log.Info().
+					Uint64("published", stats.Published).
+					Uint64("dropped", stats.Dropped).
+					Uint64("failed", stats.Failed).
+					Msg("Event publisher stopped")
+			}
 			
-			// Wait for all SNMP pollers to exit
-			log.Info().Msg("Waiting for all SNMP pollers to stop...")
-			snmpPollerWg.Wait()
+			// Persist the metrics history for the next start
+			if err := metricsHistory.Save(); err != nil {
+				log.Error().Err(err).Msg("Failed to save metrics history")
+			}
+			saveRollups(stateMgr, cfg.RollupFile)
+			saveQuarantine(stateMgr, cfg.QuarantineFile)
+			saveBackoff(stateMgr, cfg.BackoffFile)
 			
 			log.Info().Msg("Shutdown complete")
 			return
 
 		case <-icmpDiscoveryTicker.C:
-			// ICMP Discovery: Find new devices
+			// ICMP Discovery: Find new devices (results stream in via handleDiscovered)
 			checkMemoryUsage()
-			log.Info().Msg("Starting ICMP discovery scan...")
-			log.Info().Strs("networks", cfg.Networks).Msg("Scanning networks")
-			responsiveIPs := discovery.RunICMPSweep(mainCtx, cfg.Networks, cfg.IcmpWorkers, pingRateLimiter)
-			log.Info().Int("devices_found", len(responsiveIPs)).Msg("ICMP discovery completed")
-			
-			for _, ip := range responsiveIPs {
-				isNew := stateMgr.AddDevice(ip)
-				if isNew {
-					log.Info().Str("ip", ip).Msg("New device found, performing initial SNMP scan")
-					// Trigger immediate SNMP scan in background
-					go func(newIP string) {
-						// Panic recovery for SNMP scan goroutine
-						defer func() {
-							if r := recover(); r != nil {
-								log.Error().
-									Str("ip", newIP).
-									Interface("panic", r).
-									Msg("Initial SNMP scan panic recovered")
-							}
-						}()
+			if sweepRunning {
+				log.Warn().Msg("Previous discovery sweep still running, skipping this interval")
+				continue
+			}
+			startSweep(discovery.FilterNetworks(hostNets.networks(cfg.Networks), disabledNetworks))
 
-						snmpDevices := discovery.RunSNMPScan([]string{newIP}, &cfg.SNMP, cfg.SnmpWorkers)
-						if len(snmpDevices) > 0 {
-							dev := snmpDevices[0]
-							stateMgr.UpdateDeviceSNMP(dev.IP, dev.Hostname, dev.SysDescr)
-							// Write device info to InfluxDB
-							if err := writer.WriteDeviceInfo(dev.IP, dev.Hostname, dev.SysDescr); err != nil {
-								log.Error().
-									Str("ip", dev.IP).
-									Err(err).
-									Msg("Failed to write device info to InfluxDB")
-							} else {
-								log.Info().
-									Str("ip", dev.IP).
-									Str("hostname", dev.Hostname).
-									Msg("Device enriched and written to InfluxDB")
-							}
-						} else {
-							log.Debug().Str("ip", newIP).Msg("SNMP scan failed, will retry via continuous SNMP poller")
-						}
-					}(ip)
+		case <-discoveryCtl.scanRequests:
+			// On-demand discovery requested via POST /api/discovery/scan
+			if sweepRunning {
+				log.Warn().Msg("Discovery sweep already running, ignoring on-demand scan request")
+				continue
+			}
+			startSweep(discovery.FilterNetworks(hostNets.networks(cfg.Networks), disabledNetworks))
+
+		case sighting := <-arpSightings:
+			// Passive ARP: add hosts seen on the LAN, flagged as passively discovered
+			if !discovery.InNetworks(sighting.IP, discovery.FilterNetworks(hostNets.networks(cfg.Networks), disabledNetworks)) {
+				continue
+			}
+			if stateMgr.AddPassiveDevice(sighting.IP, sighting.MAC.String()) {
+				enrichNewDevice(sighting.IP, events.Discovery{Source: events.SourceARP, MAC: sighting.MAC.String(), Interface: sighting.Interface})
+				schedulePinger(sighting.IP)
+			}
+
+		case ip := <-probeAdded:
+			// Device added by POST /api/probe: it may have been pruned or removed since
+			if _, exists := stateMgr.Get(ip); exists {
+				enrichNewDevice(ip, events.Discovery{Source: events.SourceAPI})
+				schedulePinger(ip)
+			}
+
+		case ip := <-discoveredDevices:
+			// Device found by the running sweep: ping it now instead of at the next reconciliation
+			// It may have been pruned or removed since it was reported
+			if _, exists := stateMgr.Get(ip); exists {
+				schedulePinger(ip)
+			}
+
+		case now := <-pingerRampC:
+			// Pinger Ramp-up: start the monitoring of queued new devices at pinger_start_rate
+			for _, ip := range pingerRamp.release(now) {
+				// It may have been pruned or removed while queued
+				if _, exists := stateMgr.Get(ip); !exists {
+					continue
 				}
+				scanNewDevice(ip)
+				schedulePinger(ip)
+			}
+
+		case newDevices := <-sweepDone:
+			sweepRunning = false
+
+			// Close the churn cycle: devices that entered and left state since the previous sweep ended
+			cycle := churnStats.record(time.Now())
+			if !cycle.Zero() {
+				log.Info().
+					Uint64("discovered", cycle.Discovered).
+					Uint64("rediscovered", cycle.Rediscovered).
+					Uint64("pruned", cycle.Pruned).
+					Uint64("evicted", cycle.Evicted).
+					Msg("Discovery cycle device churn")
+			}
+
+			// Devices found faster than pinger_start_rate are still waiting for their monitoring to start
+			if pingerRamp != nil && pingerRamp.pending() > 0 {
+				log.Info().
+					Int("pending", pingerRamp.pending()).
+					Float64("pinger_start_rate", cfg.PingerStartRate).
+					Msg("New devices queued for ramp-up")
+			}
+
+			// Adaptive discovery: stretch the interval on quiet networks, snap back on churn
+			if next, changed := discoveryInterval.RecordSweep(newDevices); changed {
+				icmpDiscoveryTicker.Reset(next)
+				daemonSched.started(scheduleDiscovery, next, time.Now())
+				log.Info().
+					Int("new_devices", newDevices).
+					Dur("next_interval", next).
+					Msg("ICMP discovery interval adjusted")
 			}
 
 		case <-reconciliationTicker.C:
-			// Pinger Reconciliation: Ensure all devices have pingers
-			pingersMu.Lock()
-			
+			// Pinger Reconciliation: Ensure all devices are scheduled for pinging
 			// Get current state IPs
 			currentIPs := stateMgr.GetAllIPs()
 			// Pre-allocate map with exact capacity to avoid reallocation (performance optimization)
@@ -396,78 +1293,19 @@ func main() {
 				currentIPMap[ip] = true
 			}
 			
-			// Start pingers for new devices
-			// CRITICAL: Check both activePingers AND stoppingPingers to prevent race condition
+			// Schedule new devices
+			// A device removed while its ping is in flight can be re-added at once: the old entry is not rescheduled
 			for ip := range currentIPMap {
-				_, isActive := activePingers[ip]
-				_, isStopping := stoppingPingers[ip]
-				
-				// Only start pinger if IP is not active AND not currently stopping
-				if !isActive && !isStopping {
-					if len(activePingers) >= cfg.MaxConcurrentPingers {
-						log.Warn().
-							Int("max_pingers", cfg.MaxConcurrentPingers).
-							Str("ip", ip).
-							Msg("Maximum concurrent pingers reached, skipping device")
-						continue
-					}
-					log.Debug().Str("ip", ip).Msg("Starting continuous pinger")
-					pingerCtx, pingerCancel := context.WithCancel(mainCtx)
-					activePingers[ip] = pingerCancel
-					
-					// Get device info for logging
-					dev, exists := stateMgr.Get(ip)
-					if !exists {
-						dev = &state.Device{IP: ip, Hostname: ip}
-					}
-					
-					pingerWg.Add(1)
-					// Create a wrapper goroutine to handle exit notification
-					go func(d state.Device, ctx context.Context) {
-						// Panic recovery for pinger wrapper
-						defer func() {
-							if r := recover(); r != nil {
-								log.Error().
-									Str("ip", d.IP).
-									Interface("panic", r).
-									Msg("Pinger wrapper panic recovered")
-							}
-						}()
-						
-						// Run the actual pinger
-						monitoring.StartPinger(ctx, &pingerWg, d, cfg.PingInterval, cfg.PingTimeout, writer, stateMgr, pingRateLimiter, &currentInFlightPings, &totalPingsSent, cfg.PingMaxConsecutiveFails, cfg.PingBackoffDuration)
-						
-						// Notify that this pinger has exited
-						select {
-						case pingerExitChan <- d.IP:
-							// Successfully notified
-						case <-mainCtx.Done():
-							// Main context cancelled, don't block on notification
-						}
-					}(*dev, pingerCtx)
-				} else if isStopping {
-					log.Debug().
-						Str("ip", ip).
-						Msg("Pinger is stopping, will start new one after exit completes")
-				}
+				schedulePinger(ip)
 			}
 			
-			// Stop pingers for removed devices
-			// CRITICAL: Move to stoppingPingers first, then call cancelFunc
-			for ip, cancelFunc := range activePingers {
+			// Unschedule removed devices
+			for _, ip := range pingScheduler.IPs() {
 				if !currentIPMap[ip] {
 					log.Debug().Str("ip", ip).Msg("Stopping continuous pinger for stale device")
-					
-					// Move to stoppingPingers BEFORE calling cancelFunc
-					stoppingPingers[ip] = true
-					delete(activePingers, ip)
-					
-					// Now call cancelFunc (asynchronous - doesn't wait for goroutine exit)
-					cancelFunc()
+					pingScheduler.Remove(ip)
 				}
 			}
-			
-			pingersMu.Unlock()
 
 		case <-snmpReconciliationTicker.C:
 			// SNMP Poller Reconciliation: Ensure all devices have SNMP pollers
@@ -487,6 +1325,11 @@ func main() {
 				_, isActive := activeSNMPPollers[ip]
 				_, isStopping := stoppingSNMPPollers[ip]
 				
+				// New devices waiting in the ramp are started after their pingers
+				if pingerRamp != nil && pingerRamp.holds(ip) {
+					continue
+				}
+				
 				// Only start SNMP poller if IP is not active AND not currently stopping
 				if !isActive && !isStopping {
 					if len(activeSNMPPollers) >= cfg.MaxConcurrentSNMPPollers {
@@ -507,8 +1350,10 @@ func main() {
 					}
 					
 					snmpPollerWg.Add(1)
+					runningSNMPPollers.Add(1)
 					// Create a wrapper goroutine to handle exit notification
 					go func(d state.Device, ctx context.Context) {
+						defer runningSNMPPollers.Add(-1)
 						// Panic recovery for SNMP poller wrapper
 						defer func() {
 							if r := recover(); r != nil {
@@ -520,7 +1365,7 @@ func main() {
 						}()
 						
 						// Run the actual SNMP poller
-						monitoring.StartSNMPPoller(ctx, &snmpPollerWg, d, cfg.SNMPInterval, &cfg.SNMP, writer, stateMgr, snmpRateLimiter, &currentInFlightSNMPQueries, &totalSNMPQueries, cfg.SNMPMaxConsecutiveFails, cfg.SNMPBackoffDuration)
+						monitoring.StartSNMPPoller(ctx, &snmpPollerWg, d, cfg.SNMPInterval, snmpConfigFor(d.IP), results, stateMgr, snmpRateLimiter, &currentInFlightSNMPQueries, &totalSNMPQueries, cfg.SNMPMaxConsecutiveFails, cfg.SNMPBackoffDuration, addressPolicy, maintenance.resolve, snmpOptions)
 						
 						// Notify that this SNMP poller has exited
 						select {
@@ -560,13 +1405,183 @@ func main() {
 			pruned := stateMgr.PruneStale(24 * time.Hour)
 			if len(pruned) > 0 {
 				log.Info().Int("count", len(pruned)).Msg("Pruned stale devices")
+				discoveryInterval.RecordPrune(len(pruned))
 				for _, dev := range pruned {
+					if coalescer != nil {
+						coalescer.Forget(dev.IP)
+					}
+					if latencyAlerts != nil {
+						latencyAlerts.Forget(dev.IP)
+					}
 					log.Debug().
 						Str("ip", dev.IP).
 						Str("hostname", dev.Hostname).
 						Msg("Pruned device")
 				}
 			}
+			if expired := stateMgr.PruneTombstones(time.Now()); expired > 0 {
+				log.Debug().Int("count", expired).Msg("Dropped expired device tombstones")
+			}
+			if removed := stateMgr.PruneRollups(time.Now()); removed > 0 {
+				log.Debug().Int("count", removed).Msg("Dropped expired ping rollups")
+			}
+			stateMgr.PruneRTTHistory()
+
+		case <-overlapCheckC:
+			// Overlap Check: compare our devices with fingerprints reported by other scanners
+			// Look back two SNMP intervals, or one past device_info_refresh, so every remote device has written
+			// device_info at least once
+			lookback := max(2*cfg.SNMPInterval, cfg.DeviceInfoRefresh+cfg.SNMPInterval)
+			if lookback < time.Hour {
+				lookback = time.Hour
+			}
+			queryCtx, cancelQuery := context.WithTimeout(mainCtx, 30*time.Second)
+			fingerprints, err := writer.QueryFingerprints(queryCtx, lookback)
+			cancelQuery()
+			if err != nil {
+				log.Warn().Err(err).Msg("Overlap check failed")
+				continue
+			}
+			remote := make([]discovery.Fingerprint, 0, len(fingerprints))
+			for _, fp := range fingerprints {
+				remote = append(remote, discovery.Fingerprint(fp))
+			}
+
+			// Resume networks whose covering scanner no longer reports them
+			for network, scanner := range disabledNetworks {
+				if discovery.CountInNetwork(remote, scanner, network) < cfg.OverlapMinMatches {
+					delete(disabledNetworks, network)
+					log.Info().
+						Str("network", cfg.DisplayNetwork(network)).
+						Str("scanner", scanner).
+						Msg("Other scanner no longer covers network, resuming discovery")
+				}
+			}
+
+			overlaps := discovery.FindOverlaps(cfg.Networks, stateMgr.GetAll(), remote, cfg.OverlapMinMatches)
+			for _, o := range overlaps {
+				log.Warn().
+					Str("network", cfg.DisplayNetwork(o.Network)).
+					Str("scanner", o.Scanner).
+					Int("matching_devices", len(o.IPs)).
+					Strs("sample_ips", o.IPs[:min(len(o.IPs), 5)]).
+					Msg("Another scanner is covering the same network")
+
+				if cfg.OverlapAction != config.OverlapActionDisable {
+					continue
+				}
+				if !o.Yields(cfg.InstanceID) {
+					log.Info().
+						Str("network", cfg.DisplayNetwork(o.Network)).
+						Str("scanner", o.Scanner).
+						Msg("Keeping network; the other scanner has the greater instance_id and should yield")
+					continue
+				}
+				if _, already := disabledNetworks[o.Network]; already {
+					continue
+				}
+
+				// Stop discovering the range and drop its devices; reconciliation stops their pingers and pollers
+				disabledNetworks[o.Network] = o.Scanner
+				removed := stateMgr.RemoveWhere(state.RemovedOverlap, func(d state.Device) bool {
+					return discovery.InNetworks(d.IP, []string{o.Network})
+				})
+				log.Warn().
+					Str("network", cfg.DisplayNetwork(o.Network)).
+					Str("scanner", o.Scanner).
+					Int("devices_removed", len(removed)).
+					Msg("Disabled scanning of overlapping network")
+			}
+
+		case <-hostResolveC:
+			// Host Name Resolution: follow DNS changes of the host names in networks
+			if hostsResolving {
+				log.Warn().Msg("Previous host name resolution still running, skipping this interval")
+				continue
+			}
+			hostsResolving = true
+			go func() {
+				added, dropped := hostNets.resolve(mainCtx)
+				hostsResolved <- hostChange{added: added, dropped: dropped}
+			}()
+
+		case change := <-hostsResolved:
+			hostsResolving = false
+			if len(change.added) > 0 {
+				log.Info().Strs("addresses", change.added).Msg("Host names in networks resolve to new addresses, discovering them with the next sweep")
+			}
+			if len(change.dropped) > 0 {
+				// Stop monitoring dropped addresses unless another configured network still covers them
+				networks := hostNets.networks(cfg.Networks)
+				dropped := make(map[string]bool, len(change.dropped))
+				for _, ip := range change.dropped {
+					dropped[ip] = !discovery.InNetworks(ip, networks)
+				}
+				removed := stateMgr.RemoveWhere(state.RemovedDNS, func(d state.Device) bool {
+					return dropped[d.IP]
+				})
+				log.Info().
+					Strs("addresses", change.dropped).
+					Int("devices_removed", len(removed)).
+					Msg("Host names in networks no longer resolve to these addresses")
+			}
+
+		case <-compositeCheckC:
+			// Composite Checks: combine ping, SNMP and cached SNMP values into named per-device checks
+			evaluated := compositeChecks.Evaluate()
+			log.Debug().Int("results", len(evaluated)).Msg("Composite checks evaluated")
+
+		case <-snmpRescanC:
+			// Daily SNMP Re-scan: re-query every device and re-enrich those whose sysName/sysDescr changed
+			if !snmpRescanner.start(mainCtx) {
+				log.Warn().Msg("Previous daily SNMP re-scan still running, skipping this run")
+			}
+			snmpRescanTimer.Reset(time.Until(rescanSchedule.Next(time.Now(), cfg.ScheduleLocation())))
+
+		case now := <-autoTuneC:
+			// Auto-tuning: back off on probe loss or host pressure, grow while loss is low and the host has headroom
+			settings, reasons := tuner.step(sampleTuning(now))
+			if reasons == nil {
+				continue
+			}
+			discoveryWorkers.set(settings)
+			pingRateLimiter.SetLimit(rate.Limit(settings.PingRate))
+			snmpRateLimiter.SetLimit(rate.Limit(settings.SNMPRate))
+			log.Info().
+				Int("icmp_workers", settings.IcmpWorkers).
+				Int("snmp_workers", settings.SnmpWorkers).
+				Float64("ping_rate_limit", settings.PingRate).
+				Float64("snmp_rate_limit", settings.SNMPRate).
+				Strs("reasons", reasons).
+				Msg("Auto-tuning adjusted workers and rate limits")
+
+		case <-inventoryReportC:
+			// Inventory Reconciliation: missing, unexpected and mismatched devices against the CMDB export
+			report, err := reconciler.Run()
+			if err != nil {
+				log.Error().Err(err).Msg("Inventory reconciliation failed, keeping previous report")
+				continue
+			}
+			log.Info().
+				Int("expected", report.Summary.Expected).
+				Int("matched", report.Summary.Matched).
+				Int("missing", report.Summary.Missing).
+				Int("unexpected", report.Summary.Unexpected).
+				Int("mismatched", report.Summary.Mismatched).
+				Msg("Inventory reconciliation report generated")
+
+		case <-inventoryExportC:
+			// Inventory Export: timestamped snapshot of every device for audit and compliance
+			path, devices, err := inventoryExporter.Run(time.Now())
+			if err != nil {
+				log.Error().Err(err).Str("dir", cfg.InventoryExport.Dir).Msg("Inventory export failed")
+			}
+			if path != "" {
+				log.Info().
+					Str("file", path).
+					Int("devices", devices).
+					Msg("Inventory snapshot written")
+			}
 
 		case <-healthReportTicker.C:
 			// Health Report: Write health metrics to InfluxDB
@@ -575,20 +1590,99 @@ func main() {
 			
 			// Load total pings sent counter
 			pingsSent := totalPingsSent.Load()
+			churn, _ := stateMgr.Churn()
 			
-			writer.WriteHealthMetrics(
-				metrics.DeviceCount,
-				metrics.ActivePingers,
-				metrics.Goroutines,
-				int(metrics.MemoryMB),
-				int(metrics.RSSMB), // new RSS value (MB)
-				metrics.SuspendedDevices, // suspended device count
-				metrics.InfluxDBOK,
-				metrics.InfluxDBSuccessful,
-				metrics.InfluxDBFailed,
-				pingsSent, // total pings sent counter
-			)
+			if writer != nil {
+				writer.WriteHealthMetrics(
+					metrics.DeviceCount,
+					metrics.ActivePingers,
+					metrics.Goroutines,
+					int(metrics.MemoryMB),
+					int(metrics.RSSMB), // new RSS value (MB)
+					metrics.SuspendedDevices, // suspended device count
+					metrics.DevicesDown,      // devices currently reported down
+					metrics.InfluxDBOK,
+					metrics.InfluxDBSuccessful,
+					metrics.InfluxDBFailed,
+					pingsSent, // total pings sent counter
+					churn,     // devices discovered, rediscovered, pruned and evicted
+				)
+			}
+			// Each mirror gets the same metrics with its own write status
+			for _, mirror := range metrics.InfluxDBMirrors {
+				mirror.writer.WriteHealthMetrics(
+					metrics.DeviceCount,
+					metrics.ActivePingers,
+					metrics.Goroutines,
+					int(metrics.MemoryMB),
+					int(metrics.RSSMB),
+					metrics.SuspendedDevices,
+					metrics.DevicesDown,
+					mirror.OK,
+					mirror.Successful,
+					mirror.Failed,
+					pingsSent,
+					churn,
+				)
+			}
+			
+			// Per-network summaries of the interval since the previous report
+			now := time.Now()
+			subnetHealth.write(lastSampleTime, now)
+
+			// Record the history sample regardless of InfluxDB availability
+			pingsPerSec := 0.0
+			if elapsed := now.Sub(lastSampleTime).Seconds(); elapsed > 0 {
+				pingsPerSec = float64(pingsSent-lastSamplePings) / elapsed
+			}
+			lastSampleTime, lastSamplePings = now, pingsSent
+			metricsHistory.Add(history.Sample{
+				Time:        now,
+				DeviceCount: metrics.DeviceCount,
+				DevicesUp:   metrics.DevicesUp,
+				PingsPerSec: pingsPerSec,
+				MemoryMB:    int(metrics.MemoryMB),
+				RSSMB:       int(metrics.RSSMB),
+			})
+			if now.Sub(lastHistorySave) >= history.SaveInterval {
+				lastHistorySave = now
+				if err := metricsHistory.Save(); err != nil {
+					log.Error().Err(err).Msg("Failed to save metrics history")
+				}
+				saveRollups(stateMgr, cfg.RollupFile)
+				saveQuarantine(stateMgr, cfg.QuarantineFile)
+				saveBackoff(stateMgr, cfg.BackoffFile)
+			}
 		}
 	}
 }
 
+// saveRollups persists local ping rollups when rollup_file is configured
+func saveRollups(stateMgr *state.Manager, path string) {
+	if path == "" {
+		return
+	}
+	if err := stateMgr.SaveRollups(path); err != nil {
+		log.Error().Err(err).Msg("Failed to save ping rollups")
+	}
+}
+
+// saveQuarantine persists the quarantine list when quarantine_file is configured
+func saveQuarantine(stateMgr *state.Manager, path string) {
+	if path == "" {
+		return
+	}
+	if err := stateMgr.SaveQuarantine(path); err != nil {
+		log.Error().Err(err).Msg("Failed to save quarantine list")
+	}
+}
+
+// saveBackoff persists the circuit breaker backoff escalation when backoff_file is configured
+func saveBackoff(stateMgr *state.Manager, path string) {
+	if path == "" {
+		return
+	}
+	if err := stateMgr.SaveBackoff(path); err != nil {
+		log.Error().Err(err).Msg("Failed to save backoff escalation")
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/rs/zerolog/log"
+)
+
+// maxDeviceMaintenance bounds maintenance set through the API, like recurring maintenance_windows
+const maxDeviceMaintenance = 7 * 24 * time.Hour
+
+// deviceMaintenance combines the configured maintenance_windows with maintenance set per device on
+// /api/device/{ip}/maintenance; windows set through the API are kept in memory and end with the daemon
+type deviceMaintenance struct {
+	configured func(ip string, at time.Time) string // maintenance_windows (nil = none configured)
+
+	mu      sync.RWMutex
+	devices map[string]deviceMaintenanceWindow
+}
+
+// deviceMaintenanceWindow is a maintenance window set on one device through the API
+type deviceMaintenanceWindow struct {
+	Action string    `json:"action"` // skip or tag
+	Until  time.Time `json:"until"`
+}
+
+// newDeviceMaintenance creates the maintenance resolver on top of the configured windows (nil = none)
+func newDeviceMaintenance(configured func(ip string, at time.Time) string) *deviceMaintenance {
+	return &deviceMaintenance{configured: configured, devices: make(map[string]deviceMaintenanceWindow)}
+}
+
+// resolve reports the maintenance action of a device at a given time: skip when any of its windows skips,
+// tag when one tags, "" outside every window (see config.Config.MaintenanceResolver)
+func (m *deviceMaintenance) resolve(ip string, at time.Time) string {
+	action := ""
+	if m.configured != nil {
+		action = m.configured(ip, at)
+	}
+	if action == config.MaintenanceSkip {
+		return action
+	}
+	if window, ok := m.window(ip, at); ok {
+		if window.Action == config.MaintenanceSkip {
+			return config.MaintenanceSkip
+		}
+		action = config.MaintenanceTag
+	}
+	return action
+}
+
+// window returns the API window of a device if it is active at the given time
+func (m *deviceMaintenance) window(ip string, at time.Time) (deviceMaintenanceWindow, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	window, ok := m.devices[ip]
+	return window, ok && at.Before(window.Until)
+}
+
+// set puts a device in maintenance until window.Until, replacing its previous API window
+func (m *deviceMaintenance) set(ip string, window deviceMaintenanceWindow) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// Drop expired windows so the map does not keep every device ever put in maintenance
+	now := time.Now()
+	for other, w := range m.devices {
+		if !now.Before(w.Until) {
+			delete(m.devices, other)
+		}
+	}
+	m.devices[ip] = window
+}
+
+// clear ends the API window of a device; returns false if none was active
+func (m *deviceMaintenance) clear(ip string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	window, ok := m.devices[ip]
+	delete(m.devices, ip)
+	return ok && time.Now().Before(window.Until)
+}
+
+// deviceMaintenanceRequest is the PUT /api/device/{ip}/maintenance request body
+type deviceMaintenanceRequest struct {
+	Duration string `json:"duration"` // Go duration, up to 7 days
+	Action   string `json:"action"`   // skip (default) or tag
+}
+
+// deviceMaintenanceResponse is the /api/device/{ip}/maintenance response body
+type deviceMaintenanceResponse struct {
+	IP     string                   `json:"ip"`
+	Action string                   `json:"action"`           // Effective action now, including maintenance_windows ("" = none)
+	Window *deviceMaintenanceWindow `json:"window,omitempty"` // Window set through the API
+}
+
+// SetMaintenance serves per-device maintenance on /api/device/{ip}/maintenance; call before Start
+func (hs *HealthServer) SetMaintenance(maintenance *deviceMaintenance) {
+	hs.maintenance = maintenance
+}
+
+// deviceMaintenanceHandler reports whether a device is in maintenance
+func (hs *HealthServer) deviceMaintenanceHandler(w http.ResponseWriter, r *http.Request) {
+	ip, ok := hs.maintenanceTarget(w, r)
+	if !ok {
+		return
+	}
+	writeMaintenanceJSON(w, hs.maintenanceStatus(ip))
+}
+
+// deviceMaintenanceSetHandler puts a device in maintenance for a duration
+func (hs *HealthServer) deviceMaintenanceSetHandler(w http.ResponseWriter, r *http.Request) {
+	ip, ok := hs.maintenanceTarget(w, r)
+	if !ok {
+		return
+	}
+	var req deviceMaintenanceRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	duration, err := time.ParseDuration(req.Duration)
+	if err != nil || duration <= 0 || duration > maxDeviceMaintenance {
+		http.Error(w, "invalid duration: a positive duration up to 168h is required", http.StatusBadRequest)
+		return
+	}
+	if req.Action == "" {
+		req.Action = config.MaintenanceSkip
+	}
+	if req.Action != config.MaintenanceSkip && req.Action != config.MaintenanceTag {
+		http.Error(w, "invalid action: skip or tag is required", http.StatusBadRequest)
+		return
+	}
+
+	window := deviceMaintenanceWindow{Action: req.Action, Until: time.Now().Add(duration)}
+	hs.maintenance.set(ip, window)
+	log.Info().
+		Str("ip", ip).
+		Str("remote", r.RemoteAddr).
+		Str("action", window.Action).
+		Time("until", window.Until).
+		Msg("Device put in maintenance")
+	writeMaintenanceJSON(w, hs.maintenanceStatus(ip))
+}
+
+// deviceMaintenanceClearHandler ends the maintenance set on a device through the API
+// Configured maintenance_windows are not affected
+func (hs *HealthServer) deviceMaintenanceClearHandler(w http.ResponseWriter, r *http.Request) {
+	ip, ok := hs.maintenanceTarget(w, r)
+	if !ok {
+		return
+	}
+	if !hs.maintenance.clear(ip) {
+		http.Error(w, "device not in maintenance set through the API", http.StatusNotFound)
+		return
+	}
+	log.Info().Str("ip", ip).Str("remote", r.RemoteAddr).Msg("Device maintenance ended")
+	writeMaintenanceJSON(w, hs.maintenanceStatus(ip))
+}
+
+// maintenanceTarget parses and authorizes the device of a maintenance request; false means an error response was written
+func (hs *HealthServer) maintenanceTarget(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if hs.maintenance == nil {
+		http.Error(w, "maintenance not available", http.StatusServiceUnavailable)
+		return "", false
+	}
+	parsed := net.ParseIP(r.PathValue("ip"))
+	if parsed == nil {
+		http.Error(w, "invalid device ip", http.StatusBadRequest)
+		return "", false
+	}
+	ip := parsed.String()
+	if !requestToken(r).allows(ip) {
+		http.Error(w, "device outside the token's networks", http.StatusForbidden)
+		return "", false
+	}
+	if _, found := hs.stateMgr.Get(ip); !found {
+		http.Error(w, "device not found", http.StatusNotFound)
+		return "", false
+	}
+	return ip, true
+}
+
+// maintenanceStatus returns the current maintenance of a device
+func (hs *HealthServer) maintenanceStatus(ip string) deviceMaintenanceResponse {
+	now := time.Now()
+	response := deviceMaintenanceResponse{IP: ip, Action: hs.maintenance.resolve(ip, now)}
+	if window, ok := hs.maintenance.window(ip, now); ok {
+		response.Window = &window
+	}
+	return response
+}
+
+// writeMaintenanceJSON writes v as JSON
+func writeMaintenanceJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Debug().Err(err).Msg("Failed to write maintenance response")
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// TestDeviceMaintenanceResolve validates API windows combine with the configured maintenance_windows
+func TestDeviceMaintenanceResolve(t *testing.T) {
+	now := time.Now()
+	configured := func(ip string, at time.Time) string {
+		if ip == "10.0.0.1" {
+			return config.MaintenanceTag
+		}
+		return ""
+	}
+	m := newDeviceMaintenance(configured)
+	if got := m.resolve("10.0.0.2", now); got != "" {
+		t.Errorf("expected no maintenance, got %q", got)
+	}
+
+	m.set("10.0.0.1", deviceMaintenanceWindow{Action: config.MaintenanceSkip, Until: now.Add(time.Hour)})
+	m.set("10.0.0.2", deviceMaintenanceWindow{Action: config.MaintenanceTag, Until: now.Add(time.Hour)})
+	if got := m.resolve("10.0.0.1", now); got != config.MaintenanceSkip {
+		t.Errorf("expected an API skip window to win over a configured tag window, got %q", got)
+	}
+	if got := m.resolve("10.0.0.2", now); got != config.MaintenanceTag {
+		t.Errorf("expected the API tag window, got %q", got)
+	}
+	if got := m.resolve("10.0.0.2", now.Add(2*time.Hour)); got != "" {
+		t.Errorf("expected the API window to have ended, got %q", got)
+	}
+
+	if !m.clear("10.0.0.1") || m.clear("10.0.0.1") {
+		t.Error("expected clear to end the window once")
+	}
+	if got := m.resolve("10.0.0.1", now); got != config.MaintenanceTag {
+		t.Errorf("expected the configured window to remain, got %q", got)
+	}
+}
+
+// TestDeviceMaintenanceHandlers validates setting, reading and ending device maintenance, scoped by token
+func TestDeviceMaintenanceHandlers(t *testing.T) {
+	stateMgr := state.NewManager(100)
+	stateMgr.Add(state.Device{IP: "10.0.1.5"})
+	stateMgr.Add(state.Device{IP: "10.0.2.5"})
+	hs := &HealthServer{stateMgr: stateMgr}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/device/{ip}/maintenance", hs.deviceMaintenanceHandler)
+	mux.HandleFunc("PUT /api/device/{ip}/maintenance", hs.deviceMaintenanceSetHandler)
+	mux.HandleFunc("DELETE /api/device/{ip}/maintenance", hs.deviceMaintenanceClearHandler)
+	branch := newAPIToken("branch", []string{"10.0.1.0/24"})
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, withToken(httptest.NewRequest(method, path, strings.NewReader(body)), branch))
+		return rec
+	}
+
+	if rec := serve(http.MethodGet, "/api/device/10.0.1.5/maintenance", ""); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 before SetMaintenance, got %d", rec.Code)
+	}
+	maintenance := newDeviceMaintenance(nil)
+	hs.SetMaintenance(maintenance)
+
+	for _, tt := range []struct {
+		method, path, body string
+		want               int
+	}{
+		{http.MethodPut, "/api/device/10.0.2.5/maintenance", `{"duration":"1h"}`, http.StatusForbidden},
+		{http.MethodPut, "/api/device/10.0.1.9/maintenance", `{"duration":"1h"}`, http.StatusNotFound},
+		{http.MethodPut, "/api/device/10.0.1.5/maintenance", `{"duration":"200h"}`, http.StatusBadRequest},
+		{http.MethodPut, "/api/device/10.0.1.5/maintenance", `{"duration":"1h","action":"pause"}`, http.StatusBadRequest},
+		{http.MethodDelete, "/api/device/10.0.1.5/maintenance", "", http.StatusNotFound},
+	} {
+		if rec := serve(tt.method, tt.path, tt.body); rec.Code != tt.want {
+			t.Errorf("%s %s %s: expected %d, got %d", tt.method, tt.path, tt.body, tt.want, rec.Code)
+		}
+	}
+
+	rec := serve(http.MethodPut, "/api/device/10.0.1.5/maintenance", `{"duration":"2h"}`)
+	var response deviceMaintenanceResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("invalid response %d: %v", rec.Code, err)
+	}
+	if response.Action != config.MaintenanceSkip || response.Window == nil || time.Until(response.Window.Until) < time.Hour {
+		t.Errorf("expected a 2h skip window, got %+v", response)
+	}
+	if maintenance.resolve("10.0.1.5", time.Now()) != config.MaintenanceSkip {
+		t.Error("expected pings of the device to be skipped")
+	}
+
+	if rec := serve(http.MethodDelete, "/api/device/10.0.1.5/maintenance", ""); rec.Code != http.StatusOK {
+		t.Errorf("expected the maintenance to end, got %d", rec.Code)
+	}
+	if maintenance.resolve("10.0.1.5", time.Now()) != "" {
+		t.Error("expected the device to be out of maintenance")
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/monitoring"
+	"github.com/kljama/netscan/internal/ratelimit"
+	"github.com/kljama/netscan/internal/snmp"
+	"github.com/kljama/netscan/internal/state"
+	"github.com/kljama/netscan/internal/validate"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/time/rate"
+)
+
+// probeTimeout bounds an on-demand probe, including the waits for rate limiter tokens
+const probeTimeout = 30 * time.Second
+
+// probeRequest is the POST /api/probe request body
+type probeRequest struct {
+	IP  string `json:"ip"`
+	Add bool   `json:"add"` // Add the device to monitoring when it answered and is not monitored yet
+}
+
+// probeResponse is the POST /api/probe response body
+type probeResponse struct {
+	IP        string          `json:"ip"`
+	Monitored bool            `json:"monitored"` // The device was already monitored
+	Added     bool            `json:"added"`     // add=true added the device to monitoring
+	Ping      probePingResult `json:"ping"`
+	SNMP      probeSNMPResult `json:"snmp"`
+}
+
+// probePingResult is the outcome of the probe's ping cycle
+type probePingResult struct {
+	Reachable bool    `json:"reachable"`
+	Sent      int     `json:"sent"`
+	Received  int     `json:"received"`
+	LossPct   float64 `json:"loss_pct"`
+	RTTMs     float64 `json:"rtt_ms,omitempty"` // Average RTT of the replies
+	Error     string  `json:"error,omitempty"`  // The cycle could not be run
+}
+
+// probeSNMPResult is the outcome of the probe's SNMP get of the system group
+type probeSNMPResult struct {
+	OK          bool    `json:"ok"`
+	Hostname    string  `json:"hostname,omitempty"`    // sysName
+	Description string  `json:"description,omitempty"` // sysDescr
+	ObjectID    string  `json:"object_id,omitempty"`   // sysObjectID
+	UpTimeS     float64 `json:"uptime_s,omitempty"`    // sysUpTime
+	Location    string  `json:"location,omitempty"`    // sysLocation
+	Contact     string  `json:"contact,omitempty"`     // sysContact
+	Error       string  `json:"error,omitempty"`
+}
+
+// deviceProber runs on-demand probes for POST /api/probe with the daemon's ping and SNMP settings and rate limiters
+// Results are returned to the caller only; they are not written to InfluxDB and do not touch circuit breakers
+type deviceProber struct {
+	pingEngine  monitoring.Prober
+	pingCount   int
+	pingTimeout time.Duration // Timeout of the cycle's last packet, like the continuous pingers'
+	pingLimiter *rate.Limiter
+	pingLimits  *ratelimit.Partitions // network_rate_limits partitions shared with the pingers (nil = none)
+	snmpLimiter *rate.Limiter
+	snmpFor     func(ip string) *config.SNMPConfig // SNMP settings of the device's site
+	localAddr   string                             // SNMP source address ("" = any)
+	exclusions  *config.Exclusions                 // exclude_networks and exclude_ips, never probed
+	policy      config.AddressPolicy               // allow_loopback and allow_link_local
+	monitorable func(ip string) bool               // Whether ip is inside a scanned network; nothing else is probed
+	stateMgr    *state.Manager
+	added       chan string // Devices added by probes, enriched and scheduled by the event loop
+}
+
+// newDeviceProber creates a prober with the daemon's settings and ping setup; added devices are sent on added
+func newDeviceProber(cfg *config.Config, icmpSetup *pinging, pingLimiter, snmpLimiter *rate.Limiter, stateMgr *state.Manager, monitorable func(ip string) bool) *deviceProber {
+	localAddr := ""
+	if icmpSetup.source != "" {
+		localAddr = net.JoinHostPort(icmpSetup.source, "0")
+	}
+	return &deviceProber{
+		pingEngine:  icmpSetup.engine,
+		pingCount:   cfg.PingsPerCycle,
+		pingTimeout: cfg.PingTimeout,
+		pingLimiter: pingLimiter,
+		pingLimits:  icmpSetup.networkLimits,
+		snmpLimiter: snmpLimiter,
+		snmpFor:     cfg.SNMPResolver(),
+		localAddr:   localAddr,
+		exclusions:  cfg.Exclusions(),
+		policy:      cfg.AddressPolicy(),
+		monitorable: monitorable,
+		stateMgr:    stateMgr,
+		added:       make(chan string, 16),
+	}
+}
+
+// probe pings ip and queries its system group, both after waiting for a rate limiter token
+func (p *deviceProber) probe(ctx context.Context, ip string) (probePingResult, probeSNMPResult) {
+	var ping probePingResult
+	stats, err := monitoring.ProbeLimited(ctx, p.pingLimiter, p.pingLimits, p.pingEngine, p.policy, ip, p.pingCount, p.pingTimeout)
+	if err != nil {
+		ping.Error = err.Error()
+	} else {
+		ping = probePingResult{
+			Reachable: stats.PacketsRecv > 0,
+			Sent:      stats.PacketsSent,
+			Received:  stats.PacketsRecv,
+			LossPct:   stats.PacketLoss,
+		}
+		if stats.PacketsRecv > 0 {
+			ping.RTTMs = float64(stats.AvgRtt.Microseconds()) / 1000
+		}
+	}
+
+	var result probeSNMPResult
+	if err := p.snmpLimiter.Wait(ctx); err != nil {
+		result.Error = fmt.Sprintf("waiting for SNMP rate limiter: %v", err)
+		return ping, result
+	}
+	hostname, sysDescr, system, err := querySNMPSystem(ctx, ip, p.snmpFor(ip), p.localAddr)
+	if err != nil {
+		result.Error = err.Error()
+		return ping, result
+	}
+	return ping, probeSNMPResult{
+		OK:          true,
+		Hostname:    hostname,
+		Description: sysDescr,
+		ObjectID:    system.ObjectID,
+		UpTimeS:     system.UpTime.Seconds(),
+		Location:    system.Location,
+		Contact:     system.Contact,
+	}
+}
+
+// querySNMPSystem queries sysName, sysDescr and the rest of the system group of ip with snmpConfig
+func querySNMPSystem(ctx context.Context, ip string, snmpConfig *config.SNMPConfig, localAddr string) (string, string, state.SystemInfo, error) {
+	client, err := snmp.Dial(ctx, ip, snmp.NewOptions(snmpConfig, localAddr))
+	if err != nil {
+		return "", "", state.SystemInfo{}, fmt.Errorf("connect to %s failed: %v", ip, err)
+	}
+	defer client.Close()
+	variables, err := client.GetWithFallback(ctx, []string{snmp.OIDSysName, snmp.OIDSysDescr})
+	if err != nil || len(variables) < 2 {
+		return "", "", state.SystemInfo{}, fmt.Errorf("no SNMP answer from %s:%d (%v); check the community, snmp.port and the device's ACLs",
+			ip, snmpConfig.Port, err)
+	}
+	sysName, _ := validate.SNMPString(variables[0].Value, "sysName", snmpConfig.MaxStringLength)
+	sysDescr, _ := validate.SNMPString(variables[1].Value, "sysDescr", snmpConfig.MaxStringLength)
+	system, _ := snmp.QuerySystemInfo(ctx, client, snmpConfig.MaxStringLength)
+	return sysName, sysDescr, system, nil
+}
+
+// probeHandler probes one device immediately and returns the result, optionally adding the device to monitoring
+func (hs *HealthServer) probeHandler(w http.ResponseWriter, r *http.Request) {
+	if hs.prober == nil {
+		http.Error(w, "probing not available", http.StatusServiceUnavailable)
+		return
+	}
+	var req probeRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	parsed := net.ParseIP(req.IP)
+	if parsed == nil || parsed.To4() == nil {
+		http.Error(w, "invalid ip: an IPv4 address is required", http.StatusBadRequest)
+		return
+	}
+	ip := parsed.String()
+	if !requestToken(r).allows(ip) {
+		http.Error(w, "device outside the token's networks", http.StatusForbidden)
+		return
+	}
+	// Probes send the site's SNMP credentials, so only addresses netscan would scan itself are probed
+	if !hs.prober.monitorable(ip) {
+		http.Error(w, "address is outside the scanned networks", http.StatusForbidden)
+		return
+	}
+	if err := hs.prober.policy.ValidateIP(ip); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if hs.prober.exclusions.Contains(ip) {
+		http.Error(w, "address is excluded from probing (exclude_networks, exclude_ips)", http.StatusForbidden)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), probeTimeout)
+	defer cancel()
+	response := probeResponse{IP: ip}
+	_, response.Monitored = hs.stateMgr.Get(ip)
+	response.Ping, response.SNMP = hs.prober.probe(ctx, ip)
+
+	if req.Add && !response.Monitored && (response.Ping.Reachable || response.SNMP.OK) && hs.stateMgr.AddDevice(ip) {
+		select {
+		case hs.prober.added <- ip:
+			response.Added = true
+		case <-ctx.Done():
+			// The event loop is busy; reconciliation still starts the device's pinger and SNMP poller
+			response.Added = true
+		}
+	}
+	log.Info().
+		Str("ip", ip).
+		Str("remote", r.RemoteAddr).
+		Bool("reachable", response.Ping.Reachable).
+		Bool("snmp", response.SNMP.OK).
+		Bool("added", response.Added).
+		Msg("On-demand device probe")
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Debug().Err(err).Msg("Failed to write probe response")
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/discovery"
+)
+
+// TestProbeAPIRejectsRequests validates the requests refused before anything is probed
+func TestProbeAPIRejectsRequests(t *testing.T) {
+	hs := &HealthServer{}
+	rec := httptest.NewRecorder()
+	hs.probeHandler(rec, httptest.NewRequest(http.MethodPost, "/api/probe", strings.NewReader(`{"ip":"10.0.0.1"}`)))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without a prober, got %d", rec.Code)
+	}
+
+	hs.SetProber(&deviceProber{
+		exclusions: config.NewExclusions([]string{"10.0.0.128/25"}, []string{"10.0.0.5"}),
+		monitorable: func(ip string) bool {
+			return discovery.InNetworks(ip, []string{"10.0.0.0/24"})
+		},
+		added: make(chan string, 1),
+	})
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{"malformed body", `{"ip":`, http.StatusBadRequest},
+		{"missing ip", `{}`, http.StatusBadRequest},
+		{"host name", `{"ip":"router.example.com"}`, http.StatusBadRequest},
+		{"IPv6 address", `{"ip":"2001:db8::1"}`, http.StatusBadRequest},
+		{"excluded address", `{"ip":"10.0.0.5"}`, http.StatusForbidden},
+		{"excluded network", `{"ip":"10.0.0.200"}`, http.StatusForbidden},
+		{"outside networks", `{"ip":"192.168.1.1"}`, http.StatusForbidden},
+		{"add outside networks", `{"ip":"192.168.1.1","add":true}`, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			hs.probeHandler(rec, httptest.NewRequest(http.MethodPost, "/api/probe", strings.NewReader(tt.body)))
+			if rec.Code != tt.code {
+				t.Errorf("expected %d, got %d: %s", tt.code, rec.Code, rec.Body.String())
+			}
+		})
+	}
+	if len(hs.prober.added) != 0 {
+		t.Error("expected no device added by rejected probes")
+	}
+
+	// Loopback stays refused inside a scanned network unless allow_loopback is set
+	hs.prober.monitorable = func(string) bool { return true }
+	rec = httptest.NewRecorder()
+	hs.probeHandler(rec, httptest.NewRequest(http.MethodPost, "/api/probe", strings.NewReader(`{"ip":"127.0.0.1"}`)))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for loopback under the default address policy, got %d", rec.Code)
+	}
+}
+
+// TestProbeAPIScopedToken ensures network-scoped tokens can only probe their own networks
+func TestProbeAPIScopedToken(t *testing.T) {
+	if !isScopedPath("/api/probe") {
+		t.Fatal("expected /api/probe to be available to network-scoped tokens")
+	}
+	_, scope, _ := net.ParseCIDR("10.0.1.0/24")
+	hs := &HealthServer{}
+	hs.SetProber(&deviceProber{monitorable: func(string) bool { return true }})
+	req := httptest.NewRequest(http.MethodPost, "/api/probe", strings.NewReader(`{"ip":"10.0.0.1"}`))
+	rec := httptest.NewRecorder()
+	hs.probeHandler(rec, withToken(req, &apiToken{name: "branch", networks: []*net.IPNet{scope}}))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 probing outside the token's networks, got %d", rec.Code)
+	}
+}
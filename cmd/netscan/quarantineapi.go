@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/kljama/netscan/internal/state"
+	"github.com/rs/zerolog/log"
+)
+
+// quarantineSettings enables /api/quarantine
+type quarantineSettings struct {
+	trips  int
+	window time.Duration
+	file   string // Saved after every review action ("" = in memory)
+}
+
+// quarantineResponse is the GET /api/quarantine response
+type quarantineResponse struct {
+	Trips   int                     `json:"trips"`  // Circuit breaker trips that quarantine a device
+	Window  string                  `json:"window"` // Window over which trips are counted
+	Devices []state.QuarantineEntry `json:"devices"`
+}
+
+// quarantineRelease is the response of a release
+type quarantineRelease struct {
+	IP     string `json:"ip"`
+	Status string `json:"status"` // Always "released"
+}
+
+// quarantineHandler lists the quarantined and excluded devices for review
+func (hs *HealthServer) quarantineHandler(w http.ResponseWriter, r *http.Request) {
+	if hs.quarantine == nil {
+		http.Error(w, "quarantine not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	writeQuarantineJSON(w, quarantineResponse{
+		Trips:   hs.quarantine.trips,
+		Window:  hs.quarantine.window.String(),
+		Devices: hs.stateMgr.Quarantined(),
+	})
+}
+
+// quarantineReleaseHandler returns a quarantined or excluded device to monitoring
+func (hs *HealthServer) quarantineReleaseHandler(w http.ResponseWriter, r *http.Request) {
+	ip, ok := hs.quarantineTarget(w, r)
+	if !ok {
+		return
+	}
+	if !hs.stateMgr.ReleaseQuarantine(ip) {
+		http.Error(w, "device not quarantined", http.StatusNotFound)
+		return
+	}
+	log.Info().Str("ip", ip).Str("remote", r.RemoteAddr).Msg("Device released from quarantine")
+	hs.saveQuarantine()
+	writeQuarantineJSON(w, quarantineRelease{IP: ip, Status: "released"})
+}
+
+// quarantineExcludeHandler excludes a quarantined device permanently
+func (hs *HealthServer) quarantineExcludeHandler(w http.ResponseWriter, r *http.Request) {
+	ip, ok := hs.quarantineTarget(w, r)
+	if !ok {
+		return
+	}
+	entry, found := hs.stateMgr.ExcludeQuarantined(ip, time.Now())
+	if !found {
+		http.Error(w, "device not quarantined", http.StatusNotFound)
+		return
+	}
+	log.Info().Str("ip", ip).Str("remote", r.RemoteAddr).Msg("Quarantined device excluded permanently")
+	hs.saveQuarantine()
+	writeQuarantineJSON(w, entry)
+}
+
+// quarantineTarget parses the device of a review action; false means an error response was written
+func (hs *HealthServer) quarantineTarget(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if hs.quarantine == nil {
+		http.Error(w, "quarantine not enabled", http.StatusServiceUnavailable)
+		return "", false
+	}
+	ip := net.ParseIP(r.PathValue("ip"))
+	if ip == nil {
+		http.Error(w, "invalid device ip", http.StatusBadRequest)
+		return "", false
+	}
+	return ip.String(), true
+}
+
+// saveQuarantine persists the list after a review action when quarantine_file is configured
+func (hs *HealthServer) saveQuarantine() {
+	if hs.quarantine.file == "" {
+		return
+	}
+	if err := hs.stateMgr.SaveQuarantine(hs.quarantine.file); err != nil {
+		log.Error().Err(err).Msg("Failed to save quarantine list")
+	}
+}
+
+// writeQuarantineJSON writes v as JSON
+func writeQuarantineJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Debug().Err(err).Msg("Failed to write quarantine response")
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/state"
+)
+
+// TestQuarantineHandlers validates listing, excluding and releasing quarantined devices
+func TestQuarantineHandlers(t *testing.T) {
+	stateMgr := state.NewManager(100)
+	stateMgr.EnableQuarantine(1, time.Hour)
+	hs := &HealthServer{stateMgr: stateMgr}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/quarantine", hs.quarantineHandler)
+	mux.HandleFunc("POST /api/quarantine/{ip}/release", hs.quarantineReleaseHandler)
+	mux.HandleFunc("POST /api/quarantine/{ip}/exclude", hs.quarantineExcludeHandler)
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	if rec := serve(http.MethodGet, "/api/quarantine"); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 before SetQuarantine, got %d", rec.Code)
+	}
+	file := filepath.Join(t.TempDir(), "quarantine.json")
+	hs.SetQuarantine(1, time.Hour, file)
+
+	stateMgr.Add(state.Device{IP: "192.168.1.50", Hostname: "printer-2"})
+	stateMgr.ReportPingFail("192.168.1.50", 1, time.Minute)
+
+	rec := serve(http.MethodGet, "/api/quarantine")
+	var list quarantineResponse
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatalf("failed to decode list: %v", err)
+	}
+	if list.Trips != 1 || list.Window != "1h0m0s" || len(list.Devices) != 1 || list.Devices[0].Status != state.QuarantineHeld {
+		t.Fatalf("unexpected quarantine list %+v", list)
+	}
+
+	rec = serve(http.MethodPost, "/api/quarantine/192.168.1.50/exclude")
+	var entry state.QuarantineEntry
+	if err := json.NewDecoder(rec.Body).Decode(&entry); err != nil || entry.Status != state.QuarantineExcluded {
+		t.Errorf("expected the device to be excluded, got %d %+v (%v)", rec.Code, entry, err)
+	}
+	restored := state.NewManager(100)
+	restored.EnableQuarantine(1, time.Hour)
+	if err := restored.LoadQuarantine(file); err != nil || len(restored.Quarantined()) != 1 {
+		t.Errorf("expected the exclusion to be saved, got %+v (%v)", restored.Quarantined(), err)
+	}
+
+	if rec := serve(http.MethodPost, "/api/quarantine/192.168.1.50/release"); rec.Code != http.StatusOK || stateMgr.IsQuarantined("192.168.1.50") {
+		t.Errorf("expected the device to be released, got %d", rec.Code)
+	}
+	if rec := serve(http.MethodPost, "/api/quarantine/192.168.1.50/release"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a device that is not quarantined, got %d", rec.Code)
+	}
+	if rec := serve(http.MethodPost, "/api/quarantine/printer/exclude"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid ip, got %d", rec.Code)
+	}
+}
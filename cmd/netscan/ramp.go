@@ -0,0 +1,82 @@
+package main
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// startRampTick is how often the event loop releases queued devices; each tick releases up to rate x tick
+const startRampTick = 100 * time.Millisecond
+
+// startRamp queues newly discovered devices and releases them at pinger_start_rate, so a sweep finding
+// thousands of devices starts their pingers, initial SNMP scans and SNMP pollers gradually
+// add is called by discovery sweeps; release and holds by the main event loop
+type startRamp struct {
+	mu      sync.Mutex
+	limiter *rate.Limiter
+	queue   []string            // Oldest first
+	queued  map[string]struct{} // IPs in queue
+}
+
+// newStartRamp returns a ramp releasing perSecond devices per second, nil when perSecond is 0 (unlimited)
+func newStartRamp(perSecond float64) *startRamp {
+	if perSecond <= 0 {
+		return nil
+	}
+	burst := max(1, int(math.Ceil(perSecond*startRampTick.Seconds())))
+	return &startRamp{
+		limiter: rate.NewLimiter(rate.Limit(perSecond), burst),
+		queued:  make(map[string]struct{}),
+	}
+}
+
+// add queues ip unless it is already queued
+func (r *startRamp) add(ip string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.queued[ip]; ok {
+		return
+	}
+	r.queued[ip] = struct{}{}
+	r.queue = append(r.queue, ip)
+}
+
+// release dequeues the devices whose monitoring may start at now, oldest first
+func (r *startRamp) release(now time.Time) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for n < len(r.queue) && r.limiter.AllowN(now, 1) {
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+	released := append([]string(nil), r.queue[:n]...)
+	for _, ip := range released {
+		delete(r.queued, ip)
+	}
+	r.queue = r.queue[n:]
+	if len(r.queue) == 0 {
+		r.queue = nil // Let a drained backlog's array be collected
+	}
+	return released
+}
+
+// holds reports whether ip is waiting for its turn; reconciliation must not start its monitoring early
+func (r *startRamp) holds(ip string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.queued[ip]
+	return ok
+}
+
+// pending returns the number of queued devices
+func (r *startRamp) pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.queue)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestStartRampRelease verifies queued devices are released oldest first at the configured rate, without duplicates
+func TestStartRampRelease(t *testing.T) {
+	if newStartRamp(0) != nil {
+		t.Fatal("expected no ramp for an unlimited rate")
+	}
+
+	ramp := newStartRamp(50) // 5 per 100ms tick
+	for i := 1; i <= 20; i++ {
+		ramp.add(fmt.Sprintf("10.0.0.%d", i))
+	}
+	ramp.add("10.0.0.1") // Already queued
+	if ramp.pending() != 20 || !ramp.holds("10.0.0.1") || ramp.holds("10.0.1.1") {
+		t.Fatalf("unexpected queue: %d pending", ramp.pending())
+	}
+
+	now := time.Now()
+	first := ramp.release(now)
+	if len(first) != 5 || first[0] != "10.0.0.1" || first[4] != "10.0.0.5" {
+		t.Errorf("expected the burst of the 5 oldest devices, got %v", first)
+	}
+	if ramp.holds("10.0.0.1") {
+		t.Error("expected a released device not to be held")
+	}
+	if again := ramp.release(now); len(again) != 0 {
+		t.Errorf("expected nothing more within the same instant, got %v", again)
+	}
+	if next := ramp.release(now.Add(startRampTick)); len(next) != 5 || next[0] != "10.0.0.6" {
+		t.Errorf("expected the next 5 devices one tick later, got %v", next)
+	}
+
+	// After a long pause only the burst is released, never the whole backlog at once
+	if later := ramp.release(now.Add(time.Hour)); len(later) != 5 {
+		t.Errorf("expected at most the burst after a pause, got %d", len(later))
+	}
+	ramp.release(now.Add(2 * time.Hour))
+	if ramp.pending() != 0 || ramp.release(now.Add(3*time.Hour)) != nil {
+		t.Errorf("expected the queue to be drained, %d pending", ramp.pending())
+	}
+}
+
+// TestStartRampSlowRate verifies rates below one per tick still release one device at a time
+func TestStartRampSlowRate(t *testing.T) {
+	ramp := newStartRamp(1)
+	ramp.add("10.0.0.1")
+	ramp.add("10.0.0.2")
+	now := time.Now()
+	if got := ramp.release(now); len(got) != 1 {
+		t.Fatalf("expected one device, got %v", got)
+	}
+	if got := ramp.release(now.Add(500 * time.Millisecond)); len(got) != 0 {
+		t.Errorf("expected no device before a second has passed, got %v", got)
+	}
+	if got := ramp.release(now.Add(time.Second)); len(got) != 1 || got[0] != "10.0.0.2" {
+		t.Errorf("expected the second device after a second, got %v", got)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/kljama/netscan/internal/inventory"
+)
+
+// reconciliationHandler serves the latest inventory reconciliation report as JSON or, with ?format=csv, as a CSV download
+// ?refresh=true regenerates the report from the current state and expected devices file first
+func (hs *HealthServer) reconciliationHandler(w http.ResponseWriter, r *http.Request) {
+	if hs.reconciler == nil {
+		http.Error(w, "inventory reconciliation not configured (inventory_file)", http.StatusServiceUnavailable)
+		return
+	}
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		http.Error(w, "format must be json or csv", http.StatusBadRequest)
+		return
+	}
+
+	var report *inventory.Report
+	refresh, _ := strconv.ParseBool(query.Get("refresh"))
+	if refresh {
+		var err error
+		if report, err = hs.reconciler.Run(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else if report = hs.reconciler.Latest(); report == nil {
+		http.Error(w, "no reconciliation report yet (first report after inventory_report_interval, or use refresh=true)", http.StatusServiceUnavailable)
+		return
+	}
+
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="reconciliation-`+report.Generated.UTC().Format("20060102-150405")+`.csv"`)
+		if err := report.WriteCSV(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kljama/netscan/internal/inventory"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// TestReconciliationHandler validates the JSON and CSV report formats and the not-ready states
+func TestReconciliationHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "expected.csv")
+	if err := os.WriteFile(path, []byte("ip,hostname\n192.168.1.1,router\n192.168.1.2,switch\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stateMgr := state.NewManager(100)
+	stateMgr.AddDevice("192.168.1.1")
+
+	hs := &HealthServer{stateMgr: stateMgr}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/report/reconciliation", hs.reconciliationHandler)
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	// Not configured
+	if rec := get("/api/report/reconciliation"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without reconciler, got %d", rec.Code)
+	}
+
+	// Configured, but no report produced yet
+	hs.reconciler = inventory.NewReconciler(path, stateMgr)
+	if rec := get("/api/report/reconciliation"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 before the first report, got %d", rec.Code)
+	}
+
+	rec := get("/api/report/reconciliation?refresh=true")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d (%s)", rec.Code, rec.Body.String())
+	}
+	var report inventory.Report
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+	if report.Summary.Matched != 1 || report.Summary.Missing != 1 || len(report.Entries) != 1 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	// The stored report is served without refresh, here as CSV
+	rec = get("/api/report/reconciliation?format=csv")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/csv" {
+		t.Fatalf("expected CSV, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rec.Header().Get("Content-Disposition"), "attachment") || !strings.Contains(rec.Body.String(), "missing,192.168.1.2,switch") {
+		t.Errorf("unexpected CSV response: %q", rec.Body.String())
+	}
+
+	if rec := get("/api/report/reconciliation?format=xml"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown format, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+// deviceRescanResponse is the POST /api/device/{ip}/rescan response body
+type deviceRescanResponse struct {
+	IP          string `json:"ip"`
+	Answered    bool   `json:"answered"` // The device answered SNMP
+	Changed     bool   `json:"changed"`  // sysName or sysDescr changed, so the device was re-enriched
+	Hostname    string `json:"hostname,omitempty"`
+	Description string `json:"description,omitempty"` // sysDescr
+}
+
+// SetRescanner serves on-demand SNMP re-scans on POST /api/device/{ip}/rescan; call before Start
+func (hs *HealthServer) SetRescanner(rescan *snmpRescan) {
+	hs.rescan = rescan
+}
+
+// deviceRescanHandler queries a monitored device's sysName and sysDescr again and re-enriches it when they changed
+func (hs *HealthServer) deviceRescanHandler(w http.ResponseWriter, r *http.Request) {
+	if hs.rescan == nil {
+		http.Error(w, "re-scan not available", http.StatusServiceUnavailable)
+		return
+	}
+	parsed := net.ParseIP(r.PathValue("ip"))
+	if parsed == nil {
+		http.Error(w, "invalid device ip", http.StatusBadRequest)
+		return
+	}
+	ip := parsed.String()
+	if !requestToken(r).allows(ip) {
+		http.Error(w, "device outside the token's networks", http.StatusForbidden)
+		return
+	}
+	if _, found := hs.stateMgr.Get(ip); !found {
+		http.Error(w, "device not found", http.StatusNotFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), probeTimeout)
+	defer cancel()
+	dev, answered, changed := hs.rescan.device(ctx, ip)
+	response := deviceRescanResponse{IP: ip, Answered: answered, Changed: changed, Hostname: dev.Hostname, Description: dev.SysDescr}
+	log.Info().
+		Str("ip", ip).
+		Str("remote", r.RemoteAddr).
+		Bool("answered", answered).
+		Bool("changed", changed).
+		Msg("On-demand device re-scan")
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Debug().Err(err).Msg("Failed to write re-scan response")
+	}
+}
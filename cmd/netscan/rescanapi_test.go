@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// TestDeviceRescanHandler validates on-demand re-scans re-enrich only the token's own devices
+func TestDeviceRescanHandler(t *testing.T) {
+	stateMgr := state.NewManager(100)
+	stateMgr.AddDevice("10.0.1.5")
+	stateMgr.UpdateDeviceSNMP("10.0.1.5", "branch-sw", "IOS 15.2")
+	stateMgr.AddDevice("10.0.2.5")
+	sink := &deviceInfoSink{written: make(map[string]map[string]string)}
+	rescan := newSNMPRescan(&config.Config{}, stateMgr, sink, nil, "", func(ip, hostname, sysDescr string) map[string]string {
+		return map[string]string{"from": hostname}
+	})
+	var scanned []string
+	rescan.scan = func(ctx context.Context, ips []string, snmpConfig *config.SNMPConfig, workers int) []state.Device {
+		scanned = append(scanned, ips...)
+		return []state.Device{{IP: ips[0], Hostname: "branch-sw", SysDescr: "IOS 17.3"}}
+	}
+
+	hs := &HealthServer{stateMgr: stateMgr}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/device/{ip}/rescan", hs.deviceRescanHandler)
+	branch := newAPIToken("branch", []string{"10.0.1.0/24"})
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, withToken(httptest.NewRequest(http.MethodPost, path, nil), branch))
+		return rec
+	}
+
+	if rec := serve("/api/device/10.0.1.5/rescan"); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 before SetRescanner, got %d", rec.Code)
+	}
+	hs.SetRescanner(rescan)
+	for path, want := range map[string]int{
+		"/api/device/10.0.2.5/rescan":  http.StatusForbidden,
+		"/api/device/10.0.1.9/rescan":  http.StatusNotFound,
+		"/api/device/not-an-ip/rescan": http.StatusBadRequest,
+	} {
+		if rec := serve(path); rec.Code != want {
+			t.Errorf("%s: expected %d, got %d", path, want, rec.Code)
+		}
+	}
+	if len(scanned) != 0 {
+		t.Fatalf("expected rejected re-scans to query nothing, got %v", scanned)
+	}
+
+	rec := serve("/api/device/10.0.1.5/rescan")
+	var response deviceRescanResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("invalid response %d: %v", rec.Code, err)
+	}
+	if !response.Answered || !response.Changed || response.Description != "IOS 17.3" {
+		t.Errorf("expected the device to be re-enriched, got %+v", response)
+	}
+	if dev, _ := stateMgr.Get("10.0.1.5"); dev.SysDescr != "IOS 17.3" || sink.written["10.0.1.5"]["from"] != "branch-sw" {
+		t.Errorf("expected state and device_info to hold the new sysDescr, got %q and %v", dev.SysDescr, sink.written)
+	}
+}
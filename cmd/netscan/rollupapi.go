@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kljama/netscan/internal/state"
+)
+
+// rollupStats is a daily rollup (or several days combined) with its derived values
+type rollupStats struct {
+	Date            string  `json:"date,omitempty"` // Empty for multi-day summaries
+	Cycles          int     `json:"cycles"`
+	UpCycles        int     `json:"up_cycles"`
+	PacketsSent     int     `json:"packets_sent"`
+	PacketsRecv     int     `json:"packets_recv"`
+	AvailabilityPct float64 `json:"availability_pct"`
+	PacketLossPct   float64 `json:"packet_loss_pct"`
+	RTTMinMs        float64 `json:"rtt_min_ms"`
+	RTTAvgMs        float64 `json:"rtt_avg_ms"`
+	RTTMaxMs        float64 `json:"rtt_max_ms"`
+}
+
+// deviceRollupsResponse is the /api/device/{ip}/rollups response
+type deviceRollupsResponse struct {
+	IP       string        `json:"ip"`
+	Hostname string        `json:"hostname,omitempty"`
+	Days     []rollupStats `json:"days"` // Oldest first
+}
+
+// deviceRollupSummary is one device of the /api/rollups response
+type deviceRollupSummary struct {
+	IP       string `json:"ip"`
+	Hostname string `json:"hostname,omitempty"`
+	Days     int    `json:"days"` // Days with data in the window
+	rollupStats
+}
+
+// rollupsResponse is the /api/rollups response
+type rollupsResponse struct {
+	Since   string                `json:"since"` // First day of the window
+	Days    int                   `json:"days"`  // Window length in days
+	Devices []deviceRollupSummary `json:"devices"`
+}
+
+// newRollupStats adds the derived values to a rollup
+func newRollupStats(r state.DailyRollup) rollupStats {
+	return rollupStats{
+		Date:            r.Date,
+		Cycles:          r.Cycles,
+		UpCycles:        r.UpCycles,
+		PacketsSent:     r.PacketsSent,
+		PacketsRecv:     r.PacketsRecv,
+		AvailabilityPct: r.AvailabilityPct(),
+		PacketLossPct:   r.PacketLossPct(),
+		RTTMinMs:        r.RTTMinMs,
+		RTTAvgMs:        r.AvgRTTMs(),
+		RTTMaxMs:        r.RTTMaxMs,
+	}
+}
+
+// deviceRollupsHandler serves the daily ping rollups kept for one device
+// Rollups of devices pruned from state stay available until they age out
+func (hs *HealthServer) deviceRollupsHandler(w http.ResponseWriter, r *http.Request) {
+	if hs.stateMgr.RollupDays() == 0 {
+		http.Error(w, "local rollups not configured (rollup_days)", http.StatusServiceUnavailable)
+		return
+	}
+	ip := net.ParseIP(r.PathValue("ip"))
+	if ip == nil {
+		http.Error(w, "invalid device ip", http.StatusBadRequest)
+		return
+	}
+	if !requestToken(r).allows(ip.String()) {
+		http.Error(w, "device outside the token's networks", http.StatusForbidden)
+		return
+	}
+	days := hs.stateMgr.GetRollups(ip.String())
+	if len(days) == 0 {
+		http.Error(w, "no rollups for device", http.StatusNotFound)
+		return
+	}
+
+	response := deviceRollupsResponse{IP: ip.String(), Hostname: hs.hostname(ip.String())}
+	for _, day := range days {
+		response.Days = append(response.Days, newRollupStats(day))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// rollupsHandler summarizes every device's rollups over the last ?days=N days (default: the full retention)
+func (hs *HealthServer) rollupsHandler(w http.ResponseWriter, r *http.Request) {
+	retention := hs.stateMgr.RollupDays()
+	if retention == 0 {
+		http.Error(w, "local rollups not configured (rollup_days)", http.StatusServiceUnavailable)
+		return
+	}
+	window := retention
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > retention {
+			http.Error(w, "days must be between 1 and rollup_days ("+strconv.Itoa(retention)+")", http.StatusBadRequest)
+			return
+		}
+		window = n
+	}
+
+	token := requestToken(r)
+	since := hs.stateMgr.RollupCutoff(time.Now(), window)
+	response := rollupsResponse{Since: since, Days: window, Devices: []deviceRollupSummary{}}
+	for ip, days := range hs.stateMgr.GetAllRollups() {
+		if !token.allows(ip) {
+			continue
+		}
+		first := sort.Search(len(days), func(i int) bool { return days[i].Date >= since })
+		if first == len(days) {
+			continue
+		}
+		response.Devices = append(response.Devices, deviceRollupSummary{
+			IP:          ip,
+			Hostname:    hs.hostname(ip),
+			Days:        len(days) - first,
+			rollupStats: newRollupStats(state.SumRollups(days[first:])),
+		})
+	}
+	sort.Slice(response.Devices, func(i, j int) bool {
+		return compareIPs(response.Devices[i].IP, response.Devices[j].IP) < 0
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// hostname returns a monitored device's hostname, or "" when unknown
+func (hs *HealthServer) hostname(ip string) string {
+	if dev, found := hs.stateMgr.Get(ip); found && dev.Hostname != ip {
+		return dev.Hostname
+	}
+	return ""
+}
+
+// compareIPs orders IP addresses numerically, falling back to text for unparsable values
+func compareIPs(a, b string) int {
+	ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+	if ipA == nil || ipB == nil {
+		return strings.Compare(a, b)
+	}
+	return strings.Compare(string(ipA.To16()), string(ipB.To16()))
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/state"
+)
+
+// TestRollupHandlers validates the per-device and summary rollup endpoints
+func TestRollupHandlers(t *testing.T) {
+	stateMgr := state.NewManager(100)
+	hs := &HealthServer{stateMgr: stateMgr}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/device/{ip}/rollups", hs.deviceRollupsHandler)
+	mux.HandleFunc("GET /api/rollups", hs.rollupsHandler)
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	// Not configured
+	if rec := get("/api/rollups"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without rollups, got %d", rec.Code)
+	}
+
+	stateMgr.EnableRollups(7, time.Local)
+	now := time.Now()
+	stateMgr.RecordPingCycle("192.168.1.10", 1, 1, 2*time.Millisecond, 2*time.Millisecond, 2*time.Millisecond, now.Add(-24*time.Hour))
+	stateMgr.RecordPingCycle("192.168.1.10", 1, 0, 0, 0, 0, now)
+	stateMgr.RecordPingCycle("192.168.1.9", 1, 1, 4*time.Millisecond, 4*time.Millisecond, 4*time.Millisecond, now)
+
+	rec := get("/api/device/192.168.1.10/rollups")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d (%s)", rec.Code, rec.Body.String())
+	}
+	var device deviceRollupsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&device); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(device.Days) != 2 || device.Days[0].AvailabilityPct != 100 || device.Days[1].AvailabilityPct != 0 {
+		t.Errorf("unexpected device rollups: %+v", device)
+	}
+
+	if rec := get("/api/device/192.168.1.99/rollups"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for device without rollups, got %d", rec.Code)
+	}
+	if rec := get("/api/device/not-an-ip/rollups"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid ip, got %d", rec.Code)
+	}
+
+	// Summary over the full retention, sorted by IP
+	rec = get("/api/rollups")
+	var summary rollupsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&summary); err != nil {
+		t.Fatalf("failed to decode summary: %v", err)
+	}
+	if summary.Days != 7 || len(summary.Devices) != 2 || summary.Devices[0].IP != "192.168.1.9" {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+	if d := summary.Devices[1]; d.Days != 2 || d.Cycles != 2 || d.AvailabilityPct != 50 || d.RTTAvgMs != 2 {
+		t.Errorf("unexpected device summary: %+v", d)
+	}
+
+	// Today only
+	rec = get("/api/rollups?days=1")
+	summary = rollupsResponse{}
+	if err := json.NewDecoder(rec.Body).Decode(&summary); err != nil {
+		t.Fatalf("failed to decode summary: %v", err)
+	}
+	if len(summary.Devices) != 2 || summary.Devices[1].AvailabilityPct != 0 {
+		t.Errorf("expected today's rollups only, got %+v", summary)
+	}
+
+	if rec := get("/api/rollups?days=8"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for days beyond retention, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,16 @@
+//go:build darwin
+
+package main
+
+import "syscall"
+
+// getRSSMB returns the peak resident set size in MB (getrusage ru_maxrss, bytes on macOS)
+// The current RSS needs task_info, which is only reachable through cgo; the peak is close
+// for a long-running daemon whose memory use is steady. On failure it returns 0
+func getRSSMB() uint64 {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil || usage.Maxrss < 0 {
+		return 0
+	}
+	return uint64(usage.Maxrss) / 1024 / 1024
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !windows
+
+package main
+
+// getRSSMB is not implemented on this platform and always returns 0
+func getRSSMB() uint64 {
+	return 0
+}
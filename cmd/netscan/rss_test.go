@@ -0,0 +1,21 @@
+//go:build linux || darwin || windows
+
+package main
+
+import (
+	"runtime"
+	"testing"
+)
+
+// TestGetRSSMB verifies the resident set size is reported on supported platforms
+func TestGetRSSMB(t *testing.T) {
+	// Make sure the process holds more than 1 MB so the rounded value is not 0
+	buf := make([]byte, 8<<20)
+	for i := range buf {
+		buf[i] = 1
+	}
+	if rss := getRSSMB(); rss == 0 {
+		t.Error("expected a non-zero RSS")
+	}
+	runtime.KeepAlive(buf)
+}
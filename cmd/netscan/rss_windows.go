@@ -0,0 +1,44 @@
+//go:build windows
+
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// processMemoryCounters mirrors PROCESS_MEMORY_COUNTERS from psapi.h
+type processMemoryCounters struct {
+	cb                         uint32
+	pageFaultCount             uint32
+	peakWorkingSetSize         uintptr
+	workingSetSize             uintptr
+	quotaPeakPagedPoolUsage    uintptr
+	quotaPagedPoolUsage        uintptr
+	quotaPeakNonPagedPoolUsage uintptr
+	quotaNonPagedPoolUsage     uintptr
+	pagefileUsage              uintptr
+	peakPagefileUsage          uintptr
+}
+
+// procGetProcessMemoryInfo is GetProcessMemoryInfo from psapi.dll, loaded on first use
+var procGetProcessMemoryInfo = syscall.NewLazyDLL("psapi.dll").NewProc("GetProcessMemoryInfo")
+
+// getRSSMB returns the working set size (the Windows equivalent of RSS) in MB via GetProcessMemoryInfo
+// On failure it returns 0
+func getRSSMB() uint64 {
+	if procGetProcessMemoryInfo.Find() != nil {
+		return 0
+	}
+	process, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return 0
+	}
+	var counters processMemoryCounters
+	counters.cb = uint32(unsafe.Sizeof(counters))
+	ok, _, _ := procGetProcessMemoryInfo.Call(uintptr(process), uintptr(unsafe.Pointer(&counters)), uintptr(counters.cb))
+	if ok == 0 {
+		return 0
+	}
+	return uint64(counters.workingSetSize) / 1024 / 1024
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/discovery"
+	"github.com/kljama/netscan/internal/logger"
+	"github.com/kljama/netscan/internal/monitoring"
+	"github.com/kljama/netscan/internal/state"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/time/rate"
+)
+
+const scanUsage = "usage: netscan scan [-config config.yml] [-vars vars.yml] [-format table|json|csv] [-no-snmp]"
+
+// scanResult is one device found by "netscan scan"
+type scanResult struct {
+	IP       string   `json:"ip"`
+	Hostname string   `json:"hostname,omitempty"` // SNMP sysName ("" without an SNMP answer)
+	SysDescr string   `json:"sys_descr,omitempty"`
+	RTTMs    *float64 `json:"rtt_ms"` // Average RTT of one ping cycle (null when the device did not answer it)
+}
+
+// runScan performs one discovery sweep plus SNMP queries, prints the devices found and exits
+// Nothing is written to InfluxDB, so config and SNMP credentials can be checked before deploying the daemon
+func runScan(args []string) int {
+	fs := flag.NewFlagSet("scan", flag.ContinueOnError)
+	configPath := fs.String("config", "config.yml", "Path to configuration file")
+	varsPath := fs.String("vars", "", "Per-site variables file overriding the config's vars block")
+	format := fs.String("format", "table", "Output format: table, json or csv")
+	noSNMP := fs.Bool("no-snmp", false, "Skip SNMP queries (discovery and RTT only)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, scanUsage)
+		return 2
+	}
+	switch *format {
+	case "table", "json", "csv":
+	default:
+		fmt.Fprintf(os.Stderr, "unsupported format %q (table, json, csv)\n", *format)
+		return 2
+	}
+
+	// Logs go to stderr so stdout only carries the device list
+	logger.SetupStderr(false)
+
+	cfg, err := config.LoadConfigWithVars(*configPath, *varsPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		return 1
+	}
+	warning, err := config.ValidateScanConfig(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration: %v\n", err)
+		return 1
+	}
+	if warning != "" {
+		log.Warn().Str("warning", warning).Msg("Configuration warning")
+	}
+
+	// Same ping engine as the daemon
+	if err := discovery.CheckDiscoverers(cfg.DiscoveryMethods); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration: %v\n", err)
+		return 1
+	}
+	icmpSetup, err := setupPinging(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to set up ICMP pinging: %v\n", err)
+		return 1
+	}
+	defer icmpSetup.close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	limiter := rate.NewLimiter(rate.Limit(cfg.PingRateLimit), cfg.PingBurstLimit)
+	log.Info().
+		Str("mode", cfg.DiscoveryMode).
+		Strs("methods", cfg.DiscoveryMethods).
+		Strs("networks", cfg.DisplayNetworks(cfg.Networks)).
+		Msg("Scanning networks")
+	ips := discovery.RunDiscoverySweep(ctx, cfg, &discovery.Sweep{
+		Networks:      cfg.Networks,
+		Limiter:       limiter,
+		NetworkLimits: icmpSetup.networkLimits,
+		Privileged:    icmpSetup.privileged,
+		SourceIP:      icmpSetup.source,
+	})
+	if ctx.Err() != nil {
+		fmt.Fprintln(os.Stderr, "scan interrupted")
+		return 1
+	}
+
+	results := measureRTTs(ctx, icmpSetup.engine, cfg.AddressPolicy(), ips, cfg.PingTimeout, cfg.IcmpWorkers, limiter)
+	if !*noSNMP {
+		// Devices of each site are queried with that site's SNMP credentials
+		snmpFor := cfg.SNMPResolver()
+		bySettings := make(map[*config.SNMPConfig][]string)
+		for _, ip := range ips {
+			bySettings[snmpFor(ip)] = append(bySettings[snmpFor(ip)], ip)
+		}
+		var snmpDevices []state.Device
+		for snmpConfig, group := range bySettings {
+			snmpDevices = append(snmpDevices, discovery.RunSNMPScan(ctx, group, snmpConfig, cfg.SnmpWorkers, icmpSetup.source)...)
+		}
+		if ctx.Err() != nil {
+			fmt.Fprintln(os.Stderr, "scan interrupted")
+			return 1
+		}
+		for _, dev := range snmpDevices {
+			if res, ok := results[dev.IP]; ok {
+				res.Hostname = dev.Hostname
+				res.SysDescr = dev.SysDescr
+			}
+		}
+		log.Info().
+			Int("devices_found", len(ips)).
+			Int("snmp_answered", len(snmpDevices)).
+			Msg("Scan completed")
+	} else {
+		log.Info().Int("devices_found", len(ips)).Msg("Scan completed")
+	}
+
+	sorted := make([]scanResult, 0, len(results))
+	for _, res := range results {
+		sorted = append(sorted, *res)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return compareIPs(sorted[i].IP, sorted[j].IP) < 0 })
+
+	if err := writeScanResults(os.Stdout, *format, sorted); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write results: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// measureRTTs pings every discovered IP once more with prober to report its RTT; IPs rejected by policy get none
+func measureRTTs(ctx context.Context, prober monitoring.Prober, policy config.AddressPolicy, ips []string, timeout time.Duration, workers int, limiter *rate.Limiter) map[string]*scanResult {
+	if workers <= 0 {
+		workers = 64
+	}
+	results := make(map[string]*scanResult, len(ips))
+	for _, ip := range ips {
+		results[ip] = &scanResult{IP: ip}
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		jobs = make(chan string)
+	)
+	for i := 0; i < workers && i < len(ips); i++ {
+		wg.Add(1)
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Error().Interface("panic", r).Msg("Scan RTT worker panic recovered")
+				}
+			}()
+			defer wg.Done()
+			for ip := range jobs {
+				if err := limiter.Wait(ctx); err != nil {
+					continue
+				}
+				stats, err := monitoring.Probe(prober, policy, ip, 1, timeout)
+				if err != nil || stats.PacketsRecv == 0 {
+					continue
+				}
+				rtt := float64(stats.AvgRtt.Microseconds()) / 1000
+				mu.Lock()
+				results[ip].RTTMs = &rtt
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, ip := range ips {
+		jobs <- ip
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// writeScanResults prints the devices as an aligned table, a JSON array or CSV with a header row
+func writeScanResults(w io.Writer, format string, results []scanResult) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	case "csv":
+		writer := csv.NewWriter(w)
+		writer.Write([]string{"ip", "hostname", "sys_descr", "rtt_ms"})
+		for _, res := range results {
+			writer.Write([]string{res.IP, res.Hostname, res.SysDescr, formatRTT(res.RTTMs, "")})
+		}
+		writer.Flush()
+		return writer.Error()
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "IP\tHOSTNAME\tRTT (ms)\tSYSDESCR")
+		for _, res := range results {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", res.IP, orDash(res.Hostname), formatRTT(res.RTTMs, "-"), orDash(firstLine(res.SysDescr)))
+		}
+		return tw.Flush()
+	}
+}
+
+// formatRTT formats an RTT in milliseconds, or returns missing when there is none
+func formatRTT(rttMs *float64, missing string) string {
+	if rttMs == nil {
+		return missing
+	}
+	return strconv.FormatFloat(*rttMs, 'f', 3, 64)
+}
+
+// firstLine returns the first line of a multi-line sysDescr, so table rows stay on one line
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return strings.TrimSpace(line)
+}
+
+// orDash returns "-" for empty table cells
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestWriteScanResults validates the table, JSON and CSV output of "netscan scan"
+func TestWriteScanResults(t *testing.T) {
+	rtt := 1.25
+	results := []scanResult{
+		{IP: "192.168.1.1", Hostname: "core-router", SysDescr: "Cisco IOS XE\nCompiled Tue", RTTMs: &rtt},
+		{IP: "192.168.1.20"},
+	}
+
+	var buf bytes.Buffer
+	if err := writeScanResults(&buf, "table", results); err != nil {
+		t.Fatalf("table: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "IP") {
+		t.Fatalf("unexpected table:\n%s", buf.String())
+	}
+	if fields := strings.Fields(lines[1]); len(fields) != 6 || fields[2] != "1.250" || fields[5] != "XE" {
+		t.Errorf("unexpected first row %q", lines[1])
+	}
+	if fields := strings.Fields(lines[2]); len(fields) != 4 || fields[1] != "-" || fields[2] != "-" {
+		t.Errorf("unexpected second row %q", lines[2])
+	}
+
+	buf.Reset()
+	if err := writeScanResults(&buf, "json", results); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(decoded) != 2 || decoded[0]["rtt_ms"] != 1.25 || decoded[1]["rtt_ms"] != nil {
+		t.Errorf("unexpected JSON: %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := writeScanResults(&buf, "csv", results); err != nil {
+		t.Fatalf("csv: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "ip,hostname,sys_descr,rtt_ms\n") || !strings.Contains(buf.String(), "192.168.1.20,,,\n") {
+		t.Errorf("unexpected CSV:\n%s", buf.String())
+	}
+}
+
+// TestRunScanUsage validates argument errors before any network activity
+func TestRunScanUsage(t *testing.T) {
+	if code := runScan([]string{"-format", "xml"}); code != 2 {
+		t.Errorf("expected exit code 2 for unsupported format, got %d", code)
+	}
+	if code := runScan([]string{"extra"}); code != 2 {
+		t.Errorf("expected exit code 2 for extra arguments, got %d", code)
+	}
+	if code := runScan([]string{"-config", "/nonexistent/config.yml"}); code != 1 {
+		t.Errorf("expected exit code 1 for a missing config, got %d", code)
+	}
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+)
+
+const scheduleUsage = "usage: netscan schedule [-config config.yml] [-vars vars.yml] [-format table|json]"
+
+// Subsystems of the effective schedule; daemon-wide loops match the main event loop's tickers
+const (
+	scheduleDiscovery            = "discovery"             // Per network: discovery sweeps
+	schedulePing                 = "ping"                  // Per network: continuous pings of every device
+	scheduleSNMP                 = "snmp_poll"             // Per network: continuous SNMP polls of every device
+	schedulePingerReconcile      = "pinger_reconciliation" // Schedules pings for new devices
+	scheduleSNMPReconcile        = "snmp_reconciliation"   // Starts SNMP pollers for new devices
+	schedulePruning              = "state_pruning"         // Removes stale devices
+	scheduleHealthReport         = "health_report"         // Writes health metrics
+	scheduleOverlapCheck         = "overlap_check"         // Detects other scanners covering the same networks
+	scheduleCompositeChecks      = "composite_checks"      // Evaluates composite checks
+	scheduleInventoryReconcile   = "inventory_report"      // Reconciles against the expected devices file
+	scheduleSNMPRescan           = "snmp_rescan"           // Daily full SNMP re-scan at a wall-clock time
+	scheduleAutoTune             = "auto_tune"             // Adjusts worker counts and rate limits
+	scheduleInventoryExport      = "inventory_export"      // Writes inventory snapshots to files
+	scheduleHostResolve          = "host_resolve"          // Re-resolves host names in networks
+	pingerReconciliationInterval = 5 * time.Second
+	snmpReconciliationInterval   = 10 * time.Second
+	pruningInterval              = 1 * time.Hour
+)
+
+// scheduleRateLimit is one token bucket a subsystem's probes wait for
+type scheduleRateLimit struct {
+	Scope string  `json:"scope"` // "global" or the network_rate_limits partition
+	Rate  float64 `json:"rate"`  // Tokens per second
+	Burst int     `json:"burst"`
+}
+
+// scheduleEntry is one periodic activity with its resolved settings
+type scheduleEntry struct {
+	Subsystem   string              `json:"subsystem"`
+	Network     string              `json:"network,omitempty"`      // Network the entry applies to (empty = daemon-wide)
+	Label       string              `json:"label,omitempty"`        // Friendly label of the network
+	Interval    string              `json:"interval"`               // Effective interval (adaptive discovery: the current one)
+	MaxInterval string              `json:"max_interval,omitempty"` // Adaptive discovery upper bound
+	Offset      string              `json:"offset,omitempty"`       // Start spread and jitter applied to the interval
+	RateLimits  []scheduleRateLimit `json:"rate_limits,omitempty"`  // Buckets each probe waits for, most specific first
+	NextRun     *time.Time          `json:"next_run,omitempty"`     // Next tick (omitted for per-device loops)
+	Details     string              `json:"details,omitempty"`
+}
+
+// scheduleReport is the effective schedule served by GET /api/schedule and printed by "netscan schedule"
+type scheduleReport struct {
+	Generated time.Time       `json:"generated"`
+	Timezone  string          `json:"timezone"`
+	Entries   []scheduleEntry `json:"entries"`
+}
+
+// tickerLookup returns the effective interval and next tick of a daemon ticker
+// configured is the interval from config; next is nil when the ticker is not running
+type tickerLookup func(subsystem string, configured time.Duration) (interval time.Duration, next *time.Time)
+
+// buildSchedule resolves the schedule of every network and daemon loop from cfg
+func buildSchedule(cfg *config.Config, now time.Time, ticker tickerLookup) scheduleReport {
+	report := scheduleReport{Generated: now, Timezone: cfg.ScheduleLocation().String()}
+	add := func(entry scheduleEntry) {
+		report.Entries = append(report.Entries, entry)
+	}
+
+	discoveryInterval, discoveryNext := ticker(scheduleDiscovery, cfg.IcmpDiscoveryInterval)
+	discoveryDetails := "mode " + orDefault(cfg.DiscoveryMode, "icmp")
+	if cfg.ARPDiscovery {
+		discoveryDetails += " + arp"
+	}
+	if cfg.DiscoverySweepBudget > 0 {
+		discoveryDetails += fmt.Sprintf(", budget %d addresses per sweep", cfg.DiscoverySweepBudget)
+	}
+	var offset []string
+	if cfg.PingStartSpread > 0 {
+		offset = append(offset, "start spread 1s+0-"+cfg.PingStartSpread.String())
+	}
+	if cfg.PingJitter > 0 {
+		offset = append(offset, "jitter ±"+cfg.PingJitter.String())
+	}
+	snmpOffset := []string{"start offset 5s+0-" + cfg.SNMPInterval.String() + " by IP"}
+	if cfg.SNMPJitter > 0 {
+		snmpOffset = append(snmpOffset, "jitter ±"+cfg.SNMPJitter.String())
+	}
+	snmpLimits := []scheduleRateLimit{{Scope: "global", Rate: cfg.SNMPRateLimit, Burst: cfg.SNMPBurstLimit}}
+
+	for _, network := range cfg.Networks {
+		label := cfg.NetworkLabel(network)
+		pingLimits := networkRateLimits(cfg, network)
+		entry := scheduleEntry{
+			Subsystem:  scheduleDiscovery,
+			Network:    network,
+			Label:      label,
+			Interval:   discoveryInterval.String(),
+			RateLimits: pingLimits,
+			NextRun:    discoveryNext,
+			Details:    discoveryDetails,
+		}
+		if cfg.IcmpDiscoveryMaxInterval > cfg.IcmpDiscoveryInterval {
+			entry.MaxInterval = cfg.IcmpDiscoveryMaxInterval.String()
+		}
+		add(entry)
+		add(scheduleEntry{
+			Subsystem:  schedulePing,
+			Network:    network,
+			Label:      label,
+			Interval:   cfg.PingInterval.String(),
+			Offset:     strings.Join(offset, ", "),
+			RateLimits: pingLimits,
+			Details:    fmt.Sprintf("%d packet(s), timeout %v, engine %s", max(cfg.PingsPerCycle, 1), cfg.PingTimeout, orDefault(cfg.PingEngine, "batch")),
+		})
+		add(scheduleEntry{
+			Subsystem:  scheduleSNMP,
+			Network:    network,
+			Label:      label,
+			Interval:   cfg.SNMPInterval.String(),
+			Offset:     strings.Join(snmpOffset, ", "),
+			RateLimits: snmpLimits,
+		})
+	}
+
+	daemonLoop := func(subsystem string, configured time.Duration) {
+		interval, next := ticker(subsystem, configured)
+		add(scheduleEntry{Subsystem: subsystem, Interval: interval.String(), NextRun: next})
+	}
+	daemonLoop(schedulePingerReconcile, pingerReconciliationInterval)
+	daemonLoop(scheduleSNMPReconcile, snmpReconciliationInterval)
+	daemonLoop(schedulePruning, pruningInterval)
+	daemonLoop(scheduleHealthReport, cfg.HealthReportInterval)
+	if cfg.OverlapCheckInterval > 0 {
+		daemonLoop(scheduleOverlapCheck, cfg.OverlapCheckInterval)
+	}
+	if len(cfg.CompositeChecks) > 0 {
+		daemonLoop(scheduleCompositeChecks, cfg.CompositeCheckInterval)
+	}
+	if cfg.InventoryFile != "" {
+		daemonLoop(scheduleInventoryReconcile, cfg.InventoryReportInterval)
+	}
+	if len(cfg.NetworkHosts) > 0 && cfg.HostResolveInterval > 0 {
+		daemonLoop(scheduleHostResolve, cfg.HostResolveInterval)
+	}
+	if cfg.InventoryExport.Enabled() {
+		interval, next := ticker(scheduleInventoryExport, cfg.InventoryExport.Interval)
+		add(scheduleEntry{
+			Subsystem: scheduleInventoryExport,
+			Interval:  interval.String(),
+			NextRun:   next,
+			Details:   cfg.InventoryExport.Format + " to " + cfg.InventoryExport.Dir,
+		})
+	}
+	if cfg.AutoTune.Enabled {
+		interval, next := ticker(scheduleAutoTune, cfg.AutoTune.Interval)
+		add(scheduleEntry{
+			Subsystem: scheduleAutoTune,
+			Interval:  interval.String(),
+			NextRun:   next,
+			Details: fmt.Sprintf("icmp_workers %d-%d, snmp_workers %d-%d, rate limits x%g-x%g",
+				cfg.AutoTune.MinIcmpWorkers, cfg.AutoTune.MaxIcmpWorkers, cfg.AutoTune.MinSnmpWorkers, cfg.AutoTune.MaxSnmpWorkers,
+				cfg.AutoTune.MinRateFactor, cfg.AutoTune.MaxRateFactor),
+		})
+	}
+	if rescan, ok := cfg.SNMPRescanSchedule(); ok {
+		// Runs at a wall-clock time rather than on a ticker, so the next run follows from the config alone
+		next := rescan.Next(now, cfg.ScheduleLocation())
+		add(scheduleEntry{
+			Subsystem:  scheduleSNMPRescan,
+			Interval:   (24 * time.Hour).String(),
+			RateLimits: snmpLimits,
+			NextRun:    &next,
+			Details:    "at " + rescan.String(),
+		})
+	}
+	return report
+}
+
+// networkRateLimits returns the ping buckets of a network: network_rate_limits partitions that cover
+// or lie inside it (most specific first), then the global ping limit
+func networkRateLimits(cfg *config.Config, network string) []scheduleRateLimit {
+	var limits []scheduleRateLimit
+	_, ipnet, err := net.ParseCIDR(network)
+	if err == nil {
+		ones, _ := ipnet.Mask.Size()
+		var covering *scheduleRateLimit
+		coveringOnes := -1
+		for _, limit := range cfg.NetworkRateLimits {
+			_, partition, err := net.ParseCIDR(limit.Network)
+			if err != nil {
+				continue
+			}
+			partitionOnes, _ := partition.Mask.Size()
+			entry := scheduleRateLimit{Scope: partition.String(), Rate: limit.Rate, Burst: limit.BurstOrDefault()}
+			switch {
+			case partitionOnes > ones && ipnet.Contains(partition.IP):
+				limits = append(limits, entry) // Part of the network has its own share
+			case partitionOnes <= ones && partition.Contains(ipnet.IP) && partitionOnes > coveringOnes:
+				covering, coveringOnes = &entry, partitionOnes
+			}
+		}
+		if covering != nil {
+			limits = append(limits, *covering)
+		}
+	}
+	return append(limits, scheduleRateLimit{Scope: "global", Rate: cfg.PingRateLimit, Burst: cfg.PingBurstLimit})
+}
+
+// orDefault returns s, or def when s is empty
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+// tickerStart is when a ticker was created or last reset, and its interval since then
+type tickerStart struct {
+	at       time.Time
+	interval time.Duration
+}
+
+// daemonSchedule tracks the daemon's tickers so /api/schedule reports their actual next runs
+type daemonSchedule struct {
+	cfg *config.Config
+
+	mu      sync.Mutex
+	tickers map[string]tickerStart
+}
+
+// newDaemonSchedule creates an empty schedule; record each ticker with started as it is created
+func newDaemonSchedule(cfg *config.Config) *daemonSchedule {
+	return &daemonSchedule{cfg: cfg, tickers: make(map[string]tickerStart)}
+}
+
+// started records that a subsystem's ticker was created or reset to interval at time at
+func (s *daemonSchedule) started(subsystem string, interval time.Duration, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tickers[subsystem] = tickerStart{at: at, interval: interval}
+}
+
+// lookup is the tickerLookup of the running daemon
+func (s *daemonSchedule) lookup(now time.Time) tickerLookup {
+	return func(subsystem string, configured time.Duration) (time.Duration, *time.Time) {
+		s.mu.Lock()
+		start, ok := s.tickers[subsystem]
+		s.mu.Unlock()
+		if !ok || start.interval <= 0 {
+			return configured, nil
+		}
+		next := start.at.Add(start.interval * (now.Sub(start.at)/start.interval + 1))
+		return start.interval, &next
+	}
+}
+
+// report builds the effective schedule as of now
+func (s *daemonSchedule) report(now time.Time) scheduleReport {
+	return buildSchedule(s.cfg, now, s.lookup(now))
+}
+
+// projectedTicker is the tickerLookup of "netscan schedule": every ticker as if the daemon started now
+func projectedTicker(now time.Time) tickerLookup {
+	return func(subsystem string, configured time.Duration) (time.Duration, *time.Time) {
+		next := now.Add(configured)
+		return configured, &next
+	}
+}
+
+// scheduleHandler serves the daemon's effective schedule as JSON
+func (hs *HealthServer) scheduleHandler(w http.ResponseWriter, r *http.Request) {
+	if hs.schedule == nil {
+		http.Error(w, "schedule not available", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(hs.schedule.report(time.Now())); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// runSchedule prints the effective schedule of a config as the daemon would run it if started now
+func runSchedule(args []string) int {
+	fs := flag.NewFlagSet("schedule", flag.ContinueOnError)
+	configPath := fs.String("config", "config.yml", "Path to configuration file")
+	varsPath := fs.String("vars", "", "Per-site variables file overriding the config's vars block")
+	format := fs.String("format", "table", "Output format: table or json")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, scheduleUsage)
+		return 2
+	}
+	if *format != "table" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "unsupported format %q (table, json)\n", *format)
+		return 2
+	}
+
+	cfg, err := config.LoadConfigWithVars(*configPath, *varsPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		return 1
+	}
+	if _, err := config.ValidateScanConfig(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration: %v\n", err)
+		return 1
+	}
+
+	now := time.Now()
+	if err := writeSchedule(os.Stdout, *format, buildSchedule(cfg, now, projectedTicker(now))); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write schedule: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// writeSchedule prints the schedule as an aligned table or as JSON
+func writeSchedule(w io.Writer, format string, report scheduleReport) error {
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	loc, err := time.LoadLocation(report.Timezone)
+	if err != nil {
+		loc = time.Local
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SUBSYSTEM\tNETWORK\tINTERVAL\tOFFSET\tRATE LIMITS\tNEXT RUN\tDETAILS")
+	for _, entry := range report.Entries {
+		interval := entry.Interval
+		if entry.MaxInterval != "" {
+			interval += " (max " + entry.MaxInterval + ")"
+		}
+		limits := make([]string, 0, len(entry.RateLimits))
+		for _, limit := range entry.RateLimits {
+			limits = append(limits, fmt.Sprintf("%s %g/s burst %d", limit.Scope, limit.Rate, limit.Burst))
+		}
+		network := entry.Network
+		if entry.Label != "" {
+			network = entry.Label + " (" + network + ")"
+		}
+		nextRun := "-"
+		if entry.NextRun != nil {
+			nextRun = entry.NextRun.In(loc).Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", entry.Subsystem, orDash(network), interval,
+			orDash(entry.Offset), orDash(strings.Join(limits, "; ")), nextRun, orDash(entry.Details))
+	}
+	return tw.Flush()
+}
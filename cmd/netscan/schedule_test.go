@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+)
+
+// scheduleTestConfig returns a config with two networks and a rate limit partition inside one of them
+func scheduleTestConfig() *config.Config {
+	return &config.Config{
+		Networks:              []string{"10.0.0.0/16", "192.168.1.0/24"},
+		NetworkLabels:         map[string]string{"192.168.1.0/24": "office"},
+		IcmpDiscoveryInterval: 5 * time.Minute,
+		PingInterval:          2 * time.Second,
+		PingTimeout:           time.Second,
+		PingStartSpread:       2 * time.Second,
+		PingRateLimit:         64,
+		PingBurstLimit:        256,
+		NetworkRateLimits:     []config.NetworkRateLimit{{Network: "10.0.5.0/24", Rate: 8}, {Network: "10.0.0.0/8", Rate: 32, Burst: 64}},
+		SNMPInterval:          time.Hour,
+		SNMPRateLimit:         10,
+		SNMPBurstLimit:        50,
+		HealthReportInterval:  10 * time.Second,
+	}
+}
+
+// TestBuildSchedule verifies per-network entries, effective rate limits and projected next runs
+func TestBuildSchedule(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	report := buildSchedule(scheduleTestConfig(), now, projectedTicker(now))
+
+	byKey := make(map[string]scheduleEntry)
+	for _, entry := range report.Entries {
+		byKey[entry.Subsystem+" "+entry.Network] = entry
+	}
+	if len(report.Entries) != 2*3+4 {
+		t.Fatalf("expected 3 entries per network plus 4 daemon loops, got %d", len(report.Entries))
+	}
+
+	discovery := byKey["discovery 10.0.0.0/16"]
+	if discovery.Interval != "5m0s" || discovery.NextRun == nil || !discovery.NextRun.Equal(now.Add(5*time.Minute)) {
+		t.Errorf("unexpected discovery entry: %+v", discovery)
+	}
+	var scopes []string
+	for _, limit := range discovery.RateLimits {
+		scopes = append(scopes, limit.Scope)
+	}
+	if got := strings.Join(scopes, ","); got != "10.0.5.0/24,10.0.0.0/8,global" {
+		t.Errorf("expected inner partition, covering partition and global limit, got %s", got)
+	}
+	if limits := byKey["ping 192.168.1.0/24"].RateLimits; len(limits) != 1 || limits[0].Scope != "global" {
+		t.Errorf("expected only the global limit outside every partition, got %+v", limits)
+	}
+	if ping := byKey["ping 10.0.0.0/16"]; ping.Offset != "start spread 1s+0-2s" || ping.NextRun != nil {
+		t.Errorf("unexpected ping entry: %+v", ping)
+	}
+	if snmp := byKey["snmp_poll 10.0.0.0/16"]; snmp.Offset != "start offset 5s+0-1h0m0s by IP" {
+		t.Errorf("unexpected snmp entry: %+v", snmp)
+	}
+	if _, ok := byKey["overlap_check "]; ok {
+		t.Error("overlap check should not be scheduled when disabled")
+	}
+}
+
+// TestBuildScheduleSNMPRescan verifies the daily re-scan is listed with its next wall-clock run
+func TestBuildScheduleSNMPRescan(t *testing.T) {
+	cfg := scheduleTestConfig()
+	cfg.SNMPDailySchedule = "02:00"
+	cfg.SNMPDailyDays = []string{"mon"}
+	cfg.Location = time.UTC
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) // A Friday
+
+	var rescan *scheduleEntry
+	for _, entry := range buildSchedule(cfg, now, projectedTicker(now)).Entries {
+		if entry.Subsystem == scheduleSNMPRescan {
+			rescan = &entry
+		}
+	}
+	if rescan == nil || rescan.NextRun == nil || !rescan.NextRun.Equal(time.Date(2026, 1, 5, 2, 0, 0, 0, time.UTC)) || rescan.Details != "at 02:00 on mon" {
+		t.Errorf("expected a re-scan entry for Monday 02:00, got %+v", rescan)
+	}
+}
+
+// TestDaemonScheduleNextRun verifies next runs follow the ticker start and the latest reset
+func TestDaemonScheduleNextRun(t *testing.T) {
+	sched := newDaemonSchedule(scheduleTestConfig())
+	start := time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+	sched.started(scheduleHealthReport, 10*time.Second, start)
+
+	interval, next := sched.lookup(start.Add(25*time.Second))(scheduleHealthReport, time.Minute)
+	if interval != 10*time.Second || next == nil || !next.Equal(start.Add(30*time.Second)) {
+		t.Errorf("expected next run at +30s, got %v %v", interval, next)
+	}
+	if _, next := sched.lookup(start)(schedulePruning, time.Hour); next != nil {
+		t.Errorf("expected no next run for a ticker that was not started, got %v", next)
+	}
+
+	// Adaptive discovery reset the ticker to a longer interval
+	sched.started(scheduleDiscovery, 10*time.Minute, start.Add(time.Minute))
+	report := sched.report(start.Add(2 * time.Minute))
+	if entry := report.Entries[0]; entry.Interval != "10m0s" || !entry.NextRun.Equal(start.Add(11*time.Minute)) {
+		t.Errorf("expected the reset discovery interval, got %+v", entry)
+	}
+}
+
+// TestScheduleHandlerAndTable validates the API response and the table output
+func TestScheduleHandlerAndTable(t *testing.T) {
+	hs := &HealthServer{}
+	rec := httptest.NewRecorder()
+	hs.scheduleHandler(rec, httptest.NewRequest(http.MethodGet, "/api/schedule", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without a schedule, got %d", rec.Code)
+	}
+
+	hs.SetSchedule(newDaemonSchedule(scheduleTestConfig()))
+	rec = httptest.NewRecorder()
+	hs.scheduleHandler(rec, httptest.NewRequest(http.MethodGet, "/api/schedule", nil))
+	var report scheduleReport
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode schedule: %v", err)
+	}
+	if report.Timezone != "Local" || len(report.Entries) == 0 {
+		t.Errorf("unexpected schedule: %+v", report)
+	}
+
+	var buf bytes.Buffer
+	if err := writeSchedule(&buf, "table", report); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "SUBSYSTEM") || !strings.Contains(out, "office (192.168.1.0/24)") || !strings.Contains(out, "10.0.5.0/24 8/s burst 8; 10.0.0.0/8 32/s burst 64; global 64/s burst 256") {
+		t.Errorf("unexpected table:\n%s", out)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// shutdownGroup is a set of goroutines waited for at shutdown
+type shutdownGroup struct {
+	name    string          // Shown in logs, e.g. "ping_workers"
+	wg      *sync.WaitGroup // Done once every goroutine of the group has exited
+	total   int             // Goroutines running when shutdown began
+	running func() int      // Goroutines that have not exited yet
+}
+
+// waitForShutdown waits for all groups in parallel, for at most timeout in total, and logs how many goroutines
+// of each group exited cleanly; goroutines still running at the deadline are abandoned with a warning
+// Returns true when every goroutine exited in time
+func waitForShutdown(timeout time.Duration, groups ...shutdownGroup) bool {
+	done := make(chan struct{})
+	go func() {
+		for _, group := range groups {
+			group.wg.Wait()
+		}
+		close(done)
+	}()
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	clean := true
+	select {
+	case <-done:
+	case <-deadline.C:
+		clean = false
+	}
+
+	for _, group := range groups {
+		remaining := 0
+		if !clean {
+			remaining = group.running()
+		}
+		exited := max(group.total-remaining, 0)
+		if remaining > 0 {
+			log.Warn().
+				Str("group", group.name).
+				Int("exited", exited).
+				Int("abandoned", remaining).
+				Dur("shutdown_timeout", timeout).
+				Msg("Goroutines did not stop before the shutdown deadline, abandoning them")
+			continue
+		}
+		log.Info().
+			Str("group", group.name).
+			Int("exited", exited).
+			Msg("Goroutines stopped cleanly")
+	}
+	return clean
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestWaitForShutdown verifies groups are waited for in parallel and stuck goroutines are abandoned at the deadline
+func TestWaitForShutdown(t *testing.T) {
+	var fastWg, slowWg sync.WaitGroup
+	var fastRunning, slowRunning atomic.Int64
+	release := make(chan struct{})
+	start := func(wg *sync.WaitGroup, running *atomic.Int64, wait <-chan struct{}) {
+		wg.Add(1)
+		running.Add(1)
+		go func() {
+			defer wg.Done()
+			defer running.Add(-1)
+			<-wait
+		}()
+	}
+	stopped := make(chan struct{})
+	close(stopped)
+	for i := 0; i < 3; i++ {
+		start(&fastWg, &fastRunning, stopped)
+	}
+	start(&slowWg, &slowRunning, stopped)
+	start(&slowWg, &slowRunning, release)
+	defer close(release)
+
+	group := func(name string, wg *sync.WaitGroup, running *atomic.Int64, total int) shutdownGroup {
+		return shutdownGroup{name: name, wg: wg, total: total, running: func() int { return int(running.Load()) }}
+	}
+	begin := time.Now()
+	if waitForShutdown(100*time.Millisecond, group("fast", &fastWg, &fastRunning, 3), group("slow", &slowWg, &slowRunning, 2)) {
+		t.Error("expected an unclean shutdown with a stuck goroutine")
+	}
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Errorf("expected to give up at the deadline, waited %v", elapsed)
+	}
+	if got := slowRunning.Load(); got != 1 {
+		t.Errorf("expected 1 abandoned goroutine, got %d", got)
+	}
+
+	if !waitForShutdown(time.Second, group("fast", &fastWg, &fastRunning, 3)) {
+		t.Error("expected a clean shutdown once every goroutine exited")
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/discovery"
+	"github.com/kljama/netscan/internal/output"
+	"github.com/kljama/netscan/internal/state"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/time/rate"
+)
+
+// snmpRescanBatch is the number of devices queried per RunSNMPScan call of the daily re-scan
+const snmpRescanBatch = 256
+
+// snmpRescan is the daily full SNMP re-scan (snmp_daily_schedule): it queries sysName/sysDescr of every
+// monitored device again and re-enriches the devices whose values changed since they were last recorded,
+// catching renames and firmware upgrades on devices the continuous poller has suspended
+type snmpRescan struct {
+	stateMgr *state.Manager
+	results  output.Sink
+	limiter  *rate.Limiter                                         // SNMP rate limit, one token per device
+	snmpFor  func(ip string) *config.SNMPConfig                    // Credentials of each device (snmp or its site's)
+	workers  func() int                                            // SNMP workers, read at the start of every batch (auto-tuned)
+	fields   func(ip, hostname, sysDescr string) map[string]string // device_info fields of a re-enriched device
+	scan     func(ctx context.Context, ips []string, snmpConfig *config.SNMPConfig, workers int) []state.Device
+	done     func(summary snmpRescanSummary) // Called after each background run (optional)
+
+	running atomic.Bool // A re-scan is in progress; overlapping runs are skipped
+}
+
+// snmpRescanSummary counts the devices of one re-scan
+type snmpRescanSummary struct {
+	Devices  int // Devices queried
+	Answered int // Devices that answered SNMP
+	Changed  int // Devices re-enriched because sysName or sysDescr changed
+	Duration time.Duration
+}
+
+// newSNMPRescan creates the daily re-scan over stateMgr's devices, querying them from sourceIP ("" = any)
+func newSNMPRescan(cfg *config.Config, stateMgr *state.Manager, results output.Sink, limiter *rate.Limiter, sourceIP string, fields func(ip, hostname, sysDescr string) map[string]string) *snmpRescan {
+	return &snmpRescan{
+		stateMgr: stateMgr,
+		results:  results,
+		limiter:  limiter,
+		snmpFor:  cfg.SNMPResolver(),
+		workers:  func() int { return cfg.SnmpWorkers },
+		fields:   fields,
+		scan: func(ctx context.Context, ips []string, snmpConfig *config.SNMPConfig, workers int) []state.Device {
+			return discovery.RunSNMPScan(ctx, ips, snmpConfig, workers, sourceIP)
+		},
+	}
+}
+
+// start runs a re-scan in the background unless one is still running; returns whether it started
+func (r *snmpRescan) start(ctx context.Context) bool {
+	if !r.running.CompareAndSwap(false, true) {
+		return false
+	}
+	go func() {
+		// Panic recovery for the SNMP re-scan goroutine
+		defer func() {
+			if rec := recover(); rec != nil {
+				log.Error().
+					Interface("panic", rec).
+					Msg("SNMP re-scan panic recovered")
+			}
+		}()
+		defer r.running.Store(false)
+		summary := r.run(ctx)
+		if r.done != nil {
+			r.done(summary)
+		}
+	}()
+	return true
+}
+
+// run queries every monitored device in batches, logging progress after each batch, and re-enriches
+// devices whose sysName or sysDescr differs from state. A cancelled context ends the run after the current batch
+func (r *snmpRescan) run(ctx context.Context) snmpRescanSummary {
+	started := time.Now()
+	known := make(map[string]state.Device)
+	for _, dev := range r.stateMgr.GetAll() {
+		known[dev.IP] = dev
+	}
+	ips := make([]string, 0, len(known))
+	for ip := range known {
+		ips = append(ips, ip)
+	}
+	summary := snmpRescanSummary{}
+	log.Info().Int("devices", len(ips)).Msg("Daily SNMP re-scan started")
+
+	for len(ips) > 0 && ctx.Err() == nil {
+		batch := ips[:min(snmpRescanBatch, len(ips))]
+		ips = ips[len(batch):]
+
+		// Devices of each site are queried with that site's SNMP credentials
+		bySettings := make(map[*config.SNMPConfig][]string)
+		for _, ip := range batch {
+			if r.limiter != nil && r.limiter.Wait(ctx) != nil {
+				break
+			}
+			bySettings[r.snmpFor(ip)] = append(bySettings[r.snmpFor(ip)], ip)
+			summary.Devices++
+		}
+		for snmpConfig, group := range bySettings {
+			for _, dev := range r.scan(ctx, group, snmpConfig, r.workers()) {
+				summary.Answered++
+				if r.reenrich(known[dev.IP], dev) {
+					summary.Changed++
+				}
+			}
+		}
+		log.Info().
+			Int("scanned", summary.Devices).
+			Int("remaining", len(ips)).
+			Int("answered", summary.Answered).
+			Int("changed", summary.Changed).
+			Msg("Daily SNMP re-scan progress")
+	}
+
+	summary.Duration = time.Since(started)
+	log.Info().
+		Int("devices", summary.Devices).
+		Int("answered", summary.Answered).
+		Int("changed", summary.Changed).
+		Dur("duration", summary.Duration).
+		Bool("cancelled", ctx.Err() != nil).
+		Msg("Daily SNMP re-scan completed")
+	return summary
+}
+
+// device re-scans one device on demand (POST /api/device/{ip}/rescan), re-enriching it when its sysName or
+// sysDescr changed. Returns the device as it answered and whether it answered and was re-enriched
+func (r *snmpRescan) device(ctx context.Context, ip string) (state.Device, bool, bool) {
+	previous, known := r.stateMgr.Get(ip)
+	if !known {
+		return state.Device{}, false, false
+	}
+	if r.limiter != nil && r.limiter.Wait(ctx) != nil {
+		return state.Device{}, false, false
+	}
+	found := r.scan(ctx, []string{ip}, r.snmpFor(ip), 1)
+	if len(found) == 0 {
+		return state.Device{}, false, false
+	}
+	return found[0], true, r.reenrich(*previous, found[0])
+}
+
+// reenrich updates state and writes device_info when a device's sysName or sysDescr changed
+// Returns whether the device was re-enriched
+func (r *snmpRescan) reenrich(previous, dev state.Device) bool {
+	if dev.Hostname == previous.Hostname && dev.SysDescr == previous.SysDescr {
+		return false
+	}
+	r.stateMgr.UpdateDeviceSNMP(dev.IP, dev.Hostname, dev.SysDescr)
+	if !dev.SystemPolledAt.IsZero() {
+		r.stateMgr.UpdateDeviceSystem(dev.IP, dev.System, dev.SystemPolledAt)
+	}
+	if err := r.results.WriteDeviceInfoFields(dev.IP, dev.Hostname, dev.SysDescr, dev.System, r.fields(dev.IP, dev.Hostname, dev.SysDescr)); err != nil {
+		log.Error().
+			Str("ip", dev.IP).
+			Err(err).
+			Msg("Failed to write re-scanned device info")
+	}
+	log.Info().
+		Str("ip", dev.IP).
+		Str("previous_hostname", previous.Hostname).
+		Str("hostname", dev.Hostname).
+		Bool("sys_descr_changed", dev.SysDescr != previous.SysDescr).
+		Msg("Device re-enriched by SNMP re-scan")
+	return true
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/output"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// deviceInfoSink records device_info writes (other methods are not used by the re-scan)
+type deviceInfoSink struct {
+	output.Sink
+	mu      sync.Mutex
+	written map[string]map[string]string // fields by IP
+}
+
+func (s *deviceInfoSink) WriteDeviceInfoFields(ip, hostname, sysDescr string, system state.SystemInfo, fields map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.written[ip] = fields
+	return nil
+}
+
+// TestSNMPRescan verifies every device is queried with its site's credentials and only devices whose
+// sysName or sysDescr changed are re-enriched
+func TestSNMPRescan(t *testing.T) {
+	stateMgr := state.NewManager(100)
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.1.0.1"} {
+		stateMgr.AddDevice(ip)
+	}
+	stateMgr.UpdateDeviceSNMP("10.0.0.1", "core-sw", "Cisco IOS 15.2")
+	stateMgr.UpdateDeviceSNMP("10.0.0.2", "edge-rtr", "JunOS 20.4")
+
+	cfg := &config.Config{
+		SNMP:        config.SNMPConfig{Community: "global"},
+		Sites:       []config.SiteConfig{{Name: "fra1", Networks: []string{"10.1.0.0/16"}, SNMP: config.SiteSNMPConfig{Community: "fra1"}}},
+		SnmpWorkers: 4,
+	}
+	sink := &deviceInfoSink{written: make(map[string]map[string]string)}
+	rescan := newSNMPRescan(cfg, stateMgr, sink, nil, "", func(ip, hostname, sysDescr string) map[string]string {
+		return map[string]string{"from": hostname}
+	})
+
+	answers := map[string]state.Device{
+		"10.0.0.1": {IP: "10.0.0.1", Hostname: "core-sw", SysDescr: "Cisco IOS 15.2"},                 // Unchanged
+		"10.0.0.2": {IP: "10.0.0.2", Hostname: "edge-rtr", SysDescr: "JunOS 22.1"},                    // Upgraded
+		"10.1.0.1": {IP: "10.1.0.1", Hostname: "fra1-ap", SysDescr: "AP", SystemPolledAt: time.Now()}, // First answer
+	}
+	var mu sync.Mutex
+	communities := make(map[string]string)
+	rescan.scan = func(ctx context.Context, ips []string, snmpConfig *config.SNMPConfig, workers int) []state.Device {
+		mu.Lock()
+		defer mu.Unlock()
+		var found []state.Device
+		for _, ip := range ips {
+			communities[ip] = snmpConfig.Community
+			if dev, ok := answers[ip]; ok {
+				found = append(found, dev)
+			}
+		}
+		return found
+	}
+
+	summary := rescan.run(context.Background())
+	if summary.Devices != 4 || summary.Answered != 3 || summary.Changed != 2 {
+		t.Errorf("expected 4 devices, 3 answered and 2 changed, got %+v", summary)
+	}
+	if communities["10.1.0.1"] != "fra1" || communities["10.0.0.3"] != "global" {
+		t.Errorf("expected site credentials for 10.1.0.1 and global ones elsewhere, got %v", communities)
+	}
+	var written []string
+	for ip := range sink.written {
+		written = append(written, ip)
+	}
+	sort.Strings(written)
+	if len(written) != 2 || written[0] != "10.0.0.2" || written[1] != "10.1.0.1" || sink.written["10.1.0.1"]["from"] != "fra1-ap" {
+		t.Errorf("expected device_info for the changed devices only, got %v", sink.written)
+	}
+	if dev, _ := stateMgr.Get("10.0.0.2"); dev.SysDescr != "JunOS 22.1" {
+		t.Errorf("expected state to hold the new sysDescr, got %q", dev.SysDescr)
+	}
+
+	// Overlapping runs are skipped
+	rescan.running.Store(true)
+	if rescan.start(context.Background()) {
+		t.Error("expected a re-scan to be skipped while one is running")
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/influx"
+	"github.com/kljama/netscan/internal/state"
+	"github.com/rs/zerolog/log"
+)
+
+// subnetHealthReporter aggregates the devices of every configured network for the subnet_health measurement
+type subnetHealthReporter struct {
+	cfg      *config.Config
+	stateMgr *state.Manager
+	subnet   func(ip string) string // Most specific configured network of a device ("" outside every network)
+	writers  []*influx.Writer       // Main writer of every InfluxDB destination
+}
+
+// newSubnetHealthReporter returns the reporter of cfg's networks, writing to the main writer of every destination
+func newSubnetHealthReporter(cfg *config.Config, stateMgr *state.Manager, destinations []*influxDestination) *subnetHealthReporter {
+	r := &subnetHealthReporter{cfg: cfg, stateMgr: stateMgr, subnet: cfg.SubnetResolver()}
+	for _, destination := range destinations {
+		r.writers = append(r.writers, destination.writer)
+	}
+	return r
+}
+
+// summarize returns one entry per configured network, in config order, including networks without devices
+// Devices are counted like /api/groups; RTT and loss cover the ping cycles recorded after since
+func (r *subnetHealthReporter) summarize(since, now time.Time) []influx.SubnetHealth {
+	subnets := make([]influx.SubnetHealth, 0, len(r.cfg.Networks))
+	index := make(map[string]int, len(r.cfg.Networks))
+	for _, cidr := range r.cfg.Networks {
+		if _, ok := index[cidr]; ok {
+			continue
+		}
+		index[cidr] = len(subnets)
+		subnets = append(subnets, influx.SubnetHealth{CIDR: cidr, Network: r.cfg.NetworkLabel(cidr)})
+	}
+
+	cycles := make([][]state.DailyRollup, len(subnets))
+	for _, dev := range r.stateMgr.GetAll() {
+		i, ok := index[r.subnet(dev.IP)]
+		if !ok {
+			continue
+		}
+		subnet := &subnets[i]
+		subnet.Devices++
+		switch {
+		case dev.SuspendedUntil.After(now):
+			subnet.Suspended++
+		case dev.Reachability == state.ReachabilityUp:
+			subnet.Up++
+		case dev.Reachability == state.ReachabilityDown:
+			subnet.Down++
+		default:
+			subnet.Pending++
+		}
+		var recent []state.RTTSample
+		for _, sample := range r.stateMgr.GetRTTHistory(dev.IP) {
+			if sample.Time.After(since) {
+				recent = append(recent, sample)
+			}
+		}
+		if len(recent) > 0 {
+			cycles[i] = append(cycles[i], state.SummarizeRTTHistory(recent))
+		}
+	}
+	for i := range subnets {
+		subnets[i].Pings = state.SumRollups(cycles[i])
+	}
+	return subnets
+}
+
+// write writes the summaries of the interval from since to now to every destination
+func (r *subnetHealthReporter) write(since, now time.Time) {
+	if len(r.writers) == 0 {
+		return
+	}
+	for _, subnet := range r.summarize(since, now) {
+		for _, w := range r.writers {
+			if err := w.WriteSubnetHealth(subnet); err != nil {
+				log.Warn().
+					Str("cidr", subnet.CIDR).
+					Err(err).
+					Msg("Failed to write subnet health")
+			}
+		}
+	}
+}
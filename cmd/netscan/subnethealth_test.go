@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// TestSubnetHealthSummarize validates per-network counts and that RTT and loss only cover the last interval
+func TestSubnetHealthSummarize(t *testing.T) {
+	stateMgr := state.NewManager(100)
+	stateMgr.EnableRTTHistory(5)
+	stateMgr.SetDownThreshold(1)
+	cfg := &config.Config{
+		Networks:      []string{"10.1.0.0/16", "10.1.5.0/24", "10.2.0.0/24"},
+		NetworkLabels: map[string]string{"10.1.5.0/24": "fra1-servers"},
+	}
+	reporter := newSubnetHealthReporter(cfg, stateMgr, nil)
+
+	now := time.Now()
+	since := now.Add(-time.Minute)
+	stateMgr.Add(state.Device{IP: "10.1.5.1"})
+	stateMgr.Add(state.Device{IP: "10.1.5.2"})
+	stateMgr.Add(state.Device{IP: "10.1.7.1"})
+	stateMgr.Add(state.Device{IP: "192.168.1.1"}) // Outside every network
+	stateMgr.ReportPingSuccess("10.1.5.1")
+	stateMgr.RecordPingCycle("10.1.5.1", 2, 2, time.Millisecond, 2*time.Millisecond, 3*time.Millisecond, now.Add(-2*time.Minute))
+	stateMgr.RecordPingCycle("10.1.5.1", 2, 2, time.Millisecond, 4*time.Millisecond, 5*time.Millisecond, now)
+	stateMgr.ReportPingFail("10.1.5.2", 10, time.Minute)
+	stateMgr.RecordPingCycle("10.1.5.2", 2, 0, 0, 0, 0, now)
+
+	subnets := reporter.summarize(since, now)
+	if len(subnets) != 3 {
+		t.Fatalf("expected one entry per configured network, got %+v", subnets)
+	}
+	campus, servers, empty := subnets[0], subnets[1], subnets[2]
+	if campus.CIDR != "10.1.0.0/16" || campus.Devices != 1 || campus.Pending != 1 {
+		t.Errorf("expected the /16 to hold only the device outside the /24, got %+v", campus)
+	}
+	if servers.Network != "fra1-servers" || servers.Devices != 2 || servers.Up != 1 || servers.Down != 1 {
+		t.Errorf("expected one up and one down device in fra1-servers, got %+v", servers)
+	}
+	if servers.Pings.Cycles != 2 || servers.Pings.AvgRTTMs() != 4 || servers.Pings.PacketLossPct() != 50 {
+		t.Errorf("expected the two cycles of the interval (4ms, 50%% loss), got %+v", servers.Pings)
+	}
+	if empty.CIDR != "10.2.0.0/24" || empty.Devices != 0 || empty.Pings.Cycles != 0 {
+		t.Errorf("expected an empty entry for the network without devices, got %+v", empty)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/discovery"
+)
+
+const validateUsage = "usage: netscan validate [-vars vars.yml] <config.yml>"
+
+// runValidate loads a config file (resolving vars and environment variables) and reports every validation error and warning
+// Exits 1 when the config fails to load or has errors, so it can gate deployments in CI
+func runValidate(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	varsPath := fs.String("vars", "", "Per-site variables file overriding the config's vars block")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, validateUsage)
+		return 2
+	}
+	path := fs.Arg(0)
+
+	cfg, err := config.LoadConfigWithVars(path, *varsPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: failed to load config: %v\n", path, err)
+		return 1
+	}
+	result := config.CheckConfig(cfg)
+	if err := discovery.CheckDiscoverers(cfg.DiscoveryMethods); err != nil {
+		result.Errors = append(result.Errors, err) // The method registry lives in the discovery package
+	}
+	writeValidationResult(os.Stdout, path, result)
+	if !result.OK() {
+		return 1
+	}
+	return 0
+}
+
+// writeValidationResult prints one line per error and warning followed by a summary line
+func writeValidationResult(w io.Writer, path string, result config.ValidationResult) {
+	for _, err := range result.Errors {
+		fmt.Fprintf(w, "%s: error: %v\n", path, err)
+	}
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(w, "%s: warning: %s\n", path, strings.TrimPrefix(warning, "WARNING: "))
+	}
+	if result.OK() && len(result.Warnings) == 0 {
+		fmt.Fprintf(w, "%s: OK\n", path)
+		return
+	}
+	fmt.Fprintf(w, "%s: %d error(s), %d warning(s)\n", path, len(result.Errors), len(result.Warnings))
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kljama/netscan/internal/config"
+)
+
+// TestWriteValidationResult validates the per-finding lines and the summary line
+func TestWriteValidationResult(t *testing.T) {
+	var buf bytes.Buffer
+	writeValidationResult(&buf, "config.yml", config.ValidationResult{})
+	if buf.String() != "config.yml: OK\n" {
+		t.Errorf("unexpected output for a clean config: %q", buf.String())
+	}
+
+	buf.Reset()
+	writeValidationResult(&buf, "config.yml", config.ValidationResult{
+		Errors:   []error{errors.New("icmp_workers must be between 1 and 2000"), errors.New("invalid network")},
+		Warnings: []string{"WARNING: Using default SNMP community 'public'"},
+	})
+	want := "config.yml: error: icmp_workers must be between 1 and 2000\n" +
+		"config.yml: error: invalid network\n" +
+		"config.yml: warning: Using default SNMP community 'public'\n" +
+		"config.yml: 2 error(s), 1 warning(s)\n"
+	if buf.String() != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+// TestRunValidate validates exit codes for usage errors, unreadable and invalid configs
+func TestRunValidate(t *testing.T) {
+	if code := runValidate(nil); code != 2 {
+		t.Errorf("expected exit code 2 without a config path, got %d", code)
+	}
+	if code := runValidate([]string{filepath.Join(t.TempDir(), "missing.yml")}); code != 1 {
+		t.Errorf("expected exit code 1 for a missing file, got %d", code)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	invalid := "networks:\n  - \"not-a-cidr\"\nsnmp:\n  community: \"netscan-ro\"\n"
+	if err := os.WriteFile(path, []byte(invalid), 0644); err != nil {
+		t.Fatal(err)
+	}
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	stdout := os.Stdout
+	os.Stdout = devNull
+	code := runValidate([]string{path})
+	os.Stdout = stdout
+	if code != 1 {
+		t.Errorf("expected exit code 1 for an invalid config, got %d", code)
+	}
+}
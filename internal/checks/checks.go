@@ -0,0 +1,149 @@
+// Package checks evaluates config-defined composite health checks against in-memory device state
+package checks
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/state"
+	"github.com/rs/zerolog/log"
+)
+
+// StateSource provides the probe results composite checks are evaluated against
+type StateSource interface {
+	GetAll() []state.Device
+	GetSNMPResult(ip string) (*state.SNMPResult, bool)
+	IsSNMPSuspended(ip string) bool
+}
+
+// ResultWriter receives every evaluation (implemented by output.Sink)
+type ResultWriter interface {
+	WriteCompositeCheck(ip, check string, healthy bool, passed, total int, failed []string) error
+}
+
+// Result is one evaluation of a composite check for one device
+type Result struct {
+	Check    string
+	IP       string
+	Hostname string
+	Healthy  bool
+	Passed   int      // Conditions that held
+	Total    int      // Conditions evaluated
+	Failed   []string // Labels of conditions that did not hold
+	Previous bool     // Health at the previous evaluation (meaningful for transitions only)
+	Time     time.Time
+}
+
+// Evaluator runs composite checks and reports health transitions
+// Not safe for concurrent use: Evaluate is called from the main event loop only
+type Evaluator struct {
+	checks   []config.CompositeCheckConfig
+	source   StateSource
+	writer   ResultWriter
+	onChange func(Result)
+	last     map[string]bool // Health per check and device ("check|ip") at the previous evaluation
+}
+
+// NewEvaluator creates an evaluator for the configured checks
+func NewEvaluator(checks []config.CompositeCheckConfig, source StateSource, writer ResultWriter) *Evaluator {
+	return &Evaluator{
+		checks: checks,
+		source: source,
+		writer: writer,
+		last:   make(map[string]bool),
+	}
+}
+
+// SetChangeHandler registers a callback for every health transition
+// A device's first evaluation only reports a transition when it is unhealthy
+func (e *Evaluator) SetChangeHandler(handler func(Result)) {
+	e.onChange = handler
+}
+
+// Evaluate runs every check against every matching device, writes the results and returns them
+// Devices without a ping result yet are skipped so startup does not raise false alarms
+func (e *Evaluator) Evaluate() []Result {
+	now := time.Now()
+	devices := e.source.GetAll()
+	seen := make(map[string]bool)
+	var results []Result
+
+	for _, check := range e.checks {
+		for _, dev := range devices {
+			if dev.Reachability == "" || !check.Matches(dev.IP) {
+				continue
+			}
+			result := e.evaluate(check, dev)
+			result.Time = now
+			results = append(results, result)
+
+			if err := e.writer.WriteCompositeCheck(dev.IP, check.Name, result.Healthy, result.Passed, result.Total, result.Failed); err != nil {
+				log.Error().
+					Str("ip", dev.IP).
+					Str("check", check.Name).
+					Err(err).
+					Msg("Failed to write composite check result")
+			}
+
+			key := check.Name + "|" + dev.IP
+			seen[key] = true
+			previous, known := e.last[key]
+			e.last[key] = result.Healthy
+			if (known && previous != result.Healthy) || (!known && !result.Healthy) {
+				result.Previous = known && previous
+				if e.onChange != nil {
+					e.onChange(result)
+				}
+			}
+		}
+	}
+
+	// Forget devices that were pruned or no longer match
+	for key := range e.last {
+		if !seen[key] {
+			delete(e.last, key)
+		}
+	}
+	return results
+}
+
+// evaluate checks every condition of one check against one device
+func (e *Evaluator) evaluate(check config.CompositeCheckConfig, dev state.Device) Result {
+	result := Result{Check: check.Name, IP: dev.IP, Hostname: dev.Hostname, Total: len(check.Conditions)}
+	snmp, hasSNMP := e.source.GetSNMPResult(dev.IP)
+
+	for _, cond := range check.Conditions {
+		var ok bool
+		switch cond.Type {
+		case config.ConditionICMP:
+			ok = dev.Reachability == state.ReachabilityUp
+		case config.ConditionSNMP:
+			ok = hasSNMP && !e.source.IsSNMPSuspended(dev.IP)
+		case config.ConditionSNMPValue:
+			ok = hasSNMP && valueEquals(snmp, cond)
+		}
+		if ok {
+			result.Passed++
+		} else {
+			result.Failed = append(result.Failed, cond.Label())
+		}
+	}
+
+	if check.Mode == config.CheckModeAny {
+		result.Healthy = result.Passed > 0
+	} else {
+		result.Healthy = result.Passed == result.Total
+	}
+	return result
+}
+
+// valueEquals reports whether the SNMP result holds the condition's value, compared as text
+func valueEquals(result *state.SNMPResult, cond config.CheckCondition) bool {
+	for _, v := range result.Values {
+		if v.Name == cond.Name && (cond.Group == "" || v.Group == cond.Group) {
+			return fmt.Sprint(v.Value) == cond.Equals
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package checks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// fakeSource serves fixed devices and SNMP results
+type fakeSource struct {
+	devices   []state.Device
+	results   map[string]*state.SNMPResult
+	suspended map[string]bool
+}
+
+func (f *fakeSource) GetAll() []state.Device { return f.devices }
+func (f *fakeSource) GetSNMPResult(ip string) (*state.SNMPResult, bool) {
+	r, ok := f.results[ip]
+	return r, ok
+}
+func (f *fakeSource) IsSNMPSuspended(ip string) bool { return f.suspended[ip] }
+
+// recordingWriter records written check results
+type recordingWriter struct {
+	writes int
+}
+
+func (w *recordingWriter) WriteCompositeCheck(ip, check string, healthy bool, passed, total int, failed []string) error {
+	w.writes++
+	return nil
+}
+
+// routerCheck is "router healthy = ICMP up AND SNMP reachable AND uplink ifOperStatus up"
+var routerCheck = config.CompositeCheckConfig{
+	Name:    "router_healthy",
+	Mode:    config.CheckModeAll,
+	Devices: []string{"10.0.0.1"},
+	Conditions: []config.CheckCondition{
+		{Type: config.ConditionICMP},
+		{Type: config.ConditionSNMP},
+		{Type: config.ConditionSNMPValue, Group: "ifTable", Name: "ifOperStatus.1", Equals: "1"},
+	},
+}
+
+func snmpResult(operStatus int) *state.SNMPResult {
+	return &state.SNMPResult{PolledAt: time.Now(), Values: []state.SNMPValue{
+		{Group: "ifTable", Name: "ifOperStatus.1", Value: operStatus},
+	}}
+}
+
+// TestEvaluateAllOf verifies all-of evaluation, failure labels and transition reporting
+func TestEvaluateAllOf(t *testing.T) {
+	source := &fakeSource{
+		devices: []state.Device{
+			{IP: "10.0.0.1", Hostname: "router1", Reachability: state.ReachabilityUp},
+			{IP: "10.0.0.2", Reachability: state.ReachabilityUp}, // Not targeted by the check
+			{IP: "10.0.0.3"}, // Not pinged yet
+		},
+		results:   map[string]*state.SNMPResult{"10.0.0.1": snmpResult(1)},
+		suspended: map[string]bool{},
+	}
+	writer := &recordingWriter{}
+	e := NewEvaluator([]config.CompositeCheckConfig{routerCheck}, source, writer)
+	var changes []Result
+	e.SetChangeHandler(func(r Result) { changes = append(changes, r) })
+
+	results := e.Evaluate()
+	if len(results) != 1 || !results[0].Healthy || results[0].Passed != 3 {
+		t.Fatalf("expected one healthy result, got %+v", results)
+	}
+	if len(changes) != 0 {
+		t.Errorf("healthy first evaluation should not be a transition, got %+v", changes)
+	}
+
+	// Uplink goes down
+	source.results["10.0.0.1"] = snmpResult(2)
+	results = e.Evaluate()
+	if results[0].Healthy || len(results[0].Failed) != 1 || results[0].Failed[0] != "snmp_value:ifOperStatus.1" {
+		t.Fatalf("expected uplink failure, got %+v", results[0])
+	}
+	if len(changes) != 1 || changes[0].Healthy || !changes[0].Previous {
+		t.Fatalf("expected healthy -> unhealthy transition, got %+v", changes)
+	}
+
+	// Still failing: no repeated transition
+	e.Evaluate()
+	if len(changes) != 1 {
+		t.Errorf("unchanged result should not be reported again, got %d transitions", len(changes))
+	}
+	if writer.writes != 3 {
+		t.Errorf("expected every evaluation to be written, got %d writes", writer.writes)
+	}
+}
+
+// TestEvaluateAnyOf verifies any-of checks stay healthy while one condition holds
+func TestEvaluateAnyOf(t *testing.T) {
+	check := config.CompositeCheckConfig{
+		Name: "reachable",
+		Mode: config.CheckModeAny,
+		Conditions: []config.CheckCondition{
+			{Type: config.ConditionICMP},
+			{Type: config.ConditionSNMP},
+		},
+	}
+	source := &fakeSource{
+		devices:   []state.Device{{IP: "10.0.0.5", Reachability: state.ReachabilityDown}},
+		results:   map[string]*state.SNMPResult{"10.0.0.5": snmpResult(1)},
+		suspended: map[string]bool{},
+	}
+	e := NewEvaluator([]config.CompositeCheckConfig{check}, source, &recordingWriter{})
+	var changes []Result
+	e.SetChangeHandler(func(r Result) { changes = append(changes, r) })
+
+	if r := e.Evaluate()[0]; !r.Healthy || r.Passed != 1 {
+		t.Fatalf("expected healthy via SNMP, got %+v", r)
+	}
+
+	source.suspended["10.0.0.5"] = true
+	if r := e.Evaluate()[0]; r.Healthy {
+		t.Fatalf("expected unhealthy with ICMP down and SNMP suspended, got %+v", r)
+	}
+	if len(changes) != 1 {
+		t.Errorf("expected 1 transition, got %d", len(changes))
+	}
+}
@@ -0,0 +1,139 @@
+package checks
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// RTTHistorySource provides the recent ping cycles percentile thresholds are evaluated over
+type RTTHistorySource interface {
+	GetRTTHistory(ip string) []state.RTTSample
+}
+
+// LatencyAlert is a change of a device's level for one latency threshold
+type LatencyAlert struct {
+	Threshold  string
+	IP         string
+	Hostname   string
+	Level      string        // ok, warning or critical
+	Previous   string        // Level before the change (ok for a device's first alert)
+	RTT        time.Duration // RTT compared with the limits
+	Limit      time.Duration // Limit that was exceeded (0 for ok)
+	Percentile float64       // Percentile compared (0 for per-cycle thresholds)
+	Time       time.Time
+}
+
+// LatencyEvaluator checks every successful ping cycle against the latency thresholds and reports level changes
+// Safe for concurrent use: pingers call ObservePingCycle from many goroutines
+type LatencyEvaluator struct {
+	thresholds []config.LatencyThresholdConfig
+	history    RTTHistorySource
+	onChange   func(LatencyAlert)
+	mu         sync.Mutex
+	levels     map[string]string // Level per threshold and device ("threshold|ip"); absent = ok
+}
+
+// NewLatencyEvaluator creates an evaluator for the configured thresholds
+// history serves percentile thresholds; without it they compare each cycle's RTT
+func NewLatencyEvaluator(thresholds []config.LatencyThresholdConfig, history RTTHistorySource) *LatencyEvaluator {
+	return &LatencyEvaluator{
+		thresholds: thresholds,
+		history:    history,
+		levels:     make(map[string]string),
+	}
+}
+
+// SetChangeHandler registers a callback for every level change
+// Call before pinging starts; the handler runs on the pinger's goroutine and must not block
+func (e *LatencyEvaluator) SetChangeHandler(handler func(LatencyAlert)) {
+	e.onChange = handler
+}
+
+// ObservePingCycle evaluates a successful ping cycle with average RTT avgRtt against every matching threshold
+func (e *LatencyEvaluator) ObservePingCycle(ip, hostname string, avgRtt time.Duration, at time.Time) {
+	var history []state.RTTSample
+	var alerts []LatencyAlert
+
+	e.mu.Lock()
+	for i := range e.thresholds {
+		threshold := &e.thresholds[i]
+		if !threshold.Matches(ip) {
+			continue
+		}
+		rtt := avgRtt
+		if threshold.Percentile > 0 && e.history != nil {
+			if history == nil {
+				history = e.history.GetRTTHistory(ip)
+			}
+			if p, ok := rttPercentile(history, threshold.Percentile); ok {
+				rtt = p
+			}
+		}
+
+		level, limit := threshold.Level(rtt)
+		key := threshold.Name + "|" + ip
+		previous, known := e.levels[key]
+		if !known {
+			previous = config.LatencyLevelOK
+		}
+		if level == previous {
+			continue
+		}
+		if level == config.LatencyLevelOK {
+			delete(e.levels, key)
+		} else {
+			e.levels[key] = level
+		}
+		alerts = append(alerts, LatencyAlert{
+			Threshold:  threshold.Name,
+			IP:         ip,
+			Hostname:   hostname,
+			Level:      level,
+			Previous:   previous,
+			RTT:        rtt,
+			Limit:      limit,
+			Percentile: threshold.Percentile,
+			Time:       at,
+		})
+	}
+	e.mu.Unlock()
+
+	if e.onChange != nil {
+		for _, alert := range alerts {
+			e.onChange(alert)
+		}
+	}
+}
+
+// Forget drops the levels of a device that is no longer monitored
+func (e *LatencyEvaluator) Forget(ip string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i := range e.thresholds {
+		delete(e.levels, e.thresholds[i].Name+"|"+ip)
+	}
+}
+
+// rttPercentile returns the nearest-rank percentile of the average RTTs of successful cycles
+func rttPercentile(samples []state.RTTSample, percentile float64) (time.Duration, bool) {
+	rtts := make([]float64, 0, len(samples))
+	for _, s := range samples {
+		if s.Recv > 0 {
+			rtts = append(rtts, s.RTTAvgMs)
+		}
+	}
+	if len(rtts) == 0 {
+		return 0, false
+	}
+	sort.Float64s(rtts)
+	rank := int(math.Ceil(percentile / 100 * float64(len(rtts))))
+	if rank < 1 {
+		rank = 1
+	}
+	return time.Duration(rtts[rank-1] * float64(time.Millisecond)), true
+}
@@ -0,0 +1,82 @@
+package checks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// fakeHistory serves fixed RTT histories
+type fakeHistory map[string][]state.RTTSample
+
+func (f fakeHistory) GetRTTHistory(ip string) []state.RTTSample { return f[ip] }
+
+// TestLatencyEvaluatorLevels verifies level changes are reported once per transition and only for matching devices
+func TestLatencyEvaluatorLevels(t *testing.T) {
+	thresholds := []config.LatencyThresholdConfig{
+		{Name: "wan", Networks: []string{"10.0.0.0/8"}, Warning: 50 * time.Millisecond, Critical: 200 * time.Millisecond},
+	}
+	e := NewLatencyEvaluator(thresholds, nil)
+	var alerts []LatencyAlert
+	e.SetChangeHandler(func(a LatencyAlert) { alerts = append(alerts, a) })
+
+	now := time.Now()
+	for _, rtt := range []time.Duration{10, 80, 90, 300, 20} {
+		e.ObservePingCycle("10.0.0.1", "wan-router", rtt*time.Millisecond, now)
+	}
+	e.ObservePingCycle("192.168.1.1", "lan", time.Second, now) // Not targeted
+
+	want := []struct{ level, previous string }{
+		{config.LatencyLevelWarning, config.LatencyLevelOK},
+		{config.LatencyLevelCritical, config.LatencyLevelWarning},
+		{config.LatencyLevelOK, config.LatencyLevelCritical},
+	}
+	if len(alerts) != len(want) {
+		t.Fatalf("expected %d alerts, got %+v", len(want), alerts)
+	}
+	for i, w := range want {
+		if alerts[i].Level != w.level || alerts[i].Previous != w.previous || alerts[i].Threshold != "wan" || alerts[i].Hostname != "wan-router" {
+			t.Errorf("alert %d: expected %s after %s, got %+v", i, w.level, w.previous, alerts[i])
+		}
+	}
+	if alerts[1].RTT != 300*time.Millisecond || alerts[1].Limit != 200*time.Millisecond {
+		t.Errorf("expected the critical alert to carry RTT and limit, got %+v", alerts[1])
+	}
+
+	// A forgotten device starts over at ok
+	e.ObservePingCycle("10.0.0.1", "wan-router", 80*time.Millisecond, now)
+	e.Forget("10.0.0.1")
+	e.ObservePingCycle("10.0.0.1", "wan-router", 80*time.Millisecond, now)
+	if len(alerts) != 5 || alerts[4].Previous != config.LatencyLevelOK {
+		t.Errorf("expected a new warning after Forget, got %+v", alerts)
+	}
+}
+
+// TestLatencyEvaluatorPercentile verifies percentile thresholds compare the RTT history, not the single cycle
+func TestLatencyEvaluatorPercentile(t *testing.T) {
+	var samples []state.RTTSample
+	for i := 1; i <= 10; i++ {
+		samples = append(samples, state.RTTSample{Sent: 1, Recv: 1, RTTAvgMs: float64(i)})
+	}
+	samples = append(samples, state.RTTSample{Sent: 1}) // Failed cycles are ignored
+	history := fakeHistory{"10.0.0.1": samples}
+
+	thresholds := []config.LatencyThresholdConfig{{Name: "p90", Critical: 8500 * time.Microsecond, Percentile: 90}}
+	e := NewLatencyEvaluator(thresholds, history)
+	var alerts []LatencyAlert
+	e.SetChangeHandler(func(a LatencyAlert) { alerts = append(alerts, a) })
+
+	// The current cycle is fast, but the 90th percentile of the history (9ms) is above the limit
+	e.ObservePingCycle("10.0.0.1", "", time.Millisecond, time.Now())
+	if len(alerts) != 1 || alerts[0].Level != config.LatencyLevelCritical || alerts[0].RTT != 9*time.Millisecond || alerts[0].Percentile != 90 {
+		t.Fatalf("expected a critical alert for p90 = 9ms, got %+v", alerts)
+	}
+
+	// Without history the cycle's own RTT is compared
+	e.ObservePingCycle("10.0.0.2", "", 20*time.Millisecond, time.Now())
+	if len(alerts) != 2 || alerts[1].RTT != 20*time.Millisecond {
+		t.Errorf("expected the cycle RTT to be compared without history, got %+v", alerts)
+	}
+}
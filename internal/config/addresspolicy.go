@@ -0,0 +1,15 @@
+package config
+
+import "github.com/kljama/netscan/internal/validate"
+
+// AddressPolicy controls whether special-purpose address ranges may be scanned, monitored and written
+// Defined in the validate package so discovery, monitoring and the InfluxDB writer share one implementation
+type AddressPolicy = validate.AddressPolicy
+
+// AddressPolicy returns the target address policy derived from allow_loopback and allow_link_local
+func (c *Config) AddressPolicy() AddressPolicy {
+	return AddressPolicy{
+		AllowLoopback:  c.AllowLoopback,
+		AllowLinkLocal: c.AllowLinkLocal,
+	}
+}
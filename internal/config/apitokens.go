@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"net"
+)
+
+// minAPITokenLength rejects tokens short enough to guess
+const minAPITokenLength = 16
+
+// APITokenConfig defines a bearer token accepted by the API
+type APITokenConfig struct {
+	Name     string   `yaml:"name"`     // Identifies the token in access logs (the token itself is never logged)
+	Token    string   `yaml:"token"`    // Secret sent as "Authorization: Bearer <token>"; supports ${VAR} expansion
+	Networks []string `yaml:"networks"` // CIDRs whose devices the token may see and manage (empty = all devices)
+}
+
+// validateAPITokens checks token names, secrets and networks
+func validateAPITokens(tokens []APITokenConfig) error {
+	names := make(map[string]bool)
+	secrets := make(map[string]bool)
+	for _, token := range tokens {
+		if !isValidIdentifier(token.Name) {
+			return fmt.Errorf("api_tokens: invalid name %q (use letters, digits and underscores)", token.Name)
+		}
+		if names[token.Name] {
+			return fmt.Errorf("api_tokens: duplicate name %q", token.Name)
+		}
+		names[token.Name] = true
+
+		if len(token.Token) < minAPITokenLength {
+			return fmt.Errorf("api_tokens[%s]: token must be at least %d characters", token.Name, minAPITokenLength)
+		}
+		if secrets[token.Token] {
+			return fmt.Errorf("api_tokens[%s]: token is already used by another entry", token.Name)
+		}
+		secrets[token.Token] = true
+
+		for _, cidr := range token.Networks {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return fmt.Errorf("api_tokens[%s]: invalid network %q", token.Name, cidr)
+			}
+		}
+	}
+	return nil
+}
+
+// APIUserConfig defines a basic-auth user accepted by the API, scoped like a token
+type APIUserConfig struct {
+	Username string   `yaml:"username"` // Identifies the user in access logs; must not clash with a token name
+	Password string   `yaml:"password"` // Sent as "Authorization: Basic ..."; supports ${VAR} expansion
+	Networks []string `yaml:"networks"` // CIDRs whose devices the user may see and manage (empty = all devices)
+}
+
+// validateAPIUsers checks user names, passwords and networks
+func validateAPIUsers(users []APIUserConfig, tokens []APITokenConfig) error {
+	names := make(map[string]bool)
+	for _, token := range tokens {
+		names[token.Name] = true
+	}
+	for _, user := range users {
+		if !isValidIdentifier(user.Username) {
+			return fmt.Errorf("api_users: invalid username %q (use letters, digits and underscores)", user.Username)
+		}
+		if names[user.Username] {
+			return fmt.Errorf("api_users: duplicate name %q (user and token names share one namespace)", user.Username)
+		}
+		names[user.Username] = true
+
+		if len(user.Password) < minAPITokenLength {
+			return fmt.Errorf("api_users[%s]: password must be at least %d characters", user.Username, minAPITokenLength)
+		}
+		for _, cidr := range user.Networks {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return fmt.Errorf("api_users[%s]: invalid network %q", user.Username, cidr)
+			}
+		}
+	}
+	return nil
+}
+
+// APIClientCertConfig maps a client certificate, by common name, to an API caller
+type APIClientCertConfig struct {
+	Name       string   `yaml:"name"`        // Identifies the caller in access logs; must not clash with token or user names
+	CommonName string   `yaml:"common_name"` // Subject CN of certificates issued by health_tls_client_ca
+	Networks   []string `yaml:"networks"`    // CIDRs whose devices the caller may see and manage (empty = all devices)
+}
+
+// validateAPIClientCerts checks client certificate names, common names and networks
+func validateAPIClientCerts(certs []APIClientCertConfig, tokens []APITokenConfig, users []APIUserConfig) error {
+	names := make(map[string]bool)
+	for _, token := range tokens {
+		names[token.Name] = true
+	}
+	for _, user := range users {
+		names[user.Username] = true
+	}
+	commonNames := make(map[string]bool)
+	for _, cert := range certs {
+		if !isValidIdentifier(cert.Name) {
+			return fmt.Errorf("api_client_certs: invalid name %q (use letters, digits and underscores)", cert.Name)
+		}
+		if names[cert.Name] {
+			return fmt.Errorf("api_client_certs: duplicate name %q (token, user and certificate names share one namespace)", cert.Name)
+		}
+		names[cert.Name] = true
+
+		if cert.CommonName == "" {
+			return fmt.Errorf("api_client_certs[%s]: common_name is required", cert.Name)
+		}
+		if commonNames[cert.CommonName] {
+			return fmt.Errorf("api_client_certs[%s]: common_name %q is already used by another entry", cert.Name, cert.CommonName)
+		}
+		commonNames[cert.CommonName] = true
+
+		for _, cidr := range cert.Networks {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return fmt.Errorf("api_client_certs[%s]: invalid network %q", cert.Name, cidr)
+			}
+		}
+	}
+	return nil
+}
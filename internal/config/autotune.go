@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// AutoTuneConfig lets the daemon adjust icmp_workers, snmp_workers and the ping/SNMP rate limits at runtime
+// from observed probe timeouts and host CPU and memory pressure, within the configured bounds
+type AutoTuneConfig struct {
+	Enabled          bool          `yaml:"enabled"`
+	Interval         time.Duration `yaml:"interval"`           // Time between adjustments (default: 30s)
+	MinIcmpWorkers   int           `yaml:"min_icmp_workers"`   // Lower bound for icmp_workers (default: icmp_workers/8, at least 1)
+	MaxIcmpWorkers   int           `yaml:"max_icmp_workers"`   // Upper bound for icmp_workers (default: 4x icmp_workers, at most 2000)
+	MinSnmpWorkers   int           `yaml:"min_snmp_workers"`   // Lower bound for snmp_workers (default: snmp_workers/8, at least 1)
+	MaxSnmpWorkers   int           `yaml:"max_snmp_workers"`   // Upper bound for snmp_workers (default: 4x snmp_workers, at most 1000)
+	MinRateFactor    float64       `yaml:"min_rate_factor"`    // Lowest multiple of ping_rate_limit/snmp_rate_limit (default: 0.25)
+	MaxRateFactor    float64       `yaml:"max_rate_factor"`    // Highest multiple of ping_rate_limit/snmp_rate_limit (default: 2)
+	MaxLossRate      float64       `yaml:"max_loss_rate"`      // Share of probes to responsive devices that may time out before backing off (default: 0.05)
+	MaxCPUPercent    float64       `yaml:"max_cpu_percent"`    // Process CPU use, as a percentage of all cores, before backing off (default: 80)
+	MaxMemoryPercent float64       `yaml:"max_memory_percent"` // Resident memory, as a percentage of memory_limit_mb, before backing off (default: 90)
+}
+
+// applyAutoTuneDefaults fills in the interval, thresholds and the bounds around the configured worker counts
+func applyAutoTuneDefaults(cfg *AutoTuneConfig, icmpWorkers, snmpWorkers int) {
+	if cfg.Interval == 0 {
+		cfg.Interval = 30 * time.Second
+	}
+	if cfg.MinIcmpWorkers == 0 {
+		cfg.MinIcmpWorkers = max(icmpWorkers/8, 1)
+	}
+	if cfg.MaxIcmpWorkers == 0 {
+		cfg.MaxIcmpWorkers = min(icmpWorkers*4, 2000)
+	}
+	if cfg.MinSnmpWorkers == 0 {
+		cfg.MinSnmpWorkers = max(snmpWorkers/8, 1)
+	}
+	if cfg.MaxSnmpWorkers == 0 {
+		cfg.MaxSnmpWorkers = min(snmpWorkers*4, 1000)
+	}
+	if cfg.MinRateFactor == 0 {
+		cfg.MinRateFactor = 0.25
+	}
+	if cfg.MaxRateFactor == 0 {
+		cfg.MaxRateFactor = 2
+	}
+	if cfg.MaxLossRate == 0 {
+		cfg.MaxLossRate = 0.05
+	}
+	if cfg.MaxCPUPercent == 0 {
+		cfg.MaxCPUPercent = 80
+	}
+	if cfg.MaxMemoryPercent == 0 {
+		cfg.MaxMemoryPercent = 90
+	}
+}
+
+// validateAutoTune checks the bounds of an enabled auto_tune block; the configured worker counts must lie within them
+func validateAutoTune(cfg *AutoTuneConfig, icmpWorkers, snmpWorkers int) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.Interval < 5*time.Second {
+		return fmt.Errorf("auto_tune.interval must be at least 5s, got %v", cfg.Interval)
+	}
+	if cfg.MinIcmpWorkers < 1 || cfg.MinIcmpWorkers > icmpWorkers || cfg.MaxIcmpWorkers < icmpWorkers || cfg.MaxIcmpWorkers > 2000 {
+		return fmt.Errorf("auto_tune: min_icmp_workers (%d) and max_icmp_workers (%d) must satisfy 1 <= min <= icmp_workers (%d) <= max <= 2000",
+			cfg.MinIcmpWorkers, cfg.MaxIcmpWorkers, icmpWorkers)
+	}
+	if cfg.MinSnmpWorkers < 1 || cfg.MinSnmpWorkers > snmpWorkers || cfg.MaxSnmpWorkers < snmpWorkers || cfg.MaxSnmpWorkers > 1000 {
+		return fmt.Errorf("auto_tune: min_snmp_workers (%d) and max_snmp_workers (%d) must satisfy 1 <= min <= snmp_workers (%d) <= max <= 1000",
+			cfg.MinSnmpWorkers, cfg.MaxSnmpWorkers, snmpWorkers)
+	}
+	if cfg.MinRateFactor <= 0 || cfg.MinRateFactor > 1 || cfg.MaxRateFactor < 1 || cfg.MaxRateFactor > 10 {
+		return fmt.Errorf("auto_tune: min_rate_factor (%g) must be in (0, 1] and max_rate_factor (%g) in [1, 10]", cfg.MinRateFactor, cfg.MaxRateFactor)
+	}
+	if cfg.MaxLossRate <= 0 || cfg.MaxLossRate >= 1 {
+		return fmt.Errorf("auto_tune.max_loss_rate must be between 0 and 1 (exclusive), got %g", cfg.MaxLossRate)
+	}
+	if cfg.MaxCPUPercent <= 0 || cfg.MaxCPUPercent > 100 {
+		return fmt.Errorf("auto_tune.max_cpu_percent must be between 0 and 100, got %g", cfg.MaxCPUPercent)
+	}
+	if cfg.MaxMemoryPercent <= 0 || cfg.MaxMemoryPercent > 100 {
+		return fmt.Errorf("auto_tune.max_memory_percent must be between 0 and 100, got %g", cfg.MaxMemoryPercent)
+	}
+	return nil
+}
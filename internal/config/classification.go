@@ -0,0 +1,212 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+)
+
+// DefaultClassificationPortTimeout bounds each TCP connect of the classification port probe
+const DefaultClassificationPortTimeout = time.Second
+
+// Device types assigned by the built-in classification rules
+const (
+	DeviceTypeRouter      = "router"
+	DeviceTypeSwitch      = "switch"
+	DeviceTypeFirewall    = "firewall"
+	DeviceTypeAccessPoint = "access_point"
+	DeviceTypePrinter     = "printer"
+	DeviceTypeCamera      = "camera"
+	DeviceTypeUPS         = "ups"
+	DeviceTypeStorage     = "storage"
+	DeviceTypeServer      = "server"
+)
+
+// DeviceTypeRule assigns Type to devices matching every criterion it sets
+// At least one of SysDescr, SysObjectID and Ports is required
+type DeviceTypeRule struct {
+	Type        string `yaml:"type"`          // device_type tag value, e.g. "printer"
+	SysDescr    string `yaml:"sys_descr"`     // RE2 regular expression matched against sysDescr
+	SysObjectID string `yaml:"sys_object_id"` // RE2 regular expression matched against sysObjectID (dotted, no leading dot)
+	Ports       []int  `yaml:"ports"`         // TCP ports that must all accept connections (needs probe_ports)
+
+	sysDescrRE    *regexp.Regexp // Compiled SysDescr (set by compileDeviceTypeRules)
+	sysObjectIDRE *regexp.Regexp // Compiled SysObjectID (set by compileDeviceTypeRules)
+}
+
+// DeviceClassificationConfig configures the device_type classifier
+// User rules are evaluated in order before the built-in rules; the first matching rule wins
+type DeviceClassificationConfig struct {
+	Rules               []DeviceTypeRule `yaml:"rules"`                 // User rules, evaluated first
+	DisableBuiltinRules bool             `yaml:"disable_builtin_rules"` // Only use the rules above
+	ProbePorts          bool             `yaml:"probe_ports"`           // TCP connect to the ports used by rules once per new device
+	PortTimeout         time.Duration    `yaml:"port_timeout"`          // Timeout of each port probe (default: DefaultClassificationPortTimeout)
+}
+
+// builtinDeviceTypeRules cover common vendors; specific types come before the generic server rule
+var builtinDeviceTypeRules = []DeviceTypeRule{
+	{Type: DeviceTypeFirewall, SysDescr: `(?i)fortigate|fortios|pan-os|palo alto|sonicwall|adaptive security appliance|pfsense|opnsense`},
+	{Type: DeviceTypeFirewall, SysObjectID: `^1\.3\.6\.1\.4\.1\.(12356|25461|8741)\.`},
+	{Type: DeviceTypeUPS, SysDescr: `(?i)\bups\b|network management card|powerware|smart-ups`},
+	{Type: DeviceTypeUPS, SysObjectID: `^1\.3\.6\.1\.4\.1\.(318|534|705)\.`},
+	{Type: DeviceTypePrinter, SysDescr: `(?i)printer|laserjet|officejet|jetdirect|imagerunner|bizhub|workcentre|kyocera|lexmark`},
+	{Type: DeviceTypePrinter, SysObjectID: `^1\.3\.6\.1\.4\.1\.(11\.2\.3\.9|1602|1347|641)\.`},
+	{Type: DeviceTypePrinter, Ports: []int{9100}},
+	{Type: DeviceTypeCamera, SysDescr: `(?i)camera|hikvision|dahua|axis .*video|network video`},
+	{Type: DeviceTypeCamera, SysObjectID: `^1\.3\.6\.1\.4\.1\.(368|39165)\.`},
+	{Type: DeviceTypeCamera, Ports: []int{554}},
+	{Type: DeviceTypeStorage, SysDescr: `(?i)synology|diskstation|qnap|netapp|truenas|freenas`},
+	{Type: DeviceTypeStorage, SysObjectID: `^1\.3\.6\.1\.4\.1\.(6574|24681|789)\.`},
+	{Type: DeviceTypeAccessPoint, SysDescr: `(?i)access point|unifi ap|airos|wireless lan controller`},
+	{Type: DeviceTypeSwitch, SysDescr: `(?i)switch|catalyst|nexus|procurve|powerconnect`},
+	{Type: DeviceTypeRouter, SysDescr: `(?i)router|routeros|junos|ios xr|vyos|edgeos`},
+	{Type: DeviceTypeRouter, SysObjectID: `^1\.3\.6\.1\.4\.1\.(14988|2636)\.`},
+	{Type: DeviceTypeServer, SysDescr: `(?i)linux|windows|freebsd|vmware esxi|sunos`},
+	{Type: DeviceTypeServer, SysObjectID: `^1\.3\.6\.1\.4\.1\.(8072|311)\.`},
+	{Type: DeviceTypeServer, Ports: []int{3389}},
+}
+
+func init() {
+	if err := compileDeviceTypeRules(builtinDeviceTypeRules); err != nil {
+		panic(err)
+	}
+}
+
+// Matches reports whether the device satisfies every criterion of the rule
+func (r *DeviceTypeRule) Matches(sysDescr, sysObjectID string, openPorts []int) bool {
+	if r.SysDescr != "" && !matchRuleRegex(r.sysDescrRE, r.SysDescr, sysDescr) {
+		return false
+	}
+	if r.SysObjectID != "" && !matchRuleRegex(r.sysObjectIDRE, r.SysObjectID, sysObjectID) {
+		return false
+	}
+	for _, port := range r.Ports {
+		if !slices.Contains(openPorts, port) {
+			return false
+		}
+	}
+	return r.SysDescr != "" || r.SysObjectID != "" || len(r.Ports) > 0
+}
+
+// matchRuleRegex matches input against a compiled expression, compiling pattern when the rule was built in code
+func matchRuleRegex(re *regexp.Regexp, pattern, input string) bool {
+	if input == "" {
+		return false
+	}
+	if re == nil {
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			return false
+		}
+	}
+	return re.MatchString(input)
+}
+
+// Classify returns the type of the first matching rule, "" when no rule matches
+func (c *DeviceClassificationConfig) Classify(sysDescr, sysObjectID string, openPorts []int) string {
+	for i := range c.Rules {
+		if c.Rules[i].Matches(sysDescr, sysObjectID, openPorts) {
+			return c.Rules[i].Type
+		}
+	}
+	if c.DisableBuiltinRules {
+		return ""
+	}
+	for i := range builtinDeviceTypeRules {
+		if builtinDeviceTypeRules[i].Matches(sysDescr, sysObjectID, openPorts) {
+			return builtinDeviceTypeRules[i].Type
+		}
+	}
+	return ""
+}
+
+// FingerprintPorts returns the sorted TCP ports used by the active rules (what probe_ports connects to)
+func (c *DeviceClassificationConfig) FingerprintPorts() []int {
+	var ports []int
+	add := func(rules []DeviceTypeRule) {
+		for _, rule := range rules {
+			for _, port := range rule.Ports {
+				if !slices.Contains(ports, port) {
+					ports = append(ports, port)
+				}
+			}
+		}
+	}
+	add(c.Rules)
+	if !c.DisableBuiltinRules {
+		add(builtinDeviceTypeRules)
+	}
+	slices.Sort(ports)
+	return ports
+}
+
+// ProbeTimeout returns the timeout of each port probe, DefaultClassificationPortTimeout if unset
+func (c *DeviceClassificationConfig) ProbeTimeout() time.Duration {
+	if c.PortTimeout <= 0 {
+		return DefaultClassificationPortTimeout
+	}
+	return c.PortTimeout
+}
+
+// compileDeviceTypeRules compiles every rule's expressions
+func compileDeviceTypeRules(rules []DeviceTypeRule) error {
+	for i := range rules {
+		rule := &rules[i]
+		if rule.SysDescr != "" {
+			re, err := regexp.Compile(rule.SysDescr)
+			if err != nil {
+				return fmt.Errorf("invalid device_classification.rules[%s].sys_descr: %v", rule.Type, err)
+			}
+			rule.sysDescrRE = re
+		}
+		if rule.SysObjectID != "" {
+			re, err := regexp.Compile(rule.SysObjectID)
+			if err != nil {
+				return fmt.Errorf("invalid device_classification.rules[%s].sys_object_id: %v", rule.Type, err)
+			}
+			rule.sysObjectIDRE = re
+		}
+	}
+	return nil
+}
+
+// validateDeviceClassification checks rule types, criteria and ports
+func validateDeviceClassification(c DeviceClassificationConfig) error {
+	for i, rule := range c.Rules {
+		if !isValidIdentifier(rule.Type) {
+			return fmt.Errorf("device_classification.rules[%d]: invalid type %q (use letters, digits and underscores)", i, rule.Type)
+		}
+		if rule.SysDescr == "" && rule.SysObjectID == "" && len(rule.Ports) == 0 {
+			return fmt.Errorf("device_classification.rules[%d] (%s): set at least one of sys_descr, sys_object_id and ports", i, rule.Type)
+		}
+		if _, err := regexp.Compile(rule.SysDescr); err != nil {
+			return fmt.Errorf("device_classification.rules[%d] (%s): invalid sys_descr: %v", i, rule.Type, err)
+		}
+		if _, err := regexp.Compile(rule.SysObjectID); err != nil {
+			return fmt.Errorf("device_classification.rules[%d] (%s): invalid sys_object_id: %v", i, rule.Type, err)
+		}
+		for _, port := range rule.Ports {
+			if port < 1 || port > 65535 {
+				return fmt.Errorf("device_classification.rules[%d] (%s): port must be between 1 and 65535, got %d", i, rule.Type, port)
+			}
+		}
+	}
+	if c.PortTimeout < 0 || c.PortTimeout > 30*time.Second {
+		return fmt.Errorf("device_classification.port_timeout must be between 0 and 30s, got %s", c.PortTimeout)
+	}
+	return nil
+}
+
+// deviceClassificationWarning flags port rules that can never match because ports are not probed
+func deviceClassificationWarning(c DeviceClassificationConfig) string {
+	if c.ProbePorts {
+		return ""
+	}
+	for _, rule := range c.Rules {
+		if len(rule.Ports) > 0 {
+			return fmt.Sprintf("WARNING: device_classification rule %q uses ports but probe_ports is false, so it never matches", rule.Type)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// Composite check modes
+const (
+	CheckModeAll = "all" // Healthy when every condition holds (all-of)
+	CheckModeAny = "any" // Healthy when at least one condition holds (any-of)
+)
+
+// Composite check condition types
+const (
+	ConditionICMP      = "icmp"       // Device is reported up by continuous pinging
+	ConditionSNMP      = "snmp"       // Device has answered SNMP and polling is not suspended
+	ConditionSNMPValue = "snmp_value" // A value in the latest SNMP result equals the expected value
+)
+
+// CompositeCheckConfig combines several probes of a device into one named health check
+// A check with neither Networks nor Devices applies to every monitored device
+type CompositeCheckConfig struct {
+	Name       string           `yaml:"name"`     // Written as the check tag
+	Mode       string           `yaml:"mode"`     // all or any (default: all)
+	Networks   []string         `yaml:"networks"` // CIDR ranges the check applies to
+	Devices    []string         `yaml:"devices"`  // Individual device IPs the check applies to
+	Conditions []CheckCondition `yaml:"conditions"`
+}
+
+// CheckCondition is one probe result a composite check depends on
+type CheckCondition struct {
+	Type   string `yaml:"type"`   // icmp, snmp or snmp_value
+	Group  string `yaml:"group"`  // snmp_value: result group (e.g. ifTable or an OID group name); empty matches any group
+	Name   string `yaml:"name"`   // snmp_value: value name as served by /api/device/{ip}/snmp (e.g. ifOperStatus.3)
+	Equals string `yaml:"equals"` // snmp_value: expected value, compared as text
+}
+
+// Matches reports whether the check applies to the given device IP
+func (c *CompositeCheckConfig) Matches(ip string) bool {
+	return matchesTargets(ip, c.Networks, c.Devices)
+}
+
+// Label returns a short description of the condition for failure reports (e.g. snmp_value:ifOperStatus.3)
+func (c CheckCondition) Label() string {
+	if c.Type == ConditionSNMPValue {
+		return c.Type + ":" + c.Name
+	}
+	return c.Type
+}
+
+// matchesTargets reports whether ip is in one of networks or listed in devices; both empty matches every IP
+func matchesTargets(ip string, networks, devices []string) bool {
+	if len(networks) == 0 && len(devices) == 0 {
+		return true
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	for _, device := range devices {
+		if deviceIP := net.ParseIP(device); deviceIP != nil && deviceIP.Equal(parsed) {
+			return true
+		}
+	}
+	for _, cidr := range networks {
+		if _, network, err := net.ParseCIDR(cidr); err == nil && network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyCompositeCheckDefaults fills in the default mode
+func applyCompositeCheckDefaults(checks []CompositeCheckConfig) {
+	for i := range checks {
+		if checks[i].Mode == "" {
+			checks[i].Mode = CheckModeAll
+		}
+	}
+}
+
+// validateCompositeChecks checks names, modes, targets and conditions of composite checks
+func validateCompositeChecks(checks []CompositeCheckConfig, interval time.Duration) error {
+	if len(checks) > 0 && interval != 0 && interval < 5*time.Second {
+		return fmt.Errorf("composite_check_interval must be at least 5 seconds, got %v", interval)
+	}
+
+	names := make(map[string]bool)
+	for _, check := range checks {
+		if !isValidIdentifier(check.Name) {
+			return fmt.Errorf("composite_checks: invalid name %q (use letters, digits and underscores)", check.Name)
+		}
+		if names[check.Name] {
+			return fmt.Errorf("composite_checks: duplicate name %q", check.Name)
+		}
+		names[check.Name] = true
+
+		switch check.Mode {
+		case "", CheckModeAll, CheckModeAny:
+		default:
+			return fmt.Errorf("composite_checks[%s]: mode must be all or any, got %q", check.Name, check.Mode)
+		}
+		for _, cidr := range check.Networks {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return fmt.Errorf("composite_checks[%s]: invalid network %q", check.Name, cidr)
+			}
+		}
+		for _, device := range check.Devices {
+			if net.ParseIP(device) == nil {
+				return fmt.Errorf("composite_checks[%s]: invalid device IP %q", check.Name, device)
+			}
+		}
+
+		if len(check.Conditions) == 0 {
+			return fmt.Errorf("composite_checks[%s]: at least one condition is required", check.Name)
+		}
+		for _, cond := range check.Conditions {
+			switch cond.Type {
+			case ConditionICMP, ConditionSNMP:
+			case ConditionSNMPValue:
+				if cond.Name == "" {
+					return fmt.Errorf("composite_checks[%s]: snmp_value condition requires a name", check.Name)
+				}
+			default:
+				return fmt.Errorf("composite_checks[%s]: unsupported condition type %q (icmp, snmp, snmp_value)", check.Name, cond.Type)
+			}
+		}
+	}
+	return nil
+}
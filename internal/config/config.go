@@ -5,64 +5,192 @@ import (
 	"net"
 	"net/url"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/kljama/netscan/internal/logger"
+	"github.com/kljama/netscan/internal/validate"
 	"gopkg.in/yaml.v3"
 )
 
 // SNMPConfig holds SNMPv2c connection parameters
 type SNMPConfig struct {
-	Community string        `yaml:"community"`
-	Port      int           `yaml:"port"`
-	Timeout   time.Duration `yaml:"timeout"`
-	Retries   int           `yaml:"retries"`
+	Community          string            `yaml:"community"`
+	Port               int               `yaml:"port"`
+	Timeout            time.Duration     `yaml:"timeout"`
+	Retries            int               `yaml:"retries"`
+	PollInterfaces     bool              `yaml:"poll_interfaces"`      // Walk IF-MIB ifTable on each SNMP poll
+	PollRedundancy     bool              `yaml:"poll_redundancy"`      // Walk VRRP/HSRP tables to link virtual IPs to their members
+	OIDGroups          []OIDGroupConfig  `yaml:"oid_groups"`           // Custom OID sets polled per device or CIDR
+	DeviceFields       []DeviceFieldRule `yaml:"device_fields"`        // Regex rules deriving extra device_info fields
+	MaxSessions        int               `yaml:"max_sessions"`         // Connected sessions kept between polls (0 = connect per poll)
+	SessionIdleTimeout time.Duration     `yaml:"session_idle_timeout"` // Close cached sessions unused for this long (default: 2x snmp_interval)
+	MaxStringLength    int               `yaml:"max_string_length"`    // Truncate sysName, sysDescr and other SNMP strings to this many bytes (0 = 1024)
+	Transport          string            `yaml:"transport"`            // udp or tcp (default: udp), for agents only reachable over TCP
 }
 
-// InfluxDBConfig holds InfluxDB v2 connection parameters
+// SNMP transports
+const (
+	SNMPTransportUDP = "udp"
+	SNMPTransportTCP = "tcp"
+)
+
+// InfluxDBConfig holds InfluxDB v2 connection parameters, or v1 ones with version: 1
 type InfluxDBConfig struct {
-	URL           string        `yaml:"url"`
-	Token         string        `yaml:"token"`
-	Org           string        `yaml:"org"`
-	Bucket        string        `yaml:"bucket"`
-	HealthBucket  string        `yaml:"health_bucket"`   // Bucket for health metrics
-	BatchSize     int           `yaml:"batch_size"`      // Number of points to batch before writing
-	FlushInterval time.Duration `yaml:"flush_interval"`  // Maximum time to hold points before flushing
+	Version         int           `yaml:"version"`          // 2 (token/org/bucket) or 1 (username/password/database)
+	URL             string        `yaml:"url"`
+	Token           string        `yaml:"token"`
+	Org             string        `yaml:"org"`
+	Bucket          string        `yaml:"bucket"`
+	HealthBucket    string        `yaml:"health_bucket"`    // Bucket for health metrics (database with version 1)
+	Username        string        `yaml:"username"`         // Version 1: basic auth user
+	Password        string        `yaml:"password"`         // Version 1: basic auth password
+	Database        string        `yaml:"database"`         // Version 1: database of device points (replaces bucket)
+	RetentionPolicy string        `yaml:"retention_policy"` // Version 1: retention policy of device points ("" = database default)
+	BatchSize       int           `yaml:"batch_size"`       // Number of points to batch before writing
+	FlushInterval   time.Duration `yaml:"flush_interval"`   // Maximum time to hold points before flushing
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"` // Maximum time to flush pending points on shutdown
+	HealthCheckInterval time.Duration `yaml:"health_check_interval"` // Background health check interval for the health endpoints
+	MaxStringLength int           `yaml:"max_string_length"` // Truncate string fields and tag values to this many bytes (0 = 500)
+	Mirrors         []InfluxDBMirror `yaml:"mirrors"`         // Additional InfluxDB destinations receiving a copy of every point
 }
 
 // Config holds all application configuration parameters
 type Config struct {
 	DiscoveryInterval     time.Duration  `yaml:"discovery_interval"`
 	IcmpDiscoveryInterval time.Duration  `yaml:"icmp_discovery_interval"`
+	IcmpDiscoveryMaxInterval time.Duration `yaml:"icmp_discovery_max_interval"`  // Adaptive discovery: upper bound on stable networks (0 = fixed interval)
+	IcmpDiscoveryStableSweeps int        `yaml:"icmp_discovery_stable_sweeps"` // Adaptive discovery: quiet sweeps before stretching the interval
 	IcmpWorkers           int            `yaml:"icmp_workers"`
+	DiscoveryNetworkWorkers int          `yaml:"discovery_network_workers"` // Cap on the icmp_workers share of each network's sweep pipeline (0 = no cap)
 	SnmpWorkers           int            `yaml:"snmp_workers"`
 	Networks              []string       `yaml:"networks"`
+	NetworkHosts          []NetworkHost  `yaml:"-"`                      // networks entries given as host names, resolved into /32 entries of Networks
+	HostResolveInterval   time.Duration  `yaml:"host_resolve_interval"`  // How often NetworkHosts are resolved again (0 = only at load)
+	ExcludeNetworks       []string       `yaml:"exclude_networks"`        // CIDRs inside networks that are never probed
+	NetworkLabels         map[string]string `yaml:"network_labels"`       // Friendly names for networks (including inline labels in networks), used as the "network" tag
+	Sites                 []SiteConfig   `yaml:"sites"`                   // Per-site networks, SNMP credentials and InfluxDB bucket/tags (networks are merged into Networks)
+	ExcludeIPs            []string       `yaml:"exclude_ips"`             // Individual hosts that are never probed
+	DiscoveryMode         string         `yaml:"discovery_mode"`          // icmp, tcp or both
+	DiscoveryMethods      []string       `yaml:"discovery_methods"`       // Discovery plugins run by every sweep, in order (default: sweep)
+	SSDPTimeout           time.Duration  `yaml:"ssdp_timeout"`            // How long the ssdp discovery method collects M-SEARCH responses
+	TCPDiscoveryPorts     []int          `yaml:"tcp_discovery_ports"`     // Ports probed by TCP connect discovery
+	TCPDiscoveryTimeout   time.Duration  `yaml:"tcp_discovery_timeout"`   // Per-connection timeout for TCP discovery
+	ARPDiscovery          bool           `yaml:"arp_discovery"`           // Also ARP-sweep networks on directly attached segments
+	ARPListenInterfaces   []string       `yaml:"arp_listen_interfaces"`   // Interfaces passively watched for ARP traffic from new hosts (empty = disabled)
+	DiscoverySweepBudget  int            `yaml:"discovery_sweep_budget"`  // Streaming mode: max addresses probed per sweep (0 = full sweeps)
+	DiscoveryCursorFile   string         `yaml:"discovery_cursor_file"`   // Streaming mode: file persisting the sweep cursor across restarts
+	AllowLoopback         bool           `yaml:"allow_loopback"`          // Permit loopback targets (self-monitoring, labs)
+	AllowLinkLocal        bool           `yaml:"allow_link_local"`        // Permit link-local targets (169.254.0.0/16, fe80::/10)
 	SNMP                  SNMPConfig     `yaml:"snmp"`
 	PingInterval          time.Duration  `yaml:"ping_interval"`
 	PingTimeout           time.Duration  `yaml:"ping_timeout"`
+	PingsPerCycle         int            `yaml:"pings_per_cycle"`        // Echo requests per ping cycle (loss/jitter need > 1)
+	ProbeProfiles         ProbeProfiles  `yaml:"probe_profiles"`         // Packet count, spacing and size of discovery and monitoring pings
+	PingWorkers           int            `yaml:"ping_workers"`           // Worker goroutines shared by all continuous pingers
+	PingStartSpread       time.Duration  `yaml:"ping_start_spread"`      // Window over which first pings of new devices are spread (0 = no spread)
+	PingJitter            time.Duration  `yaml:"ping_jitter"`            // Random ± offset applied to every ping cycle (0 = exact interval)
+	PingEngine            string         `yaml:"ping_engine"`            // batch (one shared ICMP socket) or probing (socket per cycle)
+	ICMPMode              string         `yaml:"icmp_mode"`              // auto, privileged (raw sockets) or unprivileged (UDP sockets)
+	SourceInterface       string         `yaml:"source_interface"`       // Interface whose IPv4 address ICMP and SNMP probes are sent from
+	SourceIP              string         `yaml:"source_ip"`              // Local IPv4 address ICMP and SNMP probes are sent from
 	PingRateLimit         float64        `yaml:"ping_rate_limit"`        // Tokens per second (sustained ping rate)
 	PingBurstLimit        int            `yaml:"ping_burst_limit"`       // Token bucket capacity (max burst)
+	NetworkRateLimits     []NetworkRateLimit `yaml:"network_rate_limits"` // Per-network ping rate partitions under the global limit
 	PingMaxConsecutiveFails int          `yaml:"ping_max_consecutive_fails"` // Circuit breaker: max consecutive failures before suspension
 	PingBackoffDuration   time.Duration  `yaml:"ping_backoff_duration"`  // Circuit breaker: suspension duration after max failures
+	PingHalfOpenAfter     time.Duration  `yaml:"ping_half_open_after"`   // Circuit breaker: suspension time before a single test ping (0 = none)
+	PingBackoffMax        time.Duration  `yaml:"ping_backoff_max"`       // Circuit breaker: cap on suspensions doubled by unanswered test pings
+	PingBackoffSteps      []time.Duration `yaml:"ping_backoff_steps"`    // Circuit breaker: escalating suspensions of repeat offenders (empty = ping_backoff_duration)
+	PingBackoffReset      time.Duration  `yaml:"ping_backoff_reset"`     // Circuit breaker: time without a trip after which escalation starts over
+	BackoffFile           string         `yaml:"backoff_file"`           // Persist backoff escalation across restarts ("" = in memory)
+	PingFailureMode       string         `yaml:"ping_failure_mode"`      // Circuit breaker: consecutive (ping_max_consecutive_fails) or windowed failures
+	PingFailureWindow     int            `yaml:"ping_failure_window"`    // Windowed mode: ping cycles considered
+	PingFailureRatio      float64        `yaml:"ping_failure_ratio"`     // Windowed mode: failed share of the window that trips the circuit breaker
+	PingFailureCoalesceAfter time.Duration `yaml:"ping_failure_coalesce_after"` // Continuous downtime before failure points are thinned (0 = disabled)
+	PingFailureCoalesceEvery int           `yaml:"ping_failure_coalesce_every"` // Write one in every N failure points once coalescing
+	DeviceDownAfter       int            `yaml:"device_down_after"`      // Consecutive ping failures before a device_state "down" event
 	SNMPInterval          time.Duration  `yaml:"snmp_interval"`          // Interval for continuous SNMP polling per device
+	SNMPJitter            time.Duration  `yaml:"snmp_jitter"`            // Random ± offset applied to every SNMP poll cycle (0 = exact interval)
+	DeviceInfoRefresh     time.Duration  `yaml:"device_info_refresh"`    // Rewrite unchanged device_info after this long (0 = every SNMP poll)
 	SNMPRateLimit         float64        `yaml:"snmp_rate_limit"`        // Tokens per second (sustained SNMP query rate)
 	SNMPBurstLimit        int            `yaml:"snmp_burst_limit"`       // Token bucket capacity (max SNMP burst)
 	SNMPMaxConsecutiveFails int          `yaml:"snmp_max_consecutive_fails"` // Circuit breaker: max consecutive SNMP failures before suspension
 	SNMPBackoffDuration   time.Duration  `yaml:"snmp_backoff_duration"`  // Circuit breaker: SNMP suspension duration after max failures
 	InfluxDB              InfluxDBConfig `yaml:"influxdb"`
-	SNMPDailySchedule     string         `yaml:"snmp_daily_schedule"`  // DEPRECATED: Daily SNMP scan time (HH:MM format) - use snmp_interval instead
+	SNMPDailySchedule     string         `yaml:"snmp_daily_schedule"`  // Daily full SNMP re-scan time (HH:MM) in the schedule timezone ("" = disabled)
+	SNMPDailyDays         []string       `yaml:"snmp_daily_days"`      // Weekdays the re-scan runs on ("mon".."sun", empty = every day)
+	Timezone              string         `yaml:"timezone"`             // IANA timezone for all wall-clock schedules (default: server local time)
+	Location              *time.Location `yaml:"-"`                    // Resolved Timezone (see ScheduleLocation)
 	HealthCheckPort       int            `yaml:"health_check_port"`    // HTTP health check endpoint port
 	HealthReportInterval  time.Duration  `yaml:"health_report_interval"` // Interval for writing health metrics
+	HealthReadTimeout     time.Duration  `yaml:"health_read_timeout"`    // Max time to read a request, body included
+	HealthWriteTimeout    time.Duration  `yaml:"health_write_timeout"`   // Max time to write a response (event streams are exempt)
+	HealthIdleTimeout     time.Duration  `yaml:"health_idle_timeout"`    // Idle keep-alive connections are closed after this
+	HealthTLSCert         string         `yaml:"health_tls_cert"`        // PEM certificate (chain) served over HTTPS ("" = plain HTTP)
+	HealthTLSKey          string         `yaml:"health_tls_key"`         // PEM private key of health_tls_cert
+	HealthTLSClientCA     string         `yaml:"health_tls_client_ca"`   // PEM CA bundle verifying client certificates ("" = not requested)
+	HealthTLSClientAuth   string         `yaml:"health_tls_client_auth"` // optional or require (handshake fails without a certificate)
+	ShutdownTimeout       time.Duration  `yaml:"shutdown_timeout"`       // Maximum wait for ping workers and SNMP pollers to exit on shutdown
+	FlagsAPI              bool           `yaml:"flags_api"`              // Serve /api/flags (runtime diagnostics toggles) on the health port
+	StrictValidation      bool           `yaml:"strict_validation"`      // Treat configuration warnings as fatal errors
+	LogLevels             map[string]string `yaml:"log_levels"`          // Per-module log level overrides, e.g. discovery: debug
+	HistoryFile           string         `yaml:"history_file"`           // Persist the 24h key metrics history across restarts ("" = in memory)
+	APIRateLimit          float64        `yaml:"api_rate_limit"`         // Requests per second per API client (token or source IP)
+	Notifications         NotifyConfig   `yaml:"notifications"`          // Webhooks notified on device down/up/suspended
+	Publish               PublishConfig  `yaml:"publish"`                // Results and device events published to NATS or Kafka
+	CompositeChecks       []CompositeCheckConfig `yaml:"composite_checks"` // Named health checks combining several probes of a device
+	LatencyThresholds     []LatencyThresholdConfig `yaml:"latency_thresholds"` // RTT warning/critical limits raising latency alerts
+	Traceroute            TracerouteConfig `yaml:"traceroute"`           // Path capture for devices that go down or exceed a latency threshold
+	AutoTune              AutoTuneConfig   `yaml:"auto_tune"`            // Runtime adjustment of worker counts and rate limits
+	MaintenanceWindows    []MaintenanceWindowConfig `yaml:"maintenance_windows"` // Planned outages that don't trip circuit breakers or report devices down
+	CompositeCheckInterval time.Duration `yaml:"composite_check_interval"` // How often composite checks are evaluated
+	DeviceClassification  DeviceClassificationConfig `yaml:"device_classification"` // Rules assigning the device_type tag
+	DeviceTags            []DeviceTagRule `yaml:"device_tags"`              // Rules adding custom tags (e.g. site, tier) to matching devices
+	OSFingerprinting      OSFingerprintConfig `yaml:"os_fingerprinting"` // TTL and port probes inferring the os_family device_info field
+	InventoryFile         string         `yaml:"inventory_file"`         // Expected devices CSV (CMDB export) to reconcile against ("" = disabled)
+	InventoryReportInterval time.Duration `yaml:"inventory_report_interval"` // How often the reconciliation report is regenerated
+	InventoryExport       InventoryExportConfig `yaml:"inventory_export"` // Periodic inventory snapshots written to files
+	RollupDays            int            `yaml:"rollup_days"`            // Keep per-device daily ping rollups for this many days (0 = disabled)
+	RollupFile            string         `yaml:"rollup_file"`            // Persist rollups across restarts ("" = in memory)
+	RTTHistorySamples     int            `yaml:"rtt_history_samples"`    // Recent ping cycles kept in memory per device for /api/device/{ip}/history
+	APIBurstLimit         int            `yaml:"api_burst_limit"`        // Request burst per API client
+	APITokens             []APITokenConfig `yaml:"api_tokens"`           // Bearer tokens required for /api/ (empty = API open), optionally scoped to networks
+	APIUsers              []APIUserConfig `yaml:"api_users"`             // Basic-auth users accepted on /api/ alongside api_tokens
+	APIClientCerts        []APIClientCertConfig `yaml:"api_client_certs"` // Client certificate names and scopes (empty = any certificate of health_tls_client_ca)
+	// Multi-scanner overlap detection
+	InstanceID            string         `yaml:"instance_id"`            // Scanner identity, written as the "scanner" tag on device_info
+	OverlapCheckInterval  time.Duration  `yaml:"overlap_check_interval"` // Interval for checking other scanners' results (0 = disabled)
+	OverlapAction         string         `yaml:"overlap_action"`         // warn or disable
+	OverlapMinMatches     int            `yaml:"overlap_min_matches"`    // Matching device fingerprints before a network counts as overlapping
 	// Resource protection settings
 	MaxConcurrentPingers  int           `yaml:"max_concurrent_pingers"`
 	MaxConcurrentSNMPPollers int        `yaml:"max_concurrent_snmp_pollers"` // Maximum concurrent SNMP poller goroutines
+	PingerStartRate       float64       `yaml:"pinger_start_rate"`   // New devices whose monitoring is started per second (0 = unlimited)
 	MaxDevices            int           `yaml:"max_devices"`
+	TombstoneTTL          time.Duration `yaml:"tombstone_ttl"`       // How long pruned devices can be restored with their metadata (0 = disabled)
+	QuarantineTrips       int           `yaml:"quarantine_trips"`    // Circuit breaker trips within quarantine_window that quarantine a device (0 = disabled)
+	QuarantineWindow      time.Duration `yaml:"quarantine_window"`   // Window over which circuit breaker trips are counted
+	QuarantineFile        string        `yaml:"quarantine_file"`     // Persist the quarantine list across restarts ("" = in memory)
 	MinScanInterval       time.Duration `yaml:"min_scan_interval"`
 	MemoryLimitMB         int           `yaml:"memory_limit_mb"`
 }
 
 // LoadConfig parses YAML configuration file and returns Config struct
 func LoadConfig(path string) (*Config, error) {
+	return LoadConfigWithVars(path, "")
+}
+
+// LoadConfigWithVars parses a YAML configuration template, substituting ${vars.name} references
+// Variables come from the config's vars block, then vars_file, then varsPath (later sources override earlier ones)
+func LoadConfigWithVars(path, varsPath string) (*Config, error) {
+	return loadConfig(path, varsPath, LookupHost)
+}
+
+// loadConfig is LoadConfigWithVars with the resolver of host names in networks
+func loadConfig(path, varsPath string, lookup HostLookup) (*Config, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
@@ -73,43 +201,147 @@ func LoadConfig(path string) (*Config, error) {
 	var raw struct {
 		DiscoveryInterval       string   `yaml:"discovery_interval"`
 		IcmpDiscoveryInterval   string   `yaml:"icmp_discovery_interval"`
+		IcmpDiscoveryMaxInterval string  `yaml:"icmp_discovery_max_interval"`
+		IcmpDiscoveryStableSweeps int    `yaml:"icmp_discovery_stable_sweeps"`
 		IcmpWorkers             int      `yaml:"icmp_workers"`
+		DiscoveryNetworkWorkers int      `yaml:"discovery_network_workers"`
 		SnmpWorkers             int      `yaml:"snmp_workers"`
-		Networks                []string `yaml:"networks"`
+		Networks                []networkEntry `yaml:"networks"`
+		ExcludeNetworks         []string `yaml:"exclude_networks"`
+		NetworkLabels           map[string]string `yaml:"network_labels"`
+		HostResolveInterval     string   `yaml:"host_resolve_interval"`
+		Sites                   []rawSite `yaml:"sites"`
+		ExcludeIPs              []string `yaml:"exclude_ips"`
+		DiscoveryMode           string   `yaml:"discovery_mode"`
+		DiscoveryMethods        []string `yaml:"discovery_methods"`
+		SSDPTimeout             string   `yaml:"ssdp_timeout"`
+		TCPDiscoveryPorts       []int    `yaml:"tcp_discovery_ports"`
+		TCPDiscoveryTimeout     string   `yaml:"tcp_discovery_timeout"`
+		ARPDiscovery            bool     `yaml:"arp_discovery"`
+		ARPListenInterfaces     []string `yaml:"arp_listen_interfaces"`
+		DiscoverySweepBudget    int      `yaml:"discovery_sweep_budget"`
+		DiscoveryCursorFile     string   `yaml:"discovery_cursor_file"`
+		AllowLoopback           bool     `yaml:"allow_loopback"`
+		AllowLinkLocal          bool     `yaml:"allow_link_local"`
 		SNMP                    SNMPConfig `yaml:"snmp"`
 		PingInterval            string   `yaml:"ping_interval"`
 		PingTimeout             string   `yaml:"ping_timeout"`
+		PingsPerCycle           int      `yaml:"pings_per_cycle"`
+		ProbeProfiles           ProbeProfiles `yaml:"probe_profiles"`
+		PingWorkers             int      `yaml:"ping_workers"`
+		PingStartSpread         string   `yaml:"ping_start_spread"`
+		PingJitter              string   `yaml:"ping_jitter"`
+		PingEngine              string   `yaml:"ping_engine"`
+		ICMPMode                string   `yaml:"icmp_mode"`
+		SourceInterface         string   `yaml:"source_interface"`
+		SourceIP                string   `yaml:"source_ip"`
 		PingRateLimit           float64  `yaml:"ping_rate_limit"`
 		PingBurstLimit          int      `yaml:"ping_burst_limit"`
+		NetworkRateLimits       []NetworkRateLimit `yaml:"network_rate_limits"`
 		PingMaxConsecutiveFails int      `yaml:"ping_max_consecutive_fails"`
+		PingFailureMode         string   `yaml:"ping_failure_mode"`
+		PingFailureWindow       int      `yaml:"ping_failure_window"`
+		PingFailureRatio        float64  `yaml:"ping_failure_ratio"`
 		PingBackoffDuration     string   `yaml:"ping_backoff_duration"`
+		PingHalfOpenAfter       string   `yaml:"ping_half_open_after"`
+		PingBackoffMax          string   `yaml:"ping_backoff_max"`
+		PingBackoffSteps        []string `yaml:"ping_backoff_steps"`
+		PingBackoffReset        string   `yaml:"ping_backoff_reset"`
+		BackoffFile             string   `yaml:"backoff_file"`
+		PingFailureCoalesceAfter string  `yaml:"ping_failure_coalesce_after"`
+		PingFailureCoalesceEvery int     `yaml:"ping_failure_coalesce_every"`
+		DeviceDownAfter         int      `yaml:"device_down_after"`
 		SNMPInterval            string   `yaml:"snmp_interval"`
+		SNMPJitter              string   `yaml:"snmp_jitter"`
+		DeviceInfoRefresh       string   `yaml:"device_info_refresh"`
 		SNMPRateLimit           float64  `yaml:"snmp_rate_limit"`
 		SNMPBurstLimit          int      `yaml:"snmp_burst_limit"`
 		SNMPMaxConsecutiveFails int      `yaml:"snmp_max_consecutive_fails"`
 		SNMPBackoffDuration     string   `yaml:"snmp_backoff_duration"`
 		InfluxDB                struct {
-			URL           string `yaml:"url"`
-			Token         string `yaml:"token"`
-			Org           string `yaml:"org"`
-			Bucket        string `yaml:"bucket"`
-			HealthBucket  string `yaml:"health_bucket"`
-			BatchSize     int    `yaml:"batch_size"`
-			FlushInterval string `yaml:"flush_interval"`
+			Version         int    `yaml:"version"`
+			URL             string `yaml:"url"`
+			Token           string `yaml:"token"`
+			Org             string `yaml:"org"`
+			Bucket          string `yaml:"bucket"`
+			HealthBucket    string `yaml:"health_bucket"`
+			Username        string `yaml:"username"`
+			Password        string `yaml:"password"`
+			Database        string `yaml:"database"`
+			RetentionPolicy string `yaml:"retention_policy"`
+			BatchSize       int    `yaml:"batch_size"`
+			FlushInterval   string `yaml:"flush_interval"`
+			ShutdownTimeout string `yaml:"shutdown_timeout"`
+			HealthCheckInterval string `yaml:"health_check_interval"`
+			MaxStringLength int    `yaml:"max_string_length"`
+			Mirrors         []InfluxDBMirror `yaml:"mirrors"`
 		} `yaml:"influxdb"`
 		SNMPDailySchedule     string `yaml:"snmp_daily_schedule"`
+		SNMPDailyDays         []string `yaml:"snmp_daily_days"`
+		Timezone              string `yaml:"timezone"`
 		HealthCheckPort       int    `yaml:"health_check_port"`
 		HealthReportInterval  string `yaml:"health_report_interval"`
+		HealthReadTimeout     string `yaml:"health_read_timeout"`
+		HealthWriteTimeout    string `yaml:"health_write_timeout"`
+		HealthIdleTimeout     string `yaml:"health_idle_timeout"`
+		HealthTLSCert         string `yaml:"health_tls_cert"`
+		HealthTLSKey          string `yaml:"health_tls_key"`
+		HealthTLSClientCA     string `yaml:"health_tls_client_ca"`
+		HealthTLSClientAuth   string `yaml:"health_tls_client_auth"`
+		ShutdownTimeout       string `yaml:"shutdown_timeout"`
+		FlagsAPI              bool   `yaml:"flags_api"`
+		StrictValidation      bool   `yaml:"strict_validation"`
+		LogLevels             map[string]string `yaml:"log_levels"`
+		HistoryFile           string `yaml:"history_file"`
+		APIRateLimit          float64 `yaml:"api_rate_limit"`
+		Notifications         NotifyConfig `yaml:"notifications"`
+		Publish               PublishConfig `yaml:"publish"`
+		CompositeChecks       []CompositeCheckConfig `yaml:"composite_checks"`
+		LatencyThresholds     []LatencyThresholdConfig `yaml:"latency_thresholds"`
+		Traceroute            TracerouteConfig `yaml:"traceroute"`
+		AutoTune              AutoTuneConfig   `yaml:"auto_tune"`
+		MaintenanceWindows    []MaintenanceWindowConfig `yaml:"maintenance_windows"`
+		CompositeCheckInterval string `yaml:"composite_check_interval"`
+		DeviceClassification  DeviceClassificationConfig `yaml:"device_classification"`
+		DeviceTags            []DeviceTagRule `yaml:"device_tags"`
+		OSFingerprinting      OSFingerprintConfig `yaml:"os_fingerprinting"`
+		InventoryFile         string `yaml:"inventory_file"`
+		InventoryReportInterval string `yaml:"inventory_report_interval"`
+		InventoryExport       InventoryExportConfig `yaml:"inventory_export"`
+		RollupDays            int    `yaml:"rollup_days"`
+		RollupFile            string `yaml:"rollup_file"`
+		RTTHistorySamples     int    `yaml:"rtt_history_samples"`
+		APIBurstLimit         int    `yaml:"api_burst_limit"`
+		APITokens             []APITokenConfig `yaml:"api_tokens"`
+		APIUsers              []APIUserConfig `yaml:"api_users"`
+		APIClientCerts        []APIClientCertConfig `yaml:"api_client_certs"`
+		InstanceID            string `yaml:"instance_id"`
+		OverlapCheckInterval  string `yaml:"overlap_check_interval"`
+		OverlapAction         string `yaml:"overlap_action"`
+		OverlapMinMatches     int    `yaml:"overlap_min_matches"`
 		// Resource protection settings
 		MaxConcurrentPingers     int    `yaml:"max_concurrent_pingers"`
 		MaxConcurrentSNMPPollers int    `yaml:"max_concurrent_snmp_pollers"`
+		PingerStartRate          float64 `yaml:"pinger_start_rate"`
 		MaxDevices               int    `yaml:"max_devices"`
+		TombstoneTTL             string `yaml:"tombstone_ttl"`
+		QuarantineTrips          int    `yaml:"quarantine_trips"`
+		QuarantineWindow         string `yaml:"quarantine_window"`
+		QuarantineFile           string `yaml:"quarantine_file"`
 		MinScanInterval          string `yaml:"min_scan_interval"`
 		MemoryLimitMB            int    `yaml:"memory_limit_mb"`
 	}
 
+	// Decode into a node tree first so ${vars.name} can be substituted in scalar values
+	var doc yaml.Node
 	decoder := yaml.NewDecoder(f)
-	if err := decoder.Decode(&raw); err != nil {
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if err := applyVars(&doc, filepath.Dir(path), varsPath); err != nil {
+		return nil, err
+	}
+	if err := doc.Decode(&raw); err != nil {
 		return nil, err
 	}
 
@@ -130,6 +362,14 @@ func LoadConfig(path string) (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("invalid icmp_discovery_interval: %v", err)
 	}
+	// Parse icmp_discovery_max_interval if specified (adaptive discovery is disabled when unset)
+	var icmpDiscoveryMaxInterval time.Duration
+	if raw.IcmpDiscoveryMaxInterval != "" {
+		icmpDiscoveryMaxInterval, err = time.ParseDuration(raw.IcmpDiscoveryMaxInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid icmp_discovery_max_interval: %v", err)
+		}
+	}
 	pingInterval, err := time.ParseDuration(raw.PingInterval)
 	if err != nil {
 		return nil, fmt.Errorf("invalid ping_interval: %v", err)
@@ -147,6 +387,24 @@ func LoadConfig(path string) (*Config, error) {
 		pingTimeout = 3 * time.Second
 	}
 
+	// Parse TCPDiscoveryTimeout if specified
+	var tcpDiscoveryTimeout time.Duration
+	if raw.TCPDiscoveryTimeout != "" {
+		tcpDiscoveryTimeout, err = time.ParseDuration(raw.TCPDiscoveryTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tcp_discovery_timeout: %v", err)
+		}
+	}
+
+	// Parse SSDPTimeout if specified
+	var ssdpTimeout time.Duration
+	if raw.SSDPTimeout != "" {
+		ssdpTimeout, err = time.ParseDuration(raw.SSDPTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ssdp_timeout: %v", err)
+		}
+	}
+
 	// Parse MinScanInterval if specified
 	var minScanInterval time.Duration
 	if raw.MinScanInterval != "" {
@@ -165,6 +423,24 @@ func LoadConfig(path string) (*Config, error) {
 		}
 	}
 
+	// Parse InfluxDB ShutdownTimeout if specified
+	var shutdownTimeout time.Duration
+	if raw.InfluxDB.ShutdownTimeout != "" {
+		shutdownTimeout, err = time.ParseDuration(raw.InfluxDB.ShutdownTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid influxdb.shutdown_timeout: %v", err)
+		}
+	}
+
+	// Parse InfluxDB HealthCheckInterval if specified
+	var influxHealthCheckInterval time.Duration
+	if raw.InfluxDB.HealthCheckInterval != "" {
+		influxHealthCheckInterval, err = time.ParseDuration(raw.InfluxDB.HealthCheckInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid influxdb.health_check_interval: %v", err)
+		}
+	}
+
 	// Parse HealthReportInterval if specified
 	var healthReportInterval time.Duration
 	if raw.HealthReportInterval != "" {
@@ -174,6 +450,51 @@ func LoadConfig(path string) (*Config, error) {
 		}
 	}
 
+	// Parse health server timeouts if specified
+	var healthReadTimeout, healthWriteTimeout, healthIdleTimeout time.Duration
+	if raw.HealthReadTimeout != "" {
+		healthReadTimeout, err = time.ParseDuration(raw.HealthReadTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid health_read_timeout: %v", err)
+		}
+	}
+	if raw.HealthWriteTimeout != "" {
+		healthWriteTimeout, err = time.ParseDuration(raw.HealthWriteTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid health_write_timeout: %v", err)
+		}
+	}
+	if raw.HealthIdleTimeout != "" {
+		healthIdleTimeout, err = time.ParseDuration(raw.HealthIdleTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid health_idle_timeout: %v", err)
+		}
+	}
+
+	// Parse ShutdownTimeout if specified
+	var monitorShutdownTimeout time.Duration
+	if raw.ShutdownTimeout != "" {
+		monitorShutdownTimeout, err = time.ParseDuration(raw.ShutdownTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid shutdown_timeout: %v", err)
+		}
+	}
+
+	// Resolve the schedule timezone (daily scans, maintenance windows)
+	location, err := loadTimezone(raw.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %v", raw.Timezone, err)
+	}
+
+	// Parse OverlapCheckInterval if specified
+	var overlapCheckInterval time.Duration
+	if raw.OverlapCheckInterval != "" {
+		overlapCheckInterval, err = time.ParseDuration(raw.OverlapCheckInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid overlap_check_interval: %v", err)
+		}
+	}
+
 	// Parse PingBackoffDuration if specified
 	var pingBackoffDuration time.Duration
 	if raw.PingBackoffDuration != "" {
@@ -182,6 +503,62 @@ func LoadConfig(path string) (*Config, error) {
 			return nil, fmt.Errorf("invalid ping_backoff_duration: %v", err)
 		}
 	}
+	var pingHalfOpenAfter time.Duration
+	if raw.PingHalfOpenAfter != "" {
+		pingHalfOpenAfter, err = time.ParseDuration(raw.PingHalfOpenAfter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ping_half_open_after: %v", err)
+		}
+	}
+	var pingBackoffMax time.Duration
+	if raw.PingBackoffMax != "" {
+		pingBackoffMax, err = time.ParseDuration(raw.PingBackoffMax)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ping_backoff_max: %v", err)
+		}
+	}
+	var pingBackoffSteps []time.Duration
+	for i, step := range raw.PingBackoffSteps {
+		d, err := time.ParseDuration(step)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ping_backoff_steps[%d]: %v", i, err)
+		}
+		pingBackoffSteps = append(pingBackoffSteps, d)
+	}
+	var pingBackoffReset time.Duration
+	if raw.PingBackoffReset != "" {
+		pingBackoffReset, err = time.ParseDuration(raw.PingBackoffReset)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ping_backoff_reset: %v", err)
+		}
+	}
+
+	// Parse PingStartSpread with default of ping_interval (first pings spread over one full interval)
+	pingStartSpread := pingInterval
+	if raw.PingStartSpread != "" {
+		pingStartSpread, err = time.ParseDuration(raw.PingStartSpread)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ping_start_spread: %v", err)
+		}
+	}
+
+	// Parse PingJitter if specified (unset keeps the exact interval)
+	var pingJitter time.Duration
+	if raw.PingJitter != "" {
+		pingJitter, err = time.ParseDuration(raw.PingJitter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ping_jitter: %v", err)
+		}
+	}
+
+	// Parse PingFailureCoalesceAfter if specified (unset disables coalescing)
+	var pingFailureCoalesceAfter time.Duration
+	if raw.PingFailureCoalesceAfter != "" {
+		pingFailureCoalesceAfter, err = time.ParseDuration(raw.PingFailureCoalesceAfter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ping_failure_coalesce_after: %v", err)
+		}
+	}
 
 	// Parse SNMPInterval if specified
 	var snmpInterval time.Duration
@@ -192,6 +569,24 @@ func LoadConfig(path string) (*Config, error) {
 		}
 	}
 
+	// Parse SNMPJitter if specified (unset keeps the exact interval)
+	var snmpJitter time.Duration
+	if raw.SNMPJitter != "" {
+		snmpJitter, err = time.ParseDuration(raw.SNMPJitter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid snmp_jitter: %v", err)
+		}
+	}
+
+	// Parse DeviceInfoRefresh (default: rewrite unchanged device_info once a day)
+	deviceInfoRefresh := 24 * time.Hour
+	if raw.DeviceInfoRefresh != "" {
+		deviceInfoRefresh, err = time.ParseDuration(raw.DeviceInfoRefresh)
+		if err != nil {
+			return nil, fmt.Errorf("invalid device_info_refresh: %v", err)
+		}
+	}
+
 	// Parse SNMPBackoffDuration if specified
 	var snmpBackoffDuration time.Duration
 	if raw.SNMPBackoffDuration != "" {
@@ -201,18 +596,98 @@ func LoadConfig(path string) (*Config, error) {
 		}
 	}
 
-	// Set default SNMP timeout if not specified
+	// Set default SNMP timeout and transport if not specified
 	if raw.SNMP.Timeout == 0 {
 		raw.SNMP.Timeout = 5 * time.Second
 	}
+	if raw.SNMP.Transport == "" {
+		raw.SNMP.Transport = SNMPTransportUDP
+	}
+
+	// Fill in custom OID group defaults (measurement name, type, scale)
+	applyOIDGroupDefaults(raw.SNMP.OIDGroups)
+
+	// Parse CompositeCheckInterval if specified
+	compositeCheckInterval := 30 * time.Second // Default: evaluate composite checks every 30 seconds
+	if raw.CompositeCheckInterval != "" {
+		compositeCheckInterval, err = time.ParseDuration(raw.CompositeCheckInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid composite_check_interval: %v", err)
+		}
+	}
+	applyCompositeCheckDefaults(raw.CompositeChecks)
+	applyMaintenanceWindowDefaults(raw.MaintenanceWindows)
+
+	// Parse TombstoneTTL if specified ("0s" disables tombstones)
+	tombstoneTTL := time.Hour // Default: restore devices that reappear within an hour of being pruned
+	if raw.TombstoneTTL != "" {
+		tombstoneTTL, err = time.ParseDuration(raw.TombstoneTTL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tombstone_ttl: %v", err)
+		}
+	}
+
+	// Parse QuarantineWindow if specified
+	quarantineWindow := 72 * time.Hour // Default: count circuit breaker trips over the last three days
+	if raw.QuarantineWindow != "" {
+		quarantineWindow, err = time.ParseDuration(raw.QuarantineWindow)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quarantine_window: %v", err)
+		}
+	}
+
+	// Parse InventoryReportInterval if specified
+	inventoryReportInterval := time.Hour // Default: reconcile against the expected devices file every hour
+	if raw.InventoryReportInterval != "" {
+		inventoryReportInterval, err = time.ParseDuration(raw.InventoryReportInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid inventory_report_interval: %v", err)
+		}
+	}
+
+	// Fill in webhook notification defaults (rate limit, format, events)
+	applyNotifyDefaults(&raw.Notifications)
+	applyPublishDefaults(&raw.Publish)
+	applyTracerouteDefaults(&raw.Traceroute)
+
+	// Compile device field rule expressions once at load time
+	if err := compileDeviceFieldRules(raw.SNMP.DeviceFields); err != nil {
+		return nil, err
+	}
+	if err := compileDeviceTypeRules(raw.DeviceClassification.Rules); err != nil {
+		return nil, err
+	}
+	if err := compileDeviceTagRules(raw.DeviceTags); err != nil {
+		return nil, err
+	}
 
 	// Set default values if not specified
 	if raw.IcmpWorkers == 0 {
 		raw.IcmpWorkers = 64 // Default: 64 workers (reduced from 1024 to prevent resource contention)
 	}
+	if raw.IcmpDiscoveryStableSweeps == 0 {
+		raw.IcmpDiscoveryStableSweeps = 3 // Default: 3 quiet sweeps before stretching the discovery interval
+	}
+	if raw.DiscoveryMode == "" {
+		raw.DiscoveryMode = "icmp" // Default: ICMP echo discovery only
+	}
+	if len(raw.DiscoveryMethods) == 0 {
+		raw.DiscoveryMethods = []string{"sweep"} // Default: the built-in ICMP/TCP/ARP sweep only
+	}
+	if ssdpTimeout == 0 {
+		ssdpTimeout = 3 * time.Second // Default: 3 seconds (devices answer within MX=2 seconds)
+	}
+	if len(raw.TCPDiscoveryPorts) == 0 {
+		raw.TCPDiscoveryPorts = []int{22, 80, 443} // Default: SSH, HTTP, HTTPS
+	}
+	if tcpDiscoveryTimeout == 0 {
+		tcpDiscoveryTimeout = 1 * time.Second // Default: 1 second connect timeout (matches ICMP discovery)
+	}
 	if raw.SnmpWorkers == 0 {
 		raw.SnmpWorkers = 32 // Default: 32 workers (reduced from 256 to match ICMP workers scale)
 	}
+	applyAutoTuneDefaults(&raw.AutoTune, raw.IcmpWorkers, raw.SnmpWorkers)
+	applyInventoryExportDefaults(&raw.InventoryExport)
 	if raw.MaxConcurrentPingers == 0 {
 		raw.MaxConcurrentPingers = 20000 // Default: allow up to 20,000 concurrent pingers
 	}
@@ -232,6 +707,12 @@ func LoadConfig(path string) (*Config, error) {
 	if flushInterval == 0 {
 		flushInterval = 5 * time.Second // Default: flush every 5 seconds
 	}
+	if shutdownTimeout == 0 {
+		shutdownTimeout = 10 * time.Second // Default: wait up to 10 seconds for the final flush
+	}
+	if raw.InfluxDB.Version == 0 {
+		raw.InfluxDB.Version = InfluxDBVersion2 // Default: InfluxDB 2.x API
+	}
 	// Set health bucket default
 	if raw.InfluxDB.HealthBucket == "" {
 		raw.InfluxDB.HealthBucket = "health" // Default: health bucket
@@ -240,11 +721,56 @@ func LoadConfig(path string) (*Config, error) {
 	if healthReportInterval == 0 {
 		healthReportInterval = 10 * time.Second // Default: report health every 10 seconds
 	}
+	if healthReadTimeout == 0 {
+		healthReadTimeout = 30 * time.Second // Default: 30s to read a request
+	}
+	if healthWriteTimeout == 0 {
+		healthWriteTimeout = 60 * time.Second // Default: 60s, room for 30s probes and SNMP refreshes
+	}
+	if healthIdleTimeout == 0 {
+		healthIdleTimeout = 120 * time.Second // Default: close keep-alive connections idle for 2 minutes
+	}
+	if raw.HealthTLSClientAuth == "" {
+		raw.HealthTLSClientAuth = "optional" // Default: health probes without a certificate still connect
+	}
+	if monitorShutdownTimeout == 0 {
+		monitorShutdownTimeout = 10 * time.Second // Default: wait up to 10 seconds for pingers and SNMP pollers
+	}
+	// Set multi-scanner overlap defaults
+	if raw.InstanceID == "" {
+		raw.InstanceID = defaultInstanceID() // Default: system hostname
+	}
+	if raw.OverlapAction == "" {
+		raw.OverlapAction = "warn" // Default: log overlapping ranges, keep scanning
+	}
+	if raw.OverlapMinMatches == 0 {
+		raw.OverlapMinMatches = 3 // Default: 3 matching devices before a network counts as overlapping
+	}
 	// Set health check port default
 	if raw.HealthCheckPort == 0 {
 		raw.HealthCheckPort = 8080 // Default: port 8080 for health checks
 	}
 
+	if raw.PingsPerCycle == 0 {
+		raw.PingsPerCycle = raw.ProbeProfiles.Monitoring.Count // probe_profiles.monitoring.count is an alternative spelling
+	}
+	if raw.PingsPerCycle == 0 {
+		raw.PingsPerCycle = 1 // Default: single echo request per cycle
+	}
+	applyProbeProfileDefaults(&raw.ProbeProfiles, raw.PingsPerCycle)
+	if raw.PingEngine == "" {
+		raw.PingEngine = "batch" // Default: one shared ICMP socket for all ping cycles
+	}
+	if raw.ICMPMode == "" {
+		raw.ICMPMode = "auto" // Default: raw ICMP sockets when permitted, otherwise UDP
+	}
+	if raw.PingWorkers == 0 {
+		raw.PingWorkers = 256 // Default: 256 workers (ping_rate_limit x ping_timeout with headroom)
+	}
+	if raw.RTTHistorySamples == 0 {
+		raw.RTTHistorySamples = 30 // Default: last 30 ping cycles per device
+	}
+
 	// Set ping rate limiting defaults
 	if raw.PingRateLimit == 0 {
 		raw.PingRateLimit = 64.0 // Default: 64 pings per second
@@ -260,11 +786,42 @@ func LoadConfig(path string) (*Config, error) {
 	if pingBackoffDuration == 0 {
 		pingBackoffDuration = 5 * time.Minute // Default: 5 minute suspension
 	}
+	if pingBackoffMax == 0 {
+		pingBackoffMax = max(time.Hour, pingBackoffDuration) // Default: escalated suspensions last at most 1 hour
+	}
+	if pingBackoffReset == 0 {
+		pingBackoffReset = 24 * time.Hour // Default: a device without a trip for a day starts at the first backoff step
+	}
+	if raw.PingFailureMode == "" {
+		raw.PingFailureMode = "consecutive" // Default: trip after ping_max_consecutive_fails in a row
+	}
+	if raw.PingFailureWindow == 0 {
+		raw.PingFailureWindow = 20 // Default: windowed mode looks at the last 20 ping cycles
+	}
+	if raw.PingFailureRatio == 0 {
+		raw.PingFailureRatio = 0.7 // Default: windowed mode trips at 70% failed cycles
+	}
+	if raw.PingFailureCoalesceEvery == 0 {
+		raw.PingFailureCoalesceEvery = 10 // Default: keep every 10th failure point while coalescing
+	}
+	if raw.APIRateLimit == 0 {
+		raw.APIRateLimit = 5.0 // Default: 5 API requests per second per client
+	}
+	if raw.APIBurstLimit == 0 {
+		raw.APIBurstLimit = 20 // Default: bursts of 20 API requests per client
+	}
+	if raw.DeviceDownAfter == 0 {
+		raw.DeviceDownAfter = 3 // Default: report a device down after 3 consecutive failed pings
+	}
 
 	// Set SNMP continuous polling defaults
 	if snmpInterval == 0 {
 		snmpInterval = 1 * time.Hour // Default: poll SNMP every 1 hour per device
 	}
+	if raw.SNMP.MaxSessions > 0 && raw.SNMP.SessionIdleTimeout == 0 {
+		// Default: keep sessions across two poll intervals so every regular poll finds its session
+		raw.SNMP.SessionIdleTimeout = 2 * snmpInterval
+	}
 	if raw.SNMPRateLimit == 0 {
 		raw.SNMPRateLimit = 10.0 // Default: 10 SNMP queries per second
 	}
@@ -281,47 +838,179 @@ func LoadConfig(path string) (*Config, error) {
 		raw.MaxConcurrentSNMPPollers = 20000 // Default: allow up to 20,000 concurrent SNMP pollers
 	}
 
+	// Host names in networks are resolved to labeled /32 networks
+	networkEntries, networkHosts := expandHostEntries(raw.Networks, lookup)
+	hostResolveInterval := time.Hour
+	if raw.HostResolveInterval != "" {
+		hostResolveInterval, err = time.ParseDuration(raw.HostResolveInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid host_resolve_interval: %v", err)
+		}
+	}
+
+	// Inline network labels are merged into network_labels
+	networks, networkLabels, err := splitNetworkEntries(networkEntries, raw.NetworkLabels)
+	if err != nil {
+		return nil, err
+	}
+	// Site networks are discovered and monitored like the top-level networks
+	sites, networks, networkLabels, err := splitSites(raw.Sites, networks, networkLabels)
+	if err != nil {
+		return nil, err
+	}
+
 	// Apply environment variable expansion to sensitive fields
 	raw.InfluxDB.URL = expandEnv(raw.InfluxDB.URL)
 	raw.InfluxDB.Token = expandEnv(raw.InfluxDB.Token)
 	raw.InfluxDB.Org = expandEnv(raw.InfluxDB.Org)
 	raw.InfluxDB.Bucket = expandEnv(raw.InfluxDB.Bucket)
 	raw.InfluxDB.HealthBucket = expandEnv(raw.InfluxDB.HealthBucket)
+	raw.InfluxDB.Username = expandEnv(raw.InfluxDB.Username)
+	raw.InfluxDB.Password = expandEnv(raw.InfluxDB.Password)
+	raw.InfluxDB.Database = expandEnv(raw.InfluxDB.Database)
+	expandInfluxMirrors(raw.InfluxDB.Mirrors, raw.InfluxDB.Bucket, raw.InfluxDB.HealthBucket, raw.InfluxDB.Database)
 	raw.SNMP.Community = expandEnv(raw.SNMP.Community)
+	for i := range raw.Notifications.Webhooks {
+		raw.Notifications.Webhooks[i].URL = expandEnv(raw.Notifications.Webhooks[i].URL) // Webhook URLs embed secrets
+	}
+	for i := range raw.APITokens {
+		raw.APITokens[i].Token = expandEnv(raw.APITokens[i].Token)
+	}
+	for i := range raw.APIUsers {
+		raw.APIUsers[i].Password = expandEnv(raw.APIUsers[i].Password)
+	}
 
 	return &Config{
 		DiscoveryInterval:       discoveryInterval,
 		IcmpDiscoveryInterval:   icmpDiscoveryInterval,
+		IcmpDiscoveryMaxInterval:  icmpDiscoveryMaxInterval,
+		IcmpDiscoveryStableSweeps: raw.IcmpDiscoveryStableSweeps,
 		IcmpWorkers:             raw.IcmpWorkers,
+		DiscoveryNetworkWorkers: raw.DiscoveryNetworkWorkers,
 		SnmpWorkers:             raw.SnmpWorkers,
-		Networks:                raw.Networks,
+		Networks:                networks,
+		NetworkHosts:            networkHosts,
+		HostResolveInterval:     hostResolveInterval,
+		ExcludeNetworks:         raw.ExcludeNetworks,
+		NetworkLabels:           networkLabels,
+		Sites:                   sites,
+		ExcludeIPs:              raw.ExcludeIPs,
+		DiscoveryMode:           raw.DiscoveryMode,
+		DiscoveryMethods:        raw.DiscoveryMethods,
+		SSDPTimeout:             ssdpTimeout,
+		TCPDiscoveryPorts:       raw.TCPDiscoveryPorts,
+		TCPDiscoveryTimeout:     tcpDiscoveryTimeout,
+		ARPDiscovery:            raw.ARPDiscovery,
+		ARPListenInterfaces:     raw.ARPListenInterfaces,
+		DiscoverySweepBudget:    raw.DiscoverySweepBudget,
+		DiscoveryCursorFile:     raw.DiscoveryCursorFile,
+		AllowLoopback:           raw.AllowLoopback,
+		AllowLinkLocal:          raw.AllowLinkLocal,
 		SNMP:                    raw.SNMP,
 		PingInterval:            pingInterval,
 		PingTimeout:             pingTimeout,
+		PingsPerCycle:           raw.PingsPerCycle,
+		ProbeProfiles:           raw.ProbeProfiles,
+		PingWorkers:             raw.PingWorkers,
+		PingStartSpread:         pingStartSpread,
+		PingJitter:              pingJitter,
+		PingEngine:              raw.PingEngine,
+		ICMPMode:                raw.ICMPMode,
+		SourceInterface:         raw.SourceInterface,
+		SourceIP:                raw.SourceIP,
 		PingRateLimit:           raw.PingRateLimit,
 		PingBurstLimit:          raw.PingBurstLimit,
+		NetworkRateLimits:       raw.NetworkRateLimits,
 		PingMaxConsecutiveFails: raw.PingMaxConsecutiveFails,
+		PingFailureMode:         raw.PingFailureMode,
+		PingFailureWindow:       raw.PingFailureWindow,
+		PingFailureRatio:        raw.PingFailureRatio,
 		PingBackoffDuration:     pingBackoffDuration,
+		PingHalfOpenAfter:       pingHalfOpenAfter,
+		PingBackoffMax:          pingBackoffMax,
+		PingBackoffSteps:        pingBackoffSteps,
+		PingBackoffReset:        pingBackoffReset,
+		BackoffFile:             raw.BackoffFile,
+		PingFailureCoalesceAfter: pingFailureCoalesceAfter,
+		PingFailureCoalesceEvery: raw.PingFailureCoalesceEvery,
+		DeviceDownAfter:          raw.DeviceDownAfter,
 		SNMPInterval:            snmpInterval,
+		SNMPJitter:              snmpJitter,
+		DeviceInfoRefresh:       deviceInfoRefresh,
 		SNMPRateLimit:           raw.SNMPRateLimit,
 		SNMPBurstLimit:          raw.SNMPBurstLimit,
 		SNMPMaxConsecutiveFails: raw.SNMPMaxConsecutiveFails,
 		SNMPBackoffDuration:     snmpBackoffDuration,
 		InfluxDB: InfluxDBConfig{
-			URL:           raw.InfluxDB.URL,
-			Token:         raw.InfluxDB.Token,
-			Org:           raw.InfluxDB.Org,
-			Bucket:        raw.InfluxDB.Bucket,
-			HealthBucket:  raw.InfluxDB.HealthBucket,
-			BatchSize:     raw.InfluxDB.BatchSize,
-			FlushInterval: flushInterval,
+			Version:         raw.InfluxDB.Version,
+			URL:             raw.InfluxDB.URL,
+			Token:           raw.InfluxDB.Token,
+			Org:             raw.InfluxDB.Org,
+			Bucket:          raw.InfluxDB.Bucket,
+			HealthBucket:    raw.InfluxDB.HealthBucket,
+			Username:        raw.InfluxDB.Username,
+			Password:        raw.InfluxDB.Password,
+			Database:        raw.InfluxDB.Database,
+			RetentionPolicy: raw.InfluxDB.RetentionPolicy,
+			BatchSize:       raw.InfluxDB.BatchSize,
+			FlushInterval:   flushInterval,
+			ShutdownTimeout: shutdownTimeout,
+			HealthCheckInterval: influxHealthCheckInterval,
+			MaxStringLength: raw.InfluxDB.MaxStringLength,
+			Mirrors:         raw.InfluxDB.Mirrors,
 		},
 		SNMPDailySchedule:        raw.SNMPDailySchedule,
+		SNMPDailyDays:            raw.SNMPDailyDays,
+		Timezone:                 raw.Timezone,
+		Location:                 location,
 		HealthCheckPort:          raw.HealthCheckPort,
 		HealthReportInterval:     healthReportInterval,
+		HealthReadTimeout:        healthReadTimeout,
+		HealthWriteTimeout:       healthWriteTimeout,
+		HealthIdleTimeout:        healthIdleTimeout,
+		HealthTLSCert:            raw.HealthTLSCert,
+		HealthTLSKey:             raw.HealthTLSKey,
+		HealthTLSClientCA:        raw.HealthTLSClientCA,
+		HealthTLSClientAuth:      raw.HealthTLSClientAuth,
+		ShutdownTimeout:          monitorShutdownTimeout,
+		FlagsAPI:                 raw.FlagsAPI,
+		StrictValidation:         raw.StrictValidation,
+		LogLevels:                raw.LogLevels,
+		HistoryFile:              raw.HistoryFile,
+		APIRateLimit:             raw.APIRateLimit,
+		Notifications:            raw.Notifications,
+		Publish:                  raw.Publish,
+		CompositeChecks:          raw.CompositeChecks,
+		LatencyThresholds:        raw.LatencyThresholds,
+		Traceroute:               raw.Traceroute,
+		AutoTune:                 raw.AutoTune,
+		MaintenanceWindows:       raw.MaintenanceWindows,
+		CompositeCheckInterval:   compositeCheckInterval,
+		DeviceClassification:     raw.DeviceClassification,
+		DeviceTags:               raw.DeviceTags,
+		OSFingerprinting:         raw.OSFingerprinting,
+		InventoryFile:            raw.InventoryFile,
+		InventoryReportInterval:  inventoryReportInterval,
+		InventoryExport:          raw.InventoryExport,
+		RollupDays:               raw.RollupDays,
+		RollupFile:               raw.RollupFile,
+		RTTHistorySamples:        raw.RTTHistorySamples,
+		APIBurstLimit:            raw.APIBurstLimit,
+		APITokens:                raw.APITokens,
+		APIUsers:                 raw.APIUsers,
+		APIClientCerts:           raw.APIClientCerts,
+		InstanceID:               raw.InstanceID,
+		OverlapCheckInterval:     overlapCheckInterval,
+		OverlapAction:            raw.OverlapAction,
+		OverlapMinMatches:        raw.OverlapMinMatches,
 		MaxConcurrentPingers:     raw.MaxConcurrentPingers,
+		PingerStartRate:          raw.PingerStartRate,
 		MaxConcurrentSNMPPollers: raw.MaxConcurrentSNMPPollers,
 		MaxDevices:               raw.MaxDevices,
+		TombstoneTTL:             tombstoneTTL,
+		QuarantineTrips:          raw.QuarantineTrips,
+		QuarantineWindow:         quarantineWindow,
+		QuarantineFile:           raw.QuarantineFile,
 		MinScanInterval:          minScanInterval,
 		MemoryLimitMB:            raw.MemoryLimitMB,
 	}, nil
@@ -334,149 +1023,442 @@ func expandEnv(s string) string {
 
 // ValidateConfig performs security and sanity checks on the configuration
 // Returns warning message for security concerns, error for validation failures
+// With strict_validation enabled warnings are returned as errors instead
+// Only the first problem is returned; use CheckConfig to get all of them
 func ValidateConfig(cfg *Config) (string, error) {
+	return checkConfig(cfg, true).First()
+}
+
+// ValidateScanConfig validates the config for a one-shot discovery scan ("netscan scan"),
+// which writes nowhere and therefore does not need the InfluxDB settings
+func ValidateScanConfig(cfg *Config) (string, error) {
+	return checkConfig(cfg, false).First()
+}
+
+// CheckConfig runs every validation check and reports all errors and warnings instead of stopping at the first
+func CheckConfig(cfg *Config) ValidationResult {
+	return checkConfig(cfg, true)
+}
+
+// checkConfig runs the checks and applies strict_validation
+func checkConfig(cfg *Config, requireInfluxDB bool) ValidationResult {
+	var result ValidationResult
+	validateConfig(cfg, requireInfluxDB, &result)
+	applyStrictValidation(cfg, &result)
+	return result
+}
+
+// validateConfig runs every check and collects all errors and warnings
+// requireInfluxDB is false for the scan subcommand, which accepts an empty influxdb.url
+func validateConfig(cfg *Config, requireInfluxDB bool, v *ValidationResult) {
 	// Validate network ranges
 	for _, network := range cfg.Networks {
-		if err := validateCIDR(network); err != nil {
-			return "", err
+		v.check(validateCIDR(network, cfg.AddressPolicy()))
+	}
+
+	// Validate discovery exclusion lists
+	v.check(validateExclusions(cfg.ExcludeNetworks, cfg.ExcludeIPs))
+	v.check(validateNetworkLabels(cfg.NetworkLabels, cfg.Networks))
+	v.check(validateSites(cfg.Sites, cfg.Networks))
+	for _, warning := range hostWarnings(cfg.NetworkHosts, cfg.HostResolveInterval) {
+		v.warn(warning)
+	}
+	if cfg.HostResolveInterval != 0 && cfg.HostResolveInterval < time.Minute {
+		v.errorf("host_resolve_interval must be 0 or at least 1 minute, got %v", cfg.HostResolveInterval)
+	}
+	if warning := sitesWarning(cfg.Sites, cfg.InfluxDB.URL); warning != "" {
+		v.warn(warning)
+	}
+
+	// Validate discovery mode and TCP discovery settings
+	switch cfg.DiscoveryMode {
+	case "", "icmp":
+	case "tcp", "both":
+		if len(cfg.TCPDiscoveryPorts) == 0 {
+			v.errorf("tcp_discovery_ports must not be empty when discovery_mode is %s", cfg.DiscoveryMode)
+		}
+		for _, port := range cfg.TCPDiscoveryPorts {
+			if port < 1 || port > 65535 {
+				v.errorf("tcp_discovery_ports must be between 1 and 65535, got %d", port)
+			}
+		}
+		if cfg.TCPDiscoveryTimeout < 100*time.Millisecond || cfg.TCPDiscoveryTimeout > 10*time.Second {
+			v.errorf("tcp_discovery_timeout must be between 100ms and 10s, got %v", cfg.TCPDiscoveryTimeout)
+		}
+	default:
+		v.errorf("discovery_mode must be one of icmp, tcp, both, got %q", cfg.DiscoveryMode)
+	}
+	v.check(validateDiscoveryMethods(cfg.DiscoveryMethods))
+	if cfg.SSDPTimeout != 0 && (cfg.SSDPTimeout < time.Second || cfg.SSDPTimeout > 30*time.Second) {
+		v.errorf("ssdp_timeout must be between 1s and 30s, got %v", cfg.SSDPTimeout)
+	}
+
+	// Validate per-module log levels
+	modules := make([]string, 0, len(cfg.LogLevels))
+	for module := range cfg.LogLevels {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+	for _, module := range modules {
+		if err := logger.ValidateModuleLevel(module, cfg.LogLevels[module]); err != nil {
+			v.errorf("log_levels: %v", err)
+		}
+	}
+
+	// Validate passive ARP listener interfaces (existence is checked when the listener starts)
+	seenInterfaces := make(map[string]bool, len(cfg.ARPListenInterfaces))
+	for _, name := range cfg.ARPListenInterfaces {
+		if name == "" || len(name) > 15 || strings.ContainsAny(name, "/ \t") {
+			v.errorf("arp_listen_interfaces: invalid interface name %q", name)
+			continue
+		}
+		if seenInterfaces[name] {
+			v.errorf("arp_listen_interfaces: duplicate interface %q", name)
 		}
+		seenInterfaces[name] = true
 	}
 
 	// Validate worker counts
 	if cfg.IcmpWorkers < 1 || cfg.IcmpWorkers > 2000 {
-		return "", fmt.Errorf("icmp_workers must be between 1 and 2000, got %d", cfg.IcmpWorkers)
+		v.errorf("icmp_workers must be between 1 and 2000, got %d", cfg.IcmpWorkers)
+	}
+	if cfg.DiscoveryNetworkWorkers < 0 || cfg.DiscoveryNetworkWorkers > cfg.IcmpWorkers {
+		v.errorf("discovery_network_workers must be between 0 and icmp_workers (%d), got %d", cfg.IcmpWorkers, cfg.DiscoveryNetworkWorkers)
 	}
 	if cfg.SnmpWorkers < 1 || cfg.SnmpWorkers > 1000 {
-		return "", fmt.Errorf("snmp_workers must be between 1 and 1000, got %d", cfg.SnmpWorkers)
+		v.errorf("snmp_workers must be between 1 and 1000, got %d", cfg.SnmpWorkers)
 	}
 
 	// Validate intervals
 	if cfg.DiscoveryInterval < time.Minute {
-		return "", fmt.Errorf("discovery_interval must be at least 1 minute, got %v", cfg.DiscoveryInterval)
+		v.errorf("discovery_interval must be at least 1 minute, got %v", cfg.DiscoveryInterval)
 	}
 	if cfg.IcmpDiscoveryInterval < time.Minute {
-		return "", fmt.Errorf("icmp_discovery_interval must be at least 1 minute, got %v", cfg.IcmpDiscoveryInterval)
+		v.errorf("icmp_discovery_interval must be at least 1 minute, got %v", cfg.IcmpDiscoveryInterval)
+	}
+	// Validate adaptive discovery settings (only used when a max interval is configured)
+	if cfg.IcmpDiscoveryMaxInterval != 0 {
+		if cfg.IcmpDiscoveryMaxInterval < cfg.IcmpDiscoveryInterval {
+			v.errorf("icmp_discovery_max_interval (%v) must not be less than icmp_discovery_interval (%v)", cfg.IcmpDiscoveryMaxInterval, cfg.IcmpDiscoveryInterval)
+		}
+		if cfg.IcmpDiscoveryStableSweeps < 1 || cfg.IcmpDiscoveryStableSweeps > 100 {
+			v.errorf("icmp_discovery_stable_sweeps must be between 1 and 100, got %d", cfg.IcmpDiscoveryStableSweeps)
+		}
 	}
 	if cfg.PingInterval < time.Second {
-		return "", fmt.Errorf("ping_interval must be at least 1 second, got %v", cfg.PingInterval)
+		v.errorf("ping_interval must be at least 1 second, got %v", cfg.PingInterval)
+	}
+
+	// Validate multi-packet ping settings (0 is treated as 1)
+	if cfg.PingsPerCycle < 0 || cfg.PingsPerCycle > 10 {
+		v.errorf("pings_per_cycle must be between 1 and 10, got %d", cfg.PingsPerCycle)
+	}
+	v.check(validateProbeProfiles(cfg))
+	if cfg.PingsPerCycle > 1 {
+		// Packets are spaced probe_profiles.monitoring.interval apart, so the whole cycle must finish before the next one starts
+		cycle := cfg.PingTimeout + cfg.ProbeProfiles.Monitoring.Spread(cfg.PingsPerCycle)
+		if cycle >= cfg.PingInterval {
+			v.errorf("pings_per_cycle %d with ping_timeout %v takes %v, which must be less than ping_interval %v", cfg.PingsPerCycle, cfg.PingTimeout, cycle, cfg.PingInterval)
+		}
+	}
+
+	// Validate the continuous ping worker pool
+	if cfg.PingWorkers < 1 || cfg.PingWorkers > 10000 {
+		v.errorf("ping_workers must be between 1 and 10000, got %d", cfg.PingWorkers)
+	}
+	// Validate ping load spreading (both are bounded by the interval so cycles never overlap or starve)
+	if cfg.PingStartSpread < 0 || cfg.PingStartSpread > cfg.PingInterval {
+		v.errorf("ping_start_spread must be between 0 and ping_interval (%v), got %v", cfg.PingInterval, cfg.PingStartSpread)
+	}
+	if cfg.PingJitter < 0 || cfg.PingJitter > cfg.PingInterval/2 {
+		v.errorf("ping_jitter must be between 0 and half of ping_interval (%v), got %v", cfg.PingInterval/2, cfg.PingJitter)
+	}
+	switch cfg.PingEngine {
+	case "", "probing", "batch":
+	default:
+		v.errorf("ping_engine must be 'probing' or 'batch', got %q", cfg.PingEngine)
+	}
+	switch cfg.ICMPMode {
+	case "", "auto", "privileged", "unprivileged":
+	default:
+		v.errorf("icmp_mode must be 'auto', 'privileged' or 'unprivileged', got %q", cfg.ICMPMode)
+	}
+	v.check(validateSource(cfg))
+
+	// Validate failure point coalescing (only used when enabled)
+	if cfg.PingFailureCoalesceAfter < 0 {
+		v.errorf("ping_failure_coalesce_after must not be negative, got %v", cfg.PingFailureCoalesceAfter)
+	}
+	if cfg.PingFailureCoalesceAfter > 0 {
+		if cfg.PingFailureCoalesceAfter < cfg.PingInterval {
+			v.errorf("ping_failure_coalesce_after (%v) must not be less than ping_interval (%v)", cfg.PingFailureCoalesceAfter, cfg.PingInterval)
+		}
+		if cfg.PingFailureCoalesceEvery < 2 || cfg.PingFailureCoalesceEvery > 1000 {
+			v.errorf("ping_failure_coalesce_every must be between 2 and 1000, got %d", cfg.PingFailureCoalesceEvery)
+		}
+	}
+
+	// Validate the up/down transition threshold (0 falls back to the default)
+	if cfg.DeviceDownAfter < 0 || cfg.DeviceDownAfter > 100 {
+		v.errorf("device_down_after must be between 1 and 100, got %d", cfg.DeviceDownAfter)
+	}
+
+	// Validate per-client API rate limiting (0 falls back to the defaults)
+	if cfg.APIRateLimit < 0 || cfg.APIRateLimit > 1000 {
+		v.errorf("api_rate_limit must be between 0 and 1000 requests per second, got %.2f", cfg.APIRateLimit)
+	}
+	if cfg.APIBurstLimit < 0 || cfg.APIBurstLimit > 10000 {
+		v.errorf("api_burst_limit must be between 0 and 10000, got %d", cfg.APIBurstLimit)
 	}
 
-	// Validate SNMP daily schedule format (HH:MM)
+	// Validate multi-scanner overlap detection settings
+	v.check(validateOverlapSettings(cfg))
+
+	// Validate the schedule timezone (resolved by LoadConfig; checked here for configs built directly)
+	if cfg.Timezone != "" && cfg.Location == nil {
+		if _, err := loadTimezone(cfg.Timezone); err != nil {
+			v.errorf("invalid timezone %q: %v", cfg.Timezone, err)
+		}
+	}
+
+	// Validate SNMP daily re-scan time (HH:MM) and weekdays
 	if cfg.SNMPDailySchedule != "" {
 		if err := validateTimeFormat(cfg.SNMPDailySchedule); err != nil {
-			return "", fmt.Errorf("snmp_daily_schedule validation failed: %v", err)
+			v.errorf("snmp_daily_schedule validation failed: %v", err)
 		}
 	}
+	if _, err := parseWeekdays(cfg.SNMPDailyDays); err != nil {
+		v.errorf("snmp_daily_days: %v", err)
+	} else if len(cfg.SNMPDailyDays) > 0 && cfg.SNMPDailySchedule == "" {
+		v.errorf("snmp_daily_days requires snmp_daily_schedule")
+	}
 
 	// Validate SNMP settings
 	if cfg.SNMP.Port < 1 || cfg.SNMP.Port > 65535 {
-		return "", fmt.Errorf("snmp port must be between 1 and 65535, got %d", cfg.SNMP.Port)
+		v.errorf("snmp port must be between 1 and 65535, got %d", cfg.SNMP.Port)
 	}
 	if cfg.SNMP.Timeout < time.Second {
-		return "", fmt.Errorf("snmp timeout must be at least 1 second, got %v", cfg.SNMP.Timeout)
+		v.errorf("snmp timeout must be at least 1 second, got %v", cfg.SNMP.Timeout)
 	}
 	if cfg.SNMP.Retries < 0 || cfg.SNMP.Retries > 10 {
-		return "", fmt.Errorf("snmp retries must be between 0 and 10, got %d", cfg.SNMP.Retries)
+		v.errorf("snmp retries must be between 0 and 10, got %d", cfg.SNMP.Retries)
+	}
+	if cfg.SNMP.Transport != "" && !validSNMPTransport(cfg.SNMP.Transport) {
+		v.errorf("snmp transport must be udp or tcp, got %q", cfg.SNMP.Transport)
+	}
+	if cfg.InfluxDB.ShutdownTimeout != 0 && (cfg.InfluxDB.ShutdownTimeout < time.Second || cfg.InfluxDB.ShutdownTimeout > 5*time.Minute) {
+		v.errorf("influxdb.shutdown_timeout must be between 1s and 5m, got %v", cfg.InfluxDB.ShutdownTimeout)
+	}
+	if cfg.ShutdownTimeout != 0 && (cfg.ShutdownTimeout < time.Second || cfg.ShutdownTimeout > 5*time.Minute) {
+		v.errorf("shutdown_timeout must be between 1s and 5m, got %v", cfg.ShutdownTimeout)
+	}
+	if cfg.InfluxDB.HealthCheckInterval != 0 && (cfg.InfluxDB.HealthCheckInterval < time.Second || cfg.InfluxDB.HealthCheckInterval > 5*time.Minute) {
+		v.errorf("influxdb.health_check_interval must be between 1s and 5m, got %v", cfg.InfluxDB.HealthCheckInterval)
+	}
+	if cfg.SNMP.MaxSessions < 0 || cfg.SNMP.MaxSessions > 100000 {
+		v.errorf("snmp max_sessions must be between 0 and 100000, got %d", cfg.SNMP.MaxSessions)
+	}
+	if cfg.SNMP.MaxSessions > 0 && cfg.SNMP.SessionIdleTimeout < time.Minute {
+		v.errorf("snmp session_idle_timeout must be at least 1 minute, got %v", cfg.SNMP.SessionIdleTimeout)
+	}
+	v.check(validateMaxStringLength("snmp.max_string_length", cfg.SNMP.MaxStringLength))
+	v.check(validateMaxStringLength("influxdb.max_string_length", cfg.InfluxDB.MaxStringLength))
+	v.check(validateOIDGroups(cfg.SNMP.OIDGroups))
+	v.check(validateDeviceFieldRules(cfg.SNMP.DeviceFields))
+	v.check(validateNotifyConfig(&cfg.Notifications))
+	v.check(validatePublish(&cfg.Publish))
+	v.check(validateCompositeChecks(cfg.CompositeChecks, cfg.CompositeCheckInterval))
+	v.check(validateLatencyThresholds(cfg.LatencyThresholds))
+	v.check(validateTracerouteConfig(&cfg.Traceroute))
+	v.check(validateAutoTune(&cfg.AutoTune, cfg.IcmpWorkers, cfg.SnmpWorkers))
+	v.check(validateInventoryExport(&cfg.InventoryExport))
+	v.check(validateMaintenanceWindows(cfg.MaintenanceWindows, cfg.ScheduleLocation()))
+	v.check(validateDeviceClassification(cfg.DeviceClassification))
+	v.check(validateDeviceTags(cfg.DeviceTags, cfg.Sites))
+	if warning := deviceClassificationWarning(cfg.DeviceClassification); warning != "" {
+		v.warn(warning)
+	}
+	v.check(validateOSFingerprint(cfg.OSFingerprinting))
+	v.check(validateAPITokens(cfg.APITokens))
+	v.check(validateAPIUsers(cfg.APIUsers, cfg.APITokens))
+	v.check(validateAPIClientCerts(cfg.APIClientCerts, cfg.APITokens, cfg.APIUsers))
+	v.check(validateHealthServer(cfg))
+	if cfg.HealthWriteTimeout > 0 && cfg.HealthWriteTimeout < minHealthWriteTimeout {
+		v.warn(fmt.Sprintf("health_write_timeout %v is shorter than on-demand probes and SNMP refreshes (up to %v); their responses may be cut off", cfg.HealthWriteTimeout, minHealthWriteTimeout))
+	}
+	if cfg.InventoryFile != "" && cfg.InventoryReportInterval < time.Minute {
+		v.errorf("inventory_report_interval must be at least 1 minute, got %v", cfg.InventoryReportInterval)
+	}
+	if cfg.RollupDays < 0 || cfg.RollupDays > 366 {
+		v.errorf("rollup_days must be between 0 and 366, got %d", cfg.RollupDays)
+	}
+	if cfg.RollupFile != "" && cfg.RollupDays == 0 {
+		v.errorf("rollup_file requires rollup_days")
+	}
+	if cfg.RTTHistorySamples < 0 || cfg.RTTHistorySamples > 1000 {
+		v.errorf("rtt_history_samples must be between 1 and 1000, got %d", cfg.RTTHistorySamples)
+	}
+	if requireInfluxDB && cfg.InfluxDB.URL == "" && cfg.OverlapCheckInterval > 0 {
+		v.errorf("overlap_check_interval requires influxdb.url")
 	}
 
 	// Validate and sanitize SNMP community string
-	if warning, err := validateSNMPCommunity(cfg.SNMP.Community); err != nil {
-		return "", err
-	} else if warning != "" {
-		// Return the warning
-		return warning, nil
+	warning, err := validateSNMPCommunity(cfg.SNMP.Community)
+	v.check(err)
+	if warning != "" {
+		v.warn(warning)
 	}
 
-	// Validate required fields
-	if cfg.InfluxDB.URL == "" {
-		return "", fmt.Errorf("influxdb.url is required")
-	}
-	if err := validateURL(cfg.InfluxDB.URL); err != nil {
-		return "", fmt.Errorf("influxdb.url validation failed: %v", err)
-	}
-	if cfg.InfluxDB.Token == "" {
-		return "", fmt.Errorf("influxdb.token is required")
+	// Validate required fields (InfluxDB is optional when local rollups are kept)
+	if requireInfluxDB && cfg.InfluxDB.URL == "" && cfg.RollupDays == 0 {
+		v.errorf("influxdb.url is required (or set rollup_days to run without InfluxDB)")
 	}
-	if cfg.InfluxDB.Org == "" {
-		return "", fmt.Errorf("influxdb.org is required")
-	}
-	if cfg.InfluxDB.Bucket == "" {
-		return "", fmt.Errorf("influxdb.bucket is required")
-	}
-	if cfg.SNMP.Community == "" {
-		return "", fmt.Errorf("snmp.community is required")
+	if cfg.InfluxDB.URL != "" {
+		if err := validateURL(cfg.InfluxDB.URL); err != nil {
+			v.errorf("influxdb.url validation failed: %v", err)
+		}
+		v.check(validateInfluxTarget("influxdb", cfg.InfluxDB.Target()))
 	}
+	v.check(validateInfluxMirrors(cfg.InfluxDB))
 
-	// Validate network ranges contain valid IP addresses
+	// Validate network ranges contain valid IP addresses (invalid CIDRs were reported above)
 	for _, network := range cfg.Networks {
-		if err := validateNetworkContainsValidIPs(network); err != nil {
-			return "", fmt.Errorf("network validation failed for %s: %v", network, err)
+		if validateCIDR(network, cfg.AddressPolicy()) != nil {
+			continue
+		}
+		if err := validateNetworkContainsValidIPs(network, cfg.AddressPolicy()); err != nil {
+			v.errorf("network validation failed for %s: %v", network, err)
 		}
 	}
 
+	// Validate streaming discovery (required for networks larger than /16)
+	v.check(validateStreamingDiscovery(cfg))
+
 	// Validate resource protection settings
 	if cfg.MaxConcurrentPingers < 1 || cfg.MaxConcurrentPingers > 100000 {
-		return "", fmt.Errorf("max_concurrent_pingers must be between 1 and 100000, got %d", cfg.MaxConcurrentPingers)
+		v.errorf("max_concurrent_pingers must be between 1 and 100000, got %d", cfg.MaxConcurrentPingers)
 	}
 	if cfg.MaxConcurrentSNMPPollers < 1 || cfg.MaxConcurrentSNMPPollers > 100000 {
-		return "", fmt.Errorf("max_concurrent_snmp_pollers must be between 1 and 100000, got %d", cfg.MaxConcurrentSNMPPollers)
+		v.errorf("max_concurrent_snmp_pollers must be between 1 and 100000, got %d", cfg.MaxConcurrentSNMPPollers)
+	}
+	if cfg.PingerStartRate < 0 || cfg.PingerStartRate > 10000 {
+		v.errorf("pinger_start_rate must be between 0 and 10000, got %g", cfg.PingerStartRate)
 	}
 	if cfg.MaxDevices < 1 || cfg.MaxDevices > 100000 {
-		return "", fmt.Errorf("max_devices must be between 1 and 100000, got %d", cfg.MaxDevices)
+		v.errorf("max_devices must be between 1 and 100000, got %d", cfg.MaxDevices)
+	}
+	if cfg.TombstoneTTL < 0 || cfg.TombstoneTTL > 7*24*time.Hour {
+		v.errorf("tombstone_ttl must be between 0 and 168h, got %v", cfg.TombstoneTTL)
+	}
+	if cfg.QuarantineTrips < 0 || cfg.QuarantineTrips > 1000 {
+		v.errorf("quarantine_trips must be between 0 and 1000, got %d", cfg.QuarantineTrips)
+	}
+	if cfg.QuarantineTrips > 0 && (cfg.QuarantineWindow < time.Hour || cfg.QuarantineWindow > 30*24*time.Hour) {
+		v.errorf("quarantine_window must be between 1h and 720h, got %v", cfg.QuarantineWindow)
+	}
+	if cfg.QuarantineFile != "" && cfg.QuarantineTrips == 0 {
+		v.errorf("quarantine_file requires quarantine_trips")
 	}
 	if cfg.MinScanInterval < 30*time.Second {
-		return "", fmt.Errorf("min_scan_interval must be at least 30 seconds, got %v", cfg.MinScanInterval)
+		v.errorf("min_scan_interval must be at least 30 seconds, got %v", cfg.MinScanInterval)
 	}
 	if cfg.MemoryLimitMB < 64 || cfg.MemoryLimitMB > 16384 {
-		return "", fmt.Errorf("memory_limit_mb must be between 64 and 16384, got %d", cfg.MemoryLimitMB)
+		v.errorf("memory_limit_mb must be between 64 and 16384, got %d", cfg.MemoryLimitMB)
 	}
 
 	// Validate ping rate limiting settings
 	if cfg.PingRateLimit <= 0 {
-		return "", fmt.Errorf("ping_rate_limit must be greater than 0, got %.2f", cfg.PingRateLimit)
+		v.errorf("ping_rate_limit must be greater than 0, got %.2f", cfg.PingRateLimit)
 	}
 	if cfg.PingBurstLimit <= 0 {
-		return "", fmt.Errorf("ping_burst_limit must be greater than 0, got %d", cfg.PingBurstLimit)
+		v.errorf("ping_burst_limit must be greater than 0, got %d", cfg.PingBurstLimit)
 	}
 	// Burst should be at least equal to rate to avoid immediate throttling
 	if float64(cfg.PingBurstLimit) < cfg.PingRateLimit {
-		return "WARNING: ping_burst_limit should be >= ping_rate_limit to avoid immediate throttling", nil
+		v.warn("WARNING: ping_burst_limit should be >= ping_rate_limit to avoid immediate throttling")
 	}
+	v.check(validateNetworkRateLimits(cfg.NetworkRateLimits))
 
 	// Validate circuit breaker settings
 	if cfg.PingMaxConsecutiveFails <= 0 {
-		return "", fmt.Errorf("ping_max_consecutive_fails must be greater than 0, got %d", cfg.PingMaxConsecutiveFails)
+		v.errorf("ping_max_consecutive_fails must be greater than 0, got %d", cfg.PingMaxConsecutiveFails)
 	}
 	if cfg.PingBackoffDuration < time.Minute {
-		return "", fmt.Errorf("ping_backoff_duration must be at least 1 minute, got %v", cfg.PingBackoffDuration)
+		v.errorf("ping_backoff_duration must be at least 1 minute, got %v", cfg.PingBackoffDuration)
+	}
+	firstBackoff := cfg.PingBackoffDuration
+	if len(cfg.PingBackoffSteps) > 0 {
+		firstBackoff = cfg.PingBackoffSteps[0]
+	}
+	if cfg.PingHalfOpenAfter != 0 && (cfg.PingHalfOpenAfter < cfg.PingInterval || cfg.PingHalfOpenAfter >= firstBackoff) {
+		v.errorf("ping_half_open_after must be 0 or between ping_interval (%v) and the first backoff (%v), got %v",
+			cfg.PingInterval, firstBackoff, cfg.PingHalfOpenAfter)
+	}
+	if len(cfg.PingBackoffSteps) > 10 {
+		v.errorf("ping_backoff_steps must have at most 10 steps, got %d", len(cfg.PingBackoffSteps))
+	}
+	for i, step := range cfg.PingBackoffSteps {
+		if step < time.Minute || step > 24*time.Hour {
+			v.errorf("ping_backoff_steps[%d] must be between 1m and 24h, got %v", i, step)
+		}
+		if i > 0 && step < cfg.PingBackoffSteps[i-1] {
+			v.errorf("ping_backoff_steps must not decrease, got %v after %v", step, cfg.PingBackoffSteps[i-1])
+		}
+	}
+	if len(cfg.PingBackoffSteps) > 0 && (cfg.PingBackoffReset < time.Hour || cfg.PingBackoffReset > 30*24*time.Hour) {
+		v.errorf("ping_backoff_reset must be between 1h and 720h, got %v", cfg.PingBackoffReset)
+	}
+	if cfg.BackoffFile != "" && len(cfg.PingBackoffSteps) == 0 {
+		v.errorf("backoff_file requires ping_backoff_steps")
+	}
+	if cfg.PingBackoffMax != 0 && (cfg.PingBackoffMax < cfg.PingBackoffDuration || cfg.PingBackoffMax > 24*time.Hour) {
+		v.errorf("ping_backoff_max must be between ping_backoff_duration (%v) and 24h, got %v", cfg.PingBackoffDuration, cfg.PingBackoffMax)
+	}
+	switch cfg.PingFailureMode {
+	case "", "consecutive":
+	case "windowed":
+		if cfg.PingFailureWindow < 2 || cfg.PingFailureWindow > 64 {
+			v.errorf("ping_failure_window must be between 2 and 64 ping cycles, got %d", cfg.PingFailureWindow)
+		}
+		if cfg.PingFailureRatio <= 0 || cfg.PingFailureRatio > 1 {
+			v.errorf("ping_failure_ratio must be greater than 0 and at most 1, got %g", cfg.PingFailureRatio)
+		}
+	default:
+		v.errorf("ping_failure_mode must be 'consecutive' or 'windowed', got %q", cfg.PingFailureMode)
 	}
 
 	// Validate SNMP continuous polling settings
 	if cfg.SNMPInterval < time.Minute {
-		return "", fmt.Errorf("snmp_interval must be at least 1 minute, got %v", cfg.SNMPInterval)
+		v.errorf("snmp_interval must be at least 1 minute, got %v", cfg.SNMPInterval)
+	}
+	if cfg.SNMPJitter < 0 || cfg.SNMPJitter > cfg.SNMPInterval/2 {
+		v.errorf("snmp_jitter must be between 0 and half of snmp_interval (%v), got %v", cfg.SNMPInterval/2, cfg.SNMPJitter)
+	}
+	if cfg.DeviceInfoRefresh < 0 {
+		v.errorf("device_info_refresh must not be negative, got %v", cfg.DeviceInfoRefresh)
 	}
 	if cfg.SNMPRateLimit <= 0 {
-		return "", fmt.Errorf("snmp_rate_limit must be greater than 0, got %.2f", cfg.SNMPRateLimit)
+		v.errorf("snmp_rate_limit must be greater than 0, got %.2f", cfg.SNMPRateLimit)
 	}
 	if cfg.SNMPBurstLimit <= 0 {
-		return "", fmt.Errorf("snmp_burst_limit must be greater than 0, got %d", cfg.SNMPBurstLimit)
+		v.errorf("snmp_burst_limit must be greater than 0, got %d", cfg.SNMPBurstLimit)
 	}
 	// Burst should be at least equal to rate to avoid immediate throttling
 	if float64(cfg.SNMPBurstLimit) < cfg.SNMPRateLimit {
-		return "WARNING: snmp_burst_limit should be >= snmp_rate_limit to avoid immediate throttling", nil
+		v.warn("WARNING: snmp_burst_limit should be >= snmp_rate_limit to avoid immediate throttling")
 	}
 	if cfg.SNMPMaxConsecutiveFails <= 0 {
-		return "", fmt.Errorf("snmp_max_consecutive_fails must be greater than 0, got %d", cfg.SNMPMaxConsecutiveFails)
+		v.errorf("snmp_max_consecutive_fails must be greater than 0, got %d", cfg.SNMPMaxConsecutiveFails)
 	}
 	if cfg.SNMPBackoffDuration < time.Minute {
-		return "", fmt.Errorf("snmp_backoff_duration must be at least 1 minute, got %v", cfg.SNMPBackoffDuration)
+		v.errorf("snmp_backoff_duration must be at least 1 minute, got %v", cfg.SNMPBackoffDuration)
 	}
 
-	return "", nil
 }
 
 // validateCIDR validates a CIDR notation and checks for dangerous network ranges
-func validateCIDR(cidr string) error {
+// Loopback and link-local ranges are only accepted when the address policy allows them
+func validateCIDR(cidr string, policy AddressPolicy) error {
 	_, network, err := net.ParseCIDR(cidr)
 	if err != nil {
 		return fmt.Errorf("invalid CIDR notation: %s", cidr)
@@ -484,14 +1466,14 @@ func validateCIDR(cidr string) error {
 
 	// Check for dangerous network ranges
 	networkIP := network.IP
-	if networkIP.IsLoopback() {
-		return fmt.Errorf("loopback networks not allowed: %s", cidr)
+	if networkIP.IsLoopback() && !policy.AllowLoopback {
+		return fmt.Errorf("loopback networks not allowed: %s (set allow_loopback to permit)", cidr)
 	}
 	if networkIP.IsMulticast() {
 		return fmt.Errorf("multicast networks not allowed: %s", cidr)
 	}
-	if networkIP.IsLinkLocalUnicast() {
-		return fmt.Errorf("link-local networks not allowed: %s", cidr)
+	if networkIP.IsLinkLocalUnicast() && !policy.AllowLinkLocal {
+		return fmt.Errorf("link-local networks not allowed: %s (set allow_link_local to permit)", cidr)
 	}
 
 	// Check for overly broad ranges (larger than /8)
@@ -503,6 +1485,14 @@ func validateCIDR(cidr string) error {
 	return nil
 }
 
+// validateMaxStringLength checks a string truncation limit (0 selects the default)
+func validateMaxStringLength(name string, n int) error {
+	if n != 0 && (n < validate.MinMaxLength || n > validate.MaxMaxLength) {
+		return fmt.Errorf("%s must be between %d and %d bytes, got %d", name, validate.MinMaxLength, validate.MaxMaxLength, n)
+	}
+	return nil
+}
+
 // validateSNMPCommunity validates and sanitizes SNMP community strings
 // validateSNMPCommunity validates and sanitizes SNMP community string
 // Returns warning message for security concerns, error for validation failures
@@ -604,7 +1594,7 @@ func validateTimeFormat(timeStr string) error {
 }
 
 // validateNetworkContainsValidIPs validates that a CIDR network range contains valid IP addresses
-func validateNetworkContainsValidIPs(cidr string) error {
+func validateNetworkContainsValidIPs(cidr string, policy AddressPolicy) error {
 	ip, network, err := net.ParseCIDR(cidr)
 	if err != nil {
 		return fmt.Errorf("invalid CIDR: %v", err)
@@ -626,12 +1616,12 @@ func validateNetworkContainsValidIPs(cidr string) error {
 	}
 
 	// Validate first IP
-	if !firstIP.IsGlobalUnicast() && !firstIP.IsPrivate() {
+	if !policy.AllowsUnicast(firstIP) {
 		return fmt.Errorf("first IP %s is not a valid unicast address", firstIP)
 	}
 
 	// Validate last IP
-	if !lastIP.IsGlobalUnicast() && !lastIP.IsPrivate() {
+	if !policy.AllowsUnicast(lastIP) {
 		return fmt.Errorf("last IP %s is not a valid unicast address", lastIP)
 	}
 
@@ -0,0 +1,22 @@
+package config
+
+import "testing"
+
+// TestValidateCIDRAddressPolicy validates loopback and link-local networks pass only when allowed
+func TestValidateCIDRAddressPolicy(t *testing.T) {
+	if err := validateCIDR("127.0.0.0/30", AddressPolicy{}); err == nil {
+		t.Error("expected loopback network to be rejected by default")
+	}
+	if err := validateCIDR("127.0.0.0/30", AddressPolicy{AllowLoopback: true}); err != nil {
+		t.Errorf("expected loopback network to be allowed, got %v", err)
+	}
+	if err := validateNetworkContainsValidIPs("127.0.0.1/32", AddressPolicy{AllowLoopback: true}); err != nil {
+		t.Errorf("expected loopback host to contain valid IPs, got %v", err)
+	}
+	if err := validateCIDR("169.254.0.0/16", AddressPolicy{AllowLinkLocal: true}); err != nil {
+		t.Errorf("expected link-local network to be allowed, got %v", err)
+	}
+	if err := validateNetworkContainsValidIPs("169.254.10.0/24", AddressPolicy{}); err == nil {
+		t.Error("expected link-local network to fail IP range check by default")
+	}
+}
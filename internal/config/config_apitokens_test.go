@@ -0,0 +1,100 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestAPITokens validates token names, secrets, networks and environment expansion
+func TestAPITokens(t *testing.T) {
+	t.Setenv("NETSCAN_BRANCH_TOKEN", "branch-secret-0123456789")
+	tests := []struct {
+		name     string
+		settings string
+		wantErr  string
+	}{
+		{"none", "", ""},
+		{"scoped and global", "api_tokens:\n  - name: admin\n    token: \"admin-secret-0123456789\"\n  - name: branch\n    token: \"${NETSCAN_BRANCH_TOKEN}\"\n    networks: [\"10.1.0.0/16\"]", ""},
+		{"short token", "api_tokens:\n  - name: admin\n    token: \"short\"", "at least 16 characters"},
+		{"unset env token", "api_tokens:\n  - name: admin\n    token: \"${NETSCAN_UNSET_TOKEN}\"", "at least 16 characters"},
+		{"duplicate name", "api_tokens:\n  - name: a\n    token: \"aaaaaaaaaaaaaaaa\"\n  - name: a\n    token: \"bbbbbbbbbbbbbbbb\"", "duplicate name"},
+		{"duplicate token", "api_tokens:\n  - name: a\n    token: \"aaaaaaaaaaaaaaaa\"\n  - name: b\n    token: \"aaaaaaaaaaaaaaaa\"", "already used"},
+		{"invalid name", "api_tokens:\n  - name: \"branch office\"\n    token: \"aaaaaaaaaaaaaaaa\"", "invalid name"},
+		{"invalid network", "api_tokens:\n  - name: a\n    token: \"aaaaaaaaaaaaaaaa\"\n    networks: [\"10.1.0.0\"]", "invalid network"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadTestConfig(t, tt.settings)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("expected valid config, got %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected %q error, got %v", tt.wantErr, err)
+			}
+			if tt.name == "scoped and global" && cfg.APITokens[1].Token != "branch-secret-0123456789" {
+				t.Errorf("expected env expansion, got %q", cfg.APITokens[1].Token)
+			}
+		})
+	}
+}
+
+// TestAPIUsers validates basic-auth usernames, passwords, networks and the namespace shared with tokens
+func TestAPIUsers(t *testing.T) {
+	t.Setenv("NETSCAN_OPS_PASSWORD", "ops-password-0123456789")
+	tests := []struct {
+		name     string
+		settings string
+		wantErr  string
+	}{
+		{"scoped user", "api_users:\n  - username: ops\n    password: \"${NETSCAN_OPS_PASSWORD}\"\n    networks: [\"10.1.0.0/16\"]", ""},
+		{"short password", "api_users:\n  - username: ops\n    password: \"short\"", "at least 16 characters"},
+		{"invalid username", "api_users:\n  - username: \"ops:team\"\n    password: \"aaaaaaaaaaaaaaaa\"", "invalid username"},
+		{"duplicate username", "api_users:\n  - username: ops\n    password: \"aaaaaaaaaaaaaaaa\"\n  - username: ops\n    password: \"bbbbbbbbbbbbbbbb\"", "duplicate name"},
+		{"clashes with token", "api_tokens:\n  - name: ops\n    token: \"aaaaaaaaaaaaaaaa\"\napi_users:\n  - username: ops\n    password: \"bbbbbbbbbbbbbbbb\"", "duplicate name"},
+		{"invalid network", "api_users:\n  - username: ops\n    password: \"aaaaaaaaaaaaaaaa\"\n    networks: [\"10.1.0.0\"]", "invalid network"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadTestConfig(t, tt.settings)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("expected valid config, got %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected %q error, got %v", tt.wantErr, err)
+			}
+			if tt.name == "scoped user" && cfg.APIUsers[0].Password != "ops-password-0123456789" {
+				t.Errorf("expected env expansion, got %q", cfg.APIUsers[0].Password)
+			}
+		})
+	}
+}
+
+// TestAPIClientCerts validates client certificate names, common names and networks
+func TestAPIClientCerts(t *testing.T) {
+	tokens := []APITokenConfig{{Name: "noc", Token: "noc-token-0123456789"}}
+	users := []APIUserConfig{{Username: "ops", Password: "ops-password-0123456789"}}
+	tests := []struct {
+		name    string
+		certs   []APIClientCertConfig
+		wantErr string
+	}{
+		{"scoped certificate", []APIClientCertConfig{{Name: "branch", CommonName: "vienna.example.com", Networks: []string{"10.1.0.0/16"}}}, ""},
+		{"invalid name", []APIClientCertConfig{{Name: "branch office", CommonName: "vienna.example.com"}}, "invalid name"},
+		{"clashes with token", []APIClientCertConfig{{Name: "noc", CommonName: "noc.example.com"}}, "duplicate name"},
+		{"clashes with user", []APIClientCertConfig{{Name: "ops", CommonName: "ops.example.com"}}, "duplicate name"},
+		{"missing common name", []APIClientCertConfig{{Name: "branch"}}, "common_name is required"},
+		{"duplicate common name", []APIClientCertConfig{{Name: "a", CommonName: "x.example.com"}, {Name: "b", CommonName: "x.example.com"}}, "already used"},
+		{"invalid network", []APIClientCertConfig{{Name: "branch", CommonName: "vienna.example.com", Networks: []string{"10.1.0.0"}}}, "invalid network"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAPIClientCerts(tt.certs, tokens, users)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("expected valid certificates, got %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected %q error, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestARPListenInterfaces validates the passive ARP listener interface list
+func TestARPListenInterfaces(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		wantErr  string
+	}{
+		{name: "disabled by default", settings: ""},
+		{name: "interfaces", settings: "arp_listen_interfaces: [\"eth0\", \"eth1.100\"]\n"},
+		{name: "duplicate", settings: "arp_listen_interfaces: [\"eth0\", \"eth0\"]\n", wantErr: "duplicate interface"},
+		{name: "empty name", settings: "arp_listen_interfaces: [\"\"]\n", wantErr: "invalid interface name"},
+		{name: "too long", settings: "arp_listen_interfaces: [\"averyveryverylongname\"]\n", wantErr: "invalid interface name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), "config.yml", rollupConfig(tt.settings, ""))
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			_, err = ValidateScanConfig(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestAutoTuneConfig validates auto_tune defaults around the configured worker counts and the bounds checks
+func TestAutoTuneConfig(t *testing.T) {
+	cfg, err := loadTestConfig(t, "icmp_workers: 64\nsnmp_workers: 32\nauto_tune:\n  enabled: true")
+	if err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+	at := cfg.AutoTune
+	if at.Interval != 30*time.Second || at.MinIcmpWorkers != 8 || at.MaxIcmpWorkers != 256 || at.MinSnmpWorkers != 4 || at.MaxSnmpWorkers != 128 {
+		t.Errorf("unexpected worker defaults %+v", at)
+	}
+	if at.MinRateFactor != 0.25 || at.MaxRateFactor != 2 || at.MaxLossRate != 0.05 || at.MaxCPUPercent != 80 || at.MaxMemoryPercent != 90 {
+		t.Errorf("unexpected threshold defaults %+v", at)
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(*AutoTuneConfig)
+		wantErr string
+	}{
+		{"disabled ignores bounds", func(c *AutoTuneConfig) { c.Enabled = false; c.MaxIcmpWorkers = 1 }, ""},
+		{"short interval", func(c *AutoTuneConfig) { c.Interval = time.Second }, "auto_tune.interval"},
+		{"icmp max below configured", func(c *AutoTuneConfig) { c.MaxIcmpWorkers = 32 }, "max_icmp_workers"},
+		{"icmp min above configured", func(c *AutoTuneConfig) { c.MinIcmpWorkers = 128 }, "min_icmp_workers"},
+		{"snmp max too high", func(c *AutoTuneConfig) { c.MaxSnmpWorkers = 1001 }, "max_snmp_workers"},
+		{"rate factor above 1", func(c *AutoTuneConfig) { c.MinRateFactor = 1.5 }, "min_rate_factor"},
+		{"rate factor too high", func(c *AutoTuneConfig) { c.MaxRateFactor = 20 }, "max_rate_factor"},
+		{"loss rate", func(c *AutoTuneConfig) { c.MaxLossRate = 1 }, "max_loss_rate"},
+		{"cpu percent", func(c *AutoTuneConfig) { c.MaxCPUPercent = 150 }, "max_cpu_percent"},
+		{"memory percent", func(c *AutoTuneConfig) { c.MaxMemoryPercent = -1 }, "max_memory_percent"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := at
+			tt.mutate(&c)
+			err := validateAutoTune(&c, cfg.IcmpWorkers, cfg.SnmpWorkers)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
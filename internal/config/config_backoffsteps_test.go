@@ -0,0 +1,47 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestPingBackoffSteps validates the escalating backoff steps, their reset period and backoff_file
+func TestPingBackoffSteps(t *testing.T) {
+	tests := []struct {
+		name      string
+		setting   string
+		wantSteps int
+		wantReset time.Duration
+		wantErr   string
+	}{
+		{"defaults", "", 0, 24 * time.Hour, ""},
+		{"ladder", `ping_backoff_steps: ["1m", "5m", "30m", "4h"]`, 4, 24 * time.Hour, ""},
+		{"persisted", "ping_backoff_steps: [\"1m\", \"5m\"]\nping_backoff_reset: \"12h\"\nbackoff_file: \"/tmp/backoff.json\"", 2, 12 * time.Hour, ""},
+		{"half-open before first step", "ping_backoff_steps: [\"1m\", \"5m\"]\nping_half_open_after: \"30s\"", 2, 24 * time.Hour, ""},
+		{"half-open after first step", "ping_backoff_steps: [\"1m\", \"5m\"]\nping_half_open_after: \"2m\"", 2, 24 * time.Hour, "ping_half_open_after"},
+		{"step too short", `ping_backoff_steps: ["30s", "5m"]`, 2, 24 * time.Hour, "ping_backoff_steps[0]"},
+		{"decreasing", `ping_backoff_steps: ["5m", "1m"]`, 2, 24 * time.Hour, "must not decrease"},
+		{"reset too short", "ping_backoff_steps: [\"1m\"]\nping_backoff_reset: \"10m\"", 1, 10 * time.Minute, "ping_backoff_reset"},
+		{"file without steps", `backoff_file: "/tmp/backoff.json"`, 0, 24 * time.Hour, "backoff_file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadTestConfig(t, tt.setting)
+			if len(cfg.PingBackoffSteps) != tt.wantSteps || cfg.PingBackoffReset != tt.wantReset {
+				t.Errorf("expected %d steps and reset %v, got %v and %v", tt.wantSteps, tt.wantReset, cfg.PingBackoffSteps, cfg.PingBackoffReset)
+			}
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("expected valid config, got %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected %q error, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	path := writeFile(t, t.TempDir(), "config.yml", testConfig(`ping_backoff_steps: ["1m", "soon"]`))
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "ping_backoff_steps[1]") {
+		t.Errorf("expected an invalid step error, got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestCheckConfigCollectsAll validates that CheckConfig reports every error and warning, not just the first
+func TestCheckConfigCollectsAll(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "config.yml", testConfig(`icmp_workers: 5000
+ping_rate_limit: 64
+ping_burst_limit: 10
+`))
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	cfg.SNMP.Port = 70000
+
+	result := CheckConfig(cfg)
+	if result.OK() {
+		t.Fatal("expected errors")
+	}
+	if len(result.Errors) != 2 {
+		t.Errorf("expected 2 errors, got %d: %v", len(result.Errors), result.Errors)
+	}
+	if len(result.Warnings) != 2 {
+		t.Errorf("expected 2 warnings (burst limit, public community), got %d: %v", len(result.Warnings), result.Warnings)
+	}
+
+	// ValidateConfig still returns the first error
+	if _, err := ValidateConfig(cfg); err == nil || err.Error() != result.Errors[0].Error() {
+		t.Errorf("expected ValidateConfig to return %v, got %v", result.Errors[0], err)
+	}
+}
+
+// TestCheckConfigStrict validates that strict_validation turns every warning into an error
+func TestCheckConfigStrict(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "config.yml", testConfig(`strict_validation: true
+ping_rate_limit: 64
+ping_burst_limit: 10
+`))
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	result := CheckConfig(cfg)
+	if len(result.Warnings) != 0 {
+		t.Errorf("expected no warnings under strict_validation, got %v", result.Warnings)
+	}
+	strict := 0
+	for _, err := range result.Errors {
+		if strings.HasPrefix(err.Error(), "strict_validation: ") {
+			strict++
+		}
+	}
+	// Burst limit and public community warnings plus the localhost InfluxDB URL
+	if strict != 3 {
+		t.Errorf("expected 3 strict_validation errors, got %v", result.Errors)
+	}
+}
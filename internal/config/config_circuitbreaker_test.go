@@ -166,6 +166,7 @@ func TestValidateCircuitBreakerParams(t *testing.T) {
 				PingTimeout:              3 * time.Second,
 				PingRateLimit:            64.0,
 				PingBurstLimit:           256,
+				PingWorkers:              256,
 				PingMaxConsecutiveFails:  tt.maxFails,
 				PingBackoffDuration:      tt.backoff,
 				SNMPInterval:             1 * time.Hour,
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDeviceClassificationBuiltinRules verifies common devices are classified by the built-in rules
+func TestDeviceClassificationBuiltinRules(t *testing.T) {
+	var c DeviceClassificationConfig
+	tests := []struct {
+		sysDescr, sysObjectID string
+		ports                 []int
+		want                  string
+	}{
+		{"HP ETHERNET MULTI-ENVIRONMENT,ROM none,JETDIRECT,JD153", "", nil, DeviceTypePrinter},
+		{"", "1.3.6.1.4.1.11.2.3.9.1", nil, DeviceTypePrinter},
+		{"", "", []int{22, 9100}, DeviceTypePrinter},
+		{"Cisco IOS Software, Catalyst 4500 L3 Switch Software", "1.3.6.1.4.1.9.1.1208", nil, DeviceTypeSwitch},
+		{"RouterOS CCR1036-8G-2S+", "", nil, DeviceTypeRouter},
+		{"", "1.3.6.1.4.1.14988.1", nil, DeviceTypeRouter},
+		{"FortiGate-60F v7.2.5", "", nil, DeviceTypeFirewall},
+		{"APC Web/SNMP Management Card (MB:v4.1.0 PF:v6.8.2)", "1.3.6.1.4.1.318.1.3.27", nil, DeviceTypeUPS},
+		{"Linux nas01 4.4.302+ #72806 SMP synology", "", nil, DeviceTypeStorage},
+		{"Linux web01 5.15.0-91-generic #101-Ubuntu SMP x86_64", "1.3.6.1.4.1.8072.3.2.10", nil, DeviceTypeServer},
+		{"", "", []int{554}, DeviceTypeCamera},
+		{"", "", nil, ""},
+		{"Some unknown appliance", "1.3.6.1.4.1.99999.1", []int{22}, ""},
+	}
+	for _, tt := range tests {
+		if got := c.Classify(tt.sysDescr, tt.sysObjectID, tt.ports); got != tt.want {
+			t.Errorf("Classify(%q, %q, %v) = %q, want %q", tt.sysDescr, tt.sysObjectID, tt.ports, got, tt.want)
+		}
+	}
+}
+
+// TestDeviceClassificationUserRules verifies user rules win over built-in ones and all criteria of a rule must match
+func TestDeviceClassificationUserRules(t *testing.T) {
+	c := DeviceClassificationConfig{Rules: []DeviceTypeRule{
+		{Type: "pdu", SysDescr: "(?i)linux", Ports: []int{8443}},
+		{Type: "thin_client", SysObjectID: `^1\.3\.6\.1\.4\.1\.8072\.`},
+	}}
+	if err := compileDeviceTypeRules(c.Rules); err != nil {
+		t.Fatalf("rules should compile: %v", err)
+	}
+
+	if got := c.Classify("Linux pdu-a1", "", []int{8443}); got != "pdu" {
+		t.Errorf("expected pdu when sysDescr and port match, got %q", got)
+	}
+	if got := c.Classify("Linux pdu-a1", "", nil); got != DeviceTypeServer {
+		t.Errorf("expected the built-in server rule without port 8443, got %q", got)
+	}
+	if got := c.Classify("Linux tc-17", "1.3.6.1.4.1.8072.3.2.10", nil); got != "thin_client" {
+		t.Errorf("expected the user rule to win over the built-in net-snmp rule, got %q", got)
+	}
+
+	c.DisableBuiltinRules = true
+	if got := c.Classify("Cisco Catalyst switch", "", nil); got != "" {
+		t.Errorf("expected no type with built-in rules disabled, got %q", got)
+	}
+	if got := c.FingerprintPorts(); !slices.Equal(got, []int{8443}) {
+		t.Errorf("expected only user rule ports with built-in rules disabled, got %v", got)
+	}
+	c.DisableBuiltinRules = false
+	if got := c.FingerprintPorts(); !slices.Equal(got, []int{554, 3389, 8443, 9100}) {
+		t.Errorf("expected sorted user and built-in ports, got %v", got)
+	}
+	if got := c.ProbeTimeout(); got != DefaultClassificationPortTimeout {
+		t.Errorf("expected default port timeout, got %v", got)
+	}
+}
+
+// TestDeviceClassificationValidation verifies invalid rules are rejected and unusable port rules warned about
+func TestDeviceClassificationValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  DeviceClassificationConfig
+		wantErr string
+	}{
+		{"valid", DeviceClassificationConfig{Rules: []DeviceTypeRule{{Type: "pdu", SysDescr: "PDU"}}}, ""},
+		{"invalid type", DeviceClassificationConfig{Rules: []DeviceTypeRule{{Type: "a b", SysDescr: "x"}}}, "invalid type"},
+		{"no criteria", DeviceClassificationConfig{Rules: []DeviceTypeRule{{Type: "pdu"}}}, "at least one"},
+		{"bad regex", DeviceClassificationConfig{Rules: []DeviceTypeRule{{Type: "pdu", SysObjectID: "("}}}, "invalid sys_object_id"},
+		{"bad port", DeviceClassificationConfig{Rules: []DeviceTypeRule{{Type: "pdu", Ports: []int{70000}}}}, "port must be"},
+		{"bad timeout", DeviceClassificationConfig{PortTimeout: time.Minute}, "port_timeout"},
+	}
+	for _, tt := range tests {
+		err := validateDeviceClassification(tt.config)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+
+	portRule := DeviceClassificationConfig{Rules: []DeviceTypeRule{{Type: "pdu", Ports: []int{8443}}}}
+	if deviceClassificationWarning(portRule) == "" {
+		t.Error("expected a warning for a port rule without probe_ports")
+	}
+	portRule.ProbePorts = true
+	if warning := deviceClassificationWarning(portRule); warning != "" {
+		t.Errorf("expected no warning with probe_ports, got %q", warning)
+	}
+}
+
+// TestLoadConfigDeviceClassification verifies the device_classification block is parsed and its rules compiled
+func TestLoadConfigDeviceClassification(t *testing.T) {
+	f, err := os.CreateTemp("", "test-config-*.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	configYAML := `
+networks:
+  - "192.168.1.0/24"
+icmp_discovery_interval: "5m"
+ping_interval: "2s"
+snmp:
+  community: "test-community-123"
+  port: 161
+device_classification:
+  probe_ports: true
+  port_timeout: "500ms"
+  rules:
+    - type: "pdu"
+      sys_descr: "(?i)sentry"
+      ports: [443]
+influxdb:
+  url: "http://localhost:8086"
+  token: "test-token"
+  org: "test-org"
+  bucket: "test-bucket"
+`
+	if _, err := f.WriteString(configYAML); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	cfg, err := LoadConfig(f.Name())
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if _, err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+	c := cfg.DeviceClassification
+	if !c.ProbePorts || c.ProbeTimeout() != 500*time.Millisecond || len(c.Rules) != 1 {
+		t.Fatalf("unexpected device_classification: %+v", c)
+	}
+	if c.Rules[0].sysDescrRE == nil {
+		t.Error("expected the rule's sys_descr to be compiled at load time")
+	}
+	if got := c.Classify("Sentry Switched CDU", "", []int{443}); got != "pdu" {
+		t.Errorf("expected pdu, got %q", got)
+	}
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCompositeChecksConfig validates composite check defaults and rejection of bad definitions
+func TestCompositeChecksConfig(t *testing.T) {
+	valid := `composite_checks:
+  - name: "router_healthy"
+    devices: ["192.168.1.1"]
+    conditions:
+      - type: "icmp"
+      - type: "snmp_value"
+        group: "ifTable"
+        name: "ifOperStatus.3"
+        equals: "1"`
+
+	cfg, err := loadTestConfig(t, valid)
+	if err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+	if cfg.CompositeCheckInterval != 30*time.Second {
+		t.Errorf("expected default interval 30s, got %v", cfg.CompositeCheckInterval)
+	}
+	check := cfg.CompositeChecks[0]
+	if check.Mode != CheckModeAll {
+		t.Errorf("expected default mode all, got %q", check.Mode)
+	}
+	if !check.Matches("192.168.1.1") || check.Matches("192.168.1.2") {
+		t.Error("check should only match its device")
+	}
+	if label := check.Conditions[1].Label(); label != "snmp_value:ifOperStatus.3" {
+		t.Errorf("unexpected condition label %q", label)
+	}
+
+	invalid := []struct {
+		name     string
+		settings string
+		wantErr  string
+	}{
+		{"bad name", "ping_interval: \"2s\"\ncomposite_checks:\n  - name: \"router healthy\"\n    conditions:\n      - type: \"icmp\"", "invalid name"},
+		{"bad mode", "ping_interval: \"2s\"\ncomposite_checks:\n  - name: \"r\"\n    mode: \"most\"\n    conditions:\n      - type: \"icmp\"", "mode must be"},
+		{"no conditions", "ping_interval: \"2s\"\ncomposite_checks:\n  - name: \"r\"", "at least one condition"},
+		{"bad type", "ping_interval: \"2s\"\ncomposite_checks:\n  - name: \"r\"\n    conditions:\n      - type: \"http\"", "unsupported condition type"},
+		{"value without name", "ping_interval: \"2s\"\ncomposite_checks:\n  - name: \"r\"\n    conditions:\n      - type: \"snmp_value\"\n        equals: \"1\"", "requires a name"},
+		{"bad network", "ping_interval: \"2s\"\ncomposite_checks:\n  - name: \"r\"\n    networks: [\"10.0.0.0/33\"]\n    conditions:\n      - type: \"icmp\"", "invalid network"},
+		{"short interval", "ping_interval: \"2s\"\ncomposite_check_interval: \"1s\"\ncomposite_checks:\n  - name: \"r\"\n    conditions:\n      - type: \"icmp\"", "at least 5 seconds"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadTestConfig(t, tt.settings); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected %q error, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
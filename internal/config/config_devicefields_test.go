@@ -0,0 +1,165 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestLoadConfigDeviceFields validates device field rules are parsed, defaulted and compiled
+func TestLoadConfigDeviceFields(t *testing.T) {
+	f, err := os.CreateTemp("", "test-config-*.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	configYAML := `
+networks:
+  - "192.168.1.0/24"
+icmp_discovery_interval: "5m"
+ping_interval: "2s"
+snmp:
+  community: "test-community-123"
+  port: 161
+  device_fields:
+    - name: "firmware"
+      regex: "Version ([^,]+)"
+    - name: "site"
+      source: "sysName"
+      regex: "^([a-z]+)-"
+influxdb:
+  url: "http://localhost:8086"
+  token: "test-token"
+  org: "test-org"
+  bucket: "test-bucket"
+`
+	if _, err := f.WriteString(configYAML); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	cfg, err := LoadConfig(f.Name())
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if _, err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+
+	if len(cfg.SNMP.DeviceFields) != 2 {
+		t.Fatalf("expected 2 device field rules, got %d", len(cfg.SNMP.DeviceFields))
+	}
+	if cfg.SNMP.DeviceFields[0].Source != FieldSourceSysDescr {
+		t.Errorf("expected default source sysDescr, got %s", cfg.SNMP.DeviceFields[0].Source)
+	}
+
+	fields := DeriveDeviceFields(cfg.SNMP.DeviceFields, "ams-core-sw1",
+		"Cisco IOS Software, C3750E Software, Version 15.2(4)E10, RELEASE SOFTWARE (fc2)")
+	if fields["firmware"] != "15.2(4)E10" {
+		t.Errorf("expected firmware 15.2(4)E10, got %q", fields["firmware"])
+	}
+	if fields["site"] != "ams" {
+		t.Errorf("expected site ams, got %q", fields["site"])
+	}
+}
+
+// TestLoadConfigDeviceFieldsInvalidRegex validates a broken expression is rejected at load time
+func TestLoadConfigDeviceFieldsInvalidRegex(t *testing.T) {
+	f, err := os.CreateTemp("", "test-config-*.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	configYAML := `
+networks:
+  - "192.168.1.0/24"
+icmp_discovery_interval: "5m"
+ping_interval: "2s"
+snmp:
+  community: "public"
+  device_fields:
+    - name: "firmware"
+      regex: "Version ([^,]+"
+influxdb:
+  url: "http://localhost:8086"
+  token: "test-token"
+  org: "test-org"
+  bucket: "test-bucket"
+`
+	if _, err := f.WriteString(configYAML); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if _, err := LoadConfig(f.Name()); err == nil || !strings.Contains(err.Error(), "device_fields") {
+		t.Errorf("expected device_fields regex error, got %v", err)
+	}
+}
+
+// TestDeriveDeviceFields validates value templates, non-matching rules and first-match-wins ordering
+func TestDeriveDeviceFields(t *testing.T) {
+	sysDescr := "Linux fw01 5.15.0-91-generic #101-Ubuntu SMP x86_64"
+
+	tests := []struct {
+		name  string
+		rules []DeviceFieldRule
+		want  map[string]string
+	}{
+		{"whole match without groups", []DeviceFieldRule{{Name: "arch", Regex: "x86_64|aarch64"}}, map[string]string{"arch": "x86_64"}},
+		{"named group template", []DeviceFieldRule{{Name: "kernel", Regex: `(?P<major>\d+)\.(?P<minor>\d+)`, Value: "${major}.${minor}"}}, map[string]string{"kernel": "5.15"}},
+		{"literal template", []DeviceFieldRule{{Name: "os", Regex: "^Linux", Value: "linux"}}, map[string]string{"os": "linux"}},
+		{"no match", []DeviceFieldRule{{Name: "firmware", Regex: "Version ([^,]+)"}}, nil},
+		{"first match wins", []DeviceFieldRule{
+			{Name: "vendor", Regex: "^Cisco", Value: "cisco"},
+			{Name: "vendor", Regex: "^Linux", Value: "linux"},
+			{Name: "vendor", Regex: "Ubuntu", Value: "ubuntu"},
+		}, map[string]string{"vendor": "linux"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DeriveDeviceFields(tt.rules, "fw01", sysDescr)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("field %s: expected %q, got %q", k, v, got[k])
+				}
+			}
+		})
+	}
+}
+
+// TestValidateDeviceFieldRules validates rejection of malformed device field rules
+func TestValidateDeviceFieldRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []DeviceFieldRule
+		wantErr string
+	}{
+		{"valid", []DeviceFieldRule{{Name: "firmware", Regex: "Version (\\S+)"}}, ""},
+		{"invalid name", []DeviceFieldRule{{Name: "fw version", Regex: "x"}}, "invalid field name"},
+		{"reserved name", []DeviceFieldRule{{Name: "hostname", Regex: "x"}}, "reserved"},
+		{"bad source", []DeviceFieldRule{{Name: "fw", Source: "sysLocation", Regex: "x"}}, "unsupported source"},
+		{"missing regex", []DeviceFieldRule{{Name: "fw"}}, "regex is required"},
+		{"bad regex", []DeviceFieldRule{{Name: "fw", Regex: "(unclosed"}}, "invalid regex"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDeviceFieldRules(tt.rules)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
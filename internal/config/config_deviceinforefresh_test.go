@@ -0,0 +1,36 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDeviceInfoRefresh validates the device_info rewrite interval default and range
+func TestDeviceInfoRefresh(t *testing.T) {
+	tests := []struct {
+		name    string
+		setting string
+		want    time.Duration
+		wantErr string
+	}{
+		{"default one day", "", 24 * time.Hour, ""},
+		{"custom", `device_info_refresh: "6h"`, 6 * time.Hour, ""},
+		{"every poll", `device_info_refresh: "0s"`, 0, ""},
+		{"negative", `device_info_refresh: "-1h"`, -time.Hour, "device_info_refresh"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadTestConfig(t, tt.setting)
+			if cfg.DeviceInfoRefresh != tt.want {
+				t.Errorf("expected device_info_refresh %v, got %v", tt.want, cfg.DeviceInfoRefresh)
+			}
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("expected valid config, got %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected %q error, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
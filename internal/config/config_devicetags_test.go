@@ -0,0 +1,107 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestDeviceTagger verifies rules match by network, address range and hostname and later rules override earlier keys
+func TestDeviceTagger(t *testing.T) {
+	cfg := &Config{DeviceTags: []DeviceTagRule{
+		{Networks: []string{"10.1.0.0/16"}, Tags: map[string]string{"site": "warsaw", "tier": "access"}},
+		{IPRanges: []string{"10.1.0.1-10.1.0.20"}, Hostname: "^core-", Tags: map[string]string{"tier": "core"}},
+		{Networks: []string{"10.2.0.0/24"}, IPRanges: []string{"10.3.0.5 - 10.3.0.9"}, Tags: map[string]string{"site": "krakow"}},
+	}}
+	if err := compileDeviceTagRules(cfg.DeviceTags); err != nil {
+		t.Fatalf("rules should compile: %v", err)
+	}
+	tag := cfg.DeviceTagger()
+
+	tests := []struct {
+		ip, hostname string
+		want         map[string]string
+	}{
+		{"10.1.0.5", "core-sw1", map[string]string{"site": "warsaw", "tier": "core"}},
+		{"10.1.0.5", "access-sw1", map[string]string{"site": "warsaw", "tier": "access"}},
+		{"10.1.0.50", "core-sw2", map[string]string{"site": "warsaw", "tier": "access"}},
+		{"10.2.0.1", "", map[string]string{"site": "krakow"}},
+		{"10.3.0.9", "", map[string]string{"site": "krakow"}},
+		{"10.3.0.10", "", nil},
+		{"invalid", "core-sw1", nil},
+	}
+	for _, tt := range tests {
+		got := tag(tt.ip, tt.hostname)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s (%s): expected %v, got %v", tt.ip, tt.hostname, tt.want, got)
+			continue
+		}
+		for key, value := range tt.want {
+			if got[key] != value {
+				t.Errorf("%s (%s): expected %v, got %v", tt.ip, tt.hostname, tt.want, got)
+			}
+		}
+	}
+
+	if (&Config{}).DeviceTagger() != nil {
+		t.Error("expected no tagger without device_tags")
+	}
+}
+
+// TestDeviceTagsValidation verifies invalid rules and netscan's own tag keys are rejected
+func TestDeviceTagsValidation(t *testing.T) {
+	tags := map[string]string{"tier": "core"}
+	tests := []struct {
+		name    string
+		rule    DeviceTagRule
+		sites   []SiteConfig
+		wantErr string
+	}{
+		{"valid", DeviceTagRule{Networks: []string{"10.0.0.0/8"}, Tags: tags}, nil, ""},
+		{"site without sites", DeviceTagRule{Hostname: "^fw", Tags: map[string]string{"site": "warsaw"}}, nil, ""},
+		{"site with sites", DeviceTagRule{Hostname: "^fw", Tags: map[string]string{"site": "warsaw"}}, []SiteConfig{{Name: "fra1"}}, "written by netscan"},
+		{"reserved key", DeviceTagRule{Hostname: "^fw", Tags: map[string]string{"network": "x"}}, nil, "written by netscan"},
+		{"no criteria", DeviceTagRule{Tags: tags}, nil, "at least one of"},
+		{"no tags", DeviceTagRule{Hostname: "^fw"}, nil, "at least one tag"},
+		{"bad network", DeviceTagRule{Networks: []string{"10.0.0.0"}, Tags: tags}, nil, "invalid network"},
+		{"bad range", DeviceTagRule{IPRanges: []string{"10.0.0.9-10.0.0.1"}, Tags: tags}, nil, "ends before it starts"},
+		{"mixed range", DeviceTagRule{IPRanges: []string{"10.0.0.1-fe80::1"}, Tags: tags}, nil, "mixes IPv4 and IPv6"},
+		{"bad hostname", DeviceTagRule{Hostname: "(", Tags: tags}, nil, "invalid hostname"},
+		{"bad key", DeviceTagRule{Hostname: "^fw", Tags: map[string]string{"a b": "x"}}, nil, "invalid tag key"},
+		{"empty value", DeviceTagRule{Hostname: "^fw", Tags: map[string]string{"tier": ""}}, nil, "must not be empty"},
+	}
+	for _, tt := range tests {
+		err := validateDeviceTags([]DeviceTagRule{tt.rule}, tt.sites)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
+
+// TestLoadConfigDeviceTags verifies the device_tags block is parsed and its expressions compiled
+func TestLoadConfigDeviceTags(t *testing.T) {
+	settings := `device_tags:
+  - networks: ["192.168.1.0/24"]
+    hostname: "^core-"
+    tags:
+      site: warsaw
+      tier: core
+`
+	influx := "influxdb:\n  url: \"http://influx.example.com:8086\"\n  token: \"t\"\n  org: \"o\"\n  bucket: \"b\"\n"
+	path := writeFile(t, t.TempDir(), "config.yml", rollupConfig(settings, influx))
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if _, err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+	if len(cfg.DeviceTags) != 1 || cfg.DeviceTags[0].hostnameRE == nil {
+		t.Fatalf("expected one compiled rule, got %+v", cfg.DeviceTags)
+	}
+	if got := cfg.DeviceTagger()("192.168.1.1", "core-sw1"); got["site"] != "warsaw" || got["tier"] != "core" {
+		t.Errorf("expected site and tier tags, got %v", got)
+	}
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestValidateDiscoveryMethods validates discovery_methods names and duplicates
+func TestValidateDiscoveryMethods(t *testing.T) {
+	tests := []struct {
+		name    string
+		methods []string
+		wantErr bool
+	}{
+		{"default", []string{"sweep"}, false},
+		{"sweep and snmp", []string{"sweep", "snmp"}, false},
+		{"plugin name", []string{"cloud_inventory"}, false},
+		{"empty name", []string{""}, true},
+		{"invalid name", []string{"m-dns"}, true},
+		{"duplicate", []string{"sweep", "snmp", "sweep"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDiscoveryMethods(tt.methods)
+			if tt.wantErr && err == nil {
+				t.Error("expected validation error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+// TestSSDPTimeout validates the ssdp_timeout default and bounds
+func TestSSDPTimeout(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "config.yml", rollupConfig("", ""))
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if cfg.SSDPTimeout != 3*time.Second || len(cfg.DiscoveryMethods) != 1 || cfg.DiscoveryMethods[0] != "sweep" {
+		t.Errorf("expected ssdp_timeout 3s and discovery_methods [sweep], got %v %v", cfg.SSDPTimeout, cfg.DiscoveryMethods)
+	}
+
+	path = writeFile(t, t.TempDir(), "config.yml", rollupConfig("ssdp_timeout: \"45s\"\n", ""))
+	if cfg, err = LoadConfig(path); err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if _, err := ValidateScanConfig(cfg); err == nil || !strings.Contains(err.Error(), "ssdp_timeout must be between") {
+		t.Errorf("expected ssdp_timeout above 30s to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"testing"
+)
+
+// TestExclusionsContains validates CIDR and single-host exclusions
+func TestExclusionsContains(t *testing.T) {
+	cfg := &Config{
+		ExcludeNetworks: []string{"192.168.1.0/28"},
+		ExcludeIPs:      []string{"192.168.1.200", "10.0.0.5"},
+	}
+	ex := cfg.Exclusions()
+
+	cases := map[string]bool{
+		"192.168.1.1":   true,
+		"192.168.1.15":  true,
+		"192.168.1.16":  false,
+		"192.168.1.200": true,
+		"10.0.0.5":      true,
+		"10.0.0.6":      false,
+		"garbage":       false,
+	}
+	for ip, want := range cases {
+		if got := ex.Contains(ip); got != want {
+			t.Errorf("Contains(%s) = %v, want %v", ip, got, want)
+		}
+	}
+
+	var none *Exclusions
+	if none.Contains("192.168.1.1") {
+		t.Error("expected nil exclusions to exclude nothing")
+	}
+	if (&Config{}).Exclusions() != nil {
+		t.Error("expected nil exclusions when nothing is configured")
+	}
+}
+
+// TestValidateExclusions validates malformed exclusion entries are rejected
+func TestValidateExclusions(t *testing.T) {
+	if err := validateExclusions([]string{"10.0.0.0/24"}, []string{"10.0.1.1"}); err != nil {
+		t.Errorf("expected valid exclusions, got %v", err)
+	}
+	if err := validateExclusions([]string{"10.0.0.0/33"}, nil); err == nil {
+		t.Error("expected error for invalid exclude_networks entry")
+	}
+	if err := validateExclusions(nil, []string{"10.0.0"}); err == nil {
+		t.Error("expected error for invalid exclude_ips entry")
+	}
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestPingHalfOpen validates the half-open test ping delay and the escalated backoff cap
+func TestPingHalfOpen(t *testing.T) {
+	tests := []struct {
+		name     string
+		setting  string
+		wantOpen time.Duration
+		wantMax  time.Duration
+		wantErr  string
+	}{
+		{"defaults", "", 0, time.Hour, ""},
+		{"enabled", `ping_half_open_after: "1m"`, time.Minute, time.Hour, ""},
+		{"custom cap", "ping_half_open_after: \"30s\"\nping_backoff_max: \"2h\"", 30 * time.Second, 2 * time.Hour, ""},
+		{"cap follows long backoff", `ping_backoff_duration: "2h"`, 0, 2 * time.Hour, ""},
+		{"below ping_interval", `ping_half_open_after: "1s"`, time.Second, time.Hour, "ping_half_open_after"},
+		{"not shorter than backoff", `ping_half_open_after: "5m"`, 5 * time.Minute, time.Hour, "ping_half_open_after"},
+		{"cap below backoff", `ping_backoff_max: "1m"`, 0, time.Minute, "ping_backoff_max"},
+		{"cap above a day", `ping_backoff_max: "48h"`, 0, 48 * time.Hour, "ping_backoff_max"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadTestConfig(t, tt.setting)
+			if cfg.PingHalfOpenAfter != tt.wantOpen || cfg.PingBackoffMax != tt.wantMax {
+				t.Errorf("expected %v/%v, got %v/%v", tt.wantOpen, tt.wantMax, cfg.PingHalfOpenAfter, cfg.PingBackoffMax)
+			}
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("expected valid config, got %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected %q error, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHealthServerTimeoutDefaults verifies the health server timeouts applied when unset
+func TestHealthServerTimeoutDefaults(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "config.yml", testConfig(""))
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if cfg.HealthReadTimeout != 30*time.Second || cfg.HealthWriteTimeout != time.Minute || cfg.HealthIdleTimeout != 2*time.Minute {
+		t.Errorf("unexpected defaults: read %v, write %v, idle %v", cfg.HealthReadTimeout, cfg.HealthWriteTimeout, cfg.HealthIdleTimeout)
+	}
+	if cfg.HealthTLSCert != "" || cfg.HealthTLSKey != "" {
+		t.Error("expected plain HTTP by default")
+	}
+}
+
+// TestHealthServerValidation validates the timeout ranges and the TLS key pair
+func TestHealthServerValidation(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.pem")
+	tests := []struct {
+		name     string
+		settings string
+		wantErr  string
+		wantWarn string
+	}{
+		{"custom timeouts", "health_read_timeout: \"10s\"\nhealth_write_timeout: \"45s\"\nhealth_idle_timeout: \"5m\"", "", ""},
+		{"read timeout too short", "health_read_timeout: \"500ms\"", "health_read_timeout must be between 1s and 1h", ""},
+		{"idle timeout too long", "health_idle_timeout: \"2h\"", "health_idle_timeout must be between 1s and 1h", ""},
+		{"short write timeout", "health_write_timeout: \"10s\"", "", "health_write_timeout 10s is shorter"},
+		{"cert without key", "health_tls_cert: \"" + missing + "\"", "must be set together", ""},
+		{"unreadable key pair", "health_tls_cert: \"" + missing + "\"\nhealth_tls_key: \"" + missing + "\"", "health_tls_cert/health_tls_key", ""},
+		{"invalid client auth", "health_tls_client_auth: \"always\"", "health_tls_client_auth must be optional or require", ""},
+		{"client CA without TLS", "health_tls_client_ca: \"" + missing + "\"", "requires health_tls_cert", ""},
+		{"client certs without CA", "api_client_certs:\n  - name: noc\n    common_name: noc.example.com", "api_client_certs requires health_tls_client_ca", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), "config.yml", testConfig(tt.settings))
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			warnings, err := ValidateConfig(cfg)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("expected valid config, got %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected %q error, got %v", tt.wantErr, err)
+			}
+			if tt.wantWarn != "" && !strings.Contains(warnings, tt.wantWarn) {
+				t.Errorf("expected %q warning, got %q", tt.wantWarn, warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// testConfig returns a minimal valid config with settings added at the top level
+// ping_interval is "2s" unless settings set it
+func testConfig(settings string) string {
+	if !strings.Contains(settings, "ping_interval:") {
+		settings = "ping_interval: \"2s\"\n" + settings
+	}
+	return `
+networks:
+  - "192.168.1.0/24"
+icmp_discovery_interval: "5m"
+` + settings + `
+snmp:
+  community: "public"
+  port: 161
+  retries: 1
+influxdb:
+  url: "http://localhost:8086"
+  token: "test-token"
+  org: "test-org"
+  bucket: "test-bucket"
+`
+}
+
+// loadTestConfig loads testConfig(settings) and returns it with the ValidateConfig error
+// Load errors fail the test
+func loadTestConfig(t *testing.T, settings string) (*Config, error) {
+	t.Helper()
+	cfg, err := LoadConfig(writeFile(t, t.TempDir(), "config.yml", testConfig(settings)))
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	_, err = ValidateConfig(cfg)
+	return cfg, err
+}
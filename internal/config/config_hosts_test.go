@@ -0,0 +1,111 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// stubLookupHost resolves host names from a fixed table instead of DNS
+func stubLookupHost(records map[string][]string) HostLookup {
+	return func(ctx context.Context, name string) ([]net.IP, error) {
+		addrs, ok := records[name]
+		if !ok {
+			return nil, errors.New("no such host")
+		}
+		ips := make([]net.IP, len(addrs))
+		for i, addr := range addrs {
+			ips[i] = net.ParseIP(addr)
+		}
+		return ips, nil
+	}
+}
+
+// TestIsHostEntry verifies only dotted DNS names are resolved; CIDRs, addresses and typos are not
+func TestIsHostEntry(t *testing.T) {
+	for entry, want := range map[string]bool{
+		"core-sw1.example.com":  true,
+		"core-sw1.example.com.": true,
+		"192.168.1.0/24":        false,
+		"192.168.1.1":           false,
+		"not-a-cidr":            false,
+		"bad_name.example.com":  false,
+		"10.0.0.0/33":           false,
+	} {
+		if got := isHostEntry(entry); got != want {
+			t.Errorf("isHostEntry(%q) = %v, want %v", entry, got, want)
+		}
+	}
+}
+
+// TestNetworkHosts verifies host names in networks become labeled /32 networks and report their problems
+func TestNetworkHosts(t *testing.T) {
+	lookup := stubLookupHost(map[string][]string{
+		"core-sw1.example.com": {"10.0.0.2", "10.0.0.1", "2001:db8::1"},
+		"core-sw2.example.com": {"10.0.0.2", "10.0.0.3"},
+		"v6only.example.com":   {"2001:db8::2"},
+	})
+	cfgText := `
+networks:
+  - "192.168.1.0/24"
+  - "core-sw1.example.com"
+  - network: "core-sw2.example.com"
+    label: "core-b"
+  - "v6only.example.com"
+  - "missing.example.com"
+icmp_discovery_interval: "5m"
+ping_interval: "2s"
+snmp:
+  community: "test-community-123"
+  port: 161
+influxdb:
+  url: "http://localhost:8086"
+  token: "test-token"
+  org: "test-org"
+  bucket: "test-bucket"
+`
+	cfg, err := loadConfig(writeFile(t, t.TempDir(), "config.yml", cfgText), "", lookup)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	want := []string{"192.168.1.0/24", "10.0.0.1/32", "10.0.0.2/32", "10.0.0.3/32"}
+	if !reflect.DeepEqual(cfg.Networks, want) {
+		t.Errorf("expected networks %v, got %v", want, cfg.Networks)
+	}
+	if got := cfg.NetworkLabel("10.0.0.2/32"); got != "core-sw1.example.com" {
+		t.Errorf("expected a shared address to keep the first host's label, got %q", got)
+	}
+	if got := cfg.NetworkLabel("10.0.0.3/32"); got != "core-b" {
+		t.Errorf("expected the inline label, got %q", got)
+	}
+	if len(cfg.NetworkHosts) != 4 || !reflect.DeepEqual(cfg.NetworkHosts[1].Networks, []string{"10.0.0.3/32"}) {
+		t.Errorf("unexpected network hosts %+v", cfg.NetworkHosts)
+	}
+	if cfg.HostResolveInterval.String() != "1h0m0s" {
+		t.Errorf("expected the default host_resolve_interval of 1h, got %v", cfg.HostResolveInterval)
+	}
+
+	result := CheckConfig(cfg)
+	if !result.OK() {
+		t.Fatalf("expected a valid config, got %v", result.Errors)
+	}
+	joined := strings.Join(result.Warnings, "\n")
+	for _, fragment := range []string{`"core-sw1.example.com": IPv6`, `"v6only.example.com" has no IPv4`, `"missing.example.com" could not be resolved`} {
+		if !strings.Contains(joined, fragment) {
+			t.Errorf("expected a warning containing %q, got:\n%s", fragment, joined)
+		}
+	}
+}
+
+// TestNetworkHostsInSites verifies site networks must be CIDRs
+func TestNetworkHostsInSites(t *testing.T) {
+	lookup := stubLookupHost(map[string][]string{"core-sw1.example.com": {"10.0.0.1"}})
+	cfgText := "icmp_discovery_interval: \"5m\"\nping_interval: \"2s\"\nsites:\n  - name: \"fra1\"\n    networks:\n      - \"core-sw1.example.com\"\n"
+	_, err := loadConfig(writeFile(t, t.TempDir(), "config.yml", cfgText), "", lookup)
+	if err == nil || !strings.Contains(err.Error(), "only supported in the top-level networks") {
+		t.Errorf("expected a host name in a site to be rejected, got %v", err)
+	}
+}
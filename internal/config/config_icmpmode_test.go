@@ -0,0 +1,42 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestICMPMode validates the icmp_mode default and accepted values
+func TestICMPMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		want     string
+		wantErr  bool
+	}{
+		{name: "default", settings: "", want: "auto"},
+		{name: "privileged", settings: "icmp_mode: \"privileged\"\n", want: "privileged"},
+		{name: "unprivileged", settings: "icmp_mode: \"unprivileged\"\n", want: "unprivileged"},
+		{name: "invalid", settings: "icmp_mode: \"udp\"\n", want: "udp", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), "config.yml", rollupConfig(tt.settings, ""))
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			if cfg.ICMPMode != tt.want {
+				t.Errorf("expected icmp_mode %q, got %q", tt.want, cfg.ICMPMode)
+			}
+			_, err = ValidateScanConfig(cfg)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "icmp_mode must be") {
+					t.Errorf("expected icmp_mode error, got %v", err)
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestInfluxShutdownTimeout validates the shutdown flush deadline default and range
+func TestInfluxShutdownTimeout(t *testing.T) {
+	base := `
+networks:
+  - "192.168.1.0/24"
+icmp_discovery_interval: "5m"
+ping_interval: "2s"
+snmp:
+  community: "test-community-123"
+  port: 161
+influxdb:
+  url: "http://localhost:8086"
+  token: "test-token"
+  org: "test-org"
+  bucket: "test-bucket"
+`
+	tests := []struct {
+		name    string
+		setting string
+		want    time.Duration
+		wantErr string
+	}{
+		{"default", "", 10 * time.Second, ""},
+		{"custom", "  shutdown_timeout: \"30s\"", 30 * time.Second, ""},
+		{"too short", "  shutdown_timeout: \"100ms\"", 100 * time.Millisecond, "shutdown_timeout"},
+		{"too long", "  shutdown_timeout: \"10m\"", 10 * time.Minute, "shutdown_timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), "config.yml", base+tt.setting+"\n")
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			if cfg.InfluxDB.ShutdownTimeout != tt.want {
+				t.Errorf("expected shutdown_timeout %v, got %v", tt.want, cfg.InfluxDB.ShutdownTimeout)
+			}
+			_, err = ValidateConfig(cfg)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("expected valid config, got %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected %q error, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestInfluxMirrors validates influxdb.mirrors defaults and checks
+func TestInfluxMirrors(t *testing.T) {
+	t.Setenv("CENTRAL_TOKEN", "central-token")
+	base := `
+networks:
+  - "192.168.1.0/24"
+icmp_discovery_interval: "5m"
+ping_interval: "2s"
+snmp:
+  community: "test-community-123"
+  port: 161
+influxdb:
+  url: "http://localhost:8086"
+  token: "test-token"
+  org: "test-org"
+  bucket: "test-bucket"
+  mirrors:
+`
+	tests := []struct {
+		name    string
+		mirrors string
+		wantErr string
+	}{
+		{"mirror", "    - {name: central, url: \"https://influx.example.com\", token: \"${CENTRAL_TOKEN}\", org: hq}\n", ""},
+		{"invalid name", "    - {name: \"central-1\", url: \"https://influx.example.com\", token: t, org: hq}\n", "invalid name"},
+		{"duplicate name", "    - {name: central, url: \"https://a.example.com\", token: t, org: hq}\n    - {name: central, url: \"https://b.example.com\", token: t, org: hq}\n", "duplicate name"},
+		{"invalid url", "    - {name: central, url: \"influx.example.com\", token: t, org: hq}\n", "url validation failed"},
+		{"missing token", "    - {name: central, url: \"https://influx.example.com\", org: hq}\n", "mirrors[central].token is required"},
+		{"same bucket as primary", "    - {name: central, url: \"http://localhost:8086\", token: t, org: hq}\n", "already written by influxdb"},
+		{"other bucket on primary", "    - {name: archive, url: \"http://localhost:8086\", token: t, org: hq, bucket: archive}\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), "config.yml", base+tt.mirrors)
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			_, err = ValidateConfig(cfg)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("expected valid config, got %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected %q error, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	path := writeFile(t, t.TempDir(), "config.yml", base+tests[0].mirrors)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	mirror := cfg.InfluxDB.Mirrors[0]
+	if mirror.Token != "central-token" || mirror.Bucket != "test-bucket" || mirror.HealthBucket != "health" {
+		t.Errorf("expected expanded token and buckets of the primary, got %+v", mirror)
+	}
+}
+
+// TestInfluxV1 validates the InfluxDB 1.x settings and their target
+func TestInfluxV1(t *testing.T) {
+	base := `
+networks:
+  - "192.168.1.0/24"
+icmp_discovery_interval: "5m"
+ping_interval: "2s"
+snmp:
+  community: "test-community-123"
+  port: 161
+influxdb:
+  url: "http://localhost:8086"
+  version: 1
+`
+	tests := []struct {
+		name     string
+		settings string
+		wantErr  string
+	}{
+		{"database with auth", "  username: netscan\n  password: secret\n  database: netscan\n  retention_policy: autogen\n", ""},
+		{"no auth", "  database: netscan\n", ""},
+		{"missing database", "  username: netscan\n  bucket: netscan\n", "influxdb.database is required"},
+		{"password without username", "  password: secret\n  database: netscan\n", "influxdb.password requires influxdb.username"},
+		{"v1 mirror", "  database: netscan\n  mirrors:\n    - {name: old, version: 1, url: \"http://influx1.example.com:8086\"}\n", ""},
+		{"v1 mirror on same database", "  database: netscan\n  mirrors:\n    - {name: old, version: 1, url: \"http://localhost:8086\"}\n", "already written by influxdb"},
+		{"unknown version", "  database: netscan\n  mirrors:\n    - {name: old, version: 3, url: \"http://influx1.example.com:8086\"}\n", "version must be 1 or 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), "config.yml", base+tt.settings)
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			_, err = ValidateConfig(cfg)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("expected valid config, got %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected %q error, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	path := writeFile(t, t.TempDir(), "config.yml", base+tests[0].settings)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	target := cfg.InfluxDB.Target()
+	want := InfluxDBTarget{Version: 1, URL: "http://localhost:8086", Bucket: "netscan", HealthBucket: "health", Username: "netscan", Password: "secret", RetentionPolicy: "autogen"}
+	if target != want {
+		t.Errorf("expected target %+v, got %+v", want, target)
+	}
+}
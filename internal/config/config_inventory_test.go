@@ -0,0 +1,58 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestInventoryReportInterval validates the reconciliation interval default and minimum
+func TestInventoryReportInterval(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		want     time.Duration
+		wantErr  string
+	}{
+		{"default", "ping_interval: \"2s\"\ninventory_file: \"/etc/netscan/expected.csv\"", time.Hour, ""},
+		{"custom", "ping_interval: \"2s\"\ninventory_file: \"expected.csv\"\ninventory_report_interval: \"15m\"", 15 * time.Minute, ""},
+		{"too short", "ping_interval: \"2s\"\ninventory_file: \"expected.csv\"\ninventory_report_interval: \"30s\"", 30 * time.Second, "at least 1 minute"},
+		{"ignored without file", "ping_interval: \"2s\"\ninventory_report_interval: \"30s\"", 30 * time.Second, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadTestConfig(t, tt.settings)
+			if cfg.InventoryReportInterval != tt.want {
+				t.Errorf("expected interval %v, got %v", tt.want, cfg.InventoryReportInterval)
+			}
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("expected valid config, got %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected %q error, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestInventoryExportConfig validates export defaults and rejection of bad settings
+func TestInventoryExportConfig(t *testing.T) {
+	cfg, err := loadTestConfig(t, "inventory_export:\n  dir: \"/var/lib/netscan/exports\"")
+	if err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+	if cfg.InventoryExport.Interval != 24*time.Hour || cfg.InventoryExport.Format != InventoryExportCSV || cfg.InventoryExport.Keep != 0 {
+		t.Errorf("unexpected defaults: %+v", cfg.InventoryExport)
+	}
+
+	invalid := map[string]string{
+		"interval": "inventory_export:\n  dir: \"/tmp/x\"\n  interval: \"10s\"",
+		"format":   "inventory_export:\n  dir: \"/tmp/x\"\n  format: \"xml\"",
+		"keep":     "inventory_export:\n  dir: \"/tmp/x\"\n  keep: -1",
+	}
+	for name, settings := range invalid {
+		if _, err := loadTestConfig(t, settings); err == nil || !strings.Contains(err.Error(), "inventory_export."+name) {
+			t.Errorf("%s: expected inventory_export.%s error, got %v", name, name, err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLatencyThresholdsConfig validates latency thresholds, their levels and rejection of bad definitions
+func TestLatencyThresholdsConfig(t *testing.T) {
+	valid := `latency_thresholds:
+  - name: "wan"
+    networks: ["10.0.0.0/8"]
+    warning: "50ms"
+    critical: "200ms"
+  - name: "core_p95"
+    devices: ["192.168.1.1"]
+    critical: "5ms"
+    percentile: 95`
+
+	cfg, err := loadTestConfig(t, valid)
+	if err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+	wan := cfg.LatencyThresholds[0]
+	if wan.Warning != 50*time.Millisecond || wan.Critical != 200*time.Millisecond || wan.Percentile != 0 {
+		t.Errorf("unexpected threshold %+v", wan)
+	}
+	if !wan.Matches("10.1.2.3") || wan.Matches("192.168.1.1") {
+		t.Error("threshold should only match its network")
+	}
+	for rtt, want := range map[time.Duration]string{
+		10 * time.Millisecond:  LatencyLevelOK,
+		50 * time.Millisecond:  LatencyLevelOK,
+		80 * time.Millisecond:  LatencyLevelWarning,
+		300 * time.Millisecond: LatencyLevelCritical,
+	} {
+		if level, _ := wan.Level(rtt); level != want {
+			t.Errorf("%v: expected %s, got %s", rtt, want, level)
+		}
+	}
+	if level, limit := cfg.LatencyThresholds[1].Level(6 * time.Millisecond); level != LatencyLevelCritical || limit != 5*time.Millisecond {
+		t.Errorf("expected critical above 5ms without a warning level, got %s (%v)", level, limit)
+	}
+
+	invalid := []struct {
+		name     string
+		settings string
+		wantErr  string
+	}{
+		{"bad name", "latency_thresholds:\n  - name: \"wan links\"\n    warning: \"50ms\"", "invalid name"},
+		{"duplicate name", "latency_thresholds:\n  - name: \"wan\"\n    warning: \"50ms\"\n  - name: \"wan\"\n    warning: \"60ms\"", "duplicate name"},
+		{"no limits", "latency_thresholds:\n  - name: \"wan\"", "warning or critical is required"},
+		{"critical below warning", "latency_thresholds:\n  - name: \"wan\"\n    warning: \"200ms\"\n    critical: \"50ms\"", "must be above warning"},
+		{"bad percentile", "latency_thresholds:\n  - name: \"wan\"\n    warning: \"50ms\"\n    percentile: 150", "percentile must be"},
+		{"bad network", "latency_thresholds:\n  - name: \"wan\"\n    networks: [\"10.0.0.0/33\"]\n    warning: \"50ms\"", "invalid network"},
+		{"bad device", "latency_thresholds:\n  - name: \"wan\"\n    devices: [\"router\"]\n    warning: \"50ms\"", "invalid device IP"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadTestConfig(t, tt.settings); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected %q error, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestLogLevels validates per-module log level overrides
+func TestLogLevels(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		wantErr  string
+	}{
+		{name: "none", settings: ""},
+		{name: "valid", settings: "log_levels:\n  discovery: debug\n  influx: warn\n"},
+		{name: "unknown module", settings: "log_levels:\n  scanner: debug\n", wantErr: "unknown log module"},
+		{name: "invalid level", settings: "log_levels:\n  discovery: verbose\n", wantErr: "unsupported log level"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), "config.yml", rollupConfig(tt.settings, ""))
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			_, err = ValidateScanConfig(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
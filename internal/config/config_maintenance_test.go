@@ -0,0 +1,91 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMaintenanceWindowsConfig validates one-off and recurring windows, their actions and rejection of bad definitions
+func TestMaintenanceWindowsConfig(t *testing.T) {
+	valid := `timezone: "UTC"
+maintenance_windows:
+  - name: "core_upgrade"
+    devices: ["192.168.1.1"]
+    start: "2026-10-20 22:00"
+    end: "2026-10-21 02:00"
+  - name: "weekend_patching"
+    networks: ["192.168.1.0/25"]
+    days: ["sat", "Sun"]
+    time: "23:00"
+    duration: "3h"
+    action: "tag"`
+
+	cfg, err := loadTestConfig(t, valid)
+	if err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+	if cfg.MaintenanceWindows[0].Action != MaintenanceSkip {
+		t.Errorf("expected default action skip, got %q", cfg.MaintenanceWindows[0].Action)
+	}
+
+	resolve := cfg.MaintenanceResolver()
+	at := func(s string) time.Time {
+		t.Helper()
+		ts, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+	tests := []struct {
+		ip   string
+		at   string
+		want string
+	}{
+		{"192.168.1.1", "2026-10-20T21:59:00Z", ""},
+		{"192.168.1.1", "2026-10-20T22:00:00Z", MaintenanceSkip},
+		{"192.168.1.1", "2026-10-21T01:59:00Z", MaintenanceSkip},
+		{"192.168.1.1", "2026-10-21T02:00:00Z", ""},
+		{"192.168.1.2", "2026-10-20T23:00:00Z", ""},
+		{"192.168.1.2", "2026-10-24T23:30:00Z", MaintenanceTag}, // Saturday
+		{"192.168.1.2", "2026-10-25T01:30:00Z", MaintenanceTag}, // Saturday's window runs past midnight
+		{"192.168.1.2", "2026-10-26T01:30:00Z", MaintenanceTag}, // Sunday's window
+		{"192.168.1.2", "2026-10-27T01:30:00Z", ""},             // Monday's start is not a window day
+		{"192.168.1.200", "2026-10-24T23:30:00Z", ""},           // Outside the window's network
+		{"192.168.1.1", "2026-10-24T23:30:00Z", MaintenanceTag}, // Both windows target it, only one is open
+	}
+	for _, tt := range tests {
+		if got := resolve(tt.ip, at(tt.at)); got != tt.want {
+			t.Errorf("%s at %s: expected %q, got %q", tt.ip, tt.at, tt.want, got)
+		}
+	}
+	if (&Config{}).MaintenanceResolver() != nil {
+		t.Error("expected no resolver without windows")
+	}
+
+	invalid := []struct {
+		name     string
+		settings string
+		wantErr  string
+	}{
+		{"bad name", "maintenance_windows:\n  - name: \"core upgrade\"\n    time: \"02:00\"\n    duration: \"1h\"", "invalid name"},
+		{"duplicate name", "maintenance_windows:\n  - name: \"w\"\n    time: \"02:00\"\n    duration: \"1h\"\n  - name: \"w\"\n    time: \"03:00\"\n    duration: \"1h\"", "duplicate name"},
+		{"no times", "maintenance_windows:\n  - name: \"w\"", "are required"},
+		{"both kinds", "maintenance_windows:\n  - name: \"w\"\n    start: \"2026-10-20 22:00\"\n    end: \"2026-10-21 02:00\"\n    time: \"02:00\"", "not both"},
+		{"end before start", "maintenance_windows:\n  - name: \"w\"\n    start: \"2026-10-21 02:00\"\n    end: \"2026-10-20 22:00\"", "must be after start"},
+		{"bad start", "maintenance_windows:\n  - name: \"w\"\n    start: \"tonight\"\n    end: \"2026-10-20 22:00\"", "invalid time"},
+		{"missing duration", "maintenance_windows:\n  - name: \"w\"\n    time: \"02:00\"", "duration must be"},
+		{"too long", "maintenance_windows:\n  - name: \"w\"\n    time: \"02:00\"\n    duration: \"200h\"", "duration must be"},
+		{"bad day", "maintenance_windows:\n  - name: \"w\"\n    days: [\"someday\"]\n    time: \"02:00\"\n    duration: \"1h\"", "invalid day"},
+		{"bad action", "maintenance_windows:\n  - name: \"w\"\n    time: \"02:00\"\n    duration: \"1h\"\n    action: \"mute\"", "action must be"},
+		{"bad network", "maintenance_windows:\n  - name: \"w\"\n    networks: [\"10.0.0.0/33\"]\n    time: \"02:00\"\n    duration: \"1h\"", "invalid network"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadTestConfig(t, tt.settings); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected %q error, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
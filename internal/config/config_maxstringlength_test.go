@@ -0,0 +1,50 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestMaxStringLength validates snmp.max_string_length and influxdb.max_string_length
+func TestMaxStringLength(t *testing.T) {
+	influx := "influxdb:\n  url: \"http://influx.example.com:8086\"\n  token: \"t\"\n  org: \"o\"\n  bucket: \"b\"\n"
+	tests := []struct {
+		name    string
+		snmp    string
+		influx  string
+		wantErr string
+	}{
+		{"defaults", "", "", ""},
+		{"custom", "  max_string_length: 4096\n", "  max_string_length: 256\n", ""},
+		{"snmp too short", "  max_string_length: 10\n", "", "snmp.max_string_length must be between 64 and 65535"},
+		{"influx too long", "", "  max_string_length: 100000\n", "influxdb.max_string_length must be between 64 and 65535"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The snmp lines continue the snmp block that rollupConfig ends with
+			path := writeFile(t, t.TempDir(), "config.yml", rollupConfig("", tt.snmp+influx+tt.influx))
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			_, err = ValidateScanConfig(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	path := writeFile(t, t.TempDir(), "config.yml", rollupConfig("", "  max_string_length: 4096\n"+influx+"  max_string_length: 256\n"))
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if cfg.SNMP.MaxStringLength != 4096 || cfg.InfluxDB.MaxStringLength != 256 {
+		t.Errorf("expected limits 4096 and 256, got %d and %d", cfg.SNMP.MaxStringLength, cfg.InfluxDB.MaxStringLength)
+	}
+}
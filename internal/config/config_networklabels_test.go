@@ -0,0 +1,112 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestNetworkResolver verifies IPs resolve to the most specific network, using labels where set
+func TestNetworkResolver(t *testing.T) {
+	cfg := &Config{
+		Networks:      []string{"10.0.0.0/16", "10.0.5.0/24", "192.168.1.0/24"},
+		NetworkLabels: map[string]string{"10.0.5.0/24": "datacenter", "10.0.0.0/16": "campus"},
+	}
+	resolve := cfg.NetworkResolver()
+	tests := map[string]string{
+		"10.0.5.20":   "datacenter",
+		"10.0.7.1":    "campus",
+		"192.168.1.1": "192.168.1.0/24",
+		"172.16.0.1":  "",
+		"invalid":     "",
+	}
+	for ip, want := range tests {
+		if got := resolve(ip); got != want {
+			t.Errorf("%s: expected %q, got %q", ip, want, got)
+		}
+	}
+	if got := cfg.SubnetResolver()("10.0.5.20"); got != "10.0.5.0/24" {
+		t.Errorf("expected the subnet resolver to ignore labels, got %q", got)
+	}
+}
+
+// TestNetworkLabelsValidation validates network_labels keys and labels
+func TestNetworkLabelsValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		wantErr  string
+	}{
+		{"valid", "network_labels:\n  \"192.168.1.0/24\": \"office\"\n", ""},
+		{"not a network", "network_labels:\n  \"10.0.0.0/8\": \"other\"\n", "is not in networks"},
+		{"invalid cidr", "network_labels:\n  \"192.168.1.0\": \"office\"\n", "invalid network_labels network"},
+		{"empty label", "network_labels:\n  \"192.168.1.0/24\": \" \"\n", "must not be empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), "config.yml", rollupConfig(tt.settings, ""))
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			_, err = ValidateScanConfig(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestInlineNetworkLabels validates labels given directly in networks and their merge with network_labels
+func TestInlineNetworkLabels(t *testing.T) {
+	base := `
+icmp_discovery_interval: "5m"
+ping_interval: "2s"
+snmp:
+  community: "netscan-ro"
+  port: 161
+  retries: 1
+`
+	tests := []struct {
+		name     string
+		networks string
+		wantErr  string
+	}{
+		{"mixed forms", "networks:\n  - \"192.168.1.0/24\"\n  - network: \"10.10.0.0/16\"\n    label: \"fra1-servers\"\n", ""},
+		{"same label twice", "networks:\n  - network: \"10.10.0.0/16\"\n    label: \"fra1-servers\"\nnetwork_labels:\n  \"10.10.0.0/16\": \"fra1-servers\"\n", ""},
+		{"conflicting labels", "networks:\n  - network: \"10.10.0.0/16\"\n    label: \"fra1-servers\"\nnetwork_labels:\n  \"10.10.0.0/16\": \"fra1\"\n", "labeled \"fra1-servers\" in networks and \"fra1\" in network_labels"},
+		{"missing network", "networks:\n  - label: \"fra1-servers\"\n", "networks entry without network"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), "config.yml", tt.networks+base)
+			cfg, err := LoadConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			if _, err := ValidateScanConfig(cfg); err != nil {
+				t.Errorf("unexpected validation error: %v", err)
+			}
+			if got := cfg.NetworkLabel("10.10.0.0/16"); got != "fra1-servers" {
+				t.Errorf("expected label fra1-servers, got %q", got)
+			}
+			if got := cfg.NetworkResolver()("10.10.3.4"); got != "fra1-servers" {
+				t.Errorf("expected the network tag to use the label, got %q", got)
+			}
+			if got := cfg.DisplayNetwork("10.10.0.0/16"); got != "fra1-servers (10.10.0.0/16)" {
+				t.Errorf("unexpected display name %q", got)
+			}
+		})
+	}
+}
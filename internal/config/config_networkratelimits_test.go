@@ -0,0 +1,46 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestNetworkRateLimits validates network_rate_limits entries and the burst default
+func TestNetworkRateLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		settings  string
+		wantBurst int
+		wantErr   string
+	}{
+		{name: "default burst", settings: "network_rate_limits:\n  - network: \"192.168.1.0/24\"\n    rate: 2.5\n", wantBurst: 3},
+		{name: "explicit burst", settings: "network_rate_limits:\n  - network: \"192.168.1.0/24\"\n    rate: 10\n    burst: 20\n", wantBurst: 20},
+		{name: "invalid network", settings: "network_rate_limits:\n  - network: \"192.168.1.0\"\n    rate: 10\n", wantErr: "invalid network_rate_limits network"},
+		{name: "missing rate", settings: "network_rate_limits:\n  - network: \"192.168.1.0/24\"\n", wantErr: "must be greater than 0"},
+		{name: "negative burst", settings: "network_rate_limits:\n  - network: \"192.168.1.0/24\"\n    rate: 10\n    burst: -1\n", wantErr: "must not be negative"},
+		{name: "duplicate", settings: "network_rate_limits:\n  - network: \"192.168.1.0/24\"\n    rate: 10\n  - network: \"192.168.1.5/24\"\n    rate: 5\n", wantErr: "duplicate network_rate_limits"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), "config.yml", rollupConfig(tt.settings, ""))
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			_, err = ValidateScanConfig(cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := cfg.NetworkRateLimits[0].BurstOrDefault(); got != tt.wantBurst {
+				t.Errorf("expected burst %d, got %d", tt.wantBurst, got)
+			}
+		})
+	}
+}
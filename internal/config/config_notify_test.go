@@ -0,0 +1,105 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestNotificationsConfig validates webhook defaults and rejection of bad definitions
+func TestNotificationsConfig(t *testing.T) {
+	valid := `notifications:
+  webhooks:
+    - name: "ops_slack"
+      url: "https://hooks.example.com/T000/B000"
+      format: "slack"
+      events: ["down"]
+      template: "{{.Name}} is {{.Type}}"
+    - name: "generic"
+      url: "http://alerts.example.com/netscan"`
+
+	cfg, err := loadTestConfig(t, valid)
+	if err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+	if cfg.Notifications.RateLimit != 10 {
+		t.Errorf("expected default rate_limit 10, got %d", cfg.Notifications.RateLimit)
+	}
+	generic := cfg.Notifications.Webhooks[1]
+	if generic.Format != WebhookFormatGeneric || len(generic.Events) != 8 {
+		t.Errorf("expected generic format and all events by default, got %+v", generic)
+	}
+	if !cfg.Notifications.Webhooks[0].Wants(NotifyEventDown) || cfg.Notifications.Webhooks[0].Wants(NotifyEventUp) {
+		t.Error("slack webhook should only want down events")
+	}
+
+	invalid := []struct {
+		name     string
+		settings string
+		wantErr  string
+	}{
+		{"bad name", "ping_interval: \"2s\"\nnotifications:\n  webhooks:\n    - name: \"ops slack\"\n      url: \"https://h.example.com\"", "invalid name"},
+		{"bad url", "ping_interval: \"2s\"\nnotifications:\n  webhooks:\n    - name: \"ops\"\n      url: \"ftp://h.example.com\"", "invalid url"},
+		{"bad format", "ping_interval: \"2s\"\nnotifications:\n  webhooks:\n    - name: \"ops\"\n      url: \"https://h.example.com\"\n      format: \"irc\"", "unsupported format"},
+		{"bad event", "ping_interval: \"2s\"\nnotifications:\n  webhooks:\n    - name: \"ops\"\n      url: \"https://h.example.com\"\n      events: [\"flapping\"]", "unsupported event"},
+		{"bad template", "ping_interval: \"2s\"\nnotifications:\n  webhooks:\n    - name: \"ops\"\n      url: \"https://h.example.com\"\n      template: \"{{.IP\"", "invalid template"},
+		{"rate limit", "ping_interval: \"2s\"\nnotifications:\n  rate_limit: 1000", "rate_limit"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadTestConfig(t, tt.settings); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected %q error, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestSyslogNotificationsConfig validates syslog receiver defaults and rejection of bad settings
+func TestSyslogNotificationsConfig(t *testing.T) {
+	valid := `notifications:
+  syslog:
+    - name: "noc"
+      address: "syslog.example.com"
+      protocol: "tls"
+      facility: "local3"
+      severities:
+        up: "info"
+    - name: "relay"
+      address: "10.0.0.5:1514"`
+
+	cfg, err := loadTestConfig(t, valid)
+	if err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+	noc, relay := cfg.Notifications.Syslog[0], cfg.Notifications.Syslog[1]
+	if noc.Address != "syslog.example.com:6514" || relay.Address != "10.0.0.5:1514" || relay.Protocol != SyslogProtocolUDP {
+		t.Errorf("expected default tls port and udp protocol, got %q, %q/%s", noc.Address, relay.Address, relay.Protocol)
+	}
+	// local3 (19) * 8 + err (3), and the overridden up severity info (6)
+	if noc.Priority(NotifyEventDown) != 155 || noc.Priority(NotifyEventUp) != 158 {
+		t.Errorf("unexpected priorities %d, %d", noc.Priority(NotifyEventDown), noc.Priority(NotifyEventUp))
+	}
+	// daemon (3) * 8 + warning (4)
+	if relay.Priority(NotifyEventSuspended) != 28 || len(relay.Events) != 3 || relay.AppName != "netscan" {
+		t.Errorf("unexpected relay defaults: %+v", relay)
+	}
+
+	invalid := []struct {
+		name     string
+		settings string
+		wantErr  string
+	}{
+		{"bad protocol", "notifications:\n  syslog:\n    - name: \"noc\"\n      address: \"h.example.com\"\n      protocol: \"relp\"", "unsupported protocol"},
+		{"bad facility", "notifications:\n  syslog:\n    - name: \"noc\"\n      address: \"h.example.com\"\n      facility: \"local9\"", "unknown facility"},
+		{"bad severity", "notifications:\n  syslog:\n    - name: \"noc\"\n      address: \"h.example.com\"\n      severities:\n        down: \"fatal\"", "unknown severity"},
+		{"bad event", "notifications:\n  syslog:\n    - name: \"noc\"\n      address: \"h.example.com\"\n      events: [\"flapping\"]", "unsupported event"},
+		{"missing address", "notifications:\n  syslog:\n    - name: \"noc\"", "invalid address"},
+		{"ca without tls", "notifications:\n  syslog:\n    - name: \"noc\"\n      address: \"h.example.com\"\n      tls_ca_file: \"/etc/ssl/ca.pem\"", "requires protocol tls"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadTestConfig(t, tt.settings); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected %q error, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestLoadConfigOIDGroups validates custom OID groups are parsed and defaults are applied
+func TestLoadConfigOIDGroups(t *testing.T) {
+	f, err := os.CreateTemp("", "test-config-*.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	configYAML := `
+networks:
+  - "192.168.1.0/24"
+icmp_discovery_interval: "5m"
+ping_interval: "2s"
+snmp:
+  community: "test-community-123"
+  port: 161
+  oid_groups:
+    - name: "ups"
+      networks: ["192.168.1.0/28"]
+      oids:
+        - name: "battery_capacity"
+          oid: ".1.3.6.1.2.1.33.1.2.4.0"
+        - name: "battery_voltage"
+          oid: "1.3.6.1.2.1.33.1.2.5.0"
+          type: "float"
+          scale: 0.1
+influxdb:
+  url: "http://localhost:8086"
+  token: "test-token"
+  org: "test-org"
+  bucket: "test-bucket"
+`
+	if _, err := f.WriteString(configYAML); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	cfg, err := LoadConfig(f.Name())
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if _, err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+
+	if len(cfg.SNMP.OIDGroups) != 1 {
+		t.Fatalf("expected 1 OID group, got %d", len(cfg.SNMP.OIDGroups))
+	}
+	group := cfg.SNMP.OIDGroups[0]
+	if group.Measurement != "snmp_ups" {
+		t.Errorf("expected default measurement snmp_ups, got %s", group.Measurement)
+	}
+	if group.OIDs[0].OID != "1.3.6.1.2.1.33.1.2.4.0" {
+		t.Errorf("expected leading dot to be stripped, got %s", group.OIDs[0].OID)
+	}
+	if group.OIDs[0].Type != OIDTypeInteger || group.OIDs[0].Scale != 1.0 {
+		t.Errorf("expected integer type and scale 1.0 defaults, got %s/%v", group.OIDs[0].Type, group.OIDs[0].Scale)
+	}
+	if group.OIDs[1].Type != OIDTypeFloat || group.OIDs[1].Scale != 0.1 {
+		t.Errorf("expected float type with scale 0.1, got %s/%v", group.OIDs[1].Type, group.OIDs[1].Scale)
+	}
+}
+
+// TestValidateOIDGroups validates rejection of malformed OID group definitions
+func TestValidateOIDGroups(t *testing.T) {
+	validOID := []OIDConfig{{Name: "value", OID: "1.3.6.1.4.1.9.9.13.1.3.1.3.1", Type: OIDTypeInteger, Scale: 1}}
+
+	tests := []struct {
+		name    string
+		groups  []OIDGroupConfig
+		wantErr string
+	}{
+		{"valid", []OIDGroupConfig{{Name: "temp", Measurement: "snmp_temp", OIDs: validOID}}, ""},
+		{"invalid group name", []OIDGroupConfig{{Name: "bad name", Measurement: "m", OIDs: validOID}}, "invalid group name"},
+		{"duplicate group", []OIDGroupConfig{{Name: "a", Measurement: "m1", OIDs: validOID}, {Name: "a", Measurement: "m2", OIDs: validOID}}, "duplicate group name"},
+		{"reserved measurement", []OIDGroupConfig{{Name: "a", Measurement: "ping", OIDs: validOID}}, "reserved"},
+		{"reserved device_state measurement", []OIDGroupConfig{{Name: "a", Measurement: "device_state", OIDs: validOID}}, "reserved"},
+		{"reserved composite_check measurement", []OIDGroupConfig{{Name: "a", Measurement: "composite_check", OIDs: validOID}}, "reserved"},
+		{"reserved traceroute measurement", []OIDGroupConfig{{Name: "a", Measurement: "traceroute", OIDs: validOID}}, "reserved"},
+		{"reserved annotations measurement", []OIDGroupConfig{{Name: "a", Measurement: "annotations", OIDs: validOID}}, "reserved"},
+		{"reserved subnet_health measurement", []OIDGroupConfig{{Name: "a", Measurement: "subnet_health", OIDs: validOID}}, "reserved"},
+		{"invalid network", []OIDGroupConfig{{Name: "a", Measurement: "m", Networks: []string{"10.0.0.0/33"}, OIDs: validOID}}, "invalid network"},
+		{"invalid device", []OIDGroupConfig{{Name: "a", Measurement: "m", Devices: []string{"not-an-ip"}, OIDs: validOID}}, "invalid device IP"},
+		{"no oids", []OIDGroupConfig{{Name: "a", Measurement: "m"}}, "at least one OID"},
+		{"symbolic oid", []OIDGroupConfig{{Name: "a", Measurement: "m", OIDs: []OIDConfig{{Name: "v", OID: "sysUpTime.0", Type: OIDTypeInteger}}}}, "invalid OID"},
+		{"bad type", []OIDGroupConfig{{Name: "a", Measurement: "m", OIDs: []OIDConfig{{Name: "v", OID: "1.3.6", Type: "bool"}}}}, "unsupported type"},
+		{"reserved field", []OIDGroupConfig{{Name: "a", Measurement: "m", OIDs: []OIDConfig{{Name: "truncated", OID: "1.3.6", Type: OIDTypeString}}}}, "written by netscan"},
+		{"duplicate field", []OIDGroupConfig{{Name: "a", Measurement: "m", OIDs: append(validOID, validOID[0])}}, "duplicate field name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateOIDGroups(tt.groups)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestOIDGroupMatches validates group scoping by CIDR, device IP, and the apply-to-all default
+func TestOIDGroupMatches(t *testing.T) {
+	all := OIDGroupConfig{Name: "all"}
+	if !all.Matches("10.1.2.3") {
+		t.Error("expected group without networks or devices to match every device")
+	}
+
+	scoped := OIDGroupConfig{
+		Name:     "scoped",
+		Networks: []string{"192.168.1.0/24"},
+		Devices:  []string{"10.0.0.5"},
+	}
+	cases := map[string]bool{
+		"192.168.1.77": true,
+		"10.0.0.5":     true,
+		"10.0.0.6":     false,
+		"192.168.2.1":  false,
+		"garbage":      false,
+	}
+	for ip, want := range cases {
+		if got := scoped.Matches(ip); got != want {
+			t.Errorf("Matches(%s) = %v, want %v", ip, got, want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestInferOSFamily verifies sysDescr keywords win over port signatures, which win over the reply TTL
+func TestInferOSFamily(t *testing.T) {
+	tests := []struct {
+		name      string
+		sysDescr  string
+		ttl       int
+		openPorts []int
+		want      string
+	}{
+		{"nothing known", "", 0, nil, ""},
+		{"windows sysDescr", "Hardware: Intel64 - Software: Windows Version 6.3 (Build 17763)", 64, nil, OSFamilyWindows},
+		{"cisco ios before its unix", "Cisco IOS Software, C2960 Software (C2960-LANBASEK9-M)", 0, nil, OSFamilyNetwork},
+		{"linux sysDescr", "Linux nas 5.10.0-21-amd64 #1 SMP x86_64", 128, []int{135}, OSFamilyLinux},
+		{"freebsd sysDescr", "FreeBSD fw.example.com 13.2-RELEASE", 0, nil, OSFamilyBSD},
+		{"darwin sysDescr", "Darwin mac-mini 22.6.0 Darwin Kernel Version 22.6.0", 0, nil, OSFamilyMacOS},
+		{"msrpc port", "", 64, []int{135}, OSFamilyWindows},
+		{"smb without rdp is not enough", "", 64, []int{445}, OSFamilyUnix},
+		{"smb and rdp", "", 0, []int{445, 3389}, OSFamilyWindows},
+		{"afp port", "", 64, []int{548}, OSFamilyMacOS},
+		{"ttl 64 after hops", "", 61, nil, OSFamilyUnix},
+		{"ttl 128 after hops", "", 125, nil, OSFamilyWindows},
+		{"ttl 255", "", 254, nil, OSFamilyNetwork},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InferOSFamily(tt.sysDescr, tt.ttl, tt.openPorts); got != tt.want {
+				t.Errorf("InferOSFamily(%q, %d, %v) = %q, want %q", tt.sysDescr, tt.ttl, tt.openPorts, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFingerprintProbe verifies classification and OS signature ports are probed together with the longer timeout
+func TestFingerprintProbe(t *testing.T) {
+	cfg := &Config{}
+	if ports, _ := cfg.FingerprintProbe(); len(ports) != 0 {
+		t.Errorf("expected no ports without probe_ports, got %v", ports)
+	}
+
+	cfg.OSFingerprinting = OSFingerprintConfig{Enabled: true, ProbePorts: true, PortTimeout: 2 * time.Second}
+	ports, timeout := cfg.FingerprintProbe()
+	if !slices.Equal(ports, []int{135, 445, 548, 3389}) || timeout != 2*time.Second {
+		t.Errorf("expected the OS signature ports with 2s, got %v with %v", ports, timeout)
+	}
+
+	cfg.DeviceClassification = DeviceClassificationConfig{ProbePorts: true, DisableBuiltinRules: true, Rules: []DeviceTypeRule{{Type: "voip_phone", Ports: []int{5060, 3389}}}}
+	ports, timeout = cfg.FingerprintProbe()
+	if !slices.Equal(ports, []int{135, 445, 548, 3389, 5060}) || timeout != 2*time.Second {
+		t.Errorf("expected merged ports with 2s, got %v with %v", ports, timeout)
+	}
+
+	cfg.OSFingerprinting.Enabled = false
+	ports, timeout = cfg.FingerprintProbe()
+	if !slices.Equal(ports, []int{3389, 5060}) || timeout != DefaultClassificationPortTimeout {
+		t.Errorf("expected only classification ports when fingerprinting is off, got %v with %v", ports, timeout)
+	}
+}
+
+// TestOSFingerprintValidation verifies the port timeout range
+func TestOSFingerprintValidation(t *testing.T) {
+	if err := validateOSFingerprint(OSFingerprintConfig{Enabled: true, PortTimeout: time.Second}); err != nil {
+		t.Errorf("expected a valid block, got %v", err)
+	}
+	if err := validateOSFingerprint(OSFingerprintConfig{Enabled: true, PortTimeout: time.Minute}); err == nil {
+		t.Error("expected an error for a port_timeout above 30s")
+	}
+}
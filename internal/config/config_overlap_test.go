@@ -0,0 +1,44 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+// TestValidateOverlapSettings validates instance_id format and overlap detection parameters
+func TestValidateOverlapSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"disabled without instance id", Config{}, false},
+		{"disabled with instance id", Config{InstanceID: "scanner-a"}, false},
+		{"invalid instance id", Config{InstanceID: `bad"id`}, true},
+		{"enabled", Config{InstanceID: "scanner-a", OverlapCheckInterval: 10 * time.Minute, OverlapAction: OverlapActionDisable, OverlapMinMatches: 3}, false},
+		{"enabled without instance id", Config{OverlapCheckInterval: 10 * time.Minute, OverlapMinMatches: 3}, true},
+		{"interval too short", Config{InstanceID: "a", OverlapCheckInterval: 10 * time.Second, OverlapMinMatches: 3}, true},
+		{"unknown action", Config{InstanceID: "a", OverlapCheckInterval: time.Hour, OverlapAction: "shutdown", OverlapMinMatches: 3}, true},
+		{"zero min matches", Config{InstanceID: "a", OverlapCheckInterval: time.Hour}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateOverlapSettings(&tt.cfg)
+			if tt.wantErr && err == nil {
+				t.Error("expected validation error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+// TestDefaultInstanceID validates the hostname-derived default is a valid instance_id
+func TestDefaultInstanceID(t *testing.T) {
+	id := defaultInstanceID()
+	if !instanceIDPattern.MatchString(id) {
+		t.Errorf("default instance id %q does not satisfy instance_id validation", id)
+	}
+}
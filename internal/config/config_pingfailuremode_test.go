@@ -0,0 +1,42 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestPingFailureMode validates the circuit breaker failure mode defaults and the windowed mode ranges
+func TestPingFailureMode(t *testing.T) {
+	tests := []struct {
+		name       string
+		setting    string
+		wantMode   string
+		wantWindow int
+		wantRatio  float64
+		wantErr    string
+	}{
+		{"default consecutive", "", "consecutive", 20, 0.7, ""},
+		{"windowed", "ping_failure_mode: windowed\nping_failure_window: 30\nping_failure_ratio: 0.5", "windowed", 30, 0.5, ""},
+		{"unknown mode", "ping_failure_mode: sliding", "sliding", 20, 0.7, "ping_failure_mode"},
+		{"window too small", "ping_failure_mode: windowed\nping_failure_window: 1", "windowed", 1, 0.7, "ping_failure_window"},
+		{"window too large", "ping_failure_mode: windowed\nping_failure_window: 65", "windowed", 65, 0.7, "ping_failure_window"},
+		{"ratio above one", "ping_failure_mode: windowed\nping_failure_ratio: 1.5", "windowed", 20, 1.5, "ping_failure_ratio"},
+		{"ratio negative", "ping_failure_mode: windowed\nping_failure_ratio: -0.1", "windowed", 20, -0.1, "ping_failure_ratio"},
+		{"window ignored in consecutive mode", "ping_failure_window: 100", "consecutive", 100, 0.7, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadTestConfig(t, tt.setting)
+			if cfg.PingFailureMode != tt.wantMode || cfg.PingFailureWindow != tt.wantWindow || cfg.PingFailureRatio != tt.wantRatio {
+				t.Errorf("expected %s/%d/%g, got %s/%d/%g", tt.wantMode, tt.wantWindow, tt.wantRatio,
+					cfg.PingFailureMode, cfg.PingFailureWindow, cfg.PingFailureRatio)
+			}
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("expected valid config, got %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected %q error, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestPingSpread validates ping_start_spread and ping_jitter defaults and bounds
+func TestPingSpread(t *testing.T) {
+	tests := []struct {
+		name       string
+		settings   string
+		wantSpread time.Duration
+		wantJitter time.Duration
+		wantErr    string
+	}{
+		{name: "default", wantSpread: 2 * time.Second},
+		{name: "disabled", settings: "ping_start_spread: \"0s\"\n", wantSpread: 0},
+		{name: "custom", settings: "ping_start_spread: \"500ms\"\nping_jitter: \"200ms\"\n", wantSpread: 500 * time.Millisecond, wantJitter: 200 * time.Millisecond},
+		{name: "spread too long", settings: "ping_start_spread: \"3s\"\n", wantErr: "ping_start_spread must be between"},
+		{name: "jitter too large", settings: "ping_jitter: \"1500ms\"\n", wantErr: "ping_jitter must be between"},
+		{name: "negative jitter", settings: "ping_jitter: \"-1s\"\n", wantErr: "ping_jitter must be between"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), "config.yml", rollupConfig(tt.settings, ""))
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			_, err = ValidateScanConfig(cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.PingStartSpread != tt.wantSpread {
+				t.Errorf("expected ping_start_spread %v, got %v", tt.wantSpread, cfg.PingStartSpread)
+			}
+			if cfg.PingJitter != tt.wantJitter {
+				t.Errorf("expected ping_jitter %v, got %v", tt.wantJitter, cfg.PingJitter)
+			}
+		})
+	}
+}
+
+// TestSNMPJitter validates the snmp_jitter default and bounds
+func TestSNMPJitter(t *testing.T) {
+	tests := []struct {
+		name    string
+		setting string
+		want    time.Duration
+		wantErr string
+	}{
+		{name: "default"},
+		{name: "custom", setting: "snmp_jitter: \"5m\"\n", want: 5 * time.Minute},
+		{name: "half the interval", setting: "snmp_interval: \"10m\"\nsnmp_jitter: \"5m\"\n", want: 5 * time.Minute},
+		{name: "too large", setting: "snmp_interval: \"10m\"\nsnmp_jitter: \"6m\"\n", wantErr: "snmp_jitter must be between"},
+		{name: "negative", setting: "snmp_jitter: \"-1s\"\n", wantErr: "snmp_jitter must be between"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), "config.yml", rollupConfig(tt.setting, ""))
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			_, err = ValidateScanConfig(cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.SNMPJitter != tt.want {
+				t.Errorf("expected snmp_jitter %v, got %v", tt.want, cfg.SNMPJitter)
+			}
+		})
+	}
+}
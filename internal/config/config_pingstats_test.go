@@ -0,0 +1,166 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestPingsPerCycle validates the default, accepted values and the cycle-fits-interval check
+func TestPingsPerCycle(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		want     int
+		wantErr  string
+	}{
+		{"default", "ping_interval: \"2s\"", 1, ""},
+		{"five packets", "ping_interval: \"5s\"\nping_timeout: \"2s\"\npings_per_cycle: 5", 5, ""},
+		{"too many", "ping_interval: \"30s\"\npings_per_cycle: 11", 11, "between 1 and 10"},
+		{"cycle exceeds interval", "ping_interval: \"2s\"\nping_timeout: \"2s\"\npings_per_cycle: 3", 3, "less than ping_interval"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadTestConfig(t, tt.settings)
+			if cfg.PingsPerCycle != tt.want {
+				t.Errorf("expected pings_per_cycle %d, got %d", tt.want, cfg.PingsPerCycle)
+			}
+
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("expected valid config, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestPingFailureCoalescing validates coalescing is disabled by default and its settings are checked when enabled
+func TestPingFailureCoalescing(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		wantErr  string
+	}{
+		{"disabled by default", "ping_interval: \"2s\"", ""},
+		{"enabled", "ping_interval: \"2s\"\nping_failure_coalesce_after: \"10m\"", ""},
+		{"shorter than interval", "ping_interval: \"30s\"\nping_failure_coalesce_after: \"10s\"", "must not be less than ping_interval"},
+		{"every too small", "ping_interval: \"2s\"\nping_failure_coalesce_after: \"10m\"\nping_failure_coalesce_every: 1", "between 2 and 1000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadTestConfig(t, tt.settings)
+			if cfg.PingFailureCoalesceEvery == 0 {
+				t.Error("expected ping_failure_coalesce_every default to be applied")
+			}
+
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("expected valid config, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestPingWorkers validates the ping worker pool default and range
+func TestPingWorkers(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		want     int
+		wantErr  bool
+	}{
+		{"default", "ping_interval: \"2s\"", 256, false},
+		{"custom", "ping_interval: \"2s\"\nping_workers: 64", 64, false},
+		{"too many", "ping_interval: \"2s\"\nping_workers: 10001", 10001, true},
+		{"negative", "ping_interval: \"2s\"\nping_workers: -1", -1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadTestConfig(t, tt.settings)
+			if cfg.PingWorkers != tt.want {
+				t.Errorf("expected ping_workers %d, got %d", tt.want, cfg.PingWorkers)
+			}
+
+			if tt.wantErr && (err == nil || !strings.Contains(err.Error(), "ping_workers")) {
+				t.Errorf("expected ping_workers error, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected valid config, got %v", err)
+			}
+		})
+	}
+}
+
+// TestAPIRateLimits verifies per-client API limit defaults and bounds
+func TestAPIRateLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		settings  string
+		wantRate  float64
+		wantBurst int
+		wantErr   string
+	}{
+		{"defaults", "ping_interval: \"2s\"", 5.0, 20, ""},
+		{"custom", "ping_interval: \"2s\"\napi_rate_limit: 0.5\napi_burst_limit: 3", 0.5, 3, ""},
+		{"negative rate", "ping_interval: \"2s\"\napi_rate_limit: -1", -1, 20, "api_rate_limit"},
+		{"burst too large", "ping_interval: \"2s\"\napi_burst_limit: 20000", 5.0, 20000, "api_burst_limit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadTestConfig(t, tt.settings)
+			if cfg.APIRateLimit != tt.wantRate || cfg.APIBurstLimit != tt.wantBurst {
+				t.Errorf("expected %.2f req/s burst %d, got %.2f burst %d", tt.wantRate, tt.wantBurst, cfg.APIRateLimit, cfg.APIBurstLimit)
+			}
+
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected %s error, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("expected valid config, got %v", err)
+			}
+		})
+	}
+}
+
+// TestPingEngine verifies the ping engine default and accepted values
+func TestPingEngine(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		want     string
+		wantErr  bool
+	}{
+		{"default", "ping_interval: \"2s\"", "batch", false},
+		{"probing", "ping_interval: \"2s\"\nping_engine: \"probing\"", "probing", false},
+		{"unknown", "ping_interval: \"2s\"\nping_engine: \"fping\"", "fping", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadTestConfig(t, tt.settings)
+			if cfg.PingEngine != tt.want {
+				t.Errorf("expected ping_engine %q, got %q", tt.want, cfg.PingEngine)
+			}
+
+			if tt.wantErr && (err == nil || !strings.Contains(err.Error(), "ping_engine")) {
+				t.Errorf("expected ping_engine error, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected valid config, got %v", err)
+			}
+		})
+	}
+}
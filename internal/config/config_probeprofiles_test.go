@@ -0,0 +1,122 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestProbeProfiles validates probe profile defaults, the pings_per_cycle alias and bounds
+func TestProbeProfiles(t *testing.T) {
+	tests := []struct {
+		name       string
+		settings   string
+		discovery  ProbeProfile
+		monitoring ProbeProfile
+		pings      int
+		wantErr    string
+	}{
+		{
+			name:       "default",
+			discovery:  ProbeProfile{Count: 1, Interval: DefaultProbeInterval, Size: DefaultProbeSize},
+			monitoring: ProbeProfile{Count: 1, Interval: DefaultProbeInterval, Size: DefaultProbeSize},
+			pings:      1,
+		},
+		{
+			name:       "pings_per_cycle",
+			settings:   "pings_per_cycle: 3\nping_timeout: \"1s\"\n",
+			discovery:  ProbeProfile{Count: 1, Interval: DefaultProbeInterval, Size: DefaultProbeSize},
+			monitoring: ProbeProfile{Count: 3, Interval: DefaultProbeInterval, Size: DefaultProbeSize},
+			pings:      3,
+		},
+		{
+			name:       "custom",
+			settings:   "ping_timeout: \"1s\"\nprobe_profiles:\n  discovery:\n    count: 2\n    interval: \"50ms\"\n  monitoring:\n    count: 4\n    interval: \"100ms\"\n    size: 1472\n",
+			discovery:  ProbeProfile{Count: 2, Interval: 50 * time.Millisecond, Size: DefaultProbeSize},
+			monitoring: ProbeProfile{Count: 4, Interval: 100 * time.Millisecond, Size: 1472},
+			pings:      4,
+		},
+		{
+			name:       "dscp",
+			settings:   "probe_profiles:\n  discovery:\n    dscp: 46\n    size: 1400\n  monitoring:\n    dscp: 46\n    size: 1400\n",
+			discovery:  ProbeProfile{Count: 1, Interval: DefaultProbeInterval, Size: 1400, DSCP: 46},
+			monitoring: ProbeProfile{Count: 1, Interval: DefaultProbeInterval, Size: 1400, DSCP: 46},
+			pings:      1,
+		},
+		{
+			name:     "count conflicts with pings_per_cycle",
+			settings: "pings_per_cycle: 3\nprobe_profiles:\n  monitoring:\n    count: 2\n",
+			wantErr:  "set only one of them",
+		},
+		{
+			name:     "count too high",
+			settings: "probe_profiles:\n  discovery:\n    count: 11\n",
+			wantErr:  "probe_profiles.discovery.count must be between 1 and 10",
+		},
+		{
+			name:     "interval too short",
+			settings: "probe_profiles:\n  monitoring:\n    interval: \"1ms\"\n",
+			wantErr:  "probe_profiles.monitoring.interval must be between",
+		},
+		{
+			name:     "size too small",
+			settings: "probe_profiles:\n  monitoring:\n    size: 8\n",
+			wantErr:  "probe_profiles.monitoring.size must be between 24 and 65000",
+		},
+		{
+			name:     "dscp too high",
+			settings: "probe_profiles:\n  monitoring:\n    dscp: 64\n",
+			wantErr:  "probe_profiles.monitoring.dscp must be between 0 and 63",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), "config.yml", rollupConfig(tt.settings, ""))
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			_, err = ValidateScanConfig(cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.ProbeProfiles.Discovery != tt.discovery {
+				t.Errorf("expected discovery profile %+v, got %+v", tt.discovery, cfg.ProbeProfiles.Discovery)
+			}
+			if cfg.ProbeProfiles.Monitoring != tt.monitoring {
+				t.Errorf("expected monitoring profile %+v, got %+v", tt.monitoring, cfg.ProbeProfiles.Monitoring)
+			}
+			if cfg.PingsPerCycle != tt.pings {
+				t.Errorf("expected pings_per_cycle %d, got %d", tt.pings, cfg.PingsPerCycle)
+			}
+		})
+	}
+}
+
+// TestProbeProfileSpread checks the zero-value defaults used by Config literals and the DSCP to TOS mapping
+func TestProbeProfileSpread(t *testing.T) {
+	var p ProbeProfile
+	if p.PacketInterval() != DefaultProbeInterval || p.PacketSize() != DefaultProbeSize {
+		t.Errorf("expected defaults, got interval %v size %d", p.PacketInterval(), p.PacketSize())
+	}
+	if p.TOS() != 0 {
+		t.Errorf("expected best-effort TOS by default, got %#x", p.TOS())
+	}
+	if p.DSCP = 46; p.TOS() != 0xb8 {
+		t.Errorf("expected DSCP 46 (EF) as TOS 0xb8, got %#x", p.TOS())
+	}
+	if got := p.Spread(1); got != 0 {
+		t.Errorf("expected no spread for one packet, got %v", got)
+	}
+	p.Interval = 50 * time.Millisecond
+	if got := p.Spread(4); got != 150*time.Millisecond {
+		t.Errorf("expected 150ms spread, got %v", got)
+	}
+}
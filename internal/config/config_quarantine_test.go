@@ -0,0 +1,47 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestQuarantineSettings validates the quarantine defaults, ranges and file requirement
+func TestQuarantineSettings(t *testing.T) {
+	tests := []struct {
+		name       string
+		settings   string
+		wantTrips  int
+		wantWindow time.Duration
+		wantErr    string
+	}{
+		{name: "default", settings: "", wantWindow: 72 * time.Hour},
+		{name: "enabled", settings: "quarantine_trips: 5\nquarantine_window: \"168h\"\nquarantine_file: \"/tmp/q.json\"\n", wantTrips: 5, wantWindow: 168 * time.Hour},
+		{name: "negative trips", settings: "quarantine_trips: -1\n", wantTrips: -1, wantWindow: 72 * time.Hour, wantErr: "quarantine_trips must be between"},
+		{name: "short window", settings: "quarantine_trips: 3\nquarantine_window: \"10m\"\n", wantTrips: 3, wantWindow: 10 * time.Minute, wantErr: "quarantine_window must be between"},
+		{name: "file without trips", settings: "quarantine_file: \"/tmp/q.json\"\n", wantWindow: 72 * time.Hour, wantErr: "quarantine_file requires quarantine_trips"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), "config.yml", rollupConfig(tt.settings, ""))
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			if cfg.QuarantineTrips != tt.wantTrips || cfg.QuarantineWindow != tt.wantWindow {
+				t.Errorf("expected %d trips over %v, got %d over %v", tt.wantTrips, tt.wantWindow, cfg.QuarantineTrips, cfg.QuarantineWindow)
+			}
+			_, err = ValidateScanConfig(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
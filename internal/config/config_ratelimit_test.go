@@ -174,6 +174,7 @@ PingInterval:          2 * time.Second,
 			PingTimeout:              3 * time.Second,
 			PingRateLimit:            tt.rateLimit,
 			PingBurstLimit:           tt.burstLimit,
+			PingWorkers:              256,
 			PingMaxConsecutiveFails:  10,              // Circuit breaker default
 			PingBackoffDuration:      5 * time.Minute, // Circuit breaker default
 			SNMPInterval:             1 * time.Hour,
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// rollupConfig returns a config with the given settings and influxdb block (community is not "public",
+// so validation reaches the InfluxDB checks)
+func rollupConfig(settings, influxdb string) string {
+	return `
+networks:
+  - "192.168.1.0/24"
+icmp_discovery_interval: "5m"
+ping_interval: "2s"
+` + settings + `
+snmp:
+  community: "netscan-ro"
+  port: 161
+  retries: 1
+` + influxdb
+}
+
+// TestRollupSettings validates rollup_days, rollup_file and running without InfluxDB
+func TestRollupSettings(t *testing.T) {
+	influx := "influxdb:\n  url: \"http://influx.example.com:8086\"\n  token: \"t\"\n  org: \"o\"\n  bucket: \"b\"\n"
+	tests := []struct {
+		name     string
+		settings string
+		influxdb string
+		wantErr  string
+	}{
+		{"disabled", "", influx, ""},
+		{"with influxdb", "rollup_days: 30", influx, ""},
+		{"without influxdb", "rollup_days: 7\nrollup_file: \"/var/lib/netscan/rollups.json\"", "", ""},
+		{"influxdb required", "", "", "rollup_days"},
+		{"too many days", "rollup_days: 400", influx, "between 0 and 366"},
+		{"negative days", "rollup_days: -1", influx, "between 0 and 366"},
+		{"file without days", "rollup_file: \"rollups.json\"", influx, "requires rollup_days"},
+		{"overlap without influxdb", "rollup_days: 7\ninstance_id: \"a\"\noverlap_check_interval: \"10m\"", "", "requires influxdb.url"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), "config.yml", rollupConfig(tt.settings, tt.influxdb))
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			_, err = ValidateConfig(cfg)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("expected valid config, got %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected %q error, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package config
+
+import "testing"
+
+// TestValidateScanConfig validates that the scan subcommand does not need InfluxDB settings
+func TestValidateScanConfig(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "config.yml", rollupConfig("", ""))
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if _, err := ValidateConfig(cfg); err == nil {
+		t.Error("expected the daemon to require influxdb.url")
+	}
+	if _, err := ValidateScanConfig(cfg); err != nil {
+		t.Errorf("expected scan config without InfluxDB to be valid, got %v", err)
+	}
+
+	// Other settings are still validated
+	cfg.Networks = []string{"not-a-cidr"}
+	if _, err := ValidateScanConfig(cfg); err == nil {
+		t.Error("expected invalid network to be rejected")
+	}
+}
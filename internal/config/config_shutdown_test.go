@@ -0,0 +1,39 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestShutdownTimeout validates the pinger and SNMP poller shutdown deadline default and range
+func TestShutdownTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		setting string
+		want    time.Duration
+		wantErr string
+	}{
+		{"default", "", 10 * time.Second, ""},
+		{"custom", "shutdown_timeout: \"30s\"", 30 * time.Second, ""},
+		{"too short", "shutdown_timeout: \"100ms\"", 100 * time.Millisecond, "shutdown_timeout"},
+		{"too long", "shutdown_timeout: \"10m\"", 10 * time.Minute, "shutdown_timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadTestConfig(t, tt.setting)
+			if cfg.ShutdownTimeout != tt.want {
+				t.Errorf("expected shutdown_timeout %v, got %v", tt.want, cfg.ShutdownTimeout)
+			}
+			if cfg.InfluxDB.ShutdownTimeout != 10*time.Second {
+				t.Errorf("expected influxdb.shutdown_timeout to keep its default, got %v", cfg.InfluxDB.ShutdownTimeout)
+			}
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("expected valid config, got %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected %q error, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLoadSites verifies site networks are merged into networks and resolve to their site's settings
+func TestLoadSites(t *testing.T) {
+	sites := `sites:
+  - name: "fra1"
+    networks:
+      - network: "10.10.0.0/16"
+        label: "fra1-servers"
+    snmp:
+      community: "fra1-ro"
+      timeout: "3s"
+      transport: "tcp"
+    influxdb:
+      bucket: "netscan-fra1"
+      tags:
+        region: "eu"
+  - name: "nyc1"
+    networks:
+      - "10.20.0.0/16"
+`
+	path := writeFile(t, t.TempDir(), "config.yml", rollupConfig(sites, ""))
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if _, err := ValidateScanConfig(cfg); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	want := []string{"192.168.1.0/24", "10.10.0.0/16", "10.20.0.0/16"}
+	if strings.Join(cfg.Networks, ",") != strings.Join(want, ",") {
+		t.Errorf("expected networks %v, got %v", want, cfg.Networks)
+	}
+	if got := cfg.NetworkLabel("10.10.0.0/16"); got != "fra1-servers" {
+		t.Errorf("expected the inline site label to be kept, got %q", got)
+	}
+
+	site := cfg.SiteResolver()
+	for ip, want := range map[string]string{"10.10.1.1": "fra1", "10.20.1.1": "nyc1", "192.168.1.5": "", "invalid": ""} {
+		if got := site(ip); got != want {
+			t.Errorf("%s: expected site %q, got %q", ip, want, got)
+		}
+	}
+
+	snmpFor := cfg.SNMPResolver()
+	fra := snmpFor("10.10.1.1")
+	if fra.Community != "fra1-ro" || fra.Timeout != 3*time.Second || fra.Port != 161 || fra.Retries != 1 || fra.Transport != SNMPTransportTCP {
+		t.Errorf("expected fra1 overrides over the global snmp block, got %+v", *fra)
+	}
+	if fra != snmpFor("10.10.2.2") {
+		t.Error("expected devices of one site to share their SNMP settings")
+	}
+	if nyc := snmpFor("10.20.1.1"); nyc.Community != "netscan-ro" || nyc.Transport != SNMPTransportUDP {
+		t.Errorf("expected nyc1 to use the global community and transport, got %+v", *nyc)
+	}
+	if global := snmpFor("192.168.1.5"); global != &cfg.SNMP {
+		t.Error("expected devices outside every site to use the global snmp block")
+	}
+
+	tags := cfg.Sites[0].Tags()
+	if tags["site"] != "fra1" || tags["region"] != "eu" || len(tags) != 2 {
+		t.Errorf("unexpected site tags %v", tags)
+	}
+	if got := cfg.Sites[1].InfluxDB.BucketOr("netscan"); got != "netscan" {
+		t.Errorf("expected nyc1 to use the global bucket, got %q", got)
+	}
+}
+
+// TestSitesValidation validates site names, networks, SNMP overrides and tags
+func TestSitesValidation(t *testing.T) {
+	site := func(body string) string {
+		return "sites:\n  - name: \"fra1\"\n    networks:\n      - \"10.10.0.0/16\"\n" + body
+	}
+	tests := []struct {
+		name     string
+		settings string
+		loadErr  bool
+		wantErr  string
+	}{
+		{"valid", site("    snmp:\n      port: 1161\n"), false, ""},
+		{"invalid name", "sites:\n  - name: \"fra 1\"\n    networks:\n      - \"10.10.0.0/16\"\n", false, "invalid name"},
+		{"duplicate name", site("  - name: \"fra1\"\n    networks:\n      - \"10.20.0.0/16\"\n"), false, "duplicate name"},
+		{"no networks", "sites:\n  - name: \"fra1\"\n", false, "at least one network"},
+		{"network in two sites", site("  - name: \"nyc1\"\n    networks:\n      - \"10.10.0.0/16\"\n"), true, "listed in sites \"fra1\" and \"nyc1\""},
+		{"network also top-level", "sites:\n  - name: \"fra1\"\n    networks:\n      - \"192.168.1.0/24\"\n", true, "listed in networks and in site"},
+		{"weak community", site("    snmp:\n      community: \"private\"\n"), false, "common default"},
+		{"bad port", site("    snmp:\n      port: 70000\n"), false, "port must be between"},
+		{"short timeout", site("    snmp:\n      timeout: \"100ms\"\n"), false, "at least 1 second"},
+		{"bad transport", site("    snmp:\n      transport: \"sctp\"\n"), false, "transport must be udp or tcp"},
+		{"reserved tag", site("    influxdb:\n      tags:\n        network: \"x\"\n"), false, "written by netscan"},
+		{"invalid tag key", site("    influxdb:\n      tags:\n        \"bad-key\": \"x\"\n"), false, "invalid tag key"},
+		{"empty tag", site("    influxdb:\n      tags:\n        region: \"\"\n"), false, "must not be empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), "config.yml", rollupConfig(tt.settings, ""))
+			cfg, err := LoadConfig(path)
+			if tt.loadErr {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected load error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			_, err = ValidateScanConfig(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestSitesWarning verifies site InfluxDB settings are flagged when InfluxDB is not configured
+func TestSitesWarning(t *testing.T) {
+	sites := []SiteConfig{{Name: "fra1", InfluxDB: SiteInfluxDBConfig{Bucket: "netscan-fra1"}}}
+	if warning := sitesWarning(sites, ""); !strings.Contains(warning, "sites[fra1].influxdb") {
+		t.Errorf("expected a warning, got %q", warning)
+	}
+	if warning := sitesWarning(sites, "http://influx:8086"); warning != "" {
+		t.Errorf("expected no warning with InfluxDB configured, got %q", warning)
+	}
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// snmpSessionConfig returns a minimal valid configuration with the given snmp block settings
+func snmpSessionConfig(snmpSettings string) string {
+	return `
+networks:
+  - "192.168.1.0/24"
+icmp_discovery_interval: "5m"
+ping_interval: "2s"
+snmp_interval: "10m"
+snmp:
+  community: "test-community-123"
+  port: 161
+` + snmpSettings + `
+influxdb:
+  url: "http://localhost:8086"
+  token: "test-token"
+  org: "test-org"
+  bucket: "test-bucket"
+`
+}
+
+// TestSNMPSessionCacheConfig validates the session cache defaults and limits
+func TestSNMPSessionCacheConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		settings    string
+		wantTimeout time.Duration
+		wantErr     string
+	}{
+		{"disabled by default", "", 0, ""},
+		{"default idle timeout", "  max_sessions: 5000", 20 * time.Minute, ""},
+		{"explicit idle timeout", "  max_sessions: 5000\n  session_idle_timeout: \"45m\"", 45 * time.Minute, ""},
+		{"negative sessions", "  max_sessions: -1", 0, "max_sessions"},
+		{"short idle timeout", "  max_sessions: 100\n  session_idle_timeout: \"10s\"", 10 * time.Second, "session_idle_timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), "config.yml", snmpSessionConfig(tt.settings))
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			if cfg.SNMP.SessionIdleTimeout != tt.wantTimeout {
+				t.Errorf("expected session_idle_timeout %v, got %v", tt.wantTimeout, cfg.SNMP.SessionIdleTimeout)
+			}
+			_, err = ValidateConfig(cfg)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("expected valid config, got %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected %q error, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+// TestSourceAddress resolves source_ip and source_interface against stubbed local interfaces
+func TestSourceAddress(t *testing.T) {
+	interfaces := map[string][]net.Addr{
+		"eth0": {
+			&net.IPNet{IP: net.ParseIP("fe80::1"), Mask: net.CIDRMask(64, 128)},
+			&net.IPNet{IP: net.ParseIP("10.0.0.5").To4(), Mask: net.CIDRMask(24, 32)},
+		},
+		"eth1": {&net.IPNet{IP: net.ParseIP("192.168.1.5").To4(), Mask: net.CIDRMask(24, 32)}},
+		"wg0":  {&net.IPNet{IP: net.ParseIP("fd00::1"), Mask: net.CIDRMask(64, 128)}},
+	}
+	original := interfaceAddrs
+	interfaceAddrs = func(name string) ([]net.Addr, error) {
+		if name == "" {
+			var all []net.Addr
+			for _, addrs := range interfaces {
+				all = append(all, addrs...)
+			}
+			return all, nil
+		}
+		addrs, ok := interfaces[name]
+		if !ok {
+			return nil, errors.New("no such network interface")
+		}
+		return addrs, nil
+	}
+	t.Cleanup(func() { interfaceAddrs = original })
+
+	tests := []struct {
+		name     string
+		settings string
+		want     string
+		wantErr  string
+	}{
+		{name: "unset", settings: "", want: ""},
+		{name: "source ip", settings: "source_ip: \"192.168.1.5\"\n", want: "192.168.1.5"},
+		{name: "interface", settings: "source_interface: \"eth0\"\n", want: "10.0.0.5"},
+		{name: "ip on interface", settings: "source_interface: \"eth1\"\nsource_ip: \"192.168.1.5\"\n", want: "192.168.1.5"},
+		{name: "ip on other interface", settings: "source_interface: \"eth0\"\nsource_ip: \"192.168.1.5\"\n", wantErr: "is not assigned to source_interface"},
+		{name: "ip not local", settings: "source_ip: \"10.9.9.9\"\n", wantErr: "is not assigned to a local interface"},
+		{name: "ipv6 ip", settings: "source_ip: \"fe80::1\"\n", wantErr: "must be an IPv4 address"},
+		{name: "invalid ip", settings: "source_ip: \"eth0\"\n", wantErr: "must be an IPv4 address"},
+		{name: "unknown interface", settings: "source_interface: \"eth9\"\n", wantErr: "no such network interface"},
+		{name: "interface without ipv4", settings: "source_interface: \"wg0\"\n", wantErr: "has no IPv4 address"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), "config.yml", rollupConfig(tt.settings, ""))
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			_, err = ValidateScanConfig(cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := cfg.SourceAddress()
+			if err != nil || got != tt.want {
+				t.Errorf("expected source address %q, got %q (%v)", tt.want, got, err)
+			}
+		})
+	}
+}
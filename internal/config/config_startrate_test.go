@@ -0,0 +1,36 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestPingerStartRate validates the new device ramp-up rate default and range
+func TestPingerStartRate(t *testing.T) {
+	tests := []struct {
+		name    string
+		setting string
+		want    float64
+		wantErr string
+	}{
+		{"default unlimited", "", 0, ""},
+		{"custom", "pinger_start_rate: 50", 50, ""},
+		{"fractional", "pinger_start_rate: 0.5", 0.5, ""},
+		{"negative", "pinger_start_rate: -1", -1, "pinger_start_rate"},
+		{"too high", "pinger_start_rate: 20000", 20000, "pinger_start_rate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadTestConfig(t, tt.setting)
+			if cfg.PingerStartRate != tt.want {
+				t.Errorf("expected pinger_start_rate %g, got %g", tt.want, cfg.PingerStartRate)
+			}
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("expected valid config, got %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected %q error, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package config
+
+import "testing"
+
+// TestValidateStreamingDiscovery validates sweep budget bounds and the /16 limit for full sweeps
+func TestValidateStreamingDiscovery(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"full sweep /16", Config{Networks: []string{"10.0.0.0/16"}}, false},
+		{"full sweep /12", Config{Networks: []string{"172.16.0.0/12"}}, true},
+		{"streaming /8", Config{Networks: []string{"10.0.0.0/8"}, DiscoverySweepBudget: 65536}, false},
+		{"budget too small", Config{Networks: []string{"10.0.0.0/24"}, DiscoverySweepBudget: 10}, true},
+		{"negative budget", Config{Networks: []string{"10.0.0.0/24"}, DiscoverySweepBudget: -1}, true},
+		{"streaming ipv6", Config{Networks: []string{"2001:db8::/120"}, DiscoverySweepBudget: 1024}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateStreamingDiscovery(&tt.cfg)
+			if tt.wantErr && err == nil {
+				t.Error("expected validation error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// strictConfig returns a minimal config with the given community, InfluxDB URL and extra settings
+func strictConfig(community, influxURL, settings string) string {
+	return `
+networks:
+  - "192.168.1.0/24"
+icmp_discovery_interval: "5m"
+ping_interval: "2s"
+` + settings + `
+snmp:
+  community: "` + community + `"
+  port: 161
+  retries: 1
+influxdb:
+  url: "` + influxURL + `"
+  token: "test-token"
+  org: "test-org"
+  bucket: "test-bucket"
+`
+}
+
+// TestStrictValidation verifies warnings become errors only with strict_validation enabled
+func TestStrictValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		community   string
+		influxURL   string
+		settings    string
+		wantWarning bool
+		wantErr     string
+	}{
+		{"clean config", "netscan-ro", "http://influx.example.com:8086", "", false, ""},
+		{"public community warns", "public", "http://influx.example.com:8086", "", true, ""},
+		{"localhost allowed", "netscan-ro", "http://localhost:8086", "", false, ""},
+		{"strict clean config", "netscan-ro", "http://influx.example.com:8086", "strict_validation: true", false, ""},
+		{"strict public community", "public", "http://influx.example.com:8086", "strict_validation: true", false, "community 'public'"},
+		{"strict burst below rate", "netscan-ro", "http://influx.example.com:8086", "strict_validation: true\nping_rate_limit: 100\nping_burst_limit: 50", false, "ping_burst_limit"},
+		{"strict localhost", "netscan-ro", "http://localhost:8086", "strict_validation: true", false, "localhost"},
+		{"strict loopback IP", "netscan-ro", "http://127.0.0.1:8086", "strict_validation: true", false, "localhost"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), "config.yml", strictConfig(tt.community, tt.influxURL, tt.settings))
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+
+			warning, err := ValidateConfig(cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				if err != nil && !strings.HasPrefix(err.Error(), "strict_validation: ") {
+					t.Errorf("expected strict_validation prefix, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected valid config, got %v", err)
+			}
+			if (warning != "") != tt.wantWarning {
+				t.Errorf("expected warning=%v, got %q", tt.wantWarning, warning)
+			}
+		})
+	}
+}
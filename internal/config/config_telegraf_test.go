@@ -0,0 +1,109 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestImportTelegrafPing verifies urls, interval and timeout are extracted from [[inputs.ping]]
+func TestImportTelegrafPing(t *testing.T) {
+	telegrafConf := `
+[agent]
+  interval = "30s" # global default
+
+[[inputs.ping]]
+  ## Hosts to send ping packets to.
+  urls = ["192.168.1.1", "10.0.0.0/30", "core-sw1.example.com"]
+  interval = "10s"
+  count = 1
+  timeout = 2.5
+
+[[inputs.ping]]
+  urls = [
+    "192.168.1.2",
+    "192.168.1.1", # duplicate of the first block
+  ]
+  timeout = 1.0
+  [inputs.ping.tags]
+    site = "fra1"
+`
+	imported, err := ImportTelegrafPing(strings.NewReader(telegrafConf))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if imported.Inputs != 2 {
+		t.Errorf("expected 2 ping inputs, got %d", imported.Inputs)
+	}
+
+	wantNetworks := []string{"192.168.1.1/32", "10.0.0.0/30", "192.168.1.2/32"}
+	if len(imported.Networks) != len(wantNetworks) {
+		t.Fatalf("expected networks %v, got %v", wantNetworks, imported.Networks)
+	}
+	for i, n := range wantNetworks {
+		if imported.Networks[i] != n {
+			t.Errorf("expected network %d to be %s, got %s", i, n, imported.Networks[i])
+		}
+	}
+
+	if len(imported.Skipped) != 1 || imported.Skipped[0] != "core-sw1.example.com" {
+		t.Errorf("expected hostname to be skipped, got %v", imported.Skipped)
+	}
+
+	// Shortest interval wins (10s explicit vs 30s from [agent])
+	if imported.PingInterval != 10*time.Second {
+		t.Errorf("expected ping interval 10s, got %v", imported.PingInterval)
+	}
+
+	// Longest timeout wins so no input gets a tighter deadline than before
+	if imported.PingTimeout != 2500*time.Millisecond {
+		t.Errorf("expected ping timeout 2.5s, got %v", imported.PingTimeout)
+	}
+}
+
+// TestImportTelegrafPingAgentInterval verifies the [agent] interval is used as fallback
+func TestImportTelegrafPingAgentInterval(t *testing.T) {
+	telegrafConf := `
+[agent]
+  interval = "1m"
+[[inputs.ping]]
+  urls = ["192.168.1.1"]
+`
+	imported, err := ImportTelegrafPing(strings.NewReader(telegrafConf))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if imported.PingInterval != time.Minute {
+		t.Errorf("expected ping interval 1m from agent, got %v", imported.PingInterval)
+	}
+}
+
+// TestImportTelegrafPingNoInputs verifies an error is returned when no ping inputs exist
+func TestImportTelegrafPingNoInputs(t *testing.T) {
+	_, err := ImportTelegrafPing(strings.NewReader("[[inputs.cpu]]\n  percpu = true\n"))
+	if err == nil {
+		t.Error("expected error when no [[inputs.ping]] section exists")
+	}
+}
+
+// TestTelegrafPingImportToYAML verifies the rendered stanza can be loaded back as netscan YAML
+func TestTelegrafPingImportToYAML(t *testing.T) {
+	imported := &TelegrafPingImport{
+		Networks:     []string{"192.168.1.1/32"},
+		Skipped:      []string{"printer.local"},
+		PingInterval: 10 * time.Second,
+		PingTimeout:  2 * time.Second,
+		Inputs:       1,
+	}
+
+	out, err := imported.ToYAML()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for _, want := range []string{"networks:", "- 192.168.1.1/32", "ping_interval: 10s", "ping_timeout: 2s", "#   - printer.local"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
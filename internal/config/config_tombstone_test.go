@@ -0,0 +1,54 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestTombstoneTTL validates the tombstone retention default and range
+func TestTombstoneTTL(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		want     time.Duration
+		wantErr  string
+	}{
+		{name: "default", settings: "", want: time.Hour},
+		{name: "disabled", settings: "tombstone_ttl: \"0s\"\n", want: 0},
+		{name: "custom", settings: "tombstone_ttl: \"6h\"\n", want: 6 * time.Hour},
+		{name: "too long", settings: "tombstone_ttl: \"200h\"\n", want: 200 * time.Hour, wantErr: "tombstone_ttl must be between"},
+		{name: "negative", settings: "tombstone_ttl: \"-1m\"\n", want: -time.Minute, wantErr: "tombstone_ttl must be between"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), "config.yml", rollupConfig(tt.settings, ""))
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			if cfg.TombstoneTTL != tt.want {
+				t.Errorf("expected tombstone_ttl %v, got %v", tt.want, cfg.TombstoneTTL)
+			}
+			_, err = ValidateScanConfig(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestTombstoneTTLInvalid rejects unparsable durations at load time
+func TestTombstoneTTLInvalid(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "config.yml", rollupConfig("tombstone_ttl: \"soon\"\n", ""))
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "invalid tombstone_ttl") {
+		t.Errorf("expected invalid tombstone_ttl error, got %v", err)
+	}
+}
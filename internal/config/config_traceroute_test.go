@@ -0,0 +1,53 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestTracerouteConfig validates traceroute defaults, triggers and limits
+func TestTracerouteConfig(t *testing.T) {
+	cfg, err := loadTestConfig(t, "traceroute:\n  enabled: true\n  triggers: [down]")
+	if err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+	tr := cfg.Traceroute
+	if tr.Protocol != TracerouteICMP || tr.MaxHops != 30 || tr.Timeout != time.Second || tr.Cooldown != 10*time.Minute || tr.MaxConcurrent != 4 {
+		t.Errorf("unexpected defaults %+v", tr)
+	}
+	if !tr.Wants(TracerouteOnDown) || tr.Wants(TracerouteOnLatency) {
+		t.Errorf("expected only the down trigger, got %v", tr.Triggers)
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(*TracerouteConfig)
+		wantErr string
+	}{
+		{"disabled ignores limits", func(c *TracerouteConfig) { c.Enabled = false; c.MaxHops = 500 }, ""},
+		{"udp", func(c *TracerouteConfig) { c.Protocol = TracerouteUDP }, ""},
+		{"bad protocol", func(c *TracerouteConfig) { c.Protocol = "tcp" }, "traceroute.protocol"},
+		{"bad trigger", func(c *TracerouteConfig) { c.Triggers = []string{"reboot"} }, "unknown trigger"},
+		{"too many hops", func(c *TracerouteConfig) { c.MaxHops = 65 }, "max_hops"},
+		{"short timeout", func(c *TracerouteConfig) { c.Timeout = 10 * time.Millisecond }, "timeout"},
+		{"short cooldown", func(c *TracerouteConfig) { c.Cooldown = time.Second }, "cooldown"},
+		{"no concurrency", func(c *TracerouteConfig) { c.MaxConcurrent = -1 }, "max_concurrent"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tr
+			tt.mutate(&c)
+			err := validateTracerouteConfig(&c)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
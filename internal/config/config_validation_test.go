@@ -88,3 +88,66 @@ influxdb:
 		t.Errorf("expected default SnmpWorkers=32, got %d", cfg.SnmpWorkers)
 	}
 }
+
+// TestValidateDiscoveryMode validates discovery_mode and TCP discovery settings
+func TestValidateDiscoveryMode(t *testing.T) {
+	base := func() *Config {
+		return &Config{
+			IcmpDiscoveryInterval:    5 * time.Minute,
+			DiscoveryInterval:        4 * time.Hour,
+			IcmpWorkers:              64,
+			SnmpWorkers:              32,
+			PingInterval:             2 * time.Second,
+			SNMP:                     SNMPConfig{Community: "test-community-123", Port: 161, Timeout: 5 * time.Second},
+			InfluxDB:                 InfluxDBConfig{URL: "http://localhost:8086", Token: "t", Org: "o", Bucket: "b"},
+			MaxConcurrentPingers:     100,
+			MaxConcurrentSNMPPollers: 100,
+			MaxDevices:               100,
+			MinScanInterval:          time.Minute,
+			MemoryLimitMB:            512,
+			PingRateLimit:            64,
+			PingBurstLimit:           256,
+			PingWorkers:              256,
+			PingMaxConsecutiveFails:  10,
+			PingBackoffDuration:      5 * time.Minute,
+			SNMPInterval:             time.Hour,
+			SNMPRateLimit:            10,
+			SNMPBurstLimit:           50,
+			SNMPMaxConsecutiveFails:  5,
+			SNMPBackoffDuration:      time.Hour,
+			TCPDiscoveryPorts:        []int{22, 80, 443},
+			TCPDiscoveryTimeout:      time.Second,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr bool
+	}{
+		{"default icmp", func(c *Config) {}, false},
+		{"tcp", func(c *Config) { c.DiscoveryMode = "tcp" }, false},
+		{"both", func(c *Config) { c.DiscoveryMode = "both" }, false},
+		{"unknown mode", func(c *Config) { c.DiscoveryMode = "arp" }, true},
+		{"tcp without ports", func(c *Config) { c.DiscoveryMode = "tcp"; c.TCPDiscoveryPorts = nil }, true},
+		{"tcp invalid port", func(c *Config) { c.DiscoveryMode = "tcp"; c.TCPDiscoveryPorts = []int{0} }, true},
+		{"tcp timeout too long", func(c *Config) { c.DiscoveryMode = "both"; c.TCPDiscoveryTimeout = time.Minute }, true},
+		{"network workers cap", func(c *Config) { c.DiscoveryNetworkWorkers = 16 }, false},
+		{"network workers above icmp_workers", func(c *Config) { c.DiscoveryNetworkWorkers = 65 }, true},
+		{"negative network workers", func(c *Config) { c.DiscoveryNetworkWorkers = -1 }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base()
+			tt.modify(cfg)
+			_, err := ValidateConfig(cfg)
+			if tt.wantErr && err == nil {
+				t.Error("expected validation error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
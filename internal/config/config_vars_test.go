@@ -0,0 +1,97 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// templateYAML is a site-agnostic config using ${vars.*} references
+const templateYAML = `
+vars:
+  site: "default"
+  subnet: "192.168.1"
+  snmp_port: "161"
+networks:
+  - "${vars.subnet}.0/24"
+icmp_discovery_interval: "5m"
+ping_interval: "2s"
+instance_id: "netscan-${vars.site}"
+snmp:
+  community: "test-community-123"
+  port: ${vars.snmp_port}
+influxdb:
+  url: "http://localhost:8086"
+  token: "test-token"
+  org: "test-org"
+  bucket: "netscan_${vars.site}" # ${vars.comment_refs_are_ignored}
+`
+
+// writeFile writes content to dir/name and returns the path
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestLoadConfigVars validates ${vars.*} substitution from the vars block and typed re-resolution
+func TestLoadConfigVars(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yml", templateYAML)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if cfg.InfluxDB.Bucket != "netscan_default" {
+		t.Errorf("expected bucket netscan_default, got %s", cfg.InfluxDB.Bucket)
+	}
+	if len(cfg.Networks) != 1 || cfg.Networks[0] != "192.168.1.0/24" {
+		t.Errorf("expected network 192.168.1.0/24, got %v", cfg.Networks)
+	}
+	if cfg.SNMP.Port != 161 {
+		t.Errorf("expected integer snmp port 161, got %d", cfg.SNMP.Port)
+	}
+}
+
+// TestLoadConfigVarsOverrides validates vars_file and the override file take precedence in order
+func TestLoadConfigVarsOverrides(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "site.yml", "site: fra1\nsubnet: \"10.20.0\"\n")
+	path := writeFile(t, dir, "config.yml", templateYAML+"vars_file: site.yml\n")
+	override := writeFile(t, dir, "override.yml", "site: fra2\n")
+
+	cfg, err := LoadConfigWithVars(path, "")
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if cfg.InfluxDB.Bucket != "netscan_fra1" || cfg.Networks[0] != "10.20.0.0/24" {
+		t.Errorf("expected vars_file values, got bucket %s networks %v", cfg.InfluxDB.Bucket, cfg.Networks)
+	}
+
+	cfg, err = LoadConfigWithVars(path, override)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if cfg.InfluxDB.Bucket != "netscan_fra2" || cfg.InstanceID != "netscan-fra2" {
+		t.Errorf("expected override values, got bucket %s instance %s", cfg.InfluxDB.Bucket, cfg.InstanceID)
+	}
+	if cfg.Networks[0] != "10.20.0.0/24" {
+		t.Errorf("expected vars_file subnet to survive partial override, got %v", cfg.Networks)
+	}
+}
+
+// TestLoadConfigVarsUndefined validates undefined variable references are reported
+func TestLoadConfigVarsUndefined(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yml", strings.Replace(templateYAML, "${vars.site}\"", "${vars.region}\"", 1))
+
+	_, err := LoadConfig(path)
+	if err == nil || !strings.Contains(err.Error(), "${vars.region}") {
+		t.Errorf("expected undefined variable error, got %v", err)
+	}
+}
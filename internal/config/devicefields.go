@@ -0,0 +1,133 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Supported sources for device field rules
+const (
+	FieldSourceSysDescr = "sysDescr"
+	FieldSourceSysName  = "sysName"
+)
+
+// DeviceFieldRule derives a custom device_info field from an SNMP value using a regular expression
+// Example: name "firmware", source "sysDescr", regex "Version ([^,]+)" extracts the IOS version
+type DeviceFieldRule struct {
+	Name   string `yaml:"name"`   // device_info field name
+	Source string `yaml:"source"` // sysDescr or sysName (default: sysDescr)
+	Regex  string `yaml:"regex"`  // RE2 regular expression matched against the source value
+	Value  string `yaml:"value"`  // Expansion template, e.g. "$1" or "${major}.${minor}" (default: $1, or $0 without groups)
+
+	re *regexp.Regexp // Compiled Regex (set by compileDeviceFieldRules)
+}
+
+// Apply evaluates the rule against the SNMP values and returns the derived field value
+// Returns false when the regex does not match or the expansion is empty
+func (r *DeviceFieldRule) Apply(sysName, sysDescr string) (string, bool) {
+	re := r.re
+	if re == nil {
+		var err error
+		if re, err = regexp.Compile(r.Regex); err != nil {
+			return "", false
+		}
+	}
+
+	input := sysDescr
+	if r.Source == FieldSourceSysName {
+		input = sysName
+	}
+
+	match := re.FindStringSubmatchIndex(input)
+	if match == nil {
+		return "", false
+	}
+
+	template := r.Value
+	if template == "" {
+		template = "$0"
+		if re.NumSubexp() > 0 {
+			template = "$1"
+		}
+	}
+	value := string(re.ExpandString(nil, template, input, match))
+	return value, value != ""
+}
+
+// DeriveDeviceFields applies all rules and returns the resulting custom fields (nil if none matched)
+// When several rules write the same field, the first matching rule wins
+func DeriveDeviceFields(rules []DeviceFieldRule, sysName, sysDescr string) map[string]string {
+	var fields map[string]string
+	for i := range rules {
+		if _, exists := fields[rules[i].Name]; exists {
+			continue
+		}
+		if value, ok := rules[i].Apply(sysName, sysDescr); ok {
+			if fields == nil {
+				fields = make(map[string]string)
+			}
+			fields[rules[i].Name] = value
+		}
+	}
+	return fields
+}
+
+// compileDeviceFieldRules applies defaults and compiles every rule's regex
+func compileDeviceFieldRules(rules []DeviceFieldRule) error {
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Source == "" {
+			rule.Source = FieldSourceSysDescr
+		}
+		re, err := regexp.Compile(rule.Regex)
+		if err != nil {
+			return fmt.Errorf("invalid snmp.device_fields[%s].regex: %v", rule.Name, err)
+		}
+		rule.re = re
+	}
+	return nil
+}
+
+// reservedDeviceFields are the standard device_info fields that device field rules cannot replace
+var reservedDeviceFields = map[string]bool{
+	"hostname":         true,
+	"snmp_description": true,
+	"sys_object_id":    true,
+	"sys_uptime_s":     true,
+	"sys_location":     true,
+	"sys_contact":      true,
+	"truncated":        true,
+	// Written from UPnP descriptions found by SSDP discovery
+	"upnp_friendly_name": true,
+	"upnp_manufacturer":  true,
+	"upnp_model_name":    true,
+	"upnp_model_number":  true,
+	"upnp_device_type":   true,
+	"upnp_server":        true,
+	// Written by OS fingerprinting
+	"os_family": true,
+}
+
+// validateDeviceFieldRules checks device field rule names, sources and expressions
+func validateDeviceFieldRules(rules []DeviceFieldRule) error {
+	for _, rule := range rules {
+		if !isValidIdentifier(rule.Name) {
+			return fmt.Errorf("snmp.device_fields: invalid field name %q (use letters, digits and underscores)", rule.Name)
+		}
+		if reservedDeviceFields[rule.Name] {
+			return fmt.Errorf("snmp.device_fields: field name %q is reserved", rule.Name)
+		}
+		switch rule.Source {
+		case "", FieldSourceSysDescr, FieldSourceSysName:
+		default:
+			return fmt.Errorf("snmp.device_fields[%s]: unsupported source %q (sysDescr, sysName)", rule.Name, rule.Source)
+		}
+		if rule.Regex == "" {
+			return fmt.Errorf("snmp.device_fields[%s]: regex is required", rule.Name)
+		}
+		if _, err := regexp.Compile(rule.Regex); err != nil {
+			return fmt.Errorf("snmp.device_fields[%s]: invalid regex: %v", rule.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// DeviceTagRule adds Tags to the devices it matches
+// A device matches when it is in one of Networks or IPRanges (any device when both are empty) and, when Hostname
+// is set, its hostname matches the expression. At least one criterion is required
+type DeviceTagRule struct {
+	Networks []string          `yaml:"networks"`  // CIDR ranges
+	IPRanges []string          `yaml:"ip_ranges"` // Inclusive address ranges, e.g. "10.0.0.10-10.0.0.50"
+	Hostname string            `yaml:"hostname"`  // RE2 regular expression matched against the hostname
+	Tags     map[string]string `yaml:"tags"`      // Written as tags on the device's points, e.g. site: warsaw
+
+	hostnameRE *regexp.Regexp // Compiled Hostname (set by compileDeviceTagRules)
+}
+
+// Matches reports whether the rule applies to the device
+func (r *DeviceTagRule) Matches(ip, hostname string) bool {
+	if len(r.Networks) > 0 || len(r.IPRanges) > 0 {
+		parsed := net.ParseIP(ip)
+		if parsed == nil {
+			return false
+		}
+		inNetwork := len(r.Networks) > 0 && matchesTargets(ip, r.Networks, nil)
+		if !inNetwork && !inIPRanges(parsed, r.IPRanges) {
+			return false
+		}
+	}
+	if r.Hostname != "" && !matchRuleRegex(r.hostnameRE, r.Hostname, hostname) {
+		return false
+	}
+	return len(r.Networks) > 0 || len(r.IPRanges) > 0 || r.Hostname != ""
+}
+
+// inIPRanges reports whether ip lies in one of the ranges; malformed ranges never match
+func inIPRanges(ip net.IP, ranges []string) bool {
+	for _, ipRange := range ranges {
+		first, last, err := parseIPRange(ipRange)
+		if err != nil || (ip.To4() == nil) != (first.To4() == nil) {
+			continue
+		}
+		if bytes.Compare(ip.To16(), first) >= 0 && bytes.Compare(ip.To16(), last) <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIPRange parses "first-last" into 16-byte addresses of the same family, first not after last
+func parseIPRange(ipRange string) (first, last net.IP, err error) {
+	from, to, ok := strings.Cut(ipRange, "-")
+	if !ok {
+		return nil, nil, fmt.Errorf("expected first-last, got %q", ipRange)
+	}
+	first = net.ParseIP(strings.TrimSpace(from))
+	last = net.ParseIP(strings.TrimSpace(to))
+	if first == nil || last == nil {
+		return nil, nil, fmt.Errorf("invalid address in %q", ipRange)
+	}
+	if (first.To4() == nil) != (last.To4() == nil) {
+		return nil, nil, fmt.Errorf("%q mixes IPv4 and IPv6", ipRange)
+	}
+	first, last = first.To16(), last.To16()
+	if bytes.Compare(first, last) > 0 {
+		return nil, nil, fmt.Errorf("%q ends before it starts", ipRange)
+	}
+	return first, last, nil
+}
+
+// DeviceTagger returns a function computing the device_tags of a device: the tags of every matching rule, later
+// rules overriding the keys of earlier ones (nil when no rule matches). Returns nil when device_tags is empty
+func (c *Config) DeviceTagger() func(ip, hostname string) map[string]string {
+	if len(c.DeviceTags) == 0 {
+		return nil
+	}
+	rules := c.DeviceTags
+	return func(ip, hostname string) map[string]string {
+		var tags map[string]string
+		for i := range rules {
+			if !rules[i].Matches(ip, hostname) {
+				continue
+			}
+			if tags == nil {
+				tags = make(map[string]string)
+			}
+			maps.Copy(tags, rules[i].Tags)
+		}
+		return tags
+	}
+}
+
+// compileDeviceTagRules compiles every rule's hostname expression
+func compileDeviceTagRules(rules []DeviceTagRule) error {
+	for i := range rules {
+		if rules[i].Hostname == "" {
+			continue
+		}
+		re, err := regexp.Compile(rules[i].Hostname)
+		if err != nil {
+			return fmt.Errorf("invalid device_tags[%d].hostname: %v", i, err)
+		}
+		rules[i].hostnameRE = re
+	}
+	return nil
+}
+
+// validateDeviceTags checks criteria, ranges and tag keys; netscan's own tags cannot be set, except site
+// when no sites are configured
+func validateDeviceTags(rules []DeviceTagRule, sites []SiteConfig) error {
+	for i, rule := range rules {
+		if len(rule.Networks) == 0 && len(rule.IPRanges) == 0 && rule.Hostname == "" {
+			return fmt.Errorf("device_tags[%d]: set at least one of networks, ip_ranges and hostname", i)
+		}
+		for _, cidr := range rule.Networks {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return fmt.Errorf("device_tags[%d]: invalid network %q", i, cidr)
+			}
+		}
+		for _, ipRange := range rule.IPRanges {
+			if _, _, err := parseIPRange(ipRange); err != nil {
+				return fmt.Errorf("device_tags[%d]: invalid ip_ranges entry: %v", i, err)
+			}
+		}
+		if _, err := regexp.Compile(rule.Hostname); err != nil {
+			return fmt.Errorf("device_tags[%d]: invalid hostname: %v", i, err)
+		}
+		if len(rule.Tags) == 0 {
+			return fmt.Errorf("device_tags[%d]: at least one tag is required", i)
+		}
+		for key, value := range rule.Tags {
+			if !isValidIdentifier(key) {
+				return fmt.Errorf("device_tags[%d]: invalid tag key %q (use letters, digits and underscores)", i, key)
+			}
+			if reservedSiteTags[key] && (key != "site" || len(sites) > 0) {
+				return fmt.Errorf("device_tags[%d]: tag %q is written by netscan and cannot be set", i, key)
+			}
+			if value == "" {
+				return fmt.Errorf("device_tags[%d]: tag %q must not be empty", i, key)
+			}
+		}
+	}
+	return nil
+}
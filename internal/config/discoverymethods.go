@@ -0,0 +1,19 @@
+package config
+
+import "fmt"
+
+// validateDiscoveryMethods checks discovery_methods names are identifiers and listed once
+// Whether a name is a registered discovery plugin is checked by the discovery package at startup
+func validateDiscoveryMethods(methods []string) error {
+	seen := make(map[string]bool, len(methods))
+	for _, name := range methods {
+		if !isValidIdentifier(name) {
+			return fmt.Errorf("discovery_methods: invalid name %q (use letters, digits and underscores)", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("discovery_methods: %q is listed more than once", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"net"
+)
+
+// Exclusions holds CIDRs and hosts inside the scanned networks that must never be probed
+// A nil *Exclusions excludes nothing
+type Exclusions struct {
+	networks []*net.IPNet
+	ips      map[string]bool // Canonical IP strings
+}
+
+// Exclusions returns the exclusion list built from exclude_networks and exclude_ips (nil if both are empty)
+// Entries are validated by ValidateConfig; malformed entries are ignored here
+func (c *Config) Exclusions() *Exclusions {
+	return NewExclusions(c.ExcludeNetworks, c.ExcludeIPs)
+}
+
+// NewExclusions builds an exclusion list, returning nil when there is nothing to exclude
+func NewExclusions(networks, ips []string) *Exclusions {
+	if len(networks) == 0 && len(ips) == 0 {
+		return nil
+	}
+	e := &Exclusions{ips: make(map[string]bool, len(ips))}
+	for _, cidr := range networks {
+		if _, ipnet, err := net.ParseCIDR(cidr); err == nil {
+			e.networks = append(e.networks, ipnet)
+		}
+	}
+	for _, s := range ips {
+		if ip := net.ParseIP(s); ip != nil {
+			e.ips[ip.String()] = true
+		}
+	}
+	return e
+}
+
+// Contains reports whether ip is excluded from discovery and monitoring
+func (e *Exclusions) Contains(ipStr string) bool {
+	if e == nil {
+		return false
+	}
+	ip := net.ParseIP(ipStr)
+	if ip == nil {
+		return false
+	}
+	if e.ips[ip.String()] {
+		return true
+	}
+	for _, ipnet := range e.networks {
+		if ipnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateExclusions checks exclude_networks entries are CIDRs and exclude_ips entries are IP addresses
+func validateExclusions(networks, ips []string) error {
+	for _, cidr := range networks {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("invalid exclude_networks entry %q: %v", cidr, err)
+		}
+	}
+	for _, ip := range ips {
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("invalid exclude_ips entry %q", ip)
+		}
+	}
+	return nil
+}
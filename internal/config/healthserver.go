@@ -0,0 +1,60 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"time"
+)
+
+// minHealthWriteTimeout is the longest an API request legitimately takes (POST /api/probe, SNMP refresh=true)
+const minHealthWriteTimeout = 30 * time.Second
+
+// validateHealthServer checks the health server timeouts, TLS key pair and client certificate settings
+func validateHealthServer(cfg *Config) error {
+	timeouts := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"health_read_timeout", cfg.HealthReadTimeout},
+		{"health_write_timeout", cfg.HealthWriteTimeout},
+		{"health_idle_timeout", cfg.HealthIdleTimeout},
+	}
+	for _, timeout := range timeouts {
+		// Zero is left to net/http (no timeout), as in Config literals built by tests
+		if timeout.value < 0 || (timeout.value > 0 && timeout.value < time.Second) || timeout.value > time.Hour {
+			return fmt.Errorf("%s must be between 1s and 1h, got %v", timeout.name, timeout.value)
+		}
+	}
+
+	if (cfg.HealthTLSCert == "") != (cfg.HealthTLSKey == "") {
+		return fmt.Errorf("health_tls_cert and health_tls_key must be set together")
+	}
+	if cfg.HealthTLSCert != "" {
+		if _, err := tls.LoadX509KeyPair(cfg.HealthTLSCert, cfg.HealthTLSKey); err != nil {
+			return fmt.Errorf("health_tls_cert/health_tls_key: %v", err)
+		}
+	}
+
+	if cfg.HealthTLSClientAuth != "" && cfg.HealthTLSClientAuth != "optional" && cfg.HealthTLSClientAuth != "require" {
+		return fmt.Errorf("health_tls_client_auth must be optional or require, got %q", cfg.HealthTLSClientAuth)
+	}
+	if cfg.HealthTLSClientCA == "" {
+		if len(cfg.APIClientCerts) > 0 {
+			return fmt.Errorf("api_client_certs requires health_tls_client_ca")
+		}
+		return nil
+	}
+	if cfg.HealthTLSCert == "" {
+		return fmt.Errorf("health_tls_client_ca requires health_tls_cert and health_tls_key")
+	}
+	pem, err := os.ReadFile(cfg.HealthTLSClientCA)
+	if err != nil {
+		return fmt.Errorf("health_tls_client_ca: %v", err)
+	}
+	if !x509.NewCertPool().AppendCertsFromPEM(pem) {
+		return fmt.Errorf("health_tls_client_ca: no PEM certificates found in %s", cfg.HealthTLSClientCA)
+	}
+	return nil
+}
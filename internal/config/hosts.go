@@ -0,0 +1,141 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// hostResolveTimeout bounds the resolution of one networks host name
+const hostResolveTimeout = 5 * time.Second
+
+// hostNamePattern matches DNS names: dot-separated labels of letters, digits and hyphens with at least one letter
+var hostNamePattern = regexp.MustCompile(`^(?i:[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?)(\.(?i:[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?))*\.?$`)
+
+// HostLookup resolves a host name to its A and AAAA records
+type HostLookup func(ctx context.Context, name string) ([]net.IP, error)
+
+// LookupHost resolves a host name with the system resolver
+func LookupHost(ctx context.Context, name string) ([]net.IP, error) {
+	return net.DefaultResolver.LookupIP(ctx, "ip", name)
+}
+
+// NetworkHost is a networks entry given as a DNS name instead of a CIDR
+// Each IPv4 address it resolves to is monitored as a /32 network labeled with the host name
+type NetworkHost struct {
+	Name     string   // Host name as written in networks
+	Label    string   // Network label of its addresses: the inline label, otherwise Name
+	IPv4     []string // A records at load time, sorted
+	IPv6     []string // AAAA records at load time, sorted; not monitored (discovery and probes are IPv4 only)
+	Networks []string // /32 entries added to networks; addresses listed before the host name are not repeated
+	Err      error    // Resolution error at load time (nil = resolved)
+}
+
+// isHostEntry reports whether a networks entry is a DNS name rather than a CIDR or an address
+// Names need a dot, so a mistyped CIDR like "office" is still reported as invalid instead of looked up
+func isHostEntry(entry string) bool {
+	if !strings.Contains(strings.TrimSuffix(entry, "."), ".") || net.ParseIP(entry) != nil || len(entry) > 253 || !hostNamePattern.MatchString(entry) {
+		return false
+	}
+	for _, r := range entry {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveHost returns the sorted IPv4 and IPv6 addresses of name, looked up with lookup
+func ResolveHost(ctx context.Context, lookup HostLookup, name string) (ipv4, ipv6 []string, err error) {
+	ctx, cancel := context.WithTimeout(ctx, hostResolveTimeout)
+	defer cancel()
+	addrs, err := lookup(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	seen := make(map[string]bool, len(addrs))
+	for _, addr := range addrs {
+		s := addr.String()
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		if addr.To4() != nil {
+			ipv4 = append(ipv4, addr.To4().String())
+		} else {
+			ipv6 = append(ipv6, s)
+		}
+	}
+	sort.Strings(ipv4)
+	sort.Strings(ipv6)
+	return ipv4, ipv6, nil
+}
+
+// expandHostEntries resolves the host name entries of networks and replaces each with one labeled /32 entry per
+// IPv4 address; CIDR entries are kept as they are. An address listed earlier (as a /32 or by another host name)
+// is not added twice. Names that cannot be resolved are returned with their error and add no entries
+func expandHostEntries(entries []networkEntry, lookup HostLookup) ([]networkEntry, []NetworkHost) {
+	var hosts []NetworkHost
+	listed := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		listed[entry.Network] = true
+	}
+	expanded := make([]networkEntry, 0, len(entries))
+	for _, entry := range entries {
+		if !isHostEntry(entry.Network) {
+			expanded = append(expanded, entry)
+			continue
+		}
+		host := NetworkHost{Name: entry.Network, Label: entry.Label}
+		if host.Label == "" {
+			host.Label = entry.Network
+		}
+		host.IPv4, host.IPv6, host.Err = ResolveHost(context.Background(), lookup, entry.Network)
+		for _, ip := range host.IPv4 {
+			cidr := ip + "/32"
+			if listed[cidr] {
+				continue
+			}
+			listed[cidr] = true
+			expanded = append(expanded, networkEntry{Network: cidr, Label: host.Label})
+			host.Networks = append(host.Networks, cidr)
+		}
+		hosts = append(hosts, host)
+	}
+	return expanded, hosts
+}
+
+// checkSiteHostEntries rejects host names in site networks: re-resolved addresses could not be assigned to a site
+func checkSiteHostEntries(site string, entries []networkEntry) error {
+	for _, entry := range entries {
+		if isHostEntry(entry.Network) {
+			return fmt.Errorf("sites[%s]: network %q is a host name; host names are only supported in the top-level networks", site, entry.Network)
+		}
+	}
+	return nil
+}
+
+// hostWarnings reports host names that did not resolve to an IPv4 address and AAAA records that are not monitored
+func hostWarnings(hosts []NetworkHost, resolveInterval time.Duration) []string {
+	retry := "it is not monitored until netscan restarts"
+	if resolveInterval > 0 {
+		retry = fmt.Sprintf("it is resolved again every %v", resolveInterval)
+	}
+	var warnings []string
+	for _, host := range hosts {
+		switch {
+		case host.Err != nil:
+			warnings = append(warnings, fmt.Sprintf("WARNING: networks host %q could not be resolved (%v); %s", host.Name, host.Err, retry))
+		case len(host.IPv4) == 0:
+			warnings = append(warnings, fmt.Sprintf("WARNING: networks host %q has no IPv4 address; %s", host.Name, retry))
+		}
+		if len(host.IPv6) > 0 {
+			warnings = append(warnings, fmt.Sprintf("WARNING: networks host %q: IPv6 addresses %v are not monitored (discovery and probes are IPv4 only)", host.Name, host.IPv6))
+		}
+	}
+	return warnings
+}
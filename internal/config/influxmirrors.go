@@ -0,0 +1,82 @@
+package config
+
+import "fmt"
+
+// InfluxDBMirror is an additional InfluxDB (e.g. a central instance next to the local one) that receives a copy
+// of every point written to influxdb, with its own batching, retries and health checks
+type InfluxDBMirror struct {
+	Name            string `yaml:"name"`             // Identifies the mirror in logs and /health
+	Version         int    `yaml:"version"`          // 2 (default) or 1, independent of influxdb.version
+	URL             string `yaml:"url"`              // Supports ${VAR} expansion
+	Token           string `yaml:"token"`            // Supports ${VAR} expansion
+	Org             string `yaml:"org"`              // Supports ${VAR} expansion
+	Bucket          string `yaml:"bucket"`           // Bucket of device points (default: influxdb.bucket)
+	HealthBucket    string `yaml:"health_bucket"`    // Bucket (database with version 1) of health metrics (default: influxdb.health_bucket)
+	Username        string `yaml:"username"`         // Version 1: supports ${VAR} expansion
+	Password        string `yaml:"password"`         // Version 1: supports ${VAR} expansion
+	Database        string `yaml:"database"`         // Version 1: database of device points (default: influxdb.database)
+	RetentionPolicy string `yaml:"retention_policy"` // Version 1: "" = database default
+}
+
+// expandInfluxMirrors expands environment variables and fills in the version, buckets and database of the primary influxdb block
+func expandInfluxMirrors(mirrors []InfluxDBMirror, bucket, healthBucket, database string) {
+	for i := range mirrors {
+		m := &mirrors[i]
+		m.URL = expandEnv(m.URL)
+		m.Token = expandEnv(m.Token)
+		m.Org = expandEnv(m.Org)
+		m.Bucket = expandEnv(m.Bucket)
+		m.HealthBucket = expandEnv(m.HealthBucket)
+		m.Username = expandEnv(m.Username)
+		m.Password = expandEnv(m.Password)
+		m.Database = expandEnv(m.Database)
+		if m.Version == 0 {
+			m.Version = InfluxDBVersion2
+		}
+		if m.Database == "" {
+			m.Database = database
+		}
+		if m.Bucket == "" {
+			m.Bucket = bucket
+		}
+		if m.HealthBucket == "" {
+			m.HealthBucket = healthBucket
+		}
+	}
+}
+
+// validateInfluxMirrors checks mirror names and connection settings; no two destinations may write the same bucket
+func validateInfluxMirrors(influx InfluxDBConfig) error {
+	if len(influx.Mirrors) == 0 {
+		return nil
+	}
+	if influx.URL == "" {
+		return fmt.Errorf("influxdb.mirrors requires influxdb.url")
+	}
+	names := make(map[string]bool, len(influx.Mirrors))
+	primary := influx.Target()
+	destinations := map[string]string{primary.URL + " " + primary.Bucket + "/" + primary.RetentionPolicy: "influxdb"}
+	for _, m := range influx.Mirrors {
+		if !isValidIdentifier(m.Name) {
+			return fmt.Errorf("influxdb.mirrors: invalid name %q (use letters, digits and underscores)", m.Name)
+		}
+		if names[m.Name] {
+			return fmt.Errorf("influxdb.mirrors: duplicate name %q", m.Name)
+		}
+		names[m.Name] = true
+
+		if err := validateURL(m.URL); err != nil {
+			return fmt.Errorf("influxdb.mirrors[%s]: url validation failed: %v", m.Name, err)
+		}
+		target := m.Target()
+		if err := validateInfluxTarget("influxdb.mirrors["+m.Name+"]", target); err != nil {
+			return err
+		}
+		key := target.URL + " " + target.Bucket + "/" + target.RetentionPolicy
+		if other, ok := destinations[key]; ok {
+			return fmt.Errorf("influxdb.mirrors[%s]: bucket %q at %s is already written by %s", m.Name, target.Bucket, m.URL, other)
+		}
+		destinations[key] = "influxdb.mirrors[" + m.Name + "]"
+	}
+	return nil
+}
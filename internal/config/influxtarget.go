@@ -0,0 +1,77 @@
+package config
+
+import "fmt"
+
+// InfluxDB API versions (influxdb.version)
+const (
+	InfluxDBVersion1 = 1 // InfluxDB 1.x: username/password, database and retention policy
+	InfluxDBVersion2 = 2 // InfluxDB 2.x: token, org and bucket (default)
+)
+
+// InfluxDBTarget is the connection of one InfluxDB destination, the influxdb block or one of its mirrors
+// With version 1, Bucket is the database and Token and Org are unused
+type InfluxDBTarget struct {
+	Version         int
+	URL             string
+	Token           string
+	Org             string
+	Bucket          string // Bucket (v2) or database (v1) of device points
+	HealthBucket    string // Bucket (v2) or database (v1) of health metrics
+	Username        string // v1 basic auth ("" = no credentials)
+	Password        string
+	RetentionPolicy string // v1 retention policy of device points ("" = database default)
+}
+
+// V1 reports whether the target is written with the InfluxDB 1.x protocol
+func (t InfluxDBTarget) V1() bool {
+	return t.Version == InfluxDBVersion1
+}
+
+// Target returns the connection of the influxdb block
+func (c *InfluxDBConfig) Target() InfluxDBTarget {
+	return influxTarget(c.Version, c.URL, c.Token, c.Org, c.Bucket, c.HealthBucket, c.Username, c.Password, c.Database, c.RetentionPolicy)
+}
+
+// Target returns the connection of the mirror
+func (m *InfluxDBMirror) Target() InfluxDBTarget {
+	return influxTarget(m.Version, m.URL, m.Token, m.Org, m.Bucket, m.HealthBucket, m.Username, m.Password, m.Database, m.RetentionPolicy)
+}
+
+// influxTarget builds a target, using the database as bucket for version 1
+func influxTarget(version int, url, token, org, bucket, healthBucket, username, password, database, retentionPolicy string) InfluxDBTarget {
+	t := InfluxDBTarget{Version: version, URL: url, Token: token, Org: org, Bucket: bucket, HealthBucket: healthBucket}
+	if t.V1() {
+		t.Bucket = database
+		t.Username = username
+		t.Password = password
+		t.RetentionPolicy = retentionPolicy
+	}
+	return t
+}
+
+// validateInfluxTarget checks the credentials and bucket (v2) or database (v1) of a target
+// prefix names the block in errors (e.g. "influxdb" or "influxdb.mirrors[central]")
+func validateInfluxTarget(prefix string, t InfluxDBTarget) error {
+	switch t.Version {
+	case 0, InfluxDBVersion2:
+		if t.Token == "" {
+			return fmt.Errorf("%s.token is required", prefix)
+		}
+		if t.Org == "" {
+			return fmt.Errorf("%s.org is required", prefix)
+		}
+		if t.Bucket == "" {
+			return fmt.Errorf("%s.bucket is required", prefix)
+		}
+	case InfluxDBVersion1:
+		if t.Bucket == "" {
+			return fmt.Errorf("%s.database is required with version 1", prefix)
+		}
+		if t.Password != "" && t.Username == "" {
+			return fmt.Errorf("%s.password requires %s.username", prefix, prefix)
+		}
+	default:
+		return fmt.Errorf("%s.version must be 1 or 2, got %d", prefix, t.Version)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Inventory export file formats (inventory_export.format)
+const (
+	InventoryExportCSV  = "csv"
+	InventoryExportJSON = "json"
+)
+
+// InventoryExportConfig writes timestamped snapshots of the device inventory to a directory for audit and compliance
+type InventoryExportConfig struct {
+	Dir      string        `yaml:"dir"`      // Directory receiving the snapshots, created if missing ("" = disabled)
+	Interval time.Duration `yaml:"interval"` // Time between snapshots (default: 24h)
+	Format   string        `yaml:"format"`   // csv or json (default: csv)
+	Keep     int           `yaml:"keep"`     // Newest snapshots kept, older ones are deleted (0 = keep all)
+}
+
+// Enabled reports whether periodic inventory snapshots are configured
+func (c *InventoryExportConfig) Enabled() bool {
+	return c.Dir != ""
+}
+
+// applyInventoryExportDefaults fills in the interval and format of an enabled export
+func applyInventoryExportDefaults(cfg *InventoryExportConfig) {
+	if !cfg.Enabled() {
+		return
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = 24 * time.Hour
+	}
+	if cfg.Format == "" {
+		cfg.Format = InventoryExportCSV
+	}
+}
+
+// validateInventoryExport checks the interval, format and retention of an enabled export
+func validateInventoryExport(cfg *InventoryExportConfig) error {
+	if !cfg.Enabled() {
+		return nil
+	}
+	if cfg.Interval < time.Minute {
+		return fmt.Errorf("inventory_export.interval must be at least 1m, got %v", cfg.Interval)
+	}
+	if cfg.Format != InventoryExportCSV && cfg.Format != InventoryExportJSON {
+		return fmt.Errorf("inventory_export.format must be csv or json, got %q", cfg.Format)
+	}
+	if cfg.Keep < 0 {
+		return fmt.Errorf("inventory_export.keep must not be negative, got %d", cfg.Keep)
+	}
+	return nil
+}
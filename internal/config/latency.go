@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// Latency threshold levels, in increasing severity
+const (
+	LatencyLevelOK       = "ok"
+	LatencyLevelWarning  = "warning"
+	LatencyLevelCritical = "critical"
+)
+
+// LatencyThresholdConfig raises alerts when a device's ping RTT exceeds a warning or critical limit
+// A threshold with neither Networks nor Devices applies to every monitored device
+type LatencyThresholdConfig struct {
+	Name       string        `yaml:"name"`       // Written as the threshold tag
+	Networks   []string      `yaml:"networks"`   // CIDR ranges the threshold applies to
+	Devices    []string      `yaml:"devices"`    // Individual device IPs the threshold applies to
+	Warning    time.Duration `yaml:"warning"`    // RTT above which the device is in warning (0 = no warning level)
+	Critical   time.Duration `yaml:"critical"`   // RTT above which the device is critical (0 = no critical level)
+	Percentile float64       `yaml:"percentile"` // Compare this percentile of the RTT history instead of each cycle's RTT (0 = each cycle)
+}
+
+// Matches reports whether the threshold applies to the given device IP
+func (t *LatencyThresholdConfig) Matches(ip string) bool {
+	return matchesTargets(ip, t.Networks, t.Devices)
+}
+
+// Level returns the level an RTT falls into and the limit it exceeded (0 for ok)
+func (t *LatencyThresholdConfig) Level(rtt time.Duration) (string, time.Duration) {
+	if t.Critical > 0 && rtt > t.Critical {
+		return LatencyLevelCritical, t.Critical
+	}
+	if t.Warning > 0 && rtt > t.Warning {
+		return LatencyLevelWarning, t.Warning
+	}
+	return LatencyLevelOK, 0
+}
+
+// validateLatencyThresholds checks names, targets, limits and percentiles of latency thresholds
+func validateLatencyThresholds(thresholds []LatencyThresholdConfig) error {
+	names := make(map[string]bool)
+	for _, t := range thresholds {
+		if !isValidIdentifier(t.Name) {
+			return fmt.Errorf("latency_thresholds: invalid name %q (use letters, digits and underscores)", t.Name)
+		}
+		if names[t.Name] {
+			return fmt.Errorf("latency_thresholds: duplicate name %q", t.Name)
+		}
+		names[t.Name] = true
+
+		for _, cidr := range t.Networks {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return fmt.Errorf("latency_thresholds[%s]: invalid network %q", t.Name, cidr)
+			}
+		}
+		for _, device := range t.Devices {
+			if net.ParseIP(device) == nil {
+				return fmt.Errorf("latency_thresholds[%s]: invalid device IP %q", t.Name, device)
+			}
+		}
+
+		if t.Warning < 0 || t.Critical < 0 {
+			return fmt.Errorf("latency_thresholds[%s]: warning and critical must not be negative", t.Name)
+		}
+		if t.Warning == 0 && t.Critical == 0 {
+			return fmt.Errorf("latency_thresholds[%s]: warning or critical is required", t.Name)
+		}
+		if t.Warning > 0 && t.Critical > 0 && t.Critical <= t.Warning {
+			return fmt.Errorf("latency_thresholds[%s]: critical (%v) must be above warning (%v)", t.Name, t.Critical, t.Warning)
+		}
+		if t.Percentile < 0 || t.Percentile > 100 {
+			return fmt.Errorf("latency_thresholds[%s]: percentile must be between 1 and 100, got %v", t.Name, t.Percentile)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// Maintenance window actions
+const (
+	MaintenanceSkip = "skip" // Pings and SNMP polls are not sent
+	MaintenanceTag  = "tag"  // Probes run, their points are tagged maintenance=true
+)
+
+// maxMaintenanceDuration bounds recurring windows, so at most a week of past starts must be checked
+const maxMaintenanceDuration = 7 * 24 * time.Hour
+
+// maintenanceTimeLayout is the wall-clock format of one-off window times, in the schedule timezone
+const maintenanceTimeLayout = "2006-01-02 15:04"
+
+// MaintenanceWindowConfig is a planned outage during which failed probes neither trip circuit breakers
+// nor report devices down. A window is either one-off (Start and End) or recurring (Time and Duration on Days)
+// A window with neither Networks nor Devices applies to every monitored device
+type MaintenanceWindowConfig struct {
+	Name     string        `yaml:"name"`
+	Networks []string      `yaml:"networks"` // CIDR ranges the window applies to
+	Devices  []string      `yaml:"devices"`  // Individual device IPs the window applies to
+	Start    string        `yaml:"start"`    // One-off: "2006-01-02 15:04" in the schedule timezone, or RFC 3339
+	End      string        `yaml:"end"`      // One-off: end, same formats as Start
+	Days     []string      `yaml:"days"`     // Recurring: weekdays the window starts on ("mon".."sun", empty = every day)
+	Time     string        `yaml:"time"`     // Recurring: start time (HH:MM) in the schedule timezone
+	Duration time.Duration `yaml:"duration"` // Recurring: window length (at most 7 days)
+	Action   string        `yaml:"action"`   // skip (default) or tag
+}
+
+// Matches reports whether the window applies to the given device IP
+func (m *MaintenanceWindowConfig) Matches(ip string) bool {
+	return matchesTargets(ip, m.Networks, m.Devices)
+}
+
+// maintenanceWindow is a MaintenanceWindowConfig with its times parsed
+type maintenanceWindow struct {
+	cfg        *MaintenanceWindowConfig
+	start, end time.Time             // One-off window (zero for recurring)
+	at         DailyTime             // Recurring start time
+	days       map[time.Weekday]bool // Recurring start days (nil = every day)
+	loc        *time.Location
+}
+
+// compileMaintenanceWindow parses the times of a window; loc resolves wall-clock times
+func compileMaintenanceWindow(cfg *MaintenanceWindowConfig, loc *time.Location) (*maintenanceWindow, error) {
+	w := &maintenanceWindow{cfg: cfg, loc: loc}
+	oneOff := cfg.Start != "" || cfg.End != ""
+	recurring := cfg.Time != "" || cfg.Duration != 0 || len(cfg.Days) > 0
+	switch {
+	case oneOff && recurring:
+		return nil, fmt.Errorf("use either start/end or time/duration/days, not both")
+	case oneOff:
+		var err error
+		if w.start, err = parseMaintenanceTime(cfg.Start, loc); err != nil {
+			return nil, fmt.Errorf("start: %v", err)
+		}
+		if w.end, err = parseMaintenanceTime(cfg.End, loc); err != nil {
+			return nil, fmt.Errorf("end: %v", err)
+		}
+		if !w.end.After(w.start) {
+			return nil, fmt.Errorf("end (%s) must be after start (%s)", cfg.End, cfg.Start)
+		}
+	case recurring:
+		var err error
+		if w.at, err = ParseDailyTime(cfg.Time); err != nil {
+			return nil, fmt.Errorf("time: %v", err)
+		}
+		if cfg.Duration <= 0 || cfg.Duration > maxMaintenanceDuration {
+			return nil, fmt.Errorf("duration must be positive and at most 168h, got %v", cfg.Duration)
+		}
+		if w.days, err = parseWeekdays(cfg.Days); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("start and end, or time and duration, are required")
+	}
+	return w, nil
+}
+
+// parseMaintenanceTime parses a one-off window time in loc, or with its own offset when written as RFC 3339
+func parseMaintenanceTime(s string, loc *time.Location) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, fmt.Errorf("is required")
+	}
+	if t, err := time.ParseInLocation(maintenanceTimeLayout, s, loc); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q (use \"YYYY-MM-DD HH:MM\" or RFC 3339)", s)
+	}
+	return t, nil
+}
+
+// active reports whether the window is open at t
+func (w *maintenanceWindow) active(t time.Time) bool {
+	if !w.start.IsZero() {
+		return !t.Before(w.start) && t.Before(w.end)
+	}
+	local := t.In(w.loc)
+	for back := 0; back <= 7; back++ {
+		start := w.at.on(local.Year(), local.Month(), local.Day()-back, w.loc)
+		if w.days != nil && !w.days[start.Weekday()] {
+			continue
+		}
+		if !t.Before(start) && t.Before(start.Add(w.cfg.Duration)) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaintenanceResolver returns a function reporting the action of the maintenance windows a device is in at a
+// given time: skip when any open window skips probes, tag when only tagging windows are open, "" otherwise
+// Returns nil when no maintenance windows are configured
+func (c *Config) MaintenanceResolver() func(ip string, at time.Time) string {
+	var windows []*maintenanceWindow
+	for i := range c.MaintenanceWindows {
+		if w, err := compileMaintenanceWindow(&c.MaintenanceWindows[i], c.ScheduleLocation()); err == nil {
+			windows = append(windows, w)
+		}
+	}
+	if len(windows) == 0 {
+		return nil
+	}
+	return func(ip string, at time.Time) string {
+		action := ""
+		for _, w := range windows {
+			if !w.active(at) || !w.cfg.Matches(ip) {
+				continue
+			}
+			if w.cfg.Action == MaintenanceTag {
+				action = MaintenanceTag
+				continue
+			}
+			return MaintenanceSkip
+		}
+		return action
+	}
+}
+
+// applyMaintenanceWindowDefaults fills in the default action of maintenance windows
+func applyMaintenanceWindowDefaults(windows []MaintenanceWindowConfig) {
+	for i := range windows {
+		if windows[i].Action == "" {
+			windows[i].Action = MaintenanceSkip
+		}
+	}
+}
+
+// validateMaintenanceWindows checks names, targets, times and actions of maintenance windows
+func validateMaintenanceWindows(windows []MaintenanceWindowConfig, loc *time.Location) error {
+	names := make(map[string]bool)
+	for i := range windows {
+		w := &windows[i]
+		if !isValidIdentifier(w.Name) {
+			return fmt.Errorf("maintenance_windows: invalid name %q (use letters, digits and underscores)", w.Name)
+		}
+		if names[w.Name] {
+			return fmt.Errorf("maintenance_windows: duplicate name %q", w.Name)
+		}
+		names[w.Name] = true
+
+		for _, cidr := range w.Networks {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return fmt.Errorf("maintenance_windows[%s]: invalid network %q", w.Name, cidr)
+			}
+		}
+		for _, device := range w.Devices {
+			if net.ParseIP(device) == nil {
+				return fmt.Errorf("maintenance_windows[%s]: invalid device IP %q", w.Name, device)
+			}
+		}
+		if w.Action != "" && w.Action != MaintenanceSkip && w.Action != MaintenanceTag {
+			return fmt.Errorf("maintenance_windows[%s]: action must be skip or tag, got %q", w.Name, w.Action)
+		}
+		if _, err := compileMaintenanceWindow(w, loc); err != nil {
+			return fmt.Errorf("maintenance_windows[%s]: %v", w.Name, err)
+		}
+	}
+	return nil
+}
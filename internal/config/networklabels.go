@@ -0,0 +1,169 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// networkEntry is one item of networks: a plain CIDR or a mapping with a friendly label
+//
+//	networks:
+//	  - "192.168.0.0/24"
+//	  - network: "10.10.0.0/16"
+//	    label: "fra1-servers"
+type networkEntry struct {
+	Network string `yaml:"network"`
+	Label   string `yaml:"label"`
+}
+
+// UnmarshalYAML accepts both the plain CIDR and the mapping form
+func (e *networkEntry) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&e.Network)
+	}
+	type plain networkEntry
+	if err := node.Decode((*plain)(e)); err != nil {
+		return err
+	}
+	if e.Network == "" {
+		return fmt.Errorf("line %d: networks entry without network", node.Line)
+	}
+	return nil
+}
+
+// splitNetworkEntries returns the CIDRs of entries and network_labels extended with the entries' labels
+// A network labeled both inline and in network_labels must use the same label
+func splitNetworkEntries(entries []networkEntry, labels map[string]string) ([]string, map[string]string, error) {
+	var networks []string
+	for _, entry := range entries {
+		networks = append(networks, entry.Network)
+		if entry.Label == "" {
+			continue
+		}
+		if existing, ok := labels[entry.Network]; ok && existing != entry.Label {
+			return nil, nil, fmt.Errorf("network %q is labeled %q in networks and %q in network_labels", entry.Network, entry.Label, existing)
+		}
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[entry.Network] = entry.Label
+	}
+	return networks, labels, nil
+}
+
+// NetworkLabel returns the friendly label of a configured network ("" when it has none)
+// cidr may be written differently from the config (e.g. "10.0.0.1/24" for "10.0.0.0/24")
+func (c *Config) NetworkLabel(cidr string) string {
+	if label, ok := c.NetworkLabels[cidr]; ok {
+		return label
+	}
+	_, target, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return ""
+	}
+	for key, label := range c.NetworkLabels {
+		if _, ipnet, err := net.ParseCIDR(key); err == nil && ipnet.String() == target.String() {
+			return label
+		}
+	}
+	return ""
+}
+
+// DisplayNetwork names a network for logs and reports: "label (cidr)", or the CIDR when it has no label
+func (c *Config) DisplayNetwork(cidr string) string {
+	if label := c.NetworkLabel(cidr); label != "" {
+		return label + " (" + cidr + ")"
+	}
+	return cidr
+}
+
+// DisplayNetworks applies DisplayNetwork to every network
+func (c *Config) DisplayNetworks(networks []string) []string {
+	names := make([]string, len(networks))
+	for i, cidr := range networks {
+		names[i] = c.DisplayNetwork(cidr)
+	}
+	return names
+}
+
+// labeledNetwork is a configured network and the value it resolves to
+type labeledNetwork struct {
+	ipnet *net.IPNet
+	tag   string
+}
+
+// NetworkResolver returns a function mapping an IP to the most specific configured network containing it:
+// its label if one is set (inline in networks or in network_labels), otherwise the CIDR as written in networks
+// ("" outside every network)
+// Entries are validated by ValidateConfig; malformed networks are ignored here
+func (c *Config) NetworkResolver() func(ip string) string {
+	return c.networkMatcher(func(cidr string) string {
+		if label := c.NetworkLabel(cidr); label != "" {
+			return label
+		}
+		return cidr
+	})
+}
+
+// SubnetResolver returns a function mapping an IP to the most specific configured network containing it,
+// as written in networks regardless of labels ("" outside every network)
+func (c *Config) SubnetResolver() func(ip string) string {
+	return c.networkMatcher(func(cidr string) string { return cidr })
+}
+
+// networkMatcher returns a function mapping an IP to tag(cidr) of the most specific network containing it
+func (c *Config) networkMatcher(tag func(cidr string) string) func(ip string) string {
+	var networks []labeledNetwork
+	for _, cidr := range c.Networks {
+		_, ipnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		networks = append(networks, labeledNetwork{ipnet: ipnet, tag: tag(cidr)})
+	}
+	sort.SliceStable(networks, func(i, j int) bool {
+		a, _ := networks[i].ipnet.Mask.Size()
+		b, _ := networks[j].ipnet.Mask.Size()
+		return a > b
+	})
+
+	return func(ipStr string) string {
+		ip := net.ParseIP(ipStr)
+		if ip == nil {
+			return ""
+		}
+		for _, network := range networks {
+			if network.ipnet.Contains(ip) {
+				return network.tag
+			}
+		}
+		return ""
+	}
+}
+
+// validateNetworkLabels checks every labeled network is one of the configured networks and every label is set
+func validateNetworkLabels(labels map[string]string, networks []string) error {
+	configured := make(map[string]bool, len(networks))
+	for _, cidr := range networks {
+		if _, ipnet, err := net.ParseCIDR(cidr); err == nil {
+			configured[ipnet.String()] = true
+		}
+	}
+	for cidr, label := range labels {
+		_, ipnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("invalid network_labels network %q: %v", cidr, err)
+		}
+		if !configured[ipnet.String()] {
+			return fmt.Errorf("network_labels network %q is not in networks", cidr)
+		}
+		if strings.TrimSpace(label) == "" {
+			return fmt.Errorf("network_labels label for %q must not be empty", cidr)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"net"
+)
+
+// NetworkRateLimit is a ping rate limit partition for one network
+// Probes to addresses inside Network take a token from this partition and from the global ping limiter
+type NetworkRateLimit struct {
+	Network string  `yaml:"network"` // CIDR the partition applies to
+	Rate    float64 `yaml:"rate"`    // Tokens per second
+	Burst   int     `yaml:"burst"`   // Token bucket capacity (0 = rate rounded up, at least 1)
+}
+
+// BurstOrDefault returns the partition's burst, defaulting to the rate rounded up (at least 1)
+func (n NetworkRateLimit) BurstOrDefault() int {
+	if n.Burst > 0 {
+		return n.Burst
+	}
+	return max(int(math.Ceil(n.Rate)), 1)
+}
+
+// validateNetworkRateLimits checks every partition has a valid CIDR, a positive rate and a unique network
+func validateNetworkRateLimits(limits []NetworkRateLimit) error {
+	seen := make(map[string]bool, len(limits))
+	for _, limit := range limits {
+		_, ipnet, err := net.ParseCIDR(limit.Network)
+		if err != nil {
+			return fmt.Errorf("invalid network_rate_limits network %q: %v", limit.Network, err)
+		}
+		if seen[ipnet.String()] {
+			return fmt.Errorf("duplicate network_rate_limits network %q", limit.Network)
+		}
+		seen[ipnet.String()] = true
+		if limit.Rate <= 0 {
+			return fmt.Errorf("network_rate_limits rate for %s must be greater than 0, got %.2f", limit.Network, limit.Rate)
+		}
+		if limit.Burst < 0 {
+			return fmt.Errorf("network_rate_limits burst for %s must not be negative, got %d", limit.Network, limit.Burst)
+		}
+	}
+	return nil
+}
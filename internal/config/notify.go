@@ -0,0 +1,125 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Notification event types a webhook can subscribe to
+const (
+	NotifyEventDown        = "down"         // Device stopped answering pings
+	NotifyEventUp          = "up"           // Device answers pings again
+	NotifyEventSuspended   = "suspended"    // Circuit breaker suspended pinging of the device
+	NotifyEventCheckFailed = "check_failed" // A composite check became unhealthy
+	NotifyEventCheckOK     = "check_ok"     // A composite check is healthy again
+
+	NotifyEventLatencyWarning  = "latency_warning"  // Device RTT exceeded a latency threshold's warning limit
+	NotifyEventLatencyCritical = "latency_critical" // Device RTT exceeded a latency threshold's critical limit
+	NotifyEventLatencyOK       = "latency_ok"       // Device RTT is back within its latency threshold
+)
+
+// notifyEvents lists every notification event type
+var notifyEvents = []string{NotifyEventDown, NotifyEventUp, NotifyEventSuspended, NotifyEventCheckFailed, NotifyEventCheckOK,
+	NotifyEventLatencyWarning, NotifyEventLatencyCritical, NotifyEventLatencyOK}
+
+// isNotifyEvent reports whether event is a notification event type
+func isNotifyEvent(event string) bool {
+	for _, e := range notifyEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Supported webhook payload formats
+const (
+	WebhookFormatGeneric = "generic" // Flat JSON object with all event fields
+	WebhookFormatSlack   = "slack"   // Slack incoming webhook ({"text": ...})
+	WebhookFormatTeams   = "teams"   // Microsoft Teams connector MessageCard
+)
+
+// NotifyConfig configures device state change notifications
+type NotifyConfig struct {
+	RateLimit int             `yaml:"rate_limit"` // Max messages per webhook per minute; excess events are dropped
+	Webhooks  []WebhookConfig `yaml:"webhooks"`
+	Syslog    []SyslogConfig  `yaml:"syslog"` // Syslog receivers (RFC 5424), not rate limited
+}
+
+// WebhookConfig defines one webhook receiving device state change notifications
+type WebhookConfig struct {
+	Name     string   `yaml:"name"`     // Identifies the webhook in logs (the URL often embeds a secret)
+	URL      string   `yaml:"url"`      // http(s) endpoint receiving a JSON POST
+	Format   string   `yaml:"format"`   // generic, slack or teams (default: generic)
+	Events   []string `yaml:"events"`   // Subset of down, up, suspended, check_failed, check_ok, latency_* (default: all)
+	Template string   `yaml:"template"` // Go text/template for the message text (default: built-in per event)
+}
+
+// Wants reports whether the webhook subscribes to an event type
+func (w *WebhookConfig) Wants(event string) bool {
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// applyNotifyDefaults fills in the rate limit, payload formats and event subscriptions
+func applyNotifyDefaults(cfg *NotifyConfig) {
+	if cfg.RateLimit == 0 {
+		cfg.RateLimit = 10 // Default: 10 messages per webhook per minute
+	}
+	for i := range cfg.Webhooks {
+		hook := &cfg.Webhooks[i]
+		if hook.Format == "" {
+			hook.Format = WebhookFormatGeneric
+		}
+		if len(hook.Events) == 0 {
+			hook.Events = append([]string(nil), notifyEvents...)
+		}
+	}
+	for i := range cfg.Syslog {
+		applySyslogDefaults(&cfg.Syslog[i])
+	}
+}
+
+// validateNotifyConfig checks webhook names, URLs, formats, event types and message templates
+func validateNotifyConfig(cfg *NotifyConfig) error {
+	if cfg.RateLimit < 0 || cfg.RateLimit > 600 {
+		return fmt.Errorf("notifications.rate_limit must be between 0 and 600 messages per minute, got %d", cfg.RateLimit)
+	}
+
+	names := make(map[string]bool)
+	for _, hook := range cfg.Webhooks {
+		if !isValidIdentifier(hook.Name) {
+			return fmt.Errorf("notifications.webhooks: invalid name %q (use letters, digits and underscores)", hook.Name)
+		}
+		if names[hook.Name] {
+			return fmt.Errorf("notifications.webhooks: duplicate name %q", hook.Name)
+		}
+		names[hook.Name] = true
+
+		if err := validateURL(hook.URL); err != nil {
+			return fmt.Errorf("notifications.webhooks[%s]: invalid url: %v", hook.Name, err)
+		}
+		switch hook.Format {
+		case "", WebhookFormatGeneric, WebhookFormatSlack, WebhookFormatTeams:
+		default:
+			return fmt.Errorf("notifications.webhooks[%s]: unsupported format %q (generic, slack, teams)", hook.Name, hook.Format)
+		}
+		for _, event := range hook.Events {
+			if !isNotifyEvent(event) {
+				return fmt.Errorf("notifications.webhooks[%s]: unsupported event %q (down, up, suspended, check_failed, check_ok, latency_warning, latency_critical, latency_ok)", hook.Name, event)
+			}
+		}
+		if hook.Template != "" {
+			// Same functions as the notifier provides to templates
+			if _, err := template.New(hook.Name).Funcs(template.FuncMap{"join": strings.Join}).Parse(hook.Template); err != nil {
+				return fmt.Errorf("notifications.webhooks[%s]: invalid template: %v", hook.Name, err)
+			}
+		}
+	}
+	return validateSyslogConfigs(cfg.Syslog)
+}
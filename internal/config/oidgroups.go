@@ -0,0 +1,163 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Supported custom OID value types
+const (
+	OIDTypeInteger = "integer" // Signed integer (INTEGER, Integer32)
+	OIDTypeCounter = "counter" // Unsigned counter or gauge (Counter32, Counter64, Gauge32, TimeTicks)
+	OIDTypeFloat   = "float"   // Floating point, also parsed from numeric OctetStrings (e.g. "23.5")
+	OIDTypeString  = "string"  // OctetString, sanitized like sysName/sysDescr
+)
+
+// reservedMeasurements are written by netscan itself and cannot be used by custom OID groups
+var reservedMeasurements = map[string]bool{
+	"ping":            true,
+	"device_info":     true,
+	"snmp_interface":  true,
+	"health_metrics":  true,
+	"latency_alert":   true,
+	"device_state":    true,
+	"composite_check": true,
+	"traceroute":      true,
+	"annotations":     true,
+	"subnet_health":   true,
+}
+
+// OIDConfig defines a single custom OID polled as part of an OID group
+type OIDConfig struct {
+	Name  string  `yaml:"name"`  // InfluxDB field name for this value
+	OID   string  `yaml:"oid"`   // Numeric OID including instance suffix (e.g. 1.3.6.1.2.1.33.1.2.4.0)
+	Type  string  `yaml:"type"`  // integer, counter, float or string (default: integer)
+	Scale float64 `yaml:"scale"` // Multiplier applied to numeric values (default: 1.0)
+}
+
+// OIDGroupConfig defines a named set of OIDs polled on devices matching Networks or Devices
+// A group with neither Networks nor Devices applies to every SNMP-polled device
+type OIDGroupConfig struct {
+	Name        string      `yaml:"name"`        // Group name, written as the oid_group tag
+	Measurement string      `yaml:"measurement"` // InfluxDB measurement name (default: snmp_<name>)
+	Networks    []string    `yaml:"networks"`    // CIDR ranges the group applies to
+	Devices     []string    `yaml:"devices"`     // Individual device IPs the group applies to
+	OIDs        []OIDConfig `yaml:"oids"`
+}
+
+// Matches reports whether the group applies to the given device IP
+func (g *OIDGroupConfig) Matches(ip string) bool {
+	return matchesTargets(ip, g.Networks, g.Devices)
+}
+
+// applyOIDGroupDefaults fills in default measurement names, types and scale factors
+func applyOIDGroupDefaults(groups []OIDGroupConfig) {
+	for i := range groups {
+		group := &groups[i]
+		if group.Measurement == "" {
+			group.Measurement = "snmp_" + group.Name
+		}
+		for j := range group.OIDs {
+			oid := &group.OIDs[j]
+			oid.OID = strings.TrimPrefix(oid.OID, ".")
+			if oid.Type == "" {
+				oid.Type = OIDTypeInteger
+			}
+			if oid.Scale == 0 {
+				oid.Scale = 1.0
+			}
+		}
+	}
+}
+
+// validateOIDGroups checks custom OID group definitions for naming, OID syntax and target scope
+func validateOIDGroups(groups []OIDGroupConfig) error {
+	groupNames := make(map[string]bool)
+	for _, group := range groups {
+		if !isValidIdentifier(group.Name) {
+			return fmt.Errorf("snmp.oid_groups: invalid group name %q (use letters, digits and underscores)", group.Name)
+		}
+		if groupNames[group.Name] {
+			return fmt.Errorf("snmp.oid_groups: duplicate group name %q", group.Name)
+		}
+		groupNames[group.Name] = true
+
+		if !isValidIdentifier(group.Measurement) {
+			return fmt.Errorf("snmp.oid_groups[%s]: invalid measurement name %q", group.Name, group.Measurement)
+		}
+		if reservedMeasurements[group.Measurement] {
+			return fmt.Errorf("snmp.oid_groups[%s]: measurement %q is reserved by netscan", group.Name, group.Measurement)
+		}
+
+		for _, cidr := range group.Networks {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return fmt.Errorf("snmp.oid_groups[%s]: invalid network %q", group.Name, cidr)
+			}
+		}
+		for _, device := range group.Devices {
+			if net.ParseIP(device) == nil {
+				return fmt.Errorf("snmp.oid_groups[%s]: invalid device IP %q", group.Name, device)
+			}
+		}
+
+		if len(group.OIDs) == 0 {
+			return fmt.Errorf("snmp.oid_groups[%s]: at least one OID is required", group.Name)
+		}
+		fieldNames := make(map[string]bool)
+		for _, oid := range group.OIDs {
+			if !isValidIdentifier(oid.Name) {
+				return fmt.Errorf("snmp.oid_groups[%s]: invalid field name %q", group.Name, oid.Name)
+			}
+			if oid.Name == "truncated" {
+				return fmt.Errorf("snmp.oid_groups[%s]: field name %q is written by netscan", group.Name, oid.Name)
+			}
+			if fieldNames[oid.Name] {
+				return fmt.Errorf("snmp.oid_groups[%s]: duplicate field name %q", group.Name, oid.Name)
+			}
+			fieldNames[oid.Name] = true
+
+			if !isNumericOID(oid.OID) {
+				return fmt.Errorf("snmp.oid_groups[%s].%s: invalid OID %q (must be dotted numeric)", group.Name, oid.Name, oid.OID)
+			}
+			switch oid.Type {
+			case OIDTypeInteger, OIDTypeCounter, OIDTypeFloat, OIDTypeString:
+			default:
+				return fmt.Errorf("snmp.oid_groups[%s].%s: unsupported type %q (integer, counter, float, string)", group.Name, oid.Name, oid.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// isValidIdentifier checks that a name is safe to use as an InfluxDB measurement or field name
+func isValidIdentifier(name string) bool {
+	if name == "" || len(name) > 64 {
+		return false
+	}
+	for _, char := range name {
+		if !((char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') ||
+			(char >= '0' && char <= '9') || char == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// isNumericOID checks that an OID consists only of dot-separated decimal arcs
+func isNumericOID(oid string) bool {
+	if oid == "" {
+		return false
+	}
+	for _, arc := range strings.Split(oid, ".") {
+		if arc == "" {
+			return false
+		}
+		for _, char := range arc {
+			if char < '0' || char > '9' {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+)
+
+// OS families inferred by OS fingerprinting
+const (
+	OSFamilyWindows = "windows"
+	OSFamilyLinux   = "linux"
+	OSFamilyMacOS   = "macos"
+	OSFamilyBSD     = "bsd"
+	OSFamilyUnix    = "unix"    // TTL 64 without anything telling Linux, macOS and BSD apart, or another Unix
+	OSFamilyNetwork = "network" // Router/switch/firewall operating systems (Cisco IOS, JunOS, RouterOS, ...)
+)
+
+// OSFingerprintConfig infers the os_family device_info field of devices without SNMP from the TTL of an ICMP
+// echo reply and the TCP ports they accept; sysDescr keywords take precedence when SNMP answers
+type OSFingerprintConfig struct {
+	Enabled     bool          `yaml:"enabled"`
+	ProbePorts  bool          `yaml:"probe_ports"`  // TCP connect to the signature ports once per new device
+	PortTimeout time.Duration `yaml:"port_timeout"` // Timeout of each port probe (default: DefaultClassificationPortTimeout)
+}
+
+// osSysDescrSignatures map sysDescr keywords to OS families; network operating systems come first since
+// many of them mention the Unix they are built on
+var osSysDescrSignatures = []struct {
+	family string
+	re     *regexp.Regexp
+}{
+	{OSFamilyNetwork, regexp.MustCompile(`(?i)cisco ios|nx-os|junos|routeros|fortios|pan-os|arubaos|procurve|arista|edgeos|vyos|comware`)},
+	{OSFamilyWindows, regexp.MustCompile(`(?i)windows`)},
+	{OSFamilyMacOS, regexp.MustCompile(`(?i)darwin|mac os|macos`)},
+	{OSFamilyBSD, regexp.MustCompile(`(?i)freebsd|openbsd|netbsd|dragonfly|pfsense|opnsense|truenas|freenas`)},
+	{OSFamilyLinux, regexp.MustCompile(`(?i)linux`)},
+	{OSFamilyUnix, regexp.MustCompile(`(?i)sunos|solaris|\baix\b|hp-ux`)},
+}
+
+// osPortSignatures are TCP ports only one OS family normally listens on (MS-RPC, SMB with RDP, AFP)
+// A signature matches when all of its ports are open
+var osPortSignatures = []struct {
+	family string
+	ports  []int
+}{
+	{OSFamilyWindows, []int{135}},
+	{OSFamilyWindows, []int{445, 3389}},
+	{OSFamilyMacOS, []int{548}},
+}
+
+// Ports returns the sorted TCP ports the port signatures use (what probe_ports connects to)
+func (c *OSFingerprintConfig) Ports() []int {
+	var ports []int
+	for _, signature := range osPortSignatures {
+		for _, port := range signature.ports {
+			if !slices.Contains(ports, port) {
+				ports = append(ports, port)
+			}
+		}
+	}
+	slices.Sort(ports)
+	return ports
+}
+
+// ProbeTimeout returns the timeout of each port probe, DefaultClassificationPortTimeout if unset
+func (c *OSFingerprintConfig) ProbeTimeout() time.Duration {
+	if c.PortTimeout <= 0 {
+		return DefaultClassificationPortTimeout
+	}
+	return c.PortTimeout
+}
+
+// FingerprintProbe returns the TCP ports probed once per new device for device_classification and
+// os_fingerprinting together (nil when neither probes ports) and the timeout of each connect, the
+// longer of the two blocks' timeouts
+func (c *Config) FingerprintProbe() ([]int, time.Duration) {
+	var ports []int
+	var timeout time.Duration
+	if c.DeviceClassification.ProbePorts {
+		ports = append(ports, c.DeviceClassification.FingerprintPorts()...)
+		timeout = c.DeviceClassification.ProbeTimeout()
+	}
+	if c.OSFingerprinting.Enabled && c.OSFingerprinting.ProbePorts {
+		ports = append(ports, c.OSFingerprinting.Ports()...)
+		timeout = max(timeout, c.OSFingerprinting.ProbeTimeout())
+	}
+	slices.Sort(ports)
+	return slices.Compact(ports), timeout
+}
+
+// InferOSFamily returns the OS family of a device, "" when nothing hints at one
+// sysDescr keywords win over open port signatures, which win over the initial TTL guessed from an
+// echo reply's TTL (0 = no reply): 128 is Windows, 255 a network OS and 64 any Unix
+func InferOSFamily(sysDescr string, replyTTL int, openPorts []int) string {
+	for _, signature := range osSysDescrSignatures {
+		if sysDescr != "" && signature.re.MatchString(sysDescr) {
+			return signature.family
+		}
+	}
+	for _, signature := range osPortSignatures {
+		if containsAll(openPorts, signature.ports) {
+			return signature.family
+		}
+	}
+	switch {
+	case replyTTL <= 0:
+		return ""
+	case replyTTL <= 64:
+		return OSFamilyUnix
+	case replyTTL <= 128:
+		return OSFamilyWindows
+	default:
+		return OSFamilyNetwork
+	}
+}
+
+// containsAll reports whether every port of want is in have
+func containsAll(have, want []int) bool {
+	for _, port := range want {
+		if !slices.Contains(have, port) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateOSFingerprint checks the port probe timeout
+func validateOSFingerprint(c OSFingerprintConfig) error {
+	if c.PortTimeout < 0 || c.PortTimeout > 30*time.Second {
+		return fmt.Errorf("os_fingerprinting.port_timeout must be between 0 and 30s, got %s", c.PortTimeout)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+)
+
+// Actions taken when another scanner is found covering the same network
+const (
+	OverlapActionWarn    = "warn"    // Log the overlap and keep scanning
+	OverlapActionDisable = "disable" // Stop discovering and monitoring the overlapping network
+)
+
+// instanceIDPattern restricts instance IDs to characters that are safe in InfluxDB tags and Flux strings
+var instanceIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// defaultInstanceID returns the system hostname, or "netscan" if it cannot be determined
+func defaultInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil || !instanceIDPattern.MatchString(hostname) {
+		return "netscan"
+	}
+	return hostname
+}
+
+// validateOverlapSettings checks instance_id and, when overlap detection is enabled, its parameters
+func validateOverlapSettings(cfg *Config) error {
+	if cfg.InstanceID != "" && !instanceIDPattern.MatchString(cfg.InstanceID) {
+		return fmt.Errorf("instance_id must be 1-64 letters, digits, dots, dashes or underscores, got %q", cfg.InstanceID)
+	}
+	if cfg.OverlapCheckInterval == 0 {
+		return nil
+	}
+	if cfg.InstanceID == "" {
+		return fmt.Errorf("instance_id is required when overlap_check_interval is set")
+	}
+	if cfg.OverlapCheckInterval < time.Minute {
+		return fmt.Errorf("overlap_check_interval must be at least 1 minute, got %v", cfg.OverlapCheckInterval)
+	}
+	switch cfg.OverlapAction {
+	case "", OverlapActionWarn, OverlapActionDisable:
+	default:
+		return fmt.Errorf("overlap_action must be one of warn, disable, got %q", cfg.OverlapAction)
+	}
+	if cfg.OverlapMinMatches < 1 {
+		return fmt.Errorf("overlap_min_matches must be at least 1, got %d", cfg.OverlapMinMatches)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Probe profile defaults: one packet, pro-bing's 200ms spacing and its minimum payload
+// (8-byte timestamp plus 16-byte tracker)
+const (
+	DefaultProbeInterval = 200 * time.Millisecond
+	DefaultProbeSize     = 24
+)
+
+// Bounds for probe profile settings
+const (
+	maxProbeCount    = 10
+	minProbeInterval = 10 * time.Millisecond
+	maxProbeInterval = 5 * time.Second
+	maxProbeSize     = 65000
+	maxProbeDSCP     = 63
+)
+
+// ProbeProfile shapes the ICMP echo requests of one kind of probe
+// Zero values mean the defaults (one packet, DefaultProbeInterval, DefaultProbeSize, best-effort DSCP)
+type ProbeProfile struct {
+	Count    int           `yaml:"count"`    // Echo requests per probe
+	Interval time.Duration `yaml:"interval"` // Spacing between the echo requests of one probe
+	Size     int           `yaml:"size"`     // ICMP payload bytes per echo request
+	DSCP     int           `yaml:"dscp"`     // Differentiated services codepoint in the IP header (0 = best effort)
+}
+
+// PacketInterval returns the spacing between echo requests, DefaultProbeInterval if unset
+func (p ProbeProfile) PacketInterval() time.Duration {
+	if p.Interval <= 0 {
+		return DefaultProbeInterval
+	}
+	return p.Interval
+}
+
+// PacketSize returns the ICMP payload size, DefaultProbeSize if unset
+func (p ProbeProfile) PacketSize() int {
+	if p.Size <= 0 {
+		return DefaultProbeSize
+	}
+	return p.Size
+}
+
+// TOS returns the IPv4 type-of-service byte carrying the DSCP (ECN bits left clear)
+func (p ProbeProfile) TOS() uint8 {
+	return uint8(p.DSCP << 2)
+}
+
+// Spread is how long sending all echo requests of a probe of count packets takes
+func (p ProbeProfile) Spread(count int) time.Duration {
+	if count <= 1 {
+		return 0
+	}
+	return time.Duration(count-1) * p.PacketInterval()
+}
+
+// ProbeProfiles holds the probe profiles of discovery sweeps and continuous monitoring pings
+type ProbeProfiles struct {
+	Discovery  ProbeProfile `yaml:"discovery"`  // ICMP discovery probes (a host is alive if any request is answered)
+	Monitoring ProbeProfile `yaml:"monitoring"` // Continuous ping cycles (count defaults to pings_per_cycle)
+}
+
+// applyProbeProfileDefaults fills in unset profile settings; the monitoring count comes from pings_per_cycle
+func applyProbeProfileDefaults(profiles *ProbeProfiles, pingsPerCycle int) {
+	if profiles.Discovery.Count == 0 {
+		profiles.Discovery.Count = 1
+	}
+	if profiles.Monitoring.Count == 0 {
+		profiles.Monitoring.Count = pingsPerCycle
+	}
+	for _, p := range []*ProbeProfile{&profiles.Discovery, &profiles.Monitoring} {
+		if p.Interval == 0 {
+			p.Interval = DefaultProbeInterval
+		}
+		if p.Size == 0 {
+			p.Size = DefaultProbeSize
+		}
+	}
+}
+
+// validateProbeProfiles checks both profiles and that the monitoring count agrees with pings_per_cycle
+// Zero values are accepted as defaults
+func validateProbeProfiles(cfg *Config) error {
+	if cfg.ProbeProfiles.Monitoring.Count != 0 && cfg.ProbeProfiles.Monitoring.Count != max(cfg.PingsPerCycle, 1) {
+		return fmt.Errorf("probe_profiles.monitoring.count (%d) and pings_per_cycle (%d) differ; set only one of them", cfg.ProbeProfiles.Monitoring.Count, cfg.PingsPerCycle)
+	}
+	for _, profile := range []struct {
+		name string
+		ProbeProfile
+	}{
+		{"discovery", cfg.ProbeProfiles.Discovery},
+		{"monitoring", cfg.ProbeProfiles.Monitoring},
+	} {
+		if profile.Count < 0 || profile.Count > maxProbeCount {
+			return fmt.Errorf("probe_profiles.%s.count must be between 1 and %d, got %d", profile.name, maxProbeCount, profile.Count)
+		}
+		if profile.Interval != 0 && (profile.Interval < minProbeInterval || profile.Interval > maxProbeInterval) {
+			return fmt.Errorf("probe_profiles.%s.interval must be between %v and %v, got %v", profile.name, minProbeInterval, maxProbeInterval, profile.Interval)
+		}
+		if profile.Size != 0 && (profile.Size < DefaultProbeSize || profile.Size > maxProbeSize) {
+			return fmt.Errorf("probe_profiles.%s.size must be between %d and %d bytes, got %d", profile.name, DefaultProbeSize, maxProbeSize, profile.Size)
+		}
+		if profile.DSCP < 0 || profile.DSCP > maxProbeDSCP {
+			return fmt.Errorf("probe_profiles.%s.dscp must be between 0 and %d, got %d", profile.name, maxProbeDSCP, profile.DSCP)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Event publishing transports (publish.type)
+const (
+	PublishTypeNATS  = "nats"  // NATS core protocol, one subject per record type
+	PublishTypeKafka = "kafka" // Kafka topic through a Kafka REST Proxy (v2 API)
+)
+
+// PublishRecordTypes are the record types a publisher can emit, matching the NDJSON stream's "type" values
+var PublishRecordTypes = []string{
+	"ping", "device_info", "snmp_interface", "custom", "device_state",
+	"composite_check", "latency_alert", "traceroute", "discovered",
+}
+
+// PublishConfig publishes results and device events as JSON to NATS or Kafka for downstream consumers
+type PublishConfig struct {
+	Type      string   `yaml:"type"`       // nats or kafka ("" = disabled)
+	URL       string   `yaml:"url"`        // nats://[user:pass@]host:4222, or the REST Proxy's http(s) URL; supports ${VAR} expansion
+	Subject   string   `yaml:"subject"`    // NATS: subject prefix; records go to <subject>.<type> (default: netscan)
+	Topic     string   `yaml:"topic"`      // Kafka: topic of all records, keyed by device IP (default: netscan)
+	Events    []string `yaml:"events"`     // Record types to publish (default: all)
+	QueueSize int      `yaml:"queue_size"` // Records waiting for delivery; more are dropped (default: 10000)
+}
+
+// Enabled reports whether a publisher is configured
+func (c *PublishConfig) Enabled() bool {
+	return c.Type != ""
+}
+
+// applyPublishDefaults fills in the subject, topic, record types and queue size of a configured publisher
+func applyPublishDefaults(cfg *PublishConfig) {
+	if !cfg.Enabled() {
+		return
+	}
+	cfg.URL = expandEnv(cfg.URL)
+	if cfg.Subject == "" {
+		cfg.Subject = "netscan"
+	}
+	if cfg.Topic == "" {
+		cfg.Topic = "netscan"
+	}
+	if len(cfg.Events) == 0 {
+		cfg.Events = append([]string(nil), PublishRecordTypes...)
+	}
+	if cfg.QueueSize == 0 {
+		cfg.QueueSize = 10000
+	}
+}
+
+// validatePublish checks the transport, its URL and the record types
+func validatePublish(cfg *PublishConfig) error {
+	if !cfg.Enabled() {
+		return nil
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("publish.url %q is not a valid URL", cfg.URL)
+	}
+	switch cfg.Type {
+	case PublishTypeNATS:
+		if u.Scheme != "nats" {
+			return fmt.Errorf("publish.url must use the nats:// scheme with type nats, got %q", cfg.URL)
+		}
+		if cfg.Subject == "" || strings.ContainsAny(cfg.Subject, " \t\r\n*>") {
+			return fmt.Errorf("publish.subject must be a NATS subject without wildcards or whitespace, got %q", cfg.Subject)
+		}
+	case PublishTypeKafka:
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("publish.url must be the http(s) URL of a Kafka REST Proxy with type kafka, got %q", cfg.URL)
+		}
+		if cfg.Topic == "" || strings.ContainsAny(cfg.Topic, "/ \t") {
+			return fmt.Errorf("publish.topic must be a Kafka topic name, got %q", cfg.Topic)
+		}
+	default:
+		return fmt.Errorf("publish.type must be nats or kafka, got %q", cfg.Type)
+	}
+	known := make(map[string]bool, len(PublishRecordTypes))
+	for _, t := range PublishRecordTypes {
+		known[t] = true
+	}
+	for _, event := range cfg.Events {
+		if !known[event] {
+			return fmt.Errorf("publish.events: unknown record type %q (use %s)", event, strings.Join(PublishRecordTypes, ", "))
+		}
+	}
+	if cfg.QueueSize < 1 || cfg.QueueSize > 1000000 {
+		return fmt.Errorf("publish.queue_size must be between 1 and 1000000, got %d", cfg.QueueSize)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// loadTimezone resolves the timezone setting; empty means the server's local time
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// ScheduleLocation returns the timezone used for every wall-clock schedule (daily scans, maintenance windows)
+// Configs built without LoadConfig fall back to server local time
+func (c *Config) ScheduleLocation() *time.Location {
+	if c.Location == nil {
+		return time.Local
+	}
+	return c.Location
+}
+
+// DailyTime is a wall-clock time of day (HH:MM) interpreted in the schedule timezone
+type DailyTime struct {
+	Hour   int
+	Minute int
+}
+
+// ParseDailyTime parses an HH:MM schedule value
+func ParseDailyTime(s string) (DailyTime, error) {
+	if err := validateTimeFormat(s); err != nil {
+		return DailyTime{}, err
+	}
+	var d DailyTime
+	fmt.Sscanf(s, "%02d:%02d", &d.Hour, &d.Minute)
+	return d, nil
+}
+
+// String formats the time as HH:MM
+func (d DailyTime) String() string {
+	return fmt.Sprintf("%02d:%02d", d.Hour, d.Minute)
+}
+
+// Next returns the first occurrence of the daily time strictly after 'after', in loc
+// Across DST transitions: a time skipped by a spring-forward gap runs at the shifted wall time
+// (02:30 becomes 03:30), and a time repeated by a fall-back runs once, at its first occurrence
+func (d DailyTime) Next(after time.Time, loc *time.Location) time.Time {
+	local := after.In(loc)
+	next := d.on(local.Year(), local.Month(), local.Day(), loc)
+	if !next.After(after) {
+		next = d.on(local.Year(), local.Month(), local.Day()+1, loc)
+	}
+	return next
+}
+
+// on returns the daily time on the given date, choosing the earlier instant for ambiguous wall times
+func (d DailyTime) on(year int, month time.Month, day int, loc *time.Location) time.Time {
+	t := time.Date(year, month, day, d.Hour, d.Minute, 0, 0, loc)
+
+	// time.Date does not specify which instant it picks for a repeated wall time; if the same wall
+	// time also exists under the zone offset in effect earlier that day, prefer that earlier instant
+	_, offset := t.Zone()
+	_, earlierOffset := t.Add(-12 * time.Hour).Zone()
+	if earlierOffset > offset {
+		alt := t.Add(time.Duration(offset-earlierOffset) * time.Second)
+		if alt.Hour() == t.Hour() && alt.Minute() == t.Minute() && alt.Day() == t.Day() {
+			return alt
+		}
+	}
+	return t
+}
+
+// weekdays maps the day names of weekday masks (snmp_daily_days, recurring maintenance windows)
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// parseWeekdays parses a weekday mask ("mon".."sun", case-insensitive); nil means every day
+func parseWeekdays(days []string) (map[time.Weekday]bool, error) {
+	var mask map[time.Weekday]bool
+	for _, day := range days {
+		weekday, ok := weekdays[strings.ToLower(day)]
+		if !ok {
+			return nil, fmt.Errorf("invalid day %q (use mon, tue, wed, thu, fri, sat, sun)", day)
+		}
+		if mask == nil {
+			mask = make(map[time.Weekday]bool)
+		}
+		mask[weekday] = true
+	}
+	return mask, nil
+}
+
+// DailySchedule is a wall-clock time that recurs every day, or only on the days of a weekday mask
+type DailySchedule struct {
+	At   DailyTime
+	Days map[time.Weekday]bool // Weekdays the schedule runs on (nil = every day)
+}
+
+// Next returns the first run strictly after 'after', in loc (see DailyTime.Next for DST handling)
+func (s DailySchedule) Next(after time.Time, loc *time.Location) time.Time {
+	next := s.At.Next(after, loc)
+	for i := 0; i < 7 && s.Days != nil && !s.Days[next.In(loc).Weekday()]; i++ {
+		next = s.At.Next(next, loc)
+	}
+	return next
+}
+
+// String formats the schedule as "HH:MM" or "HH:MM on mon, thu"
+func (s DailySchedule) String() string {
+	if s.Days == nil {
+		return s.At.String()
+	}
+	days := make([]time.Weekday, 0, len(s.Days))
+	for day := range s.Days {
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i] < days[j] })
+	names := make([]string, len(days))
+	for i, day := range days {
+		names[i] = strings.ToLower(day.String()[:3])
+	}
+	return s.At.String() + " on " + strings.Join(names, ", ")
+}
+
+// SNMPRescanSchedule returns when the daily full SNMP re-scan runs (snmp_daily_schedule, limited to
+// snmp_daily_days); false when snmp_daily_schedule is not set or invalid
+func (c *Config) SNMPRescanSchedule() (DailySchedule, bool) {
+	if c.SNMPDailySchedule == "" {
+		return DailySchedule{}, false
+	}
+	at, err := ParseDailyTime(c.SNMPDailySchedule)
+	if err != nil {
+		return DailySchedule{}, false
+	}
+	days, err := parseWeekdays(c.SNMPDailyDays)
+	if err != nil {
+		return DailySchedule{}, false
+	}
+	return DailySchedule{At: at, Days: days}, true
+}
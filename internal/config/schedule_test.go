@@ -0,0 +1,180 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// mustLocation loads an IANA timezone or fails the test
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("timezone database unavailable: %v", err)
+	}
+	return loc
+}
+
+// TestParseDailyTime verifies HH:MM parsing and rejection of malformed values
+func TestParseDailyTime(t *testing.T) {
+	d, err := ParseDailyTime("02:30")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Hour != 2 || d.Minute != 30 || d.String() != "02:30" {
+		t.Errorf("expected 02:30, got %+v", d)
+	}
+
+	for _, bad := range []string{"2:30", "24:00", "12:60", "noon", ""} {
+		if _, err := ParseDailyTime(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+// TestDailyTimeNext verifies next-occurrence calculation, including both DST transitions
+// Europe/Berlin switches to CEST at 02:00 on 2026-03-29 and back to CET at 03:00 on 2026-10-25
+func TestDailyTimeNext(t *testing.T) {
+	berlin := mustLocation(t, "Europe/Berlin")
+	newYork := mustLocation(t, "America/New_York")
+
+	tests := []struct {
+		name  string
+		daily string
+		after time.Time
+		loc   *time.Location
+		want  time.Time
+	}{
+		{"later today", "14:00", time.Date(2026, 6, 1, 9, 0, 0, 0, berlin), berlin, time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)},
+		{"already passed today", "08:00", time.Date(2026, 6, 1, 9, 0, 0, 0, berlin), berlin, time.Date(2026, 6, 2, 6, 0, 0, 0, time.UTC)},
+		{"exactly now runs tomorrow", "09:00", time.Date(2026, 6, 1, 9, 0, 0, 0, berlin), berlin, time.Date(2026, 6, 2, 7, 0, 0, 0, time.UTC)},
+		{"interpreted in loc, not UTC", "01:00", time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC), newYork, time.Date(2026, 6, 1, 5, 0, 0, 0, time.UTC)},
+		{"day before spring forward", "04:00", time.Date(2026, 3, 28, 12, 0, 0, 0, berlin), berlin, time.Date(2026, 3, 29, 2, 0, 0, 0, time.UTC)},
+		{"day before fall back", "04:00", time.Date(2026, 10, 24, 12, 0, 0, 0, berlin), berlin, time.Date(2026, 10, 25, 3, 0, 0, 0, time.UTC)},
+		{"repeated hour runs at first occurrence", "02:30", time.Date(2026, 10, 24, 12, 0, 0, 0, berlin), berlin, time.Date(2026, 10, 25, 0, 30, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := ParseDailyTime(tt.daily)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := d.Next(tt.after, tt.loc); !got.Equal(tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got.UTC())
+			}
+		})
+	}
+}
+
+// TestDailyTimeNextSpringForwardGap verifies a skipped wall time still runs once that day
+func TestDailyTimeNextSpringForwardGap(t *testing.T) {
+	berlin := mustLocation(t, "Europe/Berlin")
+	d, _ := ParseDailyTime("02:30")
+
+	got := d.Next(time.Date(2026, 3, 28, 12, 0, 0, 0, berlin), berlin)
+	local := got.In(berlin)
+	if local.Year() != 2026 || local.Month() != time.March || local.Day() != 29 {
+		t.Fatalf("expected a run on 2026-03-29, got %v", local)
+	}
+	if local.Hour() != 3 || local.Minute() != 30 {
+		t.Errorf("expected the gap time to shift to 03:30, got %v", local)
+	}
+
+	// The following day is back to normal
+	if next := d.Next(got, berlin).In(berlin); next.Day() != 30 || next.Hour() != 2 || next.Minute() != 30 {
+		t.Errorf("expected 2026-03-30 02:30, got %v", next)
+	}
+}
+
+// TestDailyTimeNextRepeatedHourRunsOnce verifies the fall-back day does not run the schedule twice
+func TestDailyTimeNextRepeatedHourRunsOnce(t *testing.T) {
+	berlin := mustLocation(t, "Europe/Berlin")
+	d, _ := ParseDailyTime("02:30")
+
+	first := d.Next(time.Date(2026, 10, 24, 12, 0, 0, 0, berlin), berlin)
+	second := d.Next(first, berlin)
+	if second.Sub(first) < 24*time.Hour {
+		t.Errorf("expected next run a day later, got %v after %v", second.UTC(), first.UTC())
+	}
+	if local := second.In(berlin); local.Day() != 26 || local.Hour() != 2 || local.Minute() != 30 {
+		t.Errorf("expected 2026-10-26 02:30, got %v", local)
+	}
+}
+
+// TestTimezoneConfig verifies the timezone setting is resolved and validated
+func TestTimezoneConfig(t *testing.T) {
+	mustLocation(t, "Europe/Berlin")
+
+	path := writeFile(t, t.TempDir(), "config.yml", testConfig("timezone: \"Europe/Berlin\""))
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if got := cfg.ScheduleLocation().String(); got != "Europe/Berlin" {
+		t.Errorf("expected Europe/Berlin, got %s", got)
+	}
+
+	path = writeFile(t, t.TempDir(), "config.yml", testConfig(""))
+	cfg, err = LoadConfig(path)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if cfg.ScheduleLocation() != time.Local {
+		t.Errorf("expected server local time by default, got %s", cfg.ScheduleLocation())
+	}
+
+	path = writeFile(t, t.TempDir(), "config.yml", testConfig("timezone: \"Mars/Olympus_Mons\""))
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "invalid timezone") {
+		t.Errorf("expected invalid timezone error, got %v", err)
+	}
+
+	// Configs built directly (without a resolved Location) are validated too
+	path = writeFile(t, t.TempDir(), "config.yml", testConfig(""))
+	cfg, err = LoadConfig(path)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	cfg.Timezone, cfg.Location = "Not/AZone", nil
+	if _, err := ValidateConfig(cfg); err == nil || !strings.Contains(err.Error(), "invalid timezone") {
+		t.Errorf("expected invalid timezone error from ValidateConfig, got %v", err)
+	}
+}
+
+// TestSNMPRescanSchedule verifies the daily re-scan time, its weekday mask and their validation
+// 2026-06-01 is a Monday
+func TestSNMPRescanSchedule(t *testing.T) {
+	cfg, err := loadTestConfig(t, "timezone: \"UTC\"\nsnmp_daily_schedule: \"02:00\"\nsnmp_daily_days: [\"Wed\", \"sat\"]")
+	if err != nil {
+		t.Fatalf("expected a valid config, got %v", err)
+	}
+	rescan, ok := cfg.SNMPRescanSchedule()
+	if !ok || rescan.String() != "02:00 on wed, sat" {
+		t.Fatalf("expected 02:00 on wed, sat, got %q (enabled %v)", rescan.String(), ok)
+	}
+	monday := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	if got := rescan.Next(monday, time.UTC); !got.Equal(time.Date(2026, 6, 3, 2, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected Wednesday 02:00, got %v", got)
+	}
+	if got := rescan.Next(time.Date(2026, 6, 3, 2, 0, 0, 0, time.UTC), time.UTC); !got.Equal(time.Date(2026, 6, 6, 2, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected Saturday 02:00 after Wednesday's run, got %v", got)
+	}
+
+	every := DailySchedule{At: DailyTime{Hour: 2}}
+	if got := every.Next(monday, time.UTC); !got.Equal(time.Date(2026, 6, 2, 2, 0, 0, 0, time.UTC)) || every.String() != "02:00" {
+		t.Errorf("expected every day at 02:00, got %v (%s)", got, every)
+	}
+	if _, ok := (&Config{}).SNMPRescanSchedule(); ok {
+		t.Error("expected no re-scan without snmp_daily_schedule")
+	}
+
+	for settings, want := range map[string]string{
+		"snmp_daily_schedule: \"02:00\"\nsnmp_daily_days: [\"monday\"]": `invalid day "monday"`,
+		"snmp_daily_days: [\"mon\"]":                                    "requires snmp_daily_schedule",
+	} {
+		if _, err := loadTestConfig(t, settings); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", settings, want, err)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"time"
+)
+
+// reservedSiteTags are tag keys written by netscan itself, which sites.influxdb.tags cannot override
+var reservedSiteTags = map[string]bool{
+	"ip": true, "site": true, "network": true, "device_type": true, "virtual": true, "scanner": true,
+	"check": true, "state": true, "if_index": true, "if_name": true, "oid_group": true, "threshold": true, "level": true, "maintenance": true,
+}
+
+// SiteConfig is one location monitored by a multi-site collector
+// Its networks are discovered and monitored like the top-level networks; devices in them are polled with
+// the site's SNMP credentials and their points go to the site's bucket, tagged site=<name>
+type SiteConfig struct {
+	Name     string             `yaml:"name"`     // Written as the "site" tag on every point of the site's devices
+	Networks []string           `yaml:"networks"` // CIDRs of the site (also appended to networks when loading)
+	SNMP     SiteSNMPConfig     `yaml:"snmp"`     // Overrides of the global snmp connection settings
+	InfluxDB SiteInfluxDBConfig `yaml:"influxdb"` // Bucket and static tags of the site's points
+}
+
+// SiteSNMPConfig overrides the global SNMP connection settings for one site; zero values keep the global value
+type SiteSNMPConfig struct {
+	Community string        `yaml:"community"` // Supports ${VAR} expansion
+	Port      int           `yaml:"port"`
+	Timeout   time.Duration `yaml:"timeout"`
+	Retries   int           `yaml:"retries"`
+	Transport string        `yaml:"transport"` // udp or tcp
+}
+
+// SiteInfluxDBConfig selects where a site's points are written; the URL, token and org are shared
+type SiteInfluxDBConfig struct {
+	Bucket string            `yaml:"bucket"` // Bucket of the site's points (default: influxdb.bucket)
+	Tags   map[string]string `yaml:"tags"`   // Static tags added to every point of the site
+}
+
+// rawSite is a sites entry as written in YAML; networks accept inline labels like the top-level networks
+type rawSite struct {
+	Name     string             `yaml:"name"`
+	Networks []networkEntry     `yaml:"networks"`
+	SNMP     SiteSNMPConfig     `yaml:"snmp"`
+	InfluxDB SiteInfluxDBConfig `yaml:"influxdb"`
+}
+
+// validSNMPTransport reports whether transport is a supported SNMP transport
+func validSNMPTransport(transport string) bool {
+	return transport == SNMPTransportUDP || transport == SNMPTransportTCP
+}
+
+// BucketOr returns the site's bucket, or fallback (influxdb.bucket) when it has none
+func (s *SiteInfluxDBConfig) BucketOr(fallback string) string {
+	if s.Bucket == "" {
+		return fallback
+	}
+	return s.Bucket
+}
+
+// SNMPConfig returns global with the site's connection overrides applied
+func (s *SiteConfig) SNMPConfig(global SNMPConfig) SNMPConfig {
+	merged := global
+	if s.SNMP.Community != "" {
+		merged.Community = s.SNMP.Community
+	}
+	if s.SNMP.Port != 0 {
+		merged.Port = s.SNMP.Port
+	}
+	if s.SNMP.Timeout != 0 {
+		merged.Timeout = s.SNMP.Timeout
+	}
+	if s.SNMP.Retries != 0 {
+		merged.Retries = s.SNMP.Retries
+	}
+	if s.SNMP.Transport != "" {
+		merged.Transport = s.SNMP.Transport
+	}
+	return merged
+}
+
+// Tags returns the tags written on every point of the site: its static tags plus site=<name>
+func (s *SiteConfig) Tags() map[string]string {
+	tags := make(map[string]string, len(s.InfluxDB.Tags)+1)
+	for key, value := range s.InfluxDB.Tags {
+		tags[key] = value
+	}
+	tags["site"] = s.Name
+	return tags
+}
+
+// siteNetwork is a network of a site
+type siteNetwork struct {
+	ipnet *net.IPNet
+	site  int
+}
+
+// SiteResolver returns a function mapping an IP to the name of the site whose most specific network
+// contains it ("" for devices outside every site, which use the global settings)
+func (c *Config) SiteResolver() func(ip string) string {
+	resolve := c.siteIndexResolver()
+	return func(ip string) string {
+		if i := resolve(ip); i >= 0 {
+			return c.Sites[i].Name
+		}
+		return ""
+	}
+}
+
+// SNMPResolver returns a function mapping an IP to the SNMP settings of its site, or the global snmp block
+// The merged settings are computed once, so every device of a site shares the same pointer
+func (c *Config) SNMPResolver() func(ip string) *SNMPConfig {
+	siteSNMP := make([]*SNMPConfig, len(c.Sites))
+	for i := range c.Sites {
+		merged := c.Sites[i].SNMPConfig(c.SNMP)
+		siteSNMP[i] = &merged
+	}
+	resolve := c.siteIndexResolver()
+	return func(ip string) *SNMPConfig {
+		if i := resolve(ip); i >= 0 {
+			return siteSNMP[i]
+		}
+		return &c.SNMP
+	}
+}
+
+// siteIndexResolver maps an IP to the index of its site in Sites (-1 outside every site)
+func (c *Config) siteIndexResolver() func(ip string) int {
+	var networks []siteNetwork
+	for i, site := range c.Sites {
+		for _, cidr := range site.Networks {
+			if _, ipnet, err := net.ParseCIDR(cidr); err == nil {
+				networks = append(networks, siteNetwork{ipnet: ipnet, site: i})
+			}
+		}
+	}
+	sort.SliceStable(networks, func(i, j int) bool {
+		a, _ := networks[i].ipnet.Mask.Size()
+		b, _ := networks[j].ipnet.Mask.Size()
+		return a > b
+	})
+
+	return func(ipStr string) int {
+		ip := net.ParseIP(ipStr)
+		if ip == nil {
+			return -1
+		}
+		for _, network := range networks {
+			if network.ipnet.Contains(ip) {
+				return network.site
+			}
+		}
+		return -1
+	}
+}
+
+// splitSites converts the YAML sites entries and appends their networks to networks
+// Inline labels are merged into labels; a network may belong to only one site and not also be listed in networks
+func splitSites(entries []rawSite, networks []string, labels map[string]string) ([]SiteConfig, []string, map[string]string, error) {
+	owner := make(map[string]string)
+	for _, cidr := range networks {
+		owner[cidr] = ""
+	}
+	sites := make([]SiteConfig, 0, len(entries))
+	for _, entry := range entries {
+		if err := checkSiteHostEntries(entry.Name, entry.Networks); err != nil {
+			return nil, nil, nil, err
+		}
+		siteNetworks, merged, err := splitNetworkEntries(entry.Networks, labels)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		labels = merged
+		for _, cidr := range siteNetworks {
+			if site, ok := owner[cidr]; ok {
+				if site == "" {
+					return nil, nil, nil, fmt.Errorf("network %q is listed in networks and in site %q", cidr, entry.Name)
+				}
+				return nil, nil, nil, fmt.Errorf("network %q is listed in sites %q and %q", cidr, site, entry.Name)
+			}
+			owner[cidr] = entry.Name
+			networks = append(networks, cidr)
+		}
+		entry.SNMP.Community = expandEnv(entry.SNMP.Community)
+		entry.InfluxDB.Bucket = expandEnv(entry.InfluxDB.Bucket)
+		sites = append(sites, SiteConfig{Name: entry.Name, Networks: siteNetworks, SNMP: entry.SNMP, InfluxDB: entry.InfluxDB})
+	}
+	return sites, networks, labels, nil
+}
+
+// validateSites checks site names, networks, SNMP overrides and tags
+func validateSites(sites []SiteConfig, networks []string) error {
+	configured := make(map[string]bool, len(networks))
+	for _, cidr := range networks {
+		configured[cidr] = true
+	}
+	names := make(map[string]bool, len(sites))
+	for _, site := range sites {
+		if !isValidIdentifier(site.Name) {
+			return fmt.Errorf("sites: invalid name %q (use letters, digits and underscores)", site.Name)
+		}
+		if names[site.Name] {
+			return fmt.Errorf("sites: duplicate name %q", site.Name)
+		}
+		names[site.Name] = true
+
+		if len(site.Networks) == 0 {
+			return fmt.Errorf("sites[%s]: at least one network is required", site.Name)
+		}
+		for _, cidr := range site.Networks {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return fmt.Errorf("sites[%s]: invalid network %q", site.Name, cidr)
+			}
+			if !configured[cidr] {
+				return fmt.Errorf("sites[%s]: network %q is not in networks", site.Name, cidr)
+			}
+		}
+
+		if site.SNMP.Community != "" {
+			if _, err := validateSNMPCommunity(site.SNMP.Community); err != nil {
+				return fmt.Errorf("sites[%s]: %v", site.Name, err)
+			}
+		}
+		if site.SNMP.Port < 0 || site.SNMP.Port > 65535 {
+			return fmt.Errorf("sites[%s]: snmp port must be between 1 and 65535, got %d", site.Name, site.SNMP.Port)
+		}
+		if site.SNMP.Timeout != 0 && site.SNMP.Timeout < time.Second {
+			return fmt.Errorf("sites[%s]: snmp timeout must be at least 1 second, got %v", site.Name, site.SNMP.Timeout)
+		}
+		if site.SNMP.Retries < 0 || site.SNMP.Retries > 10 {
+			return fmt.Errorf("sites[%s]: snmp retries must be between 0 and 10, got %d", site.Name, site.SNMP.Retries)
+		}
+		if site.SNMP.Transport != "" && !validSNMPTransport(site.SNMP.Transport) {
+			return fmt.Errorf("sites[%s]: snmp transport must be udp or tcp, got %q", site.Name, site.SNMP.Transport)
+		}
+
+		for key, value := range site.InfluxDB.Tags {
+			if !isValidIdentifier(key) {
+				return fmt.Errorf("sites[%s]: invalid tag key %q (use letters, digits and underscores)", site.Name, key)
+			}
+			if reservedSiteTags[key] {
+				return fmt.Errorf("sites[%s]: tag %q is written by netscan and cannot be set", site.Name, key)
+			}
+			if value == "" {
+				return fmt.Errorf("sites[%s]: tag %q must not be empty", site.Name, key)
+			}
+		}
+	}
+	return nil
+}
+
+// sitesWarning flags site buckets and tags that are never written because InfluxDB is not configured
+func sitesWarning(sites []SiteConfig, influxURL string) string {
+	if influxURL != "" {
+		return ""
+	}
+	for _, site := range sites {
+		if site.InfluxDB.Bucket != "" || len(site.InfluxDB.Tags) > 0 {
+			return fmt.Sprintf("WARNING: sites[%s].influxdb has no effect without influxdb.url", site.Name)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"net"
+)
+
+// interfaceAddrs lists the addresses of a network interface ("" = all interfaces); replaced in tests
+var interfaceAddrs = func(name string) ([]net.Addr, error) {
+	if name == "" {
+		return net.InterfaceAddrs()
+	}
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+	return iface.Addrs()
+}
+
+// SourceAddress returns the local IPv4 address ICMP and SNMP probes are sent from ("" = chosen by the routing table)
+// source_ip wins over source_interface; with only source_interface set, its first IPv4 address is used
+func (c *Config) SourceAddress() (string, error) {
+	if c.SourceIP == "" && c.SourceInterface == "" {
+		return "", nil
+	}
+	addrs, err := interfaceAddrs(c.SourceInterface)
+	if err != nil {
+		return "", fmt.Errorf("source_interface %q: %v", c.SourceInterface, err)
+	}
+	var want net.IP
+	if c.SourceIP != "" {
+		if want = net.ParseIP(c.SourceIP).To4(); want == nil {
+			return "", fmt.Errorf("source_ip must be an IPv4 address, got %q", c.SourceIP)
+		}
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.To4() == nil {
+			continue
+		}
+		if want == nil || ipNet.IP.Equal(want) {
+			return ipNet.IP.To4().String(), nil
+		}
+	}
+	switch {
+	case c.SourceInterface == "":
+		return "", fmt.Errorf("source_ip %s is not assigned to a local interface", c.SourceIP)
+	case want != nil:
+		return "", fmt.Errorf("source_ip %s is not assigned to source_interface %q", c.SourceIP, c.SourceInterface)
+	}
+	return "", fmt.Errorf("source_interface %q has no IPv4 address", c.SourceInterface)
+}
+
+// validateSource checks that source_ip and source_interface name a local IPv4 address
+func validateSource(cfg *Config) error {
+	_, err := cfg.SourceAddress()
+	return err
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"net"
+)
+
+// Bounds for discovery_sweep_budget (addresses probed per sweep in streaming mode)
+const (
+	minSweepBudget = 256
+	maxSweepBudget = 1 << 24 // A full /8 per sweep
+
+	// maxFullSweepHostBits is the largest network a full (non-streaming) sweep can expand
+	maxFullSweepHostBits = 16
+)
+
+// validateStreamingDiscovery checks discovery_sweep_budget and that networks larger than /16 use streaming mode
+// Full sweeps expand every address in memory and cannot cover more than 65536 addresses per network
+func validateStreamingDiscovery(cfg *Config) error {
+	if cfg.DiscoverySweepBudget < 0 || (cfg.DiscoverySweepBudget > 0 && (cfg.DiscoverySweepBudget < minSweepBudget || cfg.DiscoverySweepBudget > maxSweepBudget)) {
+		return fmt.Errorf("discovery_sweep_budget must be 0 (disabled) or between %d and %d, got %d", minSweepBudget, maxSweepBudget, cfg.DiscoverySweepBudget)
+	}
+
+	for _, cidr := range cfg.Networks {
+		_, ipnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue // Reported by validateCIDR
+		}
+		ones, bits := ipnet.Mask.Size()
+		if cfg.DiscoverySweepBudget > 0 {
+			if ipnet.IP.To4() == nil {
+				return fmt.Errorf("discovery_sweep_budget supports IPv4 networks only, got %s", cidr)
+			}
+			continue
+		}
+		if bits-ones > maxFullSweepHostBits {
+			return fmt.Errorf("network %s is larger than /%d; set discovery_sweep_budget to scan it incrementally", cidr, bits-maxFullSweepHostBits)
+		}
+	}
+	return nil
+}
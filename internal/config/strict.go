@@ -0,0 +1,36 @@
+package config
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// applyStrictValidation turns validation warnings into errors when strict_validation is enabled
+// Strict mode also rejects an InfluxDB URL on localhost, which is accepted silently otherwise
+func applyStrictValidation(cfg *Config, result *ValidationResult) {
+	if !cfg.StrictValidation {
+		return
+	}
+	for _, warning := range result.Warnings {
+		result.errorf("strict_validation: %s", strings.TrimPrefix(warning, "WARNING: "))
+	}
+	result.Warnings = nil
+	if isLocalhostURL(cfg.InfluxDB.URL) {
+		result.errorf("strict_validation: influxdb.url %q points at localhost", cfg.InfluxDB.URL)
+	}
+}
+
+// isLocalhostURL reports whether the URL's host is "localhost" or a loopback address
+func isLocalhostURL(urlStr string) bool {
+	parsedURL, err := url.Parse(urlStr)
+	if err != nil {
+		return false
+	}
+	host := parsedURL.Hostname()
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
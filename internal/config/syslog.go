@@ -0,0 +1,158 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// Syslog transports (notifications.syslog[].protocol)
+const (
+	SyslogProtocolUDP = "udp" // RFC 5426, one message per datagram (default port 514)
+	SyslogProtocolTCP = "tcp" // RFC 6587 octet-counted framing (default port 514)
+	SyslogProtocolTLS = "tls" // RFC 5425 (default port 6514)
+)
+
+// SyslogFacilities maps facility names to RFC 5424 facility codes
+var SyslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19, "local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// SyslogSeverities maps severity names to RFC 5424 severity codes
+var SyslogSeverities = map[string]int{
+	"emerg": 0, "alert": 1, "crit": 2, "err": 3, "warning": 4, "notice": 5, "info": 6, "debug": 7,
+}
+
+// defaultSyslogSeverities is the severity of each event unless overridden by severities
+var defaultSyslogSeverities = map[string]string{
+	NotifyEventDown:            "err",
+	NotifyEventUp:              "notice",
+	NotifyEventSuspended:       "warning",
+	NotifyEventCheckFailed:     "err",
+	NotifyEventCheckOK:         "notice",
+	NotifyEventLatencyWarning:  "warning",
+	NotifyEventLatencyCritical: "err",
+	NotifyEventLatencyOK:       "notice",
+}
+
+// SyslogConfig defines one syslog receiver of device state change notifications
+type SyslogConfig struct {
+	Name       string            `yaml:"name"`        // Identifies the receiver in logs
+	Address    string            `yaml:"address"`     // host[:port] of the receiver
+	Protocol   string            `yaml:"protocol"`    // udp, tcp or tls (default: udp)
+	Facility   string            `yaml:"facility"`    // Facility name, e.g. daemon or local0 (default: daemon)
+	Severities map[string]string `yaml:"severities"`  // Severity name per event type (default: err for down, check_failed and latency_critical, warning for suspended and latency_warning, notice otherwise)
+	Events     []string          `yaml:"events"`      // Subset of the notification event types (default: down, up, suspended)
+	Template   string            `yaml:"template"`    // Go text/template for the message text (default: built-in per event)
+	AppName    string            `yaml:"app_name"`    // APP-NAME header field (default: netscan)
+	TLSCAFile  string            `yaml:"tls_ca_file"` // tls: PEM bundle verifying the receiver (default: system roots)
+}
+
+// Wants reports whether the receiver subscribes to an event type
+func (s *SyslogConfig) Wants(event string) bool {
+	for _, e := range s.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Priority returns the PRI value (facility * 8 + severity) of an event type
+func (s *SyslogConfig) Priority(event string) int {
+	return SyslogFacilities[s.Facility]*8 + SyslogSeverities[s.Severities[event]]
+}
+
+// applySyslogDefaults fills in the protocol, port, facility, severities, events and app name of a receiver
+func applySyslogDefaults(cfg *SyslogConfig) {
+	if cfg.Protocol == "" {
+		cfg.Protocol = SyslogProtocolUDP
+	}
+	if cfg.Address != "" {
+		if _, _, err := net.SplitHostPort(cfg.Address); err != nil {
+			port := "514"
+			if cfg.Protocol == SyslogProtocolTLS {
+				port = "6514"
+			}
+			cfg.Address = net.JoinHostPort(strings.Trim(cfg.Address, "[]"), port)
+		}
+	}
+	if cfg.Facility == "" {
+		cfg.Facility = "daemon"
+	}
+	severities := make(map[string]string, len(defaultSyslogSeverities))
+	for event, severity := range defaultSyslogSeverities {
+		severities[event] = severity
+	}
+	for event, severity := range cfg.Severities {
+		severities[event] = severity
+	}
+	cfg.Severities = severities
+	if len(cfg.Events) == 0 {
+		cfg.Events = []string{NotifyEventDown, NotifyEventUp, NotifyEventSuspended}
+	}
+	if cfg.AppName == "" {
+		cfg.AppName = "netscan"
+	}
+}
+
+// validateSyslogConfigs checks names, addresses, protocols, facilities, severities, events and templates of the receivers
+func validateSyslogConfigs(receivers []SyslogConfig) error {
+	names := make(map[string]bool)
+	for _, s := range receivers {
+		if !isValidIdentifier(s.Name) {
+			return fmt.Errorf("notifications.syslog: invalid name %q (use letters, digits and underscores)", s.Name)
+		}
+		if names[s.Name] {
+			return fmt.Errorf("notifications.syslog: duplicate name %q", s.Name)
+		}
+		names[s.Name] = true
+
+		if host, _, err := net.SplitHostPort(s.Address); err != nil || host == "" {
+			return fmt.Errorf("notifications.syslog[%s]: invalid address %q (use host or host:port)", s.Name, s.Address)
+		}
+		switch s.Protocol {
+		case SyslogProtocolUDP, SyslogProtocolTCP, SyslogProtocolTLS:
+		default:
+			return fmt.Errorf("notifications.syslog[%s]: unsupported protocol %q (udp, tcp, tls)", s.Name, s.Protocol)
+		}
+		if _, ok := SyslogFacilities[s.Facility]; !ok {
+			return fmt.Errorf("notifications.syslog[%s]: unknown facility %q", s.Name, s.Facility)
+		}
+		for event, severity := range s.Severities {
+			if !isNotifyEvent(event) {
+				return fmt.Errorf("notifications.syslog[%s].severities: unsupported event %q", s.Name, event)
+			}
+			if _, ok := SyslogSeverities[severity]; !ok {
+				return fmt.Errorf("notifications.syslog[%s].severities: unknown severity %q for %s (emerg, alert, crit, err, warning, notice, info, debug)", s.Name, severity, event)
+			}
+		}
+		for _, event := range s.Events {
+			if !isNotifyEvent(event) {
+				return fmt.Errorf("notifications.syslog[%s]: unsupported event %q (%s)", s.Name, event, strings.Join(notifyEvents, ", "))
+			}
+		}
+		if len(s.AppName) > 48 || strings.ContainsAny(s.AppName, " \t\r\n") {
+			return fmt.Errorf("notifications.syslog[%s]: app_name must be at most 48 characters without spaces, got %q", s.Name, s.AppName)
+		}
+		if s.Template != "" {
+			// Same functions as the notifier provides to templates
+			if _, err := template.New(s.Name).Funcs(template.FuncMap{"join": strings.Join}).Parse(s.Template); err != nil {
+				return fmt.Errorf("notifications.syslog[%s]: invalid template: %v", s.Name, err)
+			}
+		}
+		if s.TLSCAFile != "" {
+			if s.Protocol != SyslogProtocolTLS {
+				return fmt.Errorf("notifications.syslog[%s]: tls_ca_file requires protocol tls", s.Name)
+			}
+			if _, err := os.Stat(s.TLSCAFile); err != nil {
+				return fmt.Errorf("notifications.syslog[%s]: tls_ca_file: %v", s.Name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TelegrafPingImport holds the netscan-relevant settings extracted from Telegraf [[inputs.ping]] blocks
+type TelegrafPingImport struct {
+	Networks     []string      // Target IPs converted to single-host CIDRs (/32 or /128)
+	Skipped      []string      // URLs that could not be converted (hostnames, invalid entries)
+	PingInterval time.Duration // Shortest collection interval across all ping inputs (0 if none set)
+	PingTimeout  time.Duration // Longest per-ping timeout across all ping inputs (0 if none set)
+	Inputs       int           // Number of [[inputs.ping]] blocks found
+}
+
+// ImportTelegrafPing parses a Telegraf configuration and extracts [[inputs.ping]] urls, interval and timeout
+// Only the small TOML subset used by Telegraf ping configs is supported (key = value, arrays, comments)
+// The plugin interval falls back to the [agent] interval when not set on the input itself
+func ImportTelegrafPing(r io.Reader) (*TelegrafPingImport, error) {
+	result := &TelegrafPingImport{}
+	seen := make(map[string]bool)
+
+	var (
+		section       string
+		agentInterval time.Duration
+		inputInterval time.Duration
+		pending       string // Accumulates multi-line array values
+		pendingKey    string
+		lineNo        int
+	)
+
+	// finishInput applies the interval of the input block that just ended
+	finishInput := func() {
+		if section != "inputs.ping" {
+			return
+		}
+		interval := inputInterval
+		if interval == 0 {
+			interval = agentInterval
+		}
+		if interval > 0 && (result.PingInterval == 0 || interval < result.PingInterval) {
+			result.PingInterval = interval
+		}
+		inputInterval = 0
+	}
+
+	handleValue := func(key, value string) error {
+		switch section {
+		case "agent":
+			if key == "interval" {
+				d, err := parseTelegrafDuration(value)
+				if err != nil {
+					return fmt.Errorf("line %d: invalid agent interval: %v", lineNo, err)
+				}
+				agentInterval = d
+			}
+		case "inputs.ping":
+			switch key {
+			case "urls":
+				urls, err := parseTelegrafStringArray(value)
+				if err != nil {
+					return fmt.Errorf("line %d: invalid urls: %v", lineNo, err)
+				}
+				for _, u := range urls {
+					if seen[u] {
+						continue
+					}
+					seen[u] = true
+					if cidr, ok := hostToCIDR(u); ok {
+						result.Networks = append(result.Networks, cidr)
+					} else {
+						result.Skipped = append(result.Skipped, u)
+					}
+				}
+			case "interval":
+				d, err := parseTelegrafDuration(value)
+				if err != nil {
+					return fmt.Errorf("line %d: invalid interval: %v", lineNo, err)
+				}
+				inputInterval = d
+			case "timeout":
+				// Telegraf ping timeout is a float number of seconds
+				secs, err := strconv.ParseFloat(strings.Trim(value, `"`), 64)
+				if err != nil {
+					return fmt.Errorf("line %d: invalid timeout: %v", lineNo, err)
+				}
+				d := time.Duration(secs * float64(time.Second))
+				if d > result.PingTimeout {
+					result.PingTimeout = d
+				}
+			}
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(stripTOMLComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		// Continue a multi-line array until its closing bracket
+		if pendingKey != "" {
+			pending += " " + line
+			if strings.Count(pending, "[") <= strings.Count(pending, "]") {
+				if err := handleValue(pendingKey, pending); err != nil {
+					return nil, err
+				}
+				pendingKey, pending = "", ""
+			}
+			continue
+		}
+
+		// Section headers: [agent], [[inputs.ping]], [inputs.ping.tags], ...
+		if strings.HasPrefix(line, "[") {
+			finishInput()
+			name := strings.Trim(line, "[] ")
+			if name == "inputs.ping" {
+				result.Inputs++
+			}
+			section = name
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue // Not a key/value line, ignore
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		if strings.HasPrefix(value, "[") && strings.Count(value, "[") > strings.Count(value, "]") {
+			pendingKey, pending = key, value
+			continue
+		}
+		if err := handleValue(key, value); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if pendingKey != "" {
+		return nil, fmt.Errorf("unterminated array for key %q", pendingKey)
+	}
+	finishInput()
+
+	if result.Inputs == 0 {
+		return nil, fmt.Errorf("no [[inputs.ping]] section found")
+	}
+	return result, nil
+}
+
+// ToYAML renders the imported settings as a netscan config stanza
+// Skipped URLs are emitted as comments so nothing is silently lost
+func (t *TelegrafPingImport) ToYAML() (string, error) {
+	stanza := struct {
+		Networks     []string `yaml:"networks"`
+		PingInterval string   `yaml:"ping_interval,omitempty"`
+		PingTimeout  string   `yaml:"ping_timeout,omitempty"`
+	}{
+		Networks: t.Networks,
+	}
+	if t.PingInterval > 0 {
+		stanza.PingInterval = t.PingInterval.String()
+	}
+	if t.PingTimeout > 0 {
+		stanza.PingTimeout = t.PingTimeout.String()
+	}
+
+	out, err := yaml.Marshal(&stanza)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Imported from %d Telegraf [[inputs.ping]] block(s)\n", t.Inputs)
+	if len(t.Skipped) > 0 {
+		b.WriteString("# The following targets are not IP addresses and were not imported\n")
+		b.WriteString("# (netscan networks must be CIDR ranges):\n")
+		for _, s := range t.Skipped {
+			fmt.Fprintf(&b, "#   - %s\n", s)
+		}
+	}
+	b.Write(out)
+	return b.String(), nil
+}
+
+// hostToCIDR converts a bare IP address into a single-host CIDR
+func hostToCIDR(host string) (string, bool) {
+	if _, _, err := net.ParseCIDR(host); err == nil {
+		return host, true
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return "", false
+	}
+	if ip.To4() != nil {
+		return ip.String() + "/32", true
+	}
+	return ip.String() + "/128", true
+}
+
+// parseTelegrafDuration parses a quoted Go-style duration string such as "10s"
+func parseTelegrafDuration(value string) (time.Duration, error) {
+	return time.ParseDuration(strings.Trim(value, `"'`))
+}
+
+// parseTelegrafStringArray parses a TOML array of strings: ["a", 'b', "c"]
+func parseTelegrafStringArray(value string) ([]string, error) {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, "[") || !strings.HasSuffix(value, "]") {
+		return nil, fmt.Errorf("expected array, got %s", value)
+	}
+	inner := strings.TrimSpace(value[1 : len(value)-1])
+	if inner == "" {
+		return nil, nil
+	}
+
+	var items []string
+	for _, part := range strings.Split(inner, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue // Trailing comma
+		}
+		unquoted := strings.Trim(part, `"'`)
+		if unquoted == part {
+			return nil, fmt.Errorf("expected quoted string, got %s", part)
+		}
+		items = append(items, unquoted)
+	}
+	return items, nil
+}
+
+// stripTOMLComment removes a trailing # comment that is not inside a quoted string
+func stripTOMLComment(line string) string {
+	inQuote := rune(0)
+	for i, r := range line {
+		switch {
+		case inQuote != 0:
+			if r == inQuote {
+				inQuote = 0
+			}
+		case r == '"' || r == '\'':
+			inQuote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
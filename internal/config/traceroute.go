@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Traceroute probe protocols
+const (
+	TracerouteICMP = "icmp" // ICMP echo requests with increasing TTL
+	TracerouteUDP  = "udp"  // UDP datagrams to ports 33434 and up, like classic traceroute
+)
+
+// Traceroute triggers
+const (
+	TracerouteOnDown    = "down"    // Device transitioned to down
+	TracerouteOnLatency = "latency" // Device RTT crossed a latency threshold's warning or critical limit
+)
+
+// TracerouteConfig runs a traceroute to devices that go down or exceed a latency threshold
+// and writes the path to the traceroute measurement
+type TracerouteConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	Protocol      string        `yaml:"protocol"`       // icmp or udp (default: icmp)
+	Triggers      []string      `yaml:"triggers"`       // Subset of down, latency (default: both)
+	MaxHops       int           `yaml:"max_hops"`       // Highest TTL probed (default: 30)
+	Timeout       time.Duration `yaml:"timeout"`        // Wait per hop (default: 1s)
+	Cooldown      time.Duration `yaml:"cooldown"`       // Minimum time between traceroutes to the same device (default: 10m)
+	MaxConcurrent int           `yaml:"max_concurrent"` // Traceroutes running at once; further triggers are skipped (default: 4)
+}
+
+// Wants reports whether a trigger starts a traceroute
+func (t *TracerouteConfig) Wants(trigger string) bool {
+	if !t.Enabled {
+		return false
+	}
+	for _, want := range t.Triggers {
+		if want == trigger {
+			return true
+		}
+	}
+	return false
+}
+
+// applyTracerouteDefaults fills in the protocol, triggers and limits
+func applyTracerouteDefaults(cfg *TracerouteConfig) {
+	if cfg.Protocol == "" {
+		cfg.Protocol = TracerouteICMP
+	}
+	if len(cfg.Triggers) == 0 {
+		cfg.Triggers = []string{TracerouteOnDown, TracerouteOnLatency}
+	}
+	if cfg.MaxHops == 0 {
+		cfg.MaxHops = 30
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = time.Second
+	}
+	if cfg.Cooldown == 0 {
+		cfg.Cooldown = 10 * time.Minute
+	}
+	if cfg.MaxConcurrent == 0 {
+		cfg.MaxConcurrent = 4
+	}
+}
+
+// validateTracerouteConfig checks the protocol, triggers and limits of an enabled traceroute block
+func validateTracerouteConfig(cfg *TracerouteConfig) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.Protocol != TracerouteICMP && cfg.Protocol != TracerouteUDP {
+		return fmt.Errorf("traceroute.protocol must be icmp or udp, got %q", cfg.Protocol)
+	}
+	for _, trigger := range cfg.Triggers {
+		if trigger != TracerouteOnDown && trigger != TracerouteOnLatency {
+			return fmt.Errorf("traceroute.triggers: unknown trigger %q (down, latency)", trigger)
+		}
+	}
+	if cfg.MaxHops < 1 || cfg.MaxHops > 64 {
+		return fmt.Errorf("traceroute.max_hops must be between 1 and 64, got %d", cfg.MaxHops)
+	}
+	if cfg.Timeout < 100*time.Millisecond || cfg.Timeout > 5*time.Second {
+		return fmt.Errorf("traceroute.timeout must be between 100ms and 5s, got %v", cfg.Timeout)
+	}
+	if cfg.Cooldown < time.Minute {
+		return fmt.Errorf("traceroute.cooldown must be at least 1m, got %v", cfg.Cooldown)
+	}
+	if cfg.MaxConcurrent < 1 || cfg.MaxConcurrent > 32 {
+		return fmt.Errorf("traceroute.max_concurrent must be between 1 and 32, got %d", cfg.MaxConcurrent)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package config
+
+import "fmt"
+
+// ValidationResult lists every error and warning found in a config
+type ValidationResult struct {
+	Errors   []error
+	Warnings []string // Security and sanity warnings ("WARNING: ..."); errors under strict_validation
+}
+
+// OK reports whether the config has no errors (warnings are allowed)
+func (r ValidationResult) OK() bool {
+	return len(r.Errors) == 0
+}
+
+// First returns the first error, otherwise the first warning, in ValidateConfig's (warning, error) form
+func (r ValidationResult) First() (string, error) {
+	if len(r.Errors) > 0 {
+		return "", r.Errors[0]
+	}
+	if len(r.Warnings) > 0 {
+		return r.Warnings[0], nil
+	}
+	return "", nil
+}
+
+// check records err if it is not nil
+func (r *ValidationResult) check(err error) {
+	if err != nil {
+		r.Errors = append(r.Errors, err)
+	}
+}
+
+// errorf records a formatted error
+func (r *ValidationResult) errorf(format string, args ...interface{}) {
+	r.Errors = append(r.Errors, fmt.Errorf(format, args...))
+}
+
+// warn records a warning
+func (r *ValidationResult) warn(warning string) {
+	r.Warnings = append(r.Warnings, warning)
+}
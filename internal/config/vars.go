@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// varRefPattern matches ${vars.name} references in configuration values
+var varRefPattern = regexp.MustCompile(`\$\{vars\.([^}]*)\}`)
+
+// applyVars resolves template variables and substitutes ${vars.name} in every scalar value of doc
+// Sources in increasing precedence: the top-level vars block, vars_file (relative to configDir), overridePath
+// Comments are not substituted; referencing an undefined variable is an error
+func applyVars(doc *yaml.Node, configDir, overridePath string) error {
+	root := doc
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	vars := make(map[string]string)
+	var varsNode *yaml.Node
+	var varsFile string
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		switch root.Content[i].Value {
+		case "vars":
+			varsNode = root.Content[i+1]
+			if err := varsNode.Decode(&vars); err != nil {
+				return fmt.Errorf("invalid vars block: %v", err)
+			}
+		case "vars_file":
+			varsFile = root.Content[i+1].Value
+		}
+	}
+
+	if varsFile != "" {
+		if !filepath.IsAbs(varsFile) {
+			varsFile = filepath.Join(configDir, varsFile)
+		}
+		if err := loadVarsFile(varsFile, vars); err != nil {
+			return err
+		}
+	}
+	if overridePath != "" {
+		if err := loadVarsFile(overridePath, vars); err != nil {
+			return err
+		}
+	}
+
+	for name := range vars {
+		if !isValidIdentifier(name) {
+			return fmt.Errorf("invalid variable name %q (use letters, digits and underscores)", name)
+		}
+	}
+
+	var undefined []string
+	substituteVars(root, varsNode, vars, &undefined)
+	if len(undefined) > 0 {
+		sort.Strings(undefined)
+		return fmt.Errorf("undefined config variables: %s", strings.Join(undefined, ", "))
+	}
+	return nil
+}
+
+// loadVarsFile merges a flat YAML map of variables (site: fra1) into vars, overriding existing entries
+func loadVarsFile(path string, vars map[string]string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read vars file: %v", err)
+	}
+	var overrides map[string]string
+	if err := yaml.Unmarshal(data, &overrides); err != nil {
+		return fmt.Errorf("invalid vars file %s: %v", path, err)
+	}
+	for name, value := range overrides {
+		vars[name] = value
+	}
+	return nil
+}
+
+// substituteVars replaces variable references in all scalars under n, skipping the vars block itself
+// Undefined references are left in place and collected (once each) in undefined
+func substituteVars(n, skip *yaml.Node, vars map[string]string, undefined *[]string) {
+	if n == skip {
+		return
+	}
+	if n.Kind == yaml.ScalarNode {
+		if !strings.Contains(n.Value, "${vars.") {
+			return
+		}
+		n.Value = varRefPattern.ReplaceAllStringFunc(n.Value, func(ref string) string {
+			name := varRefPattern.FindStringSubmatch(ref)[1]
+			if value, ok := vars[name]; ok {
+				return value
+			}
+			ref = "${vars." + name + "}"
+			for _, seen := range *undefined {
+				if seen == ref {
+					return ref
+				}
+			}
+			*undefined = append(*undefined, ref)
+			return ref
+		})
+		// Substituted values are plain strings; let YAML re-resolve the type (e.g. "161" -> int)
+		n.Tag = ""
+		return
+	}
+	for _, child := range n.Content {
+		substituteVars(child, skip, vars, undefined)
+	}
+}
@@ -0,0 +1,79 @@
+package discovery
+
+import (
+	"sync"
+	"time"
+)
+
+// AdaptiveInterval stretches the ICMP discovery interval on stable networks and tightens it when churn resumes
+// Churn is any sweep that finds new devices or any prune that removes devices since the previous sweep
+// After stableSweeps consecutive quiet sweeps the interval doubles per further quiet sweep, capped at max
+type AdaptiveInterval struct {
+	mu           sync.Mutex
+	base         time.Duration // Configured icmp_discovery_interval (floor)
+	max          time.Duration // Upper bound; max <= base disables adaptation
+	stableSweeps int           // Quiet sweeps required before stretching
+	quietSweeps  int           // Consecutive sweeps without churn
+	pendingPrune int           // Devices pruned since the last sweep
+	current      time.Duration
+}
+
+// NewAdaptiveInterval creates an adaptive interval starting at base
+// A max of zero (or not above base) keeps the interval fixed at base
+func NewAdaptiveInterval(base, max time.Duration, stableSweeps int) *AdaptiveInterval {
+	if stableSweeps < 1 {
+		stableSweeps = 1
+	}
+	return &AdaptiveInterval{
+		base:         base,
+		max:          max,
+		stableSweeps: stableSweeps,
+		current:      base,
+	}
+}
+
+// RecordPrune notes devices removed by state pruning; they count as churn for the next sweep
+func (a *AdaptiveInterval) RecordPrune(count int) {
+	if count <= 0 {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.pendingPrune += count
+}
+
+// RecordSweep records the result of a discovery sweep and returns the interval until the next one
+// changed reports whether the interval differs from the previous value (caller should reset its ticker)
+func (a *AdaptiveInterval) RecordSweep(newDevices int) (next time.Duration, changed bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	previous := a.current
+	if a.max <= a.base {
+		return a.current, false // Adaptation disabled
+	}
+
+	if newDevices > 0 || a.pendingPrune > 0 {
+		// Churn: snap back to the configured interval
+		a.quietSweeps = 0
+		a.current = a.base
+	} else {
+		a.quietSweeps++
+		if a.quietSweeps >= a.stableSweeps {
+			a.current *= 2
+			if a.current > a.max {
+				a.current = a.max
+			}
+		}
+	}
+	a.pendingPrune = 0
+
+	return a.current, a.current != previous
+}
+
+// Current returns the interval currently in effect
+func (a *AdaptiveInterval) Current() time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.current
+}
@@ -0,0 +1,61 @@
+package discovery
+
+import (
+	"testing"
+	"time"
+)
+
+// TestAdaptiveIntervalStretchesWhenStable verifies the interval doubles after the stable threshold and caps at max
+func TestAdaptiveIntervalStretchesWhenStable(t *testing.T) {
+	a := NewAdaptiveInterval(5*time.Minute, 30*time.Minute, 2)
+
+	// First quiet sweep is below the threshold
+	if next, changed := a.RecordSweep(0); changed || next != 5*time.Minute {
+		t.Fatalf("expected 5m unchanged after 1 quiet sweep, got %v (changed=%v)", next, changed)
+	}
+
+	expected := []time.Duration{10 * time.Minute, 20 * time.Minute, 30 * time.Minute, 30 * time.Minute}
+	for i, want := range expected {
+		next, _ := a.RecordSweep(0)
+		if next != want {
+			t.Errorf("quiet sweep %d: expected %v, got %v", i+2, want, next)
+		}
+	}
+}
+
+// TestAdaptiveIntervalResetsOnChurn verifies new devices or pruned devices restore the base interval
+func TestAdaptiveIntervalResetsOnChurn(t *testing.T) {
+	a := NewAdaptiveInterval(5*time.Minute, time.Hour, 1)
+	a.RecordSweep(0)
+	a.RecordSweep(0)
+	if a.Current() != 20*time.Minute {
+		t.Fatalf("expected 20m after two quiet sweeps, got %v", a.Current())
+	}
+
+	// New devices found
+	if next, changed := a.RecordSweep(3); !changed || next != 5*time.Minute {
+		t.Errorf("expected reset to 5m on new devices, got %v (changed=%v)", next, changed)
+	}
+
+	// Pruned devices count as churn for the following sweep
+	a.RecordSweep(0)
+	a.RecordPrune(2)
+	if next, _ := a.RecordSweep(0); next != 5*time.Minute {
+		t.Errorf("expected reset to 5m after prune, got %v", next)
+	}
+
+	// Prune churn is consumed by one sweep
+	if next, _ := a.RecordSweep(0); next != 10*time.Minute {
+		t.Errorf("expected 10m on quiet sweep after prune was consumed, got %v", next)
+	}
+}
+
+// TestAdaptiveIntervalDisabled verifies a zero max keeps the interval fixed
+func TestAdaptiveIntervalDisabled(t *testing.T) {
+	a := NewAdaptiveInterval(5*time.Minute, 0, 1)
+	for i := 0; i < 5; i++ {
+		if next, changed := a.RecordSweep(0); changed || next != 5*time.Minute {
+			t.Fatalf("expected fixed 5m interval, got %v (changed=%v)", next, changed)
+		}
+	}
+}
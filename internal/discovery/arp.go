@@ -0,0 +1,168 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/time/rate"
+)
+
+// arpReplyWait is how long to keep listening for replies after the last ARP request was sent
+const arpReplyWait = 1 * time.Second
+
+// ARP frame constants (Ethernet + IPv4 ARP, RFC 826)
+const (
+	etherTypeARP  = 0x0806
+	arpOpRequest  = 1
+	arpOpReply    = 2
+	arpFrameLen   = 42 // 14 byte Ethernet header + 28 byte ARP payload
+	etherHdrLen   = 14
+	arpHwEthernet = 1
+	arpProtoIPv4  = 0x0800
+)
+
+// ethernetBroadcast is the destination MAC for ARP requests
+var ethernetBroadcast = net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+// arpTarget describes a configured network that is directly attached to a local interface
+type arpTarget struct {
+	iface   net.Interface
+	srcIP   net.IP // Our IPv4 address on the attached subnet
+	network string // Configured CIDR
+}
+
+// RunARPSweep sends ARP requests to every host of the configured networks that are directly attached
+// to a local Ethernet interface and returns the IPs that replied
+// Networks that are not on a local segment are skipped (ARP does not cross routers)
+// The limiter is consulted once per ARP request; hosts in excluded are never asked
+func RunARPSweep(ctx context.Context, networks []string, excluded *config.Exclusions, limiter *rate.Limiter) []string {
+	var responsiveIPs []string
+	for _, target := range findAttachedNetworks(networks) {
+		targets := filterExcluded(ipsFromCIDR(target.network), excluded)
+		found, err := arpSweepInterface(ctx, target.iface, target.srcIP, targets, limiter)
+		if err != nil {
+			log.Warn().
+				Str("network", target.network).
+				Str("interface", target.iface.Name).
+				Err(err).
+				Msg("ARP sweep failed")
+			continue
+		}
+		log.Debug().
+			Str("network", target.network).
+			Str("interface", target.iface.Name).
+			Int("found", len(found)).
+			Msg("ARP sweep completed")
+		responsiveIPs = append(responsiveIPs, found...)
+	}
+	return mergeIPs(responsiveIPs, nil)
+}
+
+// findAttachedNetworks matches configured networks against local interface subnets
+// A network qualifies when it lies entirely within an IPv4 subnet of an up, non-loopback Ethernet interface
+func findAttachedNetworks(networks []string) []arpTarget {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to list network interfaces for ARP discovery")
+		return nil
+	}
+
+	var targets []arpTarget
+	for _, cidr := range networks {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil || network.IP.To4() == nil {
+			continue // ARP is IPv4 only
+		}
+		ones, _ := network.Mask.Size()
+
+		matched := false
+		for _, iface := range ifaces {
+			if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 || len(iface.HardwareAddr) != 6 {
+				continue
+			}
+			addrs, err := iface.Addrs()
+			if err != nil {
+				continue
+			}
+			for _, addr := range addrs {
+				ifNet, ok := addr.(*net.IPNet)
+				if !ok || ifNet.IP.To4() == nil {
+					continue
+				}
+				ifOnes, _ := ifNet.Mask.Size()
+				if ifNet.Contains(network.IP) && ifOnes <= ones {
+					targets = append(targets, arpTarget{iface: iface, srcIP: ifNet.IP.To4(), network: cidr})
+					matched = true
+					break
+				}
+			}
+			if matched {
+				break
+			}
+		}
+		if !matched {
+			log.Debug().Str("network", cidr).Msg("Network is not directly attached, skipping ARP sweep")
+		}
+	}
+	return targets
+}
+
+// buildARPRequest builds a broadcast Ethernet frame carrying an ARP who-has request for targetIP
+func buildARPRequest(srcMAC net.HardwareAddr, srcIP, targetIP net.IP) []byte {
+	frame := make([]byte, arpFrameLen)
+
+	// Ethernet header
+	copy(frame[0:6], ethernetBroadcast)
+	copy(frame[6:12], srcMAC)
+	binary.BigEndian.PutUint16(frame[12:14], etherTypeARP)
+
+	// ARP payload
+	arp := frame[etherHdrLen:]
+	binary.BigEndian.PutUint16(arp[0:2], arpHwEthernet)
+	binary.BigEndian.PutUint16(arp[2:4], arpProtoIPv4)
+	arp[4] = 6 // Hardware address length
+	arp[5] = 4 // Protocol address length
+	binary.BigEndian.PutUint16(arp[6:8], arpOpRequest)
+	copy(arp[8:14], srcMAC)
+	copy(arp[14:18], srcIP.To4())
+	// Target hardware address (arp[18:24]) stays zero
+	copy(arp[24:28], targetIP.To4())
+
+	return frame
+}
+
+// parseARPReply extracts the sender IP and MAC from an Ethernet frame if it is an IPv4 ARP reply
+func parseARPReply(frame []byte) (net.IP, net.HardwareAddr, bool) {
+	op, ip, mac, ok := parseARP(frame)
+	if !ok || op != arpOpReply {
+		return nil, nil, false
+	}
+	return ip, mac, true
+}
+
+// parseARP extracts the opcode, sender IP and sender MAC from an Ethernet frame carrying IPv4 ARP
+func parseARP(frame []byte) (uint16, net.IP, net.HardwareAddr, bool) {
+	if len(frame) < arpFrameLen {
+		return 0, nil, nil, false
+	}
+	if binary.BigEndian.Uint16(frame[12:14]) != etherTypeARP {
+		return 0, nil, nil, false
+	}
+
+	arp := frame[etherHdrLen:]
+	if binary.BigEndian.Uint16(arp[0:2]) != arpHwEthernet ||
+		binary.BigEndian.Uint16(arp[2:4]) != arpProtoIPv4 ||
+		arp[4] != 6 || arp[5] != 4 {
+		return 0, nil, nil, false
+	}
+
+	op := binary.BigEndian.Uint16(arp[6:8])
+	senderMAC := net.HardwareAddr(bytes.Clone(arp[8:14]))
+	senderIP := net.IPv4(arp[14], arp[15], arp[16], arp[17]).To4()
+	return op, senderIP, senderMAC, true
+}
@@ -0,0 +1,179 @@
+//go:build linux
+
+package discovery
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/time/rate"
+)
+
+// htons converts a uint16 from host to network byte order for AF_PACKET protocol fields
+func htons(v uint16) uint16 {
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], v)
+	return binary.NativeEndian.Uint16(b[:])
+}
+
+// arpSweepInterface sends ARP requests for all targets on iface via an AF_PACKET socket and collects replies
+// Requires CAP_NET_RAW (the same privilege used for raw ICMP sockets)
+func arpSweepInterface(ctx context.Context, iface net.Interface, srcIP net.IP, targets []string, limiter *rate.Limiter) ([]string, error) {
+	fd, err := syscall.Socket(syscall.AF_PACKET, syscall.SOCK_RAW, int(htons(etherTypeARP)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open AF_PACKET socket: %v", err)
+	}
+	defer syscall.Close(fd)
+
+	if err := syscall.Bind(fd, &syscall.SockaddrLinklayer{Protocol: htons(etherTypeARP), Ifindex: iface.Index}); err != nil {
+		return nil, fmt.Errorf("failed to bind to %s: %v", iface.Name, err)
+	}
+
+	// Short receive timeout so the reader can notice cancellation and the end of the sweep
+	readTimeout := syscall.NsecToTimeval((100 * time.Millisecond).Nanoseconds())
+	if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &readTimeout); err != nil {
+		return nil, fmt.Errorf("failed to set receive timeout: %v", err)
+	}
+
+	// Only replies from hosts we asked about are counted
+	wanted := make(map[string]bool, len(targets))
+	for _, ip := range targets {
+		if ip != srcIP.String() {
+			wanted[ip] = true
+		}
+	}
+
+	var (
+		mu       sync.Mutex
+		found    = make(map[string]bool)
+		stopRead = make(chan struct{})
+		readDone = make(chan struct{})
+	)
+
+	// Reader: collect ARP replies until the sender signals completion
+	go func() {
+		// Panic recovery for ARP reader goroutine
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error().
+					Interface("panic", r).
+					Msg("ARP reader panic recovered")
+			}
+		}()
+		defer close(readDone)
+
+		buf := make([]byte, 1500)
+		for {
+			select {
+			case <-stopRead:
+				return
+			default:
+			}
+
+			n, _, err := syscall.Recvfrom(fd, buf, 0)
+			if err != nil {
+				continue // Timeout (EAGAIN) or transient error; re-check stop signal
+			}
+			ip, mac, ok := parseARPReply(buf[:n])
+			if !ok || !wanted[ip.String()] {
+				continue
+			}
+			mu.Lock()
+			if !found[ip.String()] {
+				found[ip.String()] = true
+				log.Debug().
+					Str("ip", ip.String()).
+					Str("mac", mac.String()).
+					Msg("ARP reply received")
+			}
+			mu.Unlock()
+		}
+	}()
+
+	// Sender: one broadcast who-has per target
+	dst := &syscall.SockaddrLinklayer{
+		Protocol: htons(etherTypeARP),
+		Ifindex:  iface.Index,
+		Halen:    6,
+	}
+	copy(dst.Addr[:], ethernetBroadcast)
+
+	var sendErr error
+	for ip := range wanted {
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				sendErr = err
+				break
+			}
+		}
+		frame := buildARPRequest(iface.HardwareAddr, srcIP, net.ParseIP(ip))
+		if err := syscall.Sendto(fd, frame, 0, dst); err != nil {
+			log.Debug().
+				Str("ip", ip).
+				Err(err).
+				Msg("Failed to send ARP request")
+		}
+	}
+
+	// Give late replies a chance to arrive unless we are shutting down
+	if sendErr == nil {
+		select {
+		case <-time.After(arpReplyWait):
+		case <-ctx.Done():
+		}
+	}
+	close(stopRead)
+	<-readDone
+
+	mu.Lock()
+	defer mu.Unlock()
+	result := make([]string, 0, len(found))
+	for ip := range found {
+		result = append(result, ip)
+	}
+	return result, nil
+}
+
+// packetOutgoing is the AF_PACKET packet type of frames sent by this host (PACKET_OUTGOING)
+const packetOutgoing = 4
+
+// listenARPInterface passes the sender of every ARP frame received on iface to handle until ctx is cancelled
+// Frames sent by this host (including our own sweep requests) are ignored
+func listenARPInterface(ctx context.Context, iface net.Interface, handle func(ip net.IP, mac net.HardwareAddr)) error {
+	fd, err := syscall.Socket(syscall.AF_PACKET, syscall.SOCK_RAW, int(htons(etherTypeARP)))
+	if err != nil {
+		return fmt.Errorf("failed to open AF_PACKET socket: %v", err)
+	}
+	defer syscall.Close(fd)
+
+	if err := syscall.Bind(fd, &syscall.SockaddrLinklayer{Protocol: htons(etherTypeARP), Ifindex: iface.Index}); err != nil {
+		return fmt.Errorf("failed to bind to %s: %v", iface.Name, err)
+	}
+
+	// Receive timeout so the loop notices cancellation on quiet segments
+	readTimeout := syscall.NsecToTimeval((500 * time.Millisecond).Nanoseconds())
+	if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &readTimeout); err != nil {
+		return fmt.Errorf("failed to set receive timeout: %v", err)
+	}
+
+	buf := make([]byte, 1500)
+	for ctx.Err() == nil {
+		n, from, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			continue // Timeout (EAGAIN) or transient error; re-check cancellation
+		}
+		if ll, ok := from.(*syscall.SockaddrLinklayer); ok && ll.Pkttype == packetOutgoing {
+			continue
+		}
+		if _, ip, mac, ok := parseARP(buf[:n]); ok {
+			handle(ip, mac)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"golang.org/x/time/rate"
+)
+
+// arpSweepInterface is only implemented on Linux (AF_PACKET sockets)
+func arpSweepInterface(ctx context.Context, iface net.Interface, srcIP net.IP, targets []string, limiter *rate.Limiter) ([]string, error) {
+	return nil, fmt.Errorf("ARP discovery is only supported on Linux")
+}
+
+// listenARPInterface is only implemented on Linux (AF_PACKET sockets)
+func listenARPInterface(ctx context.Context, iface net.Interface, handle func(ip net.IP, mac net.HardwareAddr)) error {
+	return fmt.Errorf("passive ARP listening is only supported on Linux")
+}
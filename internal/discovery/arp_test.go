@@ -0,0 +1,66 @@
+package discovery
+
+import (
+	"net"
+	"testing"
+)
+
+// TestBuildARPRequest verifies the Ethernet/ARP who-has frame layout
+func TestBuildARPRequest(t *testing.T) {
+	srcMAC := net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x01}
+	frame := buildARPRequest(srcMAC, net.ParseIP("192.168.1.10"), net.ParseIP("192.168.1.20"))
+
+	if len(frame) != arpFrameLen {
+		t.Fatalf("expected frame length %d, got %d", arpFrameLen, len(frame))
+	}
+	if net.HardwareAddr(frame[0:6]).String() != "ff:ff:ff:ff:ff:ff" {
+		t.Errorf("expected broadcast destination, got %s", net.HardwareAddr(frame[0:6]))
+	}
+	if frame[12] != 0x08 || frame[13] != 0x06 {
+		t.Errorf("expected ARP ethertype 0x0806, got %#x%02x", frame[12], frame[13])
+	}
+	if frame[21] != arpOpRequest {
+		t.Errorf("expected ARP request opcode, got %d", frame[21])
+	}
+	if !net.IP(frame[28:32]).Equal(net.ParseIP("192.168.1.10")) {
+		t.Errorf("expected sender IP 192.168.1.10, got %s", net.IP(frame[28:32]))
+	}
+	if !net.IP(frame[38:42]).Equal(net.ParseIP("192.168.1.20")) {
+		t.Errorf("expected target IP 192.168.1.20, got %s", net.IP(frame[38:42]))
+	}
+}
+
+// TestParseARPReply verifies replies are parsed and requests or short frames are ignored
+func TestParseARPReply(t *testing.T) {
+	replyMAC := net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x02}
+	frame := buildARPRequest(replyMAC, net.ParseIP("192.168.1.20"), net.ParseIP("192.168.1.10"))
+
+	// A request must not be treated as a reply
+	if _, _, ok := parseARPReply(frame); ok {
+		t.Error("expected ARP request to be ignored")
+	}
+
+	frame[21] = arpOpReply
+	ip, mac, ok := parseARPReply(frame)
+	if !ok {
+		t.Fatal("expected ARP reply to be parsed")
+	}
+	if ip.String() != "192.168.1.20" {
+		t.Errorf("expected sender IP 192.168.1.20, got %s", ip)
+	}
+	if mac.String() != replyMAC.String() {
+		t.Errorf("expected sender MAC %s, got %s", replyMAC, mac)
+	}
+
+	if _, _, ok := parseARPReply(frame[:20]); ok {
+		t.Error("expected truncated frame to be ignored")
+	}
+}
+
+// TestFindAttachedNetworksSkipsRemote verifies networks not on a local segment are not ARP-swept
+func TestFindAttachedNetworksSkipsRemote(t *testing.T) {
+	// TEST-NET-2 documentation range is never configured on a real interface
+	if targets := findAttachedNetworks([]string{"198.51.100.0/24", "2001:db8::/64", "invalid"}); len(targets) != 0 {
+		t.Errorf("expected no attached networks, got %v", targets)
+	}
+}
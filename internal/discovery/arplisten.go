@@ -0,0 +1,135 @@
+package discovery
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/rs/zerolog/log"
+)
+
+// arpSightingInterval is how often the same host is reported again while it keeps sending ARP traffic
+// Re-reporting lets a host pruned from state be picked up again without waiting for a sweep
+const arpSightingInterval = 30 * time.Second
+
+// arpSightingPruneSize is the number of remembered hosts above which expired entries are dropped
+const arpSightingPruneSize = 4096
+
+// ARPSighting is a host seen sending ARP traffic (a request, a reply or a gratuitous ARP)
+type ARPSighting struct {
+	IP        string
+	MAC       net.HardwareAddr
+	Interface string
+}
+
+// ListenARP passively listens for ARP traffic on the named interfaces and sends sightings of hosts
+// inside networks to out until ctx is cancelled
+// Hosts in excluded are never reported; a host is reported again at most every arpSightingInterval
+// unless its MAC changes. Linux only (AF_PACKET, requires CAP_NET_RAW)
+func ListenARP(ctx context.Context, interfaces []string, networks []string, excluded *config.Exclusions, out chan<- ARPSighting) {
+	scope := parseNetworks(networks)
+	filter := newSightingFilter()
+
+	var wg sync.WaitGroup
+	for _, name := range interfaces {
+		iface, err := net.InterfaceByName(name)
+		if err != nil {
+			log.Warn().Str("interface", name).Err(err).Msg("Passive ARP listener: interface not found, skipping")
+			continue
+		}
+
+		wg.Add(1)
+		go func(iface net.Interface) {
+			defer wg.Done()
+			// Panic recovery for passive ARP listener goroutine
+			defer func() {
+				if r := recover(); r != nil {
+					log.Error().
+						Str("interface", iface.Name).
+						Interface("panic", r).
+						Msg("Passive ARP listener panic recovered")
+				}
+			}()
+
+			err := listenARPInterface(ctx, iface, func(ip net.IP, mac net.HardwareAddr) {
+				if ip.IsUnspecified() || !containsIP(scope, ip) {
+					return // ARP probes (sender 0.0.0.0) and hosts outside the configured networks
+				}
+				if excluded.Contains(ip.String()) {
+					return
+				}
+				if !filter.allow(ip.String(), mac.String(), time.Now()) {
+					return
+				}
+				select {
+				case out <- ARPSighting{IP: ip.String(), MAC: mac, Interface: iface.Name}:
+				case <-ctx.Done():
+				}
+			})
+			if err != nil {
+				log.Warn().Str("interface", iface.Name).Err(err).Msg("Passive ARP listener stopped")
+			}
+		}(*iface)
+	}
+	wg.Wait()
+}
+
+// sightingFilter suppresses repeated sightings of the same host
+type sightingFilter struct {
+	mu   sync.Mutex
+	seen map[string]sighting // By IP
+}
+
+// sighting is the last reported MAC of a host and when it was reported
+type sighting struct {
+	mac string
+	at  time.Time
+}
+
+// newSightingFilter creates a filter that has not seen any host
+func newSightingFilter() *sightingFilter {
+	return &sightingFilter{seen: make(map[string]sighting)}
+}
+
+// allow reports whether a sighting should be passed on: the host is new, its MAC changed,
+// or it was last reported at least arpSightingInterval ago
+func (f *sightingFilter) allow(ip, mac string, now time.Time) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if last, ok := f.seen[ip]; ok && last.mac == mac && now.Sub(last.at) < arpSightingInterval {
+		return false
+	}
+	if len(f.seen) >= arpSightingPruneSize {
+		for seenIP, last := range f.seen {
+			if now.Sub(last.at) >= arpSightingInterval {
+				delete(f.seen, seenIP)
+			}
+		}
+	}
+	f.seen[ip] = sighting{mac: mac, at: now}
+	return true
+}
+
+// parseNetworks parses CIDRs, skipping invalid entries (config validation reports them)
+func parseNetworks(networks []string) []*net.IPNet {
+	parsed := make([]*net.IPNet, 0, len(networks))
+	for _, cidr := range networks {
+		if _, ipnet, err := net.ParseCIDR(cidr); err == nil {
+			parsed = append(parsed, ipnet)
+		}
+	}
+	return parsed
+}
+
+// containsIP reports whether ip lies within any of networks
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
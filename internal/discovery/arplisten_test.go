@@ -0,0 +1,63 @@
+package discovery
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// TestParseARPAcceptsRequests verifies the passive listener sees requests and gratuitous ARPs, not just replies
+func TestParseARPAcceptsRequests(t *testing.T) {
+	mac := net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x03}
+	// Gratuitous ARP: a request announcing the sender's own address
+	frame := buildARPRequest(mac, net.ParseIP("192.168.1.30"), net.ParseIP("192.168.1.30"))
+
+	op, ip, senderMAC, ok := parseARP(frame)
+	if !ok {
+		t.Fatal("expected ARP request to be parsed")
+	}
+	if op != arpOpRequest || ip.String() != "192.168.1.30" || senderMAC.String() != mac.String() {
+		t.Errorf("unexpected parse result: op=%d ip=%s mac=%s", op, ip, senderMAC)
+	}
+
+	frame[12] = 0x08
+	frame[13] = 0x00 // IPv4 ethertype
+	if _, _, _, ok := parseARP(frame); ok {
+		t.Error("expected non-ARP frame to be ignored")
+	}
+}
+
+// TestSightingFilter verifies repeated sightings are suppressed until the interval passes or the MAC changes
+func TestSightingFilter(t *testing.T) {
+	f := newSightingFilter()
+	now := time.Now()
+
+	if !f.allow("192.168.1.30", "02:00:00:00:00:03", now) {
+		t.Error("expected first sighting to be allowed")
+	}
+	if f.allow("192.168.1.30", "02:00:00:00:00:03", now.Add(time.Second)) {
+		t.Error("expected repeated sighting to be suppressed")
+	}
+	if !f.allow("192.168.1.30", "02:00:00:00:00:04", now.Add(2*time.Second)) {
+		t.Error("expected MAC change to be reported")
+	}
+	if !f.allow("192.168.1.30", "02:00:00:00:00:04", now.Add(2*time.Second+arpSightingInterval)) {
+		t.Error("expected sighting after the interval to be reported again")
+	}
+	if !f.allow("192.168.1.31", "02:00:00:00:00:05", now) {
+		t.Error("expected other host to be allowed")
+	}
+}
+
+// TestContainsIP verifies sightings are limited to the configured networks
+func TestContainsIP(t *testing.T) {
+	scope := parseNetworks([]string{"192.168.1.0/24", "invalid", "10.0.0.0/8"})
+	if len(scope) != 2 {
+		t.Fatalf("expected 2 parsed networks, got %d", len(scope))
+	}
+	for ip, want := range map[string]bool{"192.168.1.30": true, "10.1.2.3": true, "192.168.2.1": false} {
+		if got := containsIP(scope, net.ParseIP(ip)); got != want {
+			t.Errorf("containsIP(%s) = %v, want %v", ip, got, want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+)
+
+// SweepCursor tracks progress through the address space of a budgeted (streaming) discovery
+// Each sweep probes at most budget addresses starting at Offset, then advances; when a path is set
+// the cursor is saved after every sweep so very large spaces can be inventoried across restarts
+type SweepCursor struct {
+	Networks []string  `json:"networks"` // Networks the offset refers to; a change resets the cursor
+	Offset   uint64    `json:"offset"`   // Index of the next address to probe
+	Passes   uint64    `json:"passes"`   // Completed passes over the whole address space
+	Updated  time.Time `json:"updated"`
+
+	path   string // Persistence file ("" = in memory only)
+	budget uint64
+}
+
+// NewSweepCursor creates a cursor probing at most budget addresses per sweep
+// When path is set and holds a cursor for the same networks, scanning resumes where it left off
+func NewSweepCursor(path string, budget int, networks []string) (*SweepCursor, error) {
+	c := &SweepCursor{Networks: slices.Clone(networks), path: path, budget: uint64(budget)}
+	if path == "" {
+		return c, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read discovery cursor %s: %v", path, err)
+	}
+
+	var saved SweepCursor
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("invalid discovery cursor %s: %v", path, err)
+	}
+	if slices.Equal(saved.Networks, networks) {
+		c.Offset = saved.Offset
+		c.Passes = saved.Passes
+		c.Updated = saved.Updated
+	}
+	return c, nil
+}
+
+// window returns the source for the next sweep and the number of addresses it covers; excluded hosts are skipped
+func (c *SweepCursor) window(space *addressSpace, excluded *config.Exclusions) (targetSource, uint64) {
+	count := c.budget
+	if count == 0 || count > space.total {
+		count = space.total
+	}
+	if space.total > 0 {
+		c.Offset %= space.total
+	}
+	return windowSource(space, c.Offset, count, excluded), count
+}
+
+// advance moves the cursor past a completed sweep and persists it
+// Returns true when the sweep finished a full pass over the address space
+func (c *SweepCursor) advance(space *addressSpace, count uint64) (bool, error) {
+	if space.total == 0 {
+		return false, nil
+	}
+	next := c.Offset + count
+	wrapped := next >= space.total
+	if wrapped {
+		c.Passes++
+	}
+	c.Offset = next % space.total
+	c.Updated = time.Now()
+	return wrapped, c.save()
+}
+
+// save writes the cursor atomically (temp file + rename) when a path is configured
+func (c *SweepCursor) save() error {
+	if c.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), ".netscan-cursor-*")
+	if err != nil {
+		return fmt.Errorf("failed to save discovery cursor: %v", err)
+	}
+	defer os.Remove(tmp.Name()) // No-op after a successful rename
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save discovery cursor: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save discovery cursor: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to save discovery cursor: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package discovery
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// TestAddressSpaceIndexing validates host ranges skip network/broadcast addresses and span networks
+func TestAddressSpaceIndexing(t *testing.T) {
+	space := newAddressSpace([]string{"192.168.1.0/30", "10.0.0.5/32", "10.1.0.0/8"})
+
+	// /30 -> 2 hosts, /32 -> 1 host, /8 -> 2^24-2 hosts (never expanded)
+	if want := uint64(2 + 1 + (1<<24 - 2)); space.total != want {
+		t.Fatalf("expected %d addresses, got %d", want, space.total)
+	}
+	cases := map[uint64]string{
+		0:               "192.168.1.1",
+		1:               "192.168.1.2",
+		2:               "10.0.0.5",
+		3:               "10.0.0.1",
+		space.total - 1: "10.255.255.254",
+	}
+	for i, want := range cases {
+		if got := space.at(i); got != want {
+			t.Errorf("at(%d) = %s, want %s", i, got, want)
+		}
+	}
+}
+
+// collect drains a target source into a sorted slice
+func collect(source targetSource) []string {
+	jobs := make(chan string, 64)
+	go func() {
+		source(context.Background(), jobs, nil)
+		close(jobs)
+	}()
+	var ips []string
+	for ip := range jobs {
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+	return ips
+}
+
+// TestSweepCursorWindows validates budgeted windows wrap around and count completed passes
+func TestSweepCursorWindows(t *testing.T) {
+	networks := []string{"192.168.1.0/29"} // 6 hosts: .1-.6
+	space := newAddressSpace(networks)
+	cursor, err := NewSweepCursor("", 4, networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source, count := cursor.window(space, nil)
+	if got := collect(source); !reflect.DeepEqual(got, []string{"192.168.1.1", "192.168.1.2", "192.168.1.3", "192.168.1.4"}) {
+		t.Errorf("first window = %v", got)
+	}
+	if wrapped, _ := cursor.advance(space, count); wrapped {
+		t.Error("expected first window not to complete a pass")
+	}
+
+	source, count = cursor.window(space, nil)
+	if got := collect(source); !reflect.DeepEqual(got, []string{"192.168.1.1", "192.168.1.2", "192.168.1.5", "192.168.1.6"}) {
+		t.Errorf("second window = %v", got)
+	}
+	if wrapped, _ := cursor.advance(space, count); !wrapped || cursor.Passes != 1 || cursor.Offset != 2 {
+		t.Errorf("expected wrap to offset 2 after one pass, got wrapped=%v passes=%d offset=%d", wrapped, cursor.Passes, cursor.Offset)
+	}
+}
+
+// TestSweepCursorPersistence validates the cursor resumes from disk and resets when networks change
+func TestSweepCursorPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cursor.json")
+	networks := []string{"10.0.0.0/16"}
+	space := newAddressSpace(networks)
+
+	cursor, err := NewSweepCursor(path, 1000, networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, count := cursor.window(space, nil)
+	if _, err := cursor.advance(space, count); err != nil {
+		t.Fatal(err)
+	}
+
+	resumed, err := NewSweepCursor(path, 1000, networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed.Offset != 1000 {
+		t.Errorf("expected resumed offset 1000, got %d", resumed.Offset)
+	}
+
+	changed, err := NewSweepCursor(path, 1000, []string{"10.1.0.0/16"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed.Offset != 0 {
+		t.Errorf("expected cursor reset after networks changed, got offset %d", changed.Offset)
+	}
+}
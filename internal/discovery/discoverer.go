@@ -0,0 +1,235 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/ratelimit"
+	"github.com/kljama/netscan/internal/state"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/time/rate"
+)
+
+// Built-in discovery methods selectable via discovery_methods
+const (
+	MethodSweep = "sweep" // ICMP, TCP and/or ARP sweep selected by discovery_mode and arp_discovery (default)
+	MethodSNMP  = "snmp"  // SNMP sysName/sysDescr query of every address, for hosts that drop ICMP and TCP probes
+	MethodSSDP  = "ssdp"  // SSDP M-SEARCH on the directly attached segment, recording UPnP model and manufacturer
+)
+
+// Discoverer is a discovery method run by every discovery sweep
+// Discover returns the devices it found; only IP is required, methods that learn more (hostname, sysDescr)
+// fill it in. It must return promptly once ctx is cancelled
+type Discoverer interface {
+	Name() string
+	Discover(ctx context.Context) []state.Device
+}
+
+// Sweep is the discovery sweep a discoverer is built for
+type Sweep struct {
+	Networks      []string              // Networks to discover: cfg.Networks minus ranges disabled by overlap detection
+	Cursor        *SweepCursor          // Streaming window (nil = full sweeps); advanced once every method has run
+	Limiter       *rate.Limiter         // Global probe rate limit (nil = unlimited)
+	NetworkLimits *ratelimit.Partitions // network_rate_limits partitions taken before Limiter (nil = none), shared with the pingers
+	Privileged    bool                  // Raw ICMP sockets for discovery pings (false: unprivileged UDP sockets), from ResolveICMPMode
+	SourceIP      string                // Local IPv4 address discovery pings and SNMP queries are sent from ("" = any)
+	ICMPWorkers   int                   // Workers of the ICMP and TCP sweeps, e.g. auto-tuned (0 = icmp_workers)
+	SNMPWorkers   int                   // Workers of the SNMP sweep's scans, e.g. auto-tuned (0 = snmp_workers)
+	Progress      *Progress             // Sweep progress (nil = not tracked)
+	OnFound       func(ip string)       // Reports a device as soon as it is found; may be called again for the same IP
+
+	source   targetSource       // Addresses probed by the built-in methods (the cursor's window when streaming)
+	excluded *config.Exclusions // exclude_networks / exclude_ips of the sweep's configuration
+}
+
+// icmpWorkers returns the workers of the sweep's ICMP and TCP probes
+func (s *Sweep) icmpWorkers(cfg *config.Config) int {
+	if s.ICMPWorkers > 0 {
+		return s.ICMPWorkers
+	}
+	return cfg.IcmpWorkers
+}
+
+// snmpWorkers returns the workers of the sweep's SNMP scans
+func (s *Sweep) snmpWorkers(cfg *config.Config) int {
+	if s.SNMPWorkers > 0 {
+		return s.SNMPWorkers
+	}
+	return cfg.SnmpWorkers
+}
+
+// DiscovererFactory builds a discoverer for one sweep
+type DiscovererFactory func(cfg *config.Config, sweep *Sweep) Discoverer
+
+// discoverers holds the registered discovery methods by name
+var (
+	discoverersMu sync.RWMutex
+	discoverers   = make(map[string]DiscovererFactory)
+)
+
+func init() {
+	RegisterDiscoverer(MethodSweep, newProbeSweep)
+	RegisterDiscoverer(MethodSNMP, newSNMPSweep)
+	RegisterDiscoverer(MethodSSDP, newSSDPSweep)
+}
+
+// RegisterDiscoverer makes a discovery method selectable by name in discovery_methods
+// Call from an init function; panics if the name is empty or already registered
+func RegisterDiscoverer(name string, factory DiscovererFactory) {
+	discoverersMu.Lock()
+	defer discoverersMu.Unlock()
+	if name == "" || factory == nil {
+		panic("discovery: RegisterDiscoverer needs a name and a factory")
+	}
+	if _, dup := discoverers[name]; dup {
+		panic("discovery: discoverer " + name + " registered twice")
+	}
+	discoverers[name] = factory
+}
+
+// Discoverers returns the names of the registered discovery methods, sorted
+func Discoverers() []string {
+	discoverersMu.RLock()
+	defer discoverersMu.RUnlock()
+	names := make([]string, 0, len(discoverers))
+	for name := range discoverers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckDiscoverers returns an error for the first name that is not a registered discovery method
+// Config validation cannot see the registry, so callers check discovery_methods with this at startup
+func CheckDiscoverers(names []string) error {
+	for _, name := range names {
+		if lookupDiscoverer(name) == nil {
+			return fmt.Errorf("discovery_methods: unknown method %q (registered: %s)", name, strings.Join(Discoverers(), ", "))
+		}
+	}
+	return nil
+}
+
+// lookupDiscoverer returns the factory registered under name, or nil
+func lookupDiscoverer(name string) DiscovererFactory {
+	discoverersMu.RLock()
+	defer discoverersMu.RUnlock()
+	return discoverers[name]
+}
+
+// discoveryMethods returns the configured methods; a Config built without LoadConfig runs the sweep only
+func discoveryMethods(cfg *config.Config) []string {
+	if len(cfg.DiscoveryMethods) == 0 {
+		return []string{MethodSweep}
+	}
+	return cfg.DiscoveryMethods
+}
+
+// RunDiscoverers runs every method of cfg.DiscoveryMethods in order and returns the devices they found,
+// deduplicated by IP (hostname, sysDescr and UPnP description are taken from the first method that reported them)
+// Each device is reported through sweep.OnFound once, as soon as the first method finds it; excluded and
+// invalid addresses returned by a method are dropped. With sweep.Cursor set, every method works on the
+// cursor's next window and the cursor advances afterwards unless the sweep was cancelled
+func RunDiscoverers(ctx context.Context, cfg *config.Config, sweep *Sweep) []state.Device {
+	report := reportOnce(sweep.Progress.counting(sweep.OnFound))
+	run := *sweep
+	run.OnFound = report
+	run.excluded = cfg.Exclusions()
+	run.source = fullSweepSource(sweep.Networks, run.excluded)
+	var (
+		space *addressSpace
+		count uint64
+	)
+	if sweep.Cursor != nil {
+		space = newAddressSpace(sweep.Networks)
+		run.source, count = sweep.Cursor.window(space, run.excluded)
+		log.Info().
+			Uint64("offset", sweep.Cursor.Offset).
+			Uint64("count", count).
+			Uint64("total", space.total).
+			Msg("Streaming discovery window")
+	}
+
+	var (
+		found   []state.Device
+		byIP    = make(map[string]int)
+		methods = discoveryMethods(cfg)
+	)
+	for _, name := range methods {
+		if ctx.Err() != nil {
+			break
+		}
+		factory := lookupDiscoverer(name)
+		if factory == nil {
+			log.Error().Str("method", name).Msg("Unknown discovery method skipped")
+			continue
+		}
+		d := factory(cfg, &run)
+		sweep.Progress.setPhase(d.Name())
+		devices := d.Discover(ctx)
+		added := 0
+		for _, dev := range devices {
+			ip := net.ParseIP(dev.IP)
+			if ip == nil || run.excluded.Contains(dev.IP) {
+				log.Debug().
+					Str("method", name).
+					Str("ip", dev.IP).
+					Msg("Discovered address dropped (invalid or excluded)")
+				continue
+			}
+			dev.IP = ip.String()
+			if i, seen := byIP[dev.IP]; seen {
+				if found[i].Hostname == "" {
+					found[i].Hostname = dev.Hostname
+				}
+				if found[i].SysDescr == "" {
+					found[i].SysDescr = dev.SysDescr
+				}
+				if found[i].UPnP.IsZero() {
+					found[i].UPnP = dev.UPnP
+				}
+				continue
+			}
+			byIP[dev.IP] = len(found)
+			found = append(found, dev)
+			report(dev.IP)
+			added++
+		}
+		if len(methods) > 1 {
+			log.Info().
+				Str("method", name).
+				Int("found", len(devices)).
+				Int("new", added).
+				Msg("Discovery method finished")
+		}
+	}
+
+	// Only advance the cursor past windows that were fully probed
+	if sweep.Cursor != nil && ctx.Err() == nil {
+		wrapped, err := sweep.Cursor.advance(space, count)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to persist discovery cursor")
+		}
+		if wrapped {
+			log.Info().
+				Uint64("passes", sweep.Cursor.Passes).
+				Uint64("total", space.total).
+				Msg("Streaming discovery completed a full pass over all networks")
+		}
+	}
+	return found
+}
+
+// devicesFromIPs wraps responsive IPs of a probe method as devices
+func devicesFromIPs(ips []string) []state.Device {
+	devices := make([]state.Device, len(ips))
+	for i, ip := range ips {
+		devices[i] = state.Device{IP: ip}
+	}
+	return devices
+}
@@ -0,0 +1,73 @@
+package discovery
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// staticDiscoverer returns a fixed device list, like an inventory import would
+type staticDiscoverer struct {
+	name    string
+	devices []state.Device
+}
+
+func (d *staticDiscoverer) Name() string { return d.name }
+
+func (d *staticDiscoverer) Discover(ctx context.Context) []state.Device { return d.devices }
+
+func init() {
+	RegisterDiscoverer("test_inventory", func(cfg *config.Config, sweep *Sweep) Discoverer {
+		return &staticDiscoverer{name: "test_inventory", devices: []state.Device{
+			{IP: "10.0.0.1"},
+			{IP: "10.0.0.2"},
+			{IP: "10.0.0.3"}, // Excluded
+			{IP: "not-an-ip"},
+		}}
+	})
+	RegisterDiscoverer("test_names", func(cfg *config.Config, sweep *Sweep) Discoverer {
+		return &staticDiscoverer{name: "test_names", devices: []state.Device{
+			{IP: "10.0.0.2", Hostname: "switch-2"},
+			{IP: "10.0.0.4", Hostname: "printer-4"},
+		}}
+	})
+}
+
+// TestRunDiscoverers verifies registered methods run in order, devices are merged by IP and reported once,
+// and excluded or invalid addresses are dropped
+func TestRunDiscoverers(t *testing.T) {
+	cfg := &config.Config{DiscoveryMethods: []string{"test_inventory", "test_names"}, ExcludeIPs: []string{"10.0.0.3"}}
+	var reported []string
+	progress := NewProgress(nil)
+	devices := RunDiscoverers(context.Background(), cfg, &Sweep{
+		Progress: progress,
+		OnFound:  func(ip string) { reported = append(reported, ip) },
+	})
+
+	if len(devices) != 3 || devices[0].IP != "10.0.0.1" || devices[1].IP != "10.0.0.2" || devices[2].IP != "10.0.0.4" {
+		t.Fatalf("expected 10.0.0.1, 10.0.0.2 and 10.0.0.4, got %+v", devices)
+	}
+	if devices[1].Hostname != "switch-2" || devices[2].Hostname != "printer-4" {
+		t.Errorf("expected hostnames from the second method to be merged in, got %+v", devices)
+	}
+	if strings.Join(reported, ",") != "10.0.0.1,10.0.0.2,10.0.0.4" {
+		t.Errorf("expected each device reported once, got %v", reported)
+	}
+	if snap := progress.Snapshot(); snap.Phase != "test_names" || snap.Responsive != 3 {
+		t.Errorf("expected the last method's phase and 3 responsive, got %+v", snap)
+	}
+}
+
+// TestCheckDiscoverers validates built-in and registered methods are accepted and unknown ones rejected
+func TestCheckDiscoverers(t *testing.T) {
+	if err := CheckDiscoverers([]string{MethodSweep, MethodSNMP, "test_inventory"}); err != nil {
+		t.Errorf("expected registered methods to be accepted, got %v", err)
+	}
+	err := CheckDiscoverers([]string{MethodSweep, "mdns"})
+	if err == nil || !strings.Contains(err.Error(), `"mdns"`) || !strings.Contains(err.Error(), "sweep") {
+		t.Errorf("expected an error naming mdns and the registered methods, got %v", err)
+	}
+}
@@ -0,0 +1,19 @@
+package discovery
+
+import (
+	"github.com/kljama/netscan/internal/config"
+)
+
+// filterExcluded removes the hosts of excluded (exclude_networks / exclude_ips) from a sweep's target list
+func filterExcluded(ips []string, excluded *config.Exclusions) []string {
+	if excluded == nil {
+		return ips
+	}
+	kept := ips[:0]
+	for _, ip := range ips {
+		if !excluded.Contains(ip) {
+			kept = append(kept, ip)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,30 @@
+package discovery
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kljama/netscan/internal/config"
+)
+
+// TestFilterExcluded validates sweep targets drop excluded hosts and streaming expansion skips them
+func TestFilterExcluded(t *testing.T) {
+	excluded := config.NewExclusions([]string{"192.168.1.0/30"}, []string{"192.168.1.5"})
+
+	got := filterExcluded(ipsFromCIDR("192.168.1.0/29"), excluded)
+	want := []string{"192.168.1.4", "192.168.1.6"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("filterExcluded() = %v, want %v", got, want)
+	}
+
+	ch := make(chan string, 16)
+	streamIPsFromCIDR("192.168.1.0/29", excluded, ch)
+	close(ch)
+	var streamed []string
+	for ip := range ch {
+		streamed = append(streamed, ip)
+	}
+	if !reflect.DeepEqual(streamed, want) {
+		t.Errorf("streamIPsFromCIDR() = %v, want %v", streamed, want)
+	}
+}
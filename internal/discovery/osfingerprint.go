@@ -0,0 +1,36 @@
+package discovery
+
+import (
+	"context"
+
+	"github.com/kljama/netscan/internal/ratelimit"
+	probing "github.com/prometheus-community/pro-bing"
+	"golang.org/x/time/rate"
+)
+
+// ReplyTTL sends one ICMP echo request to ip and returns the TTL of the reply, 0 when there is none
+// OS fingerprinting infers the initial TTL the device's OS uses (64, 128 or 255) from it
+// privileged selects a raw or UDP ICMP socket (icmp_mode), sent from sourceIP ("" = any); the limiter and ip's partition of limits are consulted
+// once and a cancelled context returns 0
+func ReplyTTL(ctx context.Context, ip string, privileged bool, sourceIP string, limiter *rate.Limiter, limits *ratelimit.Partitions) int {
+	if err := waitForToken(ctx, limiter, limits, ip); err != nil {
+		return 0
+	}
+	pinger, err := probing.NewPinger(ip)
+	if err != nil {
+		return 0
+	}
+	pinger.Count = 1
+	pinger.Timeout = discoveryPingTimeout
+	pinger.RecordTTLs = true
+	pinger.Source = sourceIP
+	pinger.SetPrivileged(privileged)
+	if err := pinger.RunWithContext(ctx); err != nil {
+		return 0
+	}
+	ttls := pinger.Statistics().TTLs
+	if len(ttls) == 0 {
+		return 0
+	}
+	return int(ttls[0])
+}
@@ -0,0 +1,139 @@
+package discovery
+
+import (
+	"net"
+	"sort"
+
+	"github.com/kljama/netscan/internal/state"
+)
+
+// Fingerprint identifies a device as reported by a scanner in device_info
+// Two scanners reporting the same IP with identical sysName and sysDescr are polling the same device
+type Fingerprint struct {
+	Scanner  string // Instance ID from the "scanner" tag
+	IP       string
+	Hostname string
+	SysDescr string
+}
+
+// Overlap describes a configured network that another scanner is also covering
+type Overlap struct {
+	Network string   // Configured CIDR containing the matching devices
+	Scanner string   // Instance ID of the other scanner
+	IPs     []string // Devices reported identically by both scanners (sorted)
+}
+
+// Yields reports whether this instance should stop scanning the network
+// Only the instance with the lexicographically greater ID yields, so exactly one scanner keeps the range
+func (o Overlap) Yields(self string) bool {
+	return self > o.Scanner
+}
+
+// FindOverlaps matches local SNMP-enriched devices against other scanners' fingerprints
+// A network is reported once per scanner when at least minMatches of its devices match
+// Devices without SNMP data (hostname equal to IP, empty sysDescr) are ignored since they carry no fingerprint
+func FindOverlaps(networks []string, local []state.Device, remote []Fingerprint, minMatches int) []Overlap {
+	if minMatches < 1 {
+		minMatches = 1
+	}
+
+	localByIP := make(map[string]state.Device, len(local))
+	for _, dev := range local {
+		if dev.SysDescr == "" || dev.Hostname == "" || dev.Hostname == dev.IP {
+			continue
+		}
+		localByIP[dev.IP] = dev
+	}
+
+	var nets []*net.IPNet
+	for _, cidr := range networks {
+		if _, ipnet, err := net.ParseCIDR(cidr); err == nil {
+			nets = append(nets, ipnet)
+		} else {
+			nets = append(nets, nil) // Keep indexes aligned with networks
+		}
+	}
+
+	// Group matching IPs by (network, scanner)
+	type key struct{ network, scanner string }
+	matches := make(map[key]map[string]bool)
+	for _, fp := range remote {
+		dev, ok := localByIP[fp.IP]
+		if !ok || dev.Hostname != fp.Hostname || dev.SysDescr != fp.SysDescr {
+			continue
+		}
+		ip := net.ParseIP(fp.IP)
+		for i, ipnet := range nets {
+			if ipnet == nil || !ipnet.Contains(ip) {
+				continue
+			}
+			k := key{networks[i], fp.Scanner}
+			if matches[k] == nil {
+				matches[k] = make(map[string]bool)
+			}
+			matches[k][fp.IP] = true
+			break // Attribute each device to the first configured network containing it
+		}
+	}
+
+	var overlaps []Overlap
+	for k, ips := range matches {
+		if len(ips) < minMatches {
+			continue
+		}
+		o := Overlap{Network: k.network, Scanner: k.scanner, IPs: make([]string, 0, len(ips))}
+		for ip := range ips {
+			o.IPs = append(o.IPs, ip)
+		}
+		sort.Strings(o.IPs)
+		overlaps = append(overlaps, o)
+	}
+	sort.Slice(overlaps, func(i, j int) bool {
+		if overlaps[i].Network != overlaps[j].Network {
+			return overlaps[i].Network < overlaps[j].Network
+		}
+		return overlaps[i].Scanner < overlaps[j].Scanner
+	})
+	return overlaps
+}
+
+// CountInNetwork returns how many of scanner's fingerprints fall inside network
+// Used to decide whether a network disabled due to overlap can be scanned again
+func CountInNetwork(remote []Fingerprint, scanner, network string) int {
+	count := 0
+	for _, fp := range remote {
+		if fp.Scanner == scanner && InNetworks(fp.IP, []string{network}) {
+			count++
+		}
+	}
+	return count
+}
+
+// FilterNetworks returns the networks that are not disabled, preserving order
+// disabled maps a network to the scanner that now covers it
+func FilterNetworks(networks []string, disabled map[string]string) []string {
+	if len(disabled) == 0 {
+		return networks
+	}
+	active := make([]string, 0, len(networks))
+	for _, network := range networks {
+		if _, off := disabled[network]; !off {
+			active = append(active, network)
+		}
+	}
+	return active
+}
+
+// InNetworks reports whether ip falls inside any of the given CIDRs
+func InNetworks(ip string, networks []string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, cidr := range networks {
+		if _, ipnet, err := net.ParseCIDR(cidr); err == nil && ipnet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
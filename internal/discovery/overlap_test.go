@@ -0,0 +1,61 @@
+package discovery
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kljama/netscan/internal/state"
+)
+
+// TestFindOverlaps validates fingerprint matching, network attribution and the match threshold
+func TestFindOverlaps(t *testing.T) {
+	networks := []string{"10.0.1.0/24", "10.0.2.0/24"}
+	local := []state.Device{
+		{IP: "10.0.1.1", Hostname: "sw1", SysDescr: "Cisco IOS"},
+		{IP: "10.0.1.2", Hostname: "sw2", SysDescr: "Cisco IOS"},
+		{IP: "10.0.1.3", Hostname: "10.0.1.3"}, // No SNMP data, no fingerprint
+		{IP: "10.0.2.1", Hostname: "fw1", SysDescr: "Linux"},
+	}
+	remote := []Fingerprint{
+		{Scanner: "b", IP: "10.0.1.1", Hostname: "sw1", SysDescr: "Cisco IOS"},
+		{Scanner: "b", IP: "10.0.1.2", Hostname: "sw2", SysDescr: "Cisco IOS"},
+		{Scanner: "b", IP: "10.0.1.3", Hostname: "10.0.1.3"},
+		{Scanner: "b", IP: "10.0.2.1", Hostname: "other-fw", SysDescr: "Linux"}, // Same IP, different device
+		{Scanner: "c", IP: "10.0.1.1", Hostname: "sw1", SysDescr: "Cisco IOS"},
+	}
+
+	got := FindOverlaps(networks, local, remote, 2)
+	want := []Overlap{{Network: "10.0.1.0/24", Scanner: "b", IPs: []string{"10.0.1.1", "10.0.1.2"}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindOverlaps() = %+v, want %+v", got, want)
+	}
+
+	if got := FindOverlaps(networks, local, remote, 1); len(got) != 2 {
+		t.Errorf("expected scanners b and c to overlap with threshold 1, got %+v", got)
+	}
+}
+
+// TestOverlapYields validates that exactly one of two overlapping scanners yields
+func TestOverlapYields(t *testing.T) {
+	if !(Overlap{Scanner: "alpha"}).Yields("beta") {
+		t.Error("expected beta to yield to alpha")
+	}
+	if (Overlap{Scanner: "beta"}).Yields("alpha") {
+		t.Error("expected alpha to keep scanning when beta overlaps")
+	}
+}
+
+// TestFilterNetworks validates disabled networks are removed and order is preserved
+func TestFilterNetworks(t *testing.T) {
+	networks := []string{"10.0.1.0/24", "10.0.2.0/24", "10.0.3.0/24"}
+	got := FilterNetworks(networks, map[string]string{"10.0.2.0/24": "b"})
+	want := []string{"10.0.1.0/24", "10.0.3.0/24"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FilterNetworks() = %v, want %v", got, want)
+	}
+
+	remote := []Fingerprint{{Scanner: "b", IP: "10.0.2.5"}, {Scanner: "b", IP: "10.0.1.5"}, {Scanner: "c", IP: "10.0.2.6"}}
+	if n := CountInNetwork(remote, "b", "10.0.2.0/24"); n != 1 {
+		t.Errorf("CountInNetwork() = %d, want 1", n)
+	}
+}
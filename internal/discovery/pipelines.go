@@ -0,0 +1,139 @@
+package discovery
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/rs/zerolog/log"
+)
+
+// networkSweep probes the targets of source with workers and returns the responsive IPs,
+// reporting each one through onFound as it answers (icmpSweep and tcpSweep with their settings bound)
+type networkSweep func(ctx context.Context, source targetSource, workers int, onFound func(ip string)) []string
+
+// sweepNetworks runs one sweep pipeline per network concurrently, each with its own share of workers,
+// so a small network is not queued behind the addresses of a large one. maxPerNetwork caps every share
+// (0 = no cap). Pipelines hold their share of workers while they run and the shares of running pipelines never
+// add up to more than workers: with more networks than workers, a pipeline starts once an earlier one finishes
+// onFound calls are serialized across pipelines. Results are merged in network order
+// Hosts in excluded are never probed
+func sweepNetworks(ctx context.Context, networks []string, excluded *config.Exclusions, workers, maxPerNetwork int, onFound func(ip string), sweep networkSweep) []string {
+	if len(networks) <= 1 {
+		return sweep(ctx, fullSweepSource(networks, excluded), capWorkers(workers, maxPerNetwork), onFound)
+	}
+
+	var mu sync.Mutex
+	report := func(ip string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if onFound != nil {
+			onFound(ip)
+		}
+	}
+
+	shares := networkWorkers(networks, workers, maxPerNetwork)
+	slots := make(chan struct{}, max(workers, 1)) // One slot per worker of the running pipelines
+	results := make([][]string, len(networks))
+	var wg sync.WaitGroup
+	for i, network := range networks {
+		held := min(shares[i], cap(slots))
+		if !acquireSlots(ctx, slots, held) {
+			break // Cancelled: networks not started yet are skipped
+		}
+		wg.Add(1)
+		go func() {
+			// Panic recovery for per-network sweep goroutine
+			defer func() {
+				if r := recover(); r != nil {
+					log.Error().
+						Str("network", network).
+						Interface("panic", r).
+						Msg("Network sweep panic recovered")
+				}
+			}()
+			defer wg.Done()
+			defer releaseSlots(slots, held)
+
+			results[i] = sweep(ctx, fullSweepSource([]string{network}, excluded), shares[i], report)
+			log.Debug().
+				Str("network", network).
+				Int("workers", shares[i]).
+				Int("found", len(results[i])).
+				Msg("Network sweep completed")
+		}()
+	}
+	wg.Wait()
+	return mergeIPs(results...)
+}
+
+// acquireSlots takes n slots, waiting for running pipelines to release theirs; false if ctx is cancelled first
+// Slots are taken by one goroutine only, so a partially acquired share never blocks another pipeline
+func acquireSlots(ctx context.Context, slots chan struct{}, n int) bool {
+	for i := 0; i < n; i++ {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			releaseSlots(slots, i)
+			return false
+		}
+	}
+	return true
+}
+
+// releaseSlots returns n slots taken by acquireSlots
+func releaseSlots(slots chan struct{}, n int) {
+	for i := 0; i < n; i++ {
+		<-slots
+	}
+}
+
+// networkWorkers splits workers across networks: half of the pool is shared equally, so every network
+// makes progress at the same time, and the other half in proportion to the network's size
+// Every network gets at least one worker and never more than it has addresses or maxPerNetwork (when set),
+// so with more networks than workers the shares add up to more than workers (sweepNetworks bounds the total)
+func networkWorkers(networks []string, workers, maxPerNetwork int) []int {
+	sizes := make([]uint64, len(networks))
+	var total uint64
+	for i, network := range networks {
+		sizes[i] = networkSize(network)
+		total += sizes[i]
+	}
+
+	equal := workers / 2 / len(networks)
+	proportional := workers - equal*len(networks)
+	shares := make([]int, len(networks))
+	for i, size := range sizes {
+		share := equal
+		if total > 0 {
+			share += int(uint64(proportional) * size / total)
+		}
+		if size > 0 && uint64(share) > size {
+			share = int(size)
+		}
+		shares[i] = capWorkers(max(share, 1), maxPerNetwork)
+	}
+	return shares
+}
+
+// capWorkers limits workers to maxPerNetwork when set
+func capWorkers(workers, maxPerNetwork int) int {
+	if maxPerNetwork > 0 {
+		return min(workers, maxPerNetwork)
+	}
+	return workers
+}
+
+// networkSize returns the number of addresses a full sweep probes in cidr (0 for networks ipsFromCIDR does not expand)
+func networkSize(cidr string) uint64 {
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return 0
+	}
+	ones, bits := ipnet.Mask.Size()
+	if bits-ones > 16 {
+		return 0
+	}
+	return uint64(1) << uint(bits-ones)
+}
@@ -0,0 +1,115 @@
+package discovery
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestNetworkWorkers verifies small networks get an equal share next to large ones, capped by size and maxPerNetwork
+func TestNetworkWorkers(t *testing.T) {
+	tests := []struct {
+		name     string
+		networks []string
+		workers  int
+		max      int
+		want     []int
+	}{
+		{name: "small next to large", networks: []string{"10.0.0.0/16", "10.1.0.0/24"}, workers: 64, want: []int{47, 16}},
+		{name: "equal networks", networks: []string{"10.0.0.0/24", "10.1.0.0/24"}, workers: 64, want: []int{32, 32}},
+		{name: "capped by size", networks: []string{"10.0.0.0/24", "10.1.0.1/32"}, workers: 64, want: []int{47, 1}},
+		{name: "capped by maxPerNetwork", networks: []string{"10.0.0.0/16", "10.1.0.0/24"}, workers: 64, max: 20, want: []int{20, 16}},
+		{name: "fewer workers than networks", networks: []string{"10.0.0.0/24", "10.1.0.0/24", "10.2.0.0/24"}, workers: 2, want: []int{1, 1, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := networkWorkers(tt.networks, tt.workers, tt.max); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected shares %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestSweepNetworks verifies every network runs its own pipeline over its own addresses and results are merged
+func TestSweepNetworks(t *testing.T) {
+	networks := []string{"10.0.0.0/30", "10.1.0.0/30"}
+	var (
+		mu      sync.Mutex
+		probed  = make(map[int][]string)
+		started = make(chan struct{}, len(networks))
+		release = make(chan struct{})
+	)
+	sweep := func(ctx context.Context, source targetSource, workers int, onFound func(ip string)) []string {
+		jobs := make(chan string, 16)
+		source(ctx, jobs, nil)
+		close(jobs)
+		var ips []string
+		for ip := range jobs {
+			ips = append(ips, ip)
+		}
+		sort.Strings(ips)
+
+		// Both pipelines must be running at the same time
+		started <- struct{}{}
+		<-release
+		mu.Lock()
+		probed[workers] = append(probed[workers], ips...)
+		mu.Unlock()
+		onFound(ips[0])
+		return ips[:1]
+	}
+	go func() {
+		for range networks {
+			<-started
+		}
+		close(release)
+	}()
+
+	var found []string
+	ips := sweepNetworks(context.Background(), networks, nil, 8, 0, func(ip string) { found = append(found, ip) }, sweep)
+
+	if want := []string{"10.0.0.1", "10.1.0.1"}; !reflect.DeepEqual(ips, want) {
+		t.Errorf("expected merged results %v in network order, got %v", want, ips)
+	}
+	sort.Strings(found)
+	if !reflect.DeepEqual(found, []string{"10.0.0.1", "10.1.0.1"}) {
+		t.Errorf("expected both results reported, got %v", found)
+	}
+	if got := probed[4]; len(got) != 4 {
+		t.Errorf("expected two pipelines of 4 workers probing 2 addresses each, got %v", probed)
+	}
+}
+
+// TestSweepNetworksBoundsWorkers verifies pipelines never run more workers at once than the pool has,
+// even when there are more networks than workers, and that every network is still swept
+func TestSweepNetworksBoundsWorkers(t *testing.T) {
+	networks := []string{"10.0.0.0/30", "10.1.0.0/30", "10.2.0.0/30", "10.3.0.0/30", "10.4.0.0/30", "10.5.0.0/30"}
+	var running, peak atomic.Int64
+	sweep := func(ctx context.Context, source targetSource, workers int, onFound func(ip string)) []string {
+		now := running.Add(int64(workers))
+		for {
+			if seen := peak.Load(); now <= seen || peak.CompareAndSwap(seen, now) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-int64(workers))
+
+		jobs := make(chan string, 16)
+		source(ctx, jobs, nil)
+		close(jobs)
+		return []string{<-jobs}
+	}
+
+	ips := sweepNetworks(context.Background(), networks, nil, 3, 0, nil, sweep)
+	if len(ips) != len(networks) {
+		t.Errorf("expected every network swept, got %v", ips)
+	}
+	if got := peak.Load(); got > 3 {
+		t.Errorf("expected at most 3 workers running at once, got %d", got)
+	}
+}
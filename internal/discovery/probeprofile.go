@@ -0,0 +1,31 @@
+package discovery
+
+import (
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	probing "github.com/prometheus-community/pro-bing"
+)
+
+// discoveryPingTimeout is how long the last echo request of a discovery probe is awaited
+const discoveryPingTimeout = 1 * time.Second
+
+// newDiscoveryPinger creates a pinger for one ICMP discovery probe of ip shaped by profile
+// (probe_profiles.discovery; the zero profile sends one packet) over a raw (privileged) or UDP ICMP socket,
+// sent from sourceIP ("" = chosen by the routing table)
+// The host counts as alive if any of the profile's echo requests is answered
+func newDiscoveryPinger(ip string, profile config.ProbeProfile, privileged bool, sourceIP string) (*probing.Pinger, error) {
+	pinger, err := probing.NewPinger(ip)
+	if err != nil {
+		return nil, err
+	}
+	count := max(profile.Count, 1)
+	pinger.Count = count
+	pinger.Interval = profile.PacketInterval()
+	pinger.Size = profile.PacketSize()
+	pinger.SetTrafficClass(profile.TOS())
+	pinger.Timeout = discoveryPingTimeout + profile.Spread(count) // Last packet gets the full timeout
+	pinger.Source = sourceIP                                      // source_ip/source_interface ("" = any)
+	pinger.SetPrivileged(privileged)                              // Raw or UDP ICMP socket (icmp_mode)
+	return pinger, nil
+}
@@ -0,0 +1,133 @@
+package discovery
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Sweep phases reported by Progress; other discovery methods report their discovery_methods name
+const (
+	PhaseICMP = "icmp"
+	PhaseTCP  = "tcp"
+	PhaseARP  = "arp"
+)
+
+// Progress tracks a running discovery sweep; all methods are safe for concurrent use and on a nil *Progress
+// Targets are counted as sources queue them, so in "both" mode the TCP pass adds its targets when it starts
+type Progress struct {
+	networks []string
+	started  time.Time
+
+	phase      atomic.Pointer[string]
+	queued     atomic.Uint64 // Targets queued for probing
+	probed     atomic.Uint64 // Targets probed (answered or not)
+	responsive atomic.Uint64 // Distinct responsive IPs
+	finished   atomic.Pointer[time.Time]
+	cancelled  atomic.Bool
+}
+
+// ProgressSnapshot is a point-in-time copy of a sweep's progress
+type ProgressSnapshot struct {
+	Networks   []string   `json:"networks"`
+	Phase      string     `json:"phase"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Cancelled  bool       `json:"cancelled"`
+	Queued     uint64     `json:"queued"`
+	Probed     uint64     `json:"probed"`
+	Responsive uint64     `json:"responsive"`
+	ElapsedS   float64    `json:"elapsed_s"`
+	ETAS       *float64   `json:"eta_s,omitempty"` // Remaining seconds at the current probe rate (omitted until a target was probed)
+}
+
+// NewProgress starts tracking a sweep over networks
+func NewProgress(networks []string) *Progress {
+	return &Progress{networks: networks, started: time.Now()}
+}
+
+// Finish marks the sweep as ended; cancelled records that it was stopped before probing every target
+func (p *Progress) Finish(cancelled bool) {
+	if p == nil {
+		return
+	}
+	now := time.Now()
+	p.cancelled.Store(cancelled)
+	p.finished.Store(&now)
+}
+
+// Snapshot returns the current progress with an ETA extrapolated from the probe rate so far
+func (p *Progress) Snapshot() ProgressSnapshot {
+	if p == nil {
+		return ProgressSnapshot{}
+	}
+	snap := ProgressSnapshot{
+		Networks:   p.networks,
+		StartedAt:  p.started,
+		Cancelled:  p.cancelled.Load(),
+		Queued:     p.queued.Load(),
+		Probed:     p.probed.Load(),
+		Responsive: p.responsive.Load(),
+	}
+	if phase := p.phase.Load(); phase != nil {
+		snap.Phase = *phase
+	}
+
+	end := time.Now()
+	if finished := p.finished.Load(); finished != nil {
+		end = *finished
+		snap.FinishedAt = finished
+	}
+	elapsed := end.Sub(p.started).Seconds()
+	snap.ElapsedS = elapsed
+
+	if snap.FinishedAt == nil && snap.Probed > 0 {
+		remaining := 0.0
+		if snap.Queued > snap.Probed {
+			remaining = elapsed / float64(snap.Probed) * float64(snap.Queued-snap.Probed)
+		}
+		snap.ETAS = &remaining
+	}
+	return snap
+}
+
+// setPhase records which sweep is running
+func (p *Progress) setPhase(phase string) {
+	if p != nil {
+		p.phase.Store(&phase)
+	}
+}
+
+// addQueued counts n targets queued by a source
+func (p *Progress) addQueued(n uint64) {
+	if p != nil {
+		p.queued.Add(n)
+	}
+}
+
+// dropQueued uncounts one target queued ahead of time that the source skipped
+func (p *Progress) dropQueued() {
+	if p != nil {
+		p.queued.Add(^uint64(0))
+	}
+}
+
+// addProbed counts one probed target
+func (p *Progress) addProbed() {
+	if p != nil {
+		p.probed.Add(1)
+	}
+}
+
+// counting wraps onFound so every reported IP is counted as responsive
+// Used inside reportOnce, so each IP is counted once per sweep
+func (p *Progress) counting(onFound func(ip string)) func(ip string) {
+	if p == nil {
+		return onFound
+	}
+	return func(ip string) {
+		p.responsive.Add(1)
+		if onFound != nil {
+			onFound(ip)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package discovery
+
+import (
+	"context"
+
+	"github.com/kljama/netscan/internal/ratelimit"
+	"golang.org/x/time/rate"
+)
+
+// waitForToken takes a token from ip's network partition (limits, nil = none), then from the global limiter (may be nil)
+// Returns an error only if ctx is cancelled while waiting
+func waitForToken(ctx context.Context, limiter *rate.Limiter, limits *ratelimit.Partitions, ip string) error {
+	if err := limits.Wait(ctx, ip); err != nil {
+		return err
+	}
+	if limiter != nil {
+		return limiter.Wait(ctx)
+	}
+	return nil
+}
@@ -2,17 +2,15 @@ package discovery
 
 import (
 	"context"
-	"fmt"
-	"math/rand"
 	"net"
-	"strings"
 	"sync"
 	"time"
 
 	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/ratelimit"
+	"github.com/kljama/netscan/internal/snmp"
 	"github.com/kljama/netscan/internal/state"
-	"github.com/gosnmp/gosnmp"
-	probing "github.com/prometheus-community/pro-bing"
+	"github.com/kljama/netscan/internal/validate"
 	"github.com/rs/zerolog/log"
 	"golang.org/x/time/rate"
 )
@@ -22,11 +20,22 @@ func RunScanIPsOnly(cidr string) []string {
 	return ipsFromCIDR(cidr)
 }
 
-// RunICMPSweep performs concurrent ICMP ping sweep across multiple networks
+// RunICMPSweep performs concurrent ICMP ping sweep across multiple networks, one pipeline per network
 // Returns only the IP addresses that responded to pings
-// The limiter parameter controls the global rate of ping operations
+// The limiter parameter controls the global rate of ping operations; limits (network_rate_limits, nil = none) apply on top
 // The ctx parameter enables graceful shutdown and rate limiter cancellation
-func RunICMPSweep(ctx context.Context, networks []string, workers int, limiter *rate.Limiter) []string {
+// onFound (optional) is called with each responsive IP as soon as it answers; calls are serialized
+// Hosts in excluded (exclude_networks / exclude_ips, nil = none) are never pinged; probes use the default profile
+// over raw ICMP sockets from the address chosen by the routing table
+func RunICMPSweep(ctx context.Context, networks []string, excluded *config.Exclusions, workers int, limiter *rate.Limiter, limits *ratelimit.Partitions, onFound func(ip string)) []string {
+	return sweepNetworks(ctx, networks, excluded, workers, 0, onFound, func(ctx context.Context, source targetSource, workers int, onFound func(ip string)) []string {
+		return icmpSweep(ctx, source, config.ProbeProfile{}, true, "", workers, limiter, limits, nil, onFound)
+	})
+}
+
+// icmpSweep pings every target produced by source with a pool of workers, each probe shaped by profile
+// and sent over a raw (privileged) or UDP ICMP socket from sourceIP ("" = any)
+func icmpSweep(ctx context.Context, source targetSource, profile config.ProbeProfile, privileged bool, sourceIP string, workers int, limiter *rate.Limiter, limits *ratelimit.Partitions, progress *Progress, onFound func(ip string)) []string {
 	if workers <= 0 {
 		workers = 64 // Default
 	}
@@ -50,30 +59,28 @@ func RunICMPSweep(ctx context.Context, networks []string, workers int, limiter *
 
 		defer wg.Done()
 		for ip := range jobs {
-			// Acquire token from rate limiter before pinging
-			// This ensures discovery scans respect the global ping rate limit
-			if limiter != nil {
-				if err := limiter.Wait(ctx); err != nil {
-					// Context was cancelled while waiting for token
-					log.Debug().
-						Str("ip", ip).
-						Msg("ICMP discovery cancelled while waiting for rate limit token")
-					return
-				}
+			// Acquire tokens from the network partition and the global rate limiter before pinging
+			// This ensures discovery scans respect the per-network and global ping rate limits
+			if err := waitForToken(ctx, limiter, limits, ip); err != nil {
+				// Context was cancelled while waiting for token
+				log.Debug().
+					Str("ip", ip).
+					Msg("ICMP discovery cancelled while waiting for rate limit token")
+				return
 			}
 
-			pinger, err := probing.NewPinger(ip)
+			pinger, err := newDiscoveryPinger(ip, profile, privileged, sourceIP)
 			if err != nil {
 				log.Debug().
 					Str("ip", ip).
 					Err(err).
 					Msg("Failed to create pinger")
+				progress.addProbed()
 				continue // Skip invalid IP addresses
 			}
-			pinger.Count = 1                 // Single ping per device
-			pinger.Timeout = 1 * time.Second // 1-second discovery timeout
-			pinger.SetPrivileged(true)       // Use raw sockets for ICMP
-			if err := pinger.Run(); err != nil {
+			err = pinger.Run()
+			progress.addProbed()
+			if err != nil {
 				log.Debug().
 					Str("ip", ip).
 					Err(err).
@@ -93,7 +100,7 @@ func RunICMPSweep(ctx context.Context, networks []string, workers int, limiter *
 		go worker()
 	}
 
-	// Producer: collect all IPs, shuffle them, then enqueue in randomized order
+	// Producer: enqueue targets from the source (randomized order)
 	go func() {
 		// Panic recovery for producer goroutine
 		defer func() {
@@ -103,25 +110,9 @@ func RunICMPSweep(ctx context.Context, networks []string, workers int, limiter *
 					Msg("ICMP producer panic recovered")
 			}
 		}()
+		defer close(jobs)
 
-		// Step 1: Buffer all IPs from all networks into a master list
-		var allIPs []string
-		for _, network := range networks {
-			ips := ipsFromCIDR(network)
-			allIPs = append(allIPs, ips...)
-		}
-
-		// Step 2: Shuffle the master list to randomize scan order
-		// This obscures the sequential scanning pattern across all subnets
-		rand.Shuffle(len(allIPs), func(i, j int) {
-			allIPs[i], allIPs[j] = allIPs[j], allIPs[i]
-		})
-
-		// Step 3: Feed shuffled IPs to jobs channel
-		for _, ip := range allIPs {
-			jobs <- ip
-		}
-		close(jobs)
+		source(ctx, jobs, progress)
 	}()
 
 	// Wait for all workers to complete, then close results channel
@@ -139,76 +130,22 @@ func RunICMPSweep(ctx context.Context, networks []string, workers int, limiter *
 		close(results)
 	}()
 
-	// Collect all responsive IPs
+	// Collect all responsive IPs, reporting each one as it arrives
 	var responsiveIPs []string
 	for ip := range results {
 		responsiveIPs = append(responsiveIPs, ip)
-	}
-	return responsiveIPs
-}
-
-// snmpGetWithFallback attempts to get SNMP OIDs using Get, falling back to GetNext if Get fails with NoSuchInstance
-func snmpGetWithFallback(params *gosnmp.GoSNMP, oids []string) (*gosnmp.SnmpPacket, error) {
-	// Try Get first (most efficient for .0 instances)
-	resp, err := params.Get(oids)
-	if err == nil {
-		// Check if we got valid responses (no NoSuchInstance errors)
-		hasValidData := false
-		for _, variable := range resp.Variables {
-			if variable.Type != gosnmp.NoSuchInstance && variable.Type != gosnmp.NoSuchObject {
-				hasValidData = true
-				break
-			}
-		}
-		if hasValidData {
-			return resp, nil
-		}
-		// All variables returned NoSuchInstance/NoSuchObject, try GetNext
-		log.Debug().
-			Str("target", params.Target).
-			Msg("Get returned NoSuchInstance, trying GetNext fallback")
-	}
-
-	// Fallback to GetNext for each OID (works when .0 instance doesn't exist)
-	// This queries the next OID in the tree, which often returns the value we want
-	baseOIDs := make([]string, len(oids))
-	for i, oid := range oids {
-		// Remove the .0 suffix if present to get base OID
-		if strings.HasSuffix(oid, ".0") {
-			baseOIDs[i] = oid[:len(oid)-2]
-		} else {
-			baseOIDs[i] = oid
-		}
-	}
-
-	variables := make([]gosnmp.SnmpPDU, 0, len(baseOIDs))
-	for _, baseOID := range baseOIDs {
-		resp, err := params.GetNext([]string{baseOID})
-		if err != nil {
-			continue
-		}
-		if len(resp.Variables) > 0 {
-			// Verify the returned OID is under the requested base OID
-			returnedOID := resp.Variables[0].Name
-			if strings.HasPrefix(returnedOID, baseOID) {
-				variables = append(variables, resp.Variables[0])
-			}
+		if onFound != nil {
+			onFound(ip)
 		}
 	}
-
-	if len(variables) == 0 {
-		return nil, fmt.Errorf("no valid SNMP data retrieved")
-	}
-
-	// Construct a response packet with the collected variables
-	return &gosnmp.SnmpPacket{
-		Variables: variables,
-	}, nil
+	return responsiveIPs
 }
 
 // RunSNMPScan performs concurrent SNMP queries on a list of IP addresses
 // Returns devices with SNMP data populated, gracefully handles SNMP failures
-func RunSNMPScan(ips []string, snmpConfig *config.SNMPConfig, workers int) []state.Device {
+// Queries are sent from sourceIP ("" = chosen by the routing table)
+// Cancelling ctx aborts the queries in flight and skips the remaining addresses
+func RunSNMPScan(ctx context.Context, ips []string, snmpConfig *config.SNMPConfig, workers int, sourceIP string) []state.Device {
 	if workers <= 0 {
 		workers = 32 // Default
 	}
@@ -232,16 +169,12 @@ func RunSNMPScan(ips []string, snmpConfig *config.SNMPConfig, workers int) []sta
 
 		defer wg.Done()
 		for ip := range jobs {
+			if ctx.Err() != nil {
+				continue // Cancelled: drain the remaining jobs without querying them
+			}
 			// Configure SNMP connection parameters
-			params := &gosnmp.GoSNMP{
-				Target:    ip,
-				Port:      uint16(snmpConfig.Port),
-				Community: snmpConfig.Community,
-				Version:   gosnmp.Version2c,
-				Timeout:   snmpConfig.Timeout,
-				Retries:   snmpConfig.Retries,
-			}
-			if err := params.Connect(); err != nil {
+			client, err := snmp.Dial(ctx, ip, snmp.NewOptions(snmpConfig, snmpLocalAddr(sourceIP)))
+			if err != nil {
 				// SNMP failed, skip this device
 				log.Debug().
 					Str("ip", ip).
@@ -250,10 +183,16 @@ func RunSNMPScan(ips []string, snmpConfig *config.SNMPConfig, workers int) []sta
 				continue
 			}
 			// Query standard MIB-II system OIDs: sysName, sysDescr
-			oids := []string{"1.3.6.1.2.1.1.5.0", "1.3.6.1.2.1.1.1.0"}
-			resp, err := snmpGetWithFallback(params, oids)
-			params.Conn.Close()
-			if err != nil || len(resp.Variables) < 2 {
+			variables, err := client.GetWithFallback(ctx, []string{snmp.OIDSysName, snmp.OIDSysDescr})
+			var system state.SystemInfo
+			polledAt := time.Now()
+			systemOK := false
+			if err == nil {
+				// sysObjectID, sysUpTime, sysContact, sysLocation (best effort)
+				system, systemOK = snmp.QuerySystemInfo(ctx, client, snmpConfig.MaxStringLength)
+			}
+			client.Close()
+			if err != nil || len(variables) < 2 {
 				// SNMP query failed, skip this device
 				log.Debug().
 					Str("ip", ip).
@@ -263,7 +202,7 @@ func RunSNMPScan(ips []string, snmpConfig *config.SNMPConfig, workers int) []sta
 			}
 
 			// Validate and sanitize SNMP response data
-			hostname, err := validateSNMPString(resp.Variables[0].Value, "sysName")
+			hostname, err := validate.SNMPString(variables[0].Value, "sysName", snmpConfig.MaxStringLength)
 			if err != nil {
 				log.Debug().
 					Str("ip", ip).
@@ -271,7 +210,7 @@ func RunSNMPScan(ips []string, snmpConfig *config.SNMPConfig, workers int) []sta
 					Msg("Invalid sysName")
 				continue
 			}
-			sysDescr, err := validateSNMPString(resp.Variables[1].Value, "sysDescr")
+			sysDescr, err := validate.SNMPString(variables[1].Value, "sysDescr", snmpConfig.MaxStringLength)
 			if err != nil {
 				log.Debug().
 					Str("ip", ip).
@@ -286,6 +225,10 @@ func RunSNMPScan(ips []string, snmpConfig *config.SNMPConfig, workers int) []sta
 				SysDescr: sysDescr,
 				LastSeen: time.Now(),
 			}
+			if systemOK {
+				dev.System = system
+				dev.SystemPolledAt = polledAt
+			}
 			results <- dev
 		}
 	}
@@ -307,10 +250,14 @@ func RunSNMPScan(ips []string, snmpConfig *config.SNMPConfig, workers int) []sta
 			}
 		}()
 
+		defer close(jobs)
 		for _, ip := range ips {
-			jobs <- ip
+			select {
+			case jobs <- ip:
+			case <-ctx.Done():
+				return
+			}
 		}
-		close(jobs)
 	}()
 
 	// Wait for all workers to complete, then close results channel
@@ -336,8 +283,9 @@ func RunSNMPScan(ips []string, snmpConfig *config.SNMPConfig, workers int) []sta
 	return devices
 }
 
-// RunScan performs concurrent SNMPv2c discovery across configured networks
-func RunScan(cfg *config.Config) []state.Device {
+// RunScan performs concurrent SNMPv2c discovery across configured networks until ctx is cancelled
+// Queries leave from the address chosen by the routing table
+func RunScan(ctx context.Context, cfg *config.Config) []state.Device {
 	var (
 		jobs    = make(chan string, 256)       // Buffered channel for IP addresses to scan
 		results = make(chan state.Device, 256) // Buffered channel for discovered devices
@@ -357,32 +305,27 @@ func RunScan(cfg *config.Config) []state.Device {
 
 		defer wg.Done()
 		for ip := range jobs {
+			if ctx.Err() != nil {
+				continue // Cancelled: drain the remaining jobs without querying them
+			}
 			// Configure SNMP connection parameters
-			params := &gosnmp.GoSNMP{
-				Target:    ip,
-				Port:      uint16(cfg.SNMP.Port),
-				Community: cfg.SNMP.Community,
-				Version:   gosnmp.Version2c,
-				Timeout:   cfg.SNMP.Timeout,
-				Retries:   cfg.SNMP.Retries,
-			}
-			if err := params.Connect(); err != nil {
+			client, err := snmp.Dial(ctx, ip, snmp.NewOptions(&cfg.SNMP, ""))
+			if err != nil {
 				continue // Skip unresponsive devices
 			}
 			// Query standard MIB-II system OIDs: sysName, sysDescr
-			oids := []string{"1.3.6.1.2.1.1.5.0", "1.3.6.1.2.1.1.1.0"}
-			resp, err := snmpGetWithFallback(params, oids)
-			params.Conn.Close()
-			if err != nil || len(resp.Variables) < 2 {
+			variables, err := client.GetWithFallback(ctx, []string{snmp.OIDSysName, snmp.OIDSysDescr})
+			client.Close()
+			if err != nil || len(variables) < 2 {
 				continue // Skip devices with incomplete SNMP responses
 			}
 
 			// Validate and sanitize SNMP response data
-			hostname, err := validateSNMPString(resp.Variables[0].Value, "sysName")
+			hostname, err := validate.SNMPString(variables[0].Value, "sysName", cfg.SNMP.MaxStringLength)
 			if err != nil {
 				continue // Skip devices with invalid hostname data
 			}
-			sysDescr, err := validateSNMPString(resp.Variables[1].Value, "sysDescr")
+			sysDescr, err := validate.SNMPString(variables[1].Value, "sysDescr", cfg.SNMP.MaxStringLength)
 			if err != nil {
 				continue // Skip devices with invalid description data
 			}
@@ -405,9 +348,10 @@ func RunScan(cfg *config.Config) []state.Device {
 	}
 
 	// Producer: enqueue all IPs from configured CIDR ranges
+	excluded := cfg.Exclusions()
 	for _, cidr := range cfg.Networks {
 		// Stream IPs directly to jobs channel without intermediate array
-		streamIPsFromCIDR(cidr, jobs)
+		streamIPsFromCIDR(cidr, excluded, jobs)
 	}
 	close(jobs)
 
@@ -434,7 +378,8 @@ func RunScan(cfg *config.Config) []state.Device {
 	return found
 }
 
-// RunPingDiscovery performs concurrent ICMP ping sweep to find online devices
+// RunPingDiscovery performs concurrent ICMP ping sweep to find online devices over raw ICMP sockets
+// from the address chosen by the routing table
 func RunPingDiscovery(cidr string, icmpWorkers int) []state.Device {
 	// Calculate buffer size based on network size, capped at reasonable limit
 	_, ipnet, err := net.ParseCIDR(cidr)
@@ -472,13 +417,10 @@ func RunPingDiscovery(cidr string, icmpWorkers int) []state.Device {
 
 		defer wg.Done()
 		for ip := range jobs {
-			pinger, err := probing.NewPinger(ip)
+			pinger, err := newDiscoveryPinger(ip, config.ProbeProfile{}, true, "")
 			if err != nil {
 				continue // Skip invalid IP addresses
 			}
-			pinger.Count = 1                 // Single ping per device
-			pinger.Timeout = 1 * time.Second // 1-second discovery timeout
-			pinger.SetPrivileged(true)       // Use raw sockets for ICMP
 			if err := pinger.Run(); err != nil {
 				continue // Skip ping failures
 			}
@@ -501,7 +443,7 @@ func RunPingDiscovery(cidr string, icmpWorkers int) []state.Device {
 	}
 
 	// Producer: enqueue all IPs from CIDR range
-	streamIPsFromCIDR(cidr, jobs)
+	streamIPsFromCIDR(cidr, nil, jobs)
 	close(jobs)
 
 	// Wait for all workers to complete, then close results channel
@@ -527,8 +469,9 @@ func RunPingDiscovery(cidr string, icmpWorkers int) []state.Device {
 	return devices
 }
 
-// RunFullDiscovery performs ICMP ping sweep first, then SNMP polling of online devices
-func RunFullDiscovery(cfg *config.Config) []state.Device {
+// RunFullDiscovery performs ICMP ping sweep first, then SNMP polling of online devices, until ctx is cancelled
+// Pings use raw ICMP sockets; probes leave from the address chosen by the routing table
+func RunFullDiscovery(ctx context.Context, cfg *config.Config) []state.Device {
 	var (
 		jobs    = make(chan string, 256)       // Buffered channel for IP addresses to scan
 		results = make(chan state.Device, 256) // Buffered channel for discovered devices
@@ -548,16 +491,15 @@ func RunFullDiscovery(cfg *config.Config) []state.Device {
 
 		defer wg.Done()
 		for ip := range jobs {
+			if ctx.Err() != nil {
+				continue // Cancelled: drain the remaining jobs without querying them
+			}
 			// Configure SNMP connection parameters
-			params := &gosnmp.GoSNMP{
-				Target:    ip,
-				Port:      uint16(cfg.SNMP.Port),
-				Community: cfg.SNMP.Community,
-				Version:   gosnmp.Version2c,
-				Timeout:   cfg.SNMP.Timeout,
-				Retries:   cfg.SNMP.Retries,
-			}
-			if err := params.Connect(); err != nil {
+			client, err := snmp.Dial(ctx, ip, snmp.NewOptions(&cfg.SNMP, ""))
+			if err != nil {
+				if ctx.Err() != nil {
+					continue
+				}
 				// SNMP failed, but device is online (from ICMP), so add basic device info
 				results <- state.Device{
 					IP:       ip,
@@ -568,10 +510,12 @@ func RunFullDiscovery(cfg *config.Config) []state.Device {
 				continue
 			}
 			// Query standard MIB-II system OIDs: sysName, sysDescr
-			oids := []string{"1.3.6.1.2.1.1.5.0", "1.3.6.1.2.1.1.1.0"}
-			resp, err := snmpGetWithFallback(params, oids)
-			params.Conn.Close()
-			if err != nil || len(resp.Variables) < 2 {
+			variables, err := client.GetWithFallback(ctx, []string{snmp.OIDSysName, snmp.OIDSysDescr})
+			client.Close()
+			if err != nil || len(variables) < 2 {
+				if ctx.Err() != nil {
+					continue
+				}
 				// SNMP query failed, but device is online
 				results <- state.Device{
 					IP:       ip,
@@ -583,11 +527,11 @@ func RunFullDiscovery(cfg *config.Config) []state.Device {
 			}
 
 			// Validate and sanitize SNMP response data
-			hostname, err := validateSNMPString(resp.Variables[0].Value, "sysName")
+			hostname, err := validate.SNMPString(variables[0].Value, "sysName", cfg.SNMP.MaxStringLength)
 			if err != nil {
 				continue // Skip devices with invalid hostname data
 			}
-			sysDescr, err := validateSNMPString(resp.Variables[1].Value, "sysDescr")
+			sysDescr, err := validate.SNMPString(variables[1].Value, "sysDescr", cfg.SNMP.MaxStringLength)
 			if err != nil {
 				continue // Skip devices with invalid description data
 			}
@@ -623,13 +567,13 @@ func RunFullDiscovery(cfg *config.Config) []state.Device {
 
 		defer icmpWg.Done()
 		for ip := range icmpJobs {
-			pinger, err := probing.NewPinger(ip)
+			if ctx.Err() != nil {
+				continue // Cancelled: drain the remaining jobs without pinging them
+			}
+			pinger, err := newDiscoveryPinger(ip, cfg.ProbeProfiles.Discovery, true, "")
 			if err != nil {
 				continue
 			}
-			pinger.Count = 1
-			pinger.Timeout = 1 * time.Second
-			pinger.SetPrivileged(true)
 			if err := pinger.Run(); err != nil {
 				continue
 			}
@@ -648,9 +592,10 @@ func RunFullDiscovery(cfg *config.Config) []state.Device {
 	}
 
 	// Producer: enqueue all IPs from all configured networks
+	excluded := cfg.Exclusions()
 	for _, network := range cfg.Networks {
 		// Stream IPs directly to channel without intermediate array
-		streamIPsFromCIDR(network, icmpJobs)
+		streamIPsFromCIDR(network, excluded, icmpJobs)
 	}
 	close(icmpJobs)
 
@@ -717,8 +662,8 @@ func RunFullDiscovery(cfg *config.Config) []state.Device {
 
 // streamIPsFromCIDR streams IP addresses from CIDR notation directly to a channel
 // This avoids allocating memory for all IPs at once, significantly reducing memory usage
-// Network and broadcast addresses are excluded for networks /30 and larger
-func streamIPsFromCIDR(cidr string, ipChan chan<- string) {
+// Network and broadcast addresses are excluded for networks /30 and larger, as are hosts in excluded
+func streamIPsFromCIDR(cidr string, excluded *config.Exclusions, ipChan chan<- string) {
 	ip, ipnet, err := net.ParseCIDR(cidr)
 	if err != nil {
 		log.Error().
@@ -773,7 +718,10 @@ func streamIPsFromCIDR(cidr string, ipChan chan<- string) {
 			}
 		}
 		
-		ipChan <- ip.String()
+		// Never probe exclude_networks / exclude_ips
+		if ipStr := ip.String(); !excluded.Contains(ipStr) {
+			ipChan <- ipStr
+		}
 		count++
 		incIP(ip)
 	}
@@ -850,52 +798,3 @@ func incIP(ip net.IP) {
 		}
 	}
 }
-
-// validateSNMPString validates and sanitizes SNMP response string values
-func validateSNMPString(value interface{}, oidName string) (string, error) {
-	var str string
-	
-	// Handle different types that SNMP can return for string values
-	switch v := value.(type) {
-	case string:
-		str = v
-	case []byte:
-		// SNMP OctetString values are often returned as byte arrays
-		// Note: []byte and []uint8 are the same type in Go
-		str = string(v)
-	default:
-		return "", fmt.Errorf("invalid type for %s: expected string or []byte, got %T", oidName, value)
-	}
-
-	// Check for null bytes and other control characters that could be dangerous
-	if strings.ContainsRune(str, '\x00') {
-		return "", fmt.Errorf("invalid %s: contains null bytes", oidName)
-	}
-
-	// Limit string length to prevent memory exhaustion
-	if len(str) > 1024 {
-		str = str[:1024] // Truncate to reasonable length
-	}
-
-	// Basic sanitization - remove or replace potentially dangerous characters
-	// Allow printable ASCII and some common extended characters
-	sanitized := strings.Map(func(r rune) rune {
-		if r == '\n' || r == '\r' || r == '\t' {
-			return ' ' // Replace newlines/tabs with spaces
-		}
-		if r < 32 || r > 126 { // Non-printable ASCII
-			return -1 // Remove character
-		}
-		return r
-	}, str)
-
-	// Trim whitespace
-	sanitized = strings.TrimSpace(sanitized)
-
-	// Ensure we have a valid string after sanitization
-	if len(sanitized) == 0 {
-		return "", fmt.Errorf("invalid %s: empty after sanitization", oidName)
-	}
-
-	return sanitized, nil
-}
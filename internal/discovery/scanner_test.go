@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/kljama/netscan/internal/config"
 	"golang.org/x/time/rate"
 )
 
@@ -161,7 +162,7 @@ func TestRunICMPSweepWithRateLimiter(t *testing.T) {
 	defer cancel()
 	
 	start := time.Now()
-	_ = RunICMPSweep(ctx, networks, workers, limiter)
+	_ = RunICMPSweep(ctx, networks, nil, workers, limiter, nil, nil)
 	elapsed := time.Since(start)
 	
 	// With 2 usable IPs and a rate of 2 pings/sec (burst of 2):
@@ -194,7 +195,7 @@ func TestRunICMPSweepContextCancellation(t *testing.T) {
 	defer cancel()
 	
 	start := time.Now()
-	_ = RunICMPSweep(ctx, networks, workers, limiter)
+	_ = RunICMPSweep(ctx, networks, nil, workers, limiter, nil, nil)
 	elapsed := time.Since(start)
 	
 	// Should exit within ~1s (100ms timeout + buffer for cleanup)
@@ -205,6 +206,36 @@ func TestRunICMPSweepContextCancellation(t *testing.T) {
 	}
 }
 
+// TestRunSNMPScanContextCancellation verifies a cancelled scan returns without waiting for SNMP timeouts
+func TestRunSNMPScanContextCancellation(t *testing.T) {
+	// A bound UDP socket that never answers, so every query would wait for its full timeout
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	snmpConfig := &config.SNMPConfig{
+		Community: "public",
+		Port:      conn.LocalAddr().(*net.UDPAddr).Port,
+		Timeout:   10 * time.Second,
+		Retries:   1,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	devices := RunSNMPScan(ctx, []string{"127.0.0.1", "127.0.0.1", "127.0.0.1", "127.0.0.1"}, snmpConfig, 2, "")
+	elapsed := time.Since(start)
+
+	if len(devices) != 0 {
+		t.Errorf("expected no devices from a silent agent, got %d", len(devices))
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("RunSNMPScan did not respect context cancellation, took %v", elapsed)
+	}
+}
+
 // TestRunICMPSweepWithoutRateLimiter verifies that RunICMPSweep works with nil limiter
 func TestRunICMPSweepWithoutRateLimiter(t *testing.T) {
 	// /30 network has 2 usable IPs after excluding network and broadcast
@@ -215,7 +246,7 @@ func TestRunICMPSweepWithoutRateLimiter(t *testing.T) {
 	defer cancel()
 	
 	// Should work fine with nil limiter (no rate limiting)
-	_ = RunICMPSweep(ctx, networks, workers, nil)
+	_ = RunICMPSweep(ctx, networks, nil, workers, nil, nil, nil)
 	// No assertions needed - just verify it doesn't panic
 }
 
@@ -0,0 +1,82 @@
+package discovery
+
+import (
+	"context"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// snmpSweepBatch is the number of targets queried per RunSNMPScan call
+const snmpSweepBatch = 256
+
+// snmpSweep is the "snmp" discovery method: it queries sysName/sysDescr on every target with each
+// address's SNMP credentials (snmp or the site's), finding hosts that answer SNMP but drop ICMP and TCP probes
+type snmpSweep struct {
+	cfg   *config.Config
+	sweep *Sweep
+}
+
+// newSNMPSweep builds the "snmp" discovery method
+func newSNMPSweep(cfg *config.Config, sweep *Sweep) Discoverer {
+	return &snmpSweep{cfg: cfg, sweep: sweep}
+}
+
+// Name returns the discovery method name
+func (s *snmpSweep) Name() string {
+	return MethodSNMP
+}
+
+// Discover queries the sweep's targets in rate-limited batches and returns the devices that answered
+func (s *snmpSweep) Discover(ctx context.Context) []state.Device {
+	source := s.sweep.source
+	if source == nil {
+		source = fullSweepSource(s.sweep.Networks, s.sweep.excluded)
+	}
+	jobs := make(chan string, snmpSweepBatch)
+	go func() {
+		defer close(jobs)
+		source(ctx, jobs, s.sweep.Progress)
+	}()
+
+	snmpFor := s.cfg.SNMPResolver()
+	var found []state.Device
+	batch := make([]string, 0, snmpSweepBatch)
+	flush := func() {
+		// Devices of each site are queried with that site's SNMP credentials
+		bySettings := make(map[*config.SNMPConfig][]string)
+		for _, ip := range batch {
+			bySettings[snmpFor(ip)] = append(bySettings[snmpFor(ip)], ip)
+		}
+		for snmpConfig, group := range bySettings {
+			for _, dev := range RunSNMPScan(ctx, group, snmpConfig, s.sweep.snmpWorkers(s.cfg), s.sweep.SourceIP) {
+				found = append(found, dev)
+				if s.sweep.OnFound != nil {
+					s.sweep.OnFound(dev.IP)
+				}
+			}
+		}
+		for range batch {
+			s.sweep.Progress.addProbed()
+		}
+		batch = batch[:0]
+	}
+
+	for ip := range jobs {
+		// Every query counts against the same rate limits as ICMP and TCP probes
+		if err := waitForToken(ctx, s.sweep.Limiter, s.sweep.NetworkLimits, ip); err != nil {
+			break
+		}
+		batch = append(batch, ip)
+		if len(batch) == snmpSweepBatch {
+			flush()
+		}
+	}
+	for range jobs {
+		// Drain the source after cancellation so its goroutine exits
+	}
+	if ctx.Err() == nil {
+		flush()
+	}
+	return found
+}
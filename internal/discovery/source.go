@@ -0,0 +1,11 @@
+package discovery
+
+import "net"
+
+// snmpLocalAddr returns the gosnmp LocalAddr of SNMP scans sent from sourceIP ("" = any)
+func snmpLocalAddr(sourceIP string) string {
+	if sourceIP != "" {
+		return net.JoinHostPort(sourceIP, "0")
+	}
+	return ""
+}
@@ -0,0 +1,246 @@
+package discovery
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/state"
+	"github.com/kljama/netscan/internal/validate"
+	"github.com/rs/zerolog/log"
+)
+
+// SSDP search parameters
+const (
+	ssdpGroup = "239.255.255.250:1900"
+	// ssdpRequest asks every UPnP device and service to answer within MX seconds
+	ssdpRequest = "M-SEARCH * HTTP/1.1\r\n" +
+		"HOST: 239.255.255.250:1900\r\n" +
+		"MAN: \"ssdp:discover\"\r\n" +
+		"MX: 2\r\n" +
+		"ST: ssdp:all\r\n\r\n"
+	ssdpDescriptionMax     = 64 << 10 // Largest device description read
+	ssdpDescriptionTimeout = 3 * time.Second
+)
+
+// ssdpResponse is the first M-SEARCH answer received from a host
+type ssdpResponse struct {
+	location string // LOCATION header: URL of the device description
+	server   string // SERVER header
+}
+
+// ssdpSweep is the "ssdp" discovery method: it multicasts an SSDP M-SEARCH on the directly attached segment,
+// then reads the UPnP device description of every responder inside the sweep's networks for its model
+// and manufacturer (cameras, NAS, media boxes and printers usually answer)
+type ssdpSweep struct {
+	cfg   *config.Config
+	sweep *Sweep
+}
+
+// newSSDPSweep builds the "ssdp" discovery method
+func newSSDPSweep(cfg *config.Config, sweep *Sweep) Discoverer {
+	return &ssdpSweep{cfg: cfg, sweep: sweep}
+}
+
+// Name returns the discovery method name
+func (s *ssdpSweep) Name() string {
+	return MethodSSDP
+}
+
+// Discover collects M-SEARCH responses for cfg.SSDPTimeout and returns the responders with their descriptions
+func (s *ssdpSweep) Discover(ctx context.Context) []state.Device {
+	responses, err := ssdpSearch(ctx, s.cfg.SSDPTimeout)
+	if err != nil {
+		log.Warn().Err(err).Msg("SSDP discovery failed")
+		return nil
+	}
+
+	ips := make([]string, 0, len(responses))
+	for ip := range responses {
+		if InNetworks(ip, s.sweep.Networks) && !s.sweep.excluded.Contains(ip) {
+			ips = append(ips, ip)
+		}
+	}
+	sort.Strings(ips)
+	s.sweep.Progress.addQueued(uint64(len(ips)))
+
+	devices := make([]state.Device, 0, len(ips))
+	for _, ip := range ips {
+		if ctx.Err() != nil {
+			break
+		}
+		response := responses[ip]
+		dev := state.Device{IP: ip}
+		if response.location != "" {
+			// Description fetches count against the same rate limits as probes
+			if err := waitForToken(ctx, s.sweep.Limiter, s.sweep.NetworkLimits, ip); err != nil {
+				break
+			}
+			info, err := fetchDeviceDescription(ctx, response.location, ip, s.cfg.SNMP.MaxStringLength)
+			if err != nil {
+				log.Debug().
+					Str("ip", ip).
+					Str("location", response.location).
+					Err(err).
+					Msg("Failed to read UPnP device description")
+			}
+			dev.UPnP = info
+		}
+		if server, err := validate.SNMPString(response.server, "SERVER", s.cfg.SNMP.MaxStringLength); err == nil {
+			dev.UPnP.Server = server
+		}
+		s.sweep.Progress.addProbed()
+		if s.sweep.OnFound != nil {
+			s.sweep.OnFound(ip)
+		}
+		devices = append(devices, dev)
+	}
+	log.Info().
+		Int("responders", len(responses)).
+		Int("in_networks", len(ips)).
+		Msg("SSDP discovery results")
+	return devices
+}
+
+// ssdpSearch multicasts an M-SEARCH and collects the first response of every host until wait elapses
+// The request is sent twice, since SSDP runs over UDP
+func ssdpSearch(ctx context.Context, wait time.Duration) (map[string]ssdpResponse, error) {
+	group, err := net.ResolveUDPAddr("udp4", ssdpGroup)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SSDP socket: %v", err)
+	}
+	defer conn.Close()
+
+	// Unblock the read loop when the sweep is cancelled
+	stop := context.AfterFunc(ctx, func() { conn.SetReadDeadline(time.Now()) })
+	defer stop()
+
+	for i := 0; i < 2; i++ {
+		if _, err := conn.WriteToUDP([]byte(ssdpRequest), group); err != nil {
+			return nil, fmt.Errorf("failed to send M-SEARCH: %v", err)
+		}
+	}
+	if err := conn.SetReadDeadline(time.Now().Add(wait)); err != nil {
+		return nil, err
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	responses := make(map[string]ssdpResponse)
+	buf := make([]byte, 2048)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return responses, nil
+			}
+			return responses, err
+		}
+		ip := from.IP.String()
+		if _, seen := responses[ip]; seen {
+			continue
+		}
+		if response, ok := parseSSDPResponse(buf[:n]); ok {
+			responses[ip] = response
+		}
+	}
+}
+
+// parseSSDPResponse parses an M-SEARCH answer (an HTTP/1.1 200 response over UDP)
+func parseSSDPResponse(data []byte) (ssdpResponse, bool) {
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), nil)
+	if err != nil {
+		return ssdpResponse{}, false
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ssdpResponse{}, false
+	}
+	return ssdpResponse{location: resp.Header.Get("Location"), server: resp.Header.Get("Server")}, true
+}
+
+// upnpDescription is the part of a UPnP device description netscan records
+type upnpDescription struct {
+	Device struct {
+		DeviceType   string `xml:"deviceType"`
+		FriendlyName string `xml:"friendlyName"`
+		Manufacturer string `xml:"manufacturer"`
+		ModelName    string `xml:"modelName"`
+		ModelNumber  string `xml:"modelNumber"`
+	} `xml:"device"`
+}
+
+// descriptionClient fetches device descriptions; redirects are not followed so a responder cannot point
+// netscan at another host
+var descriptionClient = &http.Client{
+	Timeout: ssdpDescriptionTimeout,
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// fetchDeviceDescription reads the UPnP device description at location, which must be served by ip itself
+// Values are sanitized like SNMP strings and cut to maxLength bytes (snmp.max_string_length, 0 = default)
+func fetchDeviceDescription(ctx context.Context, location, ip string, maxLength int) (state.UPnPInfo, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return state.UPnPInfo{}, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return state.UPnPInfo{}, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	if host := net.ParseIP(u.Hostname()); host == nil || host.String() != ip {
+		return state.UPnPInfo{}, fmt.Errorf("description host %q is not the responder", u.Hostname())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return state.UPnPInfo{}, err
+	}
+	resp, err := descriptionClient.Do(req)
+	if err != nil {
+		return state.UPnPInfo{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return state.UPnPInfo{}, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var desc upnpDescription
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, ssdpDescriptionMax)).Decode(&desc); err != nil {
+		return state.UPnPInfo{}, fmt.Errorf("invalid device description: %v", err)
+	}
+	var info state.UPnPInfo
+	for _, field := range []struct {
+		dst   *string
+		value string
+		name  string
+	}{
+		{&info.FriendlyName, desc.Device.FriendlyName, "friendlyName"},
+		{&info.Manufacturer, desc.Device.Manufacturer, "manufacturer"},
+		{&info.ModelName, desc.Device.ModelName, "modelName"},
+		{&info.ModelNumber, desc.Device.ModelNumber, "modelNumber"},
+		{&info.DeviceType, desc.Device.DeviceType, "deviceType"},
+	} {
+		if value, err := validate.SNMPString(field.value, field.name, maxLength); err == nil {
+			*field.dst = value
+		}
+	}
+	return info, nil
+}
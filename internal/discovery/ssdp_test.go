@@ -0,0 +1,65 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestParseSSDPResponse validates LOCATION/SERVER extraction and that non-200 answers are ignored
+func TestParseSSDPResponse(t *testing.T) {
+	response, ok := parseSSDPResponse([]byte("HTTP/1.1 200 OK\r\n" +
+		"CACHE-CONTROL: max-age=1800\r\n" +
+		"LOCATION: http://192.168.1.20:49152/description.xml\r\n" +
+		"SERVER: Linux/4.4 UPnP/1.0 Synology/DSM\r\n" +
+		"ST: upnp:rootdevice\r\n\r\n"))
+	if !ok || response.location != "http://192.168.1.20:49152/description.xml" || response.server != "Linux/4.4 UPnP/1.0 Synology/DSM" {
+		t.Errorf("unexpected response %+v (ok=%v)", response, ok)
+	}
+
+	if _, ok := parseSSDPResponse([]byte("NOTIFY * HTTP/1.1\r\nHOST: 239.255.255.250:1900\r\n\r\n")); ok {
+		t.Error("expected a NOTIFY request not to parse as a search response")
+	}
+	if _, ok := parseSSDPResponse([]byte("HTTP/1.1 404 Not Found\r\n\r\n")); ok {
+		t.Error("expected a non-200 response to be ignored")
+	}
+}
+
+// TestFetchDeviceDescription validates the description is parsed and sanitized, and only read from the responder
+func TestFetchDeviceDescription(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <device>
+    <deviceType>urn:schemas-upnp-org:device:MediaServer:1</deviceType>
+    <friendlyName>
+      Living Room NAS
+    </friendlyName>
+    <manufacturer>Synology</manufacturer>
+    <modelName>DS920+</modelName>
+    <modelNumber></modelNumber>
+  </device>
+</root>`)
+	}))
+	defer server.Close()
+	location := server.URL + "/description.xml"
+
+	info, err := fetchDeviceDescription(context.Background(), location, "127.0.0.1", 0)
+	if err != nil {
+		t.Fatalf("failed to fetch description: %v", err)
+	}
+	if info.FriendlyName != "Living Room NAS" || info.Manufacturer != "Synology" || info.ModelName != "DS920+" ||
+		info.ModelNumber != "" || info.DeviceType != "urn:schemas-upnp-org:device:MediaServer:1" {
+		t.Errorf("unexpected description %+v", info)
+	}
+
+	if _, err := fetchDeviceDescription(context.Background(), location, "192.168.1.20", 0); err == nil || !strings.Contains(err.Error(), "not the responder") {
+		t.Errorf("expected a description on another host to be rejected, got %v", err)
+	}
+	if _, err := fetchDeviceDescription(context.Background(), "file:///etc/passwd", "127.0.0.1", 0); err == nil {
+		t.Error("expected a non-HTTP location to be rejected")
+	}
+}
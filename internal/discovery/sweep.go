@@ -0,0 +1,136 @@
+package discovery
+
+import (
+	"context"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/state"
+	"github.com/rs/zerolog/log"
+)
+
+// Discovery modes selectable via discovery_mode
+const (
+	DiscoveryModeICMP = "icmp" // ICMP echo sweep only (default)
+	DiscoveryModeTCP  = "tcp"  // TCP connect sweep only, for networks that drop ICMP
+	DiscoveryModeBoth = "both" // Union of ICMP and TCP sweeps
+)
+
+// RunDiscoverySweep runs the discovery methods of cfg.DiscoveryMethods over sweep.Networks and returns the deduplicated responsive IPs
+// sweep.Networks is normally cfg.Networks, minus any ranges disabled by overlap detection
+// With sweep.Cursor (discovery_sweep_budget set), only the cursor's next window of addresses is probed and the
+// cursor advances afterwards; addresses are generated on the fly so memory stays constant for networks up to /8
+// sweep.Progress (optional) is updated with queued, probed and responsive counts while the sweep runs
+// sweep.OnFound (optional) is called once per responsive IP as soon as it is found, so monitoring can start before
+// the sweep finishes; calls are serialized across the per-network pipelines and must not block for long
+func RunDiscoverySweep(ctx context.Context, cfg *config.Config, sweep *Sweep) []string {
+	devices := RunDiscoverers(ctx, cfg, sweep)
+	ips := make([]string, len(devices))
+	for i, dev := range devices {
+		ips[i] = dev.IP
+	}
+	return ips
+}
+
+// probeSweep is the "sweep" discovery method: the ICMP and/or TCP sweep selected by cfg.DiscoveryMode,
+// plus an ARP sweep of directly attached networks when cfg.ARPDiscovery is enabled
+type probeSweep struct {
+	cfg   *config.Config
+	sweep *Sweep
+}
+
+// newProbeSweep builds the "sweep" discovery method
+func newProbeSweep(cfg *config.Config, sweep *Sweep) Discoverer {
+	return &probeSweep{cfg: cfg, sweep: sweep}
+}
+
+// Name returns the discovery method name
+func (p *probeSweep) Name() string {
+	return MethodSweep
+}
+
+// Discover runs the ICMP/TCP sweep over the sweep's targets and merges ARP results in
+// Full sweeps run one pipeline per network; a streaming window spans networks and runs as one pipeline
+func (p *probeSweep) Discover(ctx context.Context) []state.Device {
+	cfg, progress, limiter, report := p.cfg, p.sweep.Progress, p.sweep.Limiter, p.sweep.OnFound
+	icmp := func(ctx context.Context, source targetSource, workers int, onFound func(ip string)) []string {
+		return icmpSweep(ctx, source, cfg.ProbeProfiles.Discovery, p.sweep.Privileged, p.sweep.SourceIP, workers, limiter, p.sweep.NetworkLimits, progress, onFound)
+	}
+	tcp := func(ctx context.Context, source targetSource, workers int, onFound func(ip string)) []string {
+		return tcpSweep(ctx, source, cfg.TCPDiscoveryPorts, cfg.TCPDiscoveryTimeout, workers, limiter, p.sweep.NetworkLimits, progress, onFound)
+	}
+	run := func(sweep networkSweep) []string {
+		if p.sweep.Cursor != nil && p.sweep.source != nil {
+			return sweep(ctx, p.sweep.source, p.sweep.icmpWorkers(cfg), report)
+		}
+		return sweepNetworks(ctx, p.sweep.Networks, p.sweep.excluded, p.sweep.icmpWorkers(cfg), cfg.DiscoveryNetworkWorkers, report, sweep)
+	}
+
+	var responsiveIPs []string
+	switch cfg.DiscoveryMode {
+	case DiscoveryModeTCP:
+		progress.setPhase(PhaseTCP)
+		responsiveIPs = run(tcp)
+	case DiscoveryModeBoth:
+		progress.setPhase(PhaseICMP)
+		icmpIPs := run(icmp)
+		progress.setPhase(PhaseTCP)
+		tcpIPs := run(tcp)
+		log.Info().
+			Int("icmp_found", len(icmpIPs)).
+			Int("tcp_found", len(tcpIPs)).
+			Msg("Combined ICMP/TCP discovery results")
+		responsiveIPs = mergeIPs(icmpIPs, tcpIPs)
+	default:
+		progress.setPhase(PhaseICMP)
+		responsiveIPs = run(icmp)
+	}
+
+	if cfg.ARPDiscovery && ctx.Err() == nil {
+		progress.setPhase(PhaseARP)
+		arpIPs := RunARPSweep(ctx, p.sweep.Networks, p.sweep.excluded, limiter)
+		for _, ip := range arpIPs {
+			report(ip)
+		}
+		merged := mergeIPs(responsiveIPs, arpIPs)
+		log.Info().
+			Int("arp_found", len(arpIPs)).
+			Int("arp_only", len(merged)-len(responsiveIPs)).
+			Msg("ARP discovery results merged")
+		responsiveIPs = merged
+	}
+	return devicesFromIPs(responsiveIPs)
+}
+
+// reportOnce wraps onFound so each IP is reported at most once per sweep (ICMP, TCP and ARP may all find it)
+// Returns a no-op when onFound is nil (Progress.counting returns non-nil whenever progress is tracked)
+func reportOnce(onFound func(ip string)) func(ip string) {
+	if onFound == nil {
+		return func(string) {}
+	}
+	seen := make(map[string]bool)
+	return func(ip string) {
+		if !seen[ip] {
+			seen[ip] = true
+			onFound(ip)
+		}
+	}
+}
+
+// mergeIPs returns the union of IP lists, preserving first-seen order
+func mergeIPs(lists ...[]string) []string {
+	total := 0
+	for _, list := range lists {
+		total += len(list)
+	}
+	seen := make(map[string]bool, total)
+	merged := make([]string, 0, total)
+	for _, list := range lists {
+		for _, ip := range list {
+			if !seen[ip] {
+				seen[ip] = true
+				merged = append(merged, ip)
+			}
+		}
+	}
+	return merged
+}
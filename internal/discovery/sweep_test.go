@@ -0,0 +1,88 @@
+package discovery
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+)
+
+// TestRunDiscoverySweepStreamsResults verifies responsive IPs are reported through onFound before the sweep returns
+func TestRunDiscoverySweepStreamsResults(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on loopback: %v", err)
+	}
+	defer ln.Close()
+
+	cfg := &config.Config{
+		DiscoveryMode:       DiscoveryModeTCP,
+		TCPDiscoveryPorts:   []int{ln.Addr().(*net.TCPAddr).Port},
+		TCPDiscoveryTimeout: time.Second,
+		IcmpWorkers:         4,
+	}
+
+	var found []string
+	progress := NewProgress([]string{"127.0.0.1/32"})
+	ips := RunDiscoverySweep(context.Background(), cfg, &Sweep{
+		Networks: []string{"127.0.0.1/32"},
+		Progress: progress,
+		OnFound: func(ip string) {
+			found = append(found, ip)
+		},
+	})
+	if len(ips) != 1 || len(found) != 1 || found[0] != "127.0.0.1" {
+		t.Errorf("expected 127.0.0.1 returned and reported once, got returned=%v reported=%v", ips, found)
+	}
+
+	snap := progress.Snapshot()
+	if snap.Phase != PhaseTCP || snap.Queued != 1 || snap.Probed != 1 || snap.Responsive != 1 {
+		t.Errorf("expected tcp phase with 1 queued, probed and responsive, got %+v", snap)
+	}
+}
+
+// TestReportOnce verifies duplicate IPs from different sweep methods are reported once and nil is tolerated
+func TestReportOnce(t *testing.T) {
+	var reported []string
+	report := reportOnce(func(ip string) { reported = append(reported, ip) })
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.1"} {
+		report(ip)
+	}
+	if len(reported) != 2 || reported[0] != "10.0.0.1" || reported[1] != "10.0.0.2" {
+		t.Errorf("expected [10.0.0.1 10.0.0.2], got %v", reported)
+	}
+
+	reportOnce(nil)("10.0.0.1") // Must not panic
+}
+
+// TestProgressSnapshot verifies the ETA extrapolation and that a finished sweep reports no ETA
+func TestProgressSnapshot(t *testing.T) {
+	var nilProgress *Progress
+	nilProgress.addQueued(1) // Must not panic
+	if snap := nilProgress.Snapshot(); snap.Queued != 0 {
+		t.Errorf("expected an empty snapshot for nil progress, got %+v", snap)
+	}
+
+	progress := NewProgress([]string{"10.0.0.0/24"})
+	if snap := progress.Snapshot(); snap.ETAS != nil {
+		t.Errorf("expected no ETA before the first probe, got %v", *snap.ETAS)
+	}
+
+	progress.started = time.Now().Add(-10 * time.Second)
+	progress.addQueued(100)
+	for i := 0; i < 25; i++ {
+		progress.addProbed()
+	}
+	snap := progress.Snapshot()
+	if snap.ETAS == nil || *snap.ETAS < 29 || *snap.ETAS > 31 {
+		t.Errorf("expected an ETA of about 30s after probing a quarter in 10s, got %+v", snap.ETAS)
+	}
+
+	progress.Finish(true)
+	snap = progress.Snapshot()
+	if snap.ETAS != nil || snap.FinishedAt == nil || !snap.Cancelled {
+		t.Errorf("expected a cancelled finished sweep without ETA, got %+v", snap)
+	}
+}
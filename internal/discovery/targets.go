@@ -0,0 +1,139 @@
+package discovery
+
+import (
+	"context"
+	"encoding/binary"
+	"math/rand"
+	"net"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/rs/zerolog/log"
+)
+
+// targetSource feeds sweep targets into jobs until done or ctx is cancelled
+// It must not close jobs; the sweep closes it once the source returns
+// Sources count their targets on progress (which may be nil) before feeding them
+type targetSource func(ctx context.Context, jobs chan<- string, progress *Progress)
+
+// fullSweepSource expands every network, shuffles the complete list and feeds it in randomized order
+// Memory grows with the total network size; networks larger than /16 expand to nothing (see ipsFromCIDR)
+// Hosts in excluded are never fed
+func fullSweepSource(networks []string, excluded *config.Exclusions) targetSource {
+	return func(ctx context.Context, jobs chan<- string, progress *Progress) {
+		// Step 1: Buffer all IPs from all networks into a master list
+		var allIPs []string
+		for _, network := range networks {
+			allIPs = append(allIPs, ipsFromCIDR(network)...)
+		}
+		allIPs = filterExcluded(allIPs, excluded) // Never probe exclude_networks / exclude_ips
+
+		// Step 2: Shuffle the master list to randomize scan order
+		// This obscures the sequential scanning pattern across all subnets
+		rand.Shuffle(len(allIPs), func(i, j int) {
+			allIPs[i], allIPs[j] = allIPs[j], allIPs[i]
+		})
+
+		// Step 3: Feed shuffled IPs to jobs channel
+		progress.addQueued(uint64(len(allIPs)))
+		emitTargets(ctx, jobs, allIPs)
+	}
+}
+
+// streamWindowSize bounds the targets held in memory by a windowed sweep
+const streamWindowSize = 4096
+
+// windowSource feeds count addresses of space starting at index start (wrapping around the end)
+// Addresses are generated on the fly and shuffled in windows of streamWindowSize, so memory stays
+// constant regardless of network size
+func windowSource(space *addressSpace, start, count uint64, excluded *config.Exclusions) targetSource {
+	return func(ctx context.Context, jobs chan<- string, progress *Progress) {
+		if space.total == 0 {
+			return
+		}
+		progress.addQueued(count) // Counted up front so the ETA covers the whole window
+		window := make([]string, 0, streamWindowSize)
+		for k := uint64(0); k < count; k++ {
+			ip := space.at((start + k) % space.total)
+			if excluded.Contains(ip) {
+				progress.dropQueued()
+				continue // Never probe exclude_networks / exclude_ips
+			}
+			window = append(window, ip)
+			if len(window) == streamWindowSize {
+				rand.Shuffle(len(window), func(i, j int) { window[i], window[j] = window[j], window[i] })
+				if !emitTargets(ctx, jobs, window) {
+					return
+				}
+				window = window[:0]
+			}
+		}
+		rand.Shuffle(len(window), func(i, j int) { window[i], window[j] = window[j], window[i] })
+		emitTargets(ctx, jobs, window)
+	}
+}
+
+// emitTargets sends ips to jobs, returning false if ctx was cancelled first
+func emitTargets(ctx context.Context, jobs chan<- string, ips []string) bool {
+	for _, ip := range ips {
+		select {
+		case jobs <- ip:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// hostRange is the contiguous block of usable host addresses of one IPv4 network
+type hostRange struct {
+	network string
+	first   uint32 // First usable host address
+	count   uint64 // Number of usable host addresses
+}
+
+// addressSpace indexes the usable host addresses of several IPv4 networks without expanding them
+// Network and broadcast addresses are skipped for networks /30 and larger, matching ipsFromCIDR
+type addressSpace struct {
+	ranges []hostRange
+	total  uint64
+}
+
+// newAddressSpace builds the index for networks; non-IPv4 and invalid networks are skipped
+func newAddressSpace(networks []string) *addressSpace {
+	space := &addressSpace{}
+	for _, cidr := range networks {
+		_, ipnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		v4 := ipnet.IP.To4()
+		ones, bits := ipnet.Mask.Size()
+		if v4 == nil || bits != 32 {
+			log.Warn().Str("network", cidr).Msg("Streaming discovery supports IPv4 networks only, skipping")
+			continue
+		}
+
+		first := binary.BigEndian.Uint32(v4)
+		count := uint64(1) << uint(32-ones)
+		if ones < 31 {
+			first++    // Skip network address
+			count -= 2 // Skip network and broadcast addresses
+		}
+		space.ranges = append(space.ranges, hostRange{network: cidr, first: first, count: count})
+		space.total += count
+	}
+	return space
+}
+
+// at returns the address at index i (0 <= i < total)
+func (s *addressSpace) at(i uint64) string {
+	for _, r := range s.ranges {
+		if i < r.count {
+			ip := make(net.IP, 4)
+			binary.BigEndian.PutUint32(ip, r.first+uint32(i))
+			return ip.String()
+		}
+		i -= r.count
+	}
+	return ""
+}
@@ -0,0 +1,164 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/ratelimit"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/time/rate"
+)
+
+// RunTCPSweep performs a concurrent TCP connect scan across multiple networks, one pipeline per network
+// A host counts as alive if any port accepts the connection or actively refuses it (RST),
+// since either proves the host is up; ports are tried in order and probing stops at the first answer
+// The limiter and the partition of limits (network_rate_limits, nil = none) are consulted once per connection attempt
+// onFound (optional) is called with each live IP as soon as it answers; calls are serialized
+// Hosts in excluded (nil = none) are never probed
+func RunTCPSweep(ctx context.Context, networks []string, excluded *config.Exclusions, ports []int, timeout time.Duration, workers int, limiter *rate.Limiter, limits *ratelimit.Partitions, onFound func(ip string)) []string {
+	return sweepNetworks(ctx, networks, excluded, workers, 0, onFound, func(ctx context.Context, source targetSource, workers int, onFound func(ip string)) []string {
+		return tcpSweep(ctx, source, ports, timeout, workers, limiter, limits, nil, onFound)
+	})
+}
+
+// tcpSweep probes every target produced by source with a pool of workers
+func tcpSweep(ctx context.Context, source targetSource, ports []int, timeout time.Duration, workers int, limiter *rate.Limiter, limits *ratelimit.Partitions, progress *Progress, onFound func(ip string)) []string {
+	if workers <= 0 {
+		workers = 64 // Default
+	}
+	if timeout <= 0 {
+		timeout = 1 * time.Second
+	}
+
+	var (
+		jobs    = make(chan string, 256)
+		results = make(chan string, 256)
+		wg      sync.WaitGroup
+	)
+
+	// Worker goroutine for TCP connect probes
+	worker := func() {
+		// Panic recovery for worker goroutine
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error().
+					Interface("panic", r).
+					Msg("TCP discovery worker panic recovered")
+			}
+		}()
+
+		defer wg.Done()
+		for ip := range jobs {
+			alive, err := probeTCP(ctx, ip, ports, timeout, limiter, limits)
+			if err != nil {
+				// Context was cancelled while waiting for token
+				log.Debug().
+					Str("ip", ip).
+					Msg("TCP discovery cancelled while waiting for rate limit token")
+				return
+			}
+			progress.addProbed()
+			if alive {
+				results <- ip
+			}
+		}
+	}
+
+	// Launch concurrent TCP worker goroutines
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go worker()
+	}
+
+	// Producer: enqueue targets from the source (randomized order)
+	go func() {
+		// Panic recovery for producer goroutine
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error().
+					Interface("panic", r).
+					Msg("TCP discovery producer panic recovered")
+			}
+		}()
+		defer close(jobs)
+
+		source(ctx, jobs, progress)
+	}()
+
+	// Wait for all workers to complete, then close results channel
+	go func() {
+		// Panic recovery for wait goroutine
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error().
+					Interface("panic", r).
+					Msg("TCP discovery wait goroutine panic recovered")
+			}
+		}()
+
+		wg.Wait()
+		close(results)
+	}()
+
+	// Collect all responsive IPs, reporting each one as it arrives
+	var responsiveIPs []string
+	for ip := range results {
+		responsiveIPs = append(responsiveIPs, ip)
+		if onFound != nil {
+			onFound(ip)
+		}
+	}
+	return responsiveIPs
+}
+
+// probeTCP tries each port until one answers with SYN-ACK or RST
+// Returns an error only if the context was cancelled while waiting for the rate limiter
+func probeTCP(ctx context.Context, ip string, ports []int, timeout time.Duration, limiter *rate.Limiter, limits *ratelimit.Partitions) (bool, error) {
+	dialer := net.Dialer{Timeout: timeout}
+	for _, port := range ports {
+		if err := waitForToken(ctx, limiter, limits, ip); err != nil {
+			return false, err
+		}
+
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip, strconv.Itoa(port)))
+		if err == nil {
+			conn.Close()
+			return true, nil
+		}
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			// Port closed but the host answered with RST
+			return true, nil
+		}
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+	}
+	return false, nil
+}
+
+// OpenPorts connects to each of ports on ip and returns those that accepted the connection, in order
+// Used to fingerprint devices for classification; refused and timed-out ports are left out
+// The limiter and ip's partition of limits are consulted once per connection attempt; a cancelled context returns
+// the ports found so far
+func OpenPorts(ctx context.Context, ip string, ports []int, timeout time.Duration, limiter *rate.Limiter, limits *ratelimit.Partitions) []int {
+	dialer := net.Dialer{Timeout: timeout}
+	var open []int
+	for _, port := range ports {
+		if err := waitForToken(ctx, limiter, limits, ip); err != nil {
+			return open
+		}
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip, strconv.Itoa(port)))
+		if err != nil {
+			continue
+		}
+		conn.Close()
+		open = append(open, port)
+	}
+	return open
+}
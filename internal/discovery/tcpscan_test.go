@@ -0,0 +1,103 @@
+package discovery
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestRunTCPSweepOpenPort verifies a host with a listening port is discovered
+func TestRunTCPSweepOpenPort(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on loopback: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	port := ln.Addr().(*net.TCPAddr).Port
+	ips := RunTCPSweep(context.Background(), []string{"127.0.0.1/32"}, nil, []int{port}, time.Second, 4, nil, nil, nil)
+	if len(ips) != 1 || ips[0] != "127.0.0.1" {
+		t.Errorf("expected [127.0.0.1], got %v", ips)
+	}
+}
+
+// TestRunTCPSweepRefusedPort verifies an RST (connection refused) still marks the host alive
+func TestRunTCPSweepRefusedPort(t *testing.T) {
+	// Grab a free port and close it so connections are refused
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on loopback: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	ips := RunTCPSweep(context.Background(), []string{"127.0.0.1/32"}, nil, []int{port}, time.Second, 4, nil, nil, nil)
+	if len(ips) != 1 {
+		t.Errorf("expected refused connection to count as alive, got %v", ips)
+	}
+}
+
+// TestRunTCPSweepCancelled verifies a cancelled context stops the sweep without results
+func TestRunTCPSweepCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ips := RunTCPSweep(ctx, []string{"192.0.2.0/28"}, nil, []int{22}, time.Second, 4, nil, nil, nil)
+	if len(ips) != 0 {
+		t.Errorf("expected no results from cancelled sweep, got %v", ips)
+	}
+}
+
+// TestOpenPorts verifies only accepting ports are reported, refused ones are left out
+func TestOpenPorts(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on loopback: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on loopback: %v", err)
+	}
+	refused := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	open := ln.Addr().(*net.TCPAddr).Port
+	got := OpenPorts(context.Background(), "127.0.0.1", []int{refused, open}, time.Second, nil, nil)
+	if len(got) != 1 || got[0] != open {
+		t.Errorf("expected [%d], got %v", open, got)
+	}
+}
+
+// TestMergeIPs verifies ICMP and TCP results are deduplicated
+func TestMergeIPs(t *testing.T) {
+	merged := mergeIPs([]string{"10.0.0.1", "10.0.0.2"}, []string{"10.0.0.2", "10.0.0.3"})
+	want := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}
+	if len(merged) != len(want) {
+		t.Fatalf("expected %v, got %v", want, merged)
+	}
+	for i := range want {
+		if merged[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, merged)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+// Package events is an in-process publish/subscribe bus for device, scan and sink events
+// Every subscriber receives events in the order they were published
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Event types
+const (
+	TypeDeviceState       = "device_state"       // Up/down transition (Payload: state.StateEvent)
+	TypeDeviceSuspended   = "device_suspended"   // Ping circuit breaker tripped (Payload: Suspension)
+	TypeDeviceQuarantined = "device_quarantined" // Circuit breaker tripped too often, device no longer probed (Payload: Quarantine)
+	TypeDeviceDiscovered  = "device_discovered"  // New device added to monitoring (Payload: Discovery)
+	TypeDeviceReboot      = "device_reboot"      // sysUpTime went backwards between SNMP polls (Payload: Reboot)
+	TypeDeviceChanged     = "device_changed"     // SNMP poll changed the device's device_info values (Payload: DeviceChange)
+	TypeCompositeCheck    = "composite_check"    // Composite check became healthy or unhealthy (Payload: CheckChange)
+	TypeLatencyAlert      = "latency_alert"      // Device RTT crossed a latency threshold level (Payload: LatencyAlert)
+	TypeScanCompleted     = "scan_completed"     // Discovery sweep finished (Payload: ScanSummary)
+	TypeSinkError         = "sink_error"         // An event could not be written to a result sink (Payload: SinkError)
+)
+
+// DefaultBuffer is the number of events a subscriber may fall behind before events are dropped for it
+const DefaultBuffer = 1024
+
+// Event is one published event
+type Event struct {
+	Seq      uint64      `json:"seq"` // Assigned by Publish; increases by one per event, so gaps reveal drops
+	Type     string      `json:"type"`
+	Time     time.Time   `json:"time"`
+	IP       string      `json:"ip,omitempty"`
+	Hostname string      `json:"hostname,omitempty"`
+	Payload  interface{} `json:"payload,omitempty"` // Type-specific details, see the event types
+}
+
+// Suspension is the payload of device_suspended events
+type Suspension struct {
+	Until time.Time `json:"until"` // End of the suspension
+}
+
+// Quarantine is the payload of device_quarantined events
+type Quarantine struct {
+	Trips     int       `json:"trips"`      // Circuit breaker trips within the window
+	FirstTrip time.Time `json:"first_trip"` // Oldest of those trips
+}
+
+// Reboot is the payload of device_reboot events
+type Reboot struct {
+	RebootedAt      time.Time `json:"rebooted_at"`       // Estimated restart time (poll time minus sysUpTime)
+	UpTimeS         float64   `json:"uptime_s"`          // sysUpTime after the restart
+	PreviousUpTimeS float64   `json:"previous_uptime_s"` // sysUpTime at the previous poll
+}
+
+// DeviceChange is the payload of device_changed events
+type DeviceChange struct {
+	Changes []FieldChange `json:"changes"` // Changed values, standard fields first
+}
+
+// FieldChange is one changed device_info field; Old or New is "" when the field was added or removed
+type FieldChange struct {
+	Field string `json:"field"` // hostname, snmp_description, sys_object_id, sys_location, sys_contact or a custom field
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// Discovery is the payload of device_discovered events
+type Discovery struct {
+	Source    string `json:"source"`              // "sweep", "arp" (passive ARP listener) or "api" (POST /api/probe)
+	MAC       string `json:"mac,omitempty"`       // Hardware address (arp only)
+	Interface string `json:"interface,omitempty"` // Interface the ARP traffic was seen on (arp only)
+}
+
+// Discovery sources
+const (
+	SourceSweep = "sweep"
+	SourceARP   = "arp"
+	SourceAPI   = "api"
+)
+
+// CheckChange is the payload of composite_check events
+type CheckChange struct {
+	Check   string   `json:"check"`
+	Healthy bool     `json:"healthy"`
+	Passed  int      `json:"passed"`           // Conditions that held
+	Total   int      `json:"total"`            // Conditions evaluated
+	Failed  []string `json:"failed,omitempty"` // Conditions that did not hold
+}
+
+// LatencyAlert is the payload of latency_alert events
+type LatencyAlert struct {
+	Threshold  string  `json:"threshold"`
+	Level      string  `json:"level"`                // ok, warning or critical
+	Previous   string  `json:"previous"`             // Level before the change
+	RTTMs      float64 `json:"rtt_ms"`               // RTT compared with the limits (the percentile when configured)
+	LimitMs    float64 `json:"limit_ms,omitempty"`   // Limit that was exceeded (omitted for ok)
+	Percentile float64 `json:"percentile,omitempty"` // Percentile of the RTT history compared (omitted for per-cycle thresholds)
+}
+
+// ScanSummary is the payload of scan_completed events
+type ScanSummary struct {
+	Networks   []string `json:"networks"`
+	Found      int      `json:"found"`       // Devices that answered
+	NewDevices int      `json:"new_devices"` // Devices added to monitoring
+	DurationS  float64  `json:"duration_s"`
+}
+
+// SinkError is the payload of sink_error events
+type SinkError struct {
+	Sink  string `json:"sink"`  // Sink that failed, e.g. "results"
+	Event string `json:"event"` // Type of the event that could not be written
+	Error string `json:"error"`
+}
+
+// Bus delivers published events to every subscriber in publish order
+// Publish never blocks: a subscriber whose buffer is full misses the event (counted in Dropped)
+type Bus struct {
+	mu     sync.Mutex
+	seq    uint64
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// Subscription is one subscriber's ordered event queue
+type Subscription struct {
+	name    string
+	ch      chan Event
+	dropped atomic.Uint64
+}
+
+// New creates an event bus without subscribers
+func New() *Bus {
+	return &Bus{subs: make(map[*Subscription]struct{})}
+}
+
+// Subscribe registers a subscriber that may fall buffer events behind (DefaultBuffer if <= 0)
+// Subscribing to a closed bus returns a subscription whose channel is already closed
+func (b *Bus) Subscribe(name string, buffer int) *Subscription {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	sub := &Subscription{name: name, ch: make(chan Event, buffer)}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(sub.ch)
+		return sub
+	}
+	b.subs[sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe removes a subscriber and closes its channel; safe to call more than once
+func (b *Bus) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// Publish assigns the next sequence number and queues the event for every subscriber
+// Sequence assignment and queueing happen under one lock, so all subscribers see the same order
+// Returns the sequence number (0 once the bus is closed); safe to call on a nil Bus
+func (b *Bus) Publish(event Event) uint64 {
+	if b == nil {
+		return 0
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return 0
+	}
+	b.seq++
+	event.Seq = b.seq
+	for sub := range b.subs {
+		select {
+		case sub.ch <- event:
+		default:
+			if dropped := sub.dropped.Add(1); dropped%1000 == 1 {
+				log.Warn().
+					Str("subscriber", sub.name).
+					Str("event", event.Type).
+					Uint64("dropped", dropped).
+					Msg("Event subscriber is falling behind, dropping events")
+			}
+		}
+	}
+	return event.Seq
+}
+
+// Close stops accepting events and closes every subscriber's channel
+// Subscribers still receive the events queued before Close
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for sub := range b.subs {
+		close(sub.ch)
+		delete(b.subs, sub)
+	}
+}
+
+// Events returns the subscriber's channel, closed on Unsubscribe or Close
+func (s *Subscription) Events() <-chan Event {
+	return s.ch
+}
+
+// Dropped returns the number of events this subscriber missed because its buffer was full
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Consume calls handle for every event in order until the channel is closed
+// A panicking handler is logged and skipped, so one bad event does not stop the subscriber
+func (s *Subscription) Consume(handle func(Event)) {
+	for event := range s.ch {
+		s.handle(handle, event)
+	}
+}
+
+// handle runs the handler for one event with panic recovery
+func (s *Subscription) handle(handle func(Event), event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().
+				Str("subscriber", s.name).
+				Str("event", event.Type).
+				Uint64("seq", event.Seq).
+				Interface("panic", r).
+				Msg("Event subscriber panic recovered")
+		}
+	}()
+	handle(event)
+}
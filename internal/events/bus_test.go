@@ -0,0 +1,108 @@
+package events
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestBusOrdering verifies every subscriber sees events from concurrent publishers in the same order
+func TestBusOrdering(t *testing.T) {
+	bus := New()
+	a := bus.Subscribe("a", 1000)
+	b := bus.Subscribe("b", 1000)
+
+	var wg sync.WaitGroup
+	for p := 0; p < 4; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				bus.Publish(Event{Type: TypeDeviceState, IP: "192.168.1.1"})
+			}
+		}()
+	}
+	wg.Wait()
+	bus.Close()
+
+	var seqA, seqB []uint64
+	for ev := range a.Events() {
+		seqA = append(seqA, ev.Seq)
+	}
+	for ev := range b.Events() {
+		seqB = append(seqB, ev.Seq)
+	}
+	if len(seqA) != 400 || len(seqB) != 400 {
+		t.Fatalf("expected 400 events per subscriber, got %d and %d", len(seqA), len(seqB))
+	}
+	for i := range seqA {
+		if seqA[i] != uint64(i+1) || seqB[i] != seqA[i] {
+			t.Fatalf("event %d: sequence %d/%d, expected %d for both", i, seqA[i], seqB[i], i+1)
+		}
+	}
+}
+
+// TestBusSlowSubscriber verifies a full subscriber loses events without blocking others or reordering
+func TestBusSlowSubscriber(t *testing.T) {
+	bus := New()
+	slow := bus.Subscribe("slow", 2)
+	fast := bus.Subscribe("fast", 10)
+
+	for i := 0; i < 5; i++ {
+		bus.Publish(Event{Type: TypeScanCompleted})
+	}
+	if slow.Dropped() != 3 || fast.Dropped() != 0 {
+		t.Errorf("expected 3 drops for slow and none for fast, got %d and %d", slow.Dropped(), fast.Dropped())
+	}
+	if first, second := <-slow.Events(), <-slow.Events(); first.Seq != 1 || second.Seq != 2 {
+		t.Errorf("expected the slow subscriber to keep events 1 and 2, got %d and %d", first.Seq, second.Seq)
+	}
+}
+
+// TestBusUnsubscribeAndClose verifies channels are closed and publishing stops after Close
+func TestBusUnsubscribeAndClose(t *testing.T) {
+	bus := New()
+	sub := bus.Subscribe("client", 1)
+	bus.Unsubscribe(sub)
+	bus.Unsubscribe(sub) // Second call is a no-op
+	if _, ok := <-sub.Events(); ok {
+		t.Error("expected channel closed after Unsubscribe")
+	}
+	if seq := bus.Publish(Event{Type: TypeScanCompleted}); seq != 1 {
+		t.Errorf("expected sequence 1 without subscribers, got %d", seq)
+	}
+
+	bus.Close()
+	if seq := bus.Publish(Event{Type: TypeScanCompleted}); seq != 0 {
+		t.Errorf("expected publish after Close to return 0, got %d", seq)
+	}
+	late := bus.Subscribe("late", 1)
+	if _, ok := <-late.Events(); ok {
+		t.Error("expected subscription to a closed bus to be closed")
+	}
+
+	var nilBus *Bus
+	if seq := nilBus.Publish(Event{}); seq != 0 {
+		t.Errorf("expected publish on nil bus to return 0, got %d", seq)
+	}
+}
+
+// TestConsumeRecoversPanics verifies one panicking event does not stop the subscriber
+func TestConsumeRecoversPanics(t *testing.T) {
+	bus := New()
+	sub := bus.Subscribe("consumer", 10)
+	bus.Publish(Event{Type: TypeSinkError})
+	bus.Publish(Event{Type: TypeScanCompleted, Time: time.Unix(0, 0)})
+	bus.Close()
+
+	var handled []string
+	sub.Consume(func(ev Event) {
+		if ev.Type == TypeSinkError {
+			panic("boom")
+		}
+		handled = append(handled, ev.Type)
+	})
+	if len(handled) != 1 || handled[0] != TypeScanCompleted {
+		t.Errorf("expected the second event to be handled, got %v", handled)
+	}
+}
@@ -0,0 +1,210 @@
+package flags
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kljama/netscan/internal/logger"
+	"github.com/rs/zerolog"
+)
+
+// Runtime diagnostic flags toggled via /api/flags
+const (
+	Debug      = "debug"       // Global debug log level
+	ProbeTrace = "probe_trace" // Info-level trace line for every ping and SNMP poll
+	Pprof      = "pprof"       // Serve /debug/pprof/ on the health port
+	Verbose    = "verbose"     // Probe tracing for a single device IP
+)
+
+// TTL bounds for runtime flags; every flag expires so diagnostics cannot be left on by accident
+const (
+	DefaultTTL = 15 * time.Minute
+	MaxTTL     = 24 * time.Hour
+)
+
+// State describes an active flag
+type State struct {
+	Flag      string    `json:"flag"`
+	IP        string    `json:"ip,omitempty"` // Only for verbose
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// entry is an active flag with its expiry timer
+type entry struct {
+	state State
+	timer *time.Timer
+}
+
+var (
+	mu     sync.Mutex
+	active = make(map[string]*entry) // Keyed by flag name, or "verbose/<ip>"
+
+	// Lock-free reads for the probe hot path
+	probeTraceOn atomic.Bool
+	pprofOn      atomic.Bool
+	verboseIPs   atomic.Pointer[map[string]bool]
+
+	// Log level to restore when the debug flag is cleared
+	savedLevel zerolog.Level
+)
+
+// Set enables a flag for ttl (0 = DefaultTTL), replacing any previous expiry
+// ip is required for Verbose and ignored otherwise
+func Set(flag, ip string, ttl time.Duration) (State, error) {
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	if ttl < 0 || ttl > MaxTTL {
+		return State{}, fmt.Errorf("ttl must be between 1s and %v, got %v", MaxTTL, ttl)
+	}
+	key, ip, err := flagKey(flag, ip)
+	if err != nil {
+		return State{}, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if existing, ok := active[key]; ok {
+		existing.timer.Stop()
+	} else {
+		enableLocked(flag, ip)
+	}
+
+	e := &entry{state: State{Flag: flag, IP: ip, ExpiresAt: time.Now().Add(ttl)}}
+	e.timer = time.AfterFunc(ttl, func() { expire(key, e) })
+	active[key] = e
+	return e.state, nil
+}
+
+// Clear disables a flag immediately; clearing an inactive flag is a no-op
+func Clear(flag, ip string) error {
+	key, ip, err := flagKey(flag, ip)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if e, ok := active[key]; ok {
+		e.timer.Stop()
+		delete(active, key)
+		disableLocked(flag, ip)
+	}
+	return nil
+}
+
+// Active returns the currently enabled flags sorted by flag and IP
+func Active() []State {
+	mu.Lock()
+	defer mu.Unlock()
+	states := make([]State, 0, len(active))
+	for _, e := range active {
+		states = append(states, e.state)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].Flag != states[j].Flag {
+			return states[i].Flag < states[j].Flag
+		}
+		return states[i].IP < states[j].IP
+	})
+	return states
+}
+
+// Traced reports whether probes of ip should emit trace logs (probe_trace or verbose for ip)
+func Traced(ip string) bool {
+	if probeTraceOn.Load() {
+		return true
+	}
+	if ips := verboseIPs.Load(); ips != nil {
+		return (*ips)[ip]
+	}
+	return false
+}
+
+// PprofEnabled reports whether /debug/pprof/ may be served
+func PprofEnabled() bool {
+	return pprofOn.Load()
+}
+
+// flagKey validates the flag name (and IP for Verbose) and returns the registry key and canonical IP
+func flagKey(flag, ip string) (string, string, error) {
+	switch flag {
+	case Debug, ProbeTrace, Pprof:
+		return flag, "", nil
+	case Verbose:
+		parsed := net.ParseIP(ip)
+		if parsed == nil {
+			return "", "", fmt.Errorf("verbose requires a valid ip, got %q", ip)
+		}
+		return Verbose + "/" + parsed.String(), parsed.String(), nil
+	default:
+		return "", "", fmt.Errorf("unknown flag %q (debug, probe_trace, pprof, verbose)", flag)
+	}
+}
+
+// expire clears a flag when its timer fires, unless it was re-set since (different entry)
+func expire(key string, e *entry) {
+	mu.Lock()
+	defer mu.Unlock()
+	if active[key] != e {
+		return
+	}
+	delete(active, key)
+	disableLocked(e.state.Flag, e.state.IP)
+}
+
+// enableLocked applies the side effect of turning a flag on; caller holds mu
+func enableLocked(flag, ip string) {
+	switch flag {
+	case Debug:
+		savedLevel = zerolog.GlobalLevel()
+		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+		logger.SetDebugOverride(true) // Per-module log levels would otherwise still filter debug lines
+	case ProbeTrace:
+		probeTraceOn.Store(true)
+	case Pprof:
+		pprofOn.Store(true)
+	case Verbose:
+		rebuildVerboseLocked(ip, true)
+	}
+}
+
+// disableLocked reverts the side effect of a flag; caller holds mu
+func disableLocked(flag, ip string) {
+	switch flag {
+	case Debug:
+		zerolog.SetGlobalLevel(savedLevel)
+		logger.SetDebugOverride(false)
+	case ProbeTrace:
+		probeTraceOn.Store(false)
+	case Pprof:
+		pprofOn.Store(false)
+	case Verbose:
+		rebuildVerboseLocked(ip, false)
+	}
+}
+
+// rebuildVerboseLocked publishes a new copy-on-write verbose IP set; caller holds mu
+func rebuildVerboseLocked(ip string, on bool) {
+	ips := make(map[string]bool)
+	if current := verboseIPs.Load(); current != nil {
+		for k := range *current {
+			ips[k] = true
+		}
+	}
+	if on {
+		ips[ip] = true
+	} else {
+		delete(ips, ip)
+	}
+	if len(ips) == 0 {
+		verboseIPs.Store(nil)
+		return
+	}
+	verboseIPs.Store(&ips)
+}
@@ -0,0 +1,160 @@
+package flags
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// reset clears all flags so tests do not leak state into each other
+func reset(t *testing.T) {
+	t.Helper()
+	for _, s := range Active() {
+		if err := Clear(s.Flag, s.IP); err != nil {
+			t.Fatalf("failed to clear %s: %v", s.Flag, err)
+		}
+	}
+}
+
+// TestSetAndClear validates flags toggle their effects and show up in Active
+func TestSetAndClear(t *testing.T) {
+	reset(t)
+	defer reset(t)
+
+	if Traced("10.0.0.1") || PprofEnabled() {
+		t.Fatal("expected no flags active initially")
+	}
+
+	if _, err := Set(Verbose, "10.0.0.1", time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !Traced("10.0.0.1") {
+		t.Error("expected 10.0.0.1 to be traced")
+	}
+	if Traced("10.0.0.2") {
+		t.Error("expected 10.0.0.2 not to be traced")
+	}
+
+	if _, err := Set(ProbeTrace, "", time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !Traced("10.0.0.2") {
+		t.Error("expected probe_trace to trace every device")
+	}
+
+	if _, err := Set(Pprof, "", 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !PprofEnabled() {
+		t.Error("expected pprof to be enabled")
+	}
+
+	active := Active()
+	if len(active) != 3 || active[0].Flag != Pprof || active[1].Flag != ProbeTrace || active[2].IP != "10.0.0.1" {
+		t.Errorf("unexpected active flags: %+v", active)
+	}
+
+	if err := Clear(ProbeTrace, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if Traced("10.0.0.2") {
+		t.Error("expected 10.0.0.2 not to be traced after clearing probe_trace")
+	}
+	if err := Clear(Verbose, "10.0.0.1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if Traced("10.0.0.1") {
+		t.Error("expected 10.0.0.1 not to be traced after clearing verbose")
+	}
+}
+
+// TestExpiry validates flags turn themselves off after their TTL
+func TestExpiry(t *testing.T) {
+	reset(t)
+	defer reset(t)
+
+	if _, err := Set(Pprof, "", 20*time.Millisecond); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for PprofEnabled() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if PprofEnabled() {
+		t.Fatal("expected pprof flag to expire")
+	}
+	if len(Active()) != 0 {
+		t.Errorf("expected no active flags, got %+v", Active())
+	}
+}
+
+// TestReSetExtendsExpiry validates re-enabling a flag replaces the old timer
+func TestReSetExtendsExpiry(t *testing.T) {
+	reset(t)
+	defer reset(t)
+
+	if _, err := Set(Pprof, "", 20*time.Millisecond); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := Set(Pprof, "", time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if !PprofEnabled() {
+		t.Error("expected re-set flag to outlive the original ttl")
+	}
+}
+
+// TestDebugRestoresLevel validates the debug flag restores the previous global log level
+func TestDebugRestoresLevel(t *testing.T) {
+	reset(t)
+	defer reset(t)
+
+	original := zerolog.GlobalLevel()
+	defer zerolog.SetGlobalLevel(original)
+	zerolog.SetGlobalLevel(zerolog.WarnLevel)
+
+	if _, err := Set(Debug, "", time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if zerolog.GlobalLevel() != zerolog.DebugLevel {
+		t.Errorf("expected debug level, got %v", zerolog.GlobalLevel())
+	}
+	if err := Clear(Debug, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if zerolog.GlobalLevel() != zerolog.WarnLevel {
+		t.Errorf("expected warn level restored, got %v", zerolog.GlobalLevel())
+	}
+}
+
+// TestSetValidation validates unknown flags, bad IPs and out-of-range TTLs are rejected
+func TestSetValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		flag    string
+		ip      string
+		ttl     time.Duration
+		wantErr string
+	}{
+		{"unknown flag", "tcpdump", "", time.Minute, "unknown flag"},
+		{"verbose without ip", Verbose, "", time.Minute, "valid ip"},
+		{"verbose bad ip", Verbose, "10.0.0.256", time.Minute, "valid ip"},
+		{"negative ttl", Pprof, "", -time.Second, "ttl"},
+		{"ttl too long", Pprof, "", 48 * time.Hour, "ttl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Set(tt.flag, tt.ip, tt.ttl)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+	if len(Active()) != 0 {
+		t.Errorf("expected rejected flags not to be active, got %+v", Active())
+	}
+}
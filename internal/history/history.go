@@ -0,0 +1,227 @@
+package history
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Retention is how much key metrics history is kept
+const Retention = 24 * time.Hour
+
+// SaveInterval is how often callers should persist the ring; at most this much history is lost on a crash
+const SaveInterval = 5 * time.Minute
+
+// maxSamples caps the ring size for very short sampling intervals (one sample per second)
+const maxSamples = int(Retention / time.Second)
+
+// fileMagic identifies a history file and its record layout
+var fileMagic = [4]byte{'N', 'S', 'H', '1'}
+
+// recordSize is the on-disk size of one sample: unix seconds, four uint32 counters and a float32 rate
+const recordSize = 8 + 4 + 4 + 4 + 4 + 4
+
+// Sample is one point of the key metrics history
+type Sample struct {
+	Time        time.Time `json:"time"`
+	DeviceCount int       `json:"device_count"`
+	DevicesUp   int       `json:"devices_up"`
+	PingsPerSec float64   `json:"pings_per_sec"`
+	MemoryMB    int       `json:"memory_mb"`
+	RSSMB       int       `json:"rss_mb"`
+}
+
+// Ring keeps the last 24h of samples in a fixed-size circular buffer, optionally persisted to a file
+// It does not depend on InfluxDB, so trends stay available while the database is unreachable
+type Ring struct {
+	mu       sync.Mutex
+	samples  []Sample // Circular buffer; next is the slot written by the next Add
+	next     int
+	count    int
+	interval time.Duration
+	path     string // Persistence file ("" = in memory only)
+}
+
+// NewRing creates a ring sized for 24h of samples taken every interval
+// When path is set and holds a history file, samples younger than 24h are restored
+// If the file cannot be read the error is returned with an empty ring that still saves to path
+func NewRing(interval time.Duration, path string) (*Ring, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("history interval must be positive, got %v", interval)
+	}
+	size := int(Retention / interval)
+	if size < 1 {
+		size = 1
+	}
+	if size > maxSamples {
+		size = maxSamples
+	}
+	r := &Ring{samples: make([]Sample, size), interval: interval, path: path}
+	if path == "" {
+		return r, nil
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return r, fmt.Errorf("failed to open history file %s: %v", path, err)
+	}
+	defer f.Close()
+
+	loaded, err := decode(bufio.NewReader(f))
+	if err != nil {
+		return r, fmt.Errorf("failed to read history file %s: %v", path, err)
+	}
+	cutoff := time.Now().Add(-Retention)
+	for _, s := range loaded {
+		if s.Time.After(cutoff) {
+			r.add(s)
+		}
+	}
+	return r, nil
+}
+
+// Interval returns the sampling interval the ring was sized for
+func (r *Ring) Interval() time.Duration {
+	return r.interval
+}
+
+// Add appends a sample, overwriting the oldest once the ring is full
+func (r *Ring) Add(s Sample) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.add(s)
+}
+
+// add appends a sample; must be called with r.mu held (or before the ring is shared)
+func (r *Ring) add(s Sample) {
+	r.samples[r.next] = s
+	r.next = (r.next + 1) % len(r.samples)
+	if r.count < len(r.samples) {
+		r.count++
+	}
+}
+
+// Samples returns the samples taken after since, oldest first
+func (r *Ring) Samples(since time.Time) []Sample {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]Sample, 0, r.count)
+	first := (r.next - r.count + len(r.samples)) % len(r.samples)
+	for i := 0; i < r.count; i++ {
+		s := r.samples[(first+i)%len(r.samples)]
+		if s.Time.After(since) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Save writes the ring to its file (no-op without a path)
+// The file is replaced atomically so a crash never leaves a truncated history
+func (r *Ring) Save() error {
+	if r.path == "" {
+		return nil
+	}
+	samples := r.Samples(time.Time{})
+
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), ".netscan-history-*")
+	if err != nil {
+		return fmt.Errorf("failed to save history: %v", err)
+	}
+	w := bufio.NewWriter(tmp)
+	err = encode(w, samples)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to save history: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), r.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to save history: %v", err)
+	}
+	return nil
+}
+
+// encode writes the magic, a sample count and one fixed-size little-endian record per sample
+func encode(w io.Writer, samples []Sample) error {
+	if _, err := w.Write(fileMagic[:]); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(samples))); err != nil {
+		return err
+	}
+	var rec [recordSize]byte
+	for _, s := range samples {
+		binary.LittleEndian.PutUint64(rec[0:], uint64(s.Time.Unix()))
+		binary.LittleEndian.PutUint32(rec[8:], clampUint32(s.DeviceCount))
+		binary.LittleEndian.PutUint32(rec[12:], clampUint32(s.DevicesUp))
+		binary.LittleEndian.PutUint32(rec[16:], math.Float32bits(float32(s.PingsPerSec)))
+		binary.LittleEndian.PutUint32(rec[20:], clampUint32(s.MemoryMB))
+		binary.LittleEndian.PutUint32(rec[24:], clampUint32(s.RSSMB))
+		if _, err := w.Write(rec[:]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decode reads samples written by encode
+func decode(r io.Reader) ([]Sample, error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return nil, err
+	}
+	if magic != fileMagic {
+		return nil, fmt.Errorf("not a netscan history file")
+	}
+	var count uint32
+	if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+		return nil, err
+	}
+	if int(count) > maxSamples {
+		return nil, fmt.Errorf("history file holds %d samples, max %d", count, maxSamples)
+	}
+
+	samples := make([]Sample, 0, count)
+	var rec [recordSize]byte
+	for i := uint32(0); i < count; i++ {
+		if _, err := io.ReadFull(r, rec[:]); err != nil {
+			return nil, err
+		}
+		samples = append(samples, Sample{
+			Time:        time.Unix(int64(binary.LittleEndian.Uint64(rec[0:])), 0),
+			DeviceCount: int(binary.LittleEndian.Uint32(rec[8:])),
+			DevicesUp:   int(binary.LittleEndian.Uint32(rec[12:])),
+			PingsPerSec: float64(math.Float32frombits(binary.LittleEndian.Uint32(rec[16:]))),
+			MemoryMB:    int(binary.LittleEndian.Uint32(rec[20:])),
+			RSSMB:       int(binary.LittleEndian.Uint32(rec[24:])),
+		})
+	}
+	return samples, nil
+}
+
+// clampUint32 converts a non-negative count to uint32, saturating out-of-range values
+func clampUint32(v int) uint32 {
+	if v < 0 {
+		return 0
+	}
+	if v > math.MaxUint32 {
+		return math.MaxUint32
+	}
+	return uint32(v)
+}
@@ -0,0 +1,122 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestRingWrapsAround verifies the ring keeps only the newest samples once full
+func TestRingWrapsAround(t *testing.T) {
+	r, err := NewRing(6*time.Hour, "") // 4 slots
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < 6; i++ {
+		r.Add(Sample{Time: base.Add(time.Duration(i) * time.Minute), DeviceCount: i})
+	}
+
+	samples := r.Samples(time.Time{})
+	if len(samples) != 4 {
+		t.Fatalf("expected 4 samples, got %d", len(samples))
+	}
+	for i, s := range samples {
+		if s.DeviceCount != i+2 {
+			t.Errorf("sample %d: expected device count %d, got %d", i, i+2, s.DeviceCount)
+		}
+	}
+
+	if got := len(r.Samples(base.Add(3 * time.Minute))); got != 2 {
+		t.Errorf("expected 2 samples after the cutoff, got %d", got)
+	}
+}
+
+// TestRingPersistence verifies samples survive a save/load cycle and stale samples are dropped
+func TestRingPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.bin")
+	r, err := NewRing(10*time.Second, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().Truncate(time.Second)
+	r.Add(Sample{Time: now.Add(-25 * time.Hour), DeviceCount: 1}) // Older than the retention
+	r.Add(Sample{Time: now.Add(-time.Minute), DeviceCount: 150, DevicesUp: 140, PingsPerSec: 62.5, MemoryMB: 245, RSSMB: 512})
+	r.Add(Sample{Time: now, DeviceCount: 151, DevicesUp: 141, PingsPerSec: 63.25, MemoryMB: 246, RSSMB: 513})
+	if err := r.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(8 + 3*recordSize); info.Size() != want {
+		t.Errorf("expected a %d byte file, got %d", want, info.Size())
+	}
+
+	restored, err := NewRing(10*time.Second, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := restored.Samples(time.Time{})
+	if len(samples) != 2 {
+		t.Fatalf("expected 2 restored samples, got %d", len(samples))
+	}
+	want := Sample{Time: now, DeviceCount: 151, DevicesUp: 141, PingsPerSec: 63.25, MemoryMB: 246, RSSMB: 513}
+	if got := samples[1]; !got.Time.Equal(want.Time) || got.DeviceCount != want.DeviceCount || got.DevicesUp != want.DevicesUp ||
+		got.PingsPerSec != want.PingsPerSec || got.MemoryMB != want.MemoryMB || got.RSSMB != want.RSSMB {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+// TestRingCorruptFile verifies a bad file is reported but still yields a usable ring
+func TestRingCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.bin")
+	if err := os.WriteFile(path, []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewRing(10*time.Second, path)
+	if err == nil {
+		t.Fatal("expected an error for a corrupt history file")
+	}
+	if r == nil {
+		t.Fatal("expected an empty ring alongside the error")
+	}
+	r.Add(Sample{Time: time.Now()})
+	if err := r.Save(); err != nil {
+		t.Fatalf("ring should still save to its path: %v", err)
+	}
+	if _, err := NewRing(10*time.Second, path); err != nil {
+		t.Errorf("saved file should load cleanly: %v", err)
+	}
+}
+
+// TestRingSizing verifies capacity follows the interval and is capped for tiny intervals
+func TestRingSizing(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		want     int
+	}{
+		{10 * time.Second, 8640},
+		{time.Minute, 1440},
+		{100 * time.Millisecond, maxSamples},
+		{48 * time.Hour, 1},
+	}
+	for _, tt := range tests {
+		r, err := NewRing(tt.interval, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := len(r.samples); got != tt.want {
+			t.Errorf("interval %v: expected %d slots, got %d", tt.interval, tt.want, got)
+		}
+	}
+	if _, err := NewRing(0, ""); err == nil {
+		t.Error("expected error for zero interval")
+	}
+}
@@ -0,0 +1,58 @@
+package influx
+
+import (
+	"fmt"
+	"time"
+
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+)
+
+// Annotation events (the event tag of the annotations measurement)
+const (
+	AnnotationConfigLoaded  = "config_loaded"        // The daemon started with its configuration
+	AnnotationNewDevices    = "new_devices"          // A discovery sweep added devices to monitoring
+	AnnotationScanCompleted = "daily_scan_completed" // The daily SNMP re-scan finished
+	AnnotationDeviceDown    = "device_down"          // A device went down
+)
+
+// annotationSeverity is the severity tag of each event; events not listed are "info"
+var annotationSeverity = map[string]string{
+	AnnotationDeviceDown: "critical",
+}
+
+// WriteAnnotation writes a significant event to the annotations measurement for Grafana dashboard overlays
+// Points are tagged with event and severity, plus the device tags when ip is set and scanner when instance_id is set;
+// title and text are fields
+func (w *Writer) WriteAnnotation(event, ip, title, text string) error {
+	if event == "" {
+		return fmt.Errorf("event is required for annotation")
+	}
+	tags := map[string]string{}
+	if ip != "" {
+		// Validate IP address
+		if err := w.addressPolicy.ValidateIP(ip); err != nil {
+			return fmt.Errorf("invalid IP address for annotation: %v", err)
+		}
+		tags = w.deviceTags(ip)
+	}
+	tags["event"] = event
+	tags["severity"] = "info"
+	if severity, ok := annotationSeverity[event]; ok {
+		tags["severity"] = severity
+	}
+	if w.instanceID != "" {
+		tags["scanner"] = w.instanceID
+	}
+
+	truncated := false
+	fields := map[string]interface{}{
+		"title": w.sanitizeString(title, &truncated),
+		"text":  w.sanitizeString(text, &truncated),
+	}
+	if truncated {
+		fields["truncated"] = true
+	}
+
+	w.addToBatch(influxdb2.NewPoint("annotations", tags, fields, time.Now()))
+	return nil
+}
@@ -0,0 +1,56 @@
+package influx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/influxdb-client-go/v2/api/write"
+)
+
+// TestWriteAnnotation verifies annotations carry the standard tags and the title and text fields
+func TestWriteAnnotation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := &Writer{ctx: ctx, batchChan: make(chan *write.Point, 2)}
+	w.SetInstanceID("scanner-a")
+
+	if err := w.WriteAnnotation(AnnotationDeviceDown, "192.168.1.10", "sw1 down", "192.168.1.10 stopped answering"); err != nil {
+		t.Fatalf("WriteAnnotation failed: %v", err)
+	}
+	if err := w.WriteAnnotation(AnnotationConfigLoaded, "", "netscan started", "config.yml"); err != nil {
+		t.Fatalf("WriteAnnotation failed: %v", err)
+	}
+	if err := w.WriteAnnotation("", "", "untyped", ""); err == nil {
+		t.Error("expected an error for an annotation without event")
+	}
+
+	want := []map[string]string{
+		{"event": "device_down", "severity": "critical", "ip": "192.168.1.10", "scanner": "scanner-a"},
+		{"event": "config_loaded", "severity": "info", "scanner": "scanner-a"},
+	}
+	for _, wantTags := range want {
+		p := <-w.batchChan
+		if p.Name() != "annotations" {
+			t.Errorf("expected annotations measurement, got %s", p.Name())
+		}
+		tags := make(map[string]string)
+		for _, tag := range p.TagList() {
+			tags[tag.Key] = tag.Value
+		}
+		if len(tags) != len(wantTags) {
+			t.Errorf("expected tags %v, got %v", wantTags, tags)
+		}
+		for key, value := range wantTags {
+			if tags[key] != value {
+				t.Errorf("expected tag %s=%s, got %v", key, value, tags)
+			}
+		}
+		fields := make(map[string]interface{})
+		for _, field := range p.FieldList() {
+			fields[field.Key] = field.Value
+		}
+		if fields["title"] == "" || fields["text"] == nil {
+			t.Errorf("expected title and text fields, got %v", fields)
+		}
+	}
+}
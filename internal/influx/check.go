@@ -0,0 +1,77 @@
+package influx
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/kljama/netscan/internal/config"
+)
+
+// CheckTarget verifies that target is healthy and accepts writes to its bucket and health bucket (databases with
+// version 1) with the configured credentials. It sends empty write requests, so nothing is stored
+func CheckTarget(ctx context.Context, target config.InfluxDBTarget) error {
+	var client influxdb2.Client
+	if target.V1() {
+		client = newV1Client(target.URL, target.Username, target.Password)
+	} else {
+		client = influxdb2.NewClient(target.URL, target.Token)
+	}
+	defer client.Close()
+
+	health, err := client.Health(ctx)
+	if err != nil {
+		return fmt.Errorf("health check failed: %v", err)
+	}
+	if health.Status != "pass" {
+		return fmt.Errorf("health status: %s", health.Status)
+	}
+
+	bucket := target.Bucket
+	if target.V1() {
+		bucket = V1Bucket(target.Bucket, target.RetentionPolicy)
+	}
+	if err := checkWrite(ctx, target, bucket); err != nil {
+		return err
+	}
+	return checkWrite(ctx, target, target.HealthBucket)
+}
+
+// checkWrite sends an empty write to bucket: InfluxDB checks the credentials and looks up the bucket
+// (database with version 1) before reading the body
+func checkWrite(ctx context.Context, target config.InfluxDBTarget, bucket string) error {
+	params := url.Values{"org": {target.Org}, "bucket": {bucket}, "precision": {"ns"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(target.URL, "/")+"/api/v2/write?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	var transport http.RoundTripper = http.DefaultTransport
+	if target.V1() {
+		transport = &v1Transport{next: transport, username: target.Username, password: target.Password}
+	} else {
+		req.Header.Set("Authorization", "Token "+target.Token)
+	}
+
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		return fmt.Errorf("write to %q failed: %v", bucket, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	message := strings.TrimSpace(string(body))
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("credentials rejected writing to %q (%d): %s", bucket, resp.StatusCode, message)
+	case http.StatusNotFound:
+		return fmt.Errorf("%q not found (%d): %s", bucket, resp.StatusCode, message)
+	default:
+		return fmt.Errorf("write to %q failed (%d): %s", bucket, resp.StatusCode, message)
+	}
+}
@@ -0,0 +1,81 @@
+package influx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kljama/netscan/internal/config"
+)
+
+// checkServer is a fake InfluxDB that passes health checks, accepts token "good" and knows the listed buckets
+func checkServer(t *testing.T, buckets ...string) *httptest.Server {
+	known := make(map[string]bool)
+	for _, bucket := range buckets {
+		known[bucket] = true
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name":"influxdb","status":"pass"}`))
+		case "/api/v2/write":
+			if r.Header.Get("Authorization") != "Token good" {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"code":"unauthorized","message":"unauthorized access"}`))
+				return
+			}
+			if !known[r.URL.Query().Get("bucket")] {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"code":"not found","message":"bucket not found"}`))
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case "/write":
+			if user, password, _ := r.BasicAuth(); user != "netscan" || password != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if !known[r.URL.Query().Get("db")] {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestCheckTarget verifies credentials and missing buckets are reported for v2 and v1 targets
+func TestCheckTarget(t *testing.T) {
+	server := checkServer(t, "netscan", "health")
+	tests := []struct {
+		name    string
+		target  config.InfluxDBTarget
+		wantErr string
+	}{
+		{"valid", config.InfluxDBTarget{URL: server.URL, Token: "good", Org: "o", Bucket: "netscan", HealthBucket: "health"}, ""},
+		{"bad token", config.InfluxDBTarget{URL: server.URL, Token: "bad", Org: "o", Bucket: "netscan", HealthBucket: "health"}, "credentials rejected"},
+		{"missing bucket", config.InfluxDBTarget{URL: server.URL, Token: "good", Org: "o", Bucket: "metrics", HealthBucket: "health"}, `"metrics" not found`},
+		{"missing health bucket", config.InfluxDBTarget{URL: server.URL, Token: "good", Org: "o", Bucket: "netscan", HealthBucket: "status"}, `"status" not found`},
+		{"v1 valid", config.InfluxDBTarget{Version: config.InfluxDBVersion1, URL: server.URL, Username: "netscan", Password: "secret", Bucket: "netscan", HealthBucket: "health"}, ""},
+		{"v1 bad password", config.InfluxDBTarget{Version: config.InfluxDBVersion1, URL: server.URL, Username: "netscan", Password: "wrong", Bucket: "netscan", HealthBucket: "health"}, "credentials rejected"},
+		{"unreachable", config.InfluxDBTarget{URL: "http://127.0.0.1:1", Token: "good", Bucket: "netscan", HealthBucket: "health"}, "health check failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckTarget(context.Background(), tt.target)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
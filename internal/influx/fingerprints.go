@@ -0,0 +1,75 @@
+package influx
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Fingerprint is a device as another scanner reported it in device_info
+type Fingerprint struct {
+	Scanner  string // Instance ID from the "scanner" tag
+	IP       string
+	Hostname string
+	SysDescr string
+}
+
+// QueryFingerprints returns the latest device_info fingerprints written by other scanners within lookback
+// Points without a "scanner" tag (older releases, or instance_id unset) are ignored
+func (w *Writer) QueryFingerprints(ctx context.Context, lookback time.Duration) ([]Fingerprint, error) {
+	query := buildFingerprintQuery(w.bucket, w.instanceID, lookback)
+
+	result, err := w.client.QueryAPI(w.org).Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("fingerprint query failed: %v", err)
+	}
+	defer result.Close()
+
+	// Each record carries one field; merge hostname and snmp_description per (scanner, ip)
+	type key struct{ scanner, ip string }
+	byDevice := make(map[key]*Fingerprint)
+	var order []key
+	for result.Next() {
+		rec := result.Record()
+		scanner, _ := rec.ValueByKey("scanner").(string)
+		ip, _ := rec.ValueByKey("ip").(string)
+		value, _ := rec.Value().(string)
+		if scanner == "" || ip == "" {
+			continue
+		}
+
+		k := key{scanner, ip}
+		fp, ok := byDevice[k]
+		if !ok {
+			fp = &Fingerprint{Scanner: scanner, IP: ip}
+			byDevice[k] = fp
+			order = append(order, k)
+		}
+		switch rec.Field() {
+		case "hostname":
+			fp.Hostname = value
+		case "snmp_description":
+			fp.SysDescr = value
+		}
+	}
+	if err := result.Err(); err != nil {
+		return nil, fmt.Errorf("fingerprint query failed: %v", err)
+	}
+
+	fingerprints := make([]Fingerprint, 0, len(order))
+	for _, k := range order {
+		fingerprints = append(fingerprints, *byDevice[k])
+	}
+	return fingerprints, nil
+}
+
+// buildFingerprintQuery builds the Flux query selecting other scanners' latest hostname/sysDescr per device
+// bucket and self are validated config values (no quotes), so plain %q quoting is safe
+func buildFingerprintQuery(bucket, self string, lookback time.Duration) string {
+	return fmt.Sprintf(`from(bucket: %q)
+  |> range(start: -%ds)
+  |> filter(fn: (r) => r._measurement == "device_info")
+  |> filter(fn: (r) => r._field == "hostname" or r._field == "snmp_description")
+  |> filter(fn: (r) => exists r.scanner and r.scanner != %q)
+  |> last()`, bucket, int64(lookback/time.Second), self)
+}
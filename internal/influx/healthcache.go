@@ -0,0 +1,104 @@
+package influx
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultHealthCheckInterval is how often HealthCache checks InfluxDB unless configured
+const DefaultHealthCheckInterval = 10 * time.Second
+
+// healthCheckTimeout bounds a single HealthCheck call
+const healthCheckTimeout = 5 * time.Second
+
+// errHealthStale is reported when the background checker has not finished a check in time
+var errHealthStale = errors.New("influxdb health status is stale")
+
+// HealthStatus is the outcome of the latest InfluxDB health check
+type HealthStatus struct {
+	Err       error     // nil when InfluxDB passed the check
+	CheckedAt time.Time // When the check finished (zero before the first check)
+}
+
+// OK reports whether InfluxDB passed the check
+func (s HealthStatus) OK() bool {
+	return s.Err == nil
+}
+
+// HealthCache checks InfluxDB health in the background so health endpoints never wait for it
+// A status older than two intervals plus the check timeout counts as unhealthy (the checker is stuck)
+type HealthCache struct {
+	check    func() error
+	interval time.Duration
+
+	mu     sync.RWMutex
+	status HealthStatus
+}
+
+// NewHealthCache creates a cache refreshed by check every interval (DefaultHealthCheckInterval if <= 0)
+// Until the first Record or check the status is unhealthy
+func NewHealthCache(check func() error, interval time.Duration) *HealthCache {
+	if interval <= 0 {
+		interval = DefaultHealthCheckInterval
+	}
+	return &HealthCache{
+		check:    check,
+		interval: interval,
+		status:   HealthStatus{Err: errors.New("influxdb health not checked yet")},
+	}
+}
+
+// Record stores the result of a check run elsewhere (e.g. the startup connectivity check)
+func (c *HealthCache) Record(err error, at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.status = HealthStatus{Err: err, CheckedAt: at}
+}
+
+// Status returns the latest result without blocking on InfluxDB
+func (c *HealthCache) Status() HealthStatus {
+	c.mu.RLock()
+	status := c.status
+	c.mu.RUnlock()
+
+	if !status.CheckedAt.IsZero() && time.Since(status.CheckedAt) > c.maxAge() {
+		status.Err = errHealthStale
+	}
+	return status
+}
+
+// maxAge is the bounded staleness of a cached status
+func (c *HealthCache) maxAge() time.Duration {
+	return 2*c.interval + healthCheckTimeout
+}
+
+// Run checks InfluxDB every interval until ctx is cancelled; status changes are logged
+func (c *HealthCache) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.refresh()
+		}
+	}
+}
+
+// refresh runs one check and records its result
+func (c *HealthCache) refresh() {
+	err := c.check()
+	previous := c.Status()
+	c.Record(err, time.Now())
+
+	if previous.OK() && err != nil {
+		log.Warn().Err(err).Msg("InfluxDB health check failed")
+	} else if !previous.OK() && !previous.CheckedAt.IsZero() && err == nil {
+		log.Info().Msg("InfluxDB health check passed again")
+	}
+}
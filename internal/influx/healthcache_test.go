@@ -0,0 +1,80 @@
+package influx
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestHealthCacheStatus verifies the cached status follows the background checks without blocking
+func TestHealthCacheStatus(t *testing.T) {
+	var failing atomic.Bool
+	var checks atomic.Int32
+	cache := NewHealthCache(func() error {
+		checks.Add(1)
+		if failing.Load() {
+			return errors.New("connection refused")
+		}
+		return nil
+	}, 10*time.Millisecond)
+
+	if cache.Status().OK() {
+		t.Error("expected unhealthy status before the first check")
+	}
+	cache.Record(nil, time.Now())
+	if !cache.Status().OK() {
+		t.Errorf("expected recorded healthy status, got %v", cache.Status().Err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cache.Run(ctx)
+
+	failing.Store(true)
+	waitFor(t, func() bool { return !cache.Status().OK() })
+	if err := cache.Status().Err; err == nil || err.Error() != "connection refused" {
+		t.Errorf("expected the check error, got %v", err)
+	}
+
+	failing.Store(false)
+	waitFor(t, func() bool { return cache.Status().OK() })
+	if checks.Load() < 2 {
+		t.Errorf("expected repeated background checks, got %d", checks.Load())
+	}
+}
+
+// TestHealthCacheStale verifies an old status counts as unhealthy
+func TestHealthCacheStale(t *testing.T) {
+	cache := NewHealthCache(func() error { return nil }, time.Second)
+	cache.Record(nil, time.Now().Add(-time.Minute))
+	if err := cache.Status().Err; !errors.Is(err, errHealthStale) {
+		t.Errorf("expected stale status, got %v", err)
+	}
+
+	cache.Record(nil, time.Now())
+	if !cache.Status().OK() {
+		t.Error("expected fresh status to be healthy")
+	}
+}
+
+// TestHealthCacheDefaultInterval verifies the default interval for unset configuration
+func TestHealthCacheDefaultInterval(t *testing.T) {
+	cache := NewHealthCache(func() error { return nil }, 0)
+	if cache.interval != DefaultHealthCheckInterval {
+		t.Errorf("expected default interval %v, got %v", DefaultHealthCheckInterval, cache.interval)
+	}
+}
+
+// waitFor polls cond for up to two seconds
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
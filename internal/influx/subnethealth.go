@@ -0,0 +1,58 @@
+package influx
+
+import (
+	"fmt"
+	"time"
+
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// SubnetHealth is the state of the devices of one configured network at a health report
+// Every device is counted in exactly one of Up, Down, Suspended and Pending
+type SubnetHealth struct {
+	CIDR      string // Network as written in networks
+	Network   string // Label of the network, or the CIDR when it has none
+	Devices   int
+	Up        int
+	Down      int
+	Suspended int               // Pinging suspended by the circuit breaker
+	Pending   int               // No ping result yet
+	Pings     state.DailyRollup // Ping cycles of the devices since the previous report
+}
+
+// WriteSubnetHealth writes one subnet_health point tagged with cidr and network, plus scanner when instance_id is set
+// rtt_avg_ms is only written when a device answered and packet_loss_pct when pings were sent during the interval
+func (w *Writer) WriteSubnetHealth(subnet SubnetHealth) error {
+	if subnet.CIDR == "" {
+		return fmt.Errorf("cidr is required for subnet health")
+	}
+	tags := map[string]string{
+		"cidr":    subnet.CIDR,
+		"network": subnet.Network,
+	}
+	if subnet.Network == "" {
+		tags["network"] = subnet.CIDR
+	}
+	if w.instanceID != "" {
+		tags["scanner"] = w.instanceID
+	}
+
+	fields := map[string]interface{}{
+		"devices":           subnet.Devices,
+		"devices_up":        subnet.Up,
+		"devices_down":      subnet.Down,
+		"devices_suspended": subnet.Suspended,
+		"devices_pending":   subnet.Pending,
+		"ping_cycles":       subnet.Pings.Cycles,
+	}
+	if subnet.Pings.PacketsSent > 0 {
+		fields["packet_loss_pct"] = subnet.Pings.PacketLossPct()
+	}
+	if subnet.Pings.UpCycles > 0 {
+		fields["rtt_avg_ms"] = subnet.Pings.AvgRTTMs()
+	}
+
+	w.addToBatch(influxdb2.NewPoint("subnet_health", tags, fields, time.Now()))
+	return nil
+}
@@ -0,0 +1,69 @@
+package influx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/influxdb-client-go/v2/api/write"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// TestWriteSubnetHealth verifies the tags and counts of subnet_health and that RTT and loss need ping cycles
+func TestWriteSubnetHealth(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := &Writer{ctx: ctx, batchChan: make(chan *write.Point, 2)}
+	w.SetInstanceID("scanner-a")
+
+	if err := w.WriteSubnetHealth(SubnetHealth{
+		CIDR: "10.1.0.0/24", Network: "fra1-servers", Devices: 4, Up: 2, Down: 1, Pending: 1,
+		Pings: state.DailyRollup{Cycles: 3, UpCycles: 2, PacketsSent: 6, PacketsRecv: 3, RTTSumMs: 5},
+	}); err != nil {
+		t.Fatalf("WriteSubnetHealth failed: %v", err)
+	}
+	if err := w.WriteSubnetHealth(SubnetHealth{CIDR: "10.2.0.0/24"}); err != nil {
+		t.Fatalf("WriteSubnetHealth failed: %v", err)
+	}
+	if err := w.WriteSubnetHealth(SubnetHealth{}); err == nil {
+		t.Error("expected an error for a subnet without cidr")
+	}
+
+	point := func() (map[string]string, map[string]interface{}) {
+		p := <-w.batchChan
+		if p.Name() != "subnet_health" {
+			t.Errorf("expected subnet_health measurement, got %s", p.Name())
+		}
+		tags := make(map[string]string)
+		for _, tag := range p.TagList() {
+			tags[tag.Key] = tag.Value
+		}
+		fields := make(map[string]interface{})
+		for _, field := range p.FieldList() {
+			fields[field.Key] = field.Value
+		}
+		return tags, fields
+	}
+
+	tags, fields := point()
+	if tags["cidr"] != "10.1.0.0/24" || tags["network"] != "fra1-servers" || tags["scanner"] != "scanner-a" {
+		t.Errorf("unexpected tags %v", tags)
+	}
+	if fields["devices"] != int64(4) || fields["devices_up"] != int64(2) || fields["devices_down"] != int64(1) ||
+		fields["devices_suspended"] != int64(0) || fields["devices_pending"] != int64(1) {
+		t.Errorf("unexpected device counts %v", fields)
+	}
+	if fields["rtt_avg_ms"] != 2.5 || fields["packet_loss_pct"] != 50.0 {
+		t.Errorf("expected 2.5ms average RTT and 50%% loss, got %v", fields)
+	}
+
+	tags, fields = point()
+	if tags["network"] != "10.2.0.0/24" {
+		t.Errorf("expected the CIDR as network tag of an unlabeled network, got %v", tags)
+	}
+	if _, ok := fields["rtt_avg_ms"]; ok {
+		t.Errorf("expected no RTT without ping cycles, got %v", fields)
+	}
+	if _, ok := fields["packet_loss_pct"]; ok {
+		t.Errorf("expected no loss without ping cycles, got %v", fields)
+	}
+}
@@ -0,0 +1,68 @@
+package influx
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+)
+
+// NewV1Writer creates a writer for InfluxDB 1.x: points are sent with the v1 write protocol
+// (/write?db=&rp=) and HTTP basic auth instead of a v2 org, bucket and token
+// An empty username sends no credentials (auth-enabled = false); an empty retentionPolicy uses the database default
+// Health metrics go to healthDatabase with its default retention policy
+func NewV1Writer(serverURL, username, password, database, retentionPolicy, healthDatabase string, batchSize int, flushInterval time.Duration) *Writer {
+	return newWriter(newV1Client(serverURL, username, password), "", V1Bucket(database, retentionPolicy), healthDatabase, batchSize, flushInterval)
+}
+
+// newV1Client creates a client whose requests are sent in v1 form with basic auth (see v1Transport)
+func newV1Client(serverURL, username, password string) influxdb2.Client {
+	options := influxdb2.DefaultOptions()
+	httpClient := options.HTTPClient()
+	httpClient.Transport = &v1Transport{next: httpClient.Transport, username: username, password: password}
+	return influxdb2.NewClientWithOptions(serverURL, "", options)
+}
+
+// V1Bucket returns the database/retention-policy name InfluxDB 1.8 uses in place of a bucket (e.g. in Flux queries)
+func V1Bucket(database, retentionPolicy string) string {
+	if retentionPolicy == "" {
+		return database
+	}
+	return database + "/" + retentionPolicy
+}
+
+// v1Precision maps v2 write precisions to the v1 precision parameter (v1 reads "us" as nanoseconds)
+var v1Precision = map[string]string{"ns": "n", "us": "u", "ms": "ms", "s": "s"}
+
+// v1Transport rewrites the client's v2 write requests to the v1 /write endpoint and replaces the
+// token header with basic auth on every request; health checks and Flux queries keep their path
+type v1Transport struct {
+	next     http.RoundTripper
+	username string
+	password string
+}
+
+// RoundTrip sends req in v1 form
+func (t *v1Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if strings.HasSuffix(req.URL.Path, "/api/v2/write") {
+		query := req.URL.Query()
+		database, retentionPolicy, _ := strings.Cut(query.Get("bucket"), "/")
+		params := url.Values{"db": {database}}
+		if retentionPolicy != "" {
+			params.Set("rp", retentionPolicy)
+		}
+		if precision, ok := v1Precision[query.Get("precision")]; ok {
+			params.Set("precision", precision)
+		}
+		req.URL.Path = strings.TrimSuffix(req.URL.Path, "api/v2/write") + "write"
+		req.URL.RawQuery = params.Encode()
+	}
+	req.Header.Del("Authorization")
+	if t.username != "" {
+		req.SetBasicAuth(t.username, t.password)
+	}
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,48 @@
+package influx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestV1WriterUsesV1Protocol verifies points are sent to /write with database, retention policy and basic auth
+func TestV1WriterUsesV1Protocol(t *testing.T) {
+	var mu sync.Mutex
+	var requests []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	w := NewV1Writer(server.URL, "netscan", "secret", "netscan", "autogen", "health", 100, time.Hour)
+	if err := w.WritePingResult("192.168.1.10", time.Millisecond, true, false); err != nil {
+		t.Fatalf("failed to write point: %v", err)
+	}
+	w.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 write request, got %d", len(requests))
+	}
+	r := requests[0]
+	if r.URL.Path != "/write" {
+		t.Errorf("expected v1 write path, got %s", r.URL.Path)
+	}
+	query := r.URL.Query()
+	if query.Get("db") != "netscan" || query.Get("rp") != "autogen" || query.Get("precision") != "n" || query.Has("bucket") || query.Has("org") {
+		t.Errorf("expected db, rp and precision parameters only, got %s", r.URL.RawQuery)
+	}
+	if user, password, ok := r.BasicAuth(); !ok || user != "netscan" || password != "secret" {
+		t.Errorf("expected basic auth, got %q", r.Header.Get("Authorization"))
+	}
+	if w.bucket != "netscan/autogen" {
+		t.Errorf("expected database/retention policy as bucket, got %q", w.bucket)
+	}
+}
@@ -2,29 +2,43 @@ package influx
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"net"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
 
 	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
 	"github.com/influxdata/influxdb-client-go/v2/api"
+	influxhttp "github.com/influxdata/influxdb-client-go/v2/api/http"
 	"github.com/influxdata/influxdb-client-go/v2/api/write"
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/state"
+	"github.com/kljama/netscan/internal/validate"
 	"github.com/rs/zerolog/log"
 )
 
+// Flush retry defaults: a failed batch is retried up to flushRetries times, waiting a random duration
+// between 0 and min(flushRetryMax, flushRetryBase*2^attempt) before each retry (full jitter)
+const (
+	flushRetries   = 3
+	flushRetryBase = time.Second
+	flushRetryMax  = 30 * time.Second
+)
+
 // Writer handles InfluxDB v2 time-series data writes with batching
 type Writer struct {
-	client         influxdb2.Client // InfluxDB client instance
-	writeAPI       api.WriteAPI     // Non-blocking write API for batching
-	healthWriteAPI api.WriteAPI     // Non-blocking write API for health metrics
-	org            string           // InfluxDB organization name
-	bucket         string           // InfluxDB bucket name
+	client         influxdb2.Client     // InfluxDB client instance
+	writeAPI       api.WriteAPIBlocking // Blocking write API; each batch is one request whose result is known
+	healthWriteAPI api.WriteAPI         // Non-blocking write API for health metrics
+	org            string               // InfluxDB organization name
+	bucket         string               // InfluxDB bucket name
 
-	// Error channels - must be obtained only once from WriteAPI
-	primaryErrorChan <-chan error
-	healthErrorChan  <-chan error
+	// Error channel of the health write API - must be obtained only once
+	healthErrorChan <-chan error
 
 	// Batching fields - using channel for lock-free operation
 	batchChan   chan *write.Point
@@ -33,32 +47,88 @@ type Writer struct {
 	ctx         context.Context
 	cancel      context.CancelFunc
 
+	// Bounds batch writes and retry waits; cancelled by Close at the shutdown deadline, so the final
+	// flush still runs after ctx is cancelled
+	flushCtx    context.Context
+	flushCancel context.CancelFunc
+
+	// Retry policy of failed batch writes (see flushRetries)
+	retries   int
+	retryBase time.Duration
+	retryMax  time.Duration
+
+	// Shutdown: done is closed once the background flusher has drained and written its final batch
+	done            chan struct{}
+	shutdownTimeout time.Duration
+
 	// Metrics tracking with atomic counters
 	successfulBatches atomic.Uint64
 	failedBatches     atomic.Uint64
+	pendingPoints     atomic.Int64  // Points queued or batched but not yet flushed (queue depth)
+	enqueuedPoints    atomic.Uint64 // Points accepted into the batch channel
+	flushedPoints     atomic.Uint64 // Points written to InfluxDB successfully
+	droppedFull       atomic.Uint64 // Points discarded because the batch channel was full
+	droppedShutdown   atomic.Uint64 // Points discarded because they were written after Close or left at the shutdown deadline
+
+	// Target address policy applied to device IPs (strict by default)
+	addressPolicy config.AddressPolicy
+
+	// Scanner identity written as the "scanner" tag on device_info (empty = untagged)
+	instanceID string
+
+	// Resolves VRRP/HSRP virtual IPs to their protocol for the "virtual" tag (nil = untagged)
+	virtualLookup func(ip string) string
+
+	// Resolves a device to its configured network (CIDR or label) for the "network" tag (nil = untagged)
+	networkLookup func(ip string) string
+
+	// Resolves a device to its classified type for the "device_type" tag (nil = untagged)
+	deviceTypeLookup func(ip string) string
+
+	// Reports whether a device is in a maintenance window for the "maintenance" tag (nil = untagged)
+	maintenanceLookup func(ip string) bool
+
+	// Resolves a device to its custom tags from device_tags rules (nil = none)
+	deviceTagsLookup func(ip string) map[string]string
+
+	// Tags added to every device point, e.g. site=<name> for a site's writer (nil = none)
+	staticTags map[string]string
+
+	// Length limit of string fields and tag values (0 = validate.DefaultInfluxMaxLength)
+	maxStringLength int
 }
 
 // NewWriter creates a new InfluxDB writer with batching support
 func NewWriter(url, token, org, bucket, healthBucket string, batchSize int, flushInterval time.Duration) *Writer {
-	client := influxdb2.NewClient(url, token)
-	writeAPI := client.WriteAPI(org, bucket)
+	return newWriter(influxdb2.NewClient(url, token), org, bucket, healthBucket, batchSize, flushInterval)
+}
+
+// newWriter creates a writer on client and starts its background flusher
+func newWriter(client influxdb2.Client, org, bucket, healthBucket string, batchSize int, flushInterval time.Duration) *Writer {
 	healthWriteAPI := client.WriteAPI(org, healthBucket)
 
 	ctx, cancel := context.WithCancel(context.Background())
+	flushCtx, flushCancel := context.WithCancel(context.Background())
 
 	w := &Writer{
-		client:           client,
-		writeAPI:         writeAPI,
-		healthWriteAPI:   healthWriteAPI,
-		org:              org,
-		bucket:           bucket,
-		primaryErrorChan: writeAPI.Errors(),     // Call Errors() only once during initialization
-		healthErrorChan:  healthWriteAPI.Errors(), // Call Errors() only once during initialization
-		batchChan:        make(chan *write.Point, batchSize*2), // Buffered channel for lock-free writes
-		batchSize:        batchSize,
-		flushTicker:      time.NewTicker(flushInterval),
-		ctx:              ctx,
-		cancel:           cancel,
+		client:          client,
+		writeAPI:        client.WriteAPIBlocking(org, bucket),
+		healthWriteAPI:  healthWriteAPI,
+		org:             org,
+		bucket:          bucket,
+		healthErrorChan: healthWriteAPI.Errors(), // Call Errors() only once during initialization
+		batchChan:       make(chan *write.Point, batchSize*2), // Buffered channel for lock-free writes
+		batchSize:       batchSize,
+		flushTicker:     time.NewTicker(flushInterval),
+		ctx:             ctx,
+		cancel:          cancel,
+		flushCtx:        flushCtx,
+		flushCancel:     flushCancel,
+		retries:         flushRetries,
+		retryBase:       flushRetryBase,
+		retryMax:        flushRetryMax,
+		done:            make(chan struct{}),
+		shutdownTimeout: 10 * time.Second,
 	}
 
 	// Start background flusher
@@ -69,6 +139,9 @@ func NewWriter(url, token, org, bucket, healthBucket string, batchSize int, flus
 
 // backgroundFlusher periodically flushes batched points
 func (w *Writer) backgroundFlusher() {
+	// Signal Close that the final flush completed (runs after panic recovery)
+	defer close(w.done)
+
 	// Panic recovery for background goroutine
 	defer func() {
 		if r := recover(); r != nil {
@@ -131,7 +204,8 @@ func (w *Writer) drainAndFlush(currentBatch []*write.Point) {
 	}
 }
 
-// monitorWriteErrors monitors the write API error channel and logs errors
+// monitorWriteErrors monitors the health write API error channel and logs errors
+// Batch write errors are handled by flushWithRetry
 func (w *Writer) monitorWriteErrors() {
 	// Panic recovery for error monitor goroutine
 	defer func() {
@@ -147,13 +221,6 @@ func (w *Writer) monitorWriteErrors() {
 		select {
 		case <-w.ctx.Done():
 			return
-		case err := <-w.primaryErrorChan:
-			if err != nil {
-				log.Error().
-					Err(err).
-					Str("bucket", "primary").
-					Msg("InfluxDB write error detected")
-			}
 		case err := <-w.healthErrorChan:
 			if err != nil {
 				log.Error().
@@ -167,7 +234,7 @@ func (w *Writer) monitorWriteErrors() {
 
 // HealthCheck verifies InfluxDB connectivity
 func (w *Writer) HealthCheck() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
 	defer cancel()
 
 	// Use the health check API
@@ -183,23 +250,305 @@ func (w *Writer) HealthCheck() error {
 	return nil
 }
 
+// SetAddressPolicy relaxes device IP validation (e.g. allow loopback for self-monitoring)
+// Must be called before any writes are issued
+func (w *Writer) SetAddressPolicy(policy config.AddressPolicy) {
+	w.addressPolicy = policy
+}
+
+// SetShutdownTimeout bounds how long Close waits for pending points to be flushed (default 10s)
+func (w *Writer) SetShutdownTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		w.shutdownTimeout = timeout
+	}
+}
+
+// SetInstanceID sets the scanner identity tagged on device_info points for multi-scanner overlap detection
+// Must be called before any writes are issued
+func (w *Writer) SetInstanceID(id string) {
+	w.instanceID = id
+}
+
+// SetVirtualLookup tags ping and device_info points of virtual router addresses with virtual=<protocol>
+// Must be called before any writes are issued
+func (w *Writer) SetVirtualLookup(lookup func(ip string) string) {
+	w.virtualLookup = lookup
+}
+
+// SetNetworkLookup tags ping, device_info and composite_check points with network=<CIDR or label>
+// Must be called before any writes are issued
+func (w *Writer) SetNetworkLookup(lookup func(ip string) string) {
+	w.networkLookup = lookup
+}
+
+// SetDeviceTypeLookup tags ping, device_info and composite_check points with device_type=<type>
+// Must be called before any writes are issued
+func (w *Writer) SetDeviceTypeLookup(lookup func(ip string) string) {
+	w.deviceTypeLookup = lookup
+}
+
+// SetDeviceTagsLookup adds the device's custom tags (device_tags) to ping, device_info and other device points
+// Must be called before any writes are issued
+func (w *Writer) SetDeviceTagsLookup(lookup func(ip string) map[string]string) {
+	w.deviceTagsLookup = lookup
+}
+
+// SetMaintenanceLookup tags device points with maintenance=true while the device is in a maintenance window
+// Must be called before any writes are issued
+func (w *Writer) SetMaintenanceLookup(lookup func(ip string) bool) {
+	w.maintenanceLookup = lookup
+}
+
+// SetStaticTags adds tags to every point except health metrics (used for the site tag and sites.influxdb.tags)
+// Must be called before any writes are issued
+func (w *Writer) SetStaticTags(tags map[string]string) {
+	w.staticTags = tags
+}
+
+// SetMaxStringLength sets the length limit of string fields and tag values; 0 restores the default
+// Must be called before any writes are issued
+func (w *Writer) SetMaxStringLength(n int) {
+	w.maxStringLength = n
+}
+
+// sanitizeString sanitizes and truncates s for a field or tag value, setting *truncated when s was cut
+// (here or earlier during SNMP collection)
+func (w *Writer) sanitizeString(s string, truncated *bool) string {
+	limit := w.maxStringLength
+	if limit <= 0 {
+		limit = validate.DefaultInfluxMaxLength
+	}
+	s, cut := validate.InfluxString(s, limit)
+	if cut {
+		*truncated = true
+	}
+	return s
+}
+
+// deviceTags returns the ip tag plus the network and device_type tags, the virtual tag for VRRP/HSRP virtual addresses,
+// the maintenance tag during maintenance windows and the device's custom tags
+func (w *Writer) deviceTags(ip string) map[string]string {
+	tags := map[string]string{"ip": ip}
+	if w.deviceTagsLookup != nil {
+		truncated := false
+		for key, value := range w.deviceTagsLookup(ip) {
+			tags[key] = w.sanitizeString(value, &truncated)
+		}
+	}
+	if w.networkLookup != nil {
+		if network := w.networkLookup(ip); network != "" {
+			tags["network"] = network
+		}
+	}
+	if w.deviceTypeLookup != nil {
+		if deviceType := w.deviceTypeLookup(ip); deviceType != "" {
+			tags["device_type"] = deviceType
+		}
+	}
+	if w.virtualLookup != nil {
+		if protocol := w.virtualLookup(ip); protocol != "" {
+			tags["virtual"] = protocol
+		}
+	}
+	if w.maintenanceLookup != nil && w.maintenanceLookup(ip) {
+		tags["maintenance"] = "true"
+	}
+	return tags
+}
+
 // WriteDeviceInfo writes device metadata to InfluxDB (call once per device or when SNMP data changes)
 func (w *Writer) WriteDeviceInfo(ip, hostname, sysDescr string) error {
+	return w.WriteDeviceInfoFields(ip, hostname, sysDescr, state.SystemInfo{}, nil)
+}
+
+// WriteDeviceInfoFields writes device metadata, the answered system group values and custom fields derived
+// from snmp.device_fields rules to one device_info point; custom fields cannot override the standard fields
+func (w *Writer) WriteDeviceInfoFields(ip, hostname, sysDescr string, system state.SystemInfo, fields map[string]string) error {
 	// Validate IP address
-	if err := validateIPAddress(ip); err != nil {
+	if err := w.addressPolicy.ValidateIP(ip); err != nil {
 		return fmt.Errorf("invalid IP address for device info: %v", err)
 	}
 
 	// Sanitize string fields to prevent injection or corruption
-	hostname = sanitizeInfluxString(hostname, "hostname")
-	sysDescr = sanitizeInfluxString(sysDescr, "sysDescr")
+	truncated := false
+	hostname = w.sanitizeString(hostname, &truncated)
+	sysDescr = w.sanitizeString(sysDescr, &truncated)
+
+	pointFields := make(map[string]interface{}, len(fields)+7)
+	for name, value := range fields {
+		pointFields[name] = w.sanitizeString(value, &truncated)
+	}
+	pointFields["hostname"] = hostname
+	pointFields["snmp_description"] = sysDescr
+	if system.ObjectID != "" {
+		pointFields["sys_object_id"] = w.sanitizeString(system.ObjectID, &truncated)
+	}
+	if system.UpTime > 0 {
+		pointFields["sys_uptime_s"] = int64(system.UpTime / time.Second)
+	}
+	if system.Location != "" {
+		pointFields["sys_location"] = w.sanitizeString(system.Location, &truncated)
+	}
+	if system.Contact != "" {
+		pointFields["sys_contact"] = w.sanitizeString(system.Contact, &truncated)
+	}
+	if truncated {
+		pointFields["truncated"] = true
+	}
+
+	tags := w.deviceTags(ip)
+	if w.instanceID != "" {
+		tags["scanner"] = w.instanceID
+	}
 
 	p := influxdb2.NewPoint(
 		"device_info",
-		map[string]string{"ip": ip},
+		tags,
+		pointFields,
+		time.Now(),
+	)
+
+	w.addToBatch(p)
+	return nil
+}
+
+// WriteInterfaceMetrics writes a single IF-MIB ifTable row to InfluxDB
+// Octet counters are written raw; rates are derived at query time (e.g. Flux derivative())
+func (w *Writer) WriteInterfaceMetrics(ip string, ifIndex int, ifDescr string, operStatus int, inOctets, outOctets, speed uint64) error {
+	// Validate IP address
+	if err := w.addressPolicy.ValidateIP(ip); err != nil {
+		return fmt.Errorf("invalid IP address for interface metrics: %v", err)
+	}
+
+	// Sanitize interface name since it is used as a tag value
+	truncated := false
+	ifDescr = w.sanitizeString(ifDescr, &truncated)
+
+	fields := map[string]interface{}{
+		"oper_status": operStatus,
+		"in_octets":   inOctets,
+		"out_octets":  outOctets,
+		"speed":       speed,
+	}
+	if truncated {
+		fields["truncated"] = true
+	}
+
+	p := influxdb2.NewPoint(
+		"snmp_interface",
+		map[string]string{
+			"ip":       ip,
+			"if_index": strconv.Itoa(ifIndex),
+			"if_name":  ifDescr,
+		},
+		fields,
+		time.Now(),
+	)
+
+	w.addToBatch(p)
+	return nil
+}
+
+// WriteCustomMetrics writes the values of a custom SNMP OID group as a single point
+// The measurement name comes from the oid_groups config; the group name is written as the oid_group tag
+func (w *Writer) WriteCustomMetrics(ip, measurement, group string, fields map[string]interface{}) error {
+	// Validate IP address
+	if err := w.addressPolicy.ValidateIP(ip); err != nil {
+		return fmt.Errorf("invalid IP address for custom metrics: %v", err)
+	}
+	if measurement == "" {
+		return fmt.Errorf("measurement name is required for custom metrics")
+	}
+	if len(fields) == 0 {
+		return fmt.Errorf("no fields to write for measurement %s", measurement)
+	}
+
+	// Sanitize string values the same way as device_info fields
+	truncated := false
+	sanitized := make(map[string]interface{}, len(fields)+1)
+	for name, value := range fields {
+		if str, ok := value.(string); ok {
+			value = w.sanitizeString(str, &truncated)
+		}
+		sanitized[name] = value
+	}
+	if truncated {
+		sanitized["truncated"] = true
+	}
+
+	p := influxdb2.NewPoint(
+		measurement,
+		map[string]string{
+			"ip":        ip,
+			"oid_group": group,
+		},
+		sanitized,
+		time.Now(),
+	)
+
+	w.addToBatch(p)
+	return nil
+}
+
+// WriteStateChange writes a device up/down transition to the device_state measurement
+// previousDuration is how long the device was in its previous state (0 when it was unknown)
+func (w *Writer) WriteStateChange(ip, hostname, newState, previous string, failures int, previousDuration time.Duration) error {
+	// Validate IP address
+	if err := w.addressPolicy.ValidateIP(ip); err != nil {
+		return fmt.Errorf("invalid IP address for state change: %v", err)
+	}
+	if newState == "" {
+		return fmt.Errorf("state is required for state change")
+	}
+
+	truncated := false
+	fields := map[string]interface{}{
+		"hostname": w.sanitizeString(hostname, &truncated),
+		"previous": previous,
+		"failures": failures,
+	}
+	if truncated {
+		fields["truncated"] = true
+	}
+	if previousDuration > 0 {
+		fields["previous_duration_s"] = previousDuration.Seconds()
+	}
+
+	p := influxdb2.NewPoint(
+		"device_state",
+		map[string]string{
+			"ip":    ip,
+			"state": newState,
+		},
+		fields,
+		time.Now(),
+	)
+
+	w.addToBatch(p)
+	return nil
+}
+
+// WriteCompositeCheck writes one evaluation of a composite check to the composite_check measurement
+// failed lists the conditions that did not hold (empty when all passed)
+func (w *Writer) WriteCompositeCheck(ip, check string, healthy bool, passed, total int, failed []string) error {
+	// Validate IP address
+	if err := w.addressPolicy.ValidateIP(ip); err != nil {
+		return fmt.Errorf("invalid IP address for composite check: %v", err)
+	}
+	if check == "" {
+		return fmt.Errorf("check name is required for composite check")
+	}
+
+	tags := w.deviceTags(ip)
+	tags["check"] = check
+	p := influxdb2.NewPoint(
+		"composite_check",
+		tags,
 		map[string]interface{}{
-			"hostname":         hostname,
-			"snmp_description": sysDescr,
+			"healthy":           healthy,
+			"conditions_passed": passed,
+			"conditions_total":  total,
+			"failed":            strings.Join(failed, ","),
 		},
 		time.Now(),
 	)
@@ -208,13 +557,87 @@ func (w *Writer) WriteDeviceInfo(ip, hostname, sysDescr string) error {
 	return nil
 }
 
+// WriteLatencyAlert writes a latency threshold level change to the latency_alert measurement
+// limit is the exceeded limit (0 when the level is ok); percentile is 0 for per-cycle thresholds
+func (w *Writer) WriteLatencyAlert(ip, hostname, threshold, level, previous string, rtt, limit time.Duration, percentile float64) error {
+	// Validate IP address
+	if err := w.addressPolicy.ValidateIP(ip); err != nil {
+		return fmt.Errorf("invalid IP address for latency alert: %v", err)
+	}
+	if threshold == "" || level == "" {
+		return fmt.Errorf("threshold and level are required for latency alert")
+	}
+
+	truncated := false
+	fields := map[string]interface{}{
+		"hostname": w.sanitizeString(hostname, &truncated),
+		"previous": previous,
+		"rtt_ms":   float64(rtt) / float64(time.Millisecond),
+	}
+	if truncated {
+		fields["truncated"] = true
+	}
+	if limit > 0 {
+		fields["limit_ms"] = float64(limit) / float64(time.Millisecond)
+	}
+	if percentile > 0 {
+		fields["percentile"] = percentile
+	}
+
+	tags := w.deviceTags(ip)
+	tags["threshold"] = threshold
+	tags["level"] = level
+	p := influxdb2.NewPoint("latency_alert", tags, fields, time.Now())
+
+	w.addToBatch(p)
+	return nil
+}
+
+// WriteTracerouteHop writes one hop of a traceroute to the traceroute measurement
+// All hops of a traceroute share its start time at, so they form one table per run; hopIP is "" for a hop
+// that did not answer
+func (w *Writer) WriteTracerouteHop(ip, hostname, trigger, protocol string, at time.Time, ttl int, hopIP string, rtt time.Duration, reached bool) error {
+	// Validate IP address
+	if err := w.addressPolicy.ValidateIP(ip); err != nil {
+		return fmt.Errorf("invalid IP address for traceroute: %v", err)
+	}
+	if ttl < 1 {
+		return fmt.Errorf("invalid traceroute hop %d", ttl)
+	}
+
+	truncated := false
+	fields := map[string]interface{}{
+		"hostname":  w.sanitizeString(hostname, &truncated),
+		"responded": hopIP != "",
+		"reached":   reached,
+	}
+	if hopIP != "" {
+		fields["hop_ip"] = hopIP
+		fields["rtt_ms"] = float64(rtt) / float64(time.Millisecond)
+	}
+	if truncated {
+		fields["truncated"] = true
+	}
+
+	tags := w.deviceTags(ip)
+	tags["hop"] = strconv.Itoa(ttl)
+	tags["trigger"] = trigger
+	tags["protocol"] = protocol
+	p := influxdb2.NewPoint("traceroute", tags, fields, at)
+
+	w.addToBatch(p)
+	return nil
+}
+
 // WriteHealthMetrics writes application health metrics to InfluxDB health bucket
-// Updated to include OS-level RSS in MB (rssMB), suspended device count, and total pings sent.
-func (w *Writer) WriteHealthMetrics(deviceCount, pingerCount, goroutines, memMB, rssMB, suspendedCount int, influxOK bool, influxSuccess, influxFailed, pingsSentTotal uint64) {
+// Updated to include OS-level RSS in MB (rssMB), suspended device count, devices down, and total pings sent.
+// The write queue counters are read from the writer itself.
+func (w *Writer) WriteHealthMetrics(deviceCount, pingerCount, goroutines, memMB, rssMB, suspendedCount, downCount int, influxOK bool, influxSuccess, influxFailed, pingsSentTotal uint64, churn state.Churn) {
 	log.Debug().
 		Int("device_count", deviceCount).
 		Int("active_pingers", pingerCount).
 		Int("suspended_devices", suspendedCount).
+		Int("devices_down", downCount).
 		Int("goroutines", goroutines).
 		Int("memory_mb", memMB).
 		Int("rss_mb", rssMB).
@@ -222,6 +645,8 @@ func (w *Writer) WriteHealthMetrics(deviceCount, pingerCount, goroutines, memMB,
 		Uint64("pings_sent_total", pingsSentTotal).
 		Msg("Writing health metrics to InfluxDB")
 
+	queue := w.GetQueueStats()
+
 	p := influxdb2.NewPoint(
 		"health_metrics",
 		map[string]string{},
@@ -229,6 +654,7 @@ func (w *Writer) WriteHealthMetrics(deviceCount, pingerCount, goroutines, memMB,
 			"device_count":                deviceCount,
 			"active_pingers":              pingerCount,
 			"suspended_devices":           suspendedCount,
+			"devices_down":                downCount,
 			"goroutines":                  goroutines,
 			"memory_mb":                   memMB,
 			"rss_mb":                      rssMB,
@@ -236,6 +662,15 @@ func (w *Writer) WriteHealthMetrics(deviceCount, pingerCount, goroutines, memMB,
 			"influxdb_successful_batches": influxSuccess,
 			"influxdb_failed_batches":     influxFailed,
 			"pings_sent_total":            pingsSentTotal,
+			"influxdb_points_enqueued":    queue.Enqueued,
+			"influxdb_points_flushed":     queue.Flushed,
+			"influxdb_dropped_full":       queue.DroppedFull,
+			"influxdb_dropped_shutdown":   queue.DroppedShutdown,
+			"influxdb_queue_depth":        queue.Depth,
+			"devices_discovered_total":    churn.Discovered,
+			"devices_rediscovered_total":  churn.Rediscovered,
+			"devices_pruned_total":        churn.Pruned,
+			"devices_evicted_total":       churn.Evicted,
 		},
 		time.Now(),
 	)
@@ -248,7 +683,7 @@ func (w *Writer) WriteHealthMetrics(deviceCount, pingerCount, goroutines, memMB,
 // The suspended parameter indicates whether the device is currently suspended by the circuit breaker
 func (w *Writer) WritePingResult(ip string, rtt time.Duration, successful bool, suspended bool) error {
 	// Validate IP address
-	if err := validateIPAddress(ip); err != nil {
+	if err := w.addressPolicy.ValidateIP(ip); err != nil {
 		return fmt.Errorf("invalid IP address for ping result: %v", err)
 	}
 
@@ -262,7 +697,7 @@ func (w *Writer) WritePingResult(ip string, rtt time.Duration, successful bool,
 
 	p := influxdb2.NewPoint(
 		"ping",
-		map[string]string{"ip": ip},
+		w.deviceTags(ip),
 		map[string]interface{}{
 			"rtt_ms":    float64(rtt.Nanoseconds()) / 1e6,
 			"success":   successful,
@@ -275,15 +710,61 @@ func (w *Writer) WritePingResult(ip string, rtt time.Duration, successful bool,
 	return nil
 }
 
+// WritePingStats writes a multi-packet ping cycle: the ping fields plus packet loss, min/avg/max RTT and jitter
+// RTT statistics are only written when at least one reply was received
+func (w *Writer) WritePingStats(ip string, sent, recv int, minRtt, avgRtt, maxRtt, jitter time.Duration) error {
+	// Validate IP address
+	if err := w.addressPolicy.ValidateIP(ip); err != nil {
+		return fmt.Errorf("invalid IP address for ping result: %v", err)
+	}
+
+	// Validate packet counts and RTT values
+	if sent < 1 || recv < 0 || recv > sent {
+		return fmt.Errorf("invalid packet counts: sent=%d recv=%d", sent, recv)
+	}
+	for _, rtt := range []time.Duration{minRtt, avgRtt, maxRtt, jitter} {
+		if rtt < 0 || rtt > time.Minute {
+			return fmt.Errorf("invalid RTT value: %v (must be between 0 and 1 minute)", rtt)
+		}
+	}
+
+	fields := map[string]interface{}{
+		"rtt_ms":          float64(avgRtt.Nanoseconds()) / 1e6,
+		"success":         recv > 0,
+		"suspended":       false,
+		"packets_sent":    sent,
+		"packets_recv":    recv,
+		"packet_loss_pct": float64(sent-recv) / float64(sent) * 100,
+	}
+	if recv > 0 {
+		fields["rtt_min_ms"] = float64(minRtt.Nanoseconds()) / 1e6
+		fields["rtt_max_ms"] = float64(maxRtt.Nanoseconds()) / 1e6
+		fields["jitter_ms"] = float64(jitter.Nanoseconds()) / 1e6
+	}
+
+	p := influxdb2.NewPoint("ping", w.deviceTags(ip), fields, time.Now())
+	w.addToBatch(p)
+	return nil
+}
+
 // addToBatch adds a point to the batch channel (lock-free operation)
 func (w *Writer) addToBatch(point *write.Point) {
+	if w.ctx.Err() != nil {
+		// Writer is closing, drop point
+		w.droppedShutdown.Add(1)
+		return
+	}
+	for key, value := range w.staticTags {
+		point.AddTag(key, value)
+	}
 	select {
 	case w.batchChan <- point:
 		// Point added successfully
-	case <-w.ctx.Done():
-		// Context cancelled, drop point
+		w.enqueuedPoints.Add(1)
+		w.pendingPoints.Add(1)
 	default:
 		// Channel full, log warning but don't block
+		w.droppedFull.Add(1)
 		log.Warn().Msg("Batch channel full, dropping point to avoid blocking")
 	}
 }
@@ -294,59 +775,82 @@ func (w *Writer) flushBatch(points []*write.Point) {
 		return
 	}
 
-	// Write batch to InfluxDB with retry on failure
-	w.flushWithRetry(points, 3)
+	// Write batch to InfluxDB with retry on failure; batches cut off by the shutdown deadline count as dropped
+	if w.flushWithRetry(points) {
+		w.flushedPoints.Add(uint64(len(points)))
+	} else if w.flushCtx.Err() != nil {
+		w.droppedShutdown.Add(uint64(len(points)))
+	}
+	w.pendingPoints.Add(-int64(len(points)))
 }
 
-// flushWithRetry attempts to write points with exponential backoff retry
-func (w *Writer) flushWithRetry(points []*write.Point, maxRetries int) {
-	for attempt := 0; attempt <= maxRetries; attempt++ {
-		// Write all points in the batch
-		for _, point := range points {
-			w.writeAPI.WritePoint(point)
+// flushWithRetry writes points as one request, retrying failures with exponential backoff and full jitter
+// Requests InfluxDB rejected (4xx other than 429) are not retried, as resending the same points cannot succeed
+// Returns true when the points were written; each batch counts once as successful or failed
+func (w *Writer) flushWithRetry(points []*write.Point) bool {
+	for attempt := 0; ; attempt++ {
+		err := w.writeAPI.WritePoint(w.flushCtx, points...)
+		if err == nil {
+			w.successfulBatches.Add(1)
+			log.Debug().
+				Int("points", len(points)).
+				Int("attempts", attempt+1).
+				Msg("Successfully flushed points to InfluxDB")
+			return true
 		}
 
-		// Force a flush to check for immediate errors
-		w.writeAPI.Flush()
+		if attempt >= w.retries || !retryableWriteError(err) || w.flushCtx.Err() != nil {
+			w.failedBatches.Add(1)
+			log.Error().
+				Err(err).
+				Int("points", len(points)).
+				Int("attempts", attempt+1).
+				Msg("InfluxDB write failed, dropping batch")
+			return false
+		}
 
-		// Wait a short time to see if errors appear
-		time.Sleep(100 * time.Millisecond)
+		backoff := w.retryBackoff(attempt)
+		log.Warn().
+			Err(err).
+			Int("attempt", attempt+1).
+			Int("max_retries", w.retries).
+			Dur("backoff", backoff).
+			Msg("InfluxDB write failed, retrying with backoff")
 
-		// Check error channel with timeout using stored channel reference
+		timer := time.NewTimer(backoff)
 		select {
-		case err := <-w.primaryErrorChan:
-			if err != nil {
-				if attempt < maxRetries {
-					backoffDuration := time.Duration(1<<uint(attempt)) * time.Second
-					log.Warn().
-						Err(err).
-						Int("attempt", attempt+1).
-						Int("max_retries", maxRetries).
-						Dur("backoff", backoffDuration).
-						Msg("InfluxDB write failed, retrying with backoff")
-					time.Sleep(backoffDuration)
-					continue
-				} else {
-					// Final failure - increment failed counter
-					w.failedBatches.Add(1)
-					log.Error().
-						Err(err).
-						Int("points", len(points)).
-						Msg("InfluxDB write failed after all retries")
-					return
-				}
-			}
-		default:
-			// No error, write successful - increment success counter
-			w.successfulBatches.Add(1)
-			log.Debug().
-				Int("points", len(points)).
-				Msg("Successfully flushed points to InfluxDB")
-			return
+		case <-timer.C:
+		case <-w.flushCtx.Done():
+			// Shutdown deadline reached while waiting
+			timer.Stop()
+			w.failedBatches.Add(1)
+			return false
 		}
 	}
 }
 
+// retryBackoff returns the full-jitter wait before retry attempt+1: uniform in [0, min(retryMax, retryBase*2^attempt))
+func (w *Writer) retryBackoff(attempt int) time.Duration {
+	ceiling := w.retryMax
+	if attempt < 30 && w.retryBase<<uint(attempt) < ceiling {
+		ceiling = w.retryBase << uint(attempt)
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling)
+}
+
+// retryableWriteError reports whether a failed write may succeed when resent: network errors, timeouts,
+// 429 and 5xx responses
+func retryableWriteError(err error) bool {
+	var httpErr *influxhttp.Error
+	if !errors.As(err, &httpErr) || httpErr.StatusCode == 0 {
+		return true
+	}
+	return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= 500
+}
+
 // GetSuccessfulBatches returns the number of successfully flushed batches
 func (w *Writer) GetSuccessfulBatches() uint64 {
 	return w.successfulBatches.Load()
@@ -357,76 +861,59 @@ func (w *Writer) GetFailedBatches() uint64 {
 	return w.failedBatches.Load()
 }
 
-// Close terminates the InfluxDB client connection
-func (w *Writer) Close() {
-	w.cancel()           // Stop background flusher (which will drain remaining points)
-	w.flushTicker.Stop() // Stop flush ticker
-	time.Sleep(100 * time.Millisecond) // Give background flusher time to finish
-	w.writeAPI.Flush()   // Flush primary write API buffer
-	w.healthWriteAPI.Flush() // Flush health write API buffer
-	w.client.Close()
+// GetDroppedPoints returns the number of points discarded without being written
+func (w *Writer) GetDroppedPoints() uint64 {
+	return w.droppedFull.Load() + w.droppedShutdown.Load()
 }
 
-// validateIPAddress validates IP address format and security constraints
-func validateIPAddress(ipStr string) error {
-	if ipStr == "" {
-		return fmt.Errorf("IP address cannot be empty")
-	}
-
-	ip := net.ParseIP(ipStr)
-	if ip == nil {
-		return fmt.Errorf("invalid IP address format: %s", ipStr)
-	}
-
-	// Security checks - prevent writing data for dangerous addresses
-	if ip.IsLoopback() {
-		return fmt.Errorf("loopback addresses not allowed: %s", ipStr)
-	}
-	if ip.IsMulticast() {
-		return fmt.Errorf("multicast addresses not allowed: %s", ipStr)
-	}
-	if ip.IsLinkLocalUnicast() {
-		return fmt.Errorf("link-local addresses not allowed: %s", ipStr)
-	}
-	if ip.IsUnspecified() {
-		return fmt.Errorf("unspecified addresses not allowed: %s", ipStr)
-	}
-
-	return nil
+// QueueStats is a snapshot of the write queue counters
+type QueueStats struct {
+	Enqueued        uint64 // Points accepted into the batch channel
+	Flushed         uint64 // Points written to InfluxDB successfully
+	DroppedFull     uint64 // Points discarded because the batch channel was full
+	DroppedShutdown uint64 // Points discarded after Close or at the shutdown deadline
+	Depth           int64  // Points queued or batched but not yet flushed
 }
 
-// sanitizeInfluxString sanitizes strings for safe InfluxDB storage
-func sanitizeInfluxString(s, fieldName string) string {
-	if s == "" {
-		return ""
+// GetQueueStats returns the current write queue counters
+func (w *Writer) GetQueueStats() QueueStats {
+	return QueueStats{
+		Enqueued:        w.enqueuedPoints.Load(),
+		Flushed:         w.flushedPoints.Load(),
+		DroppedFull:     w.droppedFull.Load(),
+		DroppedShutdown: w.droppedShutdown.Load(),
+		Depth:           w.pendingPoints.Load(),
 	}
+}
 
-	originalLen := len(s)
+// Close stops accepting points, waits until the background flusher has drained the batch channel
+// and finished its final flush (bounded by the shutdown timeout), then closes the client
+// At the deadline the write or retry in progress is aborted; points still pending are dropped and reported
+func (w *Writer) Close() {
+	w.cancel()           // Stop background flusher (which will drain remaining points)
+	w.flushTicker.Stop() // Stop flush ticker
 
-	// Limit string length to prevent database issues
-	if len(s) > 500 {
-		s = s[:500] + "..."
+	deadline := time.NewTimer(w.shutdownTimeout)
+	defer deadline.Stop()
+	select {
+	case <-w.done:
+	case <-deadline.C:
+		log.Error().
+			Dur("shutdown_timeout", w.shutdownTimeout).
+			Msg("InfluxDB final flush did not complete before the shutdown deadline")
+		// Abort the batch write or retry wait in progress; the flusher then drops what is left and returns
+		w.flushCancel()
+		<-w.done
 	}
-
-	// Remove or replace characters that could cause issues in InfluxDB
-	// InfluxDB field values can contain most characters, but we'll be conservative
-	s = strings.Map(func(r rune) rune {
-		// Remove control characters except tab and newline
-		if r < 32 && r != 9 && r != 10 {
-			return -1
-		}
-		// Allow most printable characters
-		return r
-	}, s)
-
-	// Trim whitespace
-	result := strings.TrimSpace(s)
-
-	// Log if string was significantly modified (for debugging)
-	if len(result) != originalLen {
-		// Could add logging here if needed, but for now just use fieldName to avoid unused parameter warning
-		_ = fieldName
+	w.flushCancel()
+	// Anything still pending was either cut off by the deadline or queued after the drain
+	if pending := w.pendingPoints.Swap(0); pending > 0 {
+		w.droppedShutdown.Add(uint64(pending))
+		log.Error().
+			Int64("dropped_points", pending).
+			Msg("Dropped unflushed points on shutdown")
 	}
 
-	return result
+	w.healthWriteAPI.Flush() // Flush health write API buffer
+	w.client.Close()
 }
@@ -0,0 +1,129 @@
+package influx
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// retryServer answers the first failures write requests with status and accepts the rest, counting
+// requests and accepted points
+func retryServer(t *testing.T, failures int64, status int) (*httptest.Server, *atomic.Int64, *atomic.Int64) {
+	var requests, received atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			if len(scanner.Bytes()) > 0 {
+				received.Add(1)
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests, &received
+}
+
+// TestFlushRetriesTransientErrors verifies a batch failing with 503 is resent as one request per attempt
+// until it succeeds and counted once, with no point written twice
+func TestFlushRetriesTransientErrors(t *testing.T) {
+	server, requests, received := retryServer(t, 2, http.StatusServiceUnavailable)
+	w := NewWriter(server.URL, "test-token", "test-org", "test-bucket", "test-health", 1000, time.Hour)
+	w.retryBase = time.Millisecond
+
+	const points = 50
+	for i := 0; i < points; i++ {
+		w.WritePingResult("192.168.1.10", time.Millisecond, true, false)
+	}
+	w.Close()
+
+	if got := requests.Load(); got != 3 {
+		t.Errorf("expected 3 write requests (2 failed, 1 retry succeeded), got %d", got)
+	}
+	if got := received.Load(); got != points {
+		t.Errorf("expected %d points written once, got %d", points, got)
+	}
+	if w.GetSuccessfulBatches() != 1 || w.GetFailedBatches() != 0 {
+		t.Errorf("expected 1 successful and 0 failed batches, got %d and %d", w.GetSuccessfulBatches(), w.GetFailedBatches())
+	}
+	if stats := w.GetQueueStats(); stats.Flushed != points || stats.Depth != 0 {
+		t.Errorf("expected %d flushed points and an empty queue, got %+v", points, stats)
+	}
+}
+
+// TestFlushDoesNotRetryRejectedBatches verifies a batch InfluxDB rejects with 400 is sent once and counted as failed
+func TestFlushDoesNotRetryRejectedBatches(t *testing.T) {
+	server, requests, _ := retryServer(t, 10, http.StatusBadRequest)
+	w := NewWriter(server.URL, "test-token", "test-org", "test-bucket", "test-health", 1000, time.Hour)
+	w.retryBase = time.Millisecond
+
+	w.WritePingResult("192.168.1.10", time.Millisecond, true, false)
+	w.Close()
+
+	if got := requests.Load(); got != 1 {
+		t.Errorf("expected 1 write request, got %d", got)
+	}
+	if w.GetSuccessfulBatches() != 0 || w.GetFailedBatches() != 1 {
+		t.Errorf("expected 0 successful and 1 failed batch, got %d and %d", w.GetSuccessfulBatches(), w.GetFailedBatches())
+	}
+	if stats := w.GetQueueStats(); stats.Flushed != 0 || stats.Depth != 0 {
+		t.Errorf("expected no flushed points and an empty queue, got %+v", stats)
+	}
+}
+
+// TestFlushGivesUpAfterRetries verifies a persistently failing batch is sent 1+retries times
+func TestFlushGivesUpAfterRetries(t *testing.T) {
+	server, requests, _ := retryServer(t, 100, http.StatusInternalServerError)
+	w := NewWriter(server.URL, "test-token", "test-org", "test-bucket", "test-health", 1000, time.Hour)
+	w.retryBase = time.Millisecond
+
+	w.WritePingResult("192.168.1.10", time.Millisecond, true, false)
+	w.Close()
+
+	if got := requests.Load(); got != flushRetries+1 {
+		t.Errorf("expected %d write requests, got %d", flushRetries+1, got)
+	}
+	if w.GetFailedBatches() != 1 {
+		t.Errorf("expected 1 failed batch, got %d", w.GetFailedBatches())
+	}
+}
+
+// TestCloseAbortsRetryWait verifies Close does not wait out a long backoff past the shutdown deadline
+func TestCloseAbortsRetryWait(t *testing.T) {
+	server, _, _ := retryServer(t, 100, http.StatusServiceUnavailable)
+	w := NewWriter(server.URL, "test-token", "test-org", "test-bucket", "test-health", 1000, time.Hour)
+	w.retryBase = time.Hour
+	w.retryMax = time.Hour
+	w.SetShutdownTimeout(200 * time.Millisecond)
+
+	w.WritePingResult("192.168.1.10", time.Millisecond, true, false)
+	start := time.Now()
+	w.Close()
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected Close to return near the shutdown deadline, took %v", elapsed)
+	}
+	if stats := w.GetQueueStats(); stats.DroppedShutdown != 1 {
+		t.Errorf("expected the unwritten point to be dropped on shutdown, got %+v", stats)
+	}
+}
+
+// TestRetryBackoff verifies backoff stays within the exponential ceiling, capped at retryMax
+func TestRetryBackoff(t *testing.T) {
+	w := &Writer{retryBase: 100 * time.Millisecond, retryMax: time.Second}
+	for attempt, ceiling := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		for i := 0; i < 100; i++ {
+			if got := w.retryBackoff(attempt); got < 0 || got >= ceiling {
+				t.Fatalf("attempt %d: backoff %v outside [0, %v)", attempt, got, ceiling)
+			}
+		}
+	}
+	if got := w.retryBackoff(100); got < 0 || got >= time.Second {
+		t.Errorf("expected a large attempt to be capped, got %v", got)
+	}
+}
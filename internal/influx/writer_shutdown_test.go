@@ -0,0 +1,54 @@
+package influx
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestCloseFlushesPendingPoints verifies Close blocks until every queued point reached InfluxDB
+func TestCloseFlushesPendingPoints(t *testing.T) {
+	var received atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			if len(scanner.Bytes()) > 0 {
+				received.Add(1)
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	// Large batch and long flush interval: nothing is written before Close
+	w := NewWriter(server.URL, "test-token", "test-org", "test-bucket", "test-health", 1000, time.Hour)
+	const points = 250
+	for i := 0; i < points; i++ {
+		if err := w.WritePingResult("192.168.1.10", time.Millisecond, true, false); err != nil {
+			t.Fatalf("failed to write point: %v", err)
+		}
+	}
+	w.Close()
+
+	if got := received.Load(); got != points {
+		t.Errorf("expected %d points written before Close returned, got %d", points, got)
+	}
+	if dropped := w.GetDroppedPoints(); dropped != 0 {
+		t.Errorf("expected no dropped points, got %d", dropped)
+	}
+	if stats := w.GetQueueStats(); stats != (QueueStats{Enqueued: points, Flushed: points}) {
+		t.Errorf("expected %d points enqueued and flushed with an empty queue, got %+v", points, stats)
+	}
+
+	// Points written after Close are dropped and counted
+	w.WritePingResult("192.168.1.10", time.Millisecond, true, false)
+	if dropped := w.GetDroppedPoints(); dropped != 1 {
+		t.Errorf("expected 1 dropped point after Close, got %d", dropped)
+	}
+	if stats := w.GetQueueStats(); stats.DroppedShutdown != 1 || stats.DroppedFull != 0 {
+		t.Errorf("expected the point to count as dropped on shutdown, got %+v", stats)
+	}
+}
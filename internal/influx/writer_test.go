@@ -1,8 +1,13 @@
 package influx
 
 import (
+	"context"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/influxdata/influxdb-client-go/v2/api/write"
+	"github.com/kljama/netscan/internal/state"
 )
 
 type mockWriter struct {
@@ -47,7 +52,146 @@ func TestWriteHealthMetrics(t *testing.T) {
 	
 	// Call WriteHealthMetrics with sample data - should not panic
 	// Args: deviceCount, pingerCount, goroutines, memMB, rssMB, suspendedCount, influxOK, influxSuccess, influxFailed, pingsSentTotal
-	w.WriteHealthMetrics(100, 50, 200, 64, 128, 10, true, 1000, 5, 5000)
+	w.WriteHealthMetrics(100, 50, 200, 64, 128, 10, 3, true, 1000, 5, 5000, state.Churn{Discovered: 120, Pruned: 20})
 	
 	// If we get here without panic, the test passes
 }
+
+// TestBuildFingerprintQuery validates the overlap query excludes this scanner and untagged points
+func TestBuildFingerprintQuery(t *testing.T) {
+	q := buildFingerprintQuery("netscan", "scanner-a", 2*time.Hour)
+	for _, want := range []string{`from(bucket: "netscan")`, `range(start: -7200s)`, `r.scanner != "scanner-a"`, `exists r.scanner`, `last()`} {
+		if !strings.Contains(q, want) {
+			t.Errorf("expected query to contain %q, got:\n%s", want, q)
+		}
+	}
+}
+
+// TestWritePingStatsValidation validates packet counts and RTT bounds are checked before writing
+func TestWritePingStatsValidation(t *testing.T) {
+	w := NewWriter("http://localhost:8086", "test-token", "test-org", "test-bucket", "test-health", 10, 1*time.Second)
+	defer w.Close()
+
+	tests := []struct {
+		name       string
+		sent, recv int
+		rtt        time.Duration
+		wantErr    bool
+	}{
+		{"all replies", 5, 5, 2 * time.Millisecond, false},
+		{"total loss", 5, 0, 0, false},
+		{"no packets sent", 0, 0, 0, true},
+		{"more replies than sent", 3, 4, time.Millisecond, true},
+		{"rtt too high", 3, 3, 2 * time.Minute, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := w.WritePingStats("192.168.1.1", tt.sent, tt.recv, tt.rtt, tt.rtt, tt.rtt, 0)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WritePingStats error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestDeviceTagsNetwork verifies the network tag is added only for devices inside a configured network
+func TestDeviceTagsNetwork(t *testing.T) {
+	w := &Writer{}
+	if tags := w.deviceTags("10.0.0.5"); len(tags) != 1 {
+		t.Errorf("expected only the ip tag without a network lookup, got %v", tags)
+	}
+
+	w.SetNetworkLookup(func(ip string) string {
+		if strings.HasPrefix(ip, "10.") {
+			return "hq"
+		}
+		return ""
+	})
+	if tags := w.deviceTags("10.0.0.5"); tags["network"] != "hq" || tags["ip"] != "10.0.0.5" {
+		t.Errorf("expected network=hq, got %v", tags)
+	}
+	if tags := w.deviceTags("192.168.1.1"); tags["network"] != "" {
+		t.Errorf("expected no network tag outside the networks, got %v", tags)
+	}
+}
+
+// TestDeviceTagsDeviceType verifies classified devices are tagged with device_type and others are not
+func TestDeviceTagsDeviceType(t *testing.T) {
+	w := &Writer{}
+	w.SetDeviceTypeLookup(func(ip string) string {
+		if ip == "10.0.0.9" {
+			return "printer"
+		}
+		return ""
+	})
+	if tags := w.deviceTags("10.0.0.9"); tags["device_type"] != "printer" {
+		t.Errorf("expected device_type=printer, got %v", tags)
+	}
+	if tags := w.deviceTags("10.0.0.10"); len(tags) != 1 {
+		t.Errorf("expected no device_type tag for an unclassified device, got %v", tags)
+	}
+}
+
+// TestDeviceTagsCustom verifies device_tags are added to device points
+func TestDeviceTagsCustom(t *testing.T) {
+	w := &Writer{}
+	w.SetDeviceTagsLookup(func(ip string) map[string]string {
+		if ip == "10.0.0.9" {
+			return map[string]string{"site": "warsaw", "tier": "core"}
+		}
+		return nil
+	})
+	if tags := w.deviceTags("10.0.0.9"); tags["site"] != "warsaw" || tags["tier"] != "core" || tags["ip"] != "10.0.0.9" {
+		t.Errorf("expected site, tier and ip tags, got %v", tags)
+	}
+	if tags := w.deviceTags("10.0.0.10"); len(tags) != 1 {
+		t.Errorf("expected only the ip tag for a device without custom tags, got %v", tags)
+	}
+}
+
+// TestStaticTags verifies static tags reach points that carry no device tags of their own
+func TestStaticTags(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := &Writer{ctx: ctx, batchChan: make(chan *write.Point, 1)}
+	w.SetStaticTags(map[string]string{"site": "fra1", "region": "eu"})
+
+	if err := w.WriteStateChange("192.168.1.10", "sw1", "down", "up", 3, 0); err != nil {
+		t.Fatalf("WriteStateChange failed: %v", err)
+	}
+	tags := make(map[string]string)
+	for _, tag := range (<-w.batchChan).TagList() {
+		tags[tag.Key] = tag.Value
+	}
+	if tags["site"] != "fra1" || tags["region"] != "eu" || tags["ip"] != "192.168.1.10" {
+		t.Errorf("expected site, region and ip tags, got %v", tags)
+	}
+}
+
+// TestDeviceInfoTruncatedField verifies device_info carries truncated=true only when a string was cut
+func TestDeviceInfoTruncatedField(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := &Writer{ctx: ctx, batchChan: make(chan *write.Point, 2)}
+	w.SetMaxStringLength(64)
+
+	if err := w.WriteDeviceInfo("192.168.1.10", "sw1", strings.Repeat("Cisco IOS ", 10)); err != nil {
+		t.Fatalf("WriteDeviceInfo failed: %v", err)
+	}
+	if err := w.WriteDeviceInfo("192.168.1.11", "sw2", "Cisco IOS"); err != nil {
+		t.Fatalf("WriteDeviceInfo failed: %v", err)
+	}
+	for _, want := range []bool{true, false} {
+		fields := make(map[string]interface{})
+		for _, field := range (<-w.batchChan).FieldList() {
+			fields[field.Key] = field.Value
+		}
+		if _, present := fields["truncated"]; present != want {
+			t.Errorf("expected truncated field present=%v, got fields %v", want, fields)
+		}
+		if descr, _ := fields["snmp_description"].(string); len(descr) > 64 {
+			t.Errorf("expected snmp_description of at most 64 bytes, got %d", len(descr))
+		}
+	}
+}
@@ -2,9 +2,13 @@ package influx
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"testing"
 	"time"
+	"unicode/utf8"
+
+	"github.com/kljama/netscan/internal/validate"
 )
 
 // MockWriteAPI simulates InfluxDB WriteAPIBlocking for testing
@@ -52,9 +56,9 @@ func TestValidateIPAddress(t *testing.T) {
 	
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := validateIPAddress(tt.ip)
+			err := validate.IPAddress(tt.ip)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("validateIPAddress(%q) error = %v, wantErr %v", tt.ip, err, tt.wantErr)
+				t.Errorf("IPAddress(%q) error = %v, wantErr %v", tt.ip, err, tt.wantErr)
 			}
 		})
 	}
@@ -72,23 +76,33 @@ func TestSanitizeInfluxString(t *testing.T) {
 		{"Very long string", string(make([]byte, 600)), "..." }, // Should be truncated
 		{"With newlines", "Line1\nLine2", "Line1\nLine2"}, // Newlines are allowed
 		{"With tabs", "Col1\tCol2", "Col1\tCol2"}, // Tabs are allowed
+		{"Unicode", "Büro 東京", "Büro 東京"},
 	}
 	
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := sanitizeInfluxString(tt.input, "test")
+			result := (&Writer{}).sanitizeString(tt.input, new(bool))
 			if tt.name == "Very long string" {
 				// Check it's truncated and has "..."
 				if len(result) > 503 {
 					t.Errorf("String not properly truncated, got length %d", len(result))
 				}
 			} else if result != tt.expected {
-				t.Errorf("sanitizeInfluxString(%q) = %q, want %q", tt.input, result, tt.expected)
+				t.Errorf("sanitizeString(%q) = %q, want %q", tt.input, result, tt.expected)
 			}
 		})
 	}
 }
 
+// TestSanitizeInfluxStringTruncatesOnRuneBoundary verifies truncation never splits a multi-byte character
+func TestSanitizeInfluxStringTruncatesOnRuneBoundary(t *testing.T) {
+	truncated := false
+	result := (&Writer{}).sanitizeString("a"+strings.Repeat("ü", 300), &truncated)
+	if !utf8.ValidString(result) || !truncated {
+		t.Errorf("expected a valid truncated string, got %q (truncated=%v)", result, truncated)
+	}
+}
+
 // TestWritePingResultValidation tests validation in WritePingResult
 func TestWritePingResultValidation(t *testing.T) {
 	// Note: This test validates the validation logic, not actual writes
@@ -109,7 +123,7 @@ func TestWritePingResultValidation(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// First validate IP
-			ipErr := validateIPAddress(tt.ip)
+			ipErr := validate.IPAddress(tt.ip)
 			
 			// Then validate RTT
 			var rttErr error
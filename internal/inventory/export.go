@@ -0,0 +1,168 @@
+package inventory
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// Device statuses of an inventory export
+const (
+	DeviceUp        = "up"        // Answering pings
+	DeviceDown      = "down"      // Stopped answering pings (device_down_after)
+	DeviceSuspended = "suspended" // Pinging suspended by the circuit breaker
+	DevicePending   = "pending"   // No ping result yet
+)
+
+// ExportedDevice is one device of an inventory export
+type ExportedDevice struct {
+	IP         string    `json:"ip"`
+	Hostname   string    `json:"hostname"`
+	SysDescr   string    `json:"sys_descr"`
+	DeviceType string    `json:"device_type"`
+	Network    string    `json:"network"`
+	OSFamily   string    `json:"os_family"`
+	MAC        string    `json:"mac"`
+	LastSeen   time.Time `json:"last_seen"`
+	Status     string    `json:"status"` // up, down, suspended or pending
+}
+
+// Export is a snapshot of the monitored devices
+type Export struct {
+	Generated time.Time        `json:"generated"`
+	Devices   []ExportedDevice `json:"devices"` // Sorted by IP
+}
+
+// NewExport builds the snapshot of devices at now
+func NewExport(devices []state.Device, now time.Time) Export {
+	export := Export{Generated: now, Devices: make([]ExportedDevice, 0, len(devices))}
+	for _, dev := range devices {
+		export.Devices = append(export.Devices, ExportedDevice{
+			IP:         dev.IP,
+			Hostname:   dev.Hostname,
+			SysDescr:   dev.SysDescr,
+			DeviceType: dev.DeviceType,
+			Network:    dev.Network,
+			OSFamily:   dev.OSFamily,
+			MAC:        dev.MAC,
+			LastSeen:   dev.LastSeen,
+			Status:     deviceStatus(dev, now),
+		})
+	}
+	sort.Slice(export.Devices, func(i, j int) bool { return compareIPs(export.Devices[i].IP, export.Devices[j].IP) < 0 })
+	return export
+}
+
+// deviceStatus returns the status of a device; a suspended device counts as suspended whatever its reachability
+func deviceStatus(dev state.Device, now time.Time) string {
+	switch {
+	case dev.SuspendedUntil.After(now):
+		return DeviceSuspended
+	case dev.Reachability == state.ReachabilityUp:
+		return DeviceUp
+	case dev.Reachability == state.ReachabilityDown:
+		return DeviceDown
+	}
+	return DevicePending
+}
+
+// WriteCSV writes the devices as CSV with a header row; last_seen is RFC3339 in UTC (empty when never seen)
+func (e *Export) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"ip", "hostname", "sys_descr", "device_type", "network", "os_family", "mac", "last_seen", "status"})
+	for _, d := range e.Devices {
+		lastSeen := ""
+		if !d.LastSeen.IsZero() {
+			lastSeen = d.LastSeen.UTC().Format(time.RFC3339)
+		}
+		writer.Write([]string{d.IP, d.Hostname, d.SysDescr, d.DeviceType, d.Network, d.OSFamily, d.MAC, lastSeen, d.Status})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON writes the snapshot as one JSON object
+func (e *Export) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(e)
+}
+
+// exportPrefix starts the name of every snapshot file; the rest is the UTC timestamp and the format
+const exportPrefix = "inventory-"
+
+// Exporter writes timestamped inventory snapshots to a directory and deletes the oldest beyond the retention
+type Exporter struct {
+	cfg    config.InventoryExportConfig
+	source DeviceSource
+}
+
+// NewExporter creates an exporter of source's devices
+func NewExporter(cfg config.InventoryExportConfig, source DeviceSource) *Exporter {
+	return &Exporter{cfg: cfg, source: source}
+}
+
+// Run writes one snapshot and prunes old ones; returns the snapshot's path and device count
+// The file is written under a temporary name and renamed, so readers never see a partial snapshot
+func (e *Exporter) Run(now time.Time) (string, int, error) {
+	if err := os.MkdirAll(e.cfg.Dir, 0o755); err != nil {
+		return "", 0, fmt.Errorf("failed to create export directory: %v", err)
+	}
+	export := NewExport(e.source.GetAll(), now)
+	path := filepath.Join(e.cfg.Dir, exportPrefix+now.UTC().Format("20060102-150405")+"."+e.cfg.Format)
+
+	tmp, err := os.CreateTemp(e.cfg.Dir, ".inventory-*.tmp")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(tmp.Name()) // No-op after the rename
+	tmp.Chmod(0o644)
+	if e.cfg.Format == config.InventoryExportJSON {
+		err = export.WriteJSON(tmp)
+	} else {
+		err = export.WriteCSV(tmp)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to write inventory snapshot: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", 0, err
+	}
+	return path, len(export.Devices), e.prune()
+}
+
+// prune deletes the oldest snapshots beyond keep; names sort by time, so the oldest come first
+func (e *Exporter) prune() error {
+	if e.cfg.Keep == 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(e.cfg.Dir)
+	if err != nil {
+		return err
+	}
+	var snapshots []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, exportPrefix) && (strings.HasSuffix(name, ".csv") || strings.HasSuffix(name, ".json")) {
+			snapshots = append(snapshots, name)
+		}
+	}
+	sort.Strings(snapshots)
+	for len(snapshots) > e.cfg.Keep {
+		if err := os.Remove(filepath.Join(e.cfg.Dir, snapshots[0])); err != nil {
+			return fmt.Errorf("failed to delete old inventory snapshot: %v", err)
+		}
+		snapshots = snapshots[1:]
+	}
+	return nil
+}
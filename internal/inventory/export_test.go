@@ -0,0 +1,57 @@
+package inventory
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// staticSource is a DeviceSource with a fixed device list
+type staticSource []state.Device
+
+func (s staticSource) GetAll() []state.Device { return s }
+
+// TestExporter validates snapshot files, their content and pruning beyond keep
+func TestExporter(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "exports")
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	source := staticSource{
+		{IP: "10.0.0.2", Hostname: "ap", SuspendedUntil: now.Add(24 * time.Hour), Reachability: state.ReachabilityUp},
+		{IP: "10.0.0.1", Hostname: "router"},
+	}
+	exporter := NewExporter(config.InventoryExportConfig{Dir: dir, Format: config.InventoryExportJSON, Keep: 2}, source)
+
+	for i := 0; i < 3; i++ {
+		path, devices, err := exporter.Run(now.Add(time.Duration(i) * time.Hour))
+		if err != nil || devices != 2 {
+			t.Fatalf("export %d failed: %v (%d devices)", i, err, devices)
+		}
+		if i == 0 && filepath.Base(path) != "inventory-20240501-120000.json" {
+			t.Errorf("unexpected snapshot name %s", path)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Name() != "inventory-20240501-130000.json" {
+		t.Fatalf("expected the 2 newest snapshots, got %v", entries)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, entries[1].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var export Export
+	if err := json.Unmarshal(data, &export); err != nil {
+		t.Fatal(err)
+	}
+	if export.Devices[0].IP != "10.0.0.1" || export.Devices[0].Status != DevicePending || export.Devices[1].Status != DeviceSuspended {
+		t.Errorf("unexpected snapshot devices: %+v", export.Devices)
+	}
+}
@@ -0,0 +1,285 @@
+// Package inventory reconciles monitored devices against an expected device list exported from a CMDB
+package inventory
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kljama/netscan/internal/monitoring"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// Reconciliation entry statuses
+const (
+	StatusMissing    = "missing"    // Expected device that is not monitored
+	StatusUnexpected = "unexpected" // Monitored device that is not in the expected list
+	StatusMismatch   = "mismatch"   // Expected and monitored, but an attribute differs
+)
+
+// Built-in attribute columns of the expected devices file; other columns are compared against snmp.device_fields
+const (
+	AttrHostname = "hostname"
+	AttrSysDescr = "sys_descr"
+)
+
+// ExpectedDevice is one row of the expected devices file
+type ExpectedDevice struct {
+	IP         string
+	Attributes map[string]string // Column name -> expected value (empty cells are not compared)
+}
+
+// Entry is one finding of a reconciliation report
+type Entry struct {
+	Status    string `json:"status"`              // missing, unexpected or mismatch
+	IP        string `json:"ip"`                  // Device IP
+	Hostname  string `json:"hostname,omitempty"`  // Monitored hostname, or the expected one for missing entries
+	Attribute string `json:"attribute,omitempty"` // Differing attribute (mismatch entries)
+	Expected  string `json:"expected,omitempty"`  // Value from the expected devices file
+	Actual    string `json:"actual,omitempty"`    // Value reported by the device
+}
+
+// Summary counts the devices behind a report
+type Summary struct {
+	Expected   int `json:"expected"`   // Devices in the expected devices file
+	Monitored  int `json:"monitored"`  // Devices currently monitored
+	Matched    int `json:"matched"`    // Expected and monitored with no attribute mismatch
+	Missing    int `json:"missing"`    // Expected but not monitored
+	Unexpected int `json:"unexpected"` // Monitored but not expected
+	Mismatched int `json:"mismatched"` // Expected and monitored with at least one attribute mismatch
+}
+
+// Report is the result of one reconciliation run
+type Report struct {
+	Generated time.Time `json:"generated"`
+	Source    string    `json:"source"` // Expected devices file
+	Summary   Summary   `json:"summary"`
+	Entries   []Entry   `json:"entries"` // Sorted by status, then IP
+}
+
+// LoadExpected reads an expected devices CSV file
+// The header row must contain an "ip" column; every other column is an attribute to compare
+func LoadExpected(path string) ([]ExpectedDevice, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseExpected(f)
+}
+
+// parseExpected parses expected devices CSV data
+func parseExpected(r io.Reader) ([]ExpectedDevice, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %v", err)
+	}
+	ipColumn := -1
+	for i, name := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(name))
+		if header[i] == "ip" {
+			ipColumn = i
+		}
+	}
+	if ipColumn < 0 {
+		return nil, fmt.Errorf("header has no ip column")
+	}
+
+	var devices []ExpectedDevice
+	seen := make(map[string]bool)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(ipColumn)
+		ip := net.ParseIP(strings.TrimSpace(record[ipColumn]))
+		if ip == nil {
+			return nil, fmt.Errorf("line %d: invalid ip %q", line, record[ipColumn])
+		}
+		if seen[ip.String()] {
+			return nil, fmt.Errorf("line %d: duplicate ip %s", line, ip)
+		}
+		seen[ip.String()] = true
+
+		device := ExpectedDevice{IP: ip.String(), Attributes: make(map[string]string)}
+		for i, value := range record {
+			if i == ipColumn || header[i] == "" {
+				continue
+			}
+			if value = strings.TrimSpace(value); value != "" {
+				device.Attributes[header[i]] = value
+			}
+		}
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
+
+// Reconcile compares the expected devices with the monitored devices
+// Attributes a device has not reported (e.g. no SNMP answer yet) are not counted as mismatches
+func Reconcile(expected []ExpectedDevice, devices []state.Device, now time.Time) Report {
+	report := Report{Generated: now, Entries: []Entry{}}
+	report.Summary.Expected = len(expected)
+	report.Summary.Monitored = len(devices)
+
+	monitored := make(map[string]state.Device, len(devices))
+	for _, dev := range devices {
+		monitored[dev.IP] = dev
+	}
+
+	expectedIPs := make(map[string]bool, len(expected))
+	for _, exp := range expected {
+		expectedIPs[exp.IP] = true
+		dev, ok := monitored[exp.IP]
+		if !ok {
+			report.Entries = append(report.Entries, Entry{Status: StatusMissing, IP: exp.IP, Hostname: exp.Attributes[AttrHostname]})
+			report.Summary.Missing++
+			continue
+		}
+
+		actual := deviceAttributes(dev)
+		mismatched := false
+		for _, attr := range sortedKeys(exp.Attributes) {
+			value, reported := actual[attr]
+			if !reported || value == "" || strings.EqualFold(value, exp.Attributes[attr]) {
+				continue
+			}
+			report.Entries = append(report.Entries, Entry{
+				Status:    StatusMismatch,
+				IP:        dev.IP,
+				Hostname:  dev.Hostname,
+				Attribute: attr,
+				Expected:  exp.Attributes[attr],
+				Actual:    value,
+			})
+			mismatched = true
+		}
+		if mismatched {
+			report.Summary.Mismatched++
+		} else {
+			report.Summary.Matched++
+		}
+	}
+
+	for _, dev := range devices {
+		if !expectedIPs[dev.IP] {
+			report.Entries = append(report.Entries, Entry{Status: StatusUnexpected, IP: dev.IP, Hostname: dev.Hostname})
+			report.Summary.Unexpected++
+		}
+	}
+
+	sort.SliceStable(report.Entries, func(i, j int) bool {
+		a, b := report.Entries[i], report.Entries[j]
+		if a.Status != b.Status {
+			return a.Status < b.Status
+		}
+		return compareIPs(a.IP, b.IP) < 0
+	})
+	return report
+}
+
+// deviceAttributes returns the attributes a monitored device reports, keyed like the expected devices file
+func deviceAttributes(dev state.Device) map[string]string {
+	attrs := make(map[string]string)
+	if dev.Hostname != "" && dev.Hostname != dev.IP {
+		attrs[AttrHostname] = dev.Hostname
+	}
+	if dev.SysDescr != "" {
+		attrs[AttrSysDescr] = dev.SysDescr
+	}
+	if dev.SNMPResult != nil {
+		for _, v := range dev.SNMPResult.Values {
+			if v.Group == monitoring.SNMPGroupDeviceFields {
+				attrs[strings.ToLower(v.Name)] = fmt.Sprint(v.Value)
+			}
+		}
+	}
+	return attrs
+}
+
+// WriteCSV writes the report entries as CSV with a header row
+func (r *Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"status", "ip", "hostname", "attribute", "expected", "actual"})
+	for _, e := range r.Entries {
+		writer.Write([]string{e.Status, e.IP, e.Hostname, e.Attribute, e.Expected, e.Actual})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// DeviceSource provides the monitored devices (implemented by state.Manager)
+type DeviceSource interface {
+	GetAll() []state.Device
+}
+
+// Reconciler periodically reconciles a device source against an expected devices file
+// The file is re-read on every run, so a fresh CMDB export is picked up without a restart
+type Reconciler struct {
+	path   string
+	source DeviceSource
+
+	mu   sync.RWMutex
+	last *Report // Latest report (nil until the first successful run)
+}
+
+// NewReconciler creates a reconciler for an expected devices file
+func NewReconciler(path string, source DeviceSource) *Reconciler {
+	return &Reconciler{path: path, source: source}
+}
+
+// Run reads the expected devices file and produces a new report
+// On error the previous report is kept
+func (r *Reconciler) Run() (*Report, error) {
+	expected, err := LoadExpected(r.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load expected devices from %s: %v", r.path, err)
+	}
+	report := Reconcile(expected, r.source.GetAll(), time.Now())
+	report.Source = r.path
+
+	r.mu.Lock()
+	r.last = &report
+	r.mu.Unlock()
+	return &report, nil
+}
+
+// Latest returns the most recent report, or nil if none was produced yet
+func (r *Reconciler) Latest() *Report {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.last
+}
+
+// sortedKeys returns the keys of m in ascending order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// compareIPs orders IP addresses numerically, falling back to text for unparsable values
+func compareIPs(a, b string) int {
+	ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+	if ipA == nil || ipB == nil {
+		return strings.Compare(a, b)
+	}
+	return strings.Compare(string(ipA.To16()), string(ipB.To16()))
+}
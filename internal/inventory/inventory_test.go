@@ -0,0 +1,93 @@
+package inventory
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/state"
+)
+
+const expectedCSV = `# CMDB export
+ip,hostname,sys_descr,vendor
+192.168.1.1,core-router,,Cisco
+192.168.1.2,access-switch,,
+192.168.1.3,printer-2f,,
+`
+
+// TestParseExpected validates header handling and rejection of bad rows
+func TestParseExpected(t *testing.T) {
+	devices, err := parseExpected(strings.NewReader(expectedCSV))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	if len(devices) != 3 {
+		t.Fatalf("expected 3 devices, got %d", len(devices))
+	}
+	router := devices[0]
+	if router.IP != "192.168.1.1" || router.Attributes["hostname"] != "core-router" || router.Attributes["vendor"] != "Cisco" {
+		t.Errorf("unexpected first device: %+v", router)
+	}
+	if _, ok := router.Attributes["sys_descr"]; ok {
+		t.Error("empty cells must not become attributes")
+	}
+
+	invalid := map[string]string{
+		"no ip column": "hostname\nrouter\n",
+		"bad ip":       "ip\n192.168.1.300\n",
+		"duplicate ip": "ip\n10.0.0.1\n10.0.0.1\n",
+	}
+	for name, data := range invalid {
+		if _, err := parseExpected(strings.NewReader(data)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+// TestReconcile validates missing, unexpected and mismatch detection
+func TestReconcile(t *testing.T) {
+	expected, err := parseExpected(strings.NewReader(expectedCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	devices := []state.Device{
+		// Hostname differs; vendor matches case-insensitively via device_fields
+		{IP: "192.168.1.1", Hostname: "edge-router", SNMPResult: &state.SNMPResult{Values: []state.SNMPValue{
+			{Group: "device_fields", Name: "vendor", Value: "cisco"},
+		}}},
+		// No SNMP answer yet: hostname is the IP and is not compared
+		{IP: "192.168.1.2", Hostname: "192.168.1.2"},
+		// Not in the expected list
+		{IP: "192.168.1.50", Hostname: "rogue-ap"},
+	}
+
+	report := Reconcile(expected, devices, time.Now())
+	want := Summary{Expected: 3, Monitored: 3, Matched: 1, Missing: 1, Unexpected: 1, Mismatched: 1}
+	if report.Summary != want {
+		t.Errorf("expected summary %+v, got %+v", want, report.Summary)
+	}
+
+	wantEntries := []Entry{
+		{Status: StatusMismatch, IP: "192.168.1.1", Hostname: "edge-router", Attribute: "hostname", Expected: "core-router", Actual: "edge-router"},
+		{Status: StatusMissing, IP: "192.168.1.3", Hostname: "printer-2f"},
+		{Status: StatusUnexpected, IP: "192.168.1.50", Hostname: "rogue-ap"},
+	}
+	if len(report.Entries) != len(wantEntries) {
+		t.Fatalf("expected %d entries, got %+v", len(wantEntries), report.Entries)
+	}
+	for i, e := range wantEntries {
+		if report.Entries[i] != e {
+			t.Errorf("entry %d: expected %+v, got %+v", i, e, report.Entries[i])
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || lines[0] != "status,ip,hostname,attribute,expected,actual" || lines[2] != "missing,192.168.1.3,printer-2f,,," {
+		t.Errorf("unexpected CSV:\n%s", buf.String())
+	}
+}
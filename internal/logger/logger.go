@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
@@ -14,27 +16,109 @@ import (
 // - if ENVIRONMENT=development we use a human-friendly console writer
 // - Caller() is enabled so debug lines include file:line (helps track false positives)
 func Setup(debugMode bool) {
-	// Human-friendly console output for local development
-	if os.Getenv("ENVIRONMENT") == "development" {
-		log.Logger = log.Output(zerolog.ConsoleWriter{
-			Out:        os.Stdout,
-			TimeFormat: time.RFC3339,
-		})
+	setup(debugMode, os.Stdout)
+}
+
+// SetupStderr initializes the global logger like Setup but never writes to stdout
+// Used when stdout carries machine-readable output (e.g. --output - for NDJSON streaming)
+func SetupStderr(debugMode bool) {
+	setup(debugMode, os.Stderr)
+}
+
+// Log formats accepted by Options.Format
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+// Options configures the global logger from command-line flags
+type Options struct {
+	Level  string // debug, info, warn or error ("" = info; DEBUG=true forces debug)
+	Format string // json or console ("" = console when ENVIRONMENT=development, otherwise json)
+	Stderr bool   // Never write to stdout (stdout carries machine-readable output)
+}
+
+// Configure initializes the global logger from flag values
+func Configure(opts Options) error {
+	level := zerolog.InfoLevel
+	if opts.Level != "" {
+		parsed, err := ParseLevel(opts.Level)
+		if err != nil {
+			return err
+		}
+		level = parsed
+	}
+	if strings.EqualFold(os.Getenv("DEBUG"), "true") {
+		level = zerolog.DebugLevel
+	}
+
+	console := os.Getenv("ENVIRONMENT") == "development"
+	switch opts.Format {
+	case "":
+	case FormatJSON:
+		console = false
+	case FormatConsole:
+		console = true
+	default:
+		return fmt.Errorf("unsupported log format %q (json, console)", opts.Format)
 	}
 
+	consoleOut := io.Writer(os.Stdout)
+	if opts.Stderr {
+		consoleOut = os.Stderr
+	}
+	configure(level, console, consoleOut)
+	return nil
+}
+
+// setup configures the global logger; consoleOut is the destination for development console output
+// (JSON logs always go to zerolog's default stderr writer)
+func setup(debugMode bool, consoleOut io.Writer) {
 	// allow enabling debug via env var DEBUG=true as a quick toggle
+	level := zerolog.InfoLevel
 	if debugMode || strings.EqualFold(os.Getenv("DEBUG"), "true") {
-		zerolog.SetGlobalLevel(zerolog.DebugLevel)
-	} else {
-		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+		level = zerolog.DebugLevel
+	}
+	configure(level, os.Getenv("ENVIRONMENT") == "development", consoleOut)
+}
+
+// configure installs the output format, base level and module level filter on the global logger
+func configure(level zerolog.Level, console bool, consoleOut io.Writer) {
+	// Human-friendly console output for local development
+	output := io.Writer(os.Stderr)
+	if console {
+		output = zerolog.ConsoleWriter{
+			Out:        consoleOut,
+			TimeFormat: time.RFC3339,
+		}
 	}
 
+	baseLevel.Store(int32(level))
+	applyGlobalLevel()
+
 	// Add common fields and caller information to help trace where logs originate
-	log.Logger = log.With().
+	log.Logger = zerolog.New(output).With().
 		Str("service", "netscan").
 		Timestamp().
 		Caller().
-		Logger()
+		Logger().
+		Hook(moduleHook{})
+}
+
+// ParseLevel parses a log level name: debug, info, warn or error
+func ParseLevel(name string) (zerolog.Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return zerolog.DebugLevel, nil
+	case "info":
+		return zerolog.InfoLevel, nil
+	case "warn", "warning":
+		return zerolog.WarnLevel, nil
+	case "error":
+		return zerolog.ErrorLevel, nil
+	default:
+		return zerolog.NoLevel, fmt.Errorf("unsupported log level %q (debug, info, warn, error)", name)
+	}
 }
 
 // Get returns a logger with context
@@ -0,0 +1,150 @@
+package logger
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// Modules are the names accepted for per-module log levels: the internal packages plus main
+var Modules = []string{
+	"checks", "config", "discovery", "events", "history", "influx", "inventory",
+	"main", "monitoring", "notify", "output", "state",
+}
+
+var (
+	// baseLevel is the level of modules without an override (set by Setup/Configure)
+	baseLevel atomic.Int32
+
+	// moduleLevels holds per-module overrides (nil = none, no filtering)
+	moduleLevels atomic.Pointer[map[string]zerolog.Level]
+
+	// debugOverride disables module filtering while the runtime debug flag is on
+	debugOverride atomic.Bool
+
+	// callerModules caches the module of each logging call site by program counter
+	callerModules sync.Map
+)
+
+// zerologFrame marks cached program counters inside zerolog itself
+const zerologFrame = "\x00zerolog"
+
+// SetModuleLevels overrides the log level of individual modules, e.g. {"discovery": "debug", "influx": "warn"}
+// Modules not listed keep the level set by the -log-level flag; an empty map removes all overrides
+func SetModuleLevels(levels map[string]string) error {
+	if len(levels) == 0 {
+		moduleLevels.Store(nil)
+		applyGlobalLevel()
+		return nil
+	}
+	parsed := make(map[string]zerolog.Level, len(levels))
+	for module, name := range levels {
+		if err := ValidateModuleLevel(module, name); err != nil {
+			return err
+		}
+		parsed[module], _ = ParseLevel(name)
+	}
+	moduleLevels.Store(&parsed)
+	applyGlobalLevel()
+	return nil
+}
+
+// ValidateModuleLevel checks a module name and level name
+func ValidateModuleLevel(module, level string) error {
+	if !isModule(module) {
+		return fmt.Errorf("unknown log module %q (%s)", module, strings.Join(Modules, ", "))
+	}
+	if _, err := ParseLevel(level); err != nil {
+		return fmt.Errorf("module %s: %v", module, err)
+	}
+	return nil
+}
+
+// SetDebugOverride turns module filtering off (on) or back on (off), so the runtime debug flag
+// shows debug lines of every module; the caller still sets zerolog's global level
+func SetDebugOverride(on bool) {
+	debugOverride.Store(on)
+}
+
+// isModule reports whether name is one of Modules
+func isModule(name string) bool {
+	i := sort.SearchStrings(Modules, name)
+	return i < len(Modules) && Modules[i] == name
+}
+
+// applyGlobalLevel sets zerolog's global level to the most verbose of the base and module levels,
+// so events of a module set to debug are not filtered before moduleHook sees them
+func applyGlobalLevel() {
+	level := zerolog.Level(baseLevel.Load())
+	if levels := moduleLevels.Load(); levels != nil {
+		for _, moduleLevel := range *levels {
+			if moduleLevel < level {
+				level = moduleLevel
+			}
+		}
+	}
+	zerolog.SetGlobalLevel(level)
+}
+
+// moduleHook discards events below the level of the module that logged them
+type moduleHook struct{}
+
+// Run implements zerolog.Hook
+func (moduleHook) Run(e *zerolog.Event, level zerolog.Level, msg string) {
+	levels := moduleLevels.Load()
+	if levels == nil || level >= zerolog.FatalLevel || debugOverride.Load() {
+		return
+	}
+	threshold := zerolog.Level(baseLevel.Load())
+	if moduleLevel, ok := (*levels)[callerModule()]; ok {
+		threshold = moduleLevel
+	}
+	if level < threshold {
+		e.Discard()
+	}
+}
+
+// callerModule returns the module of the code that logged the current event ("" if unknown)
+func callerModule() string {
+	var pcs [8]uintptr
+	// Skip runtime.Callers, callerModule and moduleHook.Run
+	n := runtime.Callers(3, pcs[:])
+	for _, pc := range pcs[:n] {
+		if module, ok := callerModules.Load(pc); ok {
+			if module == zerologFrame {
+				continue
+			}
+			return module.(string)
+		}
+		fn := runtime.FuncForPC(pc - 1)
+		if fn == nil {
+			continue
+		}
+		if strings.HasPrefix(fn.Name(), "github.com/rs/zerolog") {
+			callerModules.Store(pc, zerologFrame)
+			continue
+		}
+		module := moduleOf(fn.Name())
+		callerModules.Store(pc, module)
+		return module
+	}
+	return ""
+}
+
+// moduleOf maps a fully qualified function name to its module, e.g.
+// "github.com/kljama/netscan/internal/discovery.RunDiscoverySweep.func1" -> "discovery"
+func moduleOf(function string) string {
+	name := function[strings.LastIndex(function, "/")+1:]
+	if dot := strings.Index(name, "."); dot >= 0 {
+		name = name[:dot]
+	}
+	if !isModule(name) {
+		return ""
+	}
+	return name
+}
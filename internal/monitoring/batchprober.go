@@ -0,0 +1,305 @@
+package monitoring
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+// protocolICMP is the IANA protocol number of ICMPv4, needed to parse received messages
+const protocolICMP = 1
+
+// batchIdentifiers is how many echo identifiers a raw-socket engine rotates through
+// Each carries 65536 sequence numbers, so this bounds the echo requests in flight at once
+const batchIdentifiers = 8
+
+// batchHeaderLength is the payload prefix of every echo request: the engine tag and the request nonce
+const batchHeaderLength = 16
+
+// echoReply is one matched reply delivered to the waiting Ping call
+type echoReply struct {
+	rtt time.Duration
+}
+
+// echoKey identifies an outstanding echo request on the wire
+type echoKey struct {
+	id  uint16
+	seq uint16
+}
+
+// echoWait is an outstanding echo request
+type echoWait struct {
+	dst     net.IP
+	sent    time.Time
+	nonce   uint64         // Echoed back in the payload; a late reply to an earlier user of the key carries another one
+	replies chan echoReply // Shared by all requests of one cycle
+}
+
+// BatchProber multiplexes every ping cycle over one ICMP socket and one receive goroutine
+// With pro-bing each cycle opens its own socket and starts its own receiver; at 20k devices that is
+// thousands of file descriptors and goroutines churning every interval, and every raw socket receives
+// a copy of every ICMP packet reaching the host. Here replies are matched to requests by echo
+// identifier, sequence number, source address and a payload carrying a random per-process tag and a
+// per-request nonce, so neither other pingers' replies nor late replies to reused keys are miscounted
+// Only IPv4 targets are multiplexed; others fall back to pro-bing
+type BatchProber struct {
+	conn *icmp.PacketConn
+	udp  bool    // Unprivileged UDP ICMP socket: the kernel rewrites the identifier and only delivers this socket's replies
+	ids  []int   // Echo identifiers (one with a UDP socket, where it is replaced by the kernel's)
+	tag  [8]byte // Payload prefix distinguishing our replies from other pingers using the same identifier
+
+	profile config.ProbeProfile // Spacing and size of the echo requests; its DSCP marks the socket
+
+	mu      sync.Mutex
+	next    uint32 // Last allocated slot of ids x sequence numbers
+	nonce   uint64 // Last request nonce
+	pending map[echoKey]*echoWait
+	closed  bool
+}
+
+// NewBatchProber opens the shared ICMP socket, raw when privileged, otherwise an unprivileged UDP ICMP
+// socket (see icmp_mode), bound to source ("" = any), and starts the receiver
+// profile (probe_profiles.monitoring) spaces and sizes the echo requests, and its DSCP marks the socket
+func NewBatchProber(privileged bool, source string, profile config.ProbeProfile) (*BatchProber, error) {
+	network := "ip4:icmp"
+	if !privileged {
+		network = "udp4"
+	}
+	conn, err := icmp.ListenPacket(network, listenAddress(source))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ICMP socket: %v", err)
+	}
+	if tos := profile.TOS(); tos != 0 {
+		if err := conn.IPv4PacketConn().SetTOS(int(tos)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to set DSCP on ICMP socket: %v", err)
+		}
+	}
+	b, err := newBatchProber(conn, !privileged)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	b.profile = profile
+	go b.receive()
+	return b, nil
+}
+
+// newBatchProber initializes the matching state; conn may be nil in tests
+func newBatchProber(conn *icmp.PacketConn, udp bool) (*BatchProber, error) {
+	b := &BatchProber{
+		conn:    conn,
+		udp:     udp,
+		pending: make(map[echoKey]*echoWait),
+	}
+	count := batchIdentifiers
+	if udp {
+		count = 1
+	}
+	for len(b.ids) < count {
+		id, err := rand.Int(rand.Reader, big.NewInt(0x10000))
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(b.ids, int(id.Int64())) {
+			b.ids = append(b.ids, int(id.Int64()))
+		}
+	}
+	if _, err := rand.Read(b.tag[:]); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Close closes the shared socket, which stops the receiver
+func (b *BatchProber) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+	return b.conn.Close()
+}
+
+// Ping sends count echo requests over the shared socket and waits up to timeout after the last one for the replies
+func (b *BatchProber) Ping(ip string, count int, timeout time.Duration) (*PingStats, error) {
+	dst := net.ParseIP(ip).To4()
+	if dst == nil {
+		return proBingProber{profile: b.profile}.Ping(ip, count, timeout)
+	}
+	if count < 1 {
+		count = 1
+	}
+
+	replies := make(chan echoReply, count)
+	var keys []echoKey
+	defer func() { b.release(keys) }()
+
+	profile := b.profile
+	deadline := time.Now().Add(timeout + profile.Spread(count)) // Last packet gets the full timeout
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			time.Sleep(profile.PacketInterval())
+		}
+		key, nonce, err := b.reserve(dst, replies)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		if err := b.send(dst, key, b.payload(profile.PacketSize(), nonce)); err != nil {
+			return nil, err
+		}
+	}
+
+	rtts := make([]time.Duration, 0, count)
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	for len(rtts) < count {
+		select {
+		case reply := <-replies:
+			rtts = append(rtts, reply.rtt)
+		case <-timer.C:
+			return newPingStats(count, rtts), nil
+		}
+	}
+	return newPingStats(count, rtts), nil
+}
+
+// reserve allocates a free identifier and sequence number, and a fresh nonce, for a request to dst
+func (b *BatchProber) reserve(dst net.IP, replies chan echoReply) (echoKey, uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return echoKey{}, 0, errors.New("ping engine closed")
+	}
+	slots := uint32(len(b.ids)) << 16
+	for range slots {
+		b.next = (b.next + 1) % slots
+		key := echoKey{id: uint16(b.ids[b.next>>16]), seq: uint16(b.next)}
+		if _, used := b.pending[key]; !used {
+			b.nonce++
+			b.pending[key] = &echoWait{dst: dst, sent: time.Now(), nonce: b.nonce, replies: replies}
+			return key, b.nonce, nil
+		}
+	}
+	return echoKey{}, 0, errors.New("no free ICMP sequence numbers (too many pings in flight)")
+}
+
+// release frees the keys of a finished cycle; late replies are then ignored
+func (b *BatchProber) release(keys []echoKey) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, key := range keys {
+		delete(b.pending, key)
+	}
+}
+
+// payload returns the echo request data: the tag and the request nonce, zero-padded to size bytes
+func (b *BatchProber) payload(size int, nonce uint64) []byte {
+	data := make([]byte, max(size, batchHeaderLength))
+	copy(data, b.tag[:])
+	binary.BigEndian.PutUint64(data[len(b.tag):batchHeaderLength], nonce)
+	return data
+}
+
+// send writes one echo request; the send time is reset just before the write for accurate RTTs
+func (b *BatchProber) send(dst net.IP, key echoKey, payload []byte) error {
+	msg := icmp.Message{
+		Type: ipv4.ICMPTypeEcho,
+		Body: &icmp.Echo{ID: int(key.id), Seq: int(key.seq), Data: payload},
+	}
+	packet, err := msg.Marshal(nil)
+	if err != nil {
+		return err
+	}
+	var addr net.Addr = &net.IPAddr{IP: dst}
+	if b.udp {
+		addr = &net.UDPAddr{IP: dst} // Datagram ICMP sockets take UDP addresses
+	}
+
+	b.mu.Lock()
+	if wait, ok := b.pending[key]; ok {
+		wait.sent = time.Now()
+	}
+	b.mu.Unlock()
+
+	_, err = b.conn.WriteTo(packet, addr)
+	return err
+}
+
+// receive reads replies from the shared socket until it is closed
+func (b *BatchProber) receive() {
+	// Panic recovery so a malformed packet cannot take down the process
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().
+				Interface("panic", r).
+				Msg("Batch ping receiver panic recovered")
+		}
+	}()
+
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := b.conn.ReadFrom(buf)
+		if err != nil {
+			b.mu.Lock()
+			closed := b.closed
+			b.mu.Unlock()
+			if closed {
+				return
+			}
+			log.Debug().Err(err).Msg("Batch ping receive error")
+			continue
+		}
+		switch addr := peer.(type) {
+		case *net.IPAddr:
+			b.handleReply(addr.IP, buf[:n], time.Now())
+		case *net.UDPAddr:
+			b.handleReply(addr.IP, buf[:n], time.Now())
+		}
+	}
+}
+
+// handleReply matches a received ICMP message to its outstanding request and delivers the RTT
+func (b *BatchProber) handleReply(peer net.IP, data []byte, received time.Time) {
+	msg, err := icmp.ParseMessage(protocolICMP, data)
+	if err != nil || msg.Type != ipv4.ICMPTypeEchoReply {
+		return
+	}
+	echo, ok := msg.Body.(*icmp.Echo)
+	if !ok || len(echo.Data) < batchHeaderLength || !bytes.Equal(echo.Data[:len(b.tag)], b.tag[:]) {
+		return // Reply to another pinger
+	}
+	key := echoKey{id: uint16(echo.ID), seq: uint16(echo.Seq)}
+	if b.udp {
+		key.id = uint16(b.ids[0]) // The kernel replaced our identifier and only delivers this socket's replies
+	}
+	nonce := binary.BigEndian.Uint64(echo.Data[len(b.tag):batchHeaderLength])
+
+	b.mu.Lock()
+	wait, ok := b.pending[key]
+	if ok && wait.dst.Equal(peer) && wait.nonce == nonce {
+		delete(b.pending, key) // Ignore duplicate replies
+	} else {
+		ok = false
+	}
+	b.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	select {
+	case wait.replies <- echoReply{rtt: received.Sub(wait.sent)}:
+	default:
+	}
+}
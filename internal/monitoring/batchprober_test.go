@@ -0,0 +1,183 @@
+package monitoring
+
+import (
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+// echoReplyPacket builds a raw ICMP echo reply as read from the shared socket
+func echoReplyPacket(t *testing.T, id int, seq uint16, data []byte) []byte {
+	t.Helper()
+	msg := icmp.Message{Type: ipv4.ICMPTypeEchoReply, Body: &icmp.Echo{ID: id, Seq: int(seq), Data: data}}
+	packet, err := msg.Marshal(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return packet
+}
+
+// TestBatchProberReplyMatching verifies replies are matched by identifier, tag, nonce, sequence and source
+func TestBatchProberReplyMatching(t *testing.T) {
+	b, err := newBatchProber(nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := net.ParseIP("192.0.2.10").To4()
+	replies := make(chan echoReply, 4)
+	key, nonce, err := b.reserve(dst, replies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := b.pending[key].sent
+	payload := b.payload(0, nonce)
+
+	// Replies for other pingers, to other requests or from the wrong host are ignored
+	otherID := int(key.id) + 1
+	for slices.Contains(b.ids, otherID&0xffff) {
+		otherID++
+	}
+	b.handleReply(dst, echoReplyPacket(t, otherID, key.seq, payload), sent.Add(time.Millisecond))
+	b.handleReply(dst, echoReplyPacket(t, int(key.id), key.seq, []byte("otherpinger-0123")), sent.Add(time.Millisecond))
+	b.handleReply(dst, echoReplyPacket(t, int(key.id), key.seq, b.payload(0, nonce+1)), sent.Add(time.Millisecond))
+	b.handleReply(net.ParseIP("192.0.2.99"), echoReplyPacket(t, int(key.id), key.seq, payload), sent.Add(time.Millisecond))
+	if len(replies) != 0 {
+		t.Fatalf("expected foreign replies to be ignored, got %d", len(replies))
+	}
+
+	// The matching reply is delivered once; duplicates are dropped
+	b.handleReply(dst, echoReplyPacket(t, int(key.id), key.seq, payload), sent.Add(5*time.Millisecond))
+	b.handleReply(dst, echoReplyPacket(t, int(key.id), key.seq, payload), sent.Add(6*time.Millisecond))
+	if len(replies) != 1 {
+		t.Fatalf("expected exactly 1 reply, got %d", len(replies))
+	}
+	if reply := <-replies; reply.rtt != 5*time.Millisecond {
+		t.Errorf("expected 5ms RTT, got %v", reply.rtt)
+	}
+}
+
+// TestBatchProberStaleReply verifies a late reply to an earlier request is not credited to a reused key
+func TestBatchProberStaleReply(t *testing.T) {
+	b, err := newBatchProber(nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := net.ParseIP("192.0.2.10").To4()
+	replies := make(chan echoReply, 1)
+	first, firstNonce, _ := b.reserve(dst, replies)
+	b.release([]echoKey{first})
+
+	// Wrap around onto the released key, as after 65536 requests per identifier
+	b.next -= 1
+	second, _, _ := b.reserve(dst, replies)
+	if second != first {
+		t.Fatalf("expected the released key to be reused, got %+v and %+v", first, second)
+	}
+	b.handleReply(dst, echoReplyPacket(t, int(first.id), first.seq, b.payload(0, firstNonce)), time.Now())
+	if len(replies) != 0 {
+		t.Error("expected the late reply to the earlier request to be ignored")
+	}
+}
+
+// TestBatchProberUDPSocket verifies replies on an unprivileged socket match whatever identifier the kernel set
+func TestBatchProberUDPSocket(t *testing.T) {
+	b, err := newBatchProber(nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.ids) != 1 {
+		t.Fatalf("expected a single identifier on a UDP socket, got %d", len(b.ids))
+	}
+	dst := net.ParseIP("192.0.2.10").To4()
+	replies := make(chan echoReply, 1)
+	key, nonce, _ := b.reserve(dst, replies)
+	kernelID := int(key.id) ^ 0x5a5a
+	b.handleReply(dst, echoReplyPacket(t, kernelID, key.seq, b.payload(0, nonce)), time.Now())
+	if len(replies) != 1 {
+		t.Error("expected the reply with the kernel's identifier to match")
+	}
+}
+
+// TestBatchProberPaddedPayload verifies replies to payloads padded to the probe size still match
+func TestBatchProberPaddedPayload(t *testing.T) {
+	b, err := newBatchProber(nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := b.payload(1, 1); len(got) != batchHeaderLength {
+		t.Errorf("expected sizes below the header length to send the header, got %d bytes", len(got))
+	}
+
+	dst := net.ParseIP("192.0.2.10").To4()
+	replies := make(chan echoReply, 1)
+	key, nonce, err := b.reserve(dst, replies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := b.payload(1472, nonce)
+	if len(payload) != 1472 {
+		t.Fatalf("expected 1472-byte payload, got %d", len(payload))
+	}
+	b.handleReply(dst, echoReplyPacket(t, int(key.id), key.seq, payload), b.pending[key].sent.Add(time.Millisecond))
+	if len(replies) != 1 {
+		t.Errorf("expected the padded reply to match, got %d replies", len(replies))
+	}
+}
+
+// TestBatchProberSequenceReuse verifies keys are skipped while in use and spread over every identifier
+func TestBatchProberSequenceReuse(t *testing.T) {
+	b, err := newBatchProber(nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := net.ParseIP("192.0.2.10").To4()
+	replies := make(chan echoReply, 1)
+
+	first, _, _ := b.reserve(dst, replies)
+	b.next -= 1 // Wrap around onto the outstanding request
+	second, _, _ := b.reserve(dst, replies)
+	if second == first {
+		t.Fatal("reserved a key that is still outstanding")
+	}
+
+	// More requests in flight than one identifier's sequence numbers
+	keys := []echoKey{first, second}
+	ids := make(map[uint16]bool)
+	for range 1<<16 + 1 {
+		key, _, err := b.reserve(dst, replies)
+		if err != nil {
+			t.Fatalf("expected room for more than 65536 requests in flight: %v", err)
+		}
+		keys = append(keys, key)
+		ids[key.id] = true
+	}
+	if len(ids) < 2 {
+		t.Errorf("expected requests beyond 65536 to use another identifier, got %d", len(ids))
+	}
+	b.release(keys)
+	if len(b.pending) != 0 {
+		t.Errorf("expected no pending requests after release, got %d", len(b.pending))
+	}
+}
+
+// TestNewPingStats verifies loss and RTT statistics of a partially answered cycle
+func TestNewPingStats(t *testing.T) {
+	stats := newPingStats(4, []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond})
+	if stats.PacketsSent != 4 || stats.PacketsRecv != 3 || stats.PacketLoss != 25 {
+		t.Errorf("unexpected counts: %+v", stats)
+	}
+	if stats.MinRtt != 10*time.Millisecond || stats.AvgRtt != 20*time.Millisecond || stats.MaxRtt != 30*time.Millisecond {
+		t.Errorf("unexpected RTTs: min %v avg %v max %v", stats.MinRtt, stats.AvgRtt, stats.MaxRtt)
+	}
+	if stats.StdDevRtt < 8*time.Millisecond || stats.StdDevRtt > 9*time.Millisecond {
+		t.Errorf("expected stddev ~8.16ms, got %v", stats.StdDevRtt)
+	}
+
+	if lost := newPingStats(2, nil); lost.PacketLoss != 100 || lost.AvgRtt != 0 {
+		t.Errorf("unexpected stats for a lost cycle: %+v", lost)
+	}
+}
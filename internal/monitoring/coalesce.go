@@ -0,0 +1,96 @@
+package monitoring
+
+import (
+	"sync"
+	"time"
+)
+
+// FailureCoalescer wraps a PingWriter and thins out failure points for devices that stay down
+// Once a device has been failing continuously for longer than after, only every Nth failure point is written
+// Up/down transitions (and changes between failed and suspended) are always written
+type FailureCoalescer struct {
+	PingWriter
+	after time.Duration // Continuous downtime before coalescing starts
+	every int           // Write one in every N failure points while coalescing
+	now   func() time.Time
+
+	mu   sync.Mutex
+	down map[string]*outage // Devices currently failing, keyed by IP
+}
+
+// outage tracks a device's current run of failure points
+type outage struct {
+	since     time.Time // First failure of the run
+	suspended bool      // Kind of the last failure point (failed ping or circuit breaker suspension)
+	skipped   int       // Failure points dropped since the last one written
+}
+
+// NewFailureCoalescer creates a coalescing wrapper around writer
+func NewFailureCoalescer(writer PingWriter, after time.Duration, every int) *FailureCoalescer {
+	if every < 1 {
+		every = 1
+	}
+	return &FailureCoalescer{
+		PingWriter: writer,
+		after:      after,
+		every:      every,
+		now:        time.Now,
+		down:       make(map[string]*outage),
+	}
+}
+
+// WritePingResult writes the result unless it is a coalesced repeat of an ongoing failure
+func (c *FailureCoalescer) WritePingResult(ip string, rtt time.Duration, successful bool, suspended bool) error {
+	if !c.shouldWrite(ip, !successful, suspended) {
+		return nil
+	}
+	return c.PingWriter.WritePingResult(ip, rtt, successful, suspended)
+}
+
+// WritePingStats writes the statistics unless they are a coalesced repeat of an ongoing failure
+func (c *FailureCoalescer) WritePingStats(ip string, sent, recv int, minRtt, avgRtt, maxRtt, jitter time.Duration) error {
+	if !c.shouldWrite(ip, recv == 0, false) {
+		return nil
+	}
+	return c.PingWriter.WritePingStats(ip, sent, recv, minRtt, avgRtt, maxRtt, jitter)
+}
+
+// Forget drops tracking for devices that are no longer monitored
+func (c *FailureCoalescer) Forget(ip string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.down, ip)
+}
+
+// shouldWrite records the point for ip and reports whether it must be written
+func (c *FailureCoalescer) shouldWrite(ip string, failed, suspended bool) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !failed {
+		delete(c.down, ip) // Recovery: always written, ends the outage
+		return true
+	}
+
+	now := c.now()
+	o, ok := c.down[ip]
+	if !ok {
+		c.down[ip] = &outage{since: now, suspended: suspended} // Transition to down
+		return true
+	}
+	if o.suspended != suspended {
+		o.suspended = suspended // Failed <-> suspended transition
+		o.skipped = 0
+		return true
+	}
+	if now.Sub(o.since) < c.after {
+		return true
+	}
+
+	o.skipped++
+	if o.skipped >= c.every {
+		o.skipped = 0
+		return true
+	}
+	return false
+}
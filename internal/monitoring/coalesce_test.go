@@ -0,0 +1,68 @@
+package monitoring
+
+import (
+	"testing"
+	"time"
+)
+
+// TestFailureCoalescer validates failures are thinned after the threshold while transitions are always written
+func TestFailureCoalescer(t *testing.T) {
+	inner := &mockWriterForSuspension{}
+	c := NewFailureCoalescer(inner, time.Minute, 3)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	// Within the threshold every failure is written
+	for i := 0; i < 6; i++ {
+		c.WritePingStats("10.0.0.1", 1, 0, 0, 0, 0, 0)
+		now = now.Add(10 * time.Second)
+	}
+	if got := inner.getWriteCallsCount(); got != 6 {
+		t.Fatalf("expected 6 writes before threshold, got %d", got)
+	}
+
+	// Past the threshold only every 3rd failure is written
+	for i := 0; i < 9; i++ {
+		c.WritePingStats("10.0.0.1", 1, 0, 0, 0, 0, 0)
+		now = now.Add(10 * time.Second)
+	}
+	if got := inner.getWriteCallsCount(); got != 9 {
+		t.Fatalf("expected 3 coalesced writes (9 total), got %d", got)
+	}
+
+	// Switching to suspended is a transition and is written immediately
+	c.WritePingResult("10.0.0.1", 0, false, true)
+	if got := inner.getWriteCallsCount(); got != 10 {
+		t.Fatalf("expected suspension transition to be written, got %d writes", got)
+	}
+	c.WritePingResult("10.0.0.1", 0, false, true)
+	if got := inner.getWriteCallsCount(); got != 10 {
+		t.Fatalf("expected repeated suspension to be coalesced, got %d writes", got)
+	}
+
+	// Recovery is always written and resets the outage
+	c.WritePingStats("10.0.0.1", 1, 1, time.Millisecond, time.Millisecond, time.Millisecond, 0)
+	c.WritePingStats("10.0.0.1", 1, 0, 0, 0, 0, 0)
+	if got := inner.getWriteCallsCount(); got != 12 {
+		t.Fatalf("expected recovery and new failure to be written, got %d writes", got)
+	}
+}
+
+// TestFailureCoalescerPerDevice validates outages are tracked per device and Forget drops them
+func TestFailureCoalescerPerDevice(t *testing.T) {
+	inner := &mockWriterForSuspension{}
+	c := NewFailureCoalescer(inner, 0, 100)
+
+	c.WritePingStats("10.0.0.1", 1, 0, 0, 0, 0, 0) // Down transition, written
+	c.WritePingStats("10.0.0.1", 1, 0, 0, 0, 0, 0) // Coalesced
+	c.WritePingStats("10.0.0.2", 1, 0, 0, 0, 0, 0) // Other device, written
+	if got := inner.getWriteCallsCount(); got != 2 {
+		t.Fatalf("expected 2 writes, got %d", got)
+	}
+
+	c.Forget("10.0.0.1")
+	c.WritePingStats("10.0.0.1", 1, 0, 0, 0, 0, 0) // Treated as a new outage
+	if got := inner.getWriteCallsCount(); got != 3 {
+		t.Fatalf("expected write after Forget, got %d", got)
+	}
+}
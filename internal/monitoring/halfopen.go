@@ -0,0 +1,75 @@
+package monitoring
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/state"
+	"github.com/rs/zerolog/log"
+)
+
+// HalfOpenProber is implemented by state managers whose ping circuit breaker lets one test ping through a suspension
+// Optional: suspended devices are skipped until the backoff ends when the state manager does not implement it
+type HalfOpenProber interface {
+	ClaimHalfOpenProbe(ip string, now time.Time) bool
+	ReportHalfOpenFail(ip string, backoff time.Duration) time.Time
+}
+
+// claimHalfOpenProbe reports whether a suspended device is due for its half-open test ping
+func claimHalfOpenProbe(stateMgr StateManager, ip string) bool {
+	prober, ok := stateMgr.(HalfOpenProber)
+	return ok && prober.ClaimHalfOpenProbe(ip, time.Now())
+}
+
+// performHalfOpenProbe sends the single test ping of a suspended device; the caller holds a rate limiter token
+// An answer resumes normal monitoring at once, no answer extends the suspension. Returns whether the device answered
+func performHalfOpenProbe(prober Prober, device state.Device, timeout time.Duration, writer PingWriter, stateMgr StateManager, inFlightCounter *atomic.Int64, totalPingsSent *atomic.Uint64, backoffDuration time.Duration, policy config.AddressPolicy) bool {
+	if inFlightCounter != nil {
+		inFlightCounter.Add(1)
+		defer inFlightCounter.Add(-1)
+	}
+	if totalPingsSent != nil {
+		totalPingsSent.Add(1)
+	}
+	if err := policy.ValidateIP(device.IP); err != nil {
+		log.Error().
+			Str("ip", device.IP).
+			Err(err).
+			Msg("Invalid IP address")
+		return false
+	}
+
+	stats, err := prober.Ping(device.IP, 1, timeout)
+	if err == nil && len(stats.Rtts) > 0 && stats.AvgRtt > 0 {
+		log.Info().
+			Str("ip", device.IP).
+			Dur("rtt", stats.AvgRtt).
+			Msg("Suspended device answered its test ping, resuming monitoring (circuit breaker closed)")
+		stateMgr.ReportPingSuccess(device.IP)
+		stateMgr.UpdateLastSeen(device.IP)
+		recordPingCycle(stateMgr, device.IP, 1, 1, stats.MinRtt, stats.AvgRtt, stats.MaxRtt)
+		if err := writer.WritePingStats(device.IP, 1, 1, stats.MinRtt, stats.AvgRtt, stats.MaxRtt, 0); err != nil {
+			log.Error().
+				Str("ip", device.IP).
+				Err(err).
+				Msg("Failed to write ping result")
+		}
+		return true
+	}
+
+	until := stateMgr.(HalfOpenProber).ReportHalfOpenFail(device.IP, backoffDuration)
+	log.Debug().
+		Str("ip", device.IP).
+		Err(err).
+		Time("suspended_until", until).
+		Msg("Suspended device did not answer its test ping, extending suspension")
+	recordPingCycle(stateMgr, device.IP, 0, 0, 0, 0, 0) // Counts as an unavailable cycle
+	if err := writer.WritePingResult(device.IP, 0, false, true); err != nil {
+		log.Error().
+			Str("ip", device.IP).
+			Err(err).
+			Msg("Failed to write suspension status")
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package monitoring
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/state"
+	"golang.org/x/time/rate"
+)
+
+// answeringProber answers every echo request after 1ms
+type answeringProber struct{}
+
+func (answeringProber) Ping(ip string, count int, timeout time.Duration) (*PingStats, error) {
+	rtts := make([]time.Duration, count)
+	for i := range rtts {
+		rtts[i] = time.Millisecond
+	}
+	return &PingStats{PacketsSent: count, PacketsRecv: count, Rtts: rtts, MinRtt: time.Millisecond, AvgRtt: time.Millisecond, MaxRtt: time.Millisecond}, nil
+}
+
+// TestHalfOpenTestPing verifies a due test ping extends the suspension when unanswered and ends it when answered
+func TestHalfOpenTestPing(t *testing.T) {
+	stateMgr := state.NewManager(10)
+	stateMgr.SetHalfOpenProbing(time.Nanosecond, time.Hour)
+	stateMgr.Add(state.Device{IP: "10.0.0.1", LastSeen: time.Now()})
+	if !stateMgr.ReportPingFail("10.0.0.1", 1, time.Minute) {
+		t.Fatal("expected the circuit breaker to trip")
+	}
+	time.Sleep(time.Millisecond) // Past the test ping time
+
+	var inFlight atomic.Int64
+	var total atomic.Uint64
+	writer := &mockWriterForSuspension{}
+	s := NewPingScheduler(time.Minute, time.Second, 3, 1, writer, stateMgr, rate.NewLimiter(rate.Inf, 1), &inFlight, &total, 1, time.Minute, config.AddressPolicy{}, nil, nil)
+	device := state.Device{IP: "10.0.0.1"}
+	s.Add(device)
+
+	s.SetProber(unreachableProber{})
+	s.ping(context.Background(), s.entries[device.IP])
+	dev, _ := stateMgr.Get("10.0.0.1")
+	if dev.BackoffLevel != 1 || time.Until(dev.SuspendedUntil) < time.Minute {
+		t.Errorf("expected the suspension doubled after an unanswered test ping, got level %d until %v", dev.BackoffLevel, dev.SuspendedUntil)
+	}
+	if total.Load() != 1 {
+		t.Errorf("expected one test ping sent, got %d", total.Load())
+	}
+
+	if dev.ProbeAt.IsZero() {
+		t.Error("expected a test ping scheduled for the extended suspension")
+	}
+	time.Sleep(time.Millisecond)
+
+	s.SetProber(answeringProber{})
+	s.ping(context.Background(), s.entries[device.IP])
+	dev, _ = stateMgr.Get("10.0.0.1")
+	if stateMgr.IsSuspended("10.0.0.1") || dev.BackoffLevel != 0 {
+		t.Errorf("expected an answered test ping to resume monitoring, got until %v level %d", dev.SuspendedUntil, dev.BackoffLevel)
+	}
+	calls := writer.getWriteCalls()
+	if len(calls) != 2 || !calls[0].suspended || !calls[1].success {
+		t.Errorf("expected a suspension point then a success, got %+v", calls)
+	}
+}
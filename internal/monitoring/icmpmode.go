@@ -0,0 +1,78 @@
+package monitoring
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/net/icmp"
+)
+
+// ICMP socket modes accepted by the icmp_mode setting
+const (
+	ICMPModeAuto         = "auto"         // Raw sockets if permitted, otherwise unprivileged UDP sockets
+	ICMPModePrivileged   = "privileged"   // Raw ICMP sockets (root or CAP_NET_RAW)
+	ICMPModeUnprivileged = "unprivileged" // UDP ICMP sockets (Linux net.ipv4.ping_group_range, macOS)
+)
+
+// icmpSockets tests which ICMP sockets the process may open
+type icmpSockets struct {
+	open func(network string) error // Opens and closes an IPv4 ICMP socket ("ip4:icmp" or "udp4")
+}
+
+// openICMPSocket opens and closes an IPv4 ICMP socket to test whether the process may use it
+func openICMPSocket(network string) error {
+	conn, err := icmp.ListenPacket(network, "0.0.0.0")
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// CheckICMPSockets tries to open a raw and an unprivileged UDP ICMP socket; a nil error means the socket is permitted
+func CheckICMPSockets() (rawErr, udpErr error) {
+	return openICMPSocket("ip4:icmp"), openICMPSocket("udp4")
+}
+
+// ResolveICMPMode detects which ICMP sockets this process may open and returns whether pings
+// should use raw sockets. A configured mode that is not available falls back to the other one
+// with a warning; an error means neither raw nor UDP ICMP sockets can be opened. Pass the result to the
+// ping engine and the discovery sweeps
+func ResolveICMPMode(mode string) (bool, error) {
+	return icmpSockets{open: openICMPSocket}.resolve(mode)
+}
+
+// resolve implements ResolveICMPMode with the sockets s may open
+func (s icmpSockets) resolve(mode string) (bool, error) {
+	if mode == ICMPModeUnprivileged {
+		udpErr := s.open("udp4")
+		if udpErr == nil {
+			return false, nil
+		}
+		if s.open("ip4:icmp") == nil {
+			log.Warn().
+				Err(udpErr).
+				Msg("icmp_mode is unprivileged but UDP ICMP sockets are not permitted (check net.ipv4.ping_group_range), falling back to raw ICMP sockets")
+			return true, nil
+		}
+		return false, fmt.Errorf("cannot open UDP ICMP sockets (check net.ipv4.ping_group_range): %v", udpErr)
+	}
+
+	rawErr := s.open("ip4:icmp")
+	if rawErr == nil {
+		return true, nil
+	}
+	if udpErr := s.open("udp4"); udpErr != nil {
+		return false, fmt.Errorf("cannot open raw ICMP sockets (run as root or grant CAP_NET_RAW): %v; "+
+			"unprivileged UDP ICMP sockets are not permitted either (check net.ipv4.ping_group_range): %v", rawErr, udpErr)
+	}
+	if mode == ICMPModePrivileged {
+		log.Warn().
+			Err(rawErr).
+			Msg("icmp_mode is privileged but raw ICMP sockets are not permitted (needs root or CAP_NET_RAW), falling back to unprivileged UDP ICMP sockets")
+	} else {
+		log.Info().
+			Err(rawErr).
+			Msg("Raw ICMP sockets not permitted, using unprivileged UDP ICMP sockets")
+	}
+	return false, nil
+}
@@ -0,0 +1,50 @@
+package monitoring
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestResolveICMPMode verifies mode selection and fallback for every combination of permitted sockets
+func TestResolveICMPMode(t *testing.T) {
+	tests := []struct {
+		name           string
+		mode           string
+		raw, udp       bool // Sockets the process may open
+		wantPrivileged bool
+		wantErr        bool
+	}{
+		{name: "auto with raw", mode: ICMPModeAuto, raw: true, udp: true, wantPrivileged: true},
+		{name: "auto without raw", mode: ICMPModeAuto, udp: true, wantPrivileged: false},
+		{name: "privileged", mode: ICMPModePrivileged, raw: true, wantPrivileged: true},
+		{name: "privileged falls back", mode: ICMPModePrivileged, udp: true, wantPrivileged: false},
+		{name: "unprivileged", mode: ICMPModeUnprivileged, raw: true, udp: true, wantPrivileged: false},
+		{name: "unprivileged falls back", mode: ICMPModeUnprivileged, raw: true, wantPrivileged: true},
+		{name: "auto without sockets", mode: ICMPModeAuto, wantErr: true},
+		{name: "unprivileged without sockets", mode: ICMPModeUnprivileged, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sockets := icmpSockets{open: func(network string) error {
+				if (network == "ip4:icmp" && tt.raw) || (network == "udp4" && tt.udp) {
+					return nil
+				}
+				return errors.New("operation not permitted")
+			}}
+			privileged, err := sockets.resolve(tt.mode)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if privileged != tt.wantPrivileged {
+				t.Errorf("expected privileged=%v, got %v", tt.wantPrivileged, privileged)
+			}
+		})
+	}
+}
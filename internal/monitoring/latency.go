@@ -0,0 +1,16 @@
+package monitoring
+
+import "time"
+
+// LatencyObserver receives the RTT of every successful ping cycle (implemented by checks.LatencyEvaluator)
+type LatencyObserver interface {
+	ObservePingCycle(ip, hostname string, avgRtt time.Duration, at time.Time)
+}
+
+// observeLatency passes a successful ping cycle to the latency observer o, if any
+// Called after the cycle was recorded, so percentile thresholds see it in the RTT history
+func observeLatency(o LatencyObserver, ip, hostname string, avgRtt time.Duration) {
+	if o != nil {
+		o.ObservePingCycle(ip, hostname, avgRtt, time.Now())
+	}
+}
@@ -0,0 +1,40 @@
+package monitoring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// recordingLatencyObserver keeps the IPs of observed ping cycles
+type recordingLatencyObserver struct {
+	ips []string
+}
+
+func (o *recordingLatencyObserver) ObservePingCycle(ip, hostname string, avgRtt time.Duration, at time.Time) {
+	o.ips = append(o.ips, ip)
+}
+
+// TestSchedulerLatencyObserver verifies each scheduler reports successful cycles to its own observer only,
+// and that cycles tagged by a maintenance window are not observed
+func TestSchedulerLatencyObserver(t *testing.T) {
+	prober := NewSimulatedProber(time.Millisecond, 0, config.ProbeProfile{})
+	first, second := &recordingLatencyObserver{}, &recordingLatencyObserver{}
+	a, b := newTestScheduler(&mockWriterForSuspension{}, 1), newTestScheduler(&mockWriterForSuspension{}, 1)
+	a.SetLatencyObserver(first)
+	b.SetLatencyObserver(second)
+
+	device := state.Device{IP: "10.0.0.1"}
+	performPingWithCircuitBreaker(prober, device, time.Second, 1, a.writer, nil, nil, nil, 3, time.Minute, config.AddressPolicy{}, nil, a.latency)
+	if len(first.ips) != 1 || first.ips[0] != device.IP || len(second.ips) != 0 {
+		t.Errorf("expected one cycle observed by the first scheduler only, got %v and %v", first.ips, second.ips)
+	}
+
+	tagged := MaintenanceResolver(func(ip string, at time.Time) string { return config.MaintenanceTag })
+	performPingWithCircuitBreaker(prober, device, time.Second, 1, b.writer, nil, nil, nil, 3, time.Minute, config.AddressPolicy{}, tagged, b.latency)
+	if len(second.ips) != 0 {
+		t.Errorf("expected tagged cycles not to be observed, got %v", second.ips)
+	}
+}
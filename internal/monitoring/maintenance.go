@@ -0,0 +1,18 @@
+package monitoring
+
+import (
+	"time"
+)
+
+// MaintenanceResolver reports the maintenance action of a device at a given time: config.MaintenanceSkip,
+// config.MaintenanceTag or "" outside every window (see config.Config.MaintenanceResolver)
+// Pingers and SNMP pollers check it before and after every probe; nil means no windows
+type MaintenanceResolver func(ip string, at time.Time) string
+
+// action returns the action of the maintenance windows the device is in now ("" outside every window)
+func (r MaintenanceResolver) action(ip string) string {
+	if r == nil {
+		return ""
+	}
+	return r(ip, time.Now())
+}
@@ -0,0 +1,67 @@
+package monitoring
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// unreachableProber answers no echo request
+type unreachableProber struct{}
+
+func (unreachableProber) Ping(ip string, count int, timeout time.Duration) (*PingStats, error) {
+	return &PingStats{PacketsSent: count, PacketLoss: 100}, nil
+}
+
+// failCountingStateManager counts failures reported to the circuit breaker
+type failCountingStateManager struct {
+	mockStateManagerForSuspension
+	fails int
+}
+
+func (m *failCountingStateManager) ReportPingFail(ip string, maxFails int, backoff time.Duration) bool {
+	m.fails++
+	return false
+}
+
+// TestMaintenanceWindows verifies skipped cycles send nothing and tagged cycles don't report failures
+func TestMaintenanceWindows(t *testing.T) {
+	action := ""
+	maintenance := MaintenanceResolver(func(ip string, at time.Time) string {
+		if ip == "10.0.0.1" {
+			return action
+		}
+		return ""
+	})
+
+	device := state.Device{IP: "10.0.0.1"}
+	for _, tt := range []struct {
+		action    string
+		wantFails int
+	}{
+		{"", 1},
+		{config.MaintenanceTag, 0},
+	} {
+		action = tt.action
+		stateMgr := &failCountingStateManager{}
+		writer := &mockWriterForSuspension{}
+		performPingWithCircuitBreaker(unreachableProber{}, device, time.Second, 1, writer, stateMgr, nil, nil, 3, time.Minute, config.AddressPolicy{}, maintenance, nil)
+		if stateMgr.fails != tt.wantFails || writer.getWriteCallsCount() != 1 {
+			t.Errorf("action %q: expected %d reported failures and 1 write, got %d and %d", tt.action, tt.wantFails, stateMgr.fails, writer.getWriteCallsCount())
+		}
+	}
+
+	// A skipped device is neither pinged nor written, even while suspended
+	action = config.MaintenanceSkip
+	writer := &mockWriterForSuspension{}
+	s := newTestScheduler(writer, 1)
+	s.maintenance = maintenance
+	s.Add(device)
+	s.ping(context.Background(), s.entries[device.IP])
+	if writer.getWriteCallsCount() != 0 {
+		t.Errorf("expected no writes during a skip window, got %+v", writer.getWriteCalls())
+	}
+}
@@ -0,0 +1,42 @@
+package monitoring
+
+import "sync/atomic"
+
+// Probe outcome counters for runtime auto-tuning: only probes of devices whose previous probe succeeded are
+// counted, and counted as lost when they fail. Devices that are down keep failing without being counted, so
+// the loss rate reflects timeouts caused by overload (full socket buffers, saturated links) rather than outages
+var (
+	pingsAfterSuccess atomic.Uint64
+	pingsLost         atomic.Uint64
+	snmpAfterSuccess  atomic.Uint64
+	snmpLost          atomic.Uint64
+)
+
+// ProbeOutcomes is a snapshot of the cumulative outcome counters
+type ProbeOutcomes struct {
+	Pings       uint64 // Ping cycles of devices whose previous cycle succeeded
+	PingsLost   uint64 // Of those, cycles without a reply
+	SNMPQueries uint64 // SNMP polls of devices whose previous poll succeeded
+	SNMPLost    uint64 // Of those, polls that failed
+}
+
+// Outcomes returns the cumulative probe outcome counters
+func Outcomes() ProbeOutcomes {
+	return ProbeOutcomes{
+		Pings:       pingsAfterSuccess.Load(),
+		PingsLost:   pingsLost.Load(),
+		SNMPQueries: snmpAfterSuccess.Load(),
+		SNMPLost:    snmpLost.Load(),
+	}
+}
+
+// countOutcome records a probe when the previous probe of the device succeeded
+func countOutcome(total, lost *atomic.Uint64, previousOK, ok bool) {
+	if !previousOK {
+		return
+	}
+	total.Add(1)
+	if !ok {
+		lost.Add(1)
+	}
+}
@@ -1,23 +1,20 @@
 package monitoring
 
 import (
-	"context"
-	"fmt"
-	"net"
 	"strings"
-	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/kljama/netscan/internal/config"
 	"github.com/kljama/netscan/internal/state"
-	probing "github.com/prometheus-community/pro-bing"
+	"github.com/kljama/netscan/internal/validate"
 	"github.com/rs/zerolog/log"
-	"golang.org/x/time/rate"
 )
 
 // PingWriter interface for writing ping results to external storage
 type PingWriter interface {
 	WritePingResult(ip string, rtt time.Duration, successful bool, suspended bool) error
+	WritePingStats(ip string, sent, recv int, minRtt, avgRtt, maxRtt, jitter time.Duration) error
 	WriteDeviceInfo(ip, hostname, sysDescr string) error
 }
 
@@ -29,63 +26,16 @@ type StateManager interface {
 	IsSuspended(ip string) bool
 }
 
-// StartPinger runs continuous ICMP monitoring for a single device
-func StartPinger(ctx context.Context, wg *sync.WaitGroup, device state.Device, interval time.Duration, timeout time.Duration, writer PingWriter, stateMgr StateManager, limiter *rate.Limiter, inFlightCounter *atomic.Int64, totalPingsSent *atomic.Uint64, maxConsecutiveFails int, backoffDuration time.Duration) {
-	// Panic recovery for pinger goroutine
-	defer func() {
-		if r := recover(); r != nil {
-			log.Error().
-				Str("ip", device.IP).
-				Interface("panic", r).
-				Msg("Pinger panic recovered")
-		}
-	}()
-
-	if wg != nil {
-		defer wg.Done()
-	}
-	
-	// Initialize timer for first ping with 1 second delay to avoid immediate ping storm
-	timer := time.NewTimer(1 * time.Second)
-	defer timer.Stop()
-	
-	for {
-		select {
-		case <-ctx.Done():
-			// Stop timer on graceful shutdown
-			timer.Stop()
-			return
-		case <-timer.C:
-			// 1. CHECK CIRCUIT BREAKER *BEFORE* ACQUIRING TOKEN
-			if stateMgr.IsSuspended(device.IP) {
-				log.Debug().Str("ip", device.IP).Msg("Device ping is suspended (circuit breaker), skipping.")
-				
-				// Write suspension status to InfluxDB so we can track which devices are suspended
-				if err := writer.WritePingResult(device.IP, 0, false, true); err != nil {
-					log.Error().
-						Str("ip", device.IP).
-						Err(err).
-						Msg("Failed to write suspension status")
-				}
-				
-				timer.Reset(interval) // Reset timer and wait for next cycle
-				continue              // Skip ping logic entirely
-			}
-
-			// 2. Acquire token from rate limiter (blocks until available or context cancelled)
-			if err := limiter.Wait(ctx); err != nil {
-				// Context was cancelled while waiting for token
-				return
-			}
+// PingRollupRecorder is implemented by state managers that keep local daily ping rollups and RTT history per device
+// Optional: pingers skip recording when the state manager does not implement it
+type PingRollupRecorder interface {
+	RecordPingCycle(ip string, sent, recv int, minRtt, avgRtt, maxRtt time.Duration, at time.Time)
+}
 
-			// 3. Perform the ping operation with in-flight tracking and circuit breaker
-			performPingWithCircuitBreaker(device, timeout, writer, stateMgr, inFlightCounter, totalPingsSent, maxConsecutiveFails, backoffDuration)
-			
-			// 4. Reset timer to schedule next ping after interval
-			// This ensures interval is time BETWEEN pings, not fixed schedule
-			timer.Reset(interval)
-		}
-	}
+// QuarantineChecker is implemented by state managers that quarantine devices whose circuit breaker keeps tripping
+// Optional: pingers and SNMP pollers probe every device when the state manager does not implement it
+type QuarantineChecker interface {
+	IsQuarantined(ip string) bool
 }
 
 // performPing executes a single ping operation with in-flight counter tracking
@@ -100,7 +50,7 @@ func performPing(device state.Device, timeout time.Duration, writer PingWriter,
 	log.Debug().Str("ip", device.IP).Msg("Pinging device")
 
 	// Validate IP address before pinging
-	if err := validateIPAddress(device.IP); err != nil {
+	if err := validate.IPAddress(device.IP); err != nil {
 		log.Error().
 			Str("ip", device.IP).
 			Err(err).
@@ -108,18 +58,8 @@ func performPing(device state.Device, timeout time.Duration, writer PingWriter,
 		return
 	}
 
-	pinger, err := probing.NewPinger(device.IP)
+	stats, err := proBingProber{}.Ping(device.IP, 1, timeout) // Single ICMP echo request per interval
 	if err != nil {
-		log.Error().
-			Str("ip", device.IP).
-			Err(err).
-			Msg("Failed to create pinger")
-		return // Skip invalid IP configurations
-	}
-	pinger.Count = 1                              // Single ICMP echo request per interval
-	pinger.Timeout = timeout                      // Use configured ping timeout
-	pinger.SetPrivileged(true)                    // Use raw ICMP sockets (requires root)
-	if err := pinger.Run(); err != nil {
 		// Distinguish between network-level errors (fast failure) and other errors
 		// Network unreachable errors indicate routing/ARP issues and are fast failures (<10ms)
 		errMsg := err.Error()
@@ -136,7 +76,6 @@ func performPing(device state.Device, timeout time.Duration, writer PingWriter,
 		}
 		return // Skip execution errors
 	}
-	stats := pinger.Statistics()
 	// Determine success based on RTT data rather than just PacketsRecv
 	// This is more reliable as the RTT measurements directly prove we got a response
 	successful := len(stats.Rtts) > 0 && stats.AvgRtt > 0
@@ -175,7 +114,8 @@ func performPing(device state.Device, timeout time.Duration, writer PingWriter,
 }
 
 // performPingWithCircuitBreaker executes a single ping operation with circuit breaker integration
-func performPingWithCircuitBreaker(device state.Device, timeout time.Duration, writer PingWriter, stateMgr StateManager, inFlightCounter *atomic.Int64, totalPingsSent *atomic.Uint64, maxConsecutiveFails int, backoffDuration time.Duration) {
+// Returns whether the device answered
+func performPingWithCircuitBreaker(prober Prober, device state.Device, timeout time.Duration, pingCount int, writer PingWriter, stateMgr StateManager, inFlightCounter *atomic.Int64, totalPingsSent *atomic.Uint64, maxConsecutiveFails int, backoffDuration time.Duration, policy config.AddressPolicy, maintenance MaintenanceResolver, latency LatencyObserver) bool {
 	// Increment in-flight counter
 	if inFlightCounter != nil {
 		inFlightCounter.Add(1)
@@ -188,29 +128,23 @@ func performPingWithCircuitBreaker(device state.Device, timeout time.Duration, w
 		totalPingsSent.Add(1)
 	}
 
-	log.Debug().Str("ip", device.IP).Msg("Pinging device")
+	probeLog(device.IP).Str("ip", device.IP).Msg("Pinging device")
 
 	// Validate IP address before pinging
-	if err := validateIPAddress(device.IP); err != nil {
+	if err := policy.ValidateIP(device.IP); err != nil {
 		log.Error().
 			Str("ip", device.IP).
 			Err(err).
 			Msg("Invalid IP address")
-		return
+		return false
 	}
 
-	pinger, err := probing.NewPinger(device.IP)
-	if err != nil {
-		log.Error().
-			Str("ip", device.IP).
-			Err(err).
-			Msg("Failed to create pinger")
-		return // Skip invalid IP configurations
+	if pingCount < 1 {
+		pingCount = 1
 	}
-	pinger.Count = 1                              // Single ICMP echo request per interval
-	pinger.Timeout = timeout                      // Use configured ping timeout
-	pinger.SetPrivileged(true)                    // Use raw ICMP sockets (requires root)
-	if err := pinger.Run(); err != nil {
+	// Configured timeout applies to the last packet of the cycle
+	stats, err := prober.Ping(device.IP, pingCount, timeout)
+	if err != nil {
 		// Distinguish between network-level errors (fast failure) and other errors
 		// Network unreachable errors indicate routing/ARP issues and are fast failures (<10ms)
 		// These do NOT inflate active_pingers metric (short duration W in Little's Law)
@@ -227,17 +161,19 @@ func performPingWithCircuitBreaker(device state.Device, timeout time.Duration, w
 				Err(err).
 				Msg("Ping execution failed")
 		}
-		return // Skip execution errors
+		return false // Skip execution errors
 	}
-	stats := pinger.Statistics()
 	// Determine success based on RTT data rather than just PacketsRecv
 	// This is more reliable as the RTT measurements directly prove we got a response
+	// With several packets per cycle a single reply counts as success; loss is reported separately
 	successful := len(stats.Rtts) > 0 && stats.AvgRtt > 0
 	
 	if successful {
-		log.Debug().
+		probeLog(device.IP).
 			Str("ip", device.IP).
 			Dur("rtt", stats.AvgRtt).
+			Dur("jitter", stats.StdDevRtt).
+			Float64("packet_loss_pct", stats.PacketLoss).
 			Int("packets_recv", stats.PacketsRecv).
 			Int("packets_sent", stats.PacketsSent).
 			Msg("Ping successful")
@@ -248,22 +184,26 @@ func performPingWithCircuitBreaker(device state.Device, timeout time.Duration, w
 			stateMgr.UpdateLastSeen(device.IP)
 		}
 		
-		if err := writer.WritePingResult(device.IP, stats.AvgRtt, true, false); err != nil {
+		recordPingCycle(stateMgr, device.IP, pingCount, len(stats.Rtts), stats.MinRtt, stats.AvgRtt, stats.MaxRtt)
+		if maintenance.action(device.IP) == "" {
+			observeLatency(latency, device.IP, device.Hostname, stats.AvgRtt)
+		}
+		if err := writer.WritePingStats(device.IP, pingCount, len(stats.Rtts), stats.MinRtt, stats.AvgRtt, stats.MaxRtt, stats.StdDevRtt); err != nil {
 			log.Error().
 				Str("ip", device.IP).
 				Err(err).
 				Msg("Failed to write ping result")
 		}
 	} else {
-		log.Debug().
+		probeLog(device.IP).
 			Str("ip", device.IP).
 			Int("packets_recv", stats.PacketsRecv).
 			Int("packets_sent", stats.PacketsSent).
 			Dur("avg_rtt", stats.AvgRtt).
 			Msg("Ping failed - no response")
 		
-		// Report failure to circuit breaker
-		if stateMgr != nil {
+		// Report failure to circuit breaker, unless the outage is planned
+		if stateMgr != nil && maintenance.action(device.IP) == "" {
 			wasSuspended := stateMgr.ReportPingFail(device.IP, maxConsecutiveFails, backoffDuration)
 			if wasSuspended {
 				log.Warn().
@@ -273,45 +213,27 @@ func performPingWithCircuitBreaker(device state.Device, timeout time.Duration, w
 			}
 		}
 		
-		if err := writer.WritePingResult(device.IP, 0, false, false); err != nil {
+		recordPingCycle(stateMgr, device.IP, pingCount, 0, 0, 0, 0)
+		if err := writer.WritePingStats(device.IP, pingCount, 0, 0, 0, 0, 0); err != nil {
 			log.Error().
 				Str("ip", device.IP).
 				Err(err).
 				Msg("Failed to write ping failure")
 		}
 	}
-}
 
-// validateIPAddress validates IP address format and security constraints
-func validateIPAddress(ipStr string) error {
-	if ipStr == "" {
-		return fmt.Errorf("IP address cannot be empty")
-	}
-
-	ip := net.ParseIP(ipStr)
-	if ip == nil {
-		return fmt.Errorf("invalid IP address format: %s", ipStr)
-	}
-
-	// Security checks - prevent pinging dangerous addresses
-	if ip.IsLoopback() {
-		return fmt.Errorf("loopback addresses not allowed: %s", ipStr)
-	}
-	if ip.IsMulticast() {
-		return fmt.Errorf("multicast addresses not allowed: %s", ipStr)
-	}
-	if ip.IsLinkLocalUnicast() {
-		return fmt.Errorf("link-local addresses not allowed: %s", ipStr)
-	}
-	if ip.IsUnspecified() {
-		return fmt.Errorf("unspecified addresses not allowed: %s", ipStr)
-	}
+	return successful
+}
 
-	// Additional validation for IPv4 addresses
-	if ip.To4() != nil {
-		// Note: We allow .0 and .255 addresses as they may be valid device IPs in some networks
-		// The security checks above (loopback, multicast, etc.) are more important
+// recordPingCycle adds a cycle to the device's local rollups and RTT history when the state manager keeps them
+func recordPingCycle(stateMgr StateManager, ip string, sent, recv int, minRtt, avgRtt, maxRtt time.Duration) {
+	if recorder, ok := stateMgr.(PingRollupRecorder); ok {
+		recorder.RecordPingCycle(ip, sent, recv, minRtt, avgRtt, maxRtt, time.Now())
 	}
+}
 
-	return nil
+// isQuarantined reports whether the state manager quarantined the device
+func isQuarantined(stateMgr interface{}, ip string) bool {
+	checker, ok := stateMgr.(QuarantineChecker)
+	return ok && checker.IsQuarantined(ip)
 }
@@ -34,6 +34,10 @@ func (m *mockWriterForSuspension) WritePingResult(ip string, rtt time.Duration,
 	return nil
 }
 
+func (m *mockWriterForSuspension) WritePingStats(ip string, sent, recv int, minRtt, avgRtt, maxRtt, jitter time.Duration) error {
+	return m.WritePingResult(ip, avgRtt, recv > 0, false)
+}
+
 func (m *mockWriterForSuspension) WriteDeviceInfo(ip, hostname, sysDescr string) error {
 	return nil
 }
@@ -95,7 +99,7 @@ func TestSuspendedStatusWritten(t *testing.T) {
 	var totalPingsSent atomic.Uint64
 	
 	// Start pinger - should write suspended status after initial 1 second delay
-	go StartPinger(ctx, nil, dev, 50*time.Millisecond, 2*time.Second, writer, stateMgr, limiter, &inFlightCounter, &totalPingsSent, 10, 5*time.Minute)
+	go runPinger(ctx, dev, 50*time.Millisecond, 2*time.Second, 1, writer, stateMgr, limiter, &inFlightCounter, &totalPingsSent, 10, 5*time.Minute)
 	
 	// Wait for the initial timer (1 second) plus some buffer
 	time.Sleep(1200 * time.Millisecond)
@@ -138,7 +142,7 @@ func TestNormalPingNotSuspended(t *testing.T) {
 	var totalPingsSent atomic.Uint64
 	
 	// Start pinger - should attempt normal ping
-	go StartPinger(ctx, nil, dev, 50*time.Millisecond, 2*time.Second, writer, stateMgr, limiter, &inFlightCounter, &totalPingsSent, 10, 5*time.Minute)
+	go runPinger(ctx, dev, 50*time.Millisecond, 2*time.Second, 1, writer, stateMgr, limiter, &inFlightCounter, &totalPingsSent, 10, 5*time.Minute)
 	
 	// Wait for the initial timer (1 second) plus some buffer
 	time.Sleep(1200 * time.Millisecond)
@@ -181,7 +185,7 @@ func TestSuspendedWriteFrequency(t *testing.T) {
 	var totalPingsSent atomic.Uint64
 	
 	// Start pinger with 200ms interval
-	go StartPinger(ctx, nil, dev, 200*time.Millisecond, 2*time.Second, writer, stateMgr, limiter, &inFlightCounter, &totalPingsSent, 10, 5*time.Minute)
+	go runPinger(ctx, dev, 200*time.Millisecond, 2*time.Second, 1, writer, stateMgr, limiter, &inFlightCounter, &totalPingsSent, 10, 5*time.Minute)
 	
 	// Wait for initial timer (1s) + a few intervals (1s + 400ms = 1.4s total, plus buffer)
 	time.Sleep(1500 * time.Millisecond)
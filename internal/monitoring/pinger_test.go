@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/kljama/netscan/internal/config"
 	"github.com/kljama/netscan/internal/state"
 	"golang.org/x/time/rate"
 )
@@ -32,6 +33,10 @@ func (m *mockWriter) WritePingResult(ip string, rtt time.Duration, successful bo
 	return nil
 }
 
+func (m *mockWriter) WritePingStats(ip string, sent, recv int, minRtt, avgRtt, maxRtt, jitter time.Duration) error {
+	return m.WritePingResult(ip, avgRtt, recv > 0, false)
+}
+
 func (m *mockWriter) WriteDeviceInfo(ip, hostname, sysDescr string) error {
 	m.deviceInfoCalled = true
 	m.deviceIP = ip
@@ -63,7 +68,7 @@ func (m *mockStateManager) IsSuspended(ip string) bool {
 	return false
 }
 
-func TestStartPingerCancel(t *testing.T) {
+func TestPingSchedulerCancel(t *testing.T) {
 	if os.Geteuid() != 0 {
 		t.Skip("Test requires root privileges for ICMP ping")
 	}
@@ -73,10 +78,26 @@ func TestStartPingerCancel(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	limiter := rate.NewLimiter(rate.Limit(100.0), 256)
 	var counter atomic.Int64
-	go StartPinger(ctx, nil, dev, 10*time.Millisecond, 2*time.Second, writer, stateMgr, limiter, &counter, nil, 10, 5*time.Minute)
+	go runPinger(ctx, dev, 10*time.Millisecond, 2*time.Second, 1, writer, stateMgr, limiter, &counter, nil, 10, 5*time.Minute)
 	time.Sleep(30 * time.Millisecond)
 	cancel()
 	if !writer.called {
 		t.Errorf("expected WritePingResult to be called")
 	}
 }
+
+// TestProbeAddressPolicy validates one-shot probes only reach targets allowed by the policy they are given
+func TestProbeAddressPolicy(t *testing.T) {
+	prober := NewSimulatedProber(time.Millisecond, 0, config.ProbeProfile{})
+
+	if _, err := Probe(prober, config.AddressPolicy{}, "127.0.0.1", 1, time.Second); err == nil {
+		t.Error("expected loopback target rejected by the default policy")
+	}
+	stats, err := Probe(prober, config.AddressPolicy{AllowLoopback: true}, "127.0.0.1", 1, time.Second)
+	if err != nil {
+		t.Fatalf("expected loopback target allowed by allow_loopback, got %v", err)
+	}
+	if stats.PacketsRecv != 1 {
+		t.Errorf("expected 1 reply, got %+v", stats)
+	}
+}
@@ -0,0 +1,133 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/ratelimit"
+	probing "github.com/prometheus-community/pro-bing"
+	"golang.org/x/time/rate"
+)
+
+// Ping engine names accepted by the ping_engine setting
+const (
+	PingEngineProbing = "probing" // pro-bing: one ICMP socket and receive goroutine per ping cycle
+	PingEngineBatch   = "batch"   // BatchProber: one shared raw or UDP ICMP socket for all targets (like fping)
+)
+
+// PingStats is the outcome of one ping cycle
+// Field names follow pro-bing's Statistics so callers read the same regardless of engine
+type PingStats struct {
+	PacketsSent int
+	PacketsRecv int
+	PacketLoss  float64 // Percent of sent packets without a reply
+	Rtts        []time.Duration
+	MinRtt      time.Duration
+	AvgRtt      time.Duration
+	MaxRtt      time.Duration
+	StdDevRtt   time.Duration
+}
+
+// Prober sends one cycle of count echo requests to ip, spaced and sized by its probe profile
+// timeout is how long the last echo request is awaited, so a cycle lasts up to timeout plus the profile's
+// spacing; an error means the cycle could not be run (not that the host is down)
+type Prober interface {
+	Ping(ip string, count int, timeout time.Duration) (*PingStats, error)
+}
+
+// Probe runs one ping cycle outside the scheduler (e.g. for a one-shot scan) with prober
+// ip must be allowed by policy
+func Probe(prober Prober, policy config.AddressPolicy, ip string, count int, timeout time.Duration) (*PingStats, error) {
+	if err := policy.ValidateIP(ip); err != nil {
+		return nil, err
+	}
+	return prober.Ping(ip, count, timeout)
+}
+
+// ProbeLimited runs one ping cycle like Probe after taking a token from ip's partition of limits (network_rate_limits,
+// nil = none) and limiter, so on-demand probes share the continuous pingers' rate limits
+func ProbeLimited(ctx context.Context, limiter *rate.Limiter, limits *ratelimit.Partitions, prober Prober, policy config.AddressPolicy, ip string, count int, timeout time.Duration) (*PingStats, error) {
+	if err := policy.ValidateIP(ip); err != nil {
+		return nil, err
+	}
+	if err := waitForToken(ctx, limiter, limits, ip); err != nil {
+		return nil, fmt.Errorf("waiting for ping rate limiter: %v", err)
+	}
+	return prober.Ping(ip, count, timeout)
+}
+
+// proBingProber runs each cycle on its own pro-bing pinger
+type proBingProber struct {
+	privileged bool   // Raw ICMP sockets (false: unprivileged UDP sockets)
+	source     string // Local IPv4 address echo requests are sent from ("" = any)
+	profile    config.ProbeProfile
+}
+
+// NewProBingProber creates the pro-bing ping engine over raw (privileged) or UDP ICMP sockets, as resolved by
+// ResolveICMPMode, sent from source ("" = chosen by the routing table); profile (probe_profiles.monitoring) sets the spacing, size and DSCP of the echo requests,
+// the count of each cycle is the caller's (pings_per_cycle)
+func NewProBingProber(privileged bool, source string, profile config.ProbeProfile) Prober {
+	return proBingProber{privileged: privileged, source: source, profile: profile}
+}
+
+// Ping runs one pro-bing cycle over a raw or, with unprivileged icmp_mode, a UDP ICMP socket
+func (p proBingProber) Ping(ip string, count int, timeout time.Duration) (*PingStats, error) {
+	pinger, err := probing.NewPinger(ip)
+	if err != nil {
+		return nil, err
+	}
+	profile := p.profile
+	pinger.Count = count                       // Echo requests per cycle
+	pinger.Interval = profile.PacketInterval() // Spacing between echo requests within a cycle
+	pinger.Size = profile.PacketSize()
+	pinger.SetTrafficClass(profile.TOS())
+	pinger.Timeout = timeout + profile.Spread(count) // Last packet gets the full timeout
+	pinger.Source = p.source                         // source_ip/source_interface ("" = any)
+	pinger.SetPrivileged(p.privileged)               // Raw sockets need root or CAP_NET_RAW
+	if err := pinger.Run(); err != nil {
+		return nil, err
+	}
+
+	stats := pinger.Statistics()
+	return &PingStats{
+		PacketsSent: stats.PacketsSent,
+		PacketsRecv: stats.PacketsRecv,
+		PacketLoss:  stats.PacketLoss,
+		Rtts:        stats.Rtts,
+		MinRtt:      stats.MinRtt,
+		AvgRtt:      stats.AvgRtt,
+		MaxRtt:      stats.MaxRtt,
+		StdDevRtt:   stats.StdDevRtt,
+	}, nil
+}
+
+// newPingStats computes loss and min/avg/max/stddev RTT from the replies of one cycle
+func newPingStats(sent int, rtts []time.Duration) *PingStats {
+	stats := &PingStats{PacketsSent: sent, PacketsRecv: len(rtts), Rtts: rtts}
+	if sent > 0 {
+		stats.PacketLoss = float64(sent-len(rtts)) / float64(sent) * 100
+	}
+	if len(rtts) == 0 {
+		return stats
+	}
+
+	var total time.Duration
+	stats.MinRtt, stats.MaxRtt = rtts[0], rtts[0]
+	for _, rtt := range rtts {
+		total += rtt
+		stats.MinRtt = min(stats.MinRtt, rtt)
+		stats.MaxRtt = max(stats.MaxRtt, rtt)
+	}
+	stats.AvgRtt = total / time.Duration(len(rtts))
+
+	var variance float64
+	for _, rtt := range rtts {
+		diff := float64(rtt - stats.AvgRtt)
+		variance += diff * diff
+	}
+	stats.StdDevRtt = time.Duration(math.Sqrt(variance / float64(len(rtts))))
+	return stats
+}
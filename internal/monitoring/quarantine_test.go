@@ -0,0 +1,39 @@
+package monitoring
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kljama/netscan/internal/state"
+)
+
+// quarantiningStateManager quarantines a fixed set of devices
+type quarantiningStateManager struct {
+	mockStateManagerForSuspension
+	quarantined map[string]bool
+}
+
+func (m *quarantiningStateManager) IsQuarantined(ip string) bool {
+	return m.quarantined[ip]
+}
+
+// TestQuarantinedDeviceSkipped verifies quarantined devices are neither pinged nor written, even while suspended
+func TestQuarantinedDeviceSkipped(t *testing.T) {
+	writer := &mockWriterForSuspension{}
+	s := newTestScheduler(writer, 1)
+	s.stateMgr = &quarantiningStateManager{
+		mockStateManagerForSuspension: mockStateManagerForSuspension{suspended: true},
+		quarantined:                   map[string]bool{"10.0.0.1": true},
+	}
+	s.Add(state.Device{IP: "10.0.0.1"})
+	s.Add(state.Device{IP: "10.0.0.2"})
+
+	s.ping(context.Background(), s.entries["10.0.0.1"])
+	s.ping(context.Background(), s.entries["10.0.0.2"])
+	if writesFor(writer, "10.0.0.1") != 0 {
+		t.Errorf("expected no writes for a quarantined device, got %+v", writer.getWriteCalls())
+	}
+	if writesFor(writer, "10.0.0.2") != 1 {
+		t.Errorf("expected the suspension status of the other device to be written, got %+v", writer.getWriteCalls())
+	}
+}
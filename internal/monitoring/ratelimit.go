@@ -0,0 +1,17 @@
+package monitoring
+
+import (
+	"context"
+
+	"github.com/kljama/netscan/internal/ratelimit"
+	"golang.org/x/time/rate"
+)
+
+// waitForToken takes a token from ip's network partition (limits, nil = none), then from the global limiter
+// The partition comes first so a throttled network does not hold global tokens while it waits
+func waitForToken(ctx context.Context, limiter *rate.Limiter, limits *ratelimit.Partitions, ip string) error {
+	if err := limits.Wait(ctx, ip); err != nil {
+		return err
+	}
+	return limiter.Wait(ctx)
+}
@@ -0,0 +1,388 @@
+package monitoring
+
+import (
+	"container/heap"
+	"context"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/ratelimit"
+	"github.com/kljama/netscan/internal/state"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/time/rate"
+)
+
+// firstPingDelay delays the first ping of a newly scheduled device to avoid an immediate ping storm
+const firstPingDelay = 1 * time.Second
+
+// pingEntry is one scheduled device
+type pingEntry struct {
+	device state.Device
+	due    time.Time // Next time the device should be pinged
+	index  int       // Position in the heap, -1 while a worker holds the entry
+	lastOK bool      // Previous cycle answered (counted for auto-tuning); only touched by the worker holding the entry
+}
+
+// pingQueue is a min-heap of entries ordered by due time (container/heap implementation)
+type pingQueue []*pingEntry
+
+func (q pingQueue) Len() int           { return len(q) }
+func (q pingQueue) Less(i, j int) bool { return q[i].due.Before(q[j].due) }
+
+func (q pingQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *pingQueue) Push(x interface{}) {
+	entry := x.(*pingEntry)
+	entry.index = len(*q)
+	*q = append(*q, entry)
+}
+
+func (q *pingQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	entry := old[n-1]
+	old[n-1] = nil // Avoid retaining removed entries
+	entry.index = -1
+	*q = old[:n-1]
+	return entry
+}
+
+// PingScheduler runs continuous ICMP monitoring for all devices on a fixed pool of workers
+// A heap keyed by next-due time replaces the goroutine and timer per device, so memory and scheduler
+// overhead scale with the worker count instead of the number of monitored devices
+// Interval is the time BETWEEN pings of a device, not a fixed schedule
+type PingScheduler struct {
+	interval        time.Duration
+	timeout         time.Duration
+	pingCount       int
+	workers         int
+	writer          PingWriter
+	stateMgr        StateManager
+	limiter         *rate.Limiter
+	networkLimits   *ratelimit.Partitions // network_rate_limits partitions taken before limiter (nil = none)
+	inFlightCounter *atomic.Int64
+	totalPingsSent  *atomic.Uint64
+	maxFails        int
+	backoff         time.Duration
+	policy          config.AddressPolicy    // Devices it rejects are never pinged (allow_loopback / allow_link_local)
+	prober          Prober                  // Ping engine and its probe profile
+	maintenance     MaintenanceResolver     // Planned outages: skipped or tagged cycles (nil = none)
+	startSpread     time.Duration           // First pings are spread randomly over this window (0 = all after firstPingDelay)
+	jitter          time.Duration           // Each cycle is rescheduled up to this much earlier or later (0 = exact interval)
+	lagObserver     func(lag time.Duration) // Receives how late each cycle reached a worker (nil = not observed)
+	latency         LatencyObserver         // Evaluates latency thresholds on successful cycles (nil = none configured)
+	runningWorkers  atomic.Int64            // Workers started by Run that have not exited yet
+
+	mu      sync.Mutex
+	queue   pingQueue
+	entries map[string]*pingEntry // All scheduled devices, including those held by a worker
+	wake    chan struct{}         // Signals the dispatcher that the earliest due time may have changed
+}
+
+// NewPingScheduler creates a scheduler; call Run to start pinging and Add/Remove to manage devices
+func NewPingScheduler(interval, timeout time.Duration, pingCount, workers int, writer PingWriter, stateMgr StateManager, limiter *rate.Limiter, inFlightCounter *atomic.Int64, totalPingsSent *atomic.Uint64, maxConsecutiveFails int, backoffDuration time.Duration, policy config.AddressPolicy, maintenance MaintenanceResolver, networkLimits *ratelimit.Partitions) *PingScheduler {
+	if workers < 1 {
+		workers = 1
+	}
+	return &PingScheduler{
+		interval:        interval,
+		timeout:         timeout,
+		pingCount:       pingCount,
+		workers:         workers,
+		writer:          writer,
+		stateMgr:        stateMgr,
+		limiter:         limiter,
+		inFlightCounter: inFlightCounter,
+		totalPingsSent:  totalPingsSent,
+		maxFails:        maxConsecutiveFails,
+		backoff:         backoffDuration,
+		policy:          policy,
+		maintenance:     maintenance,
+		networkLimits:   networkLimits,
+		prober:          NewProBingProber(true, "", config.ProbeProfile{}),
+		entries:         make(map[string]*pingEntry),
+		wake:            make(chan struct{}, 1),
+	}
+}
+
+// SetSpread smooths probe load across the interval: the first ping of each added device gets a
+// random offset within startSpread, and every later cycle a random offset within ±jitter
+// Call before Run
+func (s *PingScheduler) SetSpread(startSpread, jitter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.startSpread = startSpread
+	s.jitter = jitter
+}
+
+// SetProber selects the ping engine, which carries the ICMP socket mode and the monitoring probe profile
+// (default: pro-bing over raw sockets with the default profile). Call before Run
+func (s *PingScheduler) SetProber(p Prober) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prober = p
+}
+
+// SetLagObserver registers a callback receiving, for every cycle, how long after its due time it reached
+// a worker (scheduling latency); it runs on the worker and must not block. Call before Run
+func (s *PingScheduler) SetLagObserver(observe func(lag time.Duration)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lagObserver = observe
+}
+
+// SetLatencyObserver registers the observer of successful ping cycles (latency thresholds); it runs on the
+// worker after the cycle was recorded. Cycles tagged by a maintenance window are not observed. Call before Run
+func (s *PingScheduler) SetLatencyObserver(o LatencyObserver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = o
+}
+
+// randomOffset returns a uniformly random duration in [0, d), or 0 if d <= 0
+func randomOffset(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(d)))
+}
+
+// Add schedules a device, first pinged after a short delay plus its random start offset
+// Returns false if the device is already scheduled
+func (s *PingScheduler) Add(device state.Device) bool {
+	s.mu.Lock()
+	if _, exists := s.entries[device.IP]; exists {
+		s.mu.Unlock()
+		return false
+	}
+	entry := &pingEntry{device: device, due: time.Now().Add(firstPingDelay + randomOffset(s.startSpread))}
+	s.entries[device.IP] = entry
+	heap.Push(&s.queue, entry)
+	s.mu.Unlock()
+
+	s.notify()
+	return true
+}
+
+// Remove unschedules a device; a ping already in progress finishes but is not rescheduled
+// Returns false if the device was not scheduled
+func (s *PingScheduler) Remove(ip string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, exists := s.entries[ip]
+	if !exists {
+		return false
+	}
+	delete(s.entries, ip)
+	if entry.index >= 0 {
+		heap.Remove(&s.queue, entry.index)
+	}
+	return true
+}
+
+// Has reports whether a device is scheduled
+func (s *PingScheduler) Has(ip string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, exists := s.entries[ip]
+	return exists
+}
+
+// Count returns the number of scheduled devices
+func (s *PingScheduler) Count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// IPs returns the IPs of all scheduled devices
+func (s *PingScheduler) IPs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ips := make([]string, 0, len(s.entries))
+	for ip := range s.entries {
+		ips = append(ips, ip)
+	}
+	return ips
+}
+
+// Workers returns the size of the worker pool
+func (s *PingScheduler) Workers() int {
+	return s.workers
+}
+
+// RunningWorkers returns the number of workers that have not exited yet (during shutdown: still finishing a cycle)
+func (s *PingScheduler) RunningWorkers() int {
+	return int(s.runningWorkers.Load())
+}
+
+// Run dispatches due devices to the worker pool until ctx is cancelled, then waits for the workers to exit
+func (s *PingScheduler) Run(ctx context.Context) {
+	jobs := make(chan *pingEntry)
+	var wg sync.WaitGroup
+	for i := 0; i < s.workers; i++ {
+		wg.Add(1)
+		s.runningWorkers.Add(1)
+		go s.worker(ctx, &wg, jobs)
+	}
+
+	s.dispatch(ctx, jobs)
+	close(jobs)
+	wg.Wait()
+}
+
+// dispatch hands entries to workers as they come due
+// Sending blocks while all workers are busy, so overdue devices queue up in due-time order
+func (s *PingScheduler) dispatch(ctx context.Context, jobs chan<- *pingEntry) {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		entry, wait := s.next(time.Now())
+		if entry != nil {
+			select {
+			case jobs <- entry:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		timer.Reset(wait)
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// next pops the earliest entry if it is due, otherwise returns how long to wait for it
+func (s *PingScheduler) next(now time.Time) (*pingEntry, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.queue) == 0 {
+		return nil, time.Hour // Idle until Add wakes the dispatcher
+	}
+	if wait := s.queue[0].due.Sub(now); wait > 0 {
+		return nil, wait
+	}
+	return heap.Pop(&s.queue).(*pingEntry), 0
+}
+
+// worker pings entries until the jobs channel is closed
+func (s *PingScheduler) worker(ctx context.Context, wg *sync.WaitGroup, jobs <-chan *pingEntry) {
+	defer wg.Done()
+	defer s.runningWorkers.Add(-1)
+	for entry := range jobs {
+		if s.lagObserver != nil {
+			s.lagObserver(time.Since(entry.due))
+		}
+		s.ping(ctx, entry)
+		s.reschedule(entry)
+	}
+}
+
+// ping runs one cycle for an entry: circuit breaker check, rate limiter token, then the ping itself
+func (s *PingScheduler) ping(ctx context.Context, entry *pingEntry) {
+	// Panic recovery so one bad cycle does not take down a shared worker
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().
+				Str("ip", entry.device.IP).
+				Interface("panic", r).
+				Msg("Pinger panic recovered")
+		}
+	}()
+
+	if ctx.Err() != nil || !s.scheduled(entry) {
+		return // Shutting down, or removed while waiting for a worker
+	}
+
+	// Planned outage: no ping, so the device can neither trip the circuit breaker nor go down
+	if s.maintenance.action(entry.device.IP) == config.MaintenanceSkip {
+		log.Debug().Str("ip", entry.device.IP).Msg("Device is in a maintenance window, skipping ping.")
+		return
+	}
+
+	// Quarantined devices are not probed until an operator releases them
+	if isQuarantined(s.stateMgr, entry.device.IP) {
+		log.Debug().Str("ip", entry.device.IP).Msg("Device is quarantined, skipping ping.")
+		return
+	}
+
+	// 1. CHECK CIRCUIT BREAKER *BEFORE* ACQUIRING TOKEN
+	if s.stateMgr.IsSuspended(entry.device.IP) {
+		// Half-open: one test ping per suspension, so a recovered device does not wait for the full backoff
+		if claimHalfOpenProbe(s.stateMgr, entry.device.IP) {
+			if err := waitForToken(ctx, s.limiter, s.networkLimits, entry.device.IP); err != nil {
+				return
+			}
+			entry.lastOK = performHalfOpenProbe(s.prober, entry.device, s.timeout, s.writer, s.stateMgr, s.inFlightCounter, s.totalPingsSent, s.backoff, s.policy)
+			return
+		}
+		log.Debug().Str("ip", entry.device.IP).Msg("Device ping is suspended (circuit breaker), skipping.")
+		recordPingCycle(s.stateMgr, entry.device.IP, 0, 0, 0, 0, 0) // Counts as an unavailable cycle
+
+		// Write suspension status to InfluxDB so we can track which devices are suspended
+		if err := s.writer.WritePingResult(entry.device.IP, 0, false, true); err != nil {
+			log.Error().
+				Str("ip", entry.device.IP).
+				Err(err).
+				Msg("Failed to write suspension status")
+		}
+		return
+	}
+
+	// 2. Acquire tokens from the network partition and global rate limiter (blocks until available or context cancelled)
+	if err := waitForToken(ctx, s.limiter, s.networkLimits, entry.device.IP); err != nil {
+		return
+	}
+
+	// 3. Perform the ping operation with in-flight tracking and circuit breaker
+	ok := performPingWithCircuitBreaker(s.prober, entry.device, s.timeout, s.pingCount, s.writer, s.stateMgr, s.inFlightCounter, s.totalPingsSent, s.maxFails, s.backoff, s.policy, s.maintenance, s.latency)
+	countOutcome(&pingsAfterSuccess, &pingsLost, entry.lastOK, ok)
+	entry.lastOK = ok
+}
+
+// scheduled reports whether the entry is still the live entry for its device
+func (s *PingScheduler) scheduled(entry *pingEntry) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.entries[entry.device.IP] == entry
+}
+
+// reschedule puts the entry back on the heap one interval (±jitter) from now, unless it was removed meanwhile
+func (s *PingScheduler) reschedule(entry *pingEntry) {
+	s.mu.Lock()
+	if s.entries[entry.device.IP] != entry {
+		s.mu.Unlock()
+		return
+	}
+	entry.due = time.Now().Add(s.interval)
+	if s.jitter > 0 {
+		entry.due = entry.due.Add(randomOffset(2*s.jitter) - s.jitter)
+	}
+	heap.Push(&s.queue, entry)
+	s.mu.Unlock()
+
+	s.notify()
+}
+
+// notify wakes the dispatcher without blocking
+func (s *PingScheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,238 @@
+package monitoring
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/state"
+	"golang.org/x/time/rate"
+)
+
+// newTestScheduler creates a scheduler whose devices are all suspended, so no ICMP is sent
+func newTestScheduler(writer PingWriter, workers int) *PingScheduler {
+	var inFlight atomic.Int64
+	var total atomic.Uint64
+	limiter := rate.NewLimiter(rate.Limit(1000.0), 1000)
+	return NewPingScheduler(50*time.Millisecond, time.Second, 1, workers, writer, &mockStateManagerForSuspension{suspended: true}, limiter, &inFlight, &total, 10, 5*time.Minute, config.AddressPolicy{}, nil, nil)
+}
+
+// runPinger monitors a single device on a one-worker scheduler until ctx is cancelled
+func runPinger(ctx context.Context, device state.Device, interval, timeout time.Duration, pingCount int, writer PingWriter, stateMgr StateManager, limiter *rate.Limiter, inFlightCounter *atomic.Int64, totalPingsSent *atomic.Uint64, maxConsecutiveFails int, backoffDuration time.Duration) {
+	s := NewPingScheduler(interval, timeout, pingCount, 1, writer, stateMgr, limiter, inFlightCounter, totalPingsSent, maxConsecutiveFails, backoffDuration, config.AddressPolicy{}, nil, nil)
+	s.Add(device)
+	s.Run(ctx)
+}
+
+// writesFor counts write calls for one IP
+func writesFor(writer *mockWriterForSuspension, ip string) int {
+	count := 0
+	for _, call := range writer.getWriteCalls() {
+		if call.ip == ip {
+			count++
+		}
+	}
+	return count
+}
+
+// TestPingQueueOrder verifies the heap pops entries in due-time order
+func TestPingQueueOrder(t *testing.T) {
+	base := time.Now()
+	var q pingQueue
+	for _, offset := range []int{5, 1, 4, 2, 3} {
+		heap.Push(&q, &pingEntry{
+			device: state.Device{IP: fmt.Sprintf("10.0.0.%d", offset)},
+			due:    base.Add(time.Duration(offset) * time.Second),
+		})
+	}
+
+	for want := 1; want <= 5; want++ {
+		entry := heap.Pop(&q).(*pingEntry)
+		if got := entry.device.IP; got != fmt.Sprintf("10.0.0.%d", want) {
+			t.Fatalf("pop %d: expected 10.0.0.%d, got %s", want, want, got)
+		}
+		if entry.index != -1 {
+			t.Errorf("popped entry should have index -1, got %d", entry.index)
+		}
+	}
+}
+
+// TestPingSchedulerAddRemove verifies device bookkeeping without running the scheduler
+func TestPingSchedulerAddRemove(t *testing.T) {
+	s := newTestScheduler(&mockWriterForSuspension{}, 1)
+
+	if !s.Add(state.Device{IP: "10.0.0.1"}) {
+		t.Fatal("first Add should succeed")
+	}
+	if s.Add(state.Device{IP: "10.0.0.1"}) {
+		t.Error("duplicate Add should return false")
+	}
+	s.Add(state.Device{IP: "10.0.0.2"})
+	s.Add(state.Device{IP: "10.0.0.3"})
+
+	if got := s.Count(); got != 3 {
+		t.Errorf("expected 3 scheduled devices, got %d", got)
+	}
+	if !s.Remove("10.0.0.2") {
+		t.Error("Remove of a scheduled device should return true")
+	}
+	if s.Remove("10.0.0.2") {
+		t.Error("second Remove should return false")
+	}
+	if s.Has("10.0.0.2") {
+		t.Error("removed device should not be scheduled")
+	}
+	if got := len(s.IPs()); got != 2 {
+		t.Errorf("expected 2 IPs, got %d", got)
+	}
+	if got := s.queue.Len(); got != 2 {
+		t.Errorf("expected 2 heap entries after Remove, got %d", got)
+	}
+}
+
+// TestPingSchedulerRun verifies every device is pinged repeatedly by a pool smaller than the device count
+func TestPingSchedulerRun(t *testing.T) {
+	writer := &mockWriterForSuspension{}
+	s := newTestScheduler(writer, 4)
+
+	const devices = 50
+	for i := 1; i <= devices; i++ {
+		s.Add(state.Device{IP: fmt.Sprintf("10.0.1.%d", i)})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	// First pings are due after firstPingDelay, then every 50ms
+	time.Sleep(firstPingDelay + 300*time.Millisecond)
+	if got := s.RunningWorkers(); got != s.Workers() {
+		t.Errorf("expected %d running workers, got %d", s.Workers(), got)
+	}
+	for i := 1; i <= devices; i++ {
+		ip := fmt.Sprintf("10.0.1.%d", i)
+		if got := writesFor(writer, ip); got < 2 {
+			t.Errorf("%s: expected at least 2 cycles, got %d", ip, got)
+		}
+	}
+	for _, call := range writer.getWriteCalls() {
+		if !call.suspended {
+			t.Fatalf("expected suspended writes only, got %+v", call)
+		}
+	}
+
+	// A removed device is no longer pinged
+	s.Remove("10.0.1.1")
+	time.Sleep(100 * time.Millisecond) // Let an in-flight cycle finish
+	before := writesFor(writer, "10.0.1.1")
+	time.Sleep(200 * time.Millisecond)
+	if after := writesFor(writer, "10.0.1.1"); after != before {
+		t.Errorf("removed device was pinged %d more times", after-before)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return after context cancellation")
+	}
+	if got := s.RunningWorkers(); got != 0 {
+		t.Errorf("expected no running workers after Run returned, got %d", got)
+	}
+}
+
+// TestPingSchedulerAddWakesDispatcher verifies a device added to an idle scheduler is picked up
+func TestPingSchedulerAddWakesDispatcher(t *testing.T) {
+	writer := &mockWriterForSuspension{}
+	s := newTestScheduler(writer, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	time.Sleep(50 * time.Millisecond) // Dispatcher is now idle on an empty heap
+	s.Add(state.Device{IP: "10.0.2.1"})
+
+	time.Sleep(firstPingDelay + 200*time.Millisecond)
+	if writesFor(writer, "10.0.2.1") == 0 {
+		t.Error("device added to an idle scheduler was never pinged")
+	}
+}
+
+// TestPingSchedulerSpread verifies first pings are spread over the start window and cycles are jittered
+func TestPingSchedulerSpread(t *testing.T) {
+	s := newTestScheduler(&mockWriterForSuspension{}, 1)
+	s.SetSpread(10*time.Second, 20*time.Millisecond)
+
+	before := time.Now()
+	var earliest, latest time.Time
+	for i := 1; i <= 100; i++ {
+		s.Add(state.Device{IP: fmt.Sprintf("10.0.3.%d", i)})
+	}
+	for _, entry := range s.entries {
+		if earliest.IsZero() || entry.due.Before(earliest) {
+			earliest = entry.due
+		}
+		if entry.due.After(latest) {
+			latest = entry.due
+		}
+	}
+	if earliest.Before(before.Add(firstPingDelay)) || latest.After(time.Now().Add(firstPingDelay+10*time.Second)) {
+		t.Errorf("first pings due outside the start window: %v to %v", earliest.Sub(before), latest.Sub(before))
+	}
+	if latest.Sub(earliest) < 5*time.Second {
+		t.Errorf("expected first pings spread over the window, got %v", latest.Sub(earliest))
+	}
+
+	// Every rescheduled cycle lands within interval ± jitter
+	entry, _ := s.next(latest)
+	for i := 0; i < 50; i++ {
+		now := time.Now()
+		s.reschedule(entry)
+		if wait := entry.due.Sub(now); wait < s.interval-s.jitter || wait > s.interval+s.jitter+time.Millisecond {
+			t.Fatalf("rescheduled %v ahead, expected %v ± %v", wait, s.interval, s.jitter)
+		}
+		heap.Remove(&s.queue, entry.index)
+	}
+}
+
+// TestPingSchedulerLagObserver verifies every dispatched cycle reports its lag behind the due time
+func TestPingSchedulerLagObserver(t *testing.T) {
+	writer := &mockWriterForSuspension{}
+	s := newTestScheduler(writer, 2)
+	var observed atomic.Int64
+	var negative atomic.Bool
+	s.SetLagObserver(func(lag time.Duration) {
+		observed.Add(1)
+		if lag < 0 {
+			negative.Store(true)
+		}
+	})
+	for i := 1; i <= 10; i++ {
+		s.Add(state.Device{IP: fmt.Sprintf("10.0.4.%d", i)})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	time.Sleep(firstPingDelay + 200*time.Millisecond)
+	cancel()
+	<-done
+
+	if got, writes := observed.Load(), len(writer.getWriteCalls()); got < int64(writes) || writes == 0 {
+		t.Errorf("expected a lag observation per cycle, got %d for %d cycles", got, writes)
+	}
+	if negative.Load() {
+		t.Error("a cycle was dispatched before it was due")
+	}
+}
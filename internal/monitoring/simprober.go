@@ -0,0 +1,59 @@
+package monitoring
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+)
+
+// SimulatedProber answers ping cycles from an in-memory device model instead of the network
+// Used by "netscan bench" to drive the scheduler and sinks at scale without sockets or privileges
+// Each IP gets a stable base RTT between half and one and a half times rtt with ±10% per-packet variation;
+// every echo request is lost with probability loss. Ping blocks for as long as a real cycle would:
+// the packet spread plus the last reply's RTT, or the full timeout when the last request is lost
+type SimulatedProber struct {
+	rtt     time.Duration
+	loss    float64
+	profile config.ProbeProfile // Only the packet spacing is simulated
+}
+
+// NewSimulatedProber creates a simulated ping engine; loss is a probability between 0 and 1
+func NewSimulatedProber(rtt time.Duration, loss float64, profile config.ProbeProfile) *SimulatedProber {
+	return &SimulatedProber{rtt: rtt, loss: min(max(loss, 0), 1), profile: profile}
+}
+
+// Ping simulates one cycle of count echo requests to ip
+func (p *SimulatedProber) Ping(ip string, count int, timeout time.Duration) (*PingStats, error) {
+	if count < 1 {
+		count = 1
+	}
+	base := p.baseRTT(ip)
+	rtts := make([]time.Duration, 0, count)
+	lastAnswered := false
+	for i := 0; i < count; i++ {
+		lastAnswered = rand.Float64() >= p.loss
+		if lastAnswered {
+			rtts = append(rtts, base+randomOffset(base/5)-base/10)
+		}
+	}
+
+	spread := p.profile.Spread(count)
+	wait := spread + timeout
+	if lastAnswered {
+		wait = spread + min(rtts[len(rtts)-1], timeout)
+	}
+	time.Sleep(wait)
+	return newPingStats(count, rtts), nil
+}
+
+// baseRTT derives a stable RTT for ip from a hash of the address
+func (p *SimulatedProber) baseRTT(ip string) time.Duration {
+	if p.rtt <= 0 {
+		return time.Microsecond
+	}
+	h := fnv.New32a()
+	h.Write([]byte(ip))
+	return max(p.rtt/2+time.Duration(uint64(h.Sum32())%uint64(p.rtt)), time.Microsecond)
+}
@@ -0,0 +1,53 @@
+package monitoring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+)
+
+// TestSimulatedProber verifies stable per-IP RTTs, loss and the time a cycle blocks
+func TestSimulatedProber(t *testing.T) {
+	p := NewSimulatedProber(10*time.Millisecond, 0, config.ProbeProfile{})
+
+	stats, err := p.Ping("10.0.0.1", 3, time.Second)
+	if err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if stats.PacketsSent != 3 || stats.PacketsRecv != 3 || stats.PacketLoss != 0 {
+		t.Fatalf("expected 3 of 3 replies, got %+v", stats)
+	}
+	if stats.MinRtt < 4*time.Millisecond || stats.MaxRtt > 17*time.Millisecond {
+		t.Errorf("RTTs outside 0.5-1.5x rtt ±10%%: min %v, max %v", stats.MinRtt, stats.MaxRtt)
+	}
+	if base := p.baseRTT("10.0.0.1"); base != p.baseRTT("10.0.0.1") {
+		t.Error("base RTT of an IP should be stable")
+	}
+
+	lossy := NewSimulatedProber(time.Millisecond, 1, config.ProbeProfile{})
+	start := time.Now()
+	stats, err = lossy.Ping("10.0.0.2", 2, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if stats.PacketsRecv != 0 || stats.PacketLoss != 100 || stats.AvgRtt != 0 {
+		t.Errorf("expected every request lost, got %+v", stats)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("a lost cycle should block for the timeout, returned after %v", elapsed)
+	}
+}
+
+// TestSimulatedProberSpread verifies a cycle waits for the last packet's full timeout after the profile's spacing
+func TestSimulatedProberSpread(t *testing.T) {
+	p := NewSimulatedProber(time.Millisecond, 1, config.ProbeProfile{Interval: 50 * time.Millisecond})
+
+	start := time.Now()
+	if _, err := p.Ping("10.0.0.3", 3, 20*time.Millisecond); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 120*time.Millisecond {
+		t.Errorf("a lost 3-packet cycle should block for 2 intervals plus the timeout, returned after %v", elapsed)
+	}
+}
@@ -0,0 +1,23 @@
+package monitoring
+
+import (
+	"time"
+
+	"github.com/kljama/netscan/internal/state"
+)
+
+// DeviceInfoTracker is implemented by state managers that remember the last written device_info of each device
+// Pollers of other state managers write device_info on every poll
+type DeviceInfoTracker interface {
+	RecordDeviceInfo(ip string, info state.DeviceInfo, now time.Time, refresh time.Duration) bool
+}
+
+// shouldWriteDeviceInfo reports whether a poll's device_info must be written, recording it with the tracker
+// Unchanged device_info is written again once refresh has passed since the last write (0 = every poll)
+func shouldWriteDeviceInfo(stateMgr SNMPStateManager, ip string, info state.DeviceInfo, now time.Time, refresh time.Duration) bool {
+	tracker, ok := stateMgr.(DeviceInfoTracker)
+	if !ok {
+		return true
+	}
+	return tracker.RecordDeviceInfo(ip, info, now, refresh)
+}
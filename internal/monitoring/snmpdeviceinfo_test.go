@@ -0,0 +1,39 @@
+package monitoring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/state"
+)
+
+// untrackedStateManager is an SNMP state manager that does not remember device_info
+type untrackedStateManager struct{}
+
+func (untrackedStateManager) ReportSNMPFail(ip string, maxFails int, backoff time.Duration) bool {
+	return false
+}
+func (untrackedStateManager) ReportSNMPSuccess(ip string)                    {}
+func (untrackedStateManager) UpdateDeviceSNMP(ip, hostname, sysDescr string) {}
+func (untrackedStateManager) IsSNMPSuspended(ip string) bool                 { return false }
+
+// TestShouldWriteDeviceInfo verifies unchanged device_info is skipped until device_info_refresh passes
+func TestShouldWriteDeviceInfo(t *testing.T) {
+	mgr := state.NewManager(10)
+	mgr.AddDevice("10.0.0.1")
+	info := state.DeviceInfo{Hostname: "sw1", SysDescr: "IOS 15.2"}
+	now := time.Now()
+	if !shouldWriteDeviceInfo(mgr, "10.0.0.1", info, now, time.Hour) {
+		t.Error("expected the first poll to be written")
+	}
+	if shouldWriteDeviceInfo(mgr, "10.0.0.1", info, now.Add(time.Minute), time.Hour) {
+		t.Error("expected an unchanged poll to be skipped")
+	}
+	if !shouldWriteDeviceInfo(mgr, "10.0.0.1", info, now.Add(time.Hour), time.Hour) {
+		t.Error("expected an unchanged poll to be written after device_info_refresh")
+	}
+
+	if !shouldWriteDeviceInfo(untrackedStateManager{}, "10.0.0.1", info, now, time.Hour) {
+		t.Error("expected state managers without device_info tracking to write every poll")
+	}
+}
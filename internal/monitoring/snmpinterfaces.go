@@ -0,0 +1,152 @@
+package monitoring
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/kljama/netscan/internal/snmp"
+	"github.com/kljama/netscan/internal/validate"
+	"github.com/rs/zerolog/log"
+)
+
+// IF-MIB ifTable column OIDs (without instance suffix)
+const (
+	oidIfDescr      = "1.3.6.1.2.1.2.2.1.2"
+	oidIfSpeed      = "1.3.6.1.2.1.2.2.1.5"
+	oidIfOperStatus = "1.3.6.1.2.1.2.2.1.8"
+	oidIfInOctets   = "1.3.6.1.2.1.2.2.1.10"
+	oidIfOutOctets  = "1.3.6.1.2.1.2.2.1.16"
+)
+
+// ifTableColumns lists the ifTable columns walked on every interface poll
+var ifTableColumns = []string{oidIfDescr, oidIfSpeed, oidIfOperStatus, oidIfInOctets, oidIfOutOctets}
+
+// InterfaceStats holds a single row of the IF-MIB ifTable
+type InterfaceStats struct {
+	Index      int    // ifIndex
+	Descr      string // ifDescr (interface name)
+	OperStatus int    // ifOperStatus (1=up, 2=down, 3=testing, ...)
+	InOctets   uint64 // ifInOctets counter
+	OutOctets  uint64 // ifOutOctets counter
+	Speed      uint64 // ifSpeed in bits per second
+}
+
+// walkInterfaceTable walks the ifTable columns of interest and returns one entry per ifIndex
+// A column that fails to walk is skipped so partial IF-MIB implementations still report what they have
+func walkInterfaceTable(ctx context.Context, client *snmp.Client, maxLength int) ([]InterfaceStats, error) {
+	var (
+		pdus    []gosnmp.SnmpPDU
+		lastErr error
+	)
+	for _, column := range ifTableColumns {
+		results, err := client.Walk(ctx, column)
+		if err != nil {
+			log.Debug().
+				Str("target", client.Target()).
+				Str("oid", column).
+				Err(err).
+				Msg("ifTable column walk failed")
+			lastErr = err
+			continue
+		}
+		pdus = append(pdus, results...)
+	}
+
+	if len(pdus) == 0 {
+		return nil, lastErr
+	}
+	return buildInterfaceTable(pdus, maxLength), nil
+}
+
+// buildInterfaceTable groups walked ifTable PDUs by ifIndex into InterfaceStats rows
+// Rows are returned sorted by ifIndex; rows without an ifDescr fall back to "if<index>"
+// ifDescr is cut to maxLength bytes (snmp.max_string_length, 0 = default)
+func buildInterfaceTable(pdus []gosnmp.SnmpPDU, maxLength int) []InterfaceStats {
+	rows := make(map[int]*InterfaceStats)
+
+	for _, pdu := range pdus {
+		name := strings.TrimPrefix(pdu.Name, ".")
+		column, index, ok := splitColumnIndex(name)
+		if !ok {
+			continue
+		}
+
+		row, exists := rows[index]
+		if !exists {
+			row = &InterfaceStats{Index: index}
+			rows[index] = row
+		}
+
+		switch column {
+		case oidIfDescr:
+			if descr, err := validate.SNMPString(pdu.Value, "ifDescr", maxLength); err == nil {
+				row.Descr = descr
+			}
+		case oidIfSpeed:
+			row.Speed = gosnmp.ToBigInt(pdu.Value).Uint64()
+		case oidIfOperStatus:
+			row.OperStatus = int(gosnmp.ToBigInt(pdu.Value).Int64())
+		case oidIfInOctets:
+			row.InOctets = gosnmp.ToBigInt(pdu.Value).Uint64()
+		case oidIfOutOctets:
+			row.OutOctets = gosnmp.ToBigInt(pdu.Value).Uint64()
+		}
+	}
+
+	result := make([]InterfaceStats, 0, len(rows))
+	for _, row := range rows {
+		if row.Descr == "" {
+			row.Descr = "if" + strconv.Itoa(row.Index)
+		}
+		result = append(result, *row)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Index < result[j].Index
+	})
+	return result
+}
+
+// splitColumnIndex splits an ifTable instance OID into its column OID and ifIndex
+func splitColumnIndex(oid string) (string, int, bool) {
+	dot := strings.LastIndex(oid, ".")
+	if dot <= 0 {
+		return "", 0, false
+	}
+	index, err := strconv.Atoi(oid[dot+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	return oid[:dot], index, true
+}
+
+// pollInterfaces walks the ifTable, writes one interface point per row and returns the rows
+// Interface polling is best-effort: failures are logged but do not trip the SNMP circuit breaker
+func pollInterfaces(ctx context.Context, client *snmp.Client, ip string, maxLength int, writer SNMPWriter) []InterfaceStats {
+	ifaces, err := walkInterfaceTable(ctx, client, maxLength)
+	if err != nil {
+		log.Debug().
+			Str("ip", ip).
+			Err(err).
+			Msg("Interface table walk failed")
+		return nil
+	}
+
+	for _, iface := range ifaces {
+		if err := writer.WriteInterfaceMetrics(ip, iface.Index, iface.Descr, iface.OperStatus, iface.InOctets, iface.OutOctets, iface.Speed); err != nil {
+			log.Error().
+				Str("ip", ip).
+				Int("if_index", iface.Index).
+				Err(err).
+				Msg("Failed to write interface metrics")
+		}
+	}
+
+	log.Debug().
+		Str("ip", ip).
+		Int("interfaces", len(ifaces)).
+		Msg("Interface table polled")
+	return ifaces
+}
@@ -0,0 +1,188 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/snmp"
+	"github.com/kljama/netscan/internal/state"
+	"github.com/kljama/netscan/internal/validate"
+	"github.com/rs/zerolog/log"
+)
+
+// pollOIDGroups queries every custom OID group that applies to the device, writes one point per group
+// and returns the values read (for the SNMP result cache)
+// Like interface polling this is best-effort: failures are logged but do not trip the SNMP circuit breaker
+// String values are cut to maxLength bytes (snmp.max_string_length, 0 = default)
+func pollOIDGroups(ctx context.Context, client *snmp.Client, ip string, groups []config.OIDGroupConfig, maxLength int, writer SNMPWriter) []state.SNMPValue {
+	var values []state.SNMPValue
+	for i := range groups {
+		group := &groups[i]
+		if !group.Matches(ip) {
+			continue
+		}
+
+		fields, err := queryOIDGroup(ctx, client, group, maxLength)
+		if err != nil {
+			log.Debug().
+				Str("ip", ip).
+				Str("oid_group", group.Name).
+				Err(err).
+				Msg("Custom OID group query failed")
+			continue
+		}
+		values = append(values, groupValues(group.Name, fields, time.Now())...)
+
+		if err := writer.WriteCustomMetrics(ip, group.Measurement, group.Name, fields); err != nil {
+			log.Error().
+				Str("ip", ip).
+				Str("oid_group", group.Name).
+				Err(err).
+				Msg("Failed to write custom OID metrics")
+		}
+	}
+	return values
+}
+
+// queryOIDGroup fetches the group's OIDs (chunked to the agent's MaxOids) and converts them to field values
+// OIDs the device does not implement are skipped; an error is returned only if no value could be read
+func queryOIDGroup(ctx context.Context, client *snmp.Client, group *config.OIDGroupConfig, maxLength int) (map[string]interface{}, error) {
+	byOID := make(map[string]config.OIDConfig, len(group.OIDs))
+	oids := make([]string, 0, len(group.OIDs))
+	for _, oid := range group.OIDs {
+		byOID[oid.OID] = oid
+		oids = append(oids, oid.OID)
+	}
+
+	chunkSize := client.MaxOids()
+
+	fields := make(map[string]interface{}, len(oids))
+	var lastErr error
+	for start := 0; start < len(oids); start += chunkSize {
+		end := start + chunkSize
+		if end > len(oids) {
+			end = len(oids)
+		}
+
+		variables, err := client.Get(ctx, oids[start:end])
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		for _, pdu := range variables {
+			oidCfg, ok := byOID[strings.TrimPrefix(pdu.Name, ".")]
+			if !ok {
+				continue
+			}
+			value, err := convertOIDValue(pdu, oidCfg, maxLength)
+			if err != nil {
+				log.Debug().
+					Str("target", client.Target()).
+					Str("oid", oidCfg.OID).
+					Err(err).
+					Msg("Skipping custom OID value")
+				lastErr = err
+				continue
+			}
+			fields[oidCfg.Name] = value
+		}
+	}
+
+	if len(fields) == 0 {
+		if lastErr == nil {
+			lastErr = fmt.Errorf("no values returned")
+		}
+		return nil, lastErr
+	}
+	return fields, nil
+}
+
+// convertOIDValue converts an SNMP PDU into a field value according to the configured type and scale
+// Numeric values with a scale other than 1.0 are returned as float64
+func convertOIDValue(pdu gosnmp.SnmpPDU, oidCfg config.OIDConfig, maxLength int) (interface{}, error) {
+	switch pdu.Type {
+	case gosnmp.NoSuchObject, gosnmp.NoSuchInstance, gosnmp.EndOfMibView, gosnmp.Null:
+		return nil, fmt.Errorf("%s not available on device", oidCfg.Name)
+	}
+
+	// Some agents return numbers as OctetStrings (e.g. sensor readings "23.5")
+	var text string
+	isText := pdu.Type == gosnmp.OctetString
+	if isText {
+		var ok bool
+		if text, ok = pdu.Value.(string); !ok {
+			raw, _ := pdu.Value.([]byte)
+			text = string(raw)
+		}
+		text = strings.TrimSpace(text)
+	}
+
+	scale := oidCfg.Scale
+	if scale == 0 {
+		scale = 1.0 // Unset scale (defaults not applied) means no scaling
+	}
+
+	switch oidCfg.Type {
+	case config.OIDTypeString:
+		return validate.SNMPString(pdu.Value, oidCfg.Name, maxLength)
+
+	case config.OIDTypeFloat:
+		var value float64
+		if isText {
+			parsed, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s: cannot parse %q as float", oidCfg.Name, text)
+			}
+			value = parsed
+		} else {
+			switch v := pdu.Value.(type) {
+			case float32: // OpaqueFloat
+				value = float64(v)
+			case float64: // OpaqueDouble
+				value = v
+			default:
+				value, _ = new(big.Float).SetInt(gosnmp.ToBigInt(pdu.Value)).Float64()
+			}
+		}
+		return value * scale, nil
+
+	case config.OIDTypeCounter:
+		var value uint64
+		if isText {
+			parsed, err := strconv.ParseUint(text, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s: cannot parse %q as counter", oidCfg.Name, text)
+			}
+			value = parsed
+		} else {
+			value = gosnmp.ToBigInt(pdu.Value).Uint64()
+		}
+		if scale != 1.0 {
+			return float64(value) * scale, nil
+		}
+		return value, nil
+
+	default: // config.OIDTypeInteger
+		var value int64
+		if isText {
+			parsed, err := strconv.ParseInt(text, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s: cannot parse %q as integer", oidCfg.Name, text)
+			}
+			value = parsed
+		} else {
+			value = gosnmp.ToBigInt(pdu.Value).Int64()
+		}
+		if scale != 1.0 {
+			return float64(value) * scale, nil
+		}
+		return value, nil
+	}
+}
@@ -2,14 +2,15 @@ package monitoring
 
 import (
 	"context"
-	"fmt"
+	"hash/fnv"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	"github.com/gosnmp/gosnmp"
 	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/snmp"
 	"github.com/kljama/netscan/internal/state"
+	"github.com/kljama/netscan/internal/validate"
 	"github.com/rs/zerolog/log"
 	"golang.org/x/time/rate"
 )
@@ -24,12 +25,32 @@ type SNMPStateManager interface {
 
 // SNMPWriter interface for writing device info to external storage
 type SNMPWriter interface {
-	WriteDeviceInfo(ip, hostname, sysDescr string) error
+	WriteDeviceInfoFields(ip, hostname, sysDescr string, system state.SystemInfo, fields map[string]string) error
+	WriteInterfaceMetrics(ip string, ifIndex int, ifDescr string, operStatus int, inOctets, outOctets, speed uint64) error
+	WriteCustomMetrics(ip, measurement, group string, fields map[string]interface{}) error
+}
+
+// SNMPPollOptions holds the settings shared by every SNMP poller and the SNMP refresher of a process
+type SNMPPollOptions struct {
+	Sessions *SNMPSessionCache // Sessions reused between polls (nil = connect per poll)
+	SourceIP string            // Local IPv4 address SNMP queries are sent from ("" = chosen by the routing table)
+
+	// Jitter moves every poll after the first up to this much earlier or later than the interval (snmp_jitter),
+	// so pollers whose IP offsets happen to be close drift apart instead of querying together on every cycle
+	Jitter time.Duration
+	// DeviceInfoRefresh rewrites unchanged device_info once this has passed since the last write, so short
+	// dashboard ranges and sys_uptime_s stay reasonably current (device_info_refresh, 0 = every poll)
+	DeviceInfoRefresh time.Duration
+}
+
+// connection returns the connection settings of a device's SNMP sessions
+func (o SNMPPollOptions) connection(snmpConfig *config.SNMPConfig) snmp.Options {
+	return snmp.NewOptions(snmpConfig, snmpLocalAddr(o.SourceIP))
 }
 
 // StartSNMPPoller runs continuous SNMP polling for a single device
-// This mirrors the StartPinger architecture with rate limiting and circuit breaker
-func StartSNMPPoller(ctx context.Context, wg *sync.WaitGroup, device state.Device, interval time.Duration, snmpConfig *config.SNMPConfig, writer SNMPWriter, stateMgr SNMPStateManager, limiter *rate.Limiter, inFlightCounter *atomic.Int64, totalSNMPQueries *atomic.Uint64, maxConsecutiveFails int, backoffDuration time.Duration) {
+// Probes go through the same rate limiting and circuit breaker as the ping scheduler
+func StartSNMPPoller(ctx context.Context, wg *sync.WaitGroup, device state.Device, interval time.Duration, snmpConfig *config.SNMPConfig, writer SNMPWriter, stateMgr SNMPStateManager, limiter *rate.Limiter, inFlightCounter *atomic.Int64, totalSNMPQueries *atomic.Uint64, maxConsecutiveFails int, backoffDuration time.Duration, policy config.AddressPolicy, maintenance MaintenanceResolver, opts SNMPPollOptions) {
 	// Panic recovery for SNMP poller goroutine
 	defer func() {
 		if r := recover(); r != nil {
@@ -44,9 +65,11 @@ func StartSNMPPoller(ctx context.Context, wg *sync.WaitGroup, device state.Devic
 		defer wg.Done()
 	}
 	
-	// Initialize timer for first SNMP query with 5 second delay to avoid immediate query storm
-	timer := time.NewTimer(5 * time.Second)
+	// Initialize timer for the first SNMP query at the device's offset within the interval, so pollers started
+	// together (e.g. after a restart) spread their queries instead of polling in lockstep
+	timer := time.NewTimer(snmpStartDelay(device.IP, interval))
 	defer timer.Stop()
+	lastOK := false // Previous poll answered (counted for auto-tuning)
 	
 	for {
 		select {
@@ -55,10 +78,24 @@ func StartSNMPPoller(ctx context.Context, wg *sync.WaitGroup, device state.Devic
 			timer.Stop()
 			return
 		case <-timer.C:
+			// Planned outage: no poll, so the SNMP circuit breaker is not tripped
+			if maintenance.action(device.IP) == config.MaintenanceSkip {
+				log.Debug().Str("ip", device.IP).Msg("Device is in a maintenance window, skipping SNMP poll.")
+				timer.Reset(snmpNextDelay(interval, opts.Jitter))
+				continue
+			}
+
+			// Quarantined devices are not probed until an operator releases them
+			if isQuarantined(stateMgr, device.IP) {
+				log.Debug().Str("ip", device.IP).Msg("Device is quarantined, skipping SNMP poll.")
+				timer.Reset(snmpNextDelay(interval, opts.Jitter))
+				continue
+			}
+
 			// 1. CHECK CIRCUIT BREAKER *BEFORE* ACQUIRING TOKEN
 			if stateMgr.IsSNMPSuspended(device.IP) {
 				log.Debug().Str("ip", device.IP).Msg("SNMP polling is suspended (circuit breaker), skipping.")
-				timer.Reset(interval) // Reset timer and wait for next cycle
+				timer.Reset(snmpNextDelay(interval, opts.Jitter)) // Reset timer and wait for next cycle
 				continue              // Skip SNMP query entirely
 			}
 
@@ -69,17 +106,44 @@ func StartSNMPPoller(ctx context.Context, wg *sync.WaitGroup, device state.Devic
 			}
 
 			// 3. Perform the SNMP query with in-flight tracking and circuit breaker
-			performSNMPQueryWithCircuitBreaker(device, snmpConfig, writer, stateMgr, inFlightCounter, totalSNMPQueries, maxConsecutiveFails, backoffDuration)
+			ok := performSNMPQueryWithCircuitBreaker(ctx, device, snmpConfig, writer, stateMgr, inFlightCounter, totalSNMPQueries, maxConsecutiveFails, backoffDuration, policy, maintenance, opts)
+			countOutcome(&snmpAfterSuccess, &snmpLost, lastOK, ok)
+			lastOK = ok
 			
-			// 4. Reset timer to schedule next SNMP query after interval
+			// 4. Reset timer to schedule next SNMP query after interval (±snmp_jitter)
 			// This ensures interval is time BETWEEN queries, not fixed schedule
-			timer.Reset(interval)
+			timer.Reset(snmpNextDelay(interval, opts.Jitter))
 		}
 	}
 }
 
+// snmpNextDelay returns the delay until a poller's next cycle: interval plus a random offset within ±jitter
+// (0 = exact interval)
+func snmpNextDelay(interval, jitter time.Duration) time.Duration {
+	if jitter <= 0 {
+		return interval
+	}
+	return interval + randomOffset(2*jitter) - jitter
+}
+
+// snmpFirstPollDelay is the minimum delay before a poller's first query, avoiding an immediate query storm
+const snmpFirstPollDelay = 5 * time.Second
+
+// snmpStartDelay returns the delay before a device's first SNMP poll: snmpFirstPollDelay plus an offset within
+// interval derived from a hash of the IP. The offset is stable across restarts, so every device keeps its phase
+// and a restart with thousands of pollers does not synchronize their queries
+func snmpStartDelay(ip string, interval time.Duration) time.Duration {
+	if interval <= 0 {
+		return snmpFirstPollDelay
+	}
+	h := fnv.New64a()
+	h.Write([]byte(ip))
+	return snmpFirstPollDelay + time.Duration(h.Sum64()%uint64(interval))
+}
+
 // performSNMPQueryWithCircuitBreaker executes a single SNMP query with circuit breaker integration
-func performSNMPQueryWithCircuitBreaker(device state.Device, snmpConfig *config.SNMPConfig, writer SNMPWriter, stateMgr SNMPStateManager, inFlightCounter *atomic.Int64, totalSNMPQueries *atomic.Uint64, maxConsecutiveFails int, backoffDuration time.Duration) {
+// Returns whether the device answered the system query
+func performSNMPQueryWithCircuitBreaker(ctx context.Context, device state.Device, snmpConfig *config.SNMPConfig, writer SNMPWriter, stateMgr SNMPStateManager, inFlightCounter *atomic.Int64, totalSNMPQueries *atomic.Uint64, maxConsecutiveFails int, backoffDuration time.Duration, policy config.AddressPolicy, maintenance MaintenanceResolver, opts SNMPPollOptions) bool {
 	// Increment in-flight counter
 	if inFlightCounter != nil {
 		inFlightCounter.Add(1)
@@ -92,26 +156,31 @@ func performSNMPQueryWithCircuitBreaker(device state.Device, snmpConfig *config.
 		totalSNMPQueries.Add(1)
 	}
 
-	log.Debug().Str("ip", device.IP).Msg("Querying SNMP device")
+	probeLog(device.IP).Str("ip", device.IP).Msg("Querying SNMP device")
+	polledAt := time.Now()
 
-	// Configure SNMP connection parameters
-	params := &gosnmp.GoSNMP{
-		Target:    device.IP,
-		Port:      uint16(snmpConfig.Port),
-		Community: snmpConfig.Community,
-		Version:   gosnmp.Version2c,
-		Timeout:   snmpConfig.Timeout,
-		Retries:   snmpConfig.Retries,
+	// Validate IP address before querying (same address policy as the pinger)
+	if err := policy.ValidateIP(device.IP); err != nil {
+		log.Error().
+			Str("ip", device.IP).
+			Err(err).
+			Msg("Invalid IP address")
+		return false
 	}
-	
-	if err := params.Connect(); err != nil {
-		log.Debug().
+
+	// Connect, or reuse the device's cached session when the session cache is enabled
+	client, err := opts.Sessions.open(ctx, device.IP, opts.connection(snmpConfig))
+	if err != nil {
+		if ctx.Err() != nil {
+			return false // Shutting down: an aborted connect says nothing about the device
+		}
+		probeLog(device.IP).
 			Str("ip", device.IP).
 			Err(err).
 			Msg("SNMP connection failed")
 		
-		// Report failure to circuit breaker
-		if stateMgr != nil {
+		// Report failure to circuit breaker, unless the outage is planned
+		if stateMgr != nil && maintenance.action(device.IP) == "" {
 			wasSuspended := stateMgr.ReportSNMPFail(device.IP, maxConsecutiveFails, backoffDuration)
 			if wasSuspended {
 				log.Warn().
@@ -120,22 +189,26 @@ func performSNMPQueryWithCircuitBreaker(device state.Device, snmpConfig *config.
 					Msg("SNMP polling failed max attempts, suspending SNMP (circuit breaker tripped)")
 			}
 		}
-		return
+		return false
 	}
-	defer params.Conn.Close()
+	// Only sessions that answered the system query are returned to the cache for reuse
+	healthy := false
+	defer func() { opts.Sessions.release(client, healthy) }()
 
 	// Query standard MIB-II system OIDs: sysName, sysDescr
-	// Using snmpGetWithFallback to handle devices that don't support .0 instance
-	oids := []string{"1.3.6.1.2.1.1.5.0", "1.3.6.1.2.1.1.1.0"}
-	resp, err := snmpGetWithFallback(params, oids)
-	if err != nil || len(resp.Variables) < 2 {
-		log.Debug().
+	// Using GetWithFallback to handle devices that don't support .0 instance
+	variables, err := client.GetWithFallback(ctx, []string{snmp.OIDSysName, snmp.OIDSysDescr})
+	if err != nil || len(variables) < 2 {
+		if ctx.Err() != nil {
+			return false // Shutting down: an aborted query must not count against the circuit breaker
+		}
+		probeLog(device.IP).
 			Str("ip", device.IP).
 			Err(err).
 			Msg("SNMP query failed")
 		
-		// Report failure to circuit breaker
-		if stateMgr != nil {
+		// Report failure to circuit breaker, unless the outage is planned
+		if stateMgr != nil && maintenance.action(device.IP) == "" {
 			wasSuspended := stateMgr.ReportSNMPFail(device.IP, maxConsecutiveFails, backoffDuration)
 			if wasSuspended {
 				log.Warn().
@@ -144,19 +217,19 @@ func performSNMPQueryWithCircuitBreaker(device state.Device, snmpConfig *config.
 					Msg("SNMP polling failed max attempts, suspending SNMP (circuit breaker tripped)")
 			}
 		}
-		return
+		return false
 	}
 
 	// Validate and sanitize SNMP response data
-	hostname, err := validateSNMPString(resp.Variables[0].Value, "sysName")
+	hostname, err := validate.SNMPString(variables[0].Value, "sysName", snmpConfig.MaxStringLength)
 	if err != nil {
-		log.Debug().
+		probeLog(device.IP).
 			Str("ip", device.IP).
 			Err(err).
 			Msg("Invalid sysName")
 		
-		// Report failure to circuit breaker
-		if stateMgr != nil {
+		// Report failure to circuit breaker, unless the outage is planned
+		if stateMgr != nil && maintenance.action(device.IP) == "" {
 			wasSuspended := stateMgr.ReportSNMPFail(device.IP, maxConsecutiveFails, backoffDuration)
 			if wasSuspended {
 				log.Warn().
@@ -165,18 +238,18 @@ func performSNMPQueryWithCircuitBreaker(device state.Device, snmpConfig *config.
 					Msg("SNMP polling failed max attempts, suspending SNMP (circuit breaker tripped)")
 			}
 		}
-		return
+		return false
 	}
 	
-	sysDescr, err := validateSNMPString(resp.Variables[1].Value, "sysDescr")
+	sysDescr, err := validate.SNMPString(variables[1].Value, "sysDescr", snmpConfig.MaxStringLength)
 	if err != nil {
-		log.Debug().
+		probeLog(device.IP).
 			Str("ip", device.IP).
 			Err(err).
 			Msg("Invalid sysDescr")
 		
-		// Report failure to circuit breaker
-		if stateMgr != nil {
+		// Report failure to circuit breaker, unless the outage is planned
+		if stateMgr != nil && maintenance.action(device.IP) == "" {
 			wasSuspended := stateMgr.ReportSNMPFail(device.IP, maxConsecutiveFails, backoffDuration)
 			if wasSuspended {
 				log.Warn().
@@ -185,11 +258,12 @@ func performSNMPQueryWithCircuitBreaker(device state.Device, snmpConfig *config.
 					Msg("SNMP polling failed max attempts, suspending SNMP (circuit breaker tripped)")
 			}
 		}
-		return
+		return false
 	}
 
 	// SNMP query successful
-	log.Debug().
+	healthy = true
+	probeLog(device.IP).
 		Str("ip", device.IP).
 		Str("hostname", hostname).
 		Msg("SNMP query successful")
@@ -200,137 +274,59 @@ func performSNMPQueryWithCircuitBreaker(device state.Device, snmpConfig *config.
 		stateMgr.UpdateDeviceSNMP(device.IP, hostname, sysDescr)
 	}
 	
-	// Write device info to InfluxDB
-	if err := writer.WriteDeviceInfo(device.IP, hostname, sysDescr); err != nil {
-		log.Error().
-			Str("ip", device.IP).
-			Err(err).
-			Msg("Failed to write device info")
+	// sysObjectID, sysUpTime, sysContact and sysLocation (best effort; sysUpTime going backwards is a reboot)
+	system, _ := snmp.QuerySystemInfo(ctx, client, snmpConfig.MaxStringLength)
+	if systemTracker, ok := stateMgr.(SystemInfoTracker); ok && system != (state.SystemInfo{}) {
+		systemTracker.UpdateDeviceSystem(device.IP, system, polledAt)
 	}
-}
 
-// snmpGetWithFallback attempts to get SNMP OIDs using Get, falling back to GetNext if Get fails
-// This is a local copy of the discovery.snmpGetWithFallback function to avoid circular imports
-func snmpGetWithFallback(params *gosnmp.GoSNMP, oids []string) (*gosnmp.SnmpPacket, error) {
-	// Try Get first (most efficient for .0 instances)
-	resp, err := params.Get(oids)
-	if err == nil {
-		// Check if we got valid responses (no NoSuchInstance errors)
-		hasValidData := false
-		for _, variable := range resp.Variables {
-			if variable.Type != gosnmp.NoSuchInstance && variable.Type != gosnmp.NoSuchObject {
-				hasValidData = true
-				break
-			}
+	// Write device info (plus any fields derived by snmp.device_fields rules) to InfluxDB
+	// Unchanged device info is only rewritten every device_info_refresh
+	fields := config.DeriveDeviceFields(snmpConfig.DeviceFields, hostname, sysDescr)
+	tracker, _ := stateMgr.(VirtualAddressTracker)
+	if tracker != nil {
+		// A virtual IP answers SNMP as its active member; label it so it is not mistaken for that device
+		if vip, ok := tracker.GetVirtualIP(device.IP); ok {
+			fields = virtualFields(fields, vip)
 		}
-		if hasValidData {
-			return resp, nil
-		}
-		// All variables returned NoSuchInstance/NoSuchObject, try GetNext
-		log.Debug().
-			Str("target", params.Target).
-			Msg("Get returned NoSuchInstance, trying GetNext fallback")
 	}
-
-	// Fallback to GetNext for each OID (works when .0 instance doesn't exist)
-	baseOIDs := make([]string, len(oids))
-	for i, oid := range oids {
-		// Remove the .0 suffix if present to get base OID
-		if len(oid) > 2 && oid[len(oid)-2:] == ".0" {
-			baseOIDs[i] = oid[:len(oid)-2]
-		} else {
-			baseOIDs[i] = oid
+	info := state.DeviceInfo{Hostname: hostname, SysDescr: sysDescr, ObjectID: system.ObjectID,
+		Location: system.Location, Contact: system.Contact, Fields: fields}
+	if shouldWriteDeviceInfo(stateMgr, device.IP, info, polledAt, opts.DeviceInfoRefresh) {
+		if err := writer.WriteDeviceInfoFields(device.IP, hostname, sysDescr, system, fields); err != nil {
+			log.Error().
+				Str("ip", device.IP).
+				Err(err).
+				Msg("Failed to write device info")
 		}
 	}
 
-	variables := make([]gosnmp.SnmpPDU, 0, len(baseOIDs))
-	for _, baseOID := range baseOIDs {
-		resp, err := params.GetNext([]string{baseOID})
-		if err != nil {
-			continue
-		}
-		if len(resp.Variables) > 0 {
-			// Verify the returned OID is under the requested base OID
-			returnedOID := resp.Variables[0].Name
-			if len(returnedOID) >= len(baseOID) && returnedOID[:len(baseOID)] == baseOID {
-				variables = append(variables, resp.Variables[0])
-			}
-		}
+	// Collect the full result set for the device API cache
+	values := []state.SNMPValue{
+		{Group: SNMPGroupSystem, Name: "sysName", Value: hostname, PolledAt: polledAt},
+		{Group: SNMPGroupSystem, Name: "sysDescr", Value: sysDescr, PolledAt: polledAt},
 	}
+	values = append(values, systemValues(system, polledAt)...)
+	values = append(values, groupValues(SNMPGroupDeviceFields, stringFields(fields), polledAt)...)
 
-	if len(variables) == 0 {
-		return nil, fmt.Errorf("no valid SNMP data retrieved")
+	// Optionally walk IF-MIB ifTable for per-interface metrics (reuses the open session)
+	if snmpConfig.PollInterfaces {
+		ifaces := pollInterfaces(ctx, client, device.IP, snmpConfig.MaxStringLength, writer)
+		values = append(values, interfaceValues(ifaces, time.Now())...)
 	}
 
-	// Construct a response packet with the collected variables
-	return &gosnmp.SnmpPacket{
-		Variables: variables,
-	}, nil
-}
-
-// validateSNMPString validates and sanitizes SNMP string values
-// This is a local copy of the discovery.validateSNMPString function to avoid circular imports
-func validateSNMPString(value interface{}, oidName string) (string, error) {
-	var str string
-	switch v := value.(type) {
-	case string:
-		str = v
-	case []byte:
-		str = string(v)
-	default:
-		return "", fmt.Errorf("invalid type for %s: expected string or []byte, got %T", oidName, value)
+	// Optionally walk VRRP/HSRP tables to link virtual addresses to this physical member
+	if snmpConfig.PollRedundancy && tracker != nil {
+		values = append(values, pollRedundancy(ctx, client, device.IP, tracker, time.Now())...)
 	}
 
-	// Security: reject strings containing null bytes
-	for i := 0; i < len(str); i++ {
-		if str[i] == 0 {
-			return "", fmt.Errorf("%s contains null byte at position %d", oidName, i)
-		}
+	// Query user-defined OID groups that apply to this device
+	if len(snmpConfig.OIDGroups) > 0 {
+		values = append(values, pollOIDGroups(ctx, client, device.IP, snmpConfig.OIDGroups, snmpConfig.MaxStringLength, writer)...)
 	}
 
-	// Limit string length to prevent memory exhaustion
-	if len(str) > 1024 {
-		str = str[:1024] + "..."
+	if cache, ok := stateMgr.(SNMPResultCache); ok {
+		cache.SetSNMPResult(device.IP, &state.SNMPResult{PolledAt: polledAt, Values: values})
 	}
-
-	// Sanitize: replace newlines and tabs with spaces, remove other non-printable chars
-	sanitized := make([]byte, 0, len(str))
-	for i := 0; i < len(str); i++ {
-		ch := str[i]
-		if ch == '\n' || ch == '\r' || ch == '\t' {
-			sanitized = append(sanitized, ' ')
-		} else if ch >= 32 && ch <= 126 {
-			sanitized = append(sanitized, ch)
-		}
-		// Skip other non-printable characters
-	}
-	
-	result := string(sanitized)
-	
-	// Trim whitespace
-	result = trimSpace(result)
-	
-	if len(result) == 0 {
-		return "", fmt.Errorf("%s is empty after sanitization", oidName)
-	}
-
-	return result, nil
-}
-
-// trimSpace removes leading and trailing whitespace
-func trimSpace(s string) string {
-	start := 0
-	end := len(s)
-	
-	// Trim leading spaces
-	for start < end && (s[start] == ' ' || s[start] == '\t') {
-		start++
-	}
-	
-	// Trim trailing spaces
-	for end > start && (s[end-1] == ' ' || s[end-1] == '\t') {
-		end--
-	}
-	
-	return s[start:end]
+	return true
 }
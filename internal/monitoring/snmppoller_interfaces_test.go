@@ -0,0 +1,82 @@
+package monitoring
+
+import (
+	"testing"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// TestBuildInterfaceTable verifies that walked ifTable PDUs are grouped into rows by ifIndex
+func TestBuildInterfaceTable(t *testing.T) {
+	pdus := []gosnmp.SnmpPDU{
+		{Name: ".1.3.6.1.2.1.2.2.1.2.1", Type: gosnmp.OctetString, Value: []byte("eth0")},
+		{Name: ".1.3.6.1.2.1.2.2.1.2.2", Type: gosnmp.OctetString, Value: []byte("eth1")},
+		{Name: ".1.3.6.1.2.1.2.2.1.5.1", Type: gosnmp.Gauge32, Value: uint(1000000000)},
+		{Name: ".1.3.6.1.2.1.2.2.1.8.1", Type: gosnmp.Integer, Value: 1},
+		{Name: ".1.3.6.1.2.1.2.2.1.8.2", Type: gosnmp.Integer, Value: 2},
+		{Name: ".1.3.6.1.2.1.2.2.1.10.1", Type: gosnmp.Counter32, Value: uint(12345)},
+		{Name: ".1.3.6.1.2.1.2.2.1.16.1", Type: gosnmp.Counter32, Value: uint(67890)},
+	}
+
+	rows := buildInterfaceTable(pdus, 0)
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 interface rows, got %d", len(rows))
+	}
+
+	eth0 := rows[0]
+	if eth0.Index != 1 || eth0.Descr != "eth0" {
+		t.Errorf("expected first row to be ifIndex 1 (eth0), got %d (%s)", eth0.Index, eth0.Descr)
+	}
+	if eth0.OperStatus != 1 {
+		t.Errorf("expected eth0 oper status 1, got %d", eth0.OperStatus)
+	}
+	if eth0.Speed != 1000000000 {
+		t.Errorf("expected eth0 speed 1000000000, got %d", eth0.Speed)
+	}
+	if eth0.InOctets != 12345 || eth0.OutOctets != 67890 {
+		t.Errorf("expected eth0 octets 12345/67890, got %d/%d", eth0.InOctets, eth0.OutOctets)
+	}
+
+	eth1 := rows[1]
+	if eth1.Index != 2 || eth1.Descr != "eth1" || eth1.OperStatus != 2 {
+		t.Errorf("unexpected eth1 row: %+v", eth1)
+	}
+}
+
+// TestBuildInterfaceTableMissingDescr verifies rows without ifDescr get a synthetic name
+func TestBuildInterfaceTableMissingDescr(t *testing.T) {
+	pdus := []gosnmp.SnmpPDU{
+		{Name: ".1.3.6.1.2.1.2.2.1.8.7", Type: gosnmp.Integer, Value: 1},
+	}
+
+	rows := buildInterfaceTable(pdus, 0)
+	if len(rows) != 1 {
+		t.Fatalf("expected 1 interface row, got %d", len(rows))
+	}
+	if rows[0].Descr != "if7" {
+		t.Errorf("expected synthetic name if7, got %s", rows[0].Descr)
+	}
+}
+
+// TestSplitColumnIndex verifies instance OIDs are split into column and ifIndex
+func TestSplitColumnIndex(t *testing.T) {
+	tests := []struct {
+		oid        string
+		wantColumn string
+		wantIndex  int
+		wantOK     bool
+	}{
+		{"1.3.6.1.2.1.2.2.1.2.10", oidIfDescr, 10, true},
+		{"1.3.6.1.2.1.2.2.1.16.3", oidIfOutOctets, 3, true},
+		{"1.3.6.1.2.1.2.2.1.2.x", "", 0, false},
+		{"nodots", "", 0, false},
+	}
+
+	for _, tt := range tests {
+		column, index, ok := splitColumnIndex(tt.oid)
+		if ok != tt.wantOK || column != tt.wantColumn || index != tt.wantIndex {
+			t.Errorf("splitColumnIndex(%q) = (%q, %d, %v), want (%q, %d, %v)",
+				tt.oid, column, index, ok, tt.wantColumn, tt.wantIndex, tt.wantOK)
+		}
+	}
+}
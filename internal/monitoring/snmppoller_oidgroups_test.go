@@ -0,0 +1,79 @@
+package monitoring
+
+import (
+	"testing"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/kljama/netscan/internal/config"
+)
+
+// TestConvertOIDValue validates type conversion and scaling of custom OID values
+func TestConvertOIDValue(t *testing.T) {
+	tests := []struct {
+		name string
+		pdu  gosnmp.SnmpPDU
+		cfg  config.OIDConfig
+		want interface{}
+	}{
+		{
+			name: "integer unscaled",
+			pdu:  gosnmp.SnmpPDU{Type: gosnmp.Integer, Value: -5},
+			cfg:  config.OIDConfig{Name: "v", Type: config.OIDTypeInteger, Scale: 1},
+			want: int64(-5),
+		},
+		{
+			name: "integer scaled",
+			pdu:  gosnmp.SnmpPDU{Type: gosnmp.Integer, Value: 235},
+			cfg:  config.OIDConfig{Name: "v", Type: config.OIDTypeInteger, Scale: 0.1},
+			want: 23.5,
+		},
+		{
+			name: "counter64",
+			pdu:  gosnmp.SnmpPDU{Type: gosnmp.Counter64, Value: uint64(18446744073709551615)},
+			cfg:  config.OIDConfig{Name: "v", Type: config.OIDTypeCounter, Scale: 1},
+			want: uint64(18446744073709551615),
+		},
+		{
+			name: "float from octet string",
+			pdu:  gosnmp.SnmpPDU{Type: gosnmp.OctetString, Value: []byte(" 41.5 ")},
+			cfg:  config.OIDConfig{Name: "v", Type: config.OIDTypeFloat, Scale: 2},
+			want: 83.0,
+		},
+		{
+			name: "float from gauge",
+			pdu:  gosnmp.SnmpPDU{Type: gosnmp.Gauge32, Value: uint(7)},
+			cfg:  config.OIDConfig{Name: "v", Type: config.OIDTypeFloat},
+			want: 7.0,
+		},
+		{
+			name: "string",
+			pdu:  gosnmp.SnmpPDU{Type: gosnmp.OctetString, Value: []byte("Rack 4\n")},
+			cfg:  config.OIDConfig{Name: "v", Type: config.OIDTypeString},
+			want: "Rack 4",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertOIDValue(tt.pdu, tt.cfg, 0)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %v (%T), got %v (%T)", tt.want, tt.want, got, got)
+			}
+		})
+	}
+}
+
+// TestConvertOIDValueUnavailable validates missing OIDs and unparsable strings are rejected
+func TestConvertOIDValueUnavailable(t *testing.T) {
+	cfg := config.OIDConfig{Name: "v", Type: config.OIDTypeInteger, Scale: 1}
+
+	if _, err := convertOIDValue(gosnmp.SnmpPDU{Type: gosnmp.NoSuchInstance}, cfg, 0); err == nil {
+		t.Error("expected error for NoSuchInstance")
+	}
+	if _, err := convertOIDValue(gosnmp.SnmpPDU{Type: gosnmp.OctetString, Value: []byte("n/a")}, cfg, 0); err == nil {
+		t.Error("expected error for non-numeric string with integer type")
+	}
+}
@@ -0,0 +1,59 @@
+package monitoring
+
+import (
+	"testing"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// TestBuildVirtualAddresses verifies VRRP and HSRP rows are joined with their state column
+func TestBuildVirtualAddresses(t *testing.T) {
+	pdus := []gosnmp.SnmpPDU{
+		// VRRP: ifIndex 3, VRID 10 is master for 10.0.0.1
+		{Name: ".1.3.6.1.2.1.68.1.3.1.3.3.10", Type: gosnmp.Integer, Value: 3},
+		{Name: ".1.3.6.1.2.1.68.1.4.1.2.3.10.10.0.0.1", Type: gosnmp.Integer, Value: 1},
+		// HSRP: ifIndex 5, group 20 is standby for 10.0.1.1
+		{Name: ".1.3.6.1.4.1.9.9.106.1.2.1.1.11.5.20", Type: gosnmp.IPAddress, Value: "10.0.1.1"},
+		{Name: ".1.3.6.1.4.1.9.9.106.1.2.1.1.15.5.20", Type: gosnmp.Integer, Value: 5},
+		// HSRP group that has not learned its virtual IP yet
+		{Name: ".1.3.6.1.4.1.9.9.106.1.2.1.1.11.6.30", Type: gosnmp.IPAddress, Value: "0.0.0.0"},
+	}
+
+	addrs := buildVirtualAddresses(pdus)
+	if len(addrs) != 2 {
+		t.Fatalf("expected 2 virtual addresses, got %d: %+v", len(addrs), addrs)
+	}
+	vrrp, hsrp := addrs[0], addrs[1]
+	if vrrp != (state.VirtualAddress{IP: "10.0.0.1", Protocol: state.ProtocolVRRP, Group: 10, Role: "master", Active: true}) {
+		t.Errorf("unexpected VRRP address: %+v", vrrp)
+	}
+	if hsrp != (state.VirtualAddress{IP: "10.0.1.1", Protocol: state.ProtocolHSRP, Group: 20, Role: "standby", Active: false}) {
+		t.Errorf("unexpected HSRP address: %+v", hsrp)
+	}
+}
+
+// TestVirtualFields verifies device_info labels for a virtual IP
+func TestVirtualFields(t *testing.T) {
+	vip := state.VirtualIP{
+		IP:           "10.0.0.1",
+		Protocol:     state.ProtocolVRRP,
+		Group:        10,
+		Members:      map[string]string{"10.0.0.3": "backup", "10.0.0.2": "master"},
+		ActiveMember: "10.0.0.2",
+	}
+	fields := virtualFields(map[string]string{"site": "dc1"}, vip)
+
+	want := map[string]string{
+		"site":                  "dc1",
+		"virtual_protocol":      "vrrp",
+		"virtual_group":         "10",
+		"virtual_members":       "10.0.0.2,10.0.0.3",
+		"virtual_active_member": "10.0.0.2",
+	}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, fields[k])
+		}
+	}
+}
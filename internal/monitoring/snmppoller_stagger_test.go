@@ -0,0 +1,57 @@
+package monitoring
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestSNMPStartDelay verifies first polls are spread over the interval and stable per device
+func TestSNMPStartDelay(t *testing.T) {
+	interval := time.Hour
+	buckets := make([]int, 4)
+	for i := 0; i < 1000; i++ {
+		ip := fmt.Sprintf("10.0.%d.%d", i/256, i%256)
+		delay := snmpStartDelay(ip, interval)
+		if delay < snmpFirstPollDelay || delay >= snmpFirstPollDelay+interval {
+			t.Fatalf("%s: delay %v outside [%v, %v)", ip, delay, snmpFirstPollDelay, snmpFirstPollDelay+interval)
+		}
+		if again := snmpStartDelay(ip, interval); again != delay {
+			t.Fatalf("%s: delay changed from %v to %v", ip, delay, again)
+		}
+		buckets[(delay-snmpFirstPollDelay)/(interval/4)]++
+	}
+	// 1000 devices over four quarters of the interval: each quarter gets roughly 250
+	for quarter, n := range buckets {
+		if n < 150 || n > 350 {
+			t.Errorf("quarter %d of the interval got %d first polls, expected about 250: %v", quarter, n, buckets)
+		}
+	}
+
+	if delay := snmpStartDelay("10.0.0.1", 0); delay != snmpFirstPollDelay {
+		t.Errorf("expected the minimum delay without an interval, got %v", delay)
+	}
+}
+
+// TestSNMPNextDelay verifies later polls stay within ±snmp_jitter of the interval
+func TestSNMPNextDelay(t *testing.T) {
+	interval := time.Hour
+
+	if delay := snmpNextDelay(interval, 0); delay != interval {
+		t.Errorf("expected the exact interval without jitter, got %v", delay)
+	}
+
+	jitter := 5 * time.Minute
+	earlier, later := false, false
+	for i := 0; i < 1000; i++ {
+		delay := snmpNextDelay(interval, jitter)
+		if delay < interval-jitter || delay >= interval+jitter {
+			t.Fatalf("delay %v outside [%v, %v)", delay, interval-jitter, interval+jitter)
+		}
+		earlier = earlier || delay < interval
+		later = later || delay > interval
+	}
+	if !earlier || !later {
+		t.Errorf("expected delays on both sides of the interval, earlier=%v later=%v", earlier, later)
+	}
+}
@@ -0,0 +1,29 @@
+package monitoring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/kljama/netscan/internal/snmp"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// TestParseSystemInfo verifies system group values are decoded and unanswered objects stay empty
+func TestParseSystemInfo(t *testing.T) {
+	info := snmp.ParseSystemInfo([]gosnmp.SnmpPDU{
+		{Name: ".1.3.6.1.2.1.1.2.0", Type: gosnmp.ObjectIdentifier, Value: ".1.3.6.1.4.1.9.1.1208"},
+		{Name: ".1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(8640000)},
+		{Name: ".1.3.6.1.2.1.1.4.0", Type: gosnmp.NoSuchObject, Value: nil},
+		{Name: ".1.3.6.1.2.1.1.6.0", Type: gosnmp.OctetString, Value: []byte("FRA1\track 12\n")},
+	}, 0)
+	want := state.SystemInfo{ObjectID: "1.3.6.1.4.1.9.1.1208", UpTime: 24 * time.Hour, Location: "FRA1 rack 12"}
+	if info != want {
+		t.Errorf("expected %+v, got %+v", want, info)
+	}
+
+	values := systemValues(info, time.Now())
+	if len(values) != 3 || values[1].Name != "sysUpTime" || values[1].Value != "24h0m0s" {
+		t.Errorf("unexpected device API values: %+v", values)
+	}
+}
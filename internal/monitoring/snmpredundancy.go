@@ -0,0 +1,185 @@
+package monitoring
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/kljama/netscan/internal/snmp"
+	"github.com/kljama/netscan/internal/state"
+	"github.com/rs/zerolog/log"
+)
+
+// First-hop redundancy MIB columns (without instance suffix)
+const (
+	oidVrrpOperState           = "1.3.6.1.2.1.68.1.3.1.3"         // VRRP-MIB vrrpOperState, index ifIndex.vrId
+	oidVrrpAssoIpAddrRowStatus = "1.3.6.1.2.1.68.1.4.1.2"         // VRRP-MIB vrrpAssoIpAddrRowStatus, index ifIndex.vrId.a.b.c.d
+	oidHsrpGrpVirtualIPAddr    = "1.3.6.1.4.1.9.9.106.1.2.1.1.11" // CISCO-HSRP-MIB cHsrpGrpVirtualIpAddr, index ifIndex.group
+	oidHsrpGrpStandbyState     = "1.3.6.1.4.1.9.9.106.1.2.1.1.15" // CISCO-HSRP-MIB cHsrpGrpStandbyState, index ifIndex.group
+)
+
+// SNMPGroupRedundancy groups the VRRP/HSRP memberships in a cached SNMP result
+const SNMPGroupRedundancy = "redundancy"
+
+// redundancyColumns lists the columns walked on every redundancy poll
+var redundancyColumns = []string{oidVrrpOperState, oidVrrpAssoIpAddrRowStatus, oidHsrpGrpVirtualIPAddr, oidHsrpGrpStandbyState}
+
+// vrrpStates maps vrrpOperState values to role names
+var vrrpStates = map[int]string{1: "initialize", 2: "backup", 3: "master"}
+
+// hsrpStates maps cHsrpGrpStandbyState values to role names
+var hsrpStates = map[int]string{1: "initial", 2: "learn", 3: "listen", 4: "speak", 5: "standby", 6: "active"}
+
+// VirtualAddressTracker is implemented by state managers that link virtual router addresses to their members
+// Optional: pollers skip redundancy tracking and virtual IP labels when the state manager does not implement it
+type VirtualAddressTracker interface {
+	UpdateVirtualAddresses(member string, addrs []state.VirtualAddress) []state.Failover
+	GetVirtualIP(ip string) (state.VirtualIP, bool)
+}
+
+// walkRedundancy walks the VRRP and HSRP tables; a device implementing neither returns no addresses
+// Returns an error only if every walk failed, so a timeout never wipes known memberships
+func walkRedundancy(ctx context.Context, client *snmp.Client) ([]state.VirtualAddress, error) {
+	var (
+		pdus     []gosnmp.SnmpPDU
+		lastErr  error
+		failures int
+	)
+	for _, column := range redundancyColumns {
+		results, err := client.Walk(ctx, column)
+		if err != nil {
+			lastErr = err
+			failures++
+			continue
+		}
+		pdus = append(pdus, results...)
+	}
+
+	if failures == len(redundancyColumns) {
+		return nil, lastErr
+	}
+	return buildVirtualAddresses(pdus), nil
+}
+
+// buildVirtualAddresses joins the walked address and state columns into one entry per virtual IP
+func buildVirtualAddresses(pdus []gosnmp.SnmpPDU) []state.VirtualAddress {
+	vrrpState := make(map[string]int) // "ifIndex.vrId" -> vrrpOperState
+	hsrpState := make(map[string]int) // "ifIndex.group" -> cHsrpGrpStandbyState
+	type pending struct {
+		key  string // Row key shared with the state column
+		addr state.VirtualAddress
+	}
+	var rows []pending
+
+	for _, pdu := range pdus {
+		name := strings.TrimPrefix(pdu.Name, ".")
+		switch {
+		case strings.HasPrefix(name, oidVrrpOperState+"."):
+			vrrpState[strings.TrimPrefix(name, oidVrrpOperState+".")] = int(gosnmp.ToBigInt(pdu.Value).Int64())
+		case strings.HasPrefix(name, oidHsrpGrpStandbyState+"."):
+			hsrpState[strings.TrimPrefix(name, oidHsrpGrpStandbyState+".")] = int(gosnmp.ToBigInt(pdu.Value).Int64())
+		case strings.HasPrefix(name, oidVrrpAssoIpAddrRowStatus+"."):
+			// Index is ifIndex.vrId.a.b.c.d; the virtual IP is part of the index
+			arcs := strings.Split(strings.TrimPrefix(name, oidVrrpAssoIpAddrRowStatus+"."), ".")
+			if len(arcs) != 6 {
+				continue
+			}
+			ip := net.ParseIP(strings.Join(arcs[2:], "."))
+			group, err := strconv.Atoi(arcs[1])
+			if ip == nil || err != nil {
+				continue
+			}
+			rows = append(rows, pending{key: arcs[0] + "." + arcs[1], addr: state.VirtualAddress{IP: ip.String(), Protocol: state.ProtocolVRRP, Group: group}})
+		case strings.HasPrefix(name, oidHsrpGrpVirtualIPAddr+"."):
+			key := strings.TrimPrefix(name, oidHsrpGrpVirtualIPAddr+".")
+			arcs := strings.Split(key, ".")
+			value, _ := pdu.Value.(string)
+			ip := net.ParseIP(value)
+			if len(arcs) != 2 || ip == nil || ip.IsUnspecified() {
+				continue // 0.0.0.0 until the group has learned its virtual IP
+			}
+			group, err := strconv.Atoi(arcs[1])
+			if err != nil {
+				continue
+			}
+			rows = append(rows, pending{key: key, addr: state.VirtualAddress{IP: ip.String(), Protocol: state.ProtocolHSRP, Group: group}})
+		}
+	}
+
+	addrs := make([]state.VirtualAddress, 0, len(rows))
+	for _, row := range rows {
+		addr := row.addr
+		if addr.Protocol == state.ProtocolVRRP {
+			addr.Role = vrrpStates[vrrpState[row.key]]
+			addr.Active = addr.Role == "master"
+		} else {
+			addr.Role = hsrpStates[hsrpState[row.key]]
+			addr.Active = addr.Role == "active"
+		}
+		if addr.Role == "" {
+			addr.Role = "unknown"
+		}
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i].IP < addrs[j].IP })
+	return addrs
+}
+
+// pollRedundancy records the device's VRRP/HSRP memberships and returns them as cached SNMP values
+// Redundancy polling is best-effort: failures are logged but do not trip the SNMP circuit breaker
+func pollRedundancy(ctx context.Context, client *snmp.Client, ip string, tracker VirtualAddressTracker, polledAt time.Time) []state.SNMPValue {
+	addrs, err := walkRedundancy(ctx, client)
+	if err != nil {
+		log.Debug().
+			Str("ip", ip).
+			Err(err).
+			Msg("Redundancy table walk failed")
+		return nil
+	}
+
+	for _, failover := range tracker.UpdateVirtualAddresses(ip, addrs) {
+		log.Info().
+			Str("virtual_ip", failover.VirtualIP).
+			Str("protocol", failover.Protocol).
+			Int("group", failover.Group).
+			Str("from", failover.From).
+			Str("to", failover.To).
+			Msg("Virtual router failover")
+	}
+
+	values := make([]state.SNMPValue, 0, len(addrs))
+	for _, addr := range addrs {
+		values = append(values, state.SNMPValue{
+			Group:    SNMPGroupRedundancy,
+			Name:     addr.Protocol + "." + strconv.Itoa(addr.Group) + "." + addr.IP,
+			Value:    addr.Role,
+			PolledAt: polledAt,
+		})
+	}
+	return values
+}
+
+// virtualFields labels the device_info point of a virtual IP with its protocol, group and members
+func virtualFields(fields map[string]string, vip state.VirtualIP) map[string]string {
+	labeled := make(map[string]string, len(fields)+4)
+	for k, v := range fields {
+		labeled[k] = v
+	}
+	members := make([]string, 0, len(vip.Members))
+	for member := range vip.Members {
+		members = append(members, member)
+	}
+	sort.Strings(members)
+
+	labeled["virtual_protocol"] = vip.Protocol
+	labeled["virtual_group"] = strconv.Itoa(vip.Group)
+	labeled["virtual_members"] = strings.Join(members, ",")
+	if vip.ActiveMember != "" {
+		labeled["virtual_active_member"] = vip.ActiveMember
+	}
+	return labeled
+}
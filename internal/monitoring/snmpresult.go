@@ -0,0 +1,160 @@
+package monitoring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/state"
+	"golang.org/x/time/rate"
+)
+
+// SNMPResultCache is implemented by state managers that keep the latest SNMP result per device
+// Optional: pollers skip caching when the state manager does not implement it
+type SNMPResultCache interface {
+	SetSNMPResult(ip string, result *state.SNMPResult)
+}
+
+// Groups of built-in values in a cached SNMP result
+const (
+	SNMPGroupSystem       = "system"
+	SNMPGroupDeviceFields = "device_fields"
+	SNMPGroupInterfaces   = "ifTable"
+)
+
+// RefreshMinInterval is the minimum time between on-demand polls of the same device
+const RefreshMinInterval = 30 * time.Second
+
+// ErrRefreshTooSoon is returned when a device was refreshed less than RefreshMinInterval ago
+var ErrRefreshTooSoon = errors.New("device was refreshed recently, try again later")
+
+// ErrSNMPSuspended is returned when a refresh is requested for a device with SNMP suspended by the circuit breaker
+var ErrSNMPSuspended = errors.New("SNMP polling is suspended for device (circuit breaker)")
+
+// groupValues converts a field map to values sorted by name, all stamped with polledAt
+func groupValues(group string, fields map[string]interface{}, polledAt time.Time) []state.SNMPValue {
+	values := make([]state.SNMPValue, 0, len(fields))
+	for name, value := range fields {
+		values = append(values, state.SNMPValue{Group: group, Name: name, Value: value, PolledAt: polledAt})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Name < values[j].Name
+	})
+	return values
+}
+
+// interfaceValues converts ifTable rows to values named after their IF-MIB column and ifIndex (e.g. ifInOctets.3)
+func interfaceValues(ifaces []InterfaceStats, polledAt time.Time) []state.SNMPValue {
+	values := make([]state.SNMPValue, 0, len(ifaces)*5)
+	for _, iface := range ifaces {
+		suffix := "." + strconv.Itoa(iface.Index)
+		for _, v := range []struct {
+			name  string
+			value interface{}
+		}{
+			{"ifDescr", iface.Descr},
+			{"ifOperStatus", iface.OperStatus},
+			{"ifInOctets", iface.InOctets},
+			{"ifOutOctets", iface.OutOctets},
+			{"ifSpeed", iface.Speed},
+		} {
+			values = append(values, state.SNMPValue{Group: SNMPGroupInterfaces, Name: v.name + suffix, Value: v.value, PolledAt: polledAt})
+		}
+	}
+	return values
+}
+
+// SNMPRefresher runs on-demand SNMP polls for the device API
+// Refreshes share the pollers' rate limiter and are limited to one per device per RefreshMinInterval
+type SNMPRefresher struct {
+	snmpConfig          *config.SNMPConfig
+	configFor           func(ip string) *config.SNMPConfig // Per-device settings (sites); nil uses snmpConfig
+	writer              SNMPWriter
+	stateMgr            SNMPStateManager
+	limiter             *rate.Limiter
+	inFlightCounter     *atomic.Int64
+	totalSNMPQueries    *atomic.Uint64
+	maxConsecutiveFails int
+	backoffDuration     time.Duration
+	policy              config.AddressPolicy
+	maintenance         MaintenanceResolver
+	opts                SNMPPollOptions
+
+	mu   sync.Mutex
+	last map[string]time.Time // Last refresh per device IP
+}
+
+// NewSNMPRefresher creates a refresher using the same dependencies as StartSNMPPoller
+func NewSNMPRefresher(snmpConfig *config.SNMPConfig, writer SNMPWriter, stateMgr SNMPStateManager, limiter *rate.Limiter, inFlightCounter *atomic.Int64, totalSNMPQueries *atomic.Uint64, maxConsecutiveFails int, backoffDuration time.Duration, policy config.AddressPolicy, maintenance MaintenanceResolver, opts SNMPPollOptions) *SNMPRefresher {
+	return &SNMPRefresher{
+		snmpConfig:          snmpConfig,
+		writer:              writer,
+		stateMgr:            stateMgr,
+		limiter:             limiter,
+		inFlightCounter:     inFlightCounter,
+		totalSNMPQueries:    totalSNMPQueries,
+		maxConsecutiveFails: maxConsecutiveFails,
+		backoffDuration:     backoffDuration,
+		policy:              policy,
+		maintenance:         maintenance,
+		opts:                opts,
+		last:                make(map[string]time.Time),
+	}
+}
+
+// SetConfigResolver selects the SNMP settings per device, e.g. the credentials of the device's site
+// Must be called before the first Refresh; nil uses the settings passed to NewSNMPRefresher
+func (r *SNMPRefresher) SetConfigResolver(configFor func(ip string) *config.SNMPConfig) {
+	r.configFor = configFor
+}
+
+// Refresh polls the device immediately, updating state, the result cache and InfluxDB like a scheduled poll
+func (r *SNMPRefresher) Refresh(ctx context.Context, device state.Device) error {
+	if r.stateMgr.IsSNMPSuspended(device.IP) {
+		return ErrSNMPSuspended
+	}
+	if !r.reserve(device.IP, time.Now()) {
+		return ErrRefreshTooSoon
+	}
+	if err := r.limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("waiting for SNMP rate limiter: %v", err)
+	}
+	snmpConfig := r.snmpConfig
+	if r.configFor != nil {
+		snmpConfig = r.configFor(device.IP)
+	}
+	performSNMPQueryWithCircuitBreaker(ctx, device, snmpConfig, r.writer, r.stateMgr, r.inFlightCounter, r.totalSNMPQueries, r.maxConsecutiveFails, r.backoffDuration, r.policy, r.maintenance, r.opts)
+	return nil
+}
+
+// reserve records a refresh of ip at now, returning false if the previous one was too recent
+// Expired entries are dropped so the map stays bounded by the refresh rate
+func (r *SNMPRefresher) reserve(ip string, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for k, t := range r.last {
+		if now.Sub(t) >= RefreshMinInterval {
+			delete(r.last, k)
+		}
+	}
+	if _, recent := r.last[ip]; recent {
+		return false
+	}
+	r.last[ip] = now
+	return true
+}
+
+// stringFields widens derived device fields to the generic field map used by groupValues
+func stringFields(fields map[string]string) map[string]interface{} {
+	widened := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		widened[k] = v
+	}
+	return widened
+}
@@ -0,0 +1,71 @@
+package monitoring
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/state"
+	"golang.org/x/time/rate"
+)
+
+// TestInterfaceValues validates ifTable rows are flattened to IF-MIB column names
+func TestInterfaceValues(t *testing.T) {
+	now := time.Now()
+	values := interfaceValues([]InterfaceStats{{Index: 3, Descr: "eth0", OperStatus: 1, InOctets: 100, OutOctets: 200, Speed: 1000}}, now)
+	if len(values) != 5 {
+		t.Fatalf("expected 5 values, got %d", len(values))
+	}
+	byName := make(map[string]interface{})
+	for _, v := range values {
+		if v.Group != SNMPGroupInterfaces || !v.PolledAt.Equal(now) {
+			t.Errorf("unexpected value metadata: %+v", v)
+		}
+		byName[v.Name] = v.Value
+	}
+	if byName["ifDescr.3"] != "eth0" || byName["ifInOctets.3"] != uint64(100) || byName["ifOperStatus.3"] != 1 {
+		t.Errorf("unexpected values: %v", byName)
+	}
+}
+
+// TestGroupValuesSorted validates group values are ordered by name
+func TestGroupValuesSorted(t *testing.T) {
+	values := groupValues("env", map[string]interface{}{"temp": 21.5, "fan": int64(1200), "psu": "ok"}, time.Now())
+	if len(values) != 3 || values[0].Name != "fan" || values[1].Name != "psu" || values[2].Name != "temp" {
+		t.Errorf("expected values sorted by name, got %+v", values)
+	}
+}
+
+// suspendedSNMPState reports every device as SNMP-suspended
+type suspendedSNMPState struct{}
+
+func (s *suspendedSNMPState) UpdateDeviceSNMP(ip, hostname, sysDescr string) {}
+func (s *suspendedSNMPState) ReportSNMPSuccess(ip string)                    {}
+func (s *suspendedSNMPState) ReportSNMPFail(ip string, maxFails int, backoff time.Duration) bool {
+	return false
+}
+func (s *suspendedSNMPState) IsSNMPSuspended(ip string) bool { return true }
+
+// TestSNMPRefresherLimits validates per-device refresh spacing and circuit breaker checks
+func TestSNMPRefresherLimits(t *testing.T) {
+	r := NewSNMPRefresher(nil, nil, &suspendedSNMPState{}, rate.NewLimiter(rate.Inf, 1), nil, nil, 3, time.Minute, config.AddressPolicy{}, nil, SNMPPollOptions{})
+	if err := r.Refresh(context.Background(), state.Device{IP: "192.168.1.1"}); !errors.Is(err, ErrSNMPSuspended) {
+		t.Errorf("expected ErrSNMPSuspended, got %v", err)
+	}
+
+	now := time.Now()
+	if !r.reserve("192.168.1.1", now) {
+		t.Fatal("expected first refresh to be allowed")
+	}
+	if r.reserve("192.168.1.1", now.Add(time.Second)) {
+		t.Error("expected second refresh within RefreshMinInterval to be rejected")
+	}
+	if !r.reserve("192.168.1.2", now.Add(time.Second)) {
+		t.Error("expected refresh of another device to be allowed")
+	}
+	if !r.reserve("192.168.1.1", now.Add(RefreshMinInterval)) {
+		t.Error("expected refresh after RefreshMinInterval to be allowed")
+	}
+}
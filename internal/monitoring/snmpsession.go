@@ -0,0 +1,160 @@
+package monitoring
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kljama/netscan/internal/snmp"
+	"github.com/rs/zerolog/log"
+)
+
+// SNMPSessionCache keeps connected SNMP sessions per device so repeated polls reuse their UDP socket
+// A session is checked out exclusively for one poll and returned afterwards; failed sessions are closed
+type SNMPSessionCache struct {
+	mu          sync.Mutex
+	idle        map[string]*snmpSession // Sessions not currently in use, by device IP
+	maxSessions int
+	idleTimeout time.Duration
+	closed      bool
+
+	hits   atomic.Uint64 // Polls that reused a cached session
+	misses atomic.Uint64 // Polls that had to open a new session
+}
+
+// snmpSession is a connected session waiting for its device's next poll
+type snmpSession struct {
+	client   *snmp.Client
+	lastUsed time.Time
+}
+
+// NewSNMPSessionCache creates a cache holding at most maxSessions idle sessions
+// Sessions unused for longer than idleTimeout are closed by Run
+func NewSNMPSessionCache(maxSessions int, idleTimeout time.Duration) *SNMPSessionCache {
+	return &SNMPSessionCache{
+		idle:        make(map[string]*snmpSession),
+		maxSessions: maxSessions,
+		idleTimeout: idleTimeout,
+	}
+}
+
+// Get returns a connected session for ip, reusing the cached one when its settings still match opts
+func (c *SNMPSessionCache) Get(ctx context.Context, ip string, opts snmp.Options) (*snmp.Client, error) {
+	c.mu.Lock()
+	session := c.idle[ip]
+	delete(c.idle, ip)
+	c.mu.Unlock()
+
+	if session != nil {
+		if session.client.Options().Equal(opts) && time.Since(session.lastUsed) < c.idleTimeout {
+			c.hits.Add(1)
+			return session.client, nil
+		}
+		session.client.Close()
+	}
+
+	c.misses.Add(1)
+	return snmp.Dial(ctx, ip, opts)
+}
+
+// Put returns a session after a poll; unhealthy sessions are closed instead of cached
+// When the cache is full the session is closed, so devices beyond max_sessions connect per poll
+func (c *SNMPSessionCache) Put(client *snmp.Client, healthy bool) {
+	if !healthy {
+		client.Close()
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || len(c.idle) >= c.maxSessions || c.idle[client.Target()] != nil {
+		client.Close()
+		return
+	}
+	c.idle[client.Target()] = &snmpSession{client: client, lastUsed: time.Now()}
+}
+
+// Sweep closes sessions idle since before now minus the idle timeout and returns how many were closed
+func (c *SNMPSessionCache) Sweep(now time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	closed := 0
+	for ip, session := range c.idle {
+		if now.Sub(session.lastUsed) >= c.idleTimeout {
+			session.client.Close()
+			delete(c.idle, ip)
+			closed++
+		}
+	}
+	return closed
+}
+
+// Len returns the number of idle cached sessions
+func (c *SNMPSessionCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.idle)
+}
+
+// Stats returns how many polls reused a session and how many opened a new one
+func (c *SNMPSessionCache) Stats() (hits, misses uint64) {
+	return c.hits.Load(), c.misses.Load()
+}
+
+// Run sweeps idle sessions until ctx is cancelled, then closes every cached session
+func (c *SNMPSessionCache) Run(ctx context.Context) {
+	sweepInterval := c.idleTimeout / 2
+	if sweepInterval < time.Second {
+		sweepInterval = time.Second
+	}
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			c.Close()
+			return
+		case now := <-ticker.C:
+			if closed := c.Sweep(now); closed > 0 {
+				hits, misses := c.Stats()
+				log.Debug().
+					Int("closed", closed).
+					Int("cached", c.Len()).
+					Uint64("hits", hits).
+					Uint64("misses", misses).
+					Msg("Closed idle SNMP sessions")
+			}
+		}
+	}
+}
+
+// Close closes every cached session; sessions returned afterwards are closed immediately
+func (c *SNMPSessionCache) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	for ip, session := range c.idle {
+		session.client.Close()
+		delete(c.idle, ip)
+	}
+}
+
+// open returns a connected session, from the cache when there is one (a nil cache connects per poll)
+func (c *SNMPSessionCache) open(ctx context.Context, ip string, opts snmp.Options) (*snmp.Client, error) {
+	if c != nil {
+		return c.Get(ctx, ip, opts)
+	}
+	return snmp.Dial(ctx, ip, opts)
+}
+
+// release returns a session to the cache, or closes it when there is no cache
+func (c *SNMPSessionCache) release(client *snmp.Client, healthy bool) {
+	if c != nil {
+		c.Put(client, healthy)
+		return
+	}
+	client.Close()
+}
@@ -0,0 +1,124 @@
+package monitoring
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/snmp"
+)
+
+// testSessionConfig targets a local port; UDP sessions connect without the peer answering
+var testSessionConfig = config.SNMPConfig{Community: "test", Port: 16161, Timeout: time.Second, Retries: 1}
+
+// TestSNMPSessionCacheReuse verifies healthy sessions are reused and failed ones are replaced
+func TestSNMPSessionCacheReuse(t *testing.T) {
+	cache := NewSNMPSessionCache(10, time.Minute)
+	defer cache.Close()
+
+	first, err := cache.Get(context.Background(), "127.0.0.1", snmp.NewOptions(&testSessionConfig, ""))
+	if err != nil {
+		t.Fatalf("failed to open session: %v", err)
+	}
+	cache.Put(first, true)
+	if cache.Len() != 1 {
+		t.Fatalf("expected 1 cached session, got %d", cache.Len())
+	}
+
+	second, err := cache.Get(context.Background(), "127.0.0.1", snmp.NewOptions(&testSessionConfig, ""))
+	if err != nil {
+		t.Fatalf("failed to get session: %v", err)
+	}
+	if second != first {
+		t.Error("expected the cached session to be reused")
+	}
+	if cache.Len() != 0 {
+		t.Error("a checked out session must not stay in the cache")
+	}
+
+	// A failed poll closes the session so the next poll reconnects
+	cache.Put(second, false)
+	third, err := cache.Get(context.Background(), "127.0.0.1", snmp.NewOptions(&testSessionConfig, ""))
+	if err != nil {
+		t.Fatalf("failed to open session: %v", err)
+	}
+	if third == first {
+		t.Error("expected a new session after a failed poll")
+	}
+
+	// Changed SNMP settings replace the cached session
+	cache.Put(third, true)
+	changed := testSessionConfig
+	changed.Community = "rotated"
+	fourth, err := cache.Get(context.Background(), "127.0.0.1", snmp.NewOptions(&changed, ""))
+	if err != nil {
+		t.Fatalf("failed to open session: %v", err)
+	}
+	if fourth == third || fourth.Options().Community != "rotated" {
+		t.Error("expected a new session with the changed community")
+	}
+	cache.Put(fourth, true)
+
+	if hits, misses := cache.Stats(); hits != 1 || misses != 3 {
+		t.Errorf("expected 1 hit and 3 misses, got %d and %d", hits, misses)
+	}
+}
+
+// TestSNMPSessionCacheLimits verifies max_sessions, idle sweeping and Close
+func TestSNMPSessionCacheLimits(t *testing.T) {
+	cache := NewSNMPSessionCache(1, time.Minute)
+
+	a, _ := cache.Get(context.Background(), "127.0.0.1", snmp.NewOptions(&testSessionConfig, ""))
+	b, _ := cache.Get(context.Background(), "127.0.0.2", snmp.NewOptions(&testSessionConfig, ""))
+	cache.Put(a, true)
+	cache.Put(b, true) // Cache full: closed instead of cached
+	if cache.Len() != 1 {
+		t.Fatalf("expected cache capped at 1 session, got %d", cache.Len())
+	}
+
+	if closed := cache.Sweep(time.Now()); closed != 0 {
+		t.Errorf("fresh session should not be swept, closed %d", closed)
+	}
+	if closed := cache.Sweep(time.Now().Add(2 * time.Minute)); closed != 1 || cache.Len() != 0 {
+		t.Errorf("expected idle session to be swept, closed %d, %d left", closed, cache.Len())
+	}
+
+	c, _ := cache.Get(context.Background(), "127.0.0.1", snmp.NewOptions(&testSessionConfig, ""))
+	cache.Close()
+	cache.Put(c, true)
+	if cache.Len() != 0 {
+		t.Error("sessions returned after Close must not be cached")
+	}
+}
+
+// TestSessionMatchesTransport verifies sessions are only reused over the configured transport
+// An unset transport means udp
+func TestSessionMatchesTransport(t *testing.T) {
+	cache := NewSNMPSessionCache(10, time.Minute)
+	defer cache.Close()
+
+	first, err := cache.Get(context.Background(), "127.0.0.1", snmp.NewOptions(&testSessionConfig, ""))
+	if err != nil {
+		t.Fatalf("failed to open session: %v", err)
+	}
+	cache.Put(first, true)
+	udp := testSessionConfig
+	udp.Transport = config.SNMPTransportUDP
+	second, err := cache.Get(context.Background(), "127.0.0.1", snmp.NewOptions(&udp, ""))
+	if err != nil {
+		t.Fatalf("failed to get session: %v", err)
+	}
+	if second != first {
+		t.Error("expected the default transport session to be reused for udp")
+	}
+
+	cache.Put(second, true)
+	tcp := testSessionConfig
+	tcp.Transport = config.SNMPTransportTCP
+	if !snmp.NewOptions(&tcp, "").Equal(snmp.Options{
+		Port: 16161, Community: "test", Timeout: time.Second, Retries: 1, Transport: "tcp",
+	}) || snmp.NewOptions(&tcp, "").Equal(second.Options()) {
+		t.Error("expected a udp session not to match a tcp transport")
+	}
+}
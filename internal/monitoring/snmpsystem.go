@@ -0,0 +1,33 @@
+package monitoring
+
+import (
+	"time"
+
+	"github.com/kljama/netscan/internal/state"
+)
+
+// SystemInfoTracker is implemented by state managers that keep the system group and detect reboots
+type SystemInfoTracker interface {
+	UpdateDeviceSystem(ip string, info state.SystemInfo, polledAt time.Time) bool
+}
+
+// systemValues returns the system group values for the device API cache, omitting unanswered objects
+func systemValues(info state.SystemInfo, polledAt time.Time) []state.SNMPValue {
+	var values []state.SNMPValue
+	add := func(name string, value interface{}) {
+		values = append(values, state.SNMPValue{Group: SNMPGroupSystem, Name: name, Value: value, PolledAt: polledAt})
+	}
+	if info.ObjectID != "" {
+		add("sysObjectID", info.ObjectID)
+	}
+	if info.UpTime > 0 {
+		add("sysUpTime", info.UpTime.Round(time.Second).String())
+	}
+	if info.Contact != "" {
+		add("sysContact", info.Contact)
+	}
+	if info.Location != "" {
+		add("sysLocation", info.Location)
+	}
+	return values
+}
@@ -0,0 +1,19 @@
+package monitoring
+
+import "net"
+
+// listenAddress returns the local address ICMP sockets sending from source are bound to ("" = any)
+func listenAddress(source string) string {
+	if source != "" {
+		return source
+	}
+	return "0.0.0.0"
+}
+
+// snmpLocalAddr returns the gosnmp LocalAddr of SNMP sessions sending from source ("" = any)
+func snmpLocalAddr(source string) string {
+	if source != "" {
+		return net.JoinHostPort(source, "0")
+	}
+	return ""
+}
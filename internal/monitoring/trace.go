@@ -0,0 +1,16 @@
+package monitoring
+
+import (
+	"github.com/kljama/netscan/internal/flags"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// probeLog returns the event for a per-probe debug line, promoted to info when the device is traced
+// (probe_trace or verbose runtime flag) so one device can be diagnosed without global debug logging
+func probeLog(ip string) *zerolog.Event {
+	if flags.Traced(ip) {
+		return log.Info().Bool("trace", true)
+	}
+	return log.Debug()
+}
@@ -0,0 +1,270 @@
+package monitoring
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+// traceBasePort is the first UDP destination port of UDP traceroutes (port = base + TTL)
+const traceBasePort = 33434
+
+// TraceHop is one TTL step of a traceroute
+type TraceHop struct {
+	TTL     int
+	IP      string        // Router or target that answered ("" = no answer within the timeout)
+	RTT     time.Duration // Time to the answer (0 without one)
+	Reached bool          // The target itself answered
+}
+
+// traceMatch identifies the ICMP answers to the probes of one traceroute
+type traceMatch struct {
+	protocol string
+	dst      net.IP
+	id       int // ICMP echo identifier, or UDP source port
+}
+
+// match returns the TTL of the probe a received ICMP message answers, whether the target answered
+// and whether tracing should stop (target reached or the path reported unreachable)
+func (t traceMatch) match(msg *icmp.Message, peer net.IP) (ttl int, reached, final, ok bool) {
+	switch body := msg.Body.(type) {
+	case *icmp.Echo:
+		if t.protocol != config.TracerouteICMP || msg.Type != ipv4.ICMPTypeEchoReply || body.ID != t.id || !peer.Equal(t.dst) {
+			return 0, false, false, false
+		}
+		return body.Seq, true, true, true
+	case *icmp.TimeExceeded:
+		ttl, ok = t.matchQuoted(body.Data)
+		return ttl, false, false, ok
+	case *icmp.DstUnreach:
+		ttl, ok = t.matchQuoted(body.Data)
+		if !ok {
+			return 0, false, false, false
+		}
+		// Port unreachable from the target ends a UDP traceroute; anything else means the path is broken
+		reached = t.protocol == config.TracerouteUDP && msg.Code == 3 && peer.Equal(t.dst)
+		return ttl, reached, true, true
+	}
+	return 0, false, false, false
+}
+
+// matchQuoted returns the TTL of our probe quoted in an ICMP error (original IPv4 header plus 8 bytes)
+func (t traceMatch) matchQuoted(data []byte) (int, bool) {
+	if len(data) < 20 {
+		return 0, false
+	}
+	headerLen := int(data[0]&0x0f) * 4
+	if headerLen < 20 || len(data) < headerLen+8 || !net.IP(data[16:20]).Equal(t.dst) {
+		return 0, false
+	}
+	inner := data[headerLen:]
+	switch {
+	case t.protocol == config.TracerouteICMP && data[9] == protocolICMP:
+		if inner[0] != byte(ipv4.ICMPTypeEcho) || int(binary.BigEndian.Uint16(inner[4:6])) != t.id {
+			return 0, false
+		}
+		return int(binary.BigEndian.Uint16(inner[6:8])), true
+	case t.protocol == config.TracerouteUDP && data[9] == 17:
+		if int(binary.BigEndian.Uint16(inner[0:2])) != t.id {
+			return 0, false
+		}
+		return int(binary.BigEndian.Uint16(inner[2:4])) - traceBasePort, true
+	}
+	return 0, false
+}
+
+// Traceroute probes the path to an IPv4 target with increasing TTL until the target answers, a router reports
+// it unreachable or maxHops is reached. protocol is icmp (echo requests) or udp (datagrams to ports 33434+TTL)
+// Probes are sent from source ("" = chosen by the routing table)
+// Requires raw ICMP sockets (root or CAP_NET_RAW) to receive time exceeded messages
+func Traceroute(ctx context.Context, ip, source, protocol string, maxHops int, timeout time.Duration) ([]TraceHop, error) {
+	dst := net.ParseIP(ip).To4()
+	if dst == nil {
+		return nil, fmt.Errorf("traceroute supports IPv4 targets only, got %q", ip)
+	}
+	conn, err := icmp.ListenPacket("ip4:icmp", listenAddress(source))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ICMP socket: %v", err)
+	}
+	defer conn.Close()
+
+	match := traceMatch{protocol: protocol, dst: dst}
+	var send func(ttl int) error
+	switch protocol {
+	case config.TracerouteUDP:
+		udp, err := net.ListenPacket("udp4", net.JoinHostPort(listenAddress(source), "0"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open UDP socket: %v", err)
+		}
+		defer udp.Close()
+		match.id = udp.LocalAddr().(*net.UDPAddr).Port
+		udpConn := ipv4.NewPacketConn(udp)
+		send = func(ttl int) error {
+			if err := udpConn.SetTTL(ttl); err != nil {
+				return err
+			}
+			_, err := udp.WriteTo([]byte("netscan"), &net.UDPAddr{IP: dst, Port: traceBasePort + ttl})
+			return err
+		}
+	default:
+		var id [2]byte
+		if _, err := rand.Read(id[:]); err != nil {
+			return nil, err
+		}
+		match.id = int(binary.BigEndian.Uint16(id[:]))
+		send = func(ttl int) error {
+			if err := conn.IPv4PacketConn().SetTTL(ttl); err != nil {
+				return err
+			}
+			packet, err := (&icmp.Message{
+				Type: ipv4.ICMPTypeEcho,
+				Body: &icmp.Echo{ID: match.id, Seq: ttl, Data: []byte("netscan")},
+			}).Marshal(nil)
+			if err != nil {
+				return err
+			}
+			_, err = conn.WriteTo(packet, &net.IPAddr{IP: dst})
+			return err
+		}
+	}
+
+	hops := make([]TraceHop, 0, maxHops)
+	buf := make([]byte, 1500)
+	for ttl := 1; ttl <= maxHops; ttl++ {
+		if ctx.Err() != nil {
+			return hops, ctx.Err()
+		}
+		sent := time.Now()
+		if err := send(ttl); err != nil {
+			return hops, fmt.Errorf("failed to send probe with TTL %d: %v", ttl, err)
+		}
+		hop, final, err := awaitHop(conn, match, ttl, sent, sent.Add(timeout), buf)
+		if err != nil {
+			return hops, err
+		}
+		hops = append(hops, hop)
+		if final {
+			break
+		}
+	}
+	return hops, nil
+}
+
+// awaitHop reads ICMP messages until the answer to the probe with ttl arrives or deadline passes
+// Answers to earlier probes that arrive late are ignored
+func awaitHop(conn *icmp.PacketConn, match traceMatch, ttl int, sent, deadline time.Time, buf []byte) (TraceHop, bool, error) {
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return TraceHop{}, false, err
+	}
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return TraceHop{TTL: ttl}, false, nil
+			}
+			return TraceHop{}, false, err
+		}
+		addr, isIP := peer.(*net.IPAddr)
+		if !isIP {
+			continue
+		}
+		msg, err := icmp.ParseMessage(protocolICMP, buf[:n])
+		if err != nil {
+			continue
+		}
+		answered, reached, final, ok := match.match(msg, addr.IP)
+		if !ok || answered != ttl {
+			continue
+		}
+		return TraceHop{TTL: ttl, IP: addr.IP.String(), RTT: time.Since(sent), Reached: reached}, final, nil
+	}
+}
+
+// Tracer runs traceroutes triggered by device events in the background, at most once per cooldown
+// per device and at most max_concurrent at a time
+type Tracer struct {
+	cfg    config.TracerouteConfig
+	source string                                                            // Local IPv4 address probes are sent from ("" = any)
+	write  func(ip, hostname, trigger string, at time.Time, hops []TraceHop) // Receives every completed trace
+	trace  func(ctx context.Context, ip, source, protocol string, maxHops int, timeout time.Duration) ([]TraceHop, error)
+	slots  chan struct{}
+
+	mu   sync.Mutex
+	last map[string]time.Time // Start of the latest traceroute per device
+}
+
+// NewTracer creates a tracer probing from source ("" = any) that hands every completed traceroute to write
+func NewTracer(cfg config.TracerouteConfig, source string, write func(ip, hostname, trigger string, at time.Time, hops []TraceHop)) *Tracer {
+	return &Tracer{
+		cfg:    cfg,
+		source: source,
+		write:  write,
+		trace:  Traceroute,
+		slots:  make(chan struct{}, cfg.MaxConcurrent),
+		last:   make(map[string]time.Time),
+	}
+}
+
+// Trigger starts a traceroute to ip unless one started within the cooldown or all slots are busy
+// Returns whether a traceroute was started; never blocks
+func (t *Tracer) Trigger(ctx context.Context, ip, hostname, trigger string) bool {
+	now := time.Now()
+	t.mu.Lock()
+	for device, at := range t.last {
+		if now.Sub(at) >= t.cfg.Cooldown {
+			delete(t.last, device)
+		}
+	}
+	if _, recent := t.last[ip]; recent {
+		t.mu.Unlock()
+		return false
+	}
+	select {
+	case t.slots <- struct{}{}:
+	default:
+		t.mu.Unlock()
+		log.Debug().Str("ip", ip).Str("trigger", trigger).Msg("Traceroute skipped, all slots busy")
+		return false
+	}
+	t.last[ip] = now
+	t.mu.Unlock()
+
+	go func() {
+		// Panic recovery for traceroute goroutine
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error().
+					Str("ip", ip).
+					Interface("panic", r).
+					Msg("Traceroute panic recovered")
+			}
+		}()
+		defer func() { <-t.slots }()
+
+		hops, err := t.trace(ctx, ip, t.source, t.cfg.Protocol, t.cfg.MaxHops, t.cfg.Timeout)
+		if err != nil && len(hops) == 0 {
+			log.Warn().Str("ip", ip).Str("trigger", trigger).Err(err).Msg("Traceroute failed")
+			return
+		}
+		reached := len(hops) > 0 && hops[len(hops)-1].Reached
+		log.Info().
+			Str("ip", ip).
+			Str("trigger", trigger).
+			Int("hops", len(hops)).
+			Bool("reached", reached).
+			Msg("Traceroute completed")
+		t.write(ip, hostname, trigger, now, hops)
+	}()
+	return true
+}
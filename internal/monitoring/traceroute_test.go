@@ -0,0 +1,143 @@
+package monitoring
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+// quotedProbe builds the original IPv4 header plus 8 bytes an ICMP error quotes for one of our probes
+func quotedProbe(protocol byte, dst net.IP, first, second uint16) []byte {
+	data := make([]byte, 28)
+	data[0] = 0x45
+	data[9] = protocol
+	copy(data[16:20], dst.To4())
+	if protocol == protocolICMP {
+		data[20] = byte(ipv4.ICMPTypeEcho)
+		binary.BigEndian.PutUint16(data[24:26], first)
+		binary.BigEndian.PutUint16(data[26:28], second)
+	} else {
+		binary.BigEndian.PutUint16(data[20:22], first)
+		binary.BigEndian.PutUint16(data[22:24], second)
+	}
+	return data
+}
+
+// TestTraceMatch verifies ICMP answers are matched to the probe TTL and classified as hop, target or dead end
+func TestTraceMatch(t *testing.T) {
+	dst := net.ParseIP("10.0.0.9").To4()
+	router := net.ParseIP("10.0.0.1")
+	icmpTrace := traceMatch{protocol: config.TracerouteICMP, dst: dst, id: 4242}
+	udpTrace := traceMatch{protocol: config.TracerouteUDP, dst: dst, id: 50000}
+
+	tests := []struct {
+		name                 string
+		match                traceMatch
+		msg                  *icmp.Message
+		peer                 net.IP
+		ttl                  int
+		reached, final, isOK bool
+	}{
+		{
+			name:  "icmp time exceeded",
+			match: icmpTrace,
+			msg:   &icmp.Message{Type: ipv4.ICMPTypeTimeExceeded, Body: &icmp.TimeExceeded{Data: quotedProbe(protocolICMP, dst, 4242, 3)}},
+			peer:  router, ttl: 3, isOK: true,
+		},
+		{
+			name:  "icmp time exceeded for another identifier",
+			match: icmpTrace,
+			msg:   &icmp.Message{Type: ipv4.ICMPTypeTimeExceeded, Body: &icmp.TimeExceeded{Data: quotedProbe(protocolICMP, dst, 1, 3)}},
+			peer:  router,
+		},
+		{
+			name:  "echo reply from target",
+			match: icmpTrace,
+			msg:   &icmp.Message{Type: ipv4.ICMPTypeEchoReply, Body: &icmp.Echo{ID: 4242, Seq: 7}},
+			peer:  dst, ttl: 7, reached: true, final: true, isOK: true,
+		},
+		{
+			name:  "udp time exceeded",
+			match: udpTrace,
+			msg:   &icmp.Message{Type: ipv4.ICMPTypeTimeExceeded, Body: &icmp.TimeExceeded{Data: quotedProbe(17, dst, 50000, traceBasePort+2)}},
+			peer:  router, ttl: 2, isOK: true,
+		},
+		{
+			name:  "udp port unreachable from target",
+			match: udpTrace,
+			msg:   &icmp.Message{Type: ipv4.ICMPTypeDestinationUnreachable, Code: 3, Body: &icmp.DstUnreach{Data: quotedProbe(17, dst, 50000, traceBasePort+5)}},
+			peer:  dst, ttl: 5, reached: true, final: true, isOK: true,
+		},
+		{
+			name:  "host unreachable from router",
+			match: udpTrace,
+			msg:   &icmp.Message{Type: ipv4.ICMPTypeDestinationUnreachable, Code: 1, Body: &icmp.DstUnreach{Data: quotedProbe(17, dst, 50000, traceBasePort+4)}},
+			peer:  router, ttl: 4, final: true, isOK: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ttl, reached, final, ok := tt.match.match(tt.msg, tt.peer)
+			if ok != tt.isOK || ttl != tt.ttl || reached != tt.reached || final != tt.final {
+				t.Errorf("got ttl=%d reached=%v final=%v ok=%v, want ttl=%d reached=%v final=%v ok=%v",
+					ttl, reached, final, ok, tt.ttl, tt.reached, tt.final, tt.isOK)
+			}
+		})
+	}
+}
+
+// TestTracerTrigger verifies the per-device cooldown and that triggers beyond max_concurrent are skipped
+func TestTracerTrigger(t *testing.T) {
+	cfg := config.TracerouteConfig{Enabled: true, Protocol: config.TracerouteICMP, MaxHops: 5, Timeout: time.Second, Cooldown: time.Hour, MaxConcurrent: 1}
+
+	var mu sync.Mutex
+	var written []string
+	done := make(chan struct{}, 2)
+	tracer := NewTracer(cfg, "", func(ip, hostname, trigger string, at time.Time, hops []TraceHop) {
+		mu.Lock()
+		written = append(written, ip+"/"+trigger)
+		mu.Unlock()
+		done <- struct{}{}
+	})
+	release := make(chan struct{})
+	tracer.trace = func(ctx context.Context, ip, source, protocol string, maxHops int, timeout time.Duration) ([]TraceHop, error) {
+		<-release
+		return []TraceHop{{TTL: 1, IP: ip, Reached: true}}, nil
+	}
+
+	ctx := context.Background()
+	if !tracer.Trigger(ctx, "10.0.0.1", "host-1", config.TracerouteOnDown) {
+		t.Fatal("expected the first trigger to start a traceroute")
+	}
+	if tracer.Trigger(ctx, "10.0.0.2", "host-2", config.TracerouteOnDown) {
+		t.Error("expected a trigger to be skipped while the only slot is busy")
+	}
+	close(release)
+	<-done
+
+	if tracer.Trigger(ctx, "10.0.0.1", "host-1", config.TracerouteOnLatency) {
+		t.Error("expected a trigger within the cooldown to be skipped")
+	}
+	// The slot is released just after write returns
+	deadline := time.Now().Add(time.Second)
+	for !tracer.Trigger(ctx, "10.0.0.2", "host-2", config.TracerouteOnLatency) {
+		if time.Now().After(deadline) {
+			t.Fatal("expected a trigger for another device to start once the slot is free")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(written) != 2 || written[0] != "10.0.0.1/down" || written[1] != "10.0.0.2/latency" {
+		t.Errorf("expected traces for 10.0.0.1/down and 10.0.0.2/latency, got %v", written)
+	}
+}
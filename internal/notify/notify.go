@@ -0,0 +1,349 @@
+// Package notify posts device state change notifications to webhooks (Slack, Teams or generic JSON) and syslog receivers
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/events"
+	"github.com/kljama/netscan/internal/state"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/time/rate"
+)
+
+// queueSize bounds events waiting for delivery; Notify drops events when the queue is full
+const queueSize = 256
+
+// sendTimeout bounds a single webhook POST so a hung receiver cannot stall later notifications
+const sendTimeout = 10 * time.Second
+
+// defaultTemplates are the message texts used when a webhook has no template
+var defaultTemplates = map[string]string{
+	config.NotifyEventDown:            `{{.Name}} ({{.IP}}) is DOWN after {{.Failures}} failed pings{{if .PreviousDuration}} (was up {{.PreviousDuration}}){{end}}`,
+	config.NotifyEventUp:              `{{.Name}} ({{.IP}}) is UP again{{if .PreviousDuration}} after {{.PreviousDuration}} down{{end}}`,
+	config.NotifyEventSuspended:       `{{.Name}} ({{.IP}}) pinging suspended by circuit breaker until {{.SuspendedUntil.Format "15:04:05 MST"}}`,
+	config.NotifyEventCheckFailed:     `{{.Name}} ({{.IP}}) check {{.Check}} FAILED: {{join .FailedConditions ", "}}`,
+	config.NotifyEventCheckOK:         `{{.Name}} ({{.IP}}) check {{.Check}} is healthy again`,
+	config.NotifyEventLatencyWarning:  `{{.Name}} ({{.IP}}) latency WARNING ({{.Threshold}}): {{if .Percentile}}p{{.Percentile}} {{end}}RTT {{.RTT}} above {{.Limit}}`,
+	config.NotifyEventLatencyCritical: `{{.Name}} ({{.IP}}) latency CRITICAL ({{.Threshold}}): {{if .Percentile}}p{{.Percentile}} {{end}}RTT {{.RTT}} above {{.Limit}}`,
+	config.NotifyEventLatencyOK:       `{{.Name}} ({{.IP}}) latency back to normal ({{.Threshold}}): {{if .Percentile}}p{{.Percentile}} {{end}}RTT {{.RTT}}`,
+}
+
+// templateFuncs are available to message templates
+var templateFuncs = template.FuncMap{"join": strings.Join}
+
+// Teams card colors per event type
+var teamsColors = map[string]string{
+	config.NotifyEventDown:            "D32F2F",
+	config.NotifyEventUp:              "388E3C",
+	config.NotifyEventSuspended:       "F57C00",
+	config.NotifyEventCheckFailed:     "D32F2F",
+	config.NotifyEventCheckOK:         "388E3C",
+	config.NotifyEventLatencyWarning:  "F57C00",
+	config.NotifyEventLatencyCritical: "D32F2F",
+	config.NotifyEventLatencyOK:       "388E3C",
+}
+
+// Event is one device state change; its fields are available to message templates
+type Event struct {
+	Type             string        // down, up, suspended, check_failed, check_ok or latency_warning/critical/ok
+	IP               string        // Device IP
+	Hostname         string        // Device hostname (may be empty or the IP)
+	Previous         string        // Previous reachability state (down/up events) or latency level (latency events)
+	Failures         int           // Consecutive failed pings behind a down event
+	PreviousDuration time.Duration // Time spent in the previous state (0 if unknown)
+	SuspendedUntil   time.Time     // End of the circuit breaker suspension (suspended events)
+	Check            string        // Composite check name (check events)
+	FailedConditions []string      // Conditions that did not hold (check_failed events)
+	Threshold        string        // Latency threshold name (latency events)
+	RTT              time.Duration // RTT compared with the threshold (latency events)
+	Limit            time.Duration // Limit that was exceeded (latency_warning and latency_critical events)
+	Percentile       float64       // Percentile of the RTT history compared (0 = single cycle)
+	Time             time.Time     // When the change happened
+}
+
+// Name returns the hostname, falling back to the IP
+func (e Event) Name() string {
+	if e.Hostname == "" {
+		return e.IP
+	}
+	return e.Hostname
+}
+
+// FromBusEvent converts an event bus event into a notification
+// Returns false for event types that are not notified (discoveries, scans, sink errors)
+func FromBusEvent(ev events.Event) (Event, bool) {
+	event := Event{IP: ev.IP, Hostname: ev.Hostname, Time: ev.Time}
+	switch payload := ev.Payload.(type) {
+	case state.StateEvent:
+		event.Type = payload.State
+		event.Previous = payload.Previous
+		event.Failures = payload.Failures
+		event.PreviousDuration = payload.PreviousDuration().Round(time.Second)
+	case events.Suspension:
+		event.Type = config.NotifyEventSuspended
+		event.SuspendedUntil = payload.Until
+	case events.CheckChange:
+		event.Type = config.NotifyEventCheckOK
+		event.Check = payload.Check
+		if !payload.Healthy {
+			event.Type = config.NotifyEventCheckFailed
+			event.FailedConditions = payload.Failed
+		}
+	case events.LatencyAlert:
+		event.Type = "latency_" + payload.Level
+		event.Previous = payload.Previous
+		event.Threshold = payload.Threshold
+		event.RTT = msDuration(payload.RTTMs)
+		event.Limit = msDuration(payload.LimitMs)
+		event.Percentile = payload.Percentile
+	default:
+		return Event{}, false
+	}
+	return event, true
+}
+
+// msDuration converts milliseconds to a duration, rounded to the microsecond for readable messages
+func msDuration(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond)).Round(time.Microsecond)
+}
+
+// webhook is one configured receiver with its parsed templates and rate limiter
+type webhook struct {
+	cfg        config.WebhookConfig
+	templates  map[string]*template.Template // Message template per event type
+	limiter    *rate.Limiter
+	suppressed int // Events dropped by the rate limiter since the last delivered message
+}
+
+// Notifier delivers events to webhooks and syslog receivers from a single background goroutine
+type Notifier struct {
+	webhooks []*webhook
+	syslogs  []*syslogTarget
+	client   *http.Client
+	queue    chan Event
+}
+
+// New creates a notifier for the configured webhooks and syslog receivers; returns nil if none are configured
+func New(cfg config.NotifyConfig) (*Notifier, error) {
+	if len(cfg.Webhooks) == 0 && len(cfg.Syslog) == 0 {
+		return nil, nil
+	}
+	perMinute := cfg.RateLimit
+	if perMinute < 1 {
+		perMinute = 10
+	}
+
+	n := &Notifier{
+		client: &http.Client{Timeout: sendTimeout},
+		queue:  make(chan Event, queueSize),
+	}
+	for _, hookCfg := range cfg.Webhooks {
+		hook := &webhook{
+			cfg:       hookCfg,
+			templates: make(map[string]*template.Template),
+			limiter:   rate.NewLimiter(rate.Every(time.Minute/time.Duration(perMinute)), perMinute),
+		}
+		for event, text := range defaultTemplates {
+			if hookCfg.Template != "" {
+				text = hookCfg.Template
+			}
+			tmpl, err := template.New(hookCfg.Name + "_" + event).Funcs(templateFuncs).Parse(text)
+			if err != nil {
+				return nil, fmt.Errorf("webhook %s: invalid template: %v", hookCfg.Name, err)
+			}
+			hook.templates[event] = tmpl
+		}
+		n.webhooks = append(n.webhooks, hook)
+	}
+	for _, syslogCfg := range cfg.Syslog {
+		target, err := newSyslogTarget(syslogCfg)
+		if err != nil {
+			return nil, err
+		}
+		n.syslogs = append(n.syslogs, target)
+	}
+	return n, nil
+}
+
+// Notify queues an event for delivery without blocking; safe to call on a nil Notifier
+func (n *Notifier) Notify(event Event) {
+	if n == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	select {
+	case n.queue <- event:
+	default:
+		log.Warn().
+			Str("ip", event.IP).
+			Str("event", event.Type).
+			Msg("Notification queue full, dropping event")
+	}
+}
+
+// Run delivers queued events until ctx is cancelled, then closes the syslog connections
+func (n *Notifier) Run(ctx context.Context) {
+	defer func() {
+		for _, target := range n.syslogs {
+			target.close()
+		}
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-n.queue:
+			for _, hook := range n.webhooks {
+				n.deliver(ctx, hook, event)
+			}
+			for _, target := range n.syslogs {
+				n.deliverSyslog(ctx, target, event)
+			}
+		}
+	}
+}
+
+// deliver sends one event to one webhook, subject to its subscriptions and rate limit
+func (n *Notifier) deliver(ctx context.Context, hook *webhook, event Event) {
+	if !hook.cfg.Wants(event.Type) {
+		return
+	}
+	if !hook.limiter.Allow() {
+		hook.suppressed++
+		log.Debug().
+			Str("webhook", hook.cfg.Name).
+			Str("ip", event.IP).
+			Str("event", event.Type).
+			Msg("Notification rate limited")
+		return
+	}
+
+	message, err := renderMessage(hook.templates[event.Type], event)
+	if err != nil {
+		log.Error().
+			Str("webhook", hook.cfg.Name).
+			Err(err).
+			Msg("Failed to render notification template")
+		return
+	}
+	if hook.suppressed > 0 {
+		message += fmt.Sprintf(" (%d earlier notifications suppressed by rate limit)", hook.suppressed)
+	}
+
+	body, err := json.Marshal(buildPayload(hook.cfg.Format, event, message))
+	if err != nil {
+		log.Error().Str("webhook", hook.cfg.Name).Err(err).Msg("Failed to encode notification")
+		return
+	}
+	// The URL is never logged: Slack and Teams webhook URLs embed their secret
+	if err := n.post(ctx, hook.cfg.URL, body); err != nil {
+		log.Error().
+			Str("webhook", hook.cfg.Name).
+			Str("ip", event.IP).
+			Str("event", event.Type).
+			Err(err).
+			Msg("Failed to send notification")
+		return
+	}
+	hook.suppressed = 0
+	log.Debug().
+		Str("webhook", hook.cfg.Name).
+		Str("ip", event.IP).
+		Str("event", event.Type).
+		Msg("Notification sent")
+}
+
+// post sends a JSON body and treats any non-2xx response as an error
+func (n *Notifier) post(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096)) // Drain so the connection can be reused
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// renderMessage executes a message template for an event
+func renderMessage(tmpl *template.Template, event Event) (string, error) {
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, event); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// buildPayload wraps a message in the JSON shape expected by the webhook format
+func buildPayload(format string, event Event, message string) interface{} {
+	switch format {
+	case config.WebhookFormatSlack:
+		return map[string]string{"text": message}
+	case config.WebhookFormatTeams:
+		return map[string]string{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    message,
+			"themeColor": teamsColors[event.Type],
+			"title":      fmt.Sprintf("netscan: %s %s", event.Name(), event.Type),
+			"text":       message,
+		}
+	default:
+		payload := map[string]interface{}{
+			"event":    event.Type,
+			"ip":       event.IP,
+			"hostname": event.Hostname,
+			"message":  message,
+			"time":     event.Time.UTC().Format(time.RFC3339),
+		}
+		if event.Previous != "" {
+			payload["previous"] = event.Previous
+		}
+		if event.Failures > 0 {
+			payload["failures"] = event.Failures
+		}
+		if event.PreviousDuration > 0 {
+			payload["previous_duration_s"] = event.PreviousDuration.Seconds()
+		}
+		if event.Check != "" {
+			payload["check"] = event.Check
+		}
+		if len(event.FailedConditions) > 0 {
+			payload["failed_conditions"] = event.FailedConditions
+		}
+		if event.Threshold != "" {
+			payload["threshold"] = event.Threshold
+			payload["rtt_ms"] = float64(event.RTT) / float64(time.Millisecond)
+			if event.Limit > 0 {
+				payload["limit_ms"] = float64(event.Limit) / float64(time.Millisecond)
+			}
+			if event.Percentile > 0 {
+				payload["percentile"] = event.Percentile
+			}
+		}
+		if !event.SuspendedUntil.IsZero() {
+			payload["suspended_until"] = event.SuspendedUntil.UTC().Format(time.RFC3339)
+		}
+		return payload
+	}
+}
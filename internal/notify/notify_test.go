@@ -0,0 +1,176 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/kljama/netscan/internal/events"
+	"github.com/kljama/netscan/internal/state"
+)
+
+// receiver records JSON bodies posted to a test webhook
+type receiver struct {
+	mu     sync.Mutex
+	bodies []map[string]interface{}
+}
+
+func (r *receiver) handler(w http.ResponseWriter, req *http.Request) {
+	var body map[string]interface{}
+	json.NewDecoder(req.Body).Decode(&body)
+	r.mu.Lock()
+	r.bodies = append(r.bodies, body)
+	r.mu.Unlock()
+}
+
+func (r *receiver) received() []map[string]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]map[string]interface{}(nil), r.bodies...)
+}
+
+// waitFor polls until the receiver has n bodies or the deadline passes
+func (r *receiver) waitFor(t *testing.T, n int) []map[string]interface{} {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if bodies := r.received(); len(bodies) >= n {
+			return bodies
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected %d notifications, got %d", n, len(r.received()))
+	return nil
+}
+
+// TestNotifierFormats verifies the payload shape of each webhook format and event filtering
+func TestNotifierFormats(t *testing.T) {
+	generic, slack, teams := &receiver{}, &receiver{}, &receiver{}
+	servers := []*httptest.Server{
+		httptest.NewServer(http.HandlerFunc(generic.handler)),
+		httptest.NewServer(http.HandlerFunc(slack.handler)),
+		httptest.NewServer(http.HandlerFunc(teams.handler)),
+	}
+	for _, s := range servers {
+		defer s.Close()
+	}
+
+	n, err := New(config.NotifyConfig{
+		RateLimit: 60,
+		Webhooks: []config.WebhookConfig{
+			{Name: "generic", URL: servers[0].URL, Format: config.WebhookFormatGeneric, Events: []string{"down", "up", "suspended"}},
+			{Name: "slack", URL: servers[1].URL, Format: config.WebhookFormatSlack, Events: []string{"down"}, Template: "{{.IP}} went {{.Type}}"},
+			{Name: "teams", URL: servers[2].URL, Format: config.WebhookFormatTeams, Events: []string{"down"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go n.Run(ctx)
+
+	n.Notify(Event{Type: "down", IP: "10.0.0.1", Hostname: "sw1", Previous: "up", Failures: 3, PreviousDuration: time.Hour})
+	n.Notify(Event{Type: "up", IP: "10.0.0.1", Hostname: "sw1", Previous: "down"})
+
+	got := generic.waitFor(t, 2)
+	if got[0]["event"] != "down" || got[0]["ip"] != "10.0.0.1" || got[0]["failures"] != 3.0 || got[0]["previous_duration_s"] != 3600.0 {
+		t.Errorf("unexpected generic payload: %v", got[0])
+	}
+	if msg, _ := got[0]["message"].(string); !strings.Contains(msg, "sw1 (10.0.0.1) is DOWN after 3 failed pings") {
+		t.Errorf("unexpected default message: %q", msg)
+	}
+	if got[1]["event"] != "up" {
+		t.Errorf("expected up event, got %v", got[1])
+	}
+
+	if got := slack.waitFor(t, 1); got[0]["text"] != "10.0.0.1 went down" {
+		t.Errorf("unexpected slack payload: %v", got[0])
+	}
+	if got := teams.waitFor(t, 1); got[0]["@type"] != "MessageCard" || got[0]["themeColor"] != "D32F2F" {
+		t.Errorf("unexpected teams payload: %v", got[0])
+	}
+
+	// Only subscribed events are delivered
+	time.Sleep(50 * time.Millisecond)
+	if len(slack.received()) != 1 || len(teams.received()) != 1 {
+		t.Errorf("up event should not reach down-only webhooks")
+	}
+}
+
+// TestNotifierRateLimit verifies excess events are dropped and reported in the next message
+func TestNotifierRateLimit(t *testing.T) {
+	recv := &receiver{}
+	server := httptest.NewServer(http.HandlerFunc(recv.handler))
+	defer server.Close()
+
+	n, err := New(config.NotifyConfig{
+		RateLimit: 2,
+		Webhooks:  []config.WebhookConfig{{Name: "ops", URL: server.URL, Format: config.WebhookFormatSlack, Events: []string{"down"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook := n.webhooks[0]
+	for i := 0; i < 5; i++ {
+		n.deliver(context.Background(), hook, Event{Type: "down", IP: "10.0.0.1", Failures: 3})
+	}
+
+	if got := len(recv.received()); got != 2 {
+		t.Fatalf("expected 2 delivered notifications within the burst, got %d", got)
+	}
+	if hook.suppressed != 3 {
+		t.Errorf("expected 3 suppressed events, got %d", hook.suppressed)
+	}
+}
+
+// TestNilNotifier verifies a notifier without webhooks is nil and safe to use
+func TestNilNotifier(t *testing.T) {
+	n, err := New(config.NotifyConfig{})
+	if err != nil || n != nil {
+		t.Fatalf("expected nil notifier, got %v, %v", n, err)
+	}
+	n.Notify(Event{Type: "down", IP: "10.0.0.1"}) // Must not panic
+}
+
+// TestFromBusEvent validates which bus events become notifications and how they are mapped
+func TestFromBusEvent(t *testing.T) {
+	now := time.Now()
+	down := state.StateEvent{IP: "192.168.1.1", State: state.ReachabilityDown, Previous: state.ReachabilityUp, Failures: 3, Time: now, PreviousSince: now.Add(-90 * time.Second)}
+	event, ok := FromBusEvent(events.Event{Type: events.TypeDeviceState, IP: "192.168.1.1", Time: now, Payload: down})
+	if !ok || event.Type != config.NotifyEventDown || event.Failures != 3 || event.PreviousDuration != 90*time.Second {
+		t.Errorf("unexpected down notification: %+v (ok=%v)", event, ok)
+	}
+
+	event, ok = FromBusEvent(events.Event{Type: events.TypeCompositeCheck, IP: "192.168.1.1", Payload: events.CheckChange{Check: "uplink", Failed: []string{"ping"}}})
+	if !ok || event.Type != config.NotifyEventCheckFailed || event.Check != "uplink" || len(event.FailedConditions) != 1 {
+		t.Errorf("unexpected check notification: %+v (ok=%v)", event, ok)
+	}
+
+	event, ok = FromBusEvent(events.Event{Type: events.TypeDeviceSuspended, IP: "192.168.1.1", Payload: events.Suspension{Until: now}})
+	if !ok || event.Type != config.NotifyEventSuspended || !event.SuspendedUntil.Equal(now) {
+		t.Errorf("unexpected suspension notification: %+v (ok=%v)", event, ok)
+	}
+
+	alert := events.LatencyAlert{Threshold: "wan", Level: config.LatencyLevelCritical, Previous: config.LatencyLevelWarning, RTTMs: 250.5, LimitMs: 200, Percentile: 95}
+	event, ok = FromBusEvent(events.Event{Type: events.TypeLatencyAlert, IP: "10.0.0.1", Hostname: "wan-router", Payload: alert})
+	if !ok || event.Type != config.NotifyEventLatencyCritical || event.RTT != 250500*time.Microsecond || event.Limit != 200*time.Millisecond {
+		t.Errorf("unexpected latency notification: %+v (ok=%v)", event, ok)
+	}
+	message, err := renderMessage(template.Must(template.New("t").Funcs(templateFuncs).Parse(defaultTemplates[event.Type])), event)
+	if err != nil || message != "wan-router (10.0.0.1) latency CRITICAL (wan): p95 RTT 250.5ms above 200ms" {
+		t.Errorf("unexpected latency message %q (%v)", message, err)
+	}
+
+	if _, ok := FromBusEvent(events.Event{Type: events.TypeScanCompleted, Payload: events.ScanSummary{}}); ok {
+		t.Error("expected scan events not to be notified")
+	}
+}
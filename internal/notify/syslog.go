@@ -0,0 +1,199 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/rs/zerolog/log"
+)
+
+// syslogSDID is the structured data ID of the event parameters; netscan has no registered enterprise number,
+// so the documentation number of RFC 5612 is used
+const syslogSDID = "netscan@32473"
+
+// syslogTarget sends RFC 5424 messages to one syslog receiver
+// Only the notifier's Run goroutine uses it, so the connection needs no locking
+type syslogTarget struct {
+	cfg       config.SyslogConfig
+	templates map[string]*template.Template // Message template per event type
+	tlsConfig *tls.Config                   // nil unless the protocol is tls
+	hostname  string                        // HOSTNAME header field
+	conn      net.Conn                      // Opened on the first message and again after a write error
+}
+
+// newSyslogTarget parses the receiver's templates and TLS settings
+func newSyslogTarget(cfg config.SyslogConfig) (*syslogTarget, error) {
+	s := &syslogTarget{cfg: cfg, templates: make(map[string]*template.Template), hostname: "-"}
+	for event, text := range defaultTemplates {
+		if cfg.Template != "" {
+			text = cfg.Template
+		}
+		tmpl, err := template.New(cfg.Name + "_" + event).Funcs(templateFuncs).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("syslog %s: invalid template: %v", cfg.Name, err)
+		}
+		s.templates[event] = tmpl
+	}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		s.hostname = strings.Map(func(r rune) rune {
+			if r <= ' ' || r > '~' {
+				return -1
+			}
+			return r
+		}, hostname)
+	}
+	if cfg.Protocol == config.SyslogProtocolTLS {
+		host, _, _ := net.SplitHostPort(cfg.Address)
+		s.tlsConfig = &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+		if cfg.TLSCAFile != "" {
+			pem, err := os.ReadFile(cfg.TLSCAFile)
+			if err != nil {
+				return nil, fmt.Errorf("syslog %s: %v", cfg.Name, err)
+			}
+			s.tlsConfig.RootCAs = x509.NewCertPool()
+			if !s.tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("syslog %s: no certificates in %s", cfg.Name, cfg.TLSCAFile)
+			}
+		}
+	}
+	return s, nil
+}
+
+// format builds the RFC 5424 message of an event: header, event parameters as structured data, then the text
+func (s *syslogTarget) format(event Event, message string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %d %s [%s",
+		s.cfg.Priority(event.Type), event.Time.Format("2006-01-02T15:04:05.000000Z07:00"),
+		s.hostname, s.cfg.AppName, os.Getpid(), event.Type, syslogSDID)
+	param := func(name, value string) {
+		fmt.Fprintf(&b, ` %s="%s"`, name, strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value))
+	}
+	param("event", event.Type)
+	param("ip", event.IP)
+	if event.Hostname != "" {
+		param("hostname", event.Hostname)
+	}
+	if event.Previous != "" {
+		param("previous", event.Previous)
+	}
+	if event.Failures > 0 {
+		param("failures", strconv.Itoa(event.Failures))
+	}
+	if event.PreviousDuration > 0 {
+		param("previous_duration_s", strconv.FormatFloat(event.PreviousDuration.Seconds(), 'f', -1, 64))
+	}
+	if !event.SuspendedUntil.IsZero() {
+		param("suspended_until", event.SuspendedUntil.UTC().Format(time.RFC3339))
+	}
+	if event.Check != "" {
+		param("check", event.Check)
+	}
+	if event.Threshold != "" {
+		param("threshold", event.Threshold)
+		param("rtt_ms", strconv.FormatFloat(float64(event.RTT)/float64(time.Millisecond), 'f', -1, 64))
+	}
+	b.WriteString("] ")
+	b.WriteString(message)
+	return []byte(b.String())
+}
+
+// send writes one message, connecting first if needed; a failed write is retried once on a new connection
+func (s *syslogTarget) send(ctx context.Context, msg []byte) error {
+	if s.cfg.Protocol != config.SyslogProtocolUDP {
+		msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...) // Octet-counting framing
+	}
+	if s.conn != nil && s.cfg.Protocol != config.SyslogProtocolUDP && !s.alive() {
+		s.close()
+	}
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if s.conn == nil {
+			if err = s.dial(ctx); err != nil {
+				return err
+			}
+		}
+		s.conn.SetWriteDeadline(time.Now().Add(sendTimeout))
+		if _, err = s.conn.Write(msg); err == nil {
+			return nil
+		}
+		s.close()
+	}
+	return err
+}
+
+// alive reports whether a stream connection is still open: receivers never send data, so a read that does not
+// time out means the receiver closed the connection (writes to it would be lost silently)
+func (s *syslogTarget) alive() bool {
+	s.conn.SetReadDeadline(time.Now().Add(time.Millisecond))
+	_, err := s.conn.Read(make([]byte, 1))
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return true
+	}
+	return err == nil
+}
+
+// dial connects to the receiver
+func (s *syslogTarget) dial(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	var err error
+	switch s.cfg.Protocol {
+	case config.SyslogProtocolTLS:
+		s.conn, err = (&tls.Dialer{Config: s.tlsConfig}).DialContext(ctx, "tcp", s.cfg.Address)
+	case config.SyslogProtocolTCP:
+		s.conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", s.cfg.Address)
+	default:
+		s.conn, err = (&net.Dialer{}).DialContext(ctx, "udp", s.cfg.Address)
+	}
+	if err != nil {
+		s.conn = nil
+		return fmt.Errorf("connect to %s failed: %v", s.cfg.Address, err)
+	}
+	return nil
+}
+
+// close closes the connection, if any
+func (s *syslogTarget) close() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// deliverSyslog sends one event to one syslog receiver, subject to its subscriptions
+func (n *Notifier) deliverSyslog(ctx context.Context, target *syslogTarget, event Event) {
+	if !target.cfg.Wants(event.Type) {
+		return
+	}
+	message, err := renderMessage(target.templates[event.Type], event)
+	if err != nil {
+		log.Error().
+			Str("syslog", target.cfg.Name).
+			Err(err).
+			Msg("Failed to render notification template")
+		return
+	}
+	if err := target.send(ctx, target.format(event, message)); err != nil {
+		log.Error().
+			Str("syslog", target.cfg.Name).
+			Str("ip", event.IP).
+			Str("event", event.Type).
+			Err(err).
+			Msg("Failed to send syslog notification")
+		return
+	}
+	log.Debug().
+		Str("syslog", target.cfg.Name).
+		Str("ip", event.IP).
+		Str("event", event.Type).
+		Msg("Syslog notification sent")
+}
@@ -0,0 +1,121 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+)
+
+// syslogConfig returns a receiver of down and up events on local0
+func syslogConfig(protocol, address string) config.SyslogConfig {
+	return config.SyslogConfig{
+		Name:       "noc",
+		Address:    address,
+		Protocol:   protocol,
+		Facility:   "local0",
+		Severities: map[string]string{"down": "err", "up": "info"},
+		Events:     []string{"down", "up"},
+		AppName:    "netscan",
+	}
+}
+
+// TestSyslogUDP verifies RFC 5424 messages with the configured facility and per-event severity
+func TestSyslogUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	n, err := New(config.NotifyConfig{Syslog: []config.SyslogConfig{syslogConfig("udp", pc.LocalAddr().String())}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go n.Run(ctx)
+
+	n.Notify(Event{Type: "down", IP: "10.0.0.1", Hostname: `sw"1`, Previous: "up", Failures: 3, Time: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)})
+	n.Notify(Event{Type: "suspended", IP: "10.0.0.1"}) // Not subscribed
+	n.Notify(Event{Type: "up", IP: "10.0.0.1", Previous: "down"})
+
+	buf := make([]byte, 4096)
+	pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var messages []string
+	for len(messages) < 2 {
+		size, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("expected 2 syslog messages, got %d: %v", len(messages), err)
+		}
+		messages = append(messages, string(buf[:size]))
+	}
+
+	// local0 (16) * 8 + err (3) = 131
+	if !strings.HasPrefix(messages[0], "<131>1 2024-05-01T12:00:00.000000Z ") {
+		t.Errorf("unexpected header: %q", messages[0])
+	}
+	if !strings.Contains(messages[0], ` netscan `) || !strings.Contains(messages[0], ` down [netscan@32473 event="down" ip="10.0.0.1" hostname="sw\"1" previous="up" failures="3"] `) {
+		t.Errorf("unexpected structured data: %q", messages[0])
+	}
+	if !strings.HasSuffix(messages[0], `sw"1 (10.0.0.1) is DOWN after 3 failed pings`) {
+		t.Errorf("unexpected message text: %q", messages[0])
+	}
+	// local0 (16) * 8 + info (6) = 134
+	if !strings.HasPrefix(messages[1], "<134>1 ") || !strings.Contains(messages[1], " up [") {
+		t.Errorf("unexpected up message: %q", messages[1])
+	}
+}
+
+// TestSyslogTCPFraming verifies octet-counted framing and reconnecting after the receiver closed the connection
+func TestSyslogTCPFraming(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	received := make(chan string, 4)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			r := bufio.NewReader(conn)
+			length, err := r.ReadString(' ')
+			if err == nil {
+				size, _ := strconv.Atoi(strings.TrimSpace(length))
+				msg := make([]byte, size)
+				if _, err := io.ReadFull(r, msg); err == nil {
+					received <- string(msg)
+				}
+			}
+			conn.Close() // One message per connection forces a reconnect
+		}
+	}()
+
+	n, err := New(config.NotifyConfig{Syslog: []config.SyslogConfig{syslogConfig("tcp", ln.Addr().String())}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := n.syslogs[0]
+	defer target.close()
+	for i := 0; i < 2; i++ {
+		n.deliverSyslog(context.Background(), target, Event{Type: "down", IP: "10.0.0.2", Failures: 2, Time: time.Now()})
+		select {
+		case msg := <-received:
+			if !strings.HasPrefix(msg, "<131>1 ") || !strings.Contains(msg, `ip="10.0.0.2"`) {
+				t.Errorf("unexpected message: %q", msg)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("message %d not received", i+1)
+		}
+		time.Sleep(50 * time.Millisecond) // Let the receiver close the connection
+	}
+}
@@ -0,0 +1,97 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// kafkaRESTTransport produces records to a Kafka topic through a Kafka REST Proxy (v2 API), keyed by device IP
+// so each device's records stay in one partition and in order
+type kafkaRESTTransport struct {
+	endpoint string // POST target: <proxy>/topics/<topic>
+	user     *url.Userinfo
+	client   *http.Client
+}
+
+// kafkaRecord is one record of a REST Proxy produce request
+type kafkaRecord struct {
+	Key   string          `json:"key,omitempty"`
+	Value json.RawMessage `json:"value"`
+}
+
+// newKafkaRESTTransport creates a transport for the proxy at u; credentials in u are sent as basic auth
+func newKafkaRESTTransport(u *url.URL, topic string) *kafkaRESTTransport {
+	proxy := *u
+	proxy.User = nil
+	return &kafkaRESTTransport{
+		endpoint: strings.TrimSuffix(proxy.String(), "/") + "/topics/" + url.PathEscape(topic),
+		user:     u.User,
+		client:   &http.Client{Timeout: publishTimeout},
+	}
+}
+
+// send produces a batch with one request and checks every record's result
+func (t *kafkaRESTTransport) send(ctx context.Context, batch []message) error {
+	records := make([]kafkaRecord, len(batch))
+	for i, m := range batch {
+		records[i] = kafkaRecord{Key: m.IP, Value: m.Data}
+	}
+	body, err := json.Marshal(map[string][]kafkaRecord{"records": records})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+	if t.user != nil {
+		pass, _ := t.user.Password()
+		req.SetBasicAuth(t.user.Username(), pass)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("kafka rest proxy request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("kafka rest proxy returned %s: %s", resp.Status, strings.TrimSpace(string(data[:min(len(data), 512)])))
+	}
+	var result struct {
+		Offsets []struct {
+			Error string `json:"error"`
+		} `json:"offsets"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("kafka rest proxy returned an invalid response: %v", err)
+	}
+	failed := 0
+	var first string
+	for _, offset := range result.Offsets {
+		if offset.Error != "" {
+			if failed == 0 {
+				first = offset.Error
+			}
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("kafka rest proxy rejected %d of %d records: %s", failed, len(batch), first)
+	}
+	return nil
+}
+
+// close releases idle proxy connections
+func (t *kafkaRESTTransport) close() error {
+	t.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,204 @@
+package output
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// natsTransport publishes with the NATS core protocol: every record goes to <subject>.<type>
+// The connection is opened on the first batch and after errors; a reader goroutine answers server PINGs
+type natsTransport struct {
+	addr    string
+	subject string
+	connect natsConnect
+
+	mu   sync.Mutex // Guards conn and w; held while writing
+	conn net.Conn
+	w    *bufio.Writer
+}
+
+// natsConnect is the CONNECT message sent after the server's INFO
+type natsConnect struct {
+	Verbose   bool   `json:"verbose"`
+	Pedantic  bool   `json:"pedantic"`
+	Name      string `json:"name"`
+	Lang      string `json:"lang"`
+	Version   string `json:"version"`
+	Protocol  int    `json:"protocol"`
+	User      string `json:"user,omitempty"`
+	Pass      string `json:"pass,omitempty"`
+	AuthToken string `json:"auth_token,omitempty"`
+}
+
+// newNATSTransport creates a transport for nats://[user:pass@|token@]host[:port]
+func newNATSTransport(u *url.URL, subject string) *natsTransport {
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	connect := natsConnect{Name: "netscan", Lang: "go", Version: "1.0.0", Protocol: 1}
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			connect.User, connect.Pass = u.User.Username(), pass
+		} else {
+			connect.AuthToken = u.User.Username()
+		}
+	}
+	return &natsTransport{addr: addr, subject: subject, connect: connect}
+}
+
+// send publishes a batch, connecting first if needed
+// The batch is written under ctx's deadline, which is cleared afterwards so it cannot fail later PONGs
+func (t *natsTransport) send(ctx context.Context, batch []message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.conn == nil {
+		if err := t.dial(ctx); err != nil {
+			return err
+		}
+	}
+	deadline, _ := ctx.Deadline() // Zero without a deadline
+	t.conn.SetWriteDeadline(deadline)
+	if err := t.publish(batch); err != nil {
+		t.reset()
+		return fmt.Errorf("nats publish failed: %v", err)
+	}
+	t.conn.SetWriteDeadline(time.Time{})
+	return nil
+}
+
+// publish writes a PUB per message and flushes them; the caller holds mu
+func (t *natsTransport) publish(batch []message) error {
+	for _, m := range batch {
+		if _, err := fmt.Fprintf(t.w, "PUB %s.%s %d\r\n", t.subject, m.Type, len(m.Data)); err != nil {
+			return err
+		}
+		if _, err := t.w.Write(m.Data); err != nil {
+			return err
+		}
+		if _, err := t.w.WriteString("\r\n"); err != nil {
+			return err
+		}
+	}
+	return t.w.Flush()
+}
+
+// pong answers a server PING within publishTimeout; the caller holds mu
+func (t *natsTransport) pong() error {
+	t.conn.SetWriteDeadline(time.Now().Add(publishTimeout))
+	defer t.conn.SetWriteDeadline(time.Time{})
+	if _, err := t.w.WriteString("PONG\r\n"); err != nil {
+		return err
+	}
+	return t.w.Flush()
+}
+
+// dial connects, reads INFO, sends CONNECT and waits for the PONG confirming it; the caller holds mu
+func (t *natsTransport) dial(ctx context.Context) error {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", t.addr)
+	if err != nil {
+		return fmt.Errorf("nats connect failed: %v", err)
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(publishTimeout)
+	}
+	conn.SetDeadline(deadline)
+	r := bufio.NewReader(conn)
+
+	line, err := r.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("nats connect failed: expected INFO from %s, got %q (%v)", t.addr, strings.TrimSpace(line), err)
+	}
+	var info struct {
+		TLSRequired bool `json:"tls_required"`
+	}
+	if json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info) == nil && info.TLSRequired {
+		conn.Close()
+		return fmt.Errorf("nats server %s requires TLS, which is not supported", t.addr)
+	}
+
+	connect, _ := json.Marshal(t.connect)
+	w := bufio.NewWriter(conn)
+	fmt.Fprintf(w, "CONNECT %s\r\nPING\r\n", connect)
+	if err := w.Flush(); err != nil {
+		conn.Close()
+		return fmt.Errorf("nats connect failed: %v", err)
+	}
+	for {
+		line, err = r.ReadString('\n')
+		if err != nil {
+			conn.Close()
+			return fmt.Errorf("nats connect failed: %v", err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "PONG" {
+			break
+		}
+		if strings.HasPrefix(line, "-ERR") {
+			conn.Close()
+			return fmt.Errorf("nats connect rejected: %s", line)
+		}
+	}
+	conn.SetDeadline(time.Time{})
+
+	t.conn, t.w = conn, w
+	go t.read(conn, r)
+	return nil
+}
+
+// read answers server PINGs and logs protocol errors until the connection fails
+func (t *natsTransport) read(conn net.Conn, r *bufio.Reader) {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.mu.Lock()
+			if t.conn == conn {
+				t.reset()
+			}
+			t.mu.Unlock()
+			return
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PING":
+			t.mu.Lock()
+			if t.conn == conn {
+				if err := t.pong(); err != nil {
+					// Unanswered PINGs make the server drop us; reconnect on the next batch instead
+					log.Warn().Str("server", t.addr).Err(err).Msg("NATS PONG failed, reconnecting")
+					t.reset()
+				}
+			}
+			t.mu.Unlock()
+		case strings.HasPrefix(line, "-ERR"):
+			log.Warn().Str("server", t.addr).Str("error", line).Msg("NATS server reported an error")
+		}
+	}
+}
+
+// reset closes the connection so the next batch reconnects; the caller holds mu
+func (t *natsTransport) reset() {
+	if t.conn != nil {
+		t.conn.Close()
+		t.conn, t.w = nil, nil
+	}
+}
+
+// close closes the connection
+func (t *natsTransport) close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.reset()
+	return nil
+}
@@ -0,0 +1,198 @@
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+	"github.com/rs/zerolog/log"
+)
+
+// publishBatch is the most records sent per delivery
+const publishBatch = 500
+
+// publishTimeout bounds one delivery (NATS connect and write, or one REST Proxy request)
+const publishTimeout = 10 * time.Second
+
+// publishShutdownTimeout bounds delivering the records still queued at shutdown
+const publishShutdownTimeout = 5 * time.Second
+
+// message is one encoded record
+type message struct {
+	Type string
+	IP   string
+	Data []byte // JSON object without the trailing newline
+}
+
+// transport delivers batches of messages to a broker
+type transport interface {
+	send(ctx context.Context, batch []message) error
+	close() error
+}
+
+// PublishStats counts the records of a publisher
+type PublishStats struct {
+	Published uint64 // Records accepted by the broker
+	Dropped   uint64 // Records dropped because the queue was full
+	Failed    uint64 // Records of deliveries that failed
+}
+
+// Publisher is a Sink publishing every result as a JSON message, in the record shapes of the NDJSON stream
+// Records are queued without blocking and delivered in batches by Run; when the queue is full they are dropped
+type Publisher struct {
+	*StreamWriter
+	name      string // Transport name for logs
+	transport transport
+	types     map[string]bool // Record types to publish
+	queue     chan message
+
+	published atomic.Uint64
+	dropped   atomic.Uint64
+	failed    atomic.Uint64
+}
+
+// NewPublisher creates a publisher for the publish block; returns nil when publishing is disabled
+func NewPublisher(cfg config.PublishConfig) (*Publisher, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid publish.url: %v", err)
+	}
+	var t transport
+	switch cfg.Type {
+	case config.PublishTypeNATS:
+		t = newNATSTransport(u, cfg.Subject)
+	case config.PublishTypeKafka:
+		t = newKafkaRESTTransport(u, cfg.Topic)
+	default:
+		return nil, fmt.Errorf("unsupported publish.type %q", cfg.Type)
+	}
+	return newPublisher(cfg.Type, t, cfg.Events, cfg.QueueSize), nil
+}
+
+// newPublisher creates a publisher delivering the given record types through t
+func newPublisher(name string, t transport, types []string, queueSize int) *Publisher {
+	p := &Publisher{
+		name:      name,
+		transport: t,
+		types:     make(map[string]bool, len(types)),
+		queue:     make(chan message, queueSize),
+	}
+	for _, recordType := range types {
+		p.types[recordType] = true
+	}
+	p.StreamWriter = NewStreamWriter(publishQueue{p})
+	return p
+}
+
+// publishQueue receives the records encoded by the embedded StreamWriter, one Write per record
+type publishQueue struct {
+	p *Publisher
+}
+
+// Write queues one encoded record if its type is published
+func (q publishQueue) Write(line []byte) (int, error) {
+	var head struct {
+		Type string `json:"type"`
+		IP   string `json:"ip"`
+	}
+	if err := json.Unmarshal(line, &head); err != nil {
+		return 0, err
+	}
+	if !q.p.types[head.Type] {
+		return len(line), nil
+	}
+	data := make([]byte, len(line))
+	copy(data, line)
+	if n := len(data); n > 0 && data[n-1] == '\n' {
+		data = data[:n-1]
+	}
+	select {
+	case q.p.queue <- message{Type: head.Type, IP: head.IP, Data: data}:
+	default:
+		q.p.dropped.Add(1)
+	}
+	return len(line), nil
+}
+
+// Stats returns the publisher's record counters
+func (p *Publisher) Stats() PublishStats {
+	return PublishStats{Published: p.published.Load(), Dropped: p.dropped.Load(), Failed: p.failed.Load()}
+}
+
+// Run delivers queued records until ctx is cancelled, then delivers what is still queued for at most publishShutdownTimeout
+func (p *Publisher) Run(ctx context.Context) {
+	var lastDropped uint64
+	for {
+		// select picks randomly among ready cases, so a cancelled ctx must win over a non-empty queue
+		if ctx.Err() != nil {
+			p.drain()
+			return
+		}
+		select {
+		case <-ctx.Done():
+			p.drain()
+			return
+		case first := <-p.queue:
+			// A batch taken off the queue is delivered even if ctx is cancelled meanwhile, as during the drain
+			p.deliver(context.WithoutCancel(ctx), p.collect(first))
+			if dropped := p.dropped.Load(); dropped > lastDropped {
+				log.Warn().
+					Str("publisher", p.name).
+					Uint64("dropped", dropped-lastDropped).
+					Msg("Publish queue full, records dropped")
+				lastDropped = dropped
+			}
+		}
+	}
+}
+
+// drain delivers the records still queued for at most publishShutdownTimeout
+func (p *Publisher) drain() {
+	ctx, cancel := context.WithTimeout(context.Background(), publishShutdownTimeout)
+	defer cancel()
+	for len(p.queue) > 0 && ctx.Err() == nil {
+		p.deliver(ctx, p.collect(<-p.queue))
+	}
+}
+
+// collect returns first followed by the records already queued, up to publishBatch
+func (p *Publisher) collect(first message) []message {
+	batch := []message{first}
+	for len(batch) < publishBatch {
+		select {
+		case m := <-p.queue:
+			batch = append(batch, m)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+// deliver sends one batch; a failed batch is counted and dropped (the transport reconnects on the next one)
+func (p *Publisher) deliver(ctx context.Context, batch []message) {
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+	if err := p.transport.send(ctx, batch); err != nil {
+		p.failed.Add(uint64(len(batch)))
+		log.Warn().
+			Str("publisher", p.name).
+			Int("records", len(batch)).
+			Err(err).
+			Msg("Failed to publish records")
+		return
+	}
+	p.published.Add(uint64(len(batch)))
+}
+
+// Close closes the broker connection; call after Run returned
+func (p *Publisher) Close() error {
+	return p.transport.close()
+}
@@ -0,0 +1,217 @@
+package output
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestPublisherNATS verifies records are published to <subject>.<type> after the CONNECT handshake
+func TestPublisherNATS(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	type published struct{ subject, payload string }
+	received := make(chan published, 10)
+	connected := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		conn.Write([]byte("INFO {\"server_id\":\"test\",\"max_payload\":1048576}\r\n"))
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			fields := strings.Fields(line)
+			switch {
+			case strings.HasPrefix(line, "CONNECT "):
+				connected <- strings.TrimSpace(strings.TrimPrefix(line, "CONNECT "))
+			case fields[0] == "PING":
+				conn.Write([]byte("PONG\r\n"))
+			case fields[0] == "PUB":
+				size, _ := strconv.Atoi(fields[2])
+				payload := make([]byte, size+2)
+				io.ReadFull(r, payload)
+				received <- published{fields[1], string(payload[:size])}
+			}
+		}
+	}()
+
+	u, _ := url.Parse("nats://scanner:secret@" + ln.Addr().String())
+	p := newPublisher("nats", newNATSTransport(u, "netscan"), []string{"ping", "device_state"}, 10)
+	defer p.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.Run(ctx)
+
+	p.WritePingResult("192.168.1.10", 2*time.Millisecond, true, false)
+	p.WriteDeviceInfo("192.168.1.10", "core-sw1", "Cisco IOS") // Not a published type
+	p.WriteStateChange("192.168.1.10", "core-sw1", "down", "up", 3, time.Minute)
+
+	select {
+	case connect := <-connected:
+		var c natsConnect
+		if err := json.Unmarshal([]byte(connect), &c); err != nil || c.User != "scanner" || c.Pass != "secret" || c.Verbose {
+			t.Errorf("expected CONNECT with credentials, got %s", connect)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("publisher did not connect")
+	}
+	want := []string{"netscan.ping", "netscan.device_state"}
+	for _, subject := range want {
+		select {
+		case msg := <-received:
+			if msg.subject != subject {
+				t.Errorf("expected subject %s, got %s", subject, msg.subject)
+			}
+			var record map[string]interface{}
+			if err := json.Unmarshal([]byte(msg.payload), &record); err != nil || record["ip"] != "192.168.1.10" {
+				t.Errorf("expected a JSON record of the device, got %q", msg.payload)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no message published to %s", subject)
+		}
+	}
+}
+
+// TestNATSPongAfterBatchDeadline verifies a batch's write deadline is cleared, so server PINGs arriving after
+// it has passed are still answered
+func TestNATSPongAfterBatchDeadline(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	published := make(chan net.Conn, 1)
+	pong := make(chan struct{}, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			fields := strings.Fields(line)
+			switch {
+			case len(fields) == 0:
+			case fields[0] == "PING":
+				conn.Write([]byte("PONG\r\n"))
+			case fields[0] == "PONG":
+				pong <- struct{}{}
+			case fields[0] == "PUB":
+				size, _ := strconv.Atoi(fields[2])
+				io.ReadFull(r, make([]byte, size+2))
+				published <- conn
+			}
+		}
+	}()
+
+	u, _ := url.Parse("nats://" + ln.Addr().String())
+	transport := newNATSTransport(u, "netscan")
+	defer transport.close()
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := transport.send(ctx, []message{{Type: "ping", Data: []byte(`{"ip":"192.168.1.10"}`)}}); err != nil {
+		t.Fatalf("failed to publish: %v", err)
+	}
+
+	var conn net.Conn
+	select {
+	case conn = <-published:
+	case <-time.After(5 * time.Second):
+		t.Fatal("batch was not published")
+	}
+	<-ctx.Done()
+	conn.Write([]byte("PING\r\n"))
+	select {
+	case <-pong:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server PING after the batch deadline was not answered")
+	}
+}
+
+// TestPublisherKafkaREST verifies records are produced to the topic keyed by device IP
+func TestPublisherKafkaREST(t *testing.T) {
+	// Buffered so an unexpected extra request cannot block the handler and hang server.Close
+	requests := make(chan *http.Request, 4)
+	bodies := make(chan []byte, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- r
+		bodies <- body
+		w.Write([]byte(`{"offsets":[{"partition":0,"offset":1},{"partition":0,"offset":2}]}`))
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	p := newPublisher("kafka", newKafkaRESTTransport(u, "netscan-events"), []string{"discovered", "ping"}, 10)
+	p.WriteDiscovered("192.168.1.20")
+	p.WritePingResult("192.168.1.20", time.Millisecond, true, false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // Run delivers the queued records before returning
+	p.Run(ctx)
+
+	var r *http.Request
+	select {
+	case r = <-requests:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("queued records were not delivered at shutdown: %+v", p.Stats())
+	}
+	if r.URL.Path != "/topics/netscan-events" || r.Header.Get("Content-Type") != "application/vnd.kafka.json.v2+json" {
+		t.Errorf("unexpected produce request %s %s", r.URL.Path, r.Header.Get("Content-Type"))
+	}
+	var produce struct {
+		Records []struct {
+			Key   string                 `json:"key"`
+			Value map[string]interface{} `json:"value"`
+		} `json:"records"`
+	}
+	if err := json.Unmarshal(<-bodies, &produce); err != nil {
+		t.Fatal(err)
+	}
+	if len(produce.Records) != 2 || produce.Records[0].Key != "192.168.1.20" || produce.Records[0].Value["type"] != "discovered" || produce.Records[1].Value["type"] != "ping" {
+		t.Errorf("expected two records keyed by IP, got %+v", produce.Records)
+	}
+	if stats := p.Stats(); stats != (PublishStats{Published: 2}) {
+		t.Errorf("expected 2 published records, got %+v", stats)
+	}
+}
+
+// TestPublisherDropsWhenFull verifies writes never block and overflow is counted
+func TestPublisherDropsWhenFull(t *testing.T) {
+	u, _ := url.Parse("http://127.0.0.1:1")
+	p := newPublisher("kafka", newKafkaRESTTransport(u, "netscan"), []string{"ping"}, 2)
+	for i := 0; i < 5; i++ {
+		if err := p.WritePingResult("192.168.1.10", time.Millisecond, true, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stats := p.Stats(); stats.Dropped != 3 {
+		t.Errorf("expected 3 dropped records, got %+v", stats)
+	}
+}
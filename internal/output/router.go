@@ -0,0 +1,108 @@
+package output
+
+import (
+	"time"
+
+	"github.com/kljama/netscan/internal/state"
+)
+
+// Router sends each result to the sink of the group (e.g. site) its device belongs to
+// Devices whose group has no sink go to the fallback sink; a nil fallback discards them
+type Router struct {
+	route    func(ip string) string
+	sinks    map[string]Sink
+	fallback Sink
+}
+
+// NewRouter creates a router; route maps a device IP to a key of sinks ("" for none)
+func NewRouter(route func(ip string) string, sinks map[string]Sink, fallback Sink) *Router {
+	return &Router{route: route, sinks: sinks, fallback: fallback}
+}
+
+// sink returns the sink for ip (nil when the result is discarded)
+func (r *Router) sink(ip string) Sink {
+	if s, ok := r.sinks[r.route(ip)]; ok {
+		return s
+	}
+	return r.fallback
+}
+
+// WritePingResult routes a ping result
+func (r *Router) WritePingResult(ip string, rtt time.Duration, successful bool, suspended bool) error {
+	if s := r.sink(ip); s != nil {
+		return s.WritePingResult(ip, rtt, successful, suspended)
+	}
+	return nil
+}
+
+// WritePingStats routes multi-packet ping statistics
+func (r *Router) WritePingStats(ip string, sent, recv int, minRtt, avgRtt, maxRtt, jitter time.Duration) error {
+	if s := r.sink(ip); s != nil {
+		return s.WritePingStats(ip, sent, recv, minRtt, avgRtt, maxRtt, jitter)
+	}
+	return nil
+}
+
+// WriteDeviceInfo routes device info
+func (r *Router) WriteDeviceInfo(ip, hostname, sysDescr string) error {
+	if s := r.sink(ip); s != nil {
+		return s.WriteDeviceInfo(ip, hostname, sysDescr)
+	}
+	return nil
+}
+
+// WriteDeviceInfoFields routes device info with derived custom fields
+func (r *Router) WriteDeviceInfoFields(ip, hostname, sysDescr string, system state.SystemInfo, fields map[string]string) error {
+	if s := r.sink(ip); s != nil {
+		return s.WriteDeviceInfoFields(ip, hostname, sysDescr, system, fields)
+	}
+	return nil
+}
+
+// WriteInterfaceMetrics routes an interface row
+func (r *Router) WriteInterfaceMetrics(ip string, ifIndex int, ifDescr string, operStatus int, inOctets, outOctets, speed uint64) error {
+	if s := r.sink(ip); s != nil {
+		return s.WriteInterfaceMetrics(ip, ifIndex, ifDescr, operStatus, inOctets, outOctets, speed)
+	}
+	return nil
+}
+
+// WriteCustomMetrics routes custom OID group values
+func (r *Router) WriteCustomMetrics(ip, measurement, group string, fields map[string]interface{}) error {
+	if s := r.sink(ip); s != nil {
+		return s.WriteCustomMetrics(ip, measurement, group, fields)
+	}
+	return nil
+}
+
+// WriteStateChange routes a device up/down transition
+func (r *Router) WriteStateChange(ip, hostname, newState, previous string, failures int, previousDuration time.Duration) error {
+	if s := r.sink(ip); s != nil {
+		return s.WriteStateChange(ip, hostname, newState, previous, failures, previousDuration)
+	}
+	return nil
+}
+
+// WriteCompositeCheck routes a composite check result
+func (r *Router) WriteCompositeCheck(ip, check string, healthy bool, passed, total int, failed []string) error {
+	if s := r.sink(ip); s != nil {
+		return s.WriteCompositeCheck(ip, check, healthy, passed, total, failed)
+	}
+	return nil
+}
+
+// WriteLatencyAlert routes a latency threshold level change
+func (r *Router) WriteLatencyAlert(ip, hostname, threshold, level, previous string, rtt, limit time.Duration, percentile float64) error {
+	if s := r.sink(ip); s != nil {
+		return s.WriteLatencyAlert(ip, hostname, threshold, level, previous, rtt, limit, percentile)
+	}
+	return nil
+}
+
+// WriteTracerouteHop routes one traceroute hop
+func (r *Router) WriteTracerouteHop(ip, hostname, trigger, protocol string, at time.Time, ttl int, hopIP string, rtt time.Duration, reached bool) error {
+	if s := r.sink(ip); s != nil {
+		return s.WriteTracerouteHop(ip, hostname, trigger, protocol, at, ttl, hopIP, rtt, reached)
+	}
+	return nil
+}
@@ -0,0 +1,457 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kljama/netscan/internal/state"
+)
+
+// Sink receives probe results from pingers and SNMP pollers
+// Implemented by influx.Writer and StreamWriter; satisfies monitoring.PingWriter and monitoring.SNMPWriter
+type Sink interface {
+	WritePingResult(ip string, rtt time.Duration, successful bool, suspended bool) error
+	WritePingStats(ip string, sent, recv int, minRtt, avgRtt, maxRtt, jitter time.Duration) error
+	WriteDeviceInfo(ip, hostname, sysDescr string) error
+	WriteDeviceInfoFields(ip, hostname, sysDescr string, system state.SystemInfo, fields map[string]string) error
+	WriteInterfaceMetrics(ip string, ifIndex int, ifDescr string, operStatus int, inOctets, outOctets, speed uint64) error
+	WriteCustomMetrics(ip, measurement, group string, fields map[string]interface{}) error
+	WriteStateChange(ip, hostname, newState, previous string, failures int, previousDuration time.Duration) error
+	WriteCompositeCheck(ip, check string, healthy bool, passed, total int, failed []string) error
+	WriteLatencyAlert(ip, hostname, threshold, level, previous string, rtt, limit time.Duration, percentile float64) error
+	WriteTracerouteHop(ip, hostname, trigger, protocol string, at time.Time, ttl int, hopIP string, rtt time.Duration, reached bool) error
+}
+
+// Multi fans each result out to several sinks; every sink is called and the first error is returned
+type Multi []Sink
+
+// WritePingResult forwards a ping result to every sink
+func (m Multi) WritePingResult(ip string, rtt time.Duration, successful bool, suspended bool) error {
+	var firstErr error
+	for _, s := range m {
+		if err := s.WritePingResult(ip, rtt, successful, suspended); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// WritePingStats forwards multi-packet ping statistics to every sink
+func (m Multi) WritePingStats(ip string, sent, recv int, minRtt, avgRtt, maxRtt, jitter time.Duration) error {
+	var firstErr error
+	for _, s := range m {
+		if err := s.WritePingStats(ip, sent, recv, minRtt, avgRtt, maxRtt, jitter); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// WriteDeviceInfo forwards device info to every sink
+func (m Multi) WriteDeviceInfo(ip, hostname, sysDescr string) error {
+	var firstErr error
+	for _, s := range m {
+		if err := s.WriteDeviceInfo(ip, hostname, sysDescr); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// WriteDeviceInfoFields forwards device info with derived custom fields to every sink
+func (m Multi) WriteDeviceInfoFields(ip, hostname, sysDescr string, system state.SystemInfo, fields map[string]string) error {
+	var firstErr error
+	for _, s := range m {
+		if err := s.WriteDeviceInfoFields(ip, hostname, sysDescr, system, fields); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// WriteInterfaceMetrics forwards an interface row to every sink
+func (m Multi) WriteInterfaceMetrics(ip string, ifIndex int, ifDescr string, operStatus int, inOctets, outOctets, speed uint64) error {
+	var firstErr error
+	for _, s := range m {
+		if err := s.WriteInterfaceMetrics(ip, ifIndex, ifDescr, operStatus, inOctets, outOctets, speed); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// WriteCustomMetrics forwards custom OID group values to every sink
+func (m Multi) WriteCustomMetrics(ip, measurement, group string, fields map[string]interface{}) error {
+	var firstErr error
+	for _, s := range m {
+		if err := s.WriteCustomMetrics(ip, measurement, group, fields); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// WriteStateChange forwards a device up/down transition to every sink
+func (m Multi) WriteStateChange(ip, hostname, newState, previous string, failures int, previousDuration time.Duration) error {
+	var firstErr error
+	for _, s := range m {
+		if err := s.WriteStateChange(ip, hostname, newState, previous, failures, previousDuration); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// WriteCompositeCheck forwards a composite check result to every sink
+func (m Multi) WriteCompositeCheck(ip, check string, healthy bool, passed, total int, failed []string) error {
+	var firstErr error
+	for _, s := range m {
+		if err := s.WriteCompositeCheck(ip, check, healthy, passed, total, failed); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// WriteLatencyAlert forwards a latency threshold level change to every sink
+func (m Multi) WriteLatencyAlert(ip, hostname, threshold, level, previous string, rtt, limit time.Duration, percentile float64) error {
+	var firstErr error
+	for _, s := range m {
+		if err := s.WriteLatencyAlert(ip, hostname, threshold, level, previous, rtt, limit, percentile); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// WriteTracerouteHop forwards one traceroute hop to every sink
+func (m Multi) WriteTracerouteHop(ip, hostname, trigger, protocol string, at time.Time, ttl int, hopIP string, rtt time.Duration, reached bool) error {
+	var firstErr error
+	for _, s := range m {
+		if err := s.WriteTracerouteHop(ip, hostname, trigger, protocol, at, ttl, hopIP, rtt, reached); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// StreamWriter writes probe results as line-delimited JSON (one object per line) for shell pipelines
+// Every record carries "time" (RFC3339, UTC), "type" and "ip"; remaining keys depend on the type
+type StreamWriter struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	closer io.Closer // nil when writing to stdout
+	now    func() time.Time
+}
+
+// NewStreamWriter creates a stream writer on w
+func NewStreamWriter(w io.Writer) *StreamWriter {
+	return &StreamWriter{enc: json.NewEncoder(w), now: time.Now}
+}
+
+// OpenStream opens an NDJSON destination: "-" is stdout, anything else is a file opened for appending
+func OpenStream(dest string) (*StreamWriter, error) {
+	if dest == "-" {
+		return NewStreamWriter(os.Stdout), nil
+	}
+	f, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output %s: %v", dest, err)
+	}
+	s := NewStreamWriter(f)
+	s.closer = f
+	return s, nil
+}
+
+// Close closes the underlying file (no-op for stdout)
+func (s *StreamWriter) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
+
+// pingRecord is the NDJSON shape for type "ping"
+type pingRecord struct {
+	Time      string  `json:"time"`
+	Type      string  `json:"type"`
+	IP        string  `json:"ip"`
+	RTTMs     float64 `json:"rtt_ms"`
+	Success   bool    `json:"success"`
+	Suspended bool    `json:"suspended"`
+}
+
+// pingStatsRecord is the NDJSON shape for type "ping" written by multi-packet cycles
+type pingStatsRecord struct {
+	pingRecord
+	PacketsSent   int     `json:"packets_sent"`
+	PacketsRecv   int     `json:"packets_recv"`
+	PacketLossPct float64 `json:"packet_loss_pct"`
+	RTTMinMs      float64 `json:"rtt_min_ms"`
+	RTTMaxMs      float64 `json:"rtt_max_ms"`
+	JitterMs      float64 `json:"jitter_ms"`
+}
+
+// deviceInfoRecord is the NDJSON shape for type "device_info"
+type deviceInfoRecord struct {
+	Time            string            `json:"time"`
+	Type            string            `json:"type"`
+	IP              string            `json:"ip"`
+	Hostname        string            `json:"hostname"`
+	SNMPDescription string            `json:"snmp_description"`
+	SysObjectID     string            `json:"sys_object_id,omitempty"`
+	SysUpTimeS      int64             `json:"sys_uptime_s,omitempty"`
+	SysLocation     string            `json:"sys_location,omitempty"`
+	SysContact      string            `json:"sys_contact,omitempty"`
+	Fields          map[string]string `json:"fields,omitempty"` // Derived by snmp.device_fields rules
+}
+
+// interfaceRecord is the NDJSON shape for type "snmp_interface"
+type interfaceRecord struct {
+	Time       string `json:"time"`
+	Type       string `json:"type"`
+	IP         string `json:"ip"`
+	IfIndex    int    `json:"if_index"`
+	IfName     string `json:"if_name"`
+	OperStatus int    `json:"oper_status"`
+	InOctets   uint64 `json:"in_octets"`
+	OutOctets  uint64 `json:"out_octets"`
+	Speed      uint64 `json:"speed"`
+}
+
+// customRecord is the NDJSON shape for type "custom" (snmp.oid_groups)
+type customRecord struct {
+	Time        string                 `json:"time"`
+	Type        string                 `json:"type"`
+	IP          string                 `json:"ip"`
+	Measurement string                 `json:"measurement"`
+	OIDGroup    string                 `json:"oid_group"`
+	Fields      map[string]interface{} `json:"fields"`
+}
+
+// stateRecord is the NDJSON shape for type "device_state"
+type stateRecord struct {
+	Time              string  `json:"time"`
+	Type              string  `json:"type"`
+	IP                string  `json:"ip"`
+	Hostname          string  `json:"hostname"`
+	State             string  `json:"state"`
+	Previous          string  `json:"previous"`
+	Failures          int     `json:"failures"`
+	PreviousDurationS float64 `json:"previous_duration_s"`
+}
+
+// checkRecord is the NDJSON shape for type "composite_check"
+type checkRecord struct {
+	Time    string   `json:"time"`
+	Type    string   `json:"type"`
+	IP      string   `json:"ip"`
+	Check   string   `json:"check"`
+	Healthy bool     `json:"healthy"`
+	Passed  int      `json:"passed"`
+	Total   int      `json:"total"`
+	Failed  []string `json:"failed,omitempty"`
+}
+
+// latencyRecord is the NDJSON shape for type "latency_alert"
+type latencyRecord struct {
+	Time       string  `json:"time"`
+	Type       string  `json:"type"`
+	IP         string  `json:"ip"`
+	Hostname   string  `json:"hostname"`
+	Threshold  string  `json:"threshold"`
+	Level      string  `json:"level"`
+	Previous   string  `json:"previous"`
+	RTTMs      float64 `json:"rtt_ms"`
+	LimitMs    float64 `json:"limit_ms,omitempty"`
+	Percentile float64 `json:"percentile,omitempty"`
+}
+
+// tracerouteRecord is the NDJSON shape for type "traceroute" (one record per hop)
+type tracerouteRecord struct {
+	Time     string  `json:"time"` // Start of the traceroute, shared by all its hops
+	Type     string  `json:"type"`
+	IP       string  `json:"ip"`
+	Hostname string  `json:"hostname"`
+	Trigger  string  `json:"trigger"`
+	Protocol string  `json:"protocol"`
+	Hop      int     `json:"hop"`
+	HopIP    string  `json:"hop_ip,omitempty"` // Omitted when the hop did not answer
+	RTTMs    float64 `json:"rtt_ms,omitempty"`
+	Reached  bool    `json:"reached"`
+}
+
+// discoveredRecord is the NDJSON shape for type "discovered"
+type discoveredRecord struct {
+	Time string `json:"time"`
+	Type string `json:"type"`
+	IP   string `json:"ip"`
+}
+
+// timestamp returns the current time formatted for NDJSON records
+func (s *StreamWriter) timestamp() string {
+	return s.now().UTC().Format(time.RFC3339Nano)
+}
+
+// emit encodes a single record as one line
+func (s *StreamWriter) emit(record interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(record)
+}
+
+// WritePingResult streams a ping result
+func (s *StreamWriter) WritePingResult(ip string, rtt time.Duration, successful bool, suspended bool) error {
+	return s.emit(pingRecord{
+		Time:      s.timestamp(),
+		Type:      "ping",
+		IP:        ip,
+		RTTMs:     float64(rtt.Nanoseconds()) / 1e6,
+		Success:   successful,
+		Suspended: suspended,
+	})
+}
+
+// WritePingStats streams a ping result with packet loss, min/avg/max RTT and jitter
+func (s *StreamWriter) WritePingStats(ip string, sent, recv int, minRtt, avgRtt, maxRtt, jitter time.Duration) error {
+	lossPct := 0.0
+	if sent > 0 {
+		lossPct = float64(sent-recv) / float64(sent) * 100
+	}
+	return s.emit(pingStatsRecord{
+		pingRecord: pingRecord{
+			Time:    s.timestamp(),
+			Type:    "ping",
+			IP:      ip,
+			RTTMs:   float64(avgRtt.Nanoseconds()) / 1e6,
+			Success: recv > 0,
+		},
+		PacketsSent:   sent,
+		PacketsRecv:   recv,
+		PacketLossPct: lossPct,
+		RTTMinMs:      float64(minRtt.Nanoseconds()) / 1e6,
+		RTTMaxMs:      float64(maxRtt.Nanoseconds()) / 1e6,
+		JitterMs:      float64(jitter.Nanoseconds()) / 1e6,
+	})
+}
+
+// WriteDeviceInfo streams SNMP device info
+func (s *StreamWriter) WriteDeviceInfo(ip, hostname, sysDescr string) error {
+	return s.WriteDeviceInfoFields(ip, hostname, sysDescr, state.SystemInfo{}, nil)
+}
+
+// WriteDeviceInfoFields streams SNMP device info with the system group values and derived custom fields
+func (s *StreamWriter) WriteDeviceInfoFields(ip, hostname, sysDescr string, system state.SystemInfo, fields map[string]string) error {
+	return s.emit(deviceInfoRecord{
+		Time:            s.timestamp(),
+		Type:            "device_info",
+		IP:              ip,
+		Hostname:        hostname,
+		SNMPDescription: sysDescr,
+		SysObjectID:     system.ObjectID,
+		SysUpTimeS:      int64(system.UpTime / time.Second),
+		SysLocation:     system.Location,
+		SysContact:      system.Contact,
+		Fields:          fields,
+	})
+}
+
+// WriteInterfaceMetrics streams a single IF-MIB ifTable row
+func (s *StreamWriter) WriteInterfaceMetrics(ip string, ifIndex int, ifDescr string, operStatus int, inOctets, outOctets, speed uint64) error {
+	return s.emit(interfaceRecord{
+		Time:       s.timestamp(),
+		Type:       "snmp_interface",
+		IP:         ip,
+		IfIndex:    ifIndex,
+		IfName:     ifDescr,
+		OperStatus: operStatus,
+		InOctets:   inOctets,
+		OutOctets:  outOctets,
+		Speed:      speed,
+	})
+}
+
+// WriteCustomMetrics streams the values of a custom OID group
+func (s *StreamWriter) WriteCustomMetrics(ip, measurement, group string, fields map[string]interface{}) error {
+	return s.emit(customRecord{
+		Time:        s.timestamp(),
+		Type:        "custom",
+		IP:          ip,
+		Measurement: measurement,
+		OIDGroup:    group,
+		Fields:      fields,
+	})
+}
+
+// WriteStateChange streams a device up/down transition
+func (s *StreamWriter) WriteStateChange(ip, hostname, newState, previous string, failures int, previousDuration time.Duration) error {
+	return s.emit(stateRecord{
+		Time:              s.timestamp(),
+		Type:              "device_state",
+		IP:                ip,
+		Hostname:          hostname,
+		State:             newState,
+		Previous:          previous,
+		Failures:          failures,
+		PreviousDurationS: previousDuration.Seconds(),
+	})
+}
+
+// WriteCompositeCheck streams one evaluation of a composite check for a device
+func (s *StreamWriter) WriteCompositeCheck(ip, check string, healthy bool, passed, total int, failed []string) error {
+	return s.emit(checkRecord{
+		Time:    s.timestamp(),
+		Type:    "composite_check",
+		IP:      ip,
+		Check:   check,
+		Healthy: healthy,
+		Passed:  passed,
+		Total:   total,
+		Failed:  failed,
+	})
+}
+
+// WriteLatencyAlert streams a latency threshold level change
+func (s *StreamWriter) WriteLatencyAlert(ip, hostname, threshold, level, previous string, rtt, limit time.Duration, percentile float64) error {
+	return s.emit(latencyRecord{
+		Time:       s.timestamp(),
+		Type:       "latency_alert",
+		IP:         ip,
+		Hostname:   hostname,
+		Threshold:  threshold,
+		Level:      level,
+		Previous:   previous,
+		RTTMs:      float64(rtt) / float64(time.Millisecond),
+		LimitMs:    float64(limit) / float64(time.Millisecond),
+		Percentile: percentile,
+	})
+}
+
+// WriteTracerouteHop streams one traceroute hop
+func (s *StreamWriter) WriteTracerouteHop(ip, hostname, trigger, protocol string, at time.Time, ttl int, hopIP string, rtt time.Duration, reached bool) error {
+	return s.emit(tracerouteRecord{
+		Time:     at.UTC().Format(time.RFC3339Nano),
+		Type:     "traceroute",
+		IP:       ip,
+		Hostname: hostname,
+		Trigger:  trigger,
+		Protocol: protocol,
+		Hop:      ttl,
+		HopIP:    hopIP,
+		RTTMs:    float64(rtt) / float64(time.Millisecond),
+		Reached:  reached,
+	})
+}
+
+// WriteDiscovered streams a newly discovered device
+func (s *StreamWriter) WriteDiscovered(ip string) error {
+	return s.emit(discoveredRecord{
+		Time: s.timestamp(),
+		Type: "discovered",
+		IP:   ip,
+	})
+}
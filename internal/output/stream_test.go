@@ -0,0 +1,277 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/state"
+)
+
+// TestStreamWriterNDJSON verifies each result is written as one JSON object per line
+func TestStreamWriterNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewStreamWriter(&buf)
+	s.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	if err := s.WritePingResult("192.168.1.1", 1500*time.Microsecond, true, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteDeviceInfo("192.168.1.1", "core-sw1", "Cisco IOS"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteCustomMetrics("192.168.1.1", "snmp_ups", "ups", map[string]interface{}{"battery": 98}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteDiscovered("192.168.1.2"); err != nil {
+		t.Fatal(err)
+	}
+
+	var records []map[string]interface{}
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var rec map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("line is not valid JSON: %q: %v", scanner.Text(), err)
+		}
+		records = append(records, rec)
+	}
+
+	if len(records) != 4 {
+		t.Fatalf("expected 4 lines, got %d", len(records))
+	}
+
+	wantTypes := []string{"ping", "device_info", "custom", "discovered"}
+	for i, want := range wantTypes {
+		if records[i]["type"] != want {
+			t.Errorf("line %d: expected type %s, got %v", i, want, records[i]["type"])
+		}
+		if records[i]["time"] != "2024-01-02T03:04:05Z" {
+			t.Errorf("line %d: unexpected time %v", i, records[i]["time"])
+		}
+	}
+	if records[0]["rtt_ms"] != 1.5 || records[0]["success"] != true {
+		t.Errorf("unexpected ping record: %v", records[0])
+	}
+	if records[1]["hostname"] != "core-sw1" {
+		t.Errorf("unexpected device_info record: %v", records[1])
+	}
+	if fields, ok := records[2]["fields"].(map[string]interface{}); !ok || fields["battery"] != float64(98) {
+		t.Errorf("unexpected custom record: %v", records[2])
+	}
+}
+
+// TestStreamWriterPingStats validates multi-packet ping records carry loss and RTT statistics
+func TestStreamWriterPingStats(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewStreamWriter(&buf)
+
+	if err := s.WritePingStats("192.168.1.1", 4, 3, time.Millisecond, 2*time.Millisecond, 3*time.Millisecond, 500*time.Microsecond); err != nil {
+		t.Fatal(err)
+	}
+
+	var rec map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("line is not valid JSON: %q: %v", buf.String(), err)
+	}
+	if rec["type"] != "ping" || rec["success"] != true || rec["rtt_ms"] != 2.0 {
+		t.Errorf("unexpected ping fields: %v", rec)
+	}
+	if rec["packets_sent"] != 4.0 || rec["packets_recv"] != 3.0 || rec["packet_loss_pct"] != 25.0 {
+		t.Errorf("unexpected packet counts: %v", rec)
+	}
+	if rec["rtt_min_ms"] != 1.0 || rec["rtt_max_ms"] != 3.0 || rec["jitter_ms"] != 0.5 {
+		t.Errorf("unexpected RTT statistics: %v", rec)
+	}
+}
+
+// TestStreamWriterStateChange verifies device_state records carry the transition and outage duration
+func TestStreamWriterStateChange(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewStreamWriter(&buf)
+
+	if err := s.WriteStateChange("192.168.1.1", "router1", "up", "down", 0, 90*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	var rec map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("line is not valid JSON: %q: %v", buf.String(), err)
+	}
+	if rec["type"] != "device_state" || rec["state"] != "up" || rec["previous"] != "down" || rec["hostname"] != "router1" {
+		t.Errorf("unexpected state fields: %v", rec)
+	}
+	if rec["previous_duration_s"] != 90.0 {
+		t.Errorf("expected previous_duration_s 90, got %v", rec["previous_duration_s"])
+	}
+}
+
+// TestStreamWriterLatencyAlert validates the latency_alert record
+func TestStreamWriterLatencyAlert(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewStreamWriter(&buf)
+
+	if err := s.WriteLatencyAlert("10.0.0.1", "wan-router", "wan", "critical", "warning", 250*time.Millisecond, 200*time.Millisecond, 95); err != nil {
+		t.Fatal(err)
+	}
+
+	var rec map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("line is not valid JSON: %q: %v", buf.String(), err)
+	}
+	if rec["type"] != "latency_alert" || rec["threshold"] != "wan" || rec["level"] != "critical" || rec["previous"] != "warning" {
+		t.Errorf("unexpected latency fields: %v", rec)
+	}
+	if rec["rtt_ms"] != 250.0 || rec["limit_ms"] != 200.0 || rec["percentile"] != 95.0 {
+		t.Errorf("unexpected latency values: %v", rec)
+	}
+}
+
+// TestStreamWriterTracerouteHop validates traceroute hop records share the trace start time and omit silent hops' addresses
+func TestStreamWriterTracerouteHop(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewStreamWriter(&buf)
+	at := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	if err := s.WriteTracerouteHop("10.0.0.1", "wan-router", "down", "icmp", at, 1, "192.168.1.1", 2*time.Millisecond, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteTracerouteHop("10.0.0.1", "wan-router", "down", "icmp", at, 2, "", 0, false); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	var first, second map[string]interface{}
+	if err := json.Unmarshal(lines[0], &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(lines[1], &second); err != nil {
+		t.Fatal(err)
+	}
+	if first["type"] != "traceroute" || first["time"] != "2026-10-16T12:00:00Z" || first["hop"] != 1.0 || first["hop_ip"] != "192.168.1.1" || first["rtt_ms"] != 2.0 {
+		t.Errorf("unexpected first hop: %v", first)
+	}
+	if _, ok := second["hop_ip"]; ok || second["time"] != first["time"] || second["trigger"] != "down" {
+		t.Errorf("unexpected silent hop: %v", second)
+	}
+}
+
+// failingSink records calls and always returns an error
+type failingSink struct {
+	calls int
+}
+
+func (f *failingSink) WritePingResult(ip string, rtt time.Duration, successful bool, suspended bool) error {
+	f.calls++
+	return errors.New("sink down")
+}
+func (f *failingSink) WritePingStats(ip string, sent, recv int, minRtt, avgRtt, maxRtt, jitter time.Duration) error {
+	f.calls++
+	return errors.New("sink down")
+}
+func (f *failingSink) WriteDeviceInfo(ip, hostname, sysDescr string) error {
+	f.calls++
+	return errors.New("sink down")
+}
+func (f *failingSink) WriteDeviceInfoFields(ip, hostname, sysDescr string, system state.SystemInfo, fields map[string]string) error {
+	f.calls++
+	return errors.New("sink down")
+}
+func (f *failingSink) WriteInterfaceMetrics(ip string, ifIndex int, ifDescr string, operStatus int, inOctets, outOctets, speed uint64) error {
+	f.calls++
+	return errors.New("sink down")
+}
+func (f *failingSink) WriteCustomMetrics(ip, measurement, group string, fields map[string]interface{}) error {
+	f.calls++
+	return errors.New("sink down")
+}
+func (f *failingSink) WriteStateChange(ip, hostname, newState, previous string, failures int, previousDuration time.Duration) error {
+	f.calls++
+	return errors.New("sink down")
+}
+func (f *failingSink) WriteCompositeCheck(ip, check string, healthy bool, passed, total int, failed []string) error {
+	f.calls++
+	return errors.New("sink down")
+}
+func (f *failingSink) WriteLatencyAlert(ip, hostname, threshold, level, previous string, rtt, limit time.Duration, percentile float64) error {
+	f.calls++
+	return errors.New("sink down")
+}
+func (f *failingSink) WriteTracerouteHop(ip, hostname, trigger, protocol string, at time.Time, ttl int, hopIP string, rtt time.Duration, reached bool) error {
+	f.calls++
+	return errors.New("sink down")
+}
+
+// TestMultiContinuesAfterError verifies a failing sink does not stop delivery to the others
+func TestMultiContinuesAfterError(t *testing.T) {
+	var buf bytes.Buffer
+	failing := &failingSink{}
+	m := Multi{failing, NewStreamWriter(&buf)}
+
+	if err := m.WritePingResult("10.0.0.1", time.Millisecond, true, false); err == nil {
+		t.Error("expected error from failing sink to be returned")
+	}
+	if failing.calls != 1 {
+		t.Errorf("expected failing sink to be called once, got %d", failing.calls)
+	}
+	if buf.Len() == 0 {
+		t.Error("expected stream sink to receive the result despite earlier error")
+	}
+}
+
+// TestRouterSendsToSiteSink verifies results go to the sink of the device's group and others to the fallback
+func TestRouterSendsToSiteSink(t *testing.T) {
+	var siteBuf, fallbackBuf bytes.Buffer
+	route := func(ip string) string {
+		if ip == "10.1.0.5" {
+			return "fra1"
+		}
+		return ""
+	}
+	r := NewRouter(route, map[string]Sink{"fra1": NewStreamWriter(&siteBuf)}, NewStreamWriter(&fallbackBuf))
+
+	if err := r.WritePingResult("10.1.0.5", time.Millisecond, true, false); err != nil {
+		t.Fatalf("WritePingResult failed: %v", err)
+	}
+	if err := r.WriteStateChange("10.2.0.5", "sw2", "down", "up", 3, 0); err != nil {
+		t.Fatalf("WriteStateChange failed: %v", err)
+	}
+	if !bytes.Contains(siteBuf.Bytes(), []byte("10.1.0.5")) || bytes.Contains(siteBuf.Bytes(), []byte("10.2.0.5")) {
+		t.Errorf("site sink got %q", siteBuf.String())
+	}
+	if !bytes.Contains(fallbackBuf.Bytes(), []byte("10.2.0.5")) || bytes.Contains(fallbackBuf.Bytes(), []byte("10.1.0.5")) {
+		t.Errorf("fallback sink got %q", fallbackBuf.String())
+	}
+
+	discard := NewRouter(route, nil, nil)
+	if err := discard.WritePingResult("10.2.0.5", time.Millisecond, true, false); err != nil {
+		t.Errorf("expected results without a sink to be discarded, got %v", err)
+	}
+}
+
+// TestStreamWriterDeviceInfoSystem verifies answered system group values are streamed and unanswered ones omitted
+func TestStreamWriterDeviceInfoSystem(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewStreamWriter(&buf)
+	system := state.SystemInfo{ObjectID: "1.3.6.1.4.1.9.1.1208", UpTime: 90 * time.Second, Location: "FRA1"}
+	if err := s.WriteDeviceInfoFields("192.168.1.1", "core-sw1", "Cisco IOS", system, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var rec map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec["sys_object_id"] != "1.3.6.1.4.1.9.1.1208" || rec["sys_uptime_s"] != float64(90) || rec["sys_location"] != "FRA1" {
+		t.Errorf("unexpected system values: %v", rec)
+	}
+	if _, ok := rec["sys_contact"]; ok {
+		t.Errorf("expected sys_contact to be omitted, got %v", rec["sys_contact"])
+	}
+}
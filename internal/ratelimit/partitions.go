@@ -0,0 +1,83 @@
+// Package ratelimit partitions the global ping rate limit by network
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"sort"
+
+	"github.com/kljama/netscan/internal/config"
+	"golang.org/x/time/rate"
+)
+
+// partition is the token bucket of one network
+type partition struct {
+	network *net.IPNet
+	limiter *rate.Limiter
+}
+
+// Partitions holds per-network token buckets shared by discovery sweeps and continuous pingers
+// A probe waits for its most specific network's bucket before taking a global token, so a large
+// sweep of one network cannot use up the rate of another. A nil *Partitions limits nothing
+type Partitions struct {
+	partitions []partition // Most specific network first
+}
+
+// NewPartitions builds the partitions from network_rate_limits, returning nil when there are none
+// Entries are validated by ValidateConfig; malformed networks are ignored here
+func NewPartitions(limits []config.NetworkRateLimit) *Partitions {
+	if len(limits) == 0 {
+		return nil
+	}
+	p := &Partitions{}
+	for _, limit := range limits {
+		_, ipnet, err := net.ParseCIDR(limit.Network)
+		if err != nil {
+			continue
+		}
+		p.partitions = append(p.partitions, partition{
+			network: ipnet,
+			limiter: rate.NewLimiter(rate.Limit(limit.Rate), limit.BurstOrDefault()),
+		})
+	}
+	sort.SliceStable(p.partitions, func(i, j int) bool {
+		a, _ := p.partitions[i].network.Mask.Size()
+		b, _ := p.partitions[j].network.Mask.Size()
+		return a > b
+	})
+	return p
+}
+
+// limiter returns the bucket of the most specific network containing ip, or nil
+func (p *Partitions) limiter(ipStr string) *rate.Limiter {
+	if p == nil {
+		return nil
+	}
+	ip := net.ParseIP(ipStr)
+	if ip == nil {
+		return nil
+	}
+	for _, part := range p.partitions {
+		if part.network.Contains(ip) {
+			return part.limiter
+		}
+	}
+	return nil
+}
+
+// Wait blocks until ip's network partition grants a token; addresses outside every partition
+// return immediately. Returns an error only if ctx is cancelled while waiting
+func (p *Partitions) Wait(ctx context.Context, ip string) error {
+	if limiter := p.limiter(ip); limiter != nil {
+		return limiter.Wait(ctx)
+	}
+	return nil
+}
+
+// Count returns the number of partitions
+func (p *Partitions) Count() int {
+	if p == nil {
+		return 0
+	}
+	return len(p.partitions)
+}
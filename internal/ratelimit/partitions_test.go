@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kljama/netscan/internal/config"
+)
+
+// TestPartitionsMostSpecific verifies an address uses the bucket of its most specific network
+func TestPartitionsMostSpecific(t *testing.T) {
+	p := NewPartitions([]config.NetworkRateLimit{
+		{Network: "10.0.0.0/16", Rate: 100},
+		{Network: "10.0.5.0/24", Rate: 5, Burst: 2},
+	})
+	if p.Count() != 2 {
+		t.Fatalf("expected 2 partitions, got %d", p.Count())
+	}
+	if got := p.limiter("10.0.5.9"); got == nil || got.Burst() != 2 {
+		t.Errorf("expected the /24 partition for 10.0.5.9, got %v", got)
+	}
+	if got := p.limiter("10.0.6.9"); got == nil || got.Burst() != 100 {
+		t.Errorf("expected the /16 partition for 10.0.6.9, got %v", got)
+	}
+	if got := p.limiter("192.168.1.1"); got != nil {
+		t.Errorf("expected no partition outside the networks, got %v", got)
+	}
+}
+
+// TestPartitionsWait verifies a partition throttles its network and leaves others alone
+func TestPartitionsWait(t *testing.T) {
+	var nilPartitions *Partitions
+	if err := nilPartitions.Wait(context.Background(), "10.0.0.1"); err != nil {
+		t.Fatalf("nil partitions should not limit: %v", err)
+	}
+	if NewPartitions(nil) != nil {
+		t.Error("expected nil partitions without network_rate_limits")
+	}
+
+	p := NewPartitions([]config.NetworkRateLimit{{Network: "10.0.5.0/24", Rate: 1}})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := p.Wait(ctx, "10.0.5.1"); err != nil {
+		t.Fatalf("first token should be available: %v", err)
+	}
+	if err := p.Wait(ctx, "10.0.5.2"); err == nil {
+		t.Error("expected the exhausted partition to block past the deadline")
+	}
+	if err := p.Wait(ctx, "10.0.6.1"); err != nil {
+		t.Errorf("addresses outside the partition should not wait: %v", err)
+	}
+}
@@ -0,0 +1,228 @@
+// Package snmp wraps gosnmp with the connection handling and query helpers shared by discovery and monitoring
+package snmp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/kljama/netscan/internal/config"
+	"github.com/rs/zerolog/log"
+)
+
+// MIB-II system group objects
+const (
+	OIDSysDescr    = "1.3.6.1.2.1.1.1.0"
+	OIDSysObjectID = "1.3.6.1.2.1.1.2.0"
+	OIDSysUpTime   = "1.3.6.1.2.1.1.3.0"
+	OIDSysContact  = "1.3.6.1.2.1.1.4.0"
+	OIDSysName     = "1.3.6.1.2.1.1.5.0"
+	OIDSysLocation = "1.3.6.1.2.1.1.6.0"
+)
+
+// defaultMaxRepetitions is the GetBulk max-repetitions used when the caller passes 0
+const defaultMaxRepetitions = 10
+
+// Options are the connection settings of an SNMPv2c session
+type Options struct {
+	Port      uint16
+	Community string
+	Timeout   time.Duration
+	Retries   int
+	Transport string // udp or tcp ("" = udp)
+	LocalAddr string // Local host:port queries are sent from ("" = any)
+}
+
+// NewOptions returns the connection settings of an snmp block, sent from localAddr ("" = any)
+func NewOptions(snmpConfig *config.SNMPConfig, localAddr string) Options {
+	return Options{
+		Port:      uint16(snmpConfig.Port),
+		Community: snmpConfig.Community,
+		Timeout:   snmpConfig.Timeout,
+		Retries:   snmpConfig.Retries,
+		Transport: snmpConfig.Transport,
+		LocalAddr: localAddr,
+	}
+}
+
+// Equal reports whether two option sets open identical sessions; an unset transport equals udp
+func (o Options) Equal(other Options) bool {
+	return o.withDefaults() == other.withDefaults()
+}
+
+// withDefaults returns o with the transport filled in
+func (o Options) withDefaults() Options {
+	if o.Transport == "" {
+		o.Transport = config.SNMPTransportUDP
+	}
+	return o
+}
+
+// Client is a connected SNMPv2c session to one device; it is not safe for concurrent use
+// Every query takes a context: cancelling it aborts the request in flight instead of waiting for its timeout
+type Client struct {
+	params *gosnmp.GoSNMP
+	opts   Options
+}
+
+// Dial opens a session to target with opts; ctx bounds the TCP connect
+// UDP sessions succeed without the device answering; the first query reveals an unreachable agent
+func Dial(ctx context.Context, target string, opts Options) (*Client, error) {
+	params := &gosnmp.GoSNMP{
+		Target:    target,
+		Port:      opts.Port,
+		Community: opts.Community,
+		Version:   gosnmp.Version2c,
+		Timeout:   opts.Timeout,
+		Retries:   opts.Retries,
+		Transport: opts.Transport,
+		LocalAddr: opts.LocalAddr,
+		Context:   ctx,
+	}
+	if err := params.Connect(); err != nil {
+		return nil, queryError(ctx, err)
+	}
+	return &Client{params: params, opts: opts}, nil
+}
+
+// bind makes the requests until release honour ctx
+// gosnmp only checks the context between retries, so cancellation also expires the socket deadline to
+// interrupt a read that is waiting for an answer
+func (c *Client) bind(ctx context.Context) (release func(), err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.params.Context = ctx
+	stop := context.AfterFunc(ctx, func() {
+		c.params.Conn.SetDeadline(time.Now())
+	})
+	return func() { stop() }, nil
+}
+
+// queryError returns ctx's error instead of the timeout a cancellation surfaces as
+func queryError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// Target returns the device address of the session
+func (c *Client) Target() string {
+	return c.params.Target
+}
+
+// Options returns the settings the session was opened with
+func (c *Client) Options() Options {
+	return c.opts
+}
+
+// MaxOids returns the most OIDs a single Get may request
+func (c *Client) MaxOids() int {
+	if c.params.MaxOids > 0 {
+		return c.params.MaxOids
+	}
+	return gosnmp.MaxOids
+}
+
+// Get fetches oids in one request
+func (c *Client) Get(ctx context.Context, oids []string) ([]gosnmp.SnmpPDU, error) {
+	release, err := c.bind(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	resp, err := c.params.Get(oids)
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	return resp.Variables, nil
+}
+
+// GetWithFallback fetches oids with Get, falling back to one GetNext per OID (without its .0 suffix)
+// when Get fails or every object is missing, for agents that do not implement the .0 instances
+// Values are only kept when the returned OID lies under the requested one
+func (c *Client) GetWithFallback(ctx context.Context, oids []string) ([]gosnmp.SnmpPDU, error) {
+	release, err := c.bind(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// Try Get first (most efficient for .0 instances)
+	resp, err := c.params.Get(oids)
+	if err == nil {
+		for _, variable := range resp.Variables {
+			if variable.Type != gosnmp.NoSuchInstance && variable.Type != gosnmp.NoSuchObject {
+				return resp.Variables, nil
+			}
+		}
+		// All variables returned NoSuchInstance/NoSuchObject, try GetNext
+		log.Debug().
+			Str("target", c.params.Target).
+			Msg("Get returned NoSuchInstance, trying GetNext fallback")
+	}
+
+	variables := make([]gosnmp.SnmpPDU, 0, len(oids))
+	for _, oid := range oids {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		baseOID := strings.TrimSuffix(oid, ".0")
+		resp, err := c.params.GetNext([]string{baseOID})
+		if err != nil || len(resp.Variables) == 0 {
+			continue
+		}
+		if name := strings.TrimPrefix(resp.Variables[0].Name, "."); name == baseOID || strings.HasPrefix(name, baseOID+".") {
+			variables = append(variables, resp.Variables[0])
+		}
+	}
+	if len(variables) == 0 {
+		return nil, queryError(ctx, fmt.Errorf("no valid SNMP data retrieved"))
+	}
+	return variables, nil
+}
+
+// GetBulk sends one GetBulk request: the first nonRepeaters OIDs return their next object, the others up to
+// maxRepetitions following objects each (0 = 10)
+func (c *Client) GetBulk(ctx context.Context, oids []string, nonRepeaters uint8, maxRepetitions uint32) ([]gosnmp.SnmpPDU, error) {
+	if maxRepetitions == 0 {
+		maxRepetitions = defaultMaxRepetitions
+	}
+	release, err := c.bind(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	resp, err := c.params.GetBulk(oids, nonRepeaters, maxRepetitions)
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	return resp.Variables, nil
+}
+
+// Walk returns every object under rootOID, using GetBulk requests except on SNMPv1 sessions
+func (c *Client) Walk(ctx context.Context, rootOID string) ([]gosnmp.SnmpPDU, error) {
+	release, err := c.bind(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	var pdus []gosnmp.SnmpPDU
+	if c.params.Version == gosnmp.Version1 {
+		pdus, err = c.params.WalkAll(rootOID)
+	} else {
+		pdus, err = c.params.BulkWalkAll(rootOID)
+	}
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	return pdus, nil
+}
+
+// Close closes the session's socket
+func (c *Client) Close() error {
+	return c.params.Conn.Close()
+}
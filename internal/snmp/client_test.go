@@ -0,0 +1,217 @@
+package snmp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/kljama/netscan/internal/config"
+)
+
+// fakeAgent answers Get, GetNext and GetBulk requests over UDP from a sorted list of objects
+type fakeAgent struct {
+	conn    net.PacketConn
+	objects []gosnmp.SnmpPDU // Sorted by OID, names without the leading dot
+}
+
+// startAgent serves objects on a local UDP port until the test ends
+func startAgent(t *testing.T, objects []gosnmp.SnmpPDU) (*fakeAgent, Options) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	agent := &fakeAgent{conn: conn, objects: objects}
+	go agent.serve()
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+	return agent, Options{Port: uint16(port), Community: "public", Timeout: time.Second, Retries: 0}
+}
+
+// serve decodes requests and sends the matching responses until the socket is closed
+func (a *fakeAgent) serve() {
+	decoder := &gosnmp.GoSNMP{Version: gosnmp.Version2c}
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := a.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		req, err := decoder.SnmpDecodePacket(buf[:n])
+		if err != nil {
+			continue
+		}
+		resp := &gosnmp.SnmpPacket{
+			Version:   gosnmp.Version2c,
+			Community: req.Community,
+			PDUType:   gosnmp.GetResponse,
+			RequestID: req.RequestID,
+		}
+		for i, v := range req.Variables {
+			oid := strings.TrimPrefix(v.Name, ".")
+			switch {
+			case req.PDUType == gosnmp.GetRequest:
+				resp.Variables = append(resp.Variables, a.get(oid))
+			case req.PDUType == gosnmp.GetNextRequest || i < int(req.NonRepeaters):
+				resp.Variables = append(resp.Variables, a.next(oid))
+			case req.PDUType == gosnmp.GetBulkRequest:
+				for r := uint32(0); r < req.MaxRepetitions; r++ {
+					pdu := a.next(oid)
+					resp.Variables = append(resp.Variables, pdu)
+					if pdu.Type == gosnmp.EndOfMibView {
+						break
+					}
+					oid = strings.TrimPrefix(pdu.Name, ".")
+				}
+			}
+		}
+		out, err := resp.MarshalMsg()
+		if err != nil {
+			continue
+		}
+		a.conn.WriteTo(out, addr)
+	}
+}
+
+// get returns the object named oid, or noSuchInstance
+func (a *fakeAgent) get(oid string) gosnmp.SnmpPDU {
+	for _, pdu := range a.objects {
+		if pdu.Name == oid {
+			return pdu
+		}
+	}
+	return gosnmp.SnmpPDU{Name: oid, Type: gosnmp.NoSuchInstance}
+}
+
+// next returns the first object after oid, or endOfMibView
+func (a *fakeAgent) next(oid string) gosnmp.SnmpPDU {
+	for _, pdu := range a.objects {
+		if compareOIDs(pdu.Name, oid) > 0 {
+			return pdu
+		}
+	}
+	return gosnmp.SnmpPDU{Name: oid, Type: gosnmp.EndOfMibView}
+}
+
+// compareOIDs orders OIDs numerically, component by component
+func compareOIDs(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, _ := strconv.Atoi(as[i])
+		y, _ := strconv.Atoi(bs[i])
+		if x != y {
+			return x - y
+		}
+	}
+	return len(as) - len(bs)
+}
+
+// testObjects has sysDescr and sysName without .0 instances, and a three-row ifDescr column
+var testObjects = []gosnmp.SnmpPDU{
+	{Name: "1.3.6.1.2.1.1.1.1", Type: gosnmp.OctetString, Value: []byte("Cisco IOS")},
+	{Name: "1.3.6.1.2.1.1.5.1", Type: gosnmp.OctetString, Value: []byte("core-sw1")},
+	{Name: "1.3.6.1.2.1.2.2.1.2.1", Type: gosnmp.OctetString, Value: []byte("Gi0/1")},
+	{Name: "1.3.6.1.2.1.2.2.1.2.2", Type: gosnmp.OctetString, Value: []byte("Gi0/2")},
+	{Name: "1.3.6.1.2.1.2.2.1.2.3", Type: gosnmp.OctetString, Value: []byte("Gi0/3")},
+	{Name: "1.3.6.1.2.1.2.2.1.5.1", Type: gosnmp.Gauge32, Value: uint(1000000000)},
+}
+
+// TestGetWithFallback verifies agents without .0 instances are answered through GetNext
+func TestGetWithFallback(t *testing.T) {
+	_, opts := startAgent(t, testObjects)
+	client, err := Dial(context.Background(), "127.0.0.1", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	variables, err := client.GetWithFallback(context.Background(), []string{OIDSysName, OIDSysDescr})
+	if err != nil {
+		t.Fatalf("expected the GetNext fallback to succeed: %v", err)
+	}
+	if len(variables) != 2 || string(variables[0].Value.([]byte)) != "core-sw1" || string(variables[1].Value.([]byte)) != "Cisco IOS" {
+		t.Errorf("expected sysName and sysDescr, got %+v", variables)
+	}
+
+	// An object missing entirely must not be answered with the next subtree's value
+	if _, err := client.GetWithFallback(context.Background(), []string{OIDSysLocation}); err == nil {
+		t.Error("expected an error for an object the agent does not implement")
+	}
+}
+
+// TestWalkAndGetBulk verifies Walk stays inside its subtree and GetBulk returns repetitions
+func TestWalkAndGetBulk(t *testing.T) {
+	_, opts := startAgent(t, testObjects)
+	client, err := Dial(context.Background(), "127.0.0.1", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	rows, err := client.Walk(context.Background(), "1.3.6.1.2.1.2.2.1.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[2].Name != ".1.3.6.1.2.1.2.2.1.2.3" {
+		t.Errorf("expected the three ifDescr rows, got %+v", rows)
+	}
+
+	bulk, err := client.GetBulk(context.Background(), []string{"1.3.6.1.2.1.1", "1.3.6.1.2.1.2.2.1.2"}, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bulk) != 3 || bulk[0].Name != ".1.3.6.1.2.1.1.1.1" || bulk[2].Name != ".1.3.6.1.2.1.2.2.1.2.2" {
+		t.Errorf("expected one non-repeater and two repetitions, got %+v", bulk)
+	}
+}
+
+// TestCancelAbortsQuery verifies cancelling the context interrupts a request waiting for a silent agent
+func TestCancelAbortsQuery(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	opts := Options{Port: uint16(conn.LocalAddr().(*net.UDPAddr).Port), Community: "public", Timeout: 10 * time.Second, Retries: 2}
+	client, err := Dial(context.Background(), "127.0.0.1", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	started := time.Now()
+	if _, err := client.Get(ctx, []string{OIDSysName}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Errorf("query took %v after cancellation, expected it to abort", elapsed)
+	}
+	if _, err := client.Walk(ctx, "1.3.6.1.2.1.2.2.1.2"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancelled context to fail fast, got %v", err)
+	}
+}
+
+// TestOptionsEqual verifies an unset transport is the same as udp
+func TestOptionsEqual(t *testing.T) {
+	snmpConfig := config.SNMPConfig{Community: "public", Port: 161, Timeout: time.Second, Retries: 1}
+	opts := NewOptions(&snmpConfig, "")
+	udp := opts
+	udp.Transport = config.SNMPTransportUDP
+	if !opts.Equal(udp) {
+		t.Error("expected the default transport to equal udp")
+	}
+	tcp := opts
+	tcp.Transport = config.SNMPTransportTCP
+	if opts.Equal(tcp) {
+		t.Error("expected udp and tcp options to differ")
+	}
+	if opts.Equal(NewOptions(&snmpConfig, "192.168.1.5:0")) {
+		t.Error("expected a different source address to differ")
+	}
+}
@@ -0,0 +1,44 @@
+package snmp
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/kljama/netscan/internal/state"
+	"github.com/kljama/netscan/internal/validate"
+)
+
+// QuerySystemInfo reads sysObjectID, sysUpTime, sysContact and sysLocation in one Get
+// The query is best effort: objects the agent does not answer stay empty, and a failed request returns false
+// Strings are cut to maxLength bytes (snmp.max_string_length, 0 = default)
+func QuerySystemInfo(ctx context.Context, c *Client, maxLength int) (state.SystemInfo, bool) {
+	variables, err := c.Get(ctx, []string{OIDSysObjectID, OIDSysUpTime, OIDSysContact, OIDSysLocation})
+	if err != nil {
+		return state.SystemInfo{}, false
+	}
+	return ParseSystemInfo(variables, maxLength), true
+}
+
+// ParseSystemInfo extracts the system group values from a Get response; unexpected types are skipped
+func ParseSystemInfo(variables []gosnmp.SnmpPDU, maxLength int) state.SystemInfo {
+	var info state.SystemInfo
+	for _, v := range variables {
+		switch strings.TrimPrefix(v.Name, ".") {
+		case OIDSysObjectID:
+			if oid, ok := v.Value.(string); ok && v.Type == gosnmp.ObjectIdentifier {
+				info.ObjectID = strings.TrimPrefix(oid, ".")
+			}
+		case OIDSysUpTime:
+			if v.Type == gosnmp.TimeTicks {
+				info.UpTime = time.Duration(gosnmp.ToBigInt(v.Value).Int64()) * 10 * time.Millisecond
+			}
+		case OIDSysContact:
+			info.Contact, _ = validate.SNMPString(v.Value, "sysContact", maxLength)
+		case OIDSysLocation:
+			info.Location, _ = validate.SNMPString(v.Value, "sysLocation", maxLength)
+		}
+	}
+	return info
+}
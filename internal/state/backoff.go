@@ -0,0 +1,191 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultBackoffReset is how long a device must go without a circuit breaker trip to start over at the first step
+const DefaultBackoffReset = 24 * time.Hour
+
+// Escalation is the circuit breaker backoff escalation of a device, kept across restarts by SaveBackoff
+type Escalation struct {
+	Level    int       `json:"level"`     // Index into the backoff steps of the latest suspension
+	LastTrip time.Time `json:"last_trip"` // When the circuit breaker last tripped
+}
+
+// backoffFile is the on-disk format of SaveBackoff
+type backoffFile struct {
+	Devices map[string]Escalation `json:"devices"`
+}
+
+// SetBackoffSteps makes repeat offenders escalate through steps (e.g. 1m, 5m, 30m, 4h) instead of the fixed backoff:
+// every trip within reset of the previous one, and every unanswered half-open test ping, moves a device one step
+// up; a device that goes reset without a trip starts at the first step again. Empty steps restore the fixed
+// backoff. Call once at startup, before any pings are reported
+func (m *Manager) SetBackoffSteps(steps []time.Duration, reset time.Duration) {
+	if reset <= 0 {
+		reset = DefaultBackoffReset
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.backoffSteps = append([]time.Duration(nil), steps...)
+	m.backoffReset = reset
+}
+
+// escalateLocked records a trip and sets the escalation level of its suspension
+// Without steps the level only counts the unanswered test pings of the current suspension, so a new one starts at 0
+// Caller must hold m.mu for writing
+func (m *Manager) escalateLocked(dev *Device, now time.Time) {
+	switch {
+	case len(m.backoffSteps) == 0:
+		dev.BackoffLevel = 0
+	case !dev.LastTrip.IsZero() && now.Sub(dev.LastTrip) < m.backoffReset:
+		dev.BackoffLevel = min(dev.BackoffLevel+1, len(m.backoffSteps)-1)
+	default:
+		dev.BackoffLevel = 0
+	}
+	dev.LastTrip = now
+}
+
+// suspensionLocked returns the suspension at level and the backoff that ping_half_open_after is relative to
+// Caller must hold m.mu
+func (m *Manager) suspensionLocked(level int, backoff time.Duration) (suspension, base time.Duration) {
+	if len(m.backoffSteps) > 0 {
+		return m.backoffSteps[min(level, len(m.backoffSteps)-1)], m.backoffSteps[0]
+	}
+	suspension = backoff
+	if m.halfOpenAfter > 0 {
+		for i := 0; i < level && suspension < m.backoffMax; i++ {
+			suspension *= 2
+		}
+		if m.backoffMax >= backoff {
+			suspension = min(suspension, m.backoffMax)
+		}
+	}
+	return suspension, backoff
+}
+
+// Breaker is the ping circuit breaker state of a device
+type Breaker struct {
+	Hostname         string
+	SuspendedUntil   time.Time     // Zero or past when the breaker is closed
+	ProbeAt          time.Time     // Pending half-open test ping (zero = none)
+	ConsecutiveFails int           // Failed cycles in a row
+	WindowFailures   int           // Failed cycles in the failure window (windowed mode)
+	Escalation       Escalation    // Level of the latest suspension and time of the last trip
+	NextBackoff      time.Duration // Suspension a trip would start now
+}
+
+// Breaker returns the circuit breaker state of a device; backoff is the fixed ping_backoff_duration
+func (m *Manager) Breaker(ip string, backoff time.Duration, now time.Time) (Breaker, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	dev, exists := m.devices[ip]
+	if !exists {
+		return Breaker{}, false
+	}
+	next := *dev
+	m.escalateLocked(&next, now)
+	nextBackoff, _ := m.suspensionLocked(next.BackoffLevel, backoff)
+	return Breaker{
+		Hostname:         dev.Hostname,
+		SuspendedUntil:   dev.SuspendedUntil,
+		ProbeAt:          dev.ProbeAt,
+		ConsecutiveFails: dev.ConsecutiveFails,
+		WindowFailures:   bits.OnesCount64(dev.PingWindow),
+		Escalation:       Escalation{Level: dev.BackoffLevel, LastTrip: dev.LastTrip},
+		NextBackoff:      nextBackoff,
+	}, true
+}
+
+// restoreEscalationLocked applies the escalation loaded by LoadBackoff to a device new to state
+// Caller must hold m.mu for writing
+func (m *Manager) restoreEscalationLocked(dev *Device) {
+	if escalation, ok := m.savedEscalations[dev.IP]; ok {
+		dev.BackoffLevel = escalation.Level
+		dev.LastTrip = escalation.LastTrip
+		delete(m.savedEscalations, dev.IP)
+	}
+}
+
+// SaveBackoff writes the escalation of devices that tripped within the reset period to path atomically
+func (m *Manager) SaveBackoff(path string) error {
+	file := backoffFile{Devices: make(map[string]Escalation)}
+	m.mu.RLock()
+	cutoff := time.Now().Add(-m.backoffReset)
+	for ip, dev := range m.devices {
+		if dev.LastTrip.After(cutoff) {
+			file.Devices[ip] = Escalation{Level: dev.BackoffLevel, LastTrip: dev.LastTrip}
+		}
+	}
+	// Devices not rediscovered since the restart keep their escalation
+	for ip, escalation := range m.savedEscalations {
+		if escalation.LastTrip.After(cutoff) {
+			file.Devices[ip] = escalation
+		}
+	}
+	m.mu.RUnlock()
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("failed to save backoff escalation: %v", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".netscan-backoff-*")
+	if err != nil {
+		return fmt.Errorf("failed to save backoff escalation: %v", err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to save backoff escalation: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to save backoff escalation: %v", err)
+	}
+	return nil
+}
+
+// LoadBackoff restores the escalation saved by SaveBackoff; a missing file is not an error
+// Call after SetBackoffSteps; devices are given their level when they are added to state, and entries older
+// than the reset period or beyond the current steps are dropped or capped
+func (m *Manager) LoadBackoff(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load backoff escalation: %v", err)
+	}
+	var file backoffFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to load backoff escalation from %s: %v", path, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.backoffSteps) == 0 {
+		return nil
+	}
+	cutoff := time.Now().Add(-m.backoffReset)
+	m.savedEscalations = make(map[string]Escalation, len(file.Devices))
+	for ip, escalation := range file.Devices {
+		if !escalation.LastTrip.After(cutoff) || escalation.Level < 0 {
+			continue
+		}
+		escalation.Level = min(escalation.Level, len(m.backoffSteps)-1)
+		m.savedEscalations[ip] = escalation
+		if dev, exists := m.devices[ip]; exists {
+			m.restoreEscalationLocked(dev)
+		}
+	}
+	return nil
+}
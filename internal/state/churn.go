@@ -0,0 +1,54 @@
+package state
+
+// Churn counts devices entering and leaving state
+type Churn struct {
+	Discovered   uint64 `json:"discovered"`   // New devices added by discovery
+	Rediscovered uint64 `json:"rediscovered"` // Removed devices restored from their tombstone
+	Pruned       uint64 `json:"pruned"`       // Devices removed as stale or by overlap detection
+	Evicted      uint64 `json:"evicted"`      // Least recently seen devices dropped to stay within max_devices
+}
+
+// Sub returns the churn since prev, an earlier reading of the same counters
+func (c Churn) Sub(prev Churn) Churn {
+	return Churn{
+		Discovered:   c.Discovered - prev.Discovered,
+		Rediscovered: c.Rediscovered - prev.Rediscovered,
+		Pruned:       c.Pruned - prev.Pruned,
+		Evicted:      c.Evicted - prev.Evicted,
+	}
+}
+
+// Zero reports whether no device entered or left state
+func (c Churn) Zero() bool {
+	return c == Churn{}
+}
+
+// Churn returns the devices discovered, rediscovered, pruned and evicted since the manager was created,
+// in total and per configured network (devices outside every network are counted under "")
+func (m *Manager) Churn() (Churn, map[string]Churn) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	networks := make(map[string]Churn, len(m.networkChurn))
+	for network, churn := range m.networkChurn {
+		networks[network] = *churn
+	}
+	return m.churn, networks
+}
+
+// countChurnLocked applies count to the total and the network's churn of dev; caller must hold m.mu for writing
+func (m *Manager) countChurnLocked(dev *Device, count func(*Churn)) {
+	count(&m.churn)
+	network := dev.Network
+	if network == "" {
+		network = m.resolveNetworkLocked(dev.IP)
+	}
+	if m.networkChurn == nil {
+		m.networkChurn = make(map[string]*Churn)
+	}
+	churn, ok := m.networkChurn[network]
+	if !ok {
+		churn = &Churn{}
+		m.networkChurn[network] = churn
+	}
+	count(churn)
+}
@@ -0,0 +1,114 @@
+package state
+
+import (
+	"slices"
+	"time"
+)
+
+// Classifier derives a device type from sysDescr, sysObjectID and the open TCP ports ("" = unknown)
+type Classifier func(sysDescr, sysObjectID string, openPorts []int) string
+
+// OSInferrer derives an OS family from sysDescr, the TTL of an echo reply and the open TCP ports ("" = unknown)
+type OSInferrer func(sysDescr string, replyTTL int, openPorts []int) string
+
+// Tagger derives the custom tags of a device from its IP and hostname (nil = none)
+type Tagger func(ip, hostname string) map[string]string
+
+// SetClassifier sets how device types are derived; devices are reclassified whenever their SNMP data
+// or open ports change. Call before devices are added
+func (m *Manager) SetClassifier(classify Classifier) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.classifier = classify
+}
+
+// DeviceType returns the classified type of a device, "" for unknown or unclassified devices
+// Used at write time to tag points with the device's type
+func (m *Manager) DeviceType(ip string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if dev, exists := m.devices[ip]; exists {
+		return dev.DeviceType
+	}
+	return ""
+}
+
+// SetOSInferrer sets how OS families are derived; like device types they are recomputed whenever the
+// device's SNMP data, open ports or reply TTL change. Call before devices are added
+func (m *Manager) SetOSInferrer(infer OSInferrer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.osInferrer = infer
+}
+
+// OSFamily returns the inferred OS family of a device, "" for unknown devices or families
+func (m *Manager) OSFamily(ip string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if dev, exists := m.devices[ip]; exists {
+		return dev.OSFamily
+	}
+	return ""
+}
+
+// UpdateDeviceTTL stores the TTL of the OS fingerprinting echo reply (0 = no reply) probed at probedAt
+// and re-infers the OS family; returns the resulting family. Unknown devices are ignored
+func (m *Manager) UpdateDeviceTTL(ip string, replyTTL int, probedAt time.Time) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	dev, exists := m.devices[ip]
+	if !exists {
+		return ""
+	}
+	dev.ReplyTTL = replyTTL
+	dev.TTLProbedAt = probedAt
+	m.classifyLocked(dev)
+	return dev.OSFamily
+}
+
+// UpdateDevicePorts stores the open ports found by the classification port probe at probedAt and
+// reclassifies the device; returns the resulting type. Unknown devices are ignored
+func (m *Manager) UpdateDevicePorts(ip string, openPorts []int, probedAt time.Time) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	dev, exists := m.devices[ip]
+	if !exists {
+		return ""
+	}
+	dev.OpenPorts = slices.Clone(openPorts)
+	dev.PortsProbedAt = probedAt
+	m.classifyLocked(dev)
+	return dev.DeviceType
+}
+
+// SetTagger sets how custom tags are derived; like device types they are recomputed whenever the device's
+// hostname changes. Call before devices are added
+func (m *Manager) SetTagger(tag Tagger) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tagger = tag
+}
+
+// Tags returns the custom tags of a device, nil for unknown devices or devices without tags
+// Used at write time to tag points; the map must not be modified
+func (m *Manager) Tags(ip string) map[string]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if dev, exists := m.devices[ip]; exists {
+		return dev.Tags
+	}
+	return nil
+}
+
+// classifyLocked recomputes a device's type, OS family and custom tags; caller must hold m.mu
+func (m *Manager) classifyLocked(dev *Device) {
+	if m.classifier != nil {
+		dev.DeviceType = m.classifier(dev.SysDescr, dev.System.ObjectID, dev.OpenPorts)
+	}
+	if m.osInferrer != nil {
+		dev.OSFamily = m.osInferrer(dev.SysDescr, dev.ReplyTTL, dev.OpenPorts)
+	}
+	if m.tagger != nil {
+		dev.Tags = m.tagger(dev.IP, dev.Hostname)
+	}
+}
@@ -0,0 +1,126 @@
+package state
+
+import (
+	"hash/fnv"
+	"sort"
+	"time"
+)
+
+// DeviceInfo is the content of a device_info point written by the SNMP poller
+// sysUpTime is not part of it: it advances on every poll and would make every poll a change
+type DeviceInfo struct {
+	Hostname string            // sysName
+	SysDescr string            // sysDescr
+	ObjectID string            // sysObjectID
+	Location string            // sysLocation
+	Contact  string            // sysContact
+	Fields   map[string]string // snmp.device_fields and virtual address fields (read-only once stored)
+}
+
+// InfoChange is one device_info value that differs from the previously written point
+// Custom fields are named after their device_info field; Old or New is "" when the value was added or removed
+type InfoChange struct {
+	Field string `json:"field"` // hostname, snmp_description, sys_object_id, sys_location, sys_contact or a custom field
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// Hash returns an FNV-1a hash of every value, custom fields in name order
+func (i DeviceInfo) Hash() uint64 {
+	h := fnv.New64a()
+	for _, value := range []string{i.Hostname, i.SysDescr, i.ObjectID, i.Location, i.Contact} {
+		h.Write([]byte(value))
+		h.Write([]byte{0})
+	}
+	for _, name := range sortedKeys(i.Fields) {
+		h.Write([]byte(name))
+		h.Write([]byte{0})
+		h.Write([]byte(i.Fields[name]))
+		h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
+
+// diffDeviceInfo lists the values that differ between old and new, standard fields first
+func diffDeviceInfo(old, new DeviceInfo) []InfoChange {
+	var changes []InfoChange
+	add := func(field, before, after string) {
+		if before != after {
+			changes = append(changes, InfoChange{Field: field, Old: before, New: after})
+		}
+	}
+	add("hostname", old.Hostname, new.Hostname)
+	add("snmp_description", old.SysDescr, new.SysDescr)
+	add("sys_object_id", old.ObjectID, new.ObjectID)
+	add("sys_location", old.Location, new.Location)
+	add("sys_contact", old.Contact, new.Contact)
+
+	names := sortedKeys(old.Fields)
+	for _, name := range sortedKeys(new.Fields) {
+		if _, ok := old.Fields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		add(name, old.Fields[name], new.Fields[name])
+	}
+	return changes
+}
+
+// sortedKeys returns the keys of m in ascending order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SetInfoChangeHandler registers a callback for every poll whose device_info differs from the last written one
+// Like the reboot handler it runs after the manager lock is released and must not block
+func (m *Manager) SetInfoChangeHandler(handler func(ip, hostname string, changes []InfoChange)) {
+	m.eventsMu.Lock()
+	defer m.eventsMu.Unlock()
+	m.infoChangeHandler = handler
+}
+
+// RecordDeviceInfo compares the device_info of a poll taken at now with the last written one and returns
+// whether the poll must write device_info: on the device's first poll, when a value changed, or when refresh
+// has passed since the last write (refresh 0 = every poll). Changes are passed to the info change handler;
+// the first poll has nothing to compare with and reports none
+// Unknown devices are always written
+func (m *Manager) RecordDeviceInfo(ip string, info DeviceInfo, now time.Time, refresh time.Duration) bool {
+	hash := info.Hash()
+
+	m.mu.Lock()
+	dev, exists := m.devices[ip]
+	if !exists {
+		m.mu.Unlock()
+		return true
+	}
+	first := dev.InfoWrittenAt.IsZero()
+	changed := !first && hash != dev.InfoHash
+	write := first || changed || refresh <= 0 || now.Sub(dev.InfoWrittenAt) >= refresh
+	var changes []InfoChange
+	if changed {
+		changes = diffDeviceInfo(dev.Info, info)
+	}
+	if write {
+		dev.Info = info
+		dev.InfoHash = hash
+		dev.InfoWrittenAt = now
+	}
+	m.mu.Unlock()
+
+	if len(changes) > 0 {
+		m.eventsMu.Lock()
+		handler := m.infoChangeHandler
+		m.eventsMu.Unlock()
+		if handler != nil {
+			handler(ip, info.Hostname, changes)
+		}
+	}
+	return write
+}
@@ -0,0 +1,40 @@
+package state
+
+import (
+	"math"
+	"math/bits"
+)
+
+// MaxFailureWindow bounds the windowed ping failure mode: cycle outcomes are kept as the bits of a uint64
+const MaxFailureWindow = 64
+
+// SetPingFailureWindow switches the ping circuit breaker from consecutive to windowed failures: it trips once
+// ratio of the last size ping cycles failed, so a device losing every other cycle is suspended too.
+// size 0 restores consecutive failures. Call once at startup, before any pings are reported
+func (m *Manager) SetPingFailureWindow(size int, ratio float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if size <= 0 {
+		m.failureWindow, m.failureWindowTrip = 0, 0
+		return
+	}
+	size = min(size, MaxFailureWindow)
+	m.failureWindow = size
+	m.failureWindowTrip = max(1, int(math.Ceil(ratio*float64(size)-1e-9)))
+}
+
+// recordPingOutcomeLocked shifts a ping cycle outcome into the device's failure window and returns the failures
+// in it (0 in consecutive mode). Caller must hold m.mu for writing
+func (m *Manager) recordPingOutcomeLocked(dev *Device, failed bool) int {
+	if m.failureWindow == 0 {
+		return 0
+	}
+	dev.PingWindow <<= 1
+	if failed {
+		dev.PingWindow |= 1
+	}
+	if m.failureWindow < MaxFailureWindow {
+		dev.PingWindow &= 1<<m.failureWindow - 1
+	}
+	return bits.OnesCount64(dev.PingWindow)
+}
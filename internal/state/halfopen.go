@@ -0,0 +1,58 @@
+package state
+
+import "time"
+
+// SetHalfOpenProbing lets one test ping through every ping circuit breaker suspension: after ping_half_open_after
+// (scaled with the suspension) the device is half-open, and the pinger sends a single echo request instead of
+// skipping it. An answer ends the suspension at once; no answer suspends the device again one escalation level up:
+// twice as long up to maxBackoff, or the next SetBackoffSteps step. after 0 disables test pings. Call once at
+// startup, before any pings are reported
+func (m *Manager) SetHalfOpenProbing(after, maxBackoff time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.halfOpenAfter = after
+	m.backoffMax = maxBackoff
+}
+
+// suspendLocked suspends a device for the backoff of its escalation level (backoff doubled once per level up to the
+// maximum, or the level's backoff step) and schedules its test ping at the same share of the suspension as
+// ping_half_open_after is of the first backoff. Caller must hold m.mu for writing
+func (m *Manager) suspendLocked(dev *Device, backoff time.Duration, now time.Time) {
+	suspension, base := m.suspensionLocked(dev.BackoffLevel, backoff)
+	dev.ProbeAt = time.Time{}
+	if m.halfOpenAfter > 0 && base > 0 {
+		dev.ProbeAt = now.Add(time.Duration(float64(m.halfOpenAfter) * float64(suspension) / float64(base)))
+	}
+	dev.SuspendedUntil = now.Add(suspension)
+}
+
+// ClaimHalfOpenProbe reports whether a suspended device is due for its test ping, and claims it so that only
+// one test ping is sent per suspension
+func (m *Manager) ClaimHalfOpenProbe(ip string, now time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	dev, exists := m.devices[ip]
+	if !exists || dev.ProbeAt.IsZero() || now.Before(dev.ProbeAt) || !now.Before(dev.SuspendedUntil) {
+		return false
+	}
+	dev.ProbeAt = time.Time{}
+	return true
+}
+
+// ReportHalfOpenFail extends the suspension of a device whose test ping went unanswered, doubling it up to the
+// maximum, and returns when the new suspension ends. A test ping answered is reported with ReportPingSuccess
+func (m *Manager) ReportHalfOpenFail(ip string, backoff time.Duration) time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	dev, exists := m.devices[ip]
+	now := time.Now()
+	if !exists || !now.Before(dev.SuspendedUntil) {
+		return time.Time{} // Removed or released meanwhile
+	}
+	dev.BackoffLevel++
+	if len(m.backoffSteps) > 0 {
+		dev.BackoffLevel = min(dev.BackoffLevel, len(m.backoffSteps)-1)
+	}
+	m.suspendLocked(dev, backoff, now)
+	return dev.SuspendedUntil
+}
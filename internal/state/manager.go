@@ -9,15 +9,41 @@ import (
 
 // Device represents a discovered network device with metadata
 type Device struct {
-	IP                     string    // IPv4 address of the device
-	Hostname               string    // Device hostname from SNMP or IP address
-	SysDescr               string    // SNMP sysDescr MIB-II value
-	LastSeen               time.Time // Timestamp of last successful discovery
-	ConsecutiveFails       int       // Number of consecutive ping failures (circuit breaker)
-	SuspendedUntil         time.Time // Timestamp until which device is suspended (circuit breaker)
-	SNMPConsecutiveFails   int       // Number of consecutive SNMP failures (SNMP circuit breaker)
-	SNMPSuspendedUntil     time.Time // Timestamp until which SNMP polling is suspended (SNMP circuit breaker)
-	heapIndex              int       // Index in the min-heap for O(log n) eviction (internal use only)
+	IP                     string      // IPv4 address of the device
+	Hostname               string      // Device hostname from SNMP or IP address
+	SysDescr               string      // SNMP sysDescr MIB-II value
+	LastSeen               time.Time   // Timestamp of last successful discovery
+	ConsecutiveFails       int         // Number of consecutive ping failures (circuit breaker)
+	SuspendedUntil         time.Time   // Timestamp until which device is suspended (circuit breaker)
+	PingWindow             uint64      // Recent ping cycle outcomes in windowed failure mode, newest in bit 0 (1 = failed)
+	BackoffLevel           int         // Circuit breaker escalation: backoff step of the latest suspension, or its unanswered test pings without steps
+	LastTrip               time.Time   // When the ping circuit breaker last tripped (zero = never)
+	ProbeAt                time.Time   // When the suspended device gets its half-open test ping (zero = none pending)
+	SNMPConsecutiveFails   int         // Number of consecutive SNMP failures (SNMP circuit breaker)
+	SNMPSuspendedUntil     time.Time   // Timestamp until which SNMP polling is suspended (SNMP circuit breaker)
+	SNMPResult             *SNMPResult // Latest full SNMP result set (nil until first successful poll, read-only once stored)
+	Reachability           string      // "up" or "down" from ping results ("" until the first result)
+	ReachabilitySince      time.Time   // When the current reachability state began
+	DownFails              int         // Consecutive ping failures counted toward a down transition
+	MAC                    string      // Hardware address seen in ARP traffic ("" unless the passive ARP listener saw the device)
+	PassiveDiscovery       bool        // Added by the passive ARP listener rather than a discovery sweep
+	Network                string      // Configured network the device belongs to (CIDR or its network_labels label, "" = none)
+	System                 SystemInfo  // sysObjectID, sysUpTime, sysLocation and sysContact from the latest SNMP poll
+	SystemPolledAt         time.Time   // When System was polled (zero until the first poll)
+	LastReboot             time.Time   // Estimated time of the last reboot detected from sysUpTime (zero = none seen)
+	DeviceType             string      // Type assigned by device_classification rules, e.g. "printer" ("" = unclassified)
+	OpenPorts              []int       // TCP ports that accepted a connection in the classification port probe
+	PortsProbedAt          time.Time   // When OpenPorts was probed (zero until the first probe)
+	UPnP                   UPnPInfo    // Device description from SSDP/UPnP discovery (zero unless the ssdp method found the device)
+	UPnPSeenAt             time.Time   // When UPnP was last updated
+	ReplyTTL               int         // TTL of the OS fingerprinting echo reply (0 = not probed or no reply)
+	TTLProbedAt            time.Time   // When ReplyTTL was probed
+	OSFamily               string      // Family inferred by os_fingerprinting, e.g. "windows" ("" = unknown)
+	Tags                   map[string]string // Custom tags from device_tags rules (nil = none, read-only once stored)
+	Info                   DeviceInfo  // Content of the last device_info point written by the SNMP poller
+	InfoHash               uint64      // Hash of Info, compared with every poll
+	InfoWrittenAt          time.Time   // When Info was written (zero until the first poll)
+	heapIndex              int         // Index in the min-heap for O(log n) eviction (internal use only)
 }
 
 // deviceHeap implements heap.Interface for min-heap ordered by LastSeen timestamp
@@ -62,6 +88,41 @@ type Manager struct {
 	maxDevices          int                // Maximum number of devices to manage
 	suspendedCount      atomic.Int32       // Cached count of ping-suspended devices (for O(1) reads)
 	snmpSuspendedCount  atomic.Int32       // Cached count of SNMP-suspended devices (for O(1) reads)
+	downThreshold       int                // Consecutive ping failures before a device is reported down
+	failureWindow       int                // Ping cycles in the windowed circuit breaker (0 = consecutive failures)
+	failureWindowTrip   int                // Failed cycles within failureWindow that trip the circuit breaker
+	halfOpenAfter       time.Duration      // Suspension time before the half-open test ping (0 = no test pings)
+	backoffMax          time.Duration      // Cap on suspensions doubled by unanswered test pings
+	backoffSteps        []time.Duration    // Escalating suspensions of repeat offenders (nil = fixed backoff)
+	backoffReset        time.Duration      // Time without a trip after which a device starts at the first step again
+	savedEscalations    map[string]Escalation // Escalations loaded by LoadBackoff for devices not in state yet (protected by mu)
+	eventsMu            sync.Mutex         // Protects stateEvents and the event handlers
+	stateEvents         []StateEvent       // Recent up/down transitions, oldest first
+	stateHandler        func(StateEvent)   // Called for every transition (nil = none)
+	suspendHandler      func(ip, hostname string, until time.Time) // Called when the ping circuit breaker trips (nil = none)
+	rebootHandler       func(ip, hostname string, reboot Reboot)   // Called when sysUpTime reveals a reboot (nil = none)
+	quarantineHandler   func(QuarantineEntry)                      // Called when a device is quarantined (nil = none)
+	infoChangeHandler   func(ip, hostname string, changes []InfoChange) // Called when a poll changes device_info (nil = none)
+	virtualIPs          map[string]*VirtualIP // VRRP/HSRP virtual addresses and their physical members (protected by mu)
+	rollupMu            sync.Mutex         // Protects rollups, rollupDays and rollupLoc (kept apart from mu: written on every ping cycle)
+	rollups             map[string][]DailyRollup // Per-device daily ping rollups, oldest first
+	rollupDays          int                // Days of rollups kept per device (0 = disabled)
+	rollupLoc           *time.Location     // Timezone that defines day boundaries
+	rttMu               sync.Mutex         // Protects rttHistory and rttHistorySize (kept apart from mu: written on every ping cycle)
+	rttHistory          map[string]*rttRing // Per-device recent ping cycles
+	rttHistorySize      int                // Ping cycles kept per device (0 = disabled)
+	tombstones          map[string]*Tombstone // Recently removed devices by IP (nil = tombstones disabled, protected by mu)
+	tombstoneTTL        time.Duration      // How long a removed device can be restored
+	quarantine          map[string]*QuarantineEntry // Quarantined and excluded devices by IP (nil = quarantine disabled, protected by mu)
+	tripTimes           map[string][]time.Time      // Recent circuit breaker trips per device, oldest first (protected by mu)
+	quarantineTrips     int                // Trips within quarantineWindow that quarantine a device
+	quarantineWindow    time.Duration      // Window over which trips are counted
+	networkResolver     func(ip string) string // Resolves the configured network of new devices (nil = untagged)
+	classifier          Classifier             // Derives DeviceType from SNMP data and open ports (nil = unclassified)
+	tagger              Tagger                 // Derives Tags from the IP and hostname (nil = no custom tags)
+	osInferrer          OSInferrer             // Derives OSFamily from sysDescr, reply TTL and open ports (nil = disabled)
+	churn               Churn                  // Devices added and removed since creation (protected by mu)
+	networkChurn        map[string]*Churn      // churn per configured network (protected by mu)
 }
 
 // NewManager creates a new device state manager with heap-based LRU eviction
@@ -70,9 +131,10 @@ func NewManager(maxDevices int) *Manager {
 		maxDevices = 10000 // Default if not specified
 	}
 	m := &Manager{
-		devices:      make(map[string]*Device),
-		evictionHeap: make(deviceHeap, 0, maxDevices),
-		maxDevices:   maxDevices,
+		devices:       make(map[string]*Device),
+		evictionHeap:  make(deviceHeap, 0, maxDevices),
+		maxDevices:    maxDevices,
+		downThreshold: DefaultDownThreshold,
 	}
 	heap.Init(&m.evictionHeap)
 	return m
@@ -85,6 +147,9 @@ func (m *Manager) Add(device Device) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	// The supplied device supersedes any tombstone
+	delete(m.tombstones, device.IP)
+
 	// If device already exists, update it
 	if existing, exists := m.devices[device.IP]; exists {
 		now := time.Now()
@@ -137,6 +202,53 @@ func (m *Manager) Add(device Device) {
 			m.snmpSuspendedCount.Add(-1) // SNMP polling no longer suspended
 		}
 		
+		// Keep the cached SNMP result unless the caller supplies a new one
+		if device.SNMPResult == nil {
+			device.SNMPResult = existing.SNMPResult
+		}
+		// Likewise keep the reachability state tracked from ping results
+		if device.Reachability == "" {
+			device.Reachability = existing.Reachability
+			device.ReachabilitySince = existing.ReachabilitySince
+			device.DownFails = existing.DownFails
+		}
+		// And the ARP-learned hardware address
+		if device.MAC == "" {
+			device.MAC = existing.MAC
+			device.PassiveDiscovery = existing.PassiveDiscovery
+		}
+		if device.Network == "" {
+			device.Network = existing.Network
+		}
+		// And the system group with its reboot history, which only SNMP polls update
+		if device.SystemPolledAt.IsZero() {
+			device.System = existing.System
+			device.SystemPolledAt = existing.SystemPolledAt
+			device.LastReboot = existing.LastReboot
+		}
+		// And the open ports, which only the classification probe updates
+		if device.PortsProbedAt.IsZero() {
+			device.OpenPorts = existing.OpenPorts
+			device.PortsProbedAt = existing.PortsProbedAt
+		}
+		// And the UPnP description, which only SSDP discovery updates
+		if device.UPnPSeenAt.IsZero() {
+			device.UPnP = existing.UPnP
+			device.UPnPSeenAt = existing.UPnPSeenAt
+		}
+		// And the reply TTL, which only OS fingerprinting updates
+		if device.TTLProbedAt.IsZero() {
+			device.ReplyTTL = existing.ReplyTTL
+			device.TTLProbedAt = existing.TTLProbedAt
+		}
+		// And the last written device_info, which only SNMP polls update
+		if device.InfoWrittenAt.IsZero() {
+			device.Info = existing.Info
+			device.InfoHash = existing.InfoHash
+			device.InfoWrittenAt = existing.InfoWrittenAt
+		}
+		m.classifyLocked(&device)
+
 		// Update device fields
 		oldLastSeen := existing.LastSeen
 		*existing = device
@@ -165,6 +277,7 @@ func (m *Manager) Add(device Device) {
 			}
 			
 			delete(m.devices, oldest.IP)
+			m.countChurnLocked(oldest, func(c *Churn) { c.Evicted++ })
 		}
 	}
 
@@ -192,12 +305,19 @@ func (m *Manager) Add(device Device) {
 		m.snmpSuspendedCount.Add(1)
 	}
 	
+	if device.Network == "" {
+		device.Network = m.resolveNetworkLocked(device.IP)
+	}
+	m.classifyLocked(&device)
+	m.restoreEscalationLocked(&device)
+
 	devicePtr := &device
 	m.devices[device.IP] = devicePtr
 	heap.Push(&m.evictionHeap, devicePtr)
 }
 
 // AddDevice adds a device by IP address only, returns true if it's a new device
+// A device removed within the tombstone TTL is restored with its prior metadata and is not new
 // Uses min-heap for O(log n) eviction instead of O(n) iteration
 func (m *Manager) AddDevice(ip string) bool {
 	m.mu.Lock()
@@ -225,17 +345,27 @@ func (m *Manager) AddDevice(ip string) bool {
 			}
 			
 			delete(m.devices, oldest.IP)
+			m.countChurnLocked(oldest, func(c *Churn) { c.Evicted++ })
 		}
 	}
 
+	// Restore a recently removed device
+	if m.restoreLocked(ip, time.Now()) {
+		m.countChurnLocked(m.devices[ip], func(c *Churn) { c.Rediscovered++ })
+		return false
+	}
+
 	// Add the new device with minimal info
 	device := &Device{
 		IP:       ip,
 		Hostname: ip,
 		LastSeen: time.Now(),
+		Network:  m.resolveNetworkLocked(ip),
 	}
+	m.restoreEscalationLocked(device)
 	m.devices[ip] = device
 	heap.Push(&m.evictionHeap, device)
+	m.countChurnLocked(device, func(c *Churn) { c.Discovered++ })
 	return true
 }
 
@@ -281,6 +411,7 @@ func (m *Manager) UpdateDeviceSNMP(ip, hostname, sysDescr string) {
 		dev.Hostname = hostname
 		dev.SysDescr = sysDescr
 		dev.LastSeen = time.Now()
+		m.classifyLocked(dev)
 		// Update heap position since LastSeen changed (O(log n))
 		if dev.heapIndex >= 0 {
 			heap.Fix(&m.evictionHeap, dev.heapIndex)
@@ -300,17 +431,36 @@ func (m *Manager) GetAllIPs() []string {
 }
 
 // Prune removes devices not seen within the specified duration
-// Removes devices from both the map and heap
+// Removes devices from both the map and heap, leaving tombstones when enabled
 func (m *Manager) Prune(olderThan time.Duration) []Device {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	var removed []Device
 	cutoff := time.Now().Add(-olderThan)
+	return m.removeLocked(RemovedStale, func(dev *Device) bool {
+		return dev.LastSeen.Before(cutoff)
+	})
+}
+
+// RemoveWhere removes every device for which match returns true (e.g. devices in a disabled network)
+// Removes devices from both the map and heap, leaving tombstones with the given reason when enabled
+func (m *Manager) RemoveWhere(reason string, match func(Device) bool) []Device {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.removeLocked(reason, func(dev *Device) bool {
+		return match(*dev)
+	})
+}
+
+// removeLocked deletes matching devices, tombstones them and rebuilds the eviction heap
+// Caller must hold m.mu for writing
+func (m *Manager) removeLocked(reason string, match func(*Device) bool) []Device {
+	var removed []Device
+	now := time.Now()
 	
 	// Collect devices to remove
 	var toRemove []*Device
 	for ip, dev := range m.devices {
-		if dev.LastSeen.Before(cutoff) {
+		if match(dev) {
 			removed = append(removed, *dev)
 			toRemove = append(toRemove, dev)
 			
@@ -328,7 +478,9 @@ func (m *Manager) Prune(olderThan time.Duration) []Device {
 				m.snmpSuspendedCount.Add(-1)
 			}
 			
+			m.buryLocked(dev, reason, now)
 			delete(m.devices, ip)
+			m.countChurnLocked(dev, func(c *Churn) { c.Pruned++ })
 		}
 	}
 	
@@ -361,10 +513,15 @@ func (m *Manager) Count() int {
 }
 
 // ReportPingSuccess resets circuit breaker state on successful ping
+// Also marks the device up, emitting a transition event if it was down
 func (m *Manager) ReportPingSuccess(ip string) {
+	var event *StateEvent
+	defer func() { m.publishStateEvent(event) }() // Runs after the unlock below
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	if dev, exists := m.devices[ip]; exists {
+		event = m.trackReachability(dev, true, false)
+		m.recordPingOutcomeLocked(dev, false)
 		// If SuspendedUntil is set (device was suspended at some point), decrement counter
 		// This handles both active suspensions and expired ones
 		if !dev.SuspendedUntil.IsZero() {
@@ -372,12 +529,27 @@ func (m *Manager) ReportPingSuccess(ip string) {
 		}
 		dev.ConsecutiveFails = 0
 		dev.SuspendedUntil = time.Time{} // Zero time (not suspended)
+		dev.ProbeAt = time.Time{}
+		if len(m.backoffSteps) == 0 {
+			dev.BackoffLevel = 0 // Backoff steps decay with time instead, so repeat offenders keep escalating
+		}
 	}
 }
 
 // ReportPingFail increments failure count and suspends device if threshold reached
+// The threshold is maxFails consecutive failures, or the failed share of the window with SetPingFailureWindow
 // Returns true if the device was suspended (circuit breaker tripped)
+// Also marks the device down after the down threshold (or when suspended), emitting a transition event
 func (m *Manager) ReportPingFail(ip string, maxFails int, backoff time.Duration) bool {
+	var event *StateEvent
+	var suspended *Device
+	var quarantined *QuarantineEntry
+	defer func() {
+		// Runs after the unlock below
+		m.publishStateEvent(event)
+		m.publishSuspension(suspended)
+		m.publishQuarantine(quarantined)
+	}()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	dev, exists := m.devices[ip]
@@ -386,19 +558,31 @@ func (m *Manager) ReportPingFail(ip string, maxFails int, backoff time.Duration)
 	}
 
 	dev.ConsecutiveFails++
+	tripped := dev.ConsecutiveFails >= maxFails
+	if m.failureWindow > 0 {
+		tripped = m.recordPingOutcomeLocked(dev, true) >= m.failureWindowTrip
+	}
+	event = m.trackReachability(dev, false, tripped)
 	
 	// Check if we've reached the threshold
-	if dev.ConsecutiveFails >= maxFails {
+	if tripped {
 		// Check if device is already actively suspended
 		wasAlreadySuspended := !dev.SuspendedUntil.IsZero() && time.Now().Before(dev.SuspendedUntil)
 		
 		// Trip the circuit breaker
 		dev.ConsecutiveFails = 0 // Reset counter
-		dev.SuspendedUntil = time.Now().Add(backoff)
+		dev.PingWindow = 0       // And the window, so the device starts over after the backoff
+		if !wasAlreadySuspended {
+			m.escalateLocked(dev, time.Now())
+		}
+		m.suspendLocked(dev, backoff, time.Now())
 		
 		// Only increment counter if device was NOT already suspended
 		if !wasAlreadySuspended {
 			m.suspendedCount.Add(1) // Increment atomic counter
+			snapshot := *dev
+			suspended = &snapshot
+			quarantined = m.recordTripLocked(dev, time.Now())
 		}
 		
 		return true // Device is now suspended
@@ -0,0 +1,115 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// tripDevice reports failures until the circuit breaker trips and releases the device again
+func tripDevice(t *testing.T, mgr *Manager, ip string) time.Duration {
+	t.Helper()
+	before := time.Now()
+	if !mgr.ReportPingFail(ip, 1, 5*time.Minute) {
+		t.Fatal("expected the circuit breaker to trip")
+	}
+	dev, _ := mgr.Get(ip)
+	suspension := dev.SuspendedUntil.Sub(before).Round(time.Second)
+	mgr.ReportPingSuccess(ip)
+	return suspension
+}
+
+// TestBackoffStepsEscalateRepeatOffenders verifies repeat trips climb the steps and stay at the last one
+func TestBackoffStepsEscalateRepeatOffenders(t *testing.T) {
+	mgr := NewManager(10)
+	mgr.SetBackoffSteps([]time.Duration{time.Minute, 5 * time.Minute, 30 * time.Minute, 4 * time.Hour}, time.Hour)
+	mgr.Add(Device{IP: "10.0.0.1", LastSeen: time.Now()})
+
+	for i, want := range []time.Duration{time.Minute, 5 * time.Minute, 30 * time.Minute, 4 * time.Hour, 4 * time.Hour} {
+		if got := tripDevice(t, mgr, "10.0.0.1"); got != want {
+			t.Errorf("trip %d: expected a %v suspension, got %v", i+1, want, got)
+		}
+	}
+	dev, _ := mgr.Get("10.0.0.1")
+	if dev.BackoffLevel != 3 {
+		t.Errorf("expected the answered device to keep level 3, got %d", dev.BackoffLevel)
+	}
+	breaker, _ := mgr.Breaker("10.0.0.1", 5*time.Minute, time.Now())
+	if breaker.NextBackoff != 4*time.Hour || breaker.Escalation.Level != 3 {
+		t.Errorf("expected the next trip at the last step, got %+v", breaker)
+	}
+
+	// A device without a trip for the reset period starts over
+	breaker, _ = mgr.Breaker("10.0.0.1", 5*time.Minute, time.Now().Add(2*time.Hour))
+	if breaker.NextBackoff != time.Minute {
+		t.Errorf("expected the first step after the reset period, got %v", breaker.NextBackoff)
+	}
+	dev.LastTrip = time.Now().Add(-2 * time.Hour) // Get returns the managed device
+	if got := tripDevice(t, mgr, "10.0.0.1"); got != time.Minute {
+		t.Errorf("expected a 1m suspension after the reset period, got %v", got)
+	}
+}
+
+// TestBackoffStepsHalfOpen verifies unanswered test pings move one step up and scale the test ping delay
+func TestBackoffStepsHalfOpen(t *testing.T) {
+	mgr := NewManager(10)
+	mgr.SetBackoffSteps([]time.Duration{time.Minute, 5 * time.Minute}, time.Hour)
+	mgr.SetHalfOpenProbing(30*time.Second, time.Hour)
+	mgr.Add(Device{IP: "10.0.0.1", LastSeen: time.Now()})
+	mgr.ReportPingFail("10.0.0.1", 1, 5*time.Minute)
+
+	for range 2 {
+		before := time.Now()
+		until := mgr.ReportHalfOpenFail("10.0.0.1", 5*time.Minute)
+		if got := until.Sub(before).Round(time.Second); got != 5*time.Minute {
+			t.Errorf("expected the 5m step, got %v", got)
+		}
+		dev, _ := mgr.Get("10.0.0.1")
+		if got := dev.ProbeAt.Sub(before).Round(time.Second); got != 150*time.Second {
+			t.Errorf("expected the test ping half way into the suspension, got %v", got)
+		}
+	}
+}
+
+// TestBackoffPersistence verifies escalation levels survive a restart, including for devices not rediscovered yet
+func TestBackoffPersistence(t *testing.T) {
+	steps := []time.Duration{time.Minute, 5 * time.Minute, 30 * time.Minute}
+	mgr := NewManager(10)
+	mgr.SetBackoffSteps(steps, time.Hour)
+	mgr.Add(Device{IP: "10.0.0.1", LastSeen: time.Now()})
+	mgr.AddDevice("10.0.0.2")
+	tripDevice(t, mgr, "10.0.0.1")
+	tripDevice(t, mgr, "10.0.0.1")
+	tripDevice(t, mgr, "10.0.0.2")
+	path := filepath.Join(t.TempDir(), "backoff.json")
+	if err := mgr.SaveBackoff(path); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	restarted := NewManager(10)
+	restarted.SetBackoffSteps(steps, time.Hour)
+	if err := restarted.LoadBackoff(path); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	restarted.AddDevice("10.0.0.1")
+	if got := tripDevice(t, restarted, "10.0.0.1"); got != 30*time.Minute {
+		t.Errorf("expected the restored device to escalate to 30m, got %v", got)
+	}
+
+	// 10.0.0.2 was not rediscovered; a save keeps its escalation for the next restart
+	if err := restarted.SaveBackoff(path); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	again := NewManager(10)
+	again.SetBackoffSteps(steps, time.Hour)
+	if err := again.LoadBackoff(path); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	again.Add(Device{IP: "10.0.0.2", LastSeen: time.Now()})
+	if dev, _ := again.Get("10.0.0.2"); dev.LastTrip.IsZero() {
+		t.Error("expected the escalation of a device not rediscovered to be kept")
+	}
+	if err := again.LoadBackoff(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Errorf("expected a missing file to be ignored, got %v", err)
+	}
+}
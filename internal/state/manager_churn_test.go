@@ -0,0 +1,59 @@
+package state
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestChurnCounters verifies discovered, rediscovered, pruned and evicted devices are counted in total and per network
+func TestChurnCounters(t *testing.T) {
+	m := NewManager(3)
+	m.EnableTombstones(time.Hour)
+	m.SetNetworkResolver(func(ip string) string {
+		if strings.HasPrefix(ip, "10.0.1.") {
+			return "office"
+		}
+		return "10.0.2.0/24"
+	})
+
+	for _, ip := range []string{"10.0.1.1", "10.0.1.2", "10.0.2.1"} {
+		if !m.AddDevice(ip) {
+			t.Fatalf("expected %s to be new", ip)
+		}
+	}
+	m.AddDevice("10.0.1.1") // Already known: no churn
+
+	// Stale device pruned, then seen again within the tombstone TTL
+	m.Add(Device{IP: "10.0.1.2", LastSeen: time.Now().Add(-25 * time.Hour)})
+	if pruned := m.Prune(24 * time.Hour); len(pruned) != 1 {
+		t.Fatalf("expected one pruned device, got %d", len(pruned))
+	}
+	if m.AddDevice("10.0.1.2") {
+		t.Fatal("expected the pruned device to be restored")
+	}
+
+	// A fourth device evicts the least recently seen one
+	m.Add(Device{IP: "10.0.2.1", LastSeen: time.Now().Add(-time.Hour)})
+	if !m.AddDevice("10.0.2.2") {
+		t.Fatal("expected 10.0.2.2 to be new")
+	}
+
+	total, networks := m.Churn()
+	if want := (Churn{Discovered: 4, Rediscovered: 1, Pruned: 1, Evicted: 1}); total != want {
+		t.Errorf("expected totals %+v, got %+v", want, total)
+	}
+	if want := (Churn{Discovered: 2, Rediscovered: 1, Pruned: 1}); networks["office"] != want {
+		t.Errorf("expected office churn %+v, got %+v", want, networks["office"])
+	}
+	if want := (Churn{Discovered: 2, Evicted: 1}); networks["10.0.2.0/24"] != want {
+		t.Errorf("expected 10.0.2.0/24 churn %+v, got %+v", want, networks["10.0.2.0/24"])
+	}
+
+	if delta := total.Sub(Churn{Discovered: 3}); delta != (Churn{Discovered: 1, Rediscovered: 1, Pruned: 1, Evicted: 1}) || delta.Zero() {
+		t.Errorf("unexpected churn delta %+v", delta)
+	}
+	if !total.Sub(total).Zero() {
+		t.Error("expected no churn between identical readings")
+	}
+}
@@ -0,0 +1,95 @@
+package state
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// testClassifier types printers by sysDescr or port 9100 and routers by sysObjectID
+func testClassifier(sysDescr, sysObjectID string, openPorts []int) string {
+	switch {
+	case strings.Contains(sysDescr, "LaserJet"):
+		return "printer"
+	case strings.HasPrefix(sysObjectID, "1.3.6.1.4.1.9."):
+		return "router"
+	}
+	for _, port := range openPorts {
+		if port == 9100 {
+			return "printer"
+		}
+	}
+	return ""
+}
+
+// TestDeviceClassification verifies devices are reclassified as SNMP data and open ports change
+func TestDeviceClassification(t *testing.T) {
+	m := NewManager(10)
+	m.SetClassifier(testClassifier)
+
+	m.AddDevice("10.0.0.1")
+	if got := m.DeviceType("10.0.0.1"); got != "" {
+		t.Errorf("expected an unclassified new device, got %q", got)
+	}
+
+	m.UpdateDeviceSNMP("10.0.0.1", "printer-1", "HP LaserJet M607")
+	if got := m.DeviceType("10.0.0.1"); got != "printer" {
+		t.Errorf("expected printer from sysDescr, got %q", got)
+	}
+
+	m.AddDevice("10.0.0.2")
+	m.UpdateDeviceSystem("10.0.0.2", SystemInfo{ObjectID: "1.3.6.1.4.1.9.1.1208"}, time.Now())
+	if got := m.DeviceType("10.0.0.2"); got != "router" {
+		t.Errorf("expected router from sysObjectID, got %q", got)
+	}
+
+	m.AddDevice("10.0.0.3")
+	if got := m.UpdateDevicePorts("10.0.0.3", []int{22, 9100}, time.Now()); got != "printer" {
+		t.Errorf("expected printer from open ports, got %q", got)
+	}
+	if got := m.UpdateDevicePorts("10.0.0.99", []int{9100}, time.Now()); got != "" {
+		t.Errorf("expected unknown devices to be ignored, got %q", got)
+	}
+
+	// Re-adding a device without port or system data keeps both and its type
+	m.Add(Device{IP: "10.0.0.3", Hostname: "10.0.0.3", LastSeen: time.Now()})
+	dev, _ := m.Get("10.0.0.3")
+	if len(dev.OpenPorts) != 2 || dev.PortsProbedAt.IsZero() || dev.DeviceType != "printer" {
+		t.Errorf("expected open ports and type to survive Add, got %+v", dev)
+	}
+
+	// Without a classifier devices stay unclassified
+	plain := NewManager(10)
+	plain.AddDevice("10.0.0.1")
+	plain.UpdateDeviceSNMP("10.0.0.1", "printer-1", "HP LaserJet M607")
+	if got := plain.DeviceType("10.0.0.1"); got != "" {
+		t.Errorf("expected no type without a classifier, got %q", got)
+	}
+}
+
+// TestDeviceTags verifies custom tags are derived when a device is added and recomputed when its hostname changes
+func TestDeviceTags(t *testing.T) {
+	m := NewManager(10)
+	m.SetTagger(func(ip, hostname string) map[string]string {
+		if strings.HasPrefix(hostname, "core-") {
+			return map[string]string{"tier": "core"}
+		}
+		return nil
+	})
+
+	m.AddDevice("10.0.0.1")
+	if tags := m.Tags("10.0.0.1"); tags != nil {
+		t.Errorf("expected no tags before the hostname is known, got %v", tags)
+	}
+	m.UpdateDeviceSNMP("10.0.0.1", "core-sw1", "Cisco IOS")
+	if tags := m.Tags("10.0.0.1"); tags["tier"] != "core" {
+		t.Errorf("expected tier=core from the hostname, got %v", tags)
+	}
+	m.Add(Device{IP: "10.0.0.2", Hostname: "core-rtr1", LastSeen: time.Now()})
+	if dev, _ := m.Get("10.0.0.2"); dev.Tags["tier"] != "core" {
+		t.Errorf("expected tags on an added device, got %+v", dev.Tags)
+	}
+	if tags := m.Tags("10.0.0.99"); tags != nil {
+		t.Errorf("expected no tags for an unknown device, got %v", tags)
+	}
+}
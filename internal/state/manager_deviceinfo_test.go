@@ -0,0 +1,98 @@
+package state
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestRecordDeviceInfo verifies device_info is written on the first poll, on changes and after refresh,
+// and that changes reach the handler with their old and new values
+func TestRecordDeviceInfo(t *testing.T) {
+	mgr := NewManager(10)
+	mgr.AddDevice("10.0.0.1")
+	var changes [][]InfoChange
+	mgr.SetInfoChangeHandler(func(ip, hostname string, c []InfoChange) {
+		if ip != "10.0.0.1" || hostname != "sw1-new" {
+			t.Errorf("unexpected handler call for %s (%s)", ip, hostname)
+		}
+		changes = append(changes, c)
+	})
+
+	start := time.Now()
+	info := DeviceInfo{Hostname: "sw1", SysDescr: "IOS 15.2", Location: "FRA1", Fields: map[string]string{"firmware": "15.2"}}
+	if !mgr.RecordDeviceInfo("10.0.0.1", info, start, time.Hour) {
+		t.Error("expected the first poll to be written")
+	}
+	same := info
+	same.Fields = map[string]string{"firmware": "15.2"}
+	if mgr.RecordDeviceInfo("10.0.0.1", same, start.Add(10*time.Minute), time.Hour) {
+		t.Error("expected an unchanged poll within refresh not to be written")
+	}
+	if !mgr.RecordDeviceInfo("10.0.0.1", same, start.Add(time.Hour), time.Hour) {
+		t.Error("expected an unchanged poll after refresh to be written")
+	}
+	if len(changes) != 0 {
+		t.Fatalf("expected no changes yet, got %v", changes)
+	}
+
+	changed := DeviceInfo{Hostname: "sw1-new", SysDescr: "IOS 15.2", Location: "FRA1", Fields: map[string]string{"model": "C2960"}}
+	if !mgr.RecordDeviceInfo("10.0.0.1", changed, start.Add(70*time.Minute), time.Hour) {
+		t.Error("expected a changed poll to be written")
+	}
+	want := []InfoChange{
+		{Field: "hostname", Old: "sw1", New: "sw1-new"},
+		{Field: "firmware", Old: "15.2", New: ""},
+		{Field: "model", Old: "", New: "C2960"},
+	}
+	if len(changes) != 1 || !reflect.DeepEqual(changes[0], want) {
+		t.Errorf("expected changes %v, got %v", want, changes)
+	}
+
+	// Discovery updates keep the last written device_info
+	mgr.Add(Device{IP: "10.0.0.1", Hostname: "sw1-new", LastSeen: time.Now()})
+	if mgr.RecordDeviceInfo("10.0.0.1", changed, start.Add(80*time.Minute), time.Hour) {
+		t.Error("expected device_info to be kept across discovery updates")
+	}
+
+	if !mgr.RecordDeviceInfo("10.0.0.9", info, start, time.Hour) {
+		t.Error("expected unknown devices to be written")
+	}
+}
+
+// TestRecordDeviceInfoEveryPoll verifies refresh 0 writes every poll without reporting unchanged polls
+func TestRecordDeviceInfoEveryPoll(t *testing.T) {
+	mgr := NewManager(10)
+	mgr.AddDevice("10.0.0.1")
+	calls := 0
+	mgr.SetInfoChangeHandler(func(ip, hostname string, c []InfoChange) { calls++ })
+
+	info := DeviceInfo{Hostname: "sw1", SysDescr: "IOS 15.2"}
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		if !mgr.RecordDeviceInfo("10.0.0.1", info, now.Add(time.Duration(i)*time.Second), 0) {
+			t.Errorf("poll %d: expected a write with refresh 0", i)
+		}
+	}
+	if calls != 0 {
+		t.Errorf("expected no change events, got %d", calls)
+	}
+}
+
+// TestDeviceInfoHash verifies the hash covers every value and ignores custom field order
+func TestDeviceInfoHash(t *testing.T) {
+	base := DeviceInfo{Hostname: "sw1", SysDescr: "IOS", Fields: map[string]string{"a": "1", "b": "2"}}
+	reordered := DeviceInfo{Hostname: "sw1", SysDescr: "IOS", Fields: map[string]string{"b": "2", "a": "1"}}
+	if base.Hash() != reordered.Hash() {
+		t.Error("expected equal values to hash equally")
+	}
+	for _, other := range []DeviceInfo{
+		{Hostname: "sw1", SysDescr: "IOS", Contact: "noc", Fields: base.Fields},
+		{Hostname: "sw1", SysDescr: "IOS", Fields: map[string]string{"a": "1", "b": "3"}},
+		{Hostname: "sw1IOS", Fields: base.Fields}, // Values must not run into each other
+	} {
+		if other.Hash() == base.Hash() {
+			t.Errorf("expected %+v to hash differently", other)
+		}
+	}
+}
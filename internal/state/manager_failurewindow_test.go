@@ -0,0 +1,81 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+// TestWindowedFailuresTripFlappingDevice verifies a device failing every other cycle trips the windowed breaker
+// but never the consecutive one
+func TestWindowedFailuresTripFlappingDevice(t *testing.T) {
+	flap := func(mgr *Manager, cycles int) (trippedAt int) {
+		for i := 1; i <= cycles; i++ {
+			if i%2 == 0 {
+				mgr.ReportPingSuccess("10.0.0.1")
+				continue
+			}
+			if mgr.ReportPingFail("10.0.0.1", 3, time.Minute) {
+				return i
+			}
+		}
+		return 0
+	}
+
+	consecutive := NewManager(10)
+	consecutive.Add(Device{IP: "10.0.0.1", LastSeen: time.Now()})
+	if at := flap(consecutive, 100); at != 0 {
+		t.Errorf("expected consecutive mode never to trip on alternating results, tripped at cycle %d", at)
+	}
+
+	// Half of the last 10 cycles failing trips a 0.5 ratio once five failures are in the window
+	windowed := NewManager(10)
+	windowed.SetPingFailureWindow(10, 0.5)
+	windowed.Add(Device{IP: "10.0.0.1", LastSeen: time.Now()})
+	if at := flap(windowed, 100); at != 9 {
+		t.Errorf("expected the windowed breaker to trip at cycle 9, got %d", at)
+	}
+	if !windowed.IsSuspended("10.0.0.1") {
+		t.Error("expected the device to be suspended")
+	}
+	if dev, _ := windowed.Get("10.0.0.1"); dev.PingWindow != 0 {
+		t.Errorf("expected the window to start over after a trip, got %b", dev.PingWindow)
+	}
+}
+
+// TestWindowedFailuresForgetOldCycles verifies failures that slid out of the window no longer count
+func TestWindowedFailuresForgetOldCycles(t *testing.T) {
+	mgr := NewManager(10)
+	mgr.SetPingFailureWindow(5, 0.7) // 4 of 5 cycles
+	mgr.Add(Device{IP: "10.0.0.1", LastSeen: time.Now()})
+
+	// fail, fail, fail, ok, ok, ok, fail, fail: the first failures have left the window by the last cycle
+	results := []bool{false, false, false, true, true, true, false, false}
+	for i, ok := range results {
+		if ok {
+			mgr.ReportPingSuccess("10.0.0.1")
+		} else if mgr.ReportPingFail("10.0.0.1", 100, time.Minute) {
+			t.Fatalf("unexpected trip at cycle %d", i+1)
+		}
+	}
+	if mgr.ReportPingFail("10.0.0.1", 100, time.Minute) {
+		t.Fatal("unexpected trip with 3 failures in the window")
+	}
+	if !mgr.ReportPingFail("10.0.0.1", 100, time.Minute) {
+		t.Error("expected a trip with 4 failures in the window")
+	}
+}
+
+// TestWindowedFailuresIgnoreConsecutiveLimit verifies the consecutive limit does not apply in windowed mode
+func TestWindowedFailuresIgnoreConsecutiveLimit(t *testing.T) {
+	mgr := NewManager(10)
+	mgr.SetPingFailureWindow(MaxFailureWindow, 1)
+	mgr.Add(Device{IP: "10.0.0.1", LastSeen: time.Now()})
+	for i := 1; i < MaxFailureWindow; i++ {
+		if mgr.ReportPingFail("10.0.0.1", 1, time.Minute) {
+			t.Fatalf("unexpected trip at failure %d", i)
+		}
+	}
+	if !mgr.ReportPingFail("10.0.0.1", 1, time.Minute) {
+		t.Errorf("expected a trip once all %d cycles failed", MaxFailureWindow)
+	}
+}
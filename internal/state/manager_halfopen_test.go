@@ -0,0 +1,65 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+// TestHalfOpenBackoffEscalation verifies unanswered test pings double the suspension up to the cap
+func TestHalfOpenBackoffEscalation(t *testing.T) {
+	mgr := NewManager(10)
+	mgr.SetHalfOpenProbing(time.Minute, 20*time.Minute)
+	mgr.Add(Device{IP: "10.0.0.1", LastSeen: time.Now()})
+
+	before := time.Now()
+	if !mgr.ReportPingFail("10.0.0.1", 1, 5*time.Minute) {
+		t.Fatal("expected the circuit breaker to trip")
+	}
+	dev, _ := mgr.Get("10.0.0.1")
+	if got := dev.ProbeAt.Sub(before).Round(time.Second); got != time.Minute {
+		t.Errorf("expected the test ping 1m into the suspension, got %v", got)
+	}
+	if mgr.ClaimHalfOpenProbe("10.0.0.1", time.Now()) {
+		t.Error("expected no test ping before ping_half_open_after")
+	}
+	if !mgr.ClaimHalfOpenProbe("10.0.0.1", dev.ProbeAt) {
+		t.Fatal("expected the test ping to be due")
+	}
+	if mgr.ClaimHalfOpenProbe("10.0.0.1", dev.ProbeAt) {
+		t.Error("expected a single test ping per suspension")
+	}
+
+	// 10m, then 20m (capped); the test ping keeps the same share of each suspension
+	for _, want := range []time.Duration{10 * time.Minute, 20 * time.Minute, 20 * time.Minute} {
+		before = time.Now()
+		until := mgr.ReportHalfOpenFail("10.0.0.1", 5*time.Minute)
+		if got := until.Sub(before).Round(time.Second); got != want {
+			t.Errorf("expected a %v suspension, got %v", want, got)
+		}
+		dev, _ = mgr.Get("10.0.0.1")
+		if got := dev.ProbeAt.Sub(before).Round(time.Second); got != want/5 {
+			t.Errorf("expected the test ping %v into the suspension, got %v", want/5, got)
+		}
+	}
+
+	// An answer closes the breaker and starts the escalation over
+	mgr.ReportPingSuccess("10.0.0.1")
+	dev, _ = mgr.Get("10.0.0.1")
+	if mgr.IsSuspended("10.0.0.1") || dev.BackoffLevel != 0 || !dev.ProbeAt.IsZero() {
+		t.Errorf("expected the device released with a clean backoff, got %+v", dev)
+	}
+	if mgr.GetSuspendedCount() != 0 {
+		t.Errorf("expected no suspended devices, got %d", mgr.GetSuspendedCount())
+	}
+}
+
+// TestHalfOpenDisabled verifies suspensions keep their fixed backoff without test pings by default
+func TestHalfOpenDisabled(t *testing.T) {
+	mgr := NewManager(10)
+	mgr.Add(Device{IP: "10.0.0.1", LastSeen: time.Now()})
+	mgr.ReportPingFail("10.0.0.1", 1, 5*time.Minute)
+	dev, _ := mgr.Get("10.0.0.1")
+	if !dev.ProbeAt.IsZero() || mgr.ClaimHalfOpenProbe("10.0.0.1", time.Now().Add(4*time.Minute)) {
+		t.Error("expected no test ping without SetHalfOpenProbing")
+	}
+}
@@ -0,0 +1,40 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+// TestDeviceNetwork verifies devices record their network when added and keep it across updates
+func TestDeviceNetwork(t *testing.T) {
+	mgr := NewManager(10)
+	mgr.AddDevice("10.0.0.1") // Added before the resolver: resolved on lookup
+	mgr.SetNetworkResolver(func(ip string) string {
+		if ip == "192.168.1.9" {
+			return ""
+		}
+		return "hq"
+	})
+
+	mgr.AddDevice("10.0.0.2")
+	mgr.Add(Device{IP: "10.0.0.3", LastSeen: time.Now()})
+	mgr.AddDevice("192.168.1.9")
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		if got := mgr.Network(ip); got != "hq" {
+			t.Errorf("%s: expected network hq, got %q", ip, got)
+		}
+	}
+	if got := mgr.Network("192.168.1.9"); got != "" {
+		t.Errorf("expected no network outside the configured networks, got %q", got)
+	}
+	if got := mgr.Network("10.9.9.9"); got != "" {
+		t.Errorf("expected no network for an unknown device, got %q", got)
+	}
+
+	// An update without a network keeps the recorded one
+	mgr.SetNetworkResolver(nil)
+	mgr.Add(Device{IP: "10.0.0.2", Hostname: "sw1", LastSeen: time.Now()})
+	if got := mgr.Network("10.0.0.2"); got != "hq" {
+		t.Errorf("expected the network to survive an update, got %q", got)
+	}
+}
@@ -0,0 +1,56 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+// testOSInferrer reports windows for port 135 or TTL 128, linux from sysDescr and unix for TTL 64
+func testOSInferrer(sysDescr string, replyTTL int, openPorts []int) string {
+	switch {
+	case sysDescr == "Linux":
+		return "linux"
+	case len(openPorts) > 0 && openPorts[0] == 135, replyTTL > 64:
+		return "windows"
+	case replyTTL > 0:
+		return "unix"
+	}
+	return ""
+}
+
+// TestDeviceOSFamily verifies the OS family is re-inferred as the reply TTL, ports and sysDescr change
+func TestDeviceOSFamily(t *testing.T) {
+	m := NewManager(10)
+	m.SetOSInferrer(testOSInferrer)
+
+	m.AddDevice("10.0.0.1")
+	if got := m.UpdateDeviceTTL("10.0.0.1", 63, time.Now()); got != "unix" {
+		t.Errorf("expected unix from TTL 63, got %q", got)
+	}
+	m.UpdateDeviceSNMP("10.0.0.1", "nas", "Linux")
+	if got := m.OSFamily("10.0.0.1"); got != "linux" {
+		t.Errorf("expected sysDescr to refine the family to linux, got %q", got)
+	}
+
+	m.AddDevice("10.0.0.2")
+	m.UpdateDevicePorts("10.0.0.2", []int{135}, time.Now())
+	if got := m.OSFamily("10.0.0.2"); got != "windows" {
+		t.Errorf("expected windows from open ports, got %q", got)
+	}
+	if got := m.UpdateDeviceTTL("10.0.0.99", 128, time.Now()); got != "" {
+		t.Errorf("expected unknown devices to be ignored, got %q", got)
+	}
+
+	// Re-adding a device without a probed TTL keeps the TTL, so a family is still inferred
+	m.Add(Device{IP: "10.0.0.1", Hostname: "nas", LastSeen: time.Now()})
+	if dev, _ := m.Get("10.0.0.1"); dev.ReplyTTL != 63 || dev.OSFamily == "" {
+		t.Errorf("expected the reply TTL to survive Add, got %+v", dev)
+	}
+
+	// Without an inferrer no family is set
+	plain := NewManager(10)
+	plain.AddDevice("10.0.0.1")
+	if got := plain.UpdateDeviceTTL("10.0.0.1", 128, time.Now()); got != "" {
+		t.Errorf("expected no family without an inferrer, got %q", got)
+	}
+}
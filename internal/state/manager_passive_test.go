@@ -0,0 +1,32 @@
+package state
+
+import "testing"
+
+// TestAddPassiveDevice verifies passively seen devices are flagged and known devices only get their MAC
+func TestAddPassiveDevice(t *testing.T) {
+	m := NewManager(100)
+
+	if !m.AddPassiveDevice("192.168.1.30", "02:00:00:00:00:03") {
+		t.Fatal("expected new device")
+	}
+	dev, _ := m.Get("192.168.1.30")
+	if !dev.PassiveDiscovery || dev.MAC != "02:00:00:00:00:03" {
+		t.Errorf("expected passive device with MAC, got %+v", *dev)
+	}
+
+	m.AddDevice("192.168.1.40")
+	if m.AddPassiveDevice("192.168.1.40", "02:00:00:00:00:04") {
+		t.Error("expected known device not to be reported as new")
+	}
+	dev, _ = m.Get("192.168.1.40")
+	if dev.PassiveDiscovery || dev.MAC != "02:00:00:00:00:04" {
+		t.Errorf("expected swept device to keep its flag and get the MAC, got %+v", *dev)
+	}
+
+	// Add without a MAC keeps the ARP-learned one
+	m.Add(Device{IP: "192.168.1.30", Hostname: "printer"})
+	dev, _ = m.Get("192.168.1.30")
+	if dev.MAC != "02:00:00:00:00:03" || !dev.PassiveDiscovery {
+		t.Errorf("expected MAC and flag to survive Add, got %+v", *dev)
+	}
+}
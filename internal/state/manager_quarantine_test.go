@@ -0,0 +1,107 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// tripBreaker trips the ping circuit breaker of a device once; the suspension ends right away
+func tripBreaker(m *Manager, ip string) {
+	m.ReportPingFail(ip, 1, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+}
+
+// TestQuarantineAfterRepeatedTrips verifies a device is quarantined on its Nth trip, reported once, and can be
+// excluded and released
+func TestQuarantineAfterRepeatedTrips(t *testing.T) {
+	m := NewManager(100)
+	m.EnableQuarantine(3, time.Hour)
+	var reported []QuarantineEntry
+	m.SetQuarantineHandler(func(entry QuarantineEntry) { reported = append(reported, entry) })
+	m.Add(Device{IP: "192.168.1.50", Hostname: "printer-2"})
+
+	tripBreaker(m, "192.168.1.50")
+	tripBreaker(m, "192.168.1.50")
+	if m.IsQuarantined("192.168.1.50") {
+		t.Fatal("expected no quarantine before the third trip")
+	}
+	tripBreaker(m, "192.168.1.50")
+	if !m.IsQuarantined("192.168.1.50") {
+		t.Fatal("expected the device to be quarantined on the third trip")
+	}
+	tripBreaker(m, "192.168.1.50")
+	if len(reported) != 1 || reported[0].Trips != 3 || reported[0].Status != QuarantineHeld || reported[0].Hostname != "printer-2" {
+		t.Fatalf("expected one quarantine report with 3 trips, got %+v", reported)
+	}
+
+	entry, ok := m.ExcludeQuarantined("192.168.1.50", time.Now())
+	if !ok || entry.Status != QuarantineExcluded || entry.ExcludedAt == nil {
+		t.Errorf("expected the device to be excluded, got %+v", entry)
+	}
+	if _, ok := m.ExcludeQuarantined("192.168.1.51", time.Now()); ok {
+		t.Error("expected a device that is not quarantined not to be excluded")
+	}
+
+	if !m.ReleaseQuarantine("192.168.1.50") || m.IsQuarantined("192.168.1.50") {
+		t.Fatal("expected the device to be released")
+	}
+	tripBreaker(m, "192.168.1.50")
+	if m.IsQuarantined("192.168.1.50") {
+		t.Error("expected the trip history to start over after release")
+	}
+}
+
+// TestQuarantineWindow verifies trips older than the window are not counted
+func TestQuarantineWindow(t *testing.T) {
+	m := NewManager(100)
+	m.EnableQuarantine(2, time.Hour)
+	m.Add(Device{IP: "192.168.1.50"})
+
+	m.mu.Lock()
+	m.tripTimes["192.168.1.50"] = []time.Time{time.Now().Add(-2 * time.Hour)}
+	m.mu.Unlock()
+	tripBreaker(m, "192.168.1.50")
+	if m.IsQuarantined("192.168.1.50") {
+		t.Error("expected a trip outside the window not to count")
+	}
+	tripBreaker(m, "192.168.1.50")
+	if !m.IsQuarantined("192.168.1.50") {
+		t.Error("expected two trips within the window to quarantine the device")
+	}
+}
+
+// TestQuarantinePersistence verifies the list and pending trips survive a save and load
+func TestQuarantinePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quarantine.json")
+	m := NewManager(100)
+	m.EnableQuarantine(2, time.Hour)
+	m.Add(Device{IP: "192.168.1.50"})
+	m.Add(Device{IP: "192.168.1.60"})
+	tripBreaker(m, "192.168.1.50")
+	tripBreaker(m, "192.168.1.50")
+	m.ExcludeQuarantined("192.168.1.50", time.Now())
+	tripBreaker(m, "192.168.1.60")
+	if err := m.SaveQuarantine(path); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+
+	restored := NewManager(100)
+	restored.EnableQuarantine(2, time.Hour)
+	if err := restored.LoadQuarantine(path); err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	list := restored.Quarantined()
+	if len(list) != 1 || list[0].IP != "192.168.1.50" || list[0].Status != QuarantineExcluded {
+		t.Fatalf("expected the excluded device to be restored, got %+v", list)
+	}
+	restored.Add(Device{IP: "192.168.1.60"})
+	tripBreaker(restored, "192.168.1.60")
+	if !restored.IsQuarantined("192.168.1.60") {
+		t.Error("expected the saved trip to count toward quarantine after loading")
+	}
+
+	if err := NewManager(100).LoadQuarantine(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Errorf("expected a missing file not to be an error, got %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+package state
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestReachabilityTransitions verifies up/down events are emitted only on real transitions
+func TestReachabilityTransitions(t *testing.T) {
+	mgr := NewManager(10)
+	mgr.SetDownThreshold(3)
+	mgr.Add(Device{IP: "192.168.1.1", Hostname: "router1", LastSeen: time.Now()})
+
+	var mu sync.Mutex
+	var handled []StateEvent
+	mgr.SetStateChangeHandler(func(ev StateEvent) {
+		mu.Lock()
+		handled = append(handled, ev)
+		mu.Unlock()
+	})
+
+	// First success establishes "up" without an event
+	mgr.ReportPingSuccess("192.168.1.1")
+	mgr.ReportPingSuccess("192.168.1.1")
+	if got := len(mgr.RecentStateEvents("", 10)); got != 0 {
+		t.Fatalf("expected no events for initial up, got %d", got)
+	}
+
+	// Two failures stay below the threshold, the third marks the device down
+	mgr.ReportPingFail("192.168.1.1", 10, time.Minute)
+	mgr.ReportPingFail("192.168.1.1", 10, time.Minute)
+	if mgr.GetDownCount() != 0 {
+		t.Fatal("device should not be down before the threshold")
+	}
+	mgr.ReportPingFail("192.168.1.1", 10, time.Minute)
+	mgr.ReportPingFail("192.168.1.1", 10, time.Minute) // Still down, no new event
+	if mgr.GetDownCount() != 1 {
+		t.Fatal("device should be down after 3 failures")
+	}
+
+	mgr.ReportPingSuccess("192.168.1.1")
+
+	events := mgr.RecentStateEvents("", 10)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d: %+v", len(events), events)
+	}
+	up, down := events[0], events[1] // Newest first
+	if down.State != ReachabilityDown || down.Previous != ReachabilityUp || down.Failures != 3 || down.Hostname != "router1" {
+		t.Errorf("unexpected down event: %+v", down)
+	}
+	if up.State != ReachabilityUp || up.Previous != ReachabilityDown || up.PreviousSince != down.Time {
+		t.Errorf("unexpected up event: %+v", up)
+	}
+	if up.PreviousDuration() < 0 {
+		t.Errorf("outage duration should not be negative, got %v", up.PreviousDuration())
+	}
+	if mgr.GetDownCount() != 0 {
+		t.Error("device should be up again")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(handled) != 2 || handled[0].State != ReachabilityDown || handled[1].State != ReachabilityUp {
+		t.Errorf("handler should see down then up, got %+v", handled)
+	}
+}
+
+// TestReachabilityUnknownToDown verifies a device that never answered is reported down
+func TestReachabilityUnknownToDown(t *testing.T) {
+	mgr := NewManager(10)
+	mgr.SetDownThreshold(2)
+	mgr.AddDevice("192.168.1.2")
+
+	mgr.ReportPingFail("192.168.1.2", 10, time.Minute)
+	mgr.ReportPingFail("192.168.1.2", 10, time.Minute)
+
+	events := mgr.RecentStateEvents("192.168.1.2", 10)
+	if len(events) != 1 || events[0].Previous != ReachabilityUnknown || !events[0].PreviousSince.IsZero() {
+		t.Fatalf("expected one unknown->down event, got %+v", events)
+	}
+	if events[0].PreviousDuration() != 0 {
+		t.Errorf("unknown previous state should have no duration, got %v", events[0].PreviousDuration())
+	}
+}
+
+// TestReachabilityCircuitBreakerForcesDown verifies suspension marks a device down below the threshold
+func TestReachabilityCircuitBreakerForcesDown(t *testing.T) {
+	mgr := NewManager(10)
+	mgr.SetDownThreshold(5)
+	mgr.AddDevice("192.168.1.3")
+	mgr.ReportPingSuccess("192.168.1.3")
+
+	mgr.ReportPingFail("192.168.1.3", 2, time.Minute)
+	if !mgr.ReportPingFail("192.168.1.3", 2, time.Minute) {
+		t.Fatal("expected circuit breaker to trip")
+	}
+	if mgr.GetDownCount() != 1 {
+		t.Error("suspended device should be reported down")
+	}
+}
+
+// TestReachabilitySurvivesAdd verifies re-adding a device does not reset its reachability
+func TestReachabilitySurvivesAdd(t *testing.T) {
+	mgr := NewManager(10)
+	mgr.SetDownThreshold(1)
+	mgr.Add(Device{IP: "192.168.1.4", LastSeen: time.Now()})
+	mgr.ReportPingFail("192.168.1.4", 10, time.Minute)
+
+	mgr.Add(Device{IP: "192.168.1.4", Hostname: "renamed", LastSeen: time.Now()})
+	dev, _ := mgr.Get("192.168.1.4")
+	if dev.Reachability != ReachabilityDown {
+		t.Errorf("expected reachability to be preserved, got %q", dev.Reachability)
+	}
+}
+
+// TestRecentStateEventsBounded verifies the history keeps only the newest events
+func TestRecentStateEventsBounded(t *testing.T) {
+	mgr := NewManager(10)
+	mgr.SetDownThreshold(1)
+	mgr.AddDevice("192.168.1.5")
+
+	for i := 0; i < maxStateEvents/2+10; i++ {
+		mgr.ReportPingFail("192.168.1.5", 1000, time.Minute)
+		mgr.ReportPingSuccess("192.168.1.5")
+	}
+
+	events := mgr.RecentStateEvents("", maxStateEvents*2)
+	if len(events) != maxStateEvents {
+		t.Fatalf("expected %d events, got %d", maxStateEvents, len(events))
+	}
+	if events[0].State != ReachabilityUp {
+		t.Errorf("newest event should be the last up transition, got %+v", events[0])
+	}
+	if got := len(mgr.RecentStateEvents("", 5)); got != 5 {
+		t.Errorf("expected limit to apply, got %d", got)
+	}
+}
+
+// TestSuspensionHandler verifies the handler fires once per circuit breaker trip, outside the lock
+func TestSuspensionHandler(t *testing.T) {
+	mgr := NewManager(10)
+	mgr.Add(Device{IP: "192.168.1.2", Hostname: "ap1", LastSeen: time.Now()})
+
+	var calls []time.Time
+	mgr.SetSuspensionHandler(func(ip, hostname string, until time.Time) {
+		if ip != "192.168.1.2" || hostname != "ap1" {
+			t.Errorf("unexpected suspension for %s (%s)", ip, hostname)
+		}
+		mgr.IsSuspended(ip) // Would deadlock if called with the manager lock held
+		calls = append(calls, until)
+	})
+
+	for i := 0; i < 3; i++ {
+		mgr.ReportPingFail("192.168.1.2", 3, time.Minute)
+	}
+	if len(calls) != 1 {
+		t.Fatalf("expected 1 suspension, got %d", len(calls))
+	}
+	if time.Until(calls[0]) <= 0 {
+		t.Error("suspension end should be in the future")
+	}
+
+	// Failures while already suspended do not notify again
+	for i := 0; i < 3; i++ {
+		mgr.ReportPingFail("192.168.1.2", 3, time.Minute)
+	}
+	if len(calls) != 1 {
+		t.Errorf("expected no repeat notification while suspended, got %d calls", len(calls))
+	}
+}
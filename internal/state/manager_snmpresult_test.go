@@ -0,0 +1,46 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSNMPResultCache validates results are cached per device, survive re-adds and are dropped with the device
+func TestSNMPResultCache(t *testing.T) {
+	mgr := NewManager(10)
+	mgr.Add(Device{IP: "192.168.1.1", LastSeen: time.Now()})
+
+	if result, found := mgr.GetSNMPResult("192.168.1.1"); !found || result != nil {
+		t.Fatalf("expected known device without result, got %v, %v", result, found)
+	}
+	if _, found := mgr.GetSNMPResult("192.168.1.2"); found {
+		t.Fatal("expected unknown device not to be found")
+	}
+
+	// Results for unknown devices are ignored
+	mgr.SetSNMPResult("192.168.1.2", &SNMPResult{PolledAt: time.Now()})
+	if mgr.Count() != 1 {
+		t.Errorf("expected SetSNMPResult not to add devices, got %d", mgr.Count())
+	}
+
+	polledAt := time.Now()
+	mgr.SetSNMPResult("192.168.1.1", &SNMPResult{
+		PolledAt: polledAt,
+		Values:   []SNMPValue{{Group: "system", Name: "sysName", Value: "router1", PolledAt: polledAt}},
+	})
+	result, found := mgr.GetSNMPResult("192.168.1.1")
+	if !found || result == nil || len(result.Values) != 1 || result.Values[0].Value != "router1" {
+		t.Fatalf("expected cached result, got %+v", result)
+	}
+
+	// Re-adding the device (e.g. rediscovery) keeps the cached result
+	mgr.Add(Device{IP: "192.168.1.1", LastSeen: time.Now()})
+	if result, _ := mgr.GetSNMPResult("192.168.1.1"); result == nil {
+		t.Error("expected cached result to survive re-add")
+	}
+
+	mgr.RemoveWhere(RemovedStale, func(Device) bool { return true })
+	if _, found := mgr.GetSNMPResult("192.168.1.1"); found {
+		t.Error("expected result to be dropped with the device")
+	}
+}
@@ -0,0 +1,77 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+// TestDetectReboot verifies sysUpTime readings are compared with the time between polls
+func TestDetectReboot(t *testing.T) {
+	polled := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		previous time.Duration
+		current  time.Duration
+		elapsed  time.Duration
+		want     bool
+	}{
+		{"uptime advanced", 10 * time.Hour, 11 * time.Hour, time.Hour, false},
+		{"uptime reset", 10 * time.Hour, 5 * time.Minute, time.Hour, true},
+		{"rebooted and ran longer than before", 10 * time.Minute, 50 * time.Minute, time.Hour, true},
+		{"within tolerance", 10 * time.Hour, 10*time.Hour + 59*time.Minute + 30*time.Second, time.Hour, false},
+		{"counter wrapped", sysUpTimeWrap - 10*time.Minute, 50 * time.Minute, time.Hour, false},
+		{"no previous reading", 0, 5 * time.Minute, time.Hour, false},
+		{"no current reading", 10 * time.Hour, 0, time.Hour, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reboot, got := detectReboot(tt.previous, polled, tt.current, polled.Add(tt.elapsed))
+			if got != tt.want {
+				t.Fatalf("expected reboot=%v, got %v", tt.want, got)
+			}
+			if got && !reboot.At.Equal(polled.Add(tt.elapsed).Add(-tt.current)) {
+				t.Errorf("unexpected reboot time %v", reboot.At)
+			}
+		})
+	}
+}
+
+// TestUpdateDeviceSystem verifies system values are stored, kept across updates and reboots reported once
+func TestUpdateDeviceSystem(t *testing.T) {
+	mgr := NewManager(10)
+	mgr.AddDevice("10.0.0.1")
+	var reboots []Reboot
+	mgr.SetRebootHandler(func(ip, hostname string, reboot Reboot) {
+		reboots = append(reboots, reboot)
+	})
+
+	start := time.Now()
+	info := SystemInfo{ObjectID: "1.3.6.1.4.1.9.1.1208", UpTime: 48 * time.Hour, Location: "FRA1 rack 12", Contact: "noc@example.com"}
+	if mgr.UpdateDeviceSystem("10.0.0.1", info, start) {
+		t.Error("first reading must not count as a reboot")
+	}
+	if mgr.UpdateDeviceSystem("10.0.0.9", info, start) {
+		t.Error("unknown devices must be ignored")
+	}
+
+	// Discovery updates without system values keep the polled ones
+	mgr.Add(Device{IP: "10.0.0.1", Hostname: "sw1", LastSeen: time.Now()})
+	dev, _ := mgr.Get("10.0.0.1")
+	if dev.System != info {
+		t.Errorf("expected system values to survive an update, got %+v", dev.System)
+	}
+
+	info.UpTime = 2 * time.Minute
+	if !mgr.UpdateDeviceSystem("10.0.0.1", info, start.Add(time.Hour)) || len(reboots) != 1 {
+		t.Fatalf("expected one reboot, got %d", len(reboots))
+	}
+	dev, _ = mgr.Get("10.0.0.1")
+	if !dev.LastReboot.Equal(start.Add(time.Hour - 2*time.Minute)) {
+		t.Errorf("unexpected last reboot %v", dev.LastReboot)
+	}
+
+	info.UpTime = 62 * time.Minute
+	if mgr.UpdateDeviceSystem("10.0.0.1", info, start.Add(2*time.Hour)) || len(reboots) != 1 {
+		t.Errorf("expected no further reboots, got %d", len(reboots))
+	}
+}
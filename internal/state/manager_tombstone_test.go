@@ -0,0 +1,125 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+// TestTombstoneRestore verifies a pruned device that reappears within the TTL keeps its metadata
+// and is not reported as new
+func TestTombstoneRestore(t *testing.T) {
+	m := NewManager(100)
+	m.EnableTombstones(time.Hour)
+
+	m.Add(Device{
+		IP:               "192.168.1.10",
+		Hostname:         "switch-1",
+		SysDescr:         "Cisco IOS",
+		LastSeen:         time.Now().Add(-25 * time.Hour),
+		ConsecutiveFails: 2,
+		MAC:              "02:00:00:00:00:10",
+		Reachability:     "down",
+	})
+	pruned := m.Prune(24 * time.Hour)
+	if len(pruned) != 1 || m.Count() != 0 {
+		t.Fatalf("expected the device to be pruned, got %d pruned, %d left", len(pruned), m.Count())
+	}
+
+	tombs := m.Tombstones()
+	if len(tombs) != 1 || tombs[0].IP != "192.168.1.10" || tombs[0].Reason != RemovedStale {
+		t.Fatalf("expected one stale tombstone, got %+v", tombs)
+	}
+
+	if m.AddDevice("192.168.1.10") {
+		t.Error("expected a restored device not to be reported as new")
+	}
+	dev, ok := m.Get("192.168.1.10")
+	if !ok {
+		t.Fatal("expected the device to be back in state")
+	}
+	if dev.Hostname != "switch-1" || dev.SysDescr != "Cisco IOS" || dev.MAC != "02:00:00:00:00:10" || dev.Reachability != "down" {
+		t.Errorf("expected prior metadata to be restored, got %+v", *dev)
+	}
+	if dev.ConsecutiveFails != 0 || time.Since(dev.LastSeen) > time.Minute {
+		t.Errorf("expected fresh LastSeen and circuit breaker state, got %+v", *dev)
+	}
+	if len(m.Tombstones()) != 0 {
+		t.Error("expected the tombstone to be consumed")
+	}
+
+	// The restored device is in the eviction heap and can be pruned again
+	m.Add(Device{IP: "192.168.1.10", Hostname: "switch-1", LastSeen: time.Now().Add(-25 * time.Hour)})
+	if pruned := m.Prune(24 * time.Hour); len(pruned) != 1 {
+		t.Errorf("expected the restored device to be prunable, got %d pruned", len(pruned))
+	}
+}
+
+// TestTombstoneExpiry verifies expired tombstones are not restored and are dropped
+func TestTombstoneExpiry(t *testing.T) {
+	m := NewManager(100)
+	m.EnableTombstones(time.Hour)
+
+	m.Add(Device{IP: "10.0.0.1", Hostname: "old", LastSeen: time.Now().Add(-25 * time.Hour)})
+	m.Add(Device{IP: "10.0.0.2", Hostname: "older", LastSeen: time.Now().Add(-25 * time.Hour)})
+	m.Prune(24 * time.Hour)
+
+	if dropped := m.PruneTombstones(time.Now()); dropped != 0 {
+		t.Errorf("expected no tombstone to expire yet, dropped %d", dropped)
+	}
+	if dropped := m.PruneTombstones(time.Now().Add(2 * time.Hour)); dropped != 2 {
+		t.Errorf("expected both tombstones to expire, dropped %d", dropped)
+	}
+	if !m.AddDevice("10.0.0.1") {
+		t.Error("expected a device without tombstone to be new")
+	}
+	if dev, _ := m.Get("10.0.0.1"); dev.Hostname != "10.0.0.1" {
+		t.Errorf("expected fresh metadata, got hostname %q", dev.Hostname)
+	}
+}
+
+// TestTombstonesDisabled verifies removed devices are forgotten without a TTL
+func TestTombstonesDisabled(t *testing.T) {
+	m := NewManager(100)
+	m.Add(Device{IP: "10.0.0.1", Hostname: "router", LastSeen: time.Now()})
+	m.RemoveWhere(RemovedOverlap, func(Device) bool { return true })
+
+	if len(m.Tombstones()) != 0 {
+		t.Error("expected no tombstones while disabled")
+	}
+	if !m.AddDevice("10.0.0.1") {
+		t.Error("expected the device to be new again")
+	}
+}
+
+// TestTombstoneSupersededByAdd verifies a full Add replaces the tombstone instead of restoring it
+func TestTombstoneSupersededByAdd(t *testing.T) {
+	m := NewManager(100)
+	m.EnableTombstones(time.Hour)
+	m.Add(Device{IP: "10.0.0.1", Hostname: "router", LastSeen: time.Now()})
+	m.RemoveWhere(RemovedOverlap, func(Device) bool { return true })
+
+	tombs := m.Tombstones()
+	if len(tombs) != 1 || tombs[0].Reason != RemovedOverlap {
+		t.Fatalf("expected one overlap tombstone, got %+v", tombs)
+	}
+	m.Add(Device{IP: "10.0.0.1", Hostname: "router-2", LastSeen: time.Now()})
+	if len(m.Tombstones()) != 0 {
+		t.Error("expected Add to clear the tombstone")
+	}
+}
+
+// TestTombstoneCap verifies tombstones are capped at the device limit
+func TestTombstoneCap(t *testing.T) {
+	m := NewManager(2)
+	m.EnableTombstones(time.Hour)
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2"} {
+		m.Add(Device{IP: ip, Hostname: ip, LastSeen: time.Now()})
+	}
+	m.RemoveWhere(RemovedStale, func(Device) bool { return true })
+	m.Add(Device{IP: "10.0.0.3", Hostname: "10.0.0.3", LastSeen: time.Now()})
+	m.RemoveWhere(RemovedStale, func(Device) bool { return true })
+
+	if n := len(m.Tombstones()); n != 2 {
+		t.Errorf("expected 2 tombstones at the cap, got %d", n)
+	}
+}
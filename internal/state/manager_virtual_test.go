@@ -0,0 +1,65 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+// TestUpdateVirtualAddresses verifies members are linked to virtual IPs and failovers are detected
+func TestUpdateVirtualAddresses(t *testing.T) {
+	mgr := NewManager(10)
+
+	mgr.UpdateVirtualAddresses("10.0.0.2", []VirtualAddress{{IP: "10.0.0.1", Protocol: ProtocolVRRP, Group: 10, Role: "master", Active: true}})
+	mgr.UpdateVirtualAddresses("10.0.0.3", []VirtualAddress{{IP: "10.0.0.1", Protocol: ProtocolVRRP, Group: 10, Role: "backup"}})
+
+	vip, ok := mgr.GetVirtualIP("10.0.0.1")
+	if !ok || len(vip.Members) != 2 || vip.ActiveMember != "10.0.0.2" {
+		t.Fatalf("unexpected virtual IP: %+v", vip)
+	}
+	if mgr.VirtualProtocol("10.0.0.1") != ProtocolVRRP || mgr.VirtualProtocol("10.0.0.2") != "" {
+		t.Error("only the virtual address should resolve to a protocol")
+	}
+
+	// The backup takes over
+	failovers := mgr.UpdateVirtualAddresses("10.0.0.3", []VirtualAddress{{IP: "10.0.0.1", Protocol: ProtocolVRRP, Group: 10, Role: "master", Active: true}})
+	if len(failovers) != 1 || failovers[0].From != "10.0.0.2" || failovers[0].To != "10.0.0.3" {
+		t.Fatalf("expected failover 10.0.0.2 -> 10.0.0.3, got %+v", failovers)
+	}
+
+	// Members that stop reporting the address are unlinked; the last one removes the virtual IP
+	mgr.UpdateVirtualAddresses("10.0.0.2", nil)
+	if vip, _ := mgr.GetVirtualIP("10.0.0.1"); len(vip.Members) != 1 {
+		t.Errorf("expected 1 member left, got %+v", vip.Members)
+	}
+	mgr.UpdateVirtualAddresses("10.0.0.3", nil)
+	if _, ok := mgr.GetVirtualIP("10.0.0.1"); ok {
+		t.Error("virtual IP without members should be removed")
+	}
+}
+
+// TestVirtualIPFailoverNotDown verifies a virtual IP is not reported down while a member is up
+func TestVirtualIPFailoverNotDown(t *testing.T) {
+	mgr := NewManager(10)
+	mgr.SetDownThreshold(2)
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2"} {
+		mgr.Add(Device{IP: ip, LastSeen: time.Now()})
+		mgr.ReportPingSuccess(ip)
+	}
+	mgr.UpdateVirtualAddresses("10.0.0.2", []VirtualAddress{{IP: "10.0.0.1", Protocol: ProtocolHSRP, Group: 1, Role: "active", Active: true}})
+
+	// Virtual IP stops answering during failover; its member is still up
+	for i := 0; i < 3; i++ {
+		mgr.ReportPingFail("10.0.0.1", 10, time.Minute)
+	}
+	if got := mgr.GetDownCount(); got != 0 {
+		t.Fatalf("virtual IP should not be down while a member is up, got %d down", got)
+	}
+
+	// Once the member is down too, the virtual IP is reported down
+	mgr.ReportPingFail("10.0.0.2", 10, time.Minute)
+	mgr.ReportPingFail("10.0.0.2", 10, time.Minute)
+	mgr.ReportPingFail("10.0.0.1", 10, time.Minute)
+	if got := mgr.GetDownCount(); got != 2 {
+		t.Errorf("expected member and virtual IP down, got %d", got)
+	}
+}
@@ -0,0 +1,32 @@
+package state
+
+// SetNetworkResolver sets how the configured network of a device is determined when it is added
+// Call before devices are added; devices already in state are resolved on their first Network lookup
+func (m *Manager) SetNetworkResolver(resolve func(ip string) string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.networkResolver = resolve
+}
+
+// Network returns the configured network (CIDR or label) a device belongs to, "" for unknown devices
+// Used at write time to tag points with the device's network
+func (m *Manager) Network(ip string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	dev, exists := m.devices[ip]
+	if !exists {
+		return ""
+	}
+	if dev.Network == "" {
+		return m.resolveNetworkLocked(ip)
+	}
+	return dev.Network
+}
+
+// resolveNetworkLocked resolves ip with the network resolver; caller must hold m.mu
+func (m *Manager) resolveNetworkLocked(ip string) string {
+	if m.networkResolver == nil {
+		return ""
+	}
+	return m.networkResolver(ip)
+}
@@ -0,0 +1,17 @@
+package state
+
+// AddPassiveDevice adds a device seen in ARP traffic, flagged as passively discovered, and records its MAC
+// For known devices only the MAC is updated; returns true if the device is new
+func (m *Manager) AddPassiveDevice(ip, mac string) bool {
+	isNew := m.AddDevice(ip)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if dev, exists := m.devices[ip]; exists {
+		dev.MAC = mac
+		if isNew {
+			dev.PassiveDiscovery = true
+		}
+	}
+	return isNew
+}
@@ -0,0 +1,242 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Quarantine statuses
+const (
+	QuarantineHeld     = "quarantined" // Tripped the circuit breaker too often; awaiting operator review
+	QuarantineExcluded = "excluded"    // Permanently excluded by an operator
+)
+
+// QuarantineEntry is a device that is no longer probed because its ping circuit breaker tripped
+// quarantine_trips times within quarantine_window. Entries are kept until an operator releases them
+type QuarantineEntry struct {
+	IP            string     `json:"ip"`
+	Hostname      string     `json:"hostname"`
+	Status        string     `json:"status"`     // quarantined or excluded
+	Trips         int        `json:"trips"`      // Circuit breaker trips within the window when quarantined
+	FirstTrip     time.Time  `json:"first_trip"` // Oldest of those trips
+	QuarantinedAt time.Time  `json:"quarantined_at"`
+	ExcludedAt    *time.Time `json:"excluded_at,omitempty"` // When an operator excluded the device permanently
+}
+
+// quarantineFile is the on-disk format of SaveQuarantine
+type quarantineFile struct {
+	Devices []QuarantineEntry      `json:"devices"`
+	Trips   map[string][]time.Time `json:"trips"` // Recent circuit breaker trips of devices not quarantined yet
+}
+
+// EnableQuarantine quarantines devices whose ping circuit breaker trips trips times within window (trips 0 = disabled)
+func (m *Manager) EnableQuarantine(trips int, window time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.quarantineTrips = trips
+	m.quarantineWindow = window
+	if trips <= 0 {
+		m.quarantine, m.tripTimes = nil, nil
+	} else if m.quarantine == nil {
+		m.quarantine = make(map[string]*QuarantineEntry)
+		m.tripTimes = make(map[string][]time.Time)
+	}
+}
+
+// SetQuarantineHandler registers a callback for every device that is quarantined
+// The handler is called without the manager lock held and must not block
+func (m *Manager) SetQuarantineHandler(handler func(QuarantineEntry)) {
+	m.eventsMu.Lock()
+	defer m.eventsMu.Unlock()
+	m.quarantineHandler = handler
+}
+
+// IsQuarantined reports whether a device is quarantined or excluded and must not be probed
+func (m *Manager) IsQuarantined(ip string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, quarantined := m.quarantine[ip]
+	return quarantined
+}
+
+// Quarantined returns the quarantined and excluded devices, oldest first
+func (m *Manager) Quarantined() []QuarantineEntry {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result := make([]QuarantineEntry, 0, len(m.quarantine))
+	for _, entry := range m.quarantine {
+		result = append(result, *entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].QuarantinedAt.Equal(result[j].QuarantinedAt) {
+			return result[i].QuarantinedAt.Before(result[j].QuarantinedAt)
+		}
+		return result[i].IP < result[j].IP
+	})
+	return result
+}
+
+// ReleaseQuarantine returns a quarantined or excluded device to monitoring with a clean trip history
+// Returns false if the device is not quarantined
+func (m *Manager) ReleaseQuarantine(ip string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.quarantine[ip]; !ok {
+		return false
+	}
+	delete(m.quarantine, ip)
+	delete(m.tripTimes, ip)
+	return true
+}
+
+// ExcludeQuarantined marks a quarantined device as permanently excluded; it stays unprobed until released
+// Returns the updated entry, or false if the device is not quarantined
+func (m *Manager) ExcludeQuarantined(ip string, now time.Time) (QuarantineEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.quarantine[ip]
+	if !ok {
+		return QuarantineEntry{}, false
+	}
+	if entry.Status != QuarantineExcluded {
+		entry.Status = QuarantineExcluded
+		entry.ExcludedAt = &now
+	}
+	return *entry, true
+}
+
+// recordTripLocked counts a circuit breaker trip and quarantines the device once it tripped
+// quarantineTrips times within quarantineWindow; returns the new entry (nil = not quarantined)
+// Caller must hold m.mu for writing
+func (m *Manager) recordTripLocked(dev *Device, now time.Time) *QuarantineEntry {
+	if m.quarantine == nil {
+		return nil
+	}
+	if _, ok := m.quarantine[dev.IP]; ok {
+		return nil
+	}
+	trips := append(recentTrips(m.tripTimes[dev.IP], now.Add(-m.quarantineWindow)), now)
+	if len(trips) < m.quarantineTrips {
+		m.tripTimes[dev.IP] = trips
+		return nil
+	}
+
+	delete(m.tripTimes, dev.IP)
+	entry := &QuarantineEntry{
+		IP:            dev.IP,
+		Hostname:      dev.Hostname,
+		Status:        QuarantineHeld,
+		Trips:         len(trips),
+		FirstTrip:     trips[0],
+		QuarantinedAt: now,
+	}
+	m.quarantine[dev.IP] = entry
+	snapshot := *entry
+	return &snapshot
+}
+
+// recentTrips drops trips at or before cutoff; trips are oldest first
+func recentTrips(trips []time.Time, cutoff time.Time) []time.Time {
+	keep := 0
+	for keep < len(trips) && !trips[keep].After(cutoff) {
+		keep++
+	}
+	return append([]time.Time(nil), trips[keep:]...)
+}
+
+// publishQuarantine calls the quarantine handler for a new entry; nil is ignored
+// Must be called WITHOUT m.mu held
+func (m *Manager) publishQuarantine(entry *QuarantineEntry) {
+	if entry == nil {
+		return
+	}
+
+	m.eventsMu.Lock()
+	handler := m.quarantineHandler
+	m.eventsMu.Unlock()
+
+	if handler != nil {
+		handler(*entry)
+	}
+}
+
+// SaveQuarantine writes the quarantine list and recent trips to path atomically (write to a temp file, then rename)
+func (m *Manager) SaveQuarantine(path string) error {
+	m.mu.RLock()
+	file := quarantineFile{Trips: make(map[string][]time.Time, len(m.tripTimes))}
+	cutoff := time.Now().Add(-m.quarantineWindow)
+	for ip, trips := range m.tripTimes {
+		if recent := recentTrips(trips, cutoff); len(recent) > 0 {
+			file.Trips[ip] = recent
+		}
+	}
+	m.mu.RUnlock()
+	file.Devices = m.Quarantined()
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("failed to save quarantine: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".netscan-quarantine-*")
+	if err != nil {
+		return fmt.Errorf("failed to save quarantine: %v", err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to save quarantine: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to save quarantine: %v", err)
+	}
+	return nil
+}
+
+// LoadQuarantine restores the list saved by SaveQuarantine; a missing file is not an error
+// Call after EnableQuarantine; trips outside the current window are dropped
+func (m *Manager) LoadQuarantine(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load quarantine: %v", err)
+	}
+	var file quarantineFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to load quarantine from %s: %v", path, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.quarantine == nil {
+		return nil
+	}
+	for i := range file.Devices {
+		entry := file.Devices[i]
+		if entry.IP == "" || (entry.Status != QuarantineHeld && entry.Status != QuarantineExcluded) {
+			continue
+		}
+		m.quarantine[entry.IP] = &entry
+	}
+	cutoff := time.Now().Add(-m.quarantineWindow)
+	for ip, trips := range file.Trips {
+		if _, quarantined := m.quarantine[ip]; quarantined {
+			continue
+		}
+		sort.Slice(trips, func(i, j int) bool { return trips[i].Before(trips[j]) })
+		if recent := recentTrips(trips, cutoff); len(recent) > 0 {
+			m.tripTimes[ip] = recent
+		}
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package state
+
+import "time"
+
+// Reachability states derived from ping results
+const (
+	ReachabilityUnknown = "unknown" // No ping result yet
+	ReachabilityUp      = "up"
+	ReachabilityDown    = "down"
+)
+
+// DefaultDownThreshold is the number of consecutive ping failures before a device is reported down
+const DefaultDownThreshold = 3
+
+// maxStateEvents bounds the in-memory transition history served by RecentStateEvents
+const maxStateEvents = 1000
+
+// StateEvent is an explicit up/down transition of a device
+type StateEvent struct {
+	IP            string    `json:"ip"`
+	Hostname      string    `json:"hostname"`
+	State         string    `json:"state"`              // "up" or "down"
+	Previous      string    `json:"previous"`           // "up", "down" or "unknown"
+	Failures      int       `json:"failures,omitempty"` // Consecutive failed pings behind a "down" transition
+	Time          time.Time `json:"time"`
+	PreviousSince time.Time `json:"previous_since,omitzero"` // When the previous state began (zero for "unknown")
+}
+
+// PreviousDuration returns how long the device was in its previous state (0 if unknown)
+func (e StateEvent) PreviousDuration() time.Duration {
+	if e.PreviousSince.IsZero() {
+		return 0
+	}
+	return e.Time.Sub(e.PreviousSince)
+}
+
+// SetDownThreshold sets the consecutive ping failures after which a device is reported down
+// Call once at startup, before any pings are reported
+func (m *Manager) SetDownThreshold(failures int) {
+	if failures < 1 {
+		failures = DefaultDownThreshold
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.downThreshold = failures
+}
+
+// SetStateChangeHandler registers a callback for every up/down transition
+// The handler runs on the reporting goroutine after the manager lock is released; it must not block
+func (m *Manager) SetStateChangeHandler(handler func(StateEvent)) {
+	m.eventsMu.Lock()
+	defer m.eventsMu.Unlock()
+	m.stateHandler = handler
+}
+
+// SetSuspensionHandler registers a callback for every ping circuit breaker trip
+// Like the state change handler it runs after the manager lock is released and must not block
+func (m *Manager) SetSuspensionHandler(handler func(ip, hostname string, until time.Time)) {
+	m.eventsMu.Lock()
+	defer m.eventsMu.Unlock()
+	m.suspendHandler = handler
+}
+
+// RecentStateEvents returns up to limit recent transitions, newest first
+// An empty ip returns transitions for all devices
+func (m *Manager) RecentStateEvents(ip string, limit int) []StateEvent {
+	m.eventsMu.Lock()
+	defer m.eventsMu.Unlock()
+
+	events := make([]StateEvent, 0)
+	for i := len(m.stateEvents) - 1; i >= 0 && len(events) < limit; i-- {
+		if ip == "" || m.stateEvents[i].IP == ip {
+			events = append(events, m.stateEvents[i])
+		}
+	}
+	return events
+}
+
+// GetDownCount returns the number of devices currently reported down
+func (m *Manager) GetDownCount() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	count := 0
+	for _, dev := range m.devices {
+		if dev.Reachability == ReachabilityDown {
+			count++
+		}
+	}
+	return count
+}
+
+// GetUpCount returns the number of devices currently reported up
+func (m *Manager) GetUpCount() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	count := 0
+	for _, dev := range m.devices {
+		if dev.Reachability == ReachabilityUp {
+			count++
+		}
+	}
+	return count
+}
+
+// trackReachability updates a device's reachability from one ping result
+// Returns the transition, or nil if the state did not change
+// A device's first success establishes "up" silently; every other change is an event
+// A VRRP/HSRP virtual IP is only reported down when none of its physical members is up
+// Must be called with m.mu lock held
+func (m *Manager) trackReachability(dev *Device, up bool, forceDown bool) *StateEvent {
+	now := time.Now()
+	previous := dev.Reachability
+	if previous == "" {
+		previous = ReachabilityUnknown
+	}
+
+	if up {
+		dev.DownFails = 0
+		if previous == ReachabilityUp {
+			return nil
+		}
+		since := dev.ReachabilitySince
+		dev.Reachability = ReachabilityUp
+		dev.ReachabilitySince = now
+		if previous == ReachabilityUnknown {
+			return nil
+		}
+		return &StateEvent{IP: dev.IP, Hostname: dev.Hostname, State: ReachabilityUp, Previous: previous, Time: now, PreviousSince: since}
+	}
+
+	dev.DownFails++
+	threshold := m.downThreshold
+	if threshold < 1 {
+		threshold = DefaultDownThreshold
+	}
+	if previous == ReachabilityDown || (dev.DownFails < threshold && !forceDown) {
+		return nil
+	}
+	if m.anyMemberUp(dev.IP) {
+		// A virtual IP that stops answering while a physical member is up is failing over, not down
+		return nil
+	}
+	since := dev.ReachabilitySince
+	dev.Reachability = ReachabilityDown
+	dev.ReachabilitySince = now
+	return &StateEvent{IP: dev.IP, Hostname: dev.Hostname, State: ReachabilityDown, Previous: previous, Failures: dev.DownFails, Time: now, PreviousSince: since}
+}
+
+// publishStateEvent records a transition and calls the handler; nil events are ignored
+// Must be called WITHOUT m.mu held
+func (m *Manager) publishStateEvent(event *StateEvent) {
+	if event == nil {
+		return
+	}
+
+	m.eventsMu.Lock()
+	if len(m.stateEvents) >= maxStateEvents {
+		// Drop the oldest event; copy keeps the backing array from growing without bound
+		copy(m.stateEvents, m.stateEvents[1:])
+		m.stateEvents = m.stateEvents[:len(m.stateEvents)-1]
+	}
+	m.stateEvents = append(m.stateEvents, *event)
+	handler := m.stateHandler
+	m.eventsMu.Unlock()
+
+	if handler != nil {
+		handler(*event)
+	}
+}
+
+// publishSuspension calls the suspension handler for a device snapshot taken at trip time; nil is ignored
+// Must be called WITHOUT m.mu held
+func (m *Manager) publishSuspension(dev *Device) {
+	if dev == nil {
+		return
+	}
+
+	m.eventsMu.Lock()
+	handler := m.suspendHandler
+	m.eventsMu.Unlock()
+
+	if handler != nil {
+		handler(dev.IP, dev.Hostname, dev.SuspendedUntil)
+	}
+}
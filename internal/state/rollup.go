@@ -0,0 +1,260 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// rollupDateLayout formats the day a rollup covers
+const rollupDateLayout = "2006-01-02"
+
+// DailyRollup summarizes one device's ping cycles for one day
+type DailyRollup struct {
+	Date        string  `json:"date"`         // Day in the configured timezone (YYYY-MM-DD)
+	Cycles      int     `json:"cycles"`       // Ping cycles run
+	UpCycles    int     `json:"up_cycles"`    // Cycles with at least one reply
+	PacketsSent int     `json:"packets_sent"` // Echo requests sent
+	PacketsRecv int     `json:"packets_recv"` // Echo replies received
+	RTTMinMs    float64 `json:"rtt_min_ms"`   // Lowest RTT of any successful cycle
+	RTTMaxMs    float64 `json:"rtt_max_ms"`   // Highest RTT of any successful cycle
+	RTTSumMs    float64 `json:"rtt_sum_ms"`   // Sum of successful cycle average RTTs (see AvgRTTMs)
+}
+
+// AvailabilityPct returns the share of cycles with at least one reply, in percent
+func (r DailyRollup) AvailabilityPct() float64 {
+	if r.Cycles == 0 {
+		return 0
+	}
+	return 100 * float64(r.UpCycles) / float64(r.Cycles)
+}
+
+// PacketLossPct returns the share of echo requests without a reply, in percent
+func (r DailyRollup) PacketLossPct() float64 {
+	if r.PacketsSent == 0 {
+		return 0
+	}
+	return 100 * float64(r.PacketsSent-r.PacketsRecv) / float64(r.PacketsSent)
+}
+
+// AvgRTTMs returns the mean RTT over successful cycles
+func (r DailyRollup) AvgRTTMs() float64 {
+	if r.UpCycles == 0 {
+		return 0
+	}
+	return r.RTTSumMs / float64(r.UpCycles)
+}
+
+// SumRollups combines several days into one rollup (Date is left empty)
+func SumRollups(days []DailyRollup) DailyRollup {
+	var total DailyRollup
+	for _, day := range days {
+		if day.UpCycles > 0 {
+			if total.UpCycles == 0 || day.RTTMinMs < total.RTTMinMs {
+				total.RTTMinMs = day.RTTMinMs
+			}
+			if day.RTTMaxMs > total.RTTMaxMs {
+				total.RTTMaxMs = day.RTTMaxMs
+			}
+		}
+		total.Cycles += day.Cycles
+		total.UpCycles += day.UpCycles
+		total.PacketsSent += day.PacketsSent
+		total.PacketsRecv += day.PacketsRecv
+		total.RTTSumMs += day.RTTSumMs
+	}
+	return total
+}
+
+// rollupFile is the on-disk format written by SaveRollups
+type rollupFile struct {
+	Devices map[string][]DailyRollup `json:"devices"`
+}
+
+// EnableRollups keeps per-device daily ping rollups for the last days days, bucketed by day in loc
+// Call once at startup, before pinging starts
+func (m *Manager) EnableRollups(days int, loc *time.Location) {
+	if loc == nil {
+		loc = time.Local
+	}
+	m.rollupMu.Lock()
+	defer m.rollupMu.Unlock()
+	m.rollupDays = days
+	m.rollupLoc = loc
+	if m.rollups == nil {
+		m.rollups = make(map[string][]DailyRollup)
+	}
+}
+
+// RollupDays returns the rollup retention in days (0 = rollups disabled)
+func (m *Manager) RollupDays() int {
+	m.rollupMu.Lock()
+	defer m.rollupMu.Unlock()
+	return m.rollupDays
+}
+
+// RecordPingCycle adds one ping cycle to the device's RTT history and to its rollup for the day of at
+// Each is a no-op when disabled; recv == 0 records a failed cycle, RTTs are only used for successful cycles
+func (m *Manager) RecordPingCycle(ip string, sent, recv int, minRtt, avgRtt, maxRtt time.Duration, at time.Time) {
+	m.recordRTTSample(ip, sent, recv, minRtt, avgRtt, maxRtt, at)
+
+	m.rollupMu.Lock()
+	defer m.rollupMu.Unlock()
+	if m.rollupDays <= 0 {
+		return
+	}
+
+	date := at.In(m.rollupLoc).Format(rollupDateLayout)
+	days := m.rollups[ip]
+	if len(days) == 0 || days[len(days)-1].Date != date {
+		days = append(days, DailyRollup{Date: date})
+		if len(days) > m.rollupDays {
+			days = append([]DailyRollup(nil), days[len(days)-m.rollupDays:]...)
+		}
+	}
+	day := &days[len(days)-1]
+
+	day.Cycles++
+	day.PacketsSent += sent
+	day.PacketsRecv += recv
+	if recv > 0 {
+		minMs, maxMs := durationMs(minRtt), durationMs(maxRtt)
+		if day.UpCycles == 0 || minMs < day.RTTMinMs {
+			day.RTTMinMs = minMs
+		}
+		if maxMs > day.RTTMaxMs {
+			day.RTTMaxMs = maxMs
+		}
+		day.RTTSumMs += durationMs(avgRtt)
+		day.UpCycles++
+	}
+	m.rollups[ip] = days
+}
+
+// GetRollups returns a copy of the device's daily rollups, oldest first (nil if none)
+func (m *Manager) GetRollups(ip string) []DailyRollup {
+	m.rollupMu.Lock()
+	defer m.rollupMu.Unlock()
+	if len(m.rollups[ip]) == 0 {
+		return nil
+	}
+	return append([]DailyRollup(nil), m.rollups[ip]...)
+}
+
+// GetAllRollups returns a copy of every device's daily rollups, oldest first
+func (m *Manager) GetAllRollups() map[string][]DailyRollup {
+	m.rollupMu.Lock()
+	defer m.rollupMu.Unlock()
+	all := make(map[string][]DailyRollup, len(m.rollups))
+	for ip, days := range m.rollups {
+		all[ip] = append([]DailyRollup(nil), days...)
+	}
+	return all
+}
+
+// RollupCutoff returns the date of the oldest day in a window of days days ending at now (today included)
+func (m *Manager) RollupCutoff(now time.Time, days int) string {
+	m.rollupMu.Lock()
+	loc := m.rollupLoc
+	m.rollupMu.Unlock()
+	if loc == nil {
+		loc = time.Local
+	}
+	local := now.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day()-days+1, 0, 0, 0, 0, loc).Format(rollupDateLayout)
+}
+
+// PruneRollups drops days outside the retention window ending at now, and devices left without any day
+// Rollups of devices that stopped being monitored are kept until they age out
+func (m *Manager) PruneRollups(now time.Time) int {
+	days := m.RollupDays()
+	if days <= 0 {
+		return 0
+	}
+	oldest := m.RollupCutoff(now, days)
+
+	m.rollupMu.Lock()
+	defer m.rollupMu.Unlock()
+	removed := 0
+	for ip, days := range m.rollups {
+		keep := sort.Search(len(days), func(i int) bool { return days[i].Date >= oldest })
+		if keep == len(days) {
+			delete(m.rollups, ip)
+			removed++
+			continue
+		}
+		if keep > 0 {
+			m.rollups[ip] = append([]DailyRollup(nil), days[keep:]...)
+		}
+	}
+	return removed
+}
+
+// SaveRollups writes all rollups to path atomically (write to a temp file, then rename)
+func (m *Manager) SaveRollups(path string) error {
+	data, err := json.Marshal(rollupFile{Devices: m.GetAllRollups()})
+	if err != nil {
+		return fmt.Errorf("failed to save rollups: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".netscan-rollups-*")
+	if err != nil {
+		return fmt.Errorf("failed to save rollups: %v", err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to save rollups: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to save rollups: %v", err)
+	}
+	return nil
+}
+
+// LoadRollups restores rollups saved by SaveRollups; a missing file is not an error
+// Call after EnableRollups; days beyond the current retention are dropped
+func (m *Manager) LoadRollups(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load rollups: %v", err)
+	}
+	var file rollupFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to load rollups from %s: %v", path, err)
+	}
+
+	m.rollupMu.Lock()
+	if m.rollupDays <= 0 {
+		m.rollupMu.Unlock()
+		return nil
+	}
+	for ip, days := range file.Devices {
+		sort.Slice(days, func(i, j int) bool { return days[i].Date < days[j].Date })
+		if len(days) > m.rollupDays {
+			days = days[len(days)-m.rollupDays:]
+		}
+		if len(days) > 0 {
+			m.rollups[ip] = days
+		}
+	}
+	m.rollupMu.Unlock()
+
+	m.PruneRollups(time.Now())
+	return nil
+}
+
+// durationMs converts a duration to fractional milliseconds
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,136 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestRecordPingCycle validates daily aggregation, day rollover and the retention limit
+func TestRecordPingCycle(t *testing.T) {
+	mgr := NewManager(100)
+
+	// Disabled: nothing is recorded
+	day1 := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	mgr.RecordPingCycle("192.168.1.1", 1, 1, time.Millisecond, time.Millisecond, time.Millisecond, day1)
+	if got := mgr.GetRollups("192.168.1.1"); got != nil {
+		t.Fatalf("expected no rollups while disabled, got %+v", got)
+	}
+
+	mgr.EnableRollups(2, time.UTC)
+	mgr.RecordPingCycle("192.168.1.1", 3, 3, 2*time.Millisecond, 4*time.Millisecond, 6*time.Millisecond, day1)
+	mgr.RecordPingCycle("192.168.1.1", 3, 2, 1*time.Millisecond, 2*time.Millisecond, 9*time.Millisecond, day1.Add(time.Hour))
+	mgr.RecordPingCycle("192.168.1.1", 3, 0, 0, 0, 0, day1.Add(2*time.Hour))
+
+	days := mgr.GetRollups("192.168.1.1")
+	if len(days) != 1 {
+		t.Fatalf("expected 1 day, got %d", len(days))
+	}
+	d := days[0]
+	if d.Date != "2024-03-01" || d.Cycles != 3 || d.UpCycles != 2 || d.PacketsSent != 9 || d.PacketsRecv != 5 {
+		t.Errorf("unexpected rollup: %+v", d)
+	}
+	if d.RTTMinMs != 1 || d.RTTMaxMs != 9 || d.AvgRTTMs() != 3 {
+		t.Errorf("unexpected RTT stats: min %v max %v avg %v", d.RTTMinMs, d.RTTMaxMs, d.AvgRTTMs())
+	}
+	if pct := d.AvailabilityPct(); pct < 66.6 || pct > 66.7 {
+		t.Errorf("expected ~66.7%% availability, got %v", pct)
+	}
+
+	// Two more days: the retention of 2 days drops the first one
+	mgr.RecordPingCycle("192.168.1.1", 1, 1, time.Millisecond, time.Millisecond, time.Millisecond, day1.Add(24*time.Hour))
+	mgr.RecordPingCycle("192.168.1.1", 1, 1, time.Millisecond, time.Millisecond, time.Millisecond, day1.Add(48*time.Hour))
+	days = mgr.GetRollups("192.168.1.1")
+	if len(days) != 2 || days[0].Date != "2024-03-02" || days[1].Date != "2024-03-03" {
+		t.Errorf("expected 2024-03-02 and 2024-03-03, got %+v", days)
+	}
+}
+
+// TestRollupTimezone validates that days are bucketed in the configured location
+func TestRollupTimezone(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	mgr := NewManager(100)
+	mgr.EnableRollups(7, loc)
+	mgr.RecordPingCycle("192.168.1.1", 1, 1, time.Millisecond, time.Millisecond, time.Millisecond, time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC))
+	if days := mgr.GetRollups("192.168.1.1"); len(days) != 1 || days[0].Date != "2024-03-02" {
+		t.Errorf("expected the 2024-03-02 local day, got %+v", days)
+	}
+}
+
+// TestPruneRollups validates that old days and devices without recent days are dropped
+func TestPruneRollups(t *testing.T) {
+	mgr := NewManager(100)
+	mgr.EnableRollups(3, time.UTC)
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		mgr.RecordPingCycle("192.168.1.1", 1, 1, time.Millisecond, time.Millisecond, time.Millisecond, start.Add(time.Duration(i)*24*time.Hour))
+	}
+	mgr.RecordPingCycle("192.168.1.2", 1, 0, 0, 0, 0, start)
+
+	// On 2024-03-04 the window is 03-02..03-04
+	if removed := mgr.PruneRollups(start.Add(72 * time.Hour)); removed != 1 {
+		t.Errorf("expected 1 device removed, got %d", removed)
+	}
+	if days := mgr.GetRollups("192.168.1.1"); len(days) != 2 || days[0].Date != "2024-03-02" {
+		t.Errorf("expected 2 days from 2024-03-02, got %+v", days)
+	}
+	if days := mgr.GetRollups("192.168.1.2"); days != nil {
+		t.Errorf("expected device without recent days to be dropped, got %+v", days)
+	}
+}
+
+// TestSumRollups validates combining several days
+func TestSumRollups(t *testing.T) {
+	total := SumRollups([]DailyRollup{
+		{Date: "2024-03-01", Cycles: 10, UpCycles: 10, PacketsSent: 10, PacketsRecv: 10, RTTMinMs: 2, RTTMaxMs: 5, RTTSumMs: 30},
+		{Date: "2024-03-02", Cycles: 10, UpCycles: 0, PacketsSent: 10},
+		{Date: "2024-03-03", Cycles: 10, UpCycles: 5, PacketsSent: 10, PacketsRecv: 5, RTTMinMs: 1, RTTMaxMs: 4, RTTSumMs: 15},
+	})
+	if total.Cycles != 30 || total.UpCycles != 15 || total.AvailabilityPct() != 50 || total.PacketLossPct() != 50 {
+		t.Errorf("unexpected totals: %+v", total)
+	}
+	if total.RTTMinMs != 1 || total.RTTMaxMs != 5 || total.AvgRTTMs() != 3 {
+		t.Errorf("unexpected RTT stats: %+v", total)
+	}
+}
+
+// TestRollupsSaveLoad validates persistence across restarts
+func TestRollupsSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rollups.json")
+	now := time.Now()
+
+	mgr := NewManager(100)
+	// Loading a missing file is not an error
+	mgr.EnableRollups(7, time.UTC)
+	if err := mgr.LoadRollups(path); err != nil {
+		t.Fatalf("expected missing file to be ignored, got %v", err)
+	}
+	mgr.RecordPingCycle("192.168.1.1", 2, 2, time.Millisecond, 2*time.Millisecond, 3*time.Millisecond, now)
+	if err := mgr.SaveRollups(path); err != nil {
+		t.Fatalf("failed to save rollups: %v", err)
+	}
+
+	restored := NewManager(100)
+	restored.EnableRollups(7, time.UTC)
+	if err := restored.LoadRollups(path); err != nil {
+		t.Fatalf("failed to load rollups: %v", err)
+	}
+	days := restored.GetRollups("192.168.1.1")
+	if len(days) != 1 || days[0].PacketsRecv != 2 || days[0].RTTMaxMs != 3 {
+		t.Errorf("unexpected restored rollups: %+v", days)
+	}
+
+	// Recording continues on the restored day
+	restored.RecordPingCycle("192.168.1.1", 2, 2, time.Millisecond, 2*time.Millisecond, 3*time.Millisecond, now)
+	if days := restored.GetRollups("192.168.1.1"); len(days) != 1 || days[0].Cycles != 2 {
+		t.Errorf("expected 2 cycles on the restored day, got %+v", days)
+	}
+
+	if err := os.WriteFile(path, []byte("not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := restored.LoadRollups(path); err == nil {
+		t.Error("expected error for corrupt rollup file")
+	}
+}
@@ -0,0 +1,138 @@
+package state
+
+import (
+	"time"
+)
+
+// RTTSample is one ping cycle of a device's recent history
+type RTTSample struct {
+	Time     time.Time `json:"time"`
+	Sent     int       `json:"sent"`                 // Echo requests sent
+	Recv     int       `json:"recv"`                 // Echo replies received (0 = failed cycle)
+	RTTMinMs float64   `json:"rtt_min_ms,omitempty"` // RTTs are omitted for failed cycles
+	RTTAvgMs float64   `json:"rtt_avg_ms,omitempty"`
+	RTTMaxMs float64   `json:"rtt_max_ms,omitempty"`
+}
+
+// rttSample is the compact in-memory form of an RTTSample (24 bytes instead of 64)
+type rttSample struct {
+	at            int64 // Unix milliseconds
+	sent, recv    uint16
+	min, avg, max float32 // Milliseconds
+}
+
+// rttRing is a fixed-size circular buffer of one device's latest ping cycles
+type rttRing struct {
+	samples []rttSample // next is the slot written by the next cycle
+	next    int
+	count   int
+}
+
+// EnableRTTHistory keeps the last size ping cycles of every device in memory (size <= 0 disables it)
+// Call once at startup, before pinging starts
+func (m *Manager) EnableRTTHistory(size int) {
+	m.rttMu.Lock()
+	defer m.rttMu.Unlock()
+	m.rttHistorySize = size
+	m.rttHistory = make(map[string]*rttRing)
+}
+
+// recordRTTSample adds a ping cycle to the device's history; a no-op when the history is disabled
+func (m *Manager) recordRTTSample(ip string, sent, recv int, minRtt, avgRtt, maxRtt time.Duration, at time.Time) {
+	m.rttMu.Lock()
+	defer m.rttMu.Unlock()
+	if m.rttHistorySize <= 0 {
+		return
+	}
+
+	ring := m.rttHistory[ip]
+	if ring == nil {
+		ring = &rttRing{samples: make([]rttSample, m.rttHistorySize)}
+		m.rttHistory[ip] = ring
+	}
+	sample := rttSample{at: at.UnixMilli(), sent: clampUint16(sent), recv: clampUint16(recv)}
+	if recv > 0 {
+		sample.min = float32(durationMs(minRtt))
+		sample.avg = float32(durationMs(avgRtt))
+		sample.max = float32(durationMs(maxRtt))
+	}
+	ring.samples[ring.next] = sample
+	ring.next = (ring.next + 1) % len(ring.samples)
+	if ring.count < len(ring.samples) {
+		ring.count++
+	}
+}
+
+// GetRTTHistory returns the device's recent ping cycles, oldest first (nil if none)
+func (m *Manager) GetRTTHistory(ip string) []RTTSample {
+	m.rttMu.Lock()
+	defer m.rttMu.Unlock()
+	ring := m.rttHistory[ip]
+	if ring == nil {
+		return nil
+	}
+
+	result := make([]RTTSample, 0, ring.count)
+	start := (ring.next - ring.count + len(ring.samples)) % len(ring.samples)
+	for i := 0; i < ring.count; i++ {
+		s := ring.samples[(start+i)%len(ring.samples)]
+		result = append(result, RTTSample{
+			Time:     time.UnixMilli(s.at),
+			Sent:     int(s.sent),
+			Recv:     int(s.recv),
+			RTTMinMs: float64(s.min),
+			RTTAvgMs: float64(s.avg),
+			RTTMaxMs: float64(s.max),
+		})
+	}
+	return result
+}
+
+// PruneRTTHistory drops the history of devices that are no longer monitored and returns how many were dropped
+func (m *Manager) PruneRTTHistory() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	m.rttMu.Lock()
+	defer m.rttMu.Unlock()
+	removed := 0
+	for ip := range m.rttHistory {
+		if _, exists := m.devices[ip]; !exists {
+			delete(m.rttHistory, ip)
+			removed++
+		}
+	}
+	return removed
+}
+
+// SummarizeRTTHistory combines ping cycles into one rollup (Date is left empty)
+func SummarizeRTTHistory(samples []RTTSample) DailyRollup {
+	var total DailyRollup
+	for _, s := range samples {
+		total.Cycles++
+		total.PacketsSent += s.Sent
+		total.PacketsRecv += s.Recv
+		if s.Recv == 0 {
+			continue
+		}
+		if total.UpCycles == 0 || s.RTTMinMs < total.RTTMinMs {
+			total.RTTMinMs = s.RTTMinMs
+		}
+		if s.RTTMaxMs > total.RTTMaxMs {
+			total.RTTMaxMs = s.RTTMaxMs
+		}
+		total.RTTSumMs += s.RTTAvgMs
+		total.UpCycles++
+	}
+	return total
+}
+
+// clampUint16 stores a packet count in 16 bits
+func clampUint16(n int) uint16 {
+	if n < 0 {
+		return 0
+	}
+	if n > 0xFFFF {
+		return 0xFFFF
+	}
+	return uint16(n)
+}
@@ -0,0 +1,39 @@
+package state
+
+import "time"
+
+// SNMPValue is one value from an SNMP poll
+type SNMPValue struct {
+	Group    string      `json:"group"` // "system", "device_fields", "ifTable" or a custom OID group name
+	Name     string      `json:"name"`
+	Value    interface{} `json:"value"`
+	PolledAt time.Time   `json:"polled_at"`
+}
+
+// SNMPResult is the latest full SNMP result set for a device, served by the device API without re-polling
+type SNMPResult struct {
+	PolledAt time.Time   `json:"polled_at"` // Start of the poll that produced the values
+	Values   []SNMPValue `json:"values"`
+}
+
+// SetSNMPResult caches the latest SNMP result for an existing device
+// The result must not be modified after it is stored
+func (m *Manager) SetSNMPResult(ip string, result *SNMPResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if dev, exists := m.devices[ip]; exists {
+		dev.SNMPResult = result
+	}
+}
+
+// GetSNMPResult returns the cached SNMP result for a device
+// found is false for unknown devices; result is nil for devices that have not been polled yet
+func (m *Manager) GetSNMPResult(ip string) (result *SNMPResult, found bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	dev, exists := m.devices[ip]
+	if !exists {
+		return nil, false
+	}
+	return dev.SNMPResult, true
+}
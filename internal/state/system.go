@@ -0,0 +1,81 @@
+package state
+
+import "time"
+
+// sysUpTimeWrap is where the 32-bit sysUpTime counter (hundredths of a second) wraps, about 497 days
+const sysUpTimeWrap = time.Duration(1<<32) * 10 * time.Millisecond
+
+// rebootTolerance absorbs poll latency and agent clock drift when comparing sysUpTime between polls
+const rebootTolerance = time.Minute
+
+// SystemInfo holds the MIB-II system group values polled besides sysName and sysDescr
+// Zero values mean the agent did not answer that object
+type SystemInfo struct {
+	ObjectID string        // sysObjectID: vendor and model OID, e.g. "1.3.6.1.4.1.9.1.1208"
+	UpTime   time.Duration // sysUpTime: time since the SNMP agent (re)started
+	Location string        // sysLocation
+	Contact  string        // sysContact
+}
+
+// Reboot is a device restart detected from sysUpTime between two polls
+type Reboot struct {
+	At             time.Time     // Estimated restart time (poll time minus the new sysUpTime)
+	UpTime         time.Duration // sysUpTime after the restart
+	PreviousUpTime time.Duration // sysUpTime at the previous poll
+}
+
+// SetRebootHandler registers a callback for every detected reboot
+// Like the state change handler it runs after the manager lock is released and must not block
+func (m *Manager) SetRebootHandler(handler func(ip, hostname string, reboot Reboot)) {
+	m.eventsMu.Lock()
+	defer m.eventsMu.Unlock()
+	m.rebootHandler = handler
+}
+
+// UpdateDeviceSystem stores the system group values of a poll taken at polledAt
+// Returns true and calls the reboot handler when sysUpTime is lower than the previous poll's value plus
+// the time between the polls (less rebootTolerance), i.e. the device or its SNMP agent restarted
+// Unknown devices are ignored
+func (m *Manager) UpdateDeviceSystem(ip string, info SystemInfo, polledAt time.Time) bool {
+	m.mu.Lock()
+	dev, exists := m.devices[ip]
+	if !exists {
+		m.mu.Unlock()
+		return false
+	}
+	reboot, rebooted := detectReboot(dev.System.UpTime, dev.SystemPolledAt, info.UpTime, polledAt)
+	dev.System = info
+	dev.SystemPolledAt = polledAt
+	m.classifyLocked(dev)
+	if rebooted {
+		dev.LastReboot = reboot.At
+	}
+	hostname := dev.Hostname
+	m.mu.Unlock()
+
+	if rebooted {
+		m.eventsMu.Lock()
+		handler := m.rebootHandler
+		m.eventsMu.Unlock()
+		if handler != nil {
+			handler(ip, hostname, reboot)
+		}
+	}
+	return rebooted
+}
+
+// detectReboot compares two sysUpTime readings; readings without an uptime never count as a reboot
+// A counter that may have wrapped between the polls is not reported
+func detectReboot(previous time.Duration, previousAt time.Time, current time.Duration, at time.Time) (Reboot, bool) {
+	if previous <= 0 || current <= 0 || previousAt.IsZero() {
+		return Reboot{}, false
+	}
+	expected := previous + at.Sub(previousAt)
+	if expected >= sysUpTimeWrap-rebootTolerance {
+		return Reboot{}, false
+	}
+	if current+rebootTolerance >= expected {
+		return Reboot{}, false
+	}
+	return Reboot{At: at.Add(-current), UpTime: current, PreviousUpTime: previous}, true
+}
@@ -0,0 +1,108 @@
+package state
+
+import (
+	"container/heap"
+	"time"
+)
+
+// Reasons a device was removed from state
+const (
+	RemovedStale   = "stale"   // Not seen within the prune age
+	RemovedOverlap = "overlap" // Its network is covered by another scanner
+	RemovedDNS     = "dns"     // Its networks host name no longer resolves to it
+)
+
+// Tombstone records a removed device for the tombstone TTL, so a device that reappears soon
+// gets its prior metadata back instead of being handled as new
+type Tombstone struct {
+	IP       string    `json:"ip"`
+	PrunedAt time.Time `json:"pruned_at"`
+	Reason   string    `json:"reason"`
+	device   Device    // Device as it was when removed
+}
+
+// EnableTombstones keeps a tombstone of every pruned or removed device for ttl (0 = disabled)
+func (m *Manager) EnableTombstones(ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tombstoneTTL = ttl
+	if ttl <= 0 {
+		m.tombstones = nil
+	} else if m.tombstones == nil {
+		m.tombstones = make(map[string]*Tombstone)
+	}
+}
+
+// Tombstones returns the tombstones that have not expired yet
+func (m *Manager) Tombstones() []Tombstone {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	cutoff := time.Now().Add(-m.tombstoneTTL)
+	result := make([]Tombstone, 0, len(m.tombstones))
+	for _, tomb := range m.tombstones {
+		if tomb.PrunedAt.After(cutoff) {
+			result = append(result, *tomb)
+		}
+	}
+	return result
+}
+
+// PruneTombstones drops tombstones older than the tombstone TTL; returns the number dropped
+func (m *Manager) PruneTombstones(now time.Time) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.pruneTombstonesLocked(now)
+}
+
+// pruneTombstonesLocked drops expired tombstones; caller must hold m.mu for writing
+func (m *Manager) pruneTombstonesLocked(now time.Time) int {
+	cutoff := now.Add(-m.tombstoneTTL)
+	removed := 0
+	for ip, tomb := range m.tombstones {
+		if !tomb.PrunedAt.After(cutoff) {
+			delete(m.tombstones, ip)
+			removed++
+		}
+	}
+	return removed
+}
+
+// buryLocked keeps a tombstone of a removed device; caller must hold m.mu for writing
+// Tombstones are capped at maxDevices: when full, expired ones are dropped and, if none expired,
+// the device is removed without a tombstone
+func (m *Manager) buryLocked(dev *Device, reason string, now time.Time) {
+	if m.tombstones == nil {
+		return
+	}
+	if len(m.tombstones) >= m.maxDevices && m.pruneTombstonesLocked(now) == 0 {
+		return
+	}
+	m.tombstones[dev.IP] = &Tombstone{IP: dev.IP, PrunedAt: now, Reason: reason, device: *dev}
+}
+
+// restoreLocked re-adds a device from its tombstone if it has one that has not expired
+// The prior metadata (hostname, sysDescr, SNMP result, reachability, MAC) is kept; LastSeen is
+// refreshed and circuit breaker state starts over. Caller must hold m.mu for writing and has
+// made room for the device; returns false if there was nothing to restore
+func (m *Manager) restoreLocked(ip string, now time.Time) bool {
+	tomb, ok := m.tombstones[ip]
+	if !ok {
+		return false
+	}
+	delete(m.tombstones, ip)
+	if !tomb.PrunedAt.After(now.Add(-m.tombstoneTTL)) {
+		return false
+	}
+
+	device := tomb.device
+	device.LastSeen = now
+	device.ConsecutiveFails = 0
+	device.PingWindow = 0
+	device.ProbeAt = time.Time{} // BackoffLevel and LastTrip are kept, so a repeat offender keeps escalating
+	device.SuspendedUntil = time.Time{}
+	device.SNMPConsecutiveFails = 0
+	device.SNMPSuspendedUntil = time.Time{}
+	m.devices[ip] = &device
+	heap.Push(&m.evictionHeap, &device)
+	return true
+}
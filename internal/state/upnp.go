@@ -0,0 +1,50 @@
+package state
+
+import "time"
+
+// UPnPInfo is the UPnP device description of a device that answered SSDP discovery
+type UPnPInfo struct {
+	FriendlyName string // friendlyName, e.g. "Living Room TV"
+	Manufacturer string // manufacturer, e.g. "Synology"
+	ModelName    string // modelName, e.g. "DS920+"
+	ModelNumber  string // modelNumber
+	DeviceType   string // deviceType URN, e.g. "urn:schemas-upnp-org:device:MediaServer:1"
+	Server       string // SERVER header of the SSDP response (OS and UPnP stack)
+}
+
+// IsZero reports whether no UPnP metadata is known
+func (i UPnPInfo) IsZero() bool {
+	return i == UPnPInfo{}
+}
+
+// Fields returns the known values as device_info fields (upnp_friendly_name, upnp_manufacturer, ...)
+func (i UPnPInfo) Fields() map[string]string {
+	fields := make(map[string]string, 6)
+	for name, value := range map[string]string{
+		"upnp_friendly_name": i.FriendlyName,
+		"upnp_manufacturer":  i.Manufacturer,
+		"upnp_model_name":    i.ModelName,
+		"upnp_model_number":  i.ModelNumber,
+		"upnp_device_type":   i.DeviceType,
+		"upnp_server":        i.Server,
+	} {
+		if value != "" {
+			fields[name] = value
+		}
+	}
+	return fields
+}
+
+// UpdateDeviceUPnP stores the UPnP description found by SSDP discovery at seenAt
+// Returns false for unknown devices
+func (m *Manager) UpdateDeviceUPnP(ip string, info UPnPInfo, seenAt time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	dev, exists := m.devices[ip]
+	if !exists {
+		return false
+	}
+	dev.UPnP = info
+	dev.UPnPSeenAt = seenAt
+	return true
+}
@@ -0,0 +1,30 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+// TestUpdateDeviceUPnP verifies the UPnP description is stored, kept when the device is re-added and exported as fields
+func TestUpdateDeviceUPnP(t *testing.T) {
+	m := NewManager(100)
+	info := UPnPInfo{Manufacturer: "Synology", ModelName: "DS920+"}
+	if m.UpdateDeviceUPnP("192.168.1.20", info, time.Now()) {
+		t.Error("expected an unknown device to be ignored")
+	}
+
+	m.Add(Device{IP: "192.168.1.20"})
+	if !m.UpdateDeviceUPnP("192.168.1.20", info, time.Now()) {
+		t.Fatal("expected the description to be stored")
+	}
+	m.Add(Device{IP: "192.168.1.20", Hostname: "nas"})
+	dev, _ := m.Get("192.168.1.20")
+	if dev.UPnP != info || dev.UPnPSeenAt.IsZero() {
+		t.Errorf("expected the description to survive a re-add, got %+v", dev.UPnP)
+	}
+
+	fields := dev.UPnP.Fields()
+	if len(fields) != 2 || fields["upnp_manufacturer"] != "Synology" || fields["upnp_model_name"] != "DS920+" {
+		t.Errorf("unexpected fields %v", fields)
+	}
+}
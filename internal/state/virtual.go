@@ -0,0 +1,150 @@
+package state
+
+import (
+	"sort"
+	"time"
+)
+
+// First-hop redundancy protocols whose virtual addresses are tracked
+const (
+	ProtocolVRRP = "vrrp"
+	ProtocolHSRP = "hsrp"
+)
+
+// VirtualAddress is one virtual router address as reported by a single physical member
+type VirtualAddress struct {
+	IP       string // Virtual IP address
+	Protocol string // vrrp or hsrp
+	Group    int    // VRRP VRID or HSRP group number
+	Role     string // Member's role, e.g. master/backup (VRRP) or active/standby (HSRP)
+	Active   bool   // Member currently forwards for the virtual IP (VRRP master, HSRP active)
+}
+
+// VirtualIP is a virtual router address linked to the physical members that serve it
+type VirtualIP struct {
+	IP           string            `json:"ip"`
+	Protocol     string            `json:"protocol"`
+	Group        int               `json:"group"`
+	Members      map[string]string `json:"members"`       // Member IP -> role
+	ActiveMember string            `json:"active_member"` // Member IP currently forwarding ("" if unknown)
+	Updated      time.Time         `json:"updated"`
+}
+
+// Failover is a change of the active member of a virtual IP
+type Failover struct {
+	VirtualIP string
+	Protocol  string
+	Group     int
+	From      string // Previous active member IP
+	To        string // New active member IP
+}
+
+// UpdateVirtualAddresses replaces the virtual addresses reported by one physical member
+// Returns the failovers detected, i.e. virtual IPs whose active member changed
+func (m *Manager) UpdateVirtualAddresses(member string, addrs []VirtualAddress) []Failover {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.virtualIPs == nil {
+		m.virtualIPs = make(map[string]*VirtualIP)
+	}
+
+	now := time.Now()
+	reported := make(map[string]bool, len(addrs))
+	var failovers []Failover
+	for _, addr := range addrs {
+		reported[addr.IP] = true
+		vip, exists := m.virtualIPs[addr.IP]
+		if !exists {
+			vip = &VirtualIP{IP: addr.IP, Members: make(map[string]string)}
+			m.virtualIPs[addr.IP] = vip
+		}
+		vip.Protocol = addr.Protocol
+		vip.Group = addr.Group
+		vip.Members[member] = addr.Role
+		vip.Updated = now
+
+		if addr.Active && vip.ActiveMember != member {
+			if vip.ActiveMember != "" {
+				failovers = append(failovers, Failover{VirtualIP: vip.IP, Protocol: vip.Protocol, Group: vip.Group, From: vip.ActiveMember, To: member})
+			}
+			vip.ActiveMember = member
+		} else if !addr.Active && vip.ActiveMember == member {
+			vip.ActiveMember = "" // Lost mastership; the new active member reports on its next poll
+		}
+	}
+
+	// Drop memberships the member no longer reports, and virtual IPs left without members
+	for ip, vip := range m.virtualIPs {
+		if reported[ip] {
+			continue
+		}
+		if _, isMember := vip.Members[member]; !isMember {
+			continue
+		}
+		delete(vip.Members, member)
+		if vip.ActiveMember == member {
+			vip.ActiveMember = ""
+		}
+		if len(vip.Members) == 0 {
+			delete(m.virtualIPs, ip)
+		}
+	}
+	return failovers
+}
+
+// GetVirtualIP returns a copy of a tracked virtual IP
+func (m *Manager) GetVirtualIP(ip string) (VirtualIP, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	vip, exists := m.virtualIPs[ip]
+	if !exists {
+		return VirtualIP{}, false
+	}
+	return copyVirtualIP(vip), true
+}
+
+// GetVirtualIPs returns copies of all tracked virtual IPs, sorted by IP
+func (m *Manager) GetVirtualIPs() []VirtualIP {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	vips := make([]VirtualIP, 0, len(m.virtualIPs))
+	for _, vip := range m.virtualIPs {
+		vips = append(vips, copyVirtualIP(vip))
+	}
+	sort.Slice(vips, func(i, j int) bool { return vips[i].IP < vips[j].IP })
+	return vips
+}
+
+// VirtualProtocol returns the redundancy protocol of a virtual IP, or "" for physical addresses
+func (m *Manager) VirtualProtocol(ip string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if vip, exists := m.virtualIPs[ip]; exists {
+		return vip.Protocol
+	}
+	return ""
+}
+
+// anyMemberUp reports whether a physical member of the virtual IP is currently reachable
+// Must be called with m.mu lock held
+func (m *Manager) anyMemberUp(ip string) bool {
+	vip, exists := m.virtualIPs[ip]
+	if !exists {
+		return false
+	}
+	for member := range vip.Members {
+		if dev, ok := m.devices[member]; ok && dev.Reachability == ReachabilityUp {
+			return true
+		}
+	}
+	return false
+}
+
+func copyVirtualIP(vip *VirtualIP) VirtualIP {
+	c := *vip
+	c.Members = make(map[string]string, len(vip.Members))
+	for member, role := range vip.Members {
+		c.Members[member] = role
+	}
+	return c
+}
@@ -0,0 +1,58 @@
+package validate
+
+import (
+	"fmt"
+	"net"
+)
+
+// AddressPolicy controls whether special-purpose address ranges may be scanned, monitored and written
+// The zero value is the strict default: loopback and link-local addresses are rejected
+// Multicast and unspecified addresses are always rejected regardless of policy
+type AddressPolicy struct {
+	AllowLoopback  bool // Permit 127.0.0.0/8 and ::1 (self-monitoring, lab setups)
+	AllowLinkLocal bool // Permit 169.254.0.0/16 and fe80::/10
+}
+
+// IPAddress validates IP address format and security constraints using the strict default policy
+func IPAddress(ipStr string) error {
+	return AddressPolicy{}.ValidateIP(ipStr)
+}
+
+// ValidateIP validates IP address format and security constraints for a single target
+func (p AddressPolicy) ValidateIP(ipStr string) error {
+	if ipStr == "" {
+		return fmt.Errorf("IP address cannot be empty")
+	}
+
+	ip := net.ParseIP(ipStr)
+	if ip == nil {
+		return fmt.Errorf("invalid IP address format: %s", ipStr)
+	}
+
+	return p.CheckSpecialRanges(ip, ipStr)
+}
+
+// CheckSpecialRanges rejects address ranges that are never (or, by policy, not) valid monitoring targets
+func (p AddressPolicy) CheckSpecialRanges(ip net.IP, label string) error {
+	if ip.IsLoopback() && !p.AllowLoopback {
+		return fmt.Errorf("loopback addresses not allowed: %s (set allow_loopback to permit)", label)
+	}
+	if ip.IsMulticast() {
+		return fmt.Errorf("multicast addresses not allowed: %s", label)
+	}
+	if ip.IsLinkLocalUnicast() && !p.AllowLinkLocal {
+		return fmt.Errorf("link-local addresses not allowed: %s (set allow_link_local to permit)", label)
+	}
+	if ip.IsUnspecified() {
+		return fmt.Errorf("unspecified addresses not allowed: %s", label)
+	}
+	return nil
+}
+
+// AllowsUnicast reports whether ip is a usable unicast target under the policy
+func (p AddressPolicy) AllowsUnicast(ip net.IP) bool {
+	if ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return true
+	}
+	return (ip.IsLoopback() && p.AllowLoopback) || (ip.IsLinkLocalUnicast() && p.AllowLinkLocal)
+}
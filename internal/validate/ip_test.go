@@ -0,0 +1,34 @@
+package validate
+
+import "testing"
+
+// TestAddressPolicyValidateIP validates loopback and link-local targets are gated by the policy
+func TestAddressPolicyValidateIP(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  AddressPolicy
+		ip      string
+		wantErr bool
+	}{
+		{"strict unicast", AddressPolicy{}, "192.168.1.1", false},
+		{"strict loopback", AddressPolicy{}, "127.0.0.1", true},
+		{"strict link-local", AddressPolicy{}, "169.254.1.1", true},
+		{"allow loopback", AddressPolicy{AllowLoopback: true}, "127.0.0.1", false},
+		{"allow loopback v6", AddressPolicy{AllowLoopback: true}, "::1", false},
+		{"allow loopback keeps link-local strict", AddressPolicy{AllowLoopback: true}, "169.254.1.1", true},
+		{"allow link-local", AddressPolicy{AllowLinkLocal: true}, "169.254.1.1", false},
+		{"multicast always rejected", AddressPolicy{AllowLoopback: true, AllowLinkLocal: true}, "224.0.0.1", true},
+		{"unspecified always rejected", AddressPolicy{AllowLoopback: true, AllowLinkLocal: true}, "0.0.0.0", true},
+		{"invalid", AddressPolicy{AllowLoopback: true}, "not-an-ip", true},
+		{"empty", AddressPolicy{}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.ValidateIP(tt.ip)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateIP(%q) error = %v, wantErr %v", tt.ip, err, tt.wantErr)
+			}
+		})
+	}
+}
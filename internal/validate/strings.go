@@ -0,0 +1,111 @@
+package validate
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Default length limits in bytes
+const (
+	DefaultSNMPMaxLength   = 1024 // SNMP string values (sysName, sysDescr, ifDescr, ...)
+	DefaultInfluxMaxLength = 500  // String fields and tag values written to InfluxDB
+)
+
+// Length limits accepted by the max_string_length settings
+const (
+	MinMaxLength = 64
+	MaxMaxLength = 65535
+)
+
+// TruncationMarker ends every truncated string and counts toward the limit
+// A single character that SNMP agents practically never send, so IsTruncated has no false positives
+const TruncationMarker = "\u2026" // …
+
+// Truncate cuts s to at most max bytes without splitting a multi-byte character, ending it with TruncationMarker
+// Returns s unchanged and false when it already fits (or max is not positive)
+func Truncate(s string, max int) (string, bool) {
+	if max <= 0 || len(s) <= max {
+		return s, false
+	}
+	cut := max - len(TruncationMarker)
+	if cut < 0 {
+		cut = 0
+	}
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + TruncationMarker, true
+}
+
+// IsTruncated reports whether s was cut by Truncate
+func IsTruncated(s string) bool {
+	return strings.HasSuffix(s, TruncationMarker)
+}
+
+// SNMPString validates and sanitizes an SNMP string value (string or OctetString bytes) named oidName
+// Printable Unicode (umlauts, CJK sysNames) is kept; values with null bytes are rejected, invalid UTF-8
+// sequences and control, format (bidi overrides, zero-width) and other non-printable characters are removed,
+// line breaks and tabs become spaces, and the result is truncated to maxLength bytes (snmp.max_string_length,
+// 0 = DefaultSNMPMaxLength)
+func SNMPString(value interface{}, oidName string, maxLength int) (string, error) {
+	var str string
+	switch v := value.(type) {
+	case string:
+		str = v
+	case []byte:
+		// Note: []byte and []uint8 are the same type in Go
+		str = string(v)
+	default:
+		return "", fmt.Errorf("invalid type for %s: expected string or []byte, got %T", oidName, value)
+	}
+
+	// Security: reject strings containing null bytes
+	if i := strings.IndexByte(str, 0); i >= 0 {
+		return "", fmt.Errorf("%s contains null byte at position %d", oidName, i)
+	}
+
+	// Drop invalid UTF-8 sequences (e.g. binary OctetStrings or Latin-1 bytes)
+	str = strings.ToValidUTF8(str, "")
+	str = strings.TrimSpace(strings.Map(snmpRune, str))
+	if len(str) == 0 {
+		return "", fmt.Errorf("%s is empty after sanitization", oidName)
+	}
+
+	if maxLength <= 0 {
+		maxLength = DefaultSNMPMaxLength
+	}
+	str, _ = Truncate(str, maxLength)
+	return str, nil
+}
+
+// snmpRune maps line breaks and tabs to spaces and drops control, format and non-graphic characters
+func snmpRune(r rune) rune {
+	switch {
+	case r == '\n' || r == '\r' || r == '\t' || r == '\u2028' || r == '\u2029':
+		return ' '
+	case r == utf8.RuneError, unicode.IsControl(r), unicode.Is(unicode.Cf, r), !unicode.IsGraphic(r):
+		return -1
+	}
+	return r
+}
+
+// InfluxString sanitizes a string for an InfluxDB field or tag value and truncates it to max bytes
+// Control characters other than tab and newline are removed; the result reports whether the value is
+// truncated, either here or earlier by SNMPString
+func InfluxString(s string, max int) (string, bool) {
+	if s == "" {
+		return "", false
+	}
+	s = strings.Map(func(r rune) rune {
+		if r < 32 && r != '\t' && r != '\n' {
+			return -1
+		}
+		return r
+	}, strings.ToValidUTF8(s, ""))
+	s = strings.TrimSpace(s)
+
+	s, cut := Truncate(s, max)
+	return s, cut || IsTruncated(s)
+}
@@ -0,0 +1,101 @@
+package validate
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// TestSNMPString verifies international names survive while control and bidi characters are stripped
+func TestSNMPString(t *testing.T) {
+	tests := []struct {
+		name  string
+		input interface{}
+		want  string
+	}{
+		{"ASCII", "core-sw-01", "core-sw-01"},
+		{"Umlauts", []byte("Zürich Büro"), "Zürich Büro"},
+		{"CJK", "北京核心交换机", "北京核心交换机"},
+		{"Line breaks become spaces", "Cisco IOS\r\nVersion 15.2", "Cisco IOS  Version 15.2"},
+		{"Tabs and trailing newline", "rack\t12\n", "rack 12"},
+		{"Control characters stripped", "sw\x1b[31m-01\x7f", "sw[31m-01"},
+		{"Bidi and zero-width stripped", "ro\u200buter\u202e01", "router01"},
+		{"Invalid UTF-8 dropped", []byte{'a', 0xff, 0xfe, 'b'}, "ab"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SNMPString(tt.input, "sysName", 0)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("SNMPString(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	for _, bad := range []interface{}{"a\x00b", "\u202e\r\n", []byte{0xff}, 7} {
+		if _, err := SNMPString(bad, "sysName", 0); err == nil {
+			t.Errorf("SNMPString(%q) should fail", bad)
+		}
+	}
+}
+
+// TestSNMPStringMaxLength verifies the configurable limit and that truncation keeps UTF-8 valid
+func TestSNMPStringMaxLength(t *testing.T) {
+	got, err := SNMPString(strings.Repeat("日", 500), "sysDescr", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) > DefaultSNMPMaxLength || !utf8.ValidString(got) || !IsTruncated(got) {
+		t.Errorf("expected a valid truncated value of at most %d bytes, got %d bytes", DefaultSNMPMaxLength, len(got))
+	}
+
+	got, _ = SNMPString(strings.Repeat("a", 100), "sysDescr", 64)
+	if len(got) != 64 || !IsTruncated(got) {
+		t.Errorf("expected 64 bytes ending with the marker, got %q", got)
+	}
+	if got, _ := SNMPString("short", "sysName", 64); got != "short" || IsTruncated(got) {
+		t.Errorf("expected short values unchanged, got %q", got)
+	}
+}
+
+// TestTruncate verifies cuts happen on rune boundaries and include the marker in the limit
+func TestTruncate(t *testing.T) {
+	if got, cut := Truncate("abc", 3); got != "abc" || cut {
+		t.Errorf("expected a fitting value unchanged, got %q (%v)", got, cut)
+	}
+	if got, cut := Truncate("abcdef", 0); got != "abcdef" || cut {
+		t.Errorf("expected no limit for 0, got %q (%v)", got, cut)
+	}
+	got, cut := Truncate("ab"+strings.Repeat("ü", 10), 8)
+	if !cut || got != "abü"+TruncationMarker || len(got) > 8 {
+		t.Errorf("unexpected truncation %q (%d bytes)", got, len(got))
+	}
+}
+
+// TestInfluxString verifies control characters are removed and truncation is reported
+func TestInfluxString(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		max       int
+		want      string
+		truncated bool
+	}{
+		{"Normal string", "MyDevice", 500, "MyDevice", false},
+		{"With control chars", "Device\x00Name", 500, "DeviceName", false},
+		{"Newlines and tabs kept", "Col1\tCol2\nLine2", 500, "Col1\tCol2\nLine2", false},
+		{"Unicode", "Büro 東京", 500, "Büro 東京", false},
+		{"Cut here", strings.Repeat("x", 80), 64, strings.Repeat("x", 61) + TruncationMarker, true},
+		{"Cut by SNMPString", "Cisco IOS" + TruncationMarker, 500, "Cisco IOS" + TruncationMarker, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, truncated := InfluxString(tt.input, tt.max)
+			if got != tt.want || truncated != tt.truncated {
+				t.Errorf("InfluxString(%q) = %q, %v; want %q, %v", tt.input, got, truncated, tt.want, tt.truncated)
+			}
+		})
+	}
+}